@@ -3,35 +3,119 @@ package handlers
 import (
 	"net/http"
 	"os"
+	"time"
 
+	"github.com/aleury/service/app/services/sales-api/handlers/v1/auditgrp"
+	"github.com/aleury/service/app/services/sales-api/handlers/v1/categorygrp"
+	"github.com/aleury/service/app/services/sales-api/handlers/v1/departmentgrp"
+	"github.com/aleury/service/app/services/sales-api/handlers/v1/exchangegrp"
+	"github.com/aleury/service/app/services/sales-api/handlers/v1/gdprgrp"
+	"github.com/aleury/service/app/services/sales-api/handlers/v1/oidcgrp"
+	"github.com/aleury/service/app/services/sales-api/handlers/v1/ordergrp"
+	"github.com/aleury/service/app/services/sales-api/handlers/v1/productgrp"
+	"github.com/aleury/service/app/services/sales-api/handlers/v1/promotiongrp"
+	"github.com/aleury/service/app/services/sales-api/handlers/v1/reservationgrp"
+	"github.com/aleury/service/app/services/sales-api/handlers/v1/scimgrp"
+	"github.com/aleury/service/app/services/sales-api/handlers/v1/sessiongrp"
+	"github.com/aleury/service/app/services/sales-api/handlers/v1/stockalertgrp"
+	"github.com/aleury/service/app/services/sales-api/handlers/v1/taxgrp"
 	"github.com/aleury/service/app/services/sales-api/handlers/v1/testgrp"
 	"github.com/aleury/service/app/services/sales-api/handlers/v1/usergrp"
+	"github.com/aleury/service/business/core/apitoken"
+	"github.com/aleury/service/business/core/apitoken/stores/apitokendb"
+	"github.com/aleury/service/business/core/audit"
+	"github.com/aleury/service/business/core/audit/stores/auditdb"
+	"github.com/aleury/service/business/core/category"
+	"github.com/aleury/service/business/core/category/stores/categorydb"
+	"github.com/aleury/service/business/core/department"
+	"github.com/aleury/service/business/core/department/stores/departmentdb"
+	"github.com/aleury/service/business/core/exchange"
+	"github.com/aleury/service/business/core/exchange/stores/exchangedb"
+	"github.com/aleury/service/business/core/gdpr"
+	"github.com/aleury/service/business/core/identity"
+	"github.com/aleury/service/business/core/identity/stores/identitydb"
+	"github.com/aleury/service/business/core/product"
+	"github.com/aleury/service/business/core/product/stores/productdb"
+	"github.com/aleury/service/business/core/promotion"
+	"github.com/aleury/service/business/core/promotion/stores/promotiondb"
+	"github.com/aleury/service/business/core/reservation"
+	"github.com/aleury/service/business/core/reservation/stores/reservationdb"
+	"github.com/aleury/service/business/core/salesorder"
+	"github.com/aleury/service/business/core/salesorder/stores/salesorderdb"
+	"github.com/aleury/service/business/core/scimtoken"
+	"github.com/aleury/service/business/core/scimtoken/stores/scimtokendb"
+	"github.com/aleury/service/business/core/session"
+	"github.com/aleury/service/business/core/session/stores/sessiondb"
+	"github.com/aleury/service/business/core/stockalert"
+	"github.com/aleury/service/business/core/stockalert/stores/stockalertdb"
+	"github.com/aleury/service/business/core/tax"
+	"github.com/aleury/service/business/core/tax/stores/taxdb"
 	"github.com/aleury/service/business/core/user"
 	"github.com/aleury/service/business/core/user/stores/userdb"
+	"github.com/aleury/service/business/core/usersummary"
+	"github.com/aleury/service/business/core/usersummary/stores/usersummarydb"
 	"github.com/aleury/service/business/web/auth"
 	"github.com/aleury/service/business/web/v1/mid"
+	"github.com/aleury/service/foundation/oidc"
 	"github.com/aleury/service/foundation/web"
+	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 	"go.uber.org/zap"
 )
 
+// OIDCConfig contains what is needed to sign users in through an OpenID
+// Connect provider. The routes are not registered without a provider.
+type OIDCConfig struct {
+	Provider  *oidc.Provider
+	TenantID  uuid.UUID
+	ActiveKID string
+	Issuer    string
+	TokenTTL  time.Duration
+}
+
+// SessionConfig controls the cookie based sessions offered to browsers. When
+// disabled, session cookies are ignored and only JWTs are accepted.
+type SessionConfig struct {
+	Enabled bool
+	TTL     time.Duration
+}
+
+// ReservationConfig controls how long stock is held for carts. The TTL is
+// used when a reservation doesn't ask for one and can't exceed MaxTTL.
+type ReservationConfig struct {
+	TTL    time.Duration
+	MaxTTL time.Duration
+}
+
 // APIMuxConfig contains all the mandatory systems required by handlers.
 type APIMuxConfig struct {
-	Shutdown chan os.Signal
-	Log      *zap.SugaredLogger
-	Auth     *auth.Auth
-	DB       *sqlx.DB
+	Shutdown    chan os.Signal
+	Log         *zap.SugaredLogger
+	Auth        *auth.Auth
+	DB          *sqlx.DB
+	OIDC        OIDCConfig
+	Session     SessionConfig
+	Reservation ReservationConfig
+
+	// RequireIfMatch makes clients send the ETag of users and products they
+	// update. When false, If-Match is only checked if it is sent.
+	RequireIfMatch bool
 }
 
 // APIMux construct a http.Handler with all application routes defined.
 func APIMux(cfg APIMuxConfig) *web.App {
-	app := web.NewApp(
-		cfg.Shutdown,
+	mw := []web.Middleware{
 		mid.Logger(cfg.Log),
 		mid.Errors(cfg.Log),
 		mid.Metrics(),
 		mid.Panics(),
-	)
+	}
+
+	if cfg.Session.Enabled {
+		mw = append(mw, mid.CSRF())
+	}
+
+	app := web.NewApp(cfg.Shutdown, mw...)
 
 	app.Handle(http.MethodGet, "/test", testgrp.Test)
 	app.Handle(
@@ -42,10 +126,211 @@ func APIMux(cfg APIMuxConfig) *web.App {
 
 	// -------------------------------------------------------------------------
 
-	usrCore := user.NewCore(userdb.NewStore(cfg.Log, cfg.DB))
-	ugh := usergrp.New(usrCore)
+	auditCore := audit.NewCore(auditdb.NewStore(cfg.Log, cfg.DB))
+	usrCore := user.NewCore(userdb.NewStore(cfg.Log, cfg.DB), auditCore)
+	sesCore := session.NewCore(sessiondb.NewStore(cfg.Log, cfg.DB), usrCore)
+	idtCore := identity.NewCore(identitydb.NewStore(cfg.Log, cfg.DB), usrCore)
+	apiTokCore := apitoken.NewCore(apitokendb.NewStore(cfg.Log, cfg.DB))
+	exchCore := exchange.NewCore(exchangedb.NewStore(cfg.Log, cfg.DB))
+
+	authen := mid.Authenticate(cfg.Auth)
+	if cfg.Session.Enabled {
+		authen = mid.AuthenticateSession(cfg.Auth, sesCore)
+	}
+
+	ifMatch := mid.IfMatch(cfg.RequireIfMatch)
+
+	usmCore := usersummary.NewCore(usersummarydb.NewStore(cfg.Log, cfg.DB), exchCore)
+	ugh := usergrp.New(usrCore, usmCore)
+
+	app.Handle(http.MethodGet, "/users", ugh.Query, authen, mid.Authorize(cfg.Auth, auth.RuleAny))
+	app.Handle(http.MethodGet, "/users/summary", ugh.QuerySummary, authen, mid.Authorize(cfg.Auth, auth.RuleAny))
+	app.Handle(http.MethodGet, "/users/:user_id", ugh.QueryByID, authen, mid.AuthorizeUser(cfg.Auth, auth.RuleAdminOrDepartmentAdminOrSubject, usrCore))
+	app.Handle(http.MethodGet, "/users/:user_id/history", ugh.QueryHistory, authen, mid.AuthorizeUser(cfg.Auth, auth.RuleAdminOrDepartmentAdminOrSubject, usrCore))
+	app.Handle(http.MethodPost, "/users", ugh.Create, authen, mid.Authorize(cfg.Auth, auth.RuleAdminOrDepartmentAdmin))
+	app.Handle(http.MethodPut, "/users/:user_id", ugh.Update, authen, mid.AuthorizeUser(cfg.Auth, auth.RuleAdminOrDepartmentAdminOrSubject, usrCore), ifMatch)
+	app.Handle(http.MethodDelete, "/users/:user_id", ugh.Delete, authen, mid.AuthorizeUser(cfg.Auth, auth.RuleAdminOrDepartmentAdminOrSubject, usrCore))
+	app.Handle(http.MethodPost, "/users/:user_id/restore", ugh.Restore, authen, mid.Authorize(cfg.Auth, auth.RuleAdminOnly))
+
+	// -------------------------------------------------------------------------
+
+	dptCore := department.NewCore(departmentdb.NewStore(cfg.Log, cfg.DB), auditCore)
+	dgh := departmentgrp.New(dptCore)
+
+	app.Handle(http.MethodGet, "/departments", dgh.Query, authen, mid.Authorize(cfg.Auth, auth.RuleAny))
+	app.Handle(http.MethodGet, "/departments/:department_id", dgh.QueryByID, authen, mid.Authorize(cfg.Auth, auth.RuleAny))
+	app.Handle(http.MethodPost, "/departments", dgh.Create, authen, mid.Authorize(cfg.Auth, auth.RuleAdminOnly))
+	app.Handle(http.MethodPut, "/departments/:department_id", dgh.Update, authen, mid.Authorize(cfg.Auth, auth.RuleAdminOnly))
+	app.Handle(http.MethodDelete, "/departments/:department_id", dgh.Delete, authen, mid.Authorize(cfg.Auth, auth.RuleAdminOnly))
+
+	// -------------------------------------------------------------------------
+
+	prdCore := product.NewCore(cfg.Log, usrCore, auditCore, exchCore, productdb.NewStore(cfg.Log, cfg.DB))
+	pgh := productgrp.New(prdCore)
+
+	app.Handle(http.MethodGet, "/products", pgh.Query, authen, mid.Authorize(cfg.Auth, auth.RuleAny))
+	app.Handle(http.MethodGet, "/products/:product_id", pgh.QueryByID, authen, mid.Authorize(cfg.Auth, auth.RuleAny))
+	app.Handle(http.MethodGet, "/products/:product_id/history", pgh.QueryHistory, authen, mid.Authorize(cfg.Auth, auth.RuleAny))
+	app.Handle(http.MethodPost, "/products", pgh.Create, authen, mid.Authorize(cfg.Auth, auth.RuleAny))
+	app.Handle(http.MethodPut, "/products/:product_id", pgh.Update, authen, mid.AuthorizeProduct(cfg.Auth, auth.RuleAdminOrSubject, prdCore), ifMatch)
+	app.Handle(http.MethodDelete, "/products/:product_id", pgh.Delete, authen, mid.AuthorizeProduct(cfg.Auth, auth.RuleAdminOrSubject, prdCore))
+	app.Handle(http.MethodPost, "/products/:product_id/restore", pgh.Restore, authen, mid.Authorize(cfg.Auth, auth.RuleAdminOnly))
+	app.Handle(http.MethodPost, "/products/:product_id/variants", pgh.CreateVariant, authen, mid.AuthorizeProduct(cfg.Auth, auth.RuleAdminOrSubject, prdCore))
+	app.Handle(http.MethodGet, "/products/:product_id/movements", pgh.QueryMovements, authen, mid.AuthorizeProduct(cfg.Auth, auth.RuleAdminOrSubject, prdCore))
+	app.Handle(http.MethodPost, "/products/:product_id/receipts", pgh.Receive, authen, mid.AuthorizeProduct(cfg.Auth, auth.RuleAdminOrSubject, prdCore))
+	app.Handle(http.MethodPost, "/products/:product_id/adjustments", pgh.Adjust, authen, mid.AuthorizeProduct(cfg.Auth, auth.RuleAdminOrSubject, prdCore))
+	app.Handle(http.MethodGet, "/inventory/reconciliation", pgh.Reconcile, authen, mid.Authorize(cfg.Auth, auth.RuleAdminOnly))
+	app.Handle(http.MethodGet, "/products/:product_id/prices", pgh.QueryPrices, authen, mid.Authorize(cfg.Auth, auth.RuleAny))
+	app.Handle(http.MethodPut, "/products/:product_id/prices/:currency", pgh.SetPrice, authen, mid.AuthorizeProduct(cfg.Auth, auth.RuleAdminOrSubject, prdCore))
+	app.Handle(http.MethodDelete, "/products/:product_id/prices/:currency", pgh.DeletePrice, authen, mid.AuthorizeProduct(cfg.Auth, auth.RuleAdminOrSubject, prdCore))
+	app.Handle(http.MethodGet, "/products/:product_id/price-history", pgh.QueryPriceHistory, authen, mid.Authorize(cfg.Auth, auth.RuleAny))
+	app.Handle(http.MethodGet, "/products/:product_id/price-changes", pgh.QueryPriceChanges, authen, mid.AuthorizeProduct(cfg.Auth, auth.RuleAdminOrSubject, prdCore))
+	app.Handle(http.MethodPost, "/products/:product_id/price-changes", pgh.SchedulePriceChange, authen, mid.AuthorizeProduct(cfg.Auth, auth.RuleAdminOrSubject, prdCore))
+	app.Handle(http.MethodDelete, "/products/:product_id/price-changes/:change_id", pgh.CancelPriceChange, authen, mid.AuthorizeProduct(cfg.Auth, auth.RuleAdminOrSubject, prdCore))
+
+	// -------------------------------------------------------------------------
+
+	// Alerts are raised by a background job, the API only reports on them.
+	stkCore := stockalert.NewCore(stockalertdb.NewStore(cfg.Log, cfg.DB), nil)
+	stkgh := stockalertgrp.New(stkCore)
+
+	app.Handle(http.MethodGet, "/products/low-stock", stkgh.LowStock, authen, mid.Authorize(cfg.Auth, auth.RuleAdminOnly))
+
+	// -------------------------------------------------------------------------
+
+	catCore := category.NewCore(categorydb.NewStore(cfg.Log, cfg.DB), auditCore)
+	cgh := categorygrp.New(catCore)
+
+	app.Handle(http.MethodGet, "/categories", cgh.Query, authen, mid.Authorize(cfg.Auth, auth.RuleAny))
+	app.Handle(http.MethodGet, "/categories/:category_id", cgh.QueryByID, authen, mid.Authorize(cfg.Auth, auth.RuleAny))
+	app.Handle(http.MethodPost, "/categories", cgh.Create, authen, mid.Authorize(cfg.Auth, auth.RuleAdminOnly))
+	app.Handle(http.MethodPut, "/categories/:category_id", cgh.Update, authen, mid.Authorize(cfg.Auth, auth.RuleAdminOnly))
+	app.Handle(http.MethodPost, "/categories/:category_id/move", cgh.Move, authen, mid.Authorize(cfg.Auth, auth.RuleAdminOnly))
+	app.Handle(http.MethodDelete, "/categories/:category_id", cgh.Delete, authen, mid.Authorize(cfg.Auth, auth.RuleAdminOnly))
+	app.Handle(http.MethodGet, "/products/:product_id/categories", cgh.QueryByProduct, authen, mid.Authorize(cfg.Auth, auth.RuleAny))
+	app.Handle(http.MethodPut, "/products/:product_id/categories/:category_id", cgh.Assign, authen, mid.AuthorizeProduct(cfg.Auth, auth.RuleAdminOrSubject, prdCore))
+	app.Handle(http.MethodDelete, "/products/:product_id/categories/:category_id", cgh.Unassign, authen, mid.AuthorizeProduct(cfg.Auth, auth.RuleAdminOrSubject, prdCore))
+
+	// -------------------------------------------------------------------------
+
+	egh := exchangegrp.New(exchCore)
+
+	app.Handle(http.MethodGet, "/exchange-rates", egh.Query, authen, mid.Authorize(cfg.Auth, auth.RuleAny))
+	app.Handle(http.MethodGet, "/exchange-rates/:base/:quote", egh.QueryByCurrencies, authen, mid.Authorize(cfg.Auth, auth.RuleAny))
+	app.Handle(http.MethodPut, "/exchange-rates", egh.Set, authen, mid.Authorize(cfg.Auth, auth.RuleAdminOnly))
+	app.Handle(http.MethodPost, "/exchange-rates/import", egh.Import, authen, mid.Authorize(cfg.Auth, auth.RuleAdminOnly))
+	app.Handle(http.MethodDelete, "/exchange-rates/:base/:quote", egh.Delete, authen, mid.Authorize(cfg.Auth, auth.RuleAdminOnly))
+
+	// -------------------------------------------------------------------------
+
+	taxCore := tax.NewCore(taxdb.NewStore(cfg.Log, cfg.DB), prdCore)
+	tgh := taxgrp.New(taxCore)
+
+	app.Handle(http.MethodGet, "/tax/jurisdictions", tgh.Query, authen, mid.Authorize(cfg.Auth, auth.RuleAny))
+	app.Handle(http.MethodGet, "/tax/jurisdictions/:code", tgh.QueryByCode, authen, mid.Authorize(cfg.Auth, auth.RuleAny))
+	app.Handle(http.MethodPost, "/tax/jurisdictions/import", tgh.Import, authen, mid.Authorize(cfg.Auth, auth.RuleAdminOnly))
+	app.Handle(http.MethodPost, "/tax/quote", tgh.Quote, authen, mid.Authorize(cfg.Auth, auth.RuleAny))
+
+	// -------------------------------------------------------------------------
+
+	resCore := reservation.NewCore(reservationdb.NewStore(cfg.Log, cfg.DB), prdCore)
+	rgh := reservationgrp.New(resCore, reservationgrp.Config{
+		TTL:    cfg.Reservation.TTL,
+		MaxTTL: cfg.Reservation.MaxTTL,
+	})
+
+	app.Handle(http.MethodGet, "/reservations", rgh.Query, authen, mid.Authorize(cfg.Auth, auth.RuleAny))
+	app.Handle(http.MethodGet, "/reservations/:reservation_id", rgh.QueryByID, authen, mid.Authorize(cfg.Auth, auth.RuleAny))
+	app.Handle(http.MethodPost, "/reservations", rgh.Create, authen, mid.Authorize(cfg.Auth, auth.RuleAny))
+	app.Handle(http.MethodDelete, "/reservations/:reservation_id", rgh.Release, authen, mid.Authorize(cfg.Auth, auth.RuleAny))
+
+	// -------------------------------------------------------------------------
+
+	promoCore := promotion.NewCore(promotiondb.NewStore(cfg.Log, cfg.DB), auditCore)
+	prgh := promotiongrp.New(promoCore)
+
+	app.Handle(http.MethodGet, "/promotions", prgh.Query, authen, mid.Authorize(cfg.Auth, auth.RuleAdminOnly))
+	app.Handle(http.MethodGet, "/promotions/:promotion_id", prgh.QueryByID, authen, mid.Authorize(cfg.Auth, auth.RuleAdminOnly))
+	app.Handle(http.MethodPost, "/promotions", prgh.Create, authen, mid.Authorize(cfg.Auth, auth.RuleAdminOnly))
+	app.Handle(http.MethodPut, "/promotions/:promotion_id", prgh.Update, authen, mid.Authorize(cfg.Auth, auth.RuleAdminOnly))
+	app.Handle(http.MethodDelete, "/promotions/:promotion_id", prgh.Delete, authen, mid.Authorize(cfg.Auth, auth.RuleAdminOnly))
+
+	// -------------------------------------------------------------------------
+
+	ordCore := salesorder.NewCore(salesorderdb.NewStore(cfg.Log, cfg.DB), prdCore, resCore, promoCore, taxCore, auditCore)
+	orh := ordergrp.New(ordCore)
+
+	app.Handle(http.MethodGet, "/orders", orh.Query, authen, mid.Authorize(cfg.Auth, auth.RuleAny))
+	app.Handle(http.MethodGet, "/orders/:order_id", orh.QueryByID, authen, mid.AuthorizeOrder(cfg.Auth, auth.RuleAdminOrSubject, ordCore))
+	app.Handle(http.MethodPost, "/orders", orh.Create, authen, mid.Authorize(cfg.Auth, auth.RuleAny))
+	app.Handle(http.MethodPost, "/orders/:order_id/pay", orh.Transition(salesorder.StatusPaid), authen, mid.AuthorizeOrderTransition(cfg.Auth, salesorder.StatusPaid, ordCore))
+	app.Handle(http.MethodPost, "/orders/:order_id/fulfill", orh.Transition(salesorder.StatusFulfilled), authen, mid.AuthorizeOrderTransition(cfg.Auth, salesorder.StatusFulfilled, ordCore))
+	app.Handle(http.MethodPost, "/orders/:order_id/cancel", orh.Transition(salesorder.StatusCancelled), authen, mid.AuthorizeOrderTransition(cfg.Auth, salesorder.StatusCancelled, ordCore))
+	app.Handle(http.MethodGet, "/orders/:order_id/returns", orh.QueryReturns, authen, mid.AuthorizeOrder(cfg.Auth, auth.RuleAdminOrSubject, ordCore))
+	app.Handle(http.MethodPost, "/orders/:order_id/returns", orh.Return, authen, mid.AuthorizeOrder(cfg.Auth, auth.RuleAdminOnly, ordCore))
+
+	// -------------------------------------------------------------------------
+
+	gdprCore := gdpr.NewCore(usrCore, prdCore, ordCore, sesCore, idtCore, apiTokCore, auditCore)
+	ggh := gdprgrp.New(gdprCore)
+
+	app.Handle(http.MethodGet, "/users/:user_id/export", ggh.Export, authen, mid.Authorize(cfg.Auth, auth.RuleAdminOnly))
+	app.Handle(http.MethodPost, "/users/:user_id/erase", ggh.Erase, authen, mid.Authorize(cfg.Auth, auth.RuleAdminOnly))
+
+	// -------------------------------------------------------------------------
+
+	agh := auditgrp.New(auditCore)
+
+	app.Handle(http.MethodGet, "/audit", agh.Query, authen, mid.Authorize(cfg.Auth, auth.RuleAdminOnly))
+
+	// -------------------------------------------------------------------------
+
+	if cfg.Session.Enabled {
+		sesh := sessiongrp.New(sesCore, usrCore, cfg.Session.TTL)
+
+		app.Handle(http.MethodPost, "/sessions", sesh.Create)
+		app.Handle(http.MethodDelete, "/sessions/current", sesh.DeleteCurrent, authen, mid.Authorize(cfg.Auth, auth.RuleAny))
+		app.Handle(http.MethodGet, "/users/:user_id/sessions", sesh.QueryByUser, authen, mid.AuthorizeUser(cfg.Auth, auth.RuleAdminOrSubject, usrCore))
+		app.Handle(http.MethodDelete, "/users/:user_id/sessions", sesh.RevokeAll, authen, mid.AuthorizeUser(cfg.Auth, auth.RuleAdminOrSubject, usrCore))
+		app.Handle(http.MethodDelete, "/users/:user_id/sessions/:session_id", sesh.Revoke, authen, mid.AuthorizeUser(cfg.Auth, auth.RuleAdminOrSubject, usrCore))
+	}
+
+	// -------------------------------------------------------------------------
+
+	if cfg.OIDC.Provider != nil {
+		ogh := oidcgrp.New(oidcgrp.Config{
+			Provider:  cfg.OIDC.Provider,
+			Identity:  idtCore,
+			APIToken:  apiTokCore,
+			Auth:      cfg.Auth,
+			ActiveKID: cfg.OIDC.ActiveKID,
+			Issuer:    cfg.OIDC.Issuer,
+			TenantID:  cfg.OIDC.TenantID,
+			TokenTTL:  cfg.OIDC.TokenTTL,
+		})
+
+		app.Handle(http.MethodGet, "/oidc/login", ogh.Login)
+		app.Handle(http.MethodGet, "/oidc/callback", ogh.Callback)
+	}
+
+	// -------------------------------------------------------------------------
+
+	// SCIM clients authenticate with their own credential and receive errors
+	// in the SCIM format, so these routes don't use the JWT middleware.
+	tokCore := scimtoken.NewCore(scimtokendb.NewStore(cfg.Log, cfg.DB))
+	scimErrs := mid.SCIMErrors(cfg.Log)
+	scimAuth := mid.AuthenticateSCIM(tokCore)
+	sgh := scimgrp.New(usrCore)
 
-	app.Handle(http.MethodGet, "/users", ugh.Query)
+	app.Handle(http.MethodGet, "/scim/v2/Users", sgh.QueryUsers, scimErrs, scimAuth)
+	app.Handle(http.MethodPost, "/scim/v2/Users", sgh.CreateUser, scimErrs, scimAuth)
+	app.Handle(http.MethodGet, "/scim/v2/Users/:id", sgh.QueryUserByID, scimErrs, scimAuth)
+	app.Handle(http.MethodPut, "/scim/v2/Users/:id", sgh.ReplaceUser, scimErrs, scimAuth)
+	app.Handle(http.MethodPatch, "/scim/v2/Users/:id", sgh.PatchUser, scimErrs, scimAuth)
+	app.Handle(http.MethodDelete, "/scim/v2/Users/:id", sgh.DeleteUser, scimErrs, scimAuth)
+	app.Handle(http.MethodGet, "/scim/v2/Groups", sgh.QueryGroups, scimErrs, scimAuth)
+	app.Handle(http.MethodGet, "/scim/v2/Groups/:id", sgh.QueryGroupByID, scimErrs, scimAuth)
+	app.Handle(http.MethodPatch, "/scim/v2/Groups/:id", sgh.PatchGroup, scimErrs, scimAuth)
 
 	return app
 }
@@ -0,0 +1,59 @@
+// Package auditgrp maintains the group of handlers for audit access.
+package auditgrp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/aleury/service/business/core/audit"
+	"github.com/aleury/service/business/web/v1/paging"
+	"github.com/aleury/service/foundation/web"
+)
+
+// Handlers manages the set of audit endpoints.
+type Handlers struct {
+	audit *audit.Core
+}
+
+// New constructs a handlers for route access.
+func New(audit *audit.Core) *Handlers {
+	return &Handlers{
+		audit: audit,
+	}
+}
+
+// Query returns a list of audit entries with paging.
+func (h *Handlers) Query(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	page, err := paging.ParseRequest(r)
+	if err != nil {
+		return err
+	}
+
+	filter, err := parseFilter(r)
+	if err != nil {
+		return err
+	}
+
+	orderBy, err := parseOrder(r)
+	if err != nil {
+		return err
+	}
+
+	entries, err := h.audit.Query(ctx, filter, orderBy, page.Number, page.RowsPerPage)
+	if err != nil {
+		return fmt.Errorf("query: %w", err)
+	}
+
+	items := make([]AppEntry, len(entries))
+	for i, e := range entries {
+		items[i] = toAppEntry(e)
+	}
+
+	total, err := h.audit.Count(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("count: %w", err)
+	}
+
+	return web.Respond(ctx, w, paging.NewResponse(items, total, page.Number, page.RowsPerPage), http.StatusOK)
+}
@@ -0,0 +1,66 @@
+package auditgrp
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/aleury/service/business/core/audit"
+	"github.com/aleury/service/business/sys/validate"
+	"github.com/google/uuid"
+)
+
+func parseFilter(r *http.Request) (audit.QueryFilter, error) {
+	values := r.URL.Query()
+
+	var filter audit.QueryFilter
+
+	if actorID := values.Get("actor_id"); actorID != "" {
+		id, err := uuid.Parse(actorID)
+		if err != nil {
+			return audit.QueryFilter{}, validate.NewFieldsError("actor_id", err)
+		}
+		filter.WithActorID(id)
+	}
+
+	if action := values.Get("action"); action != "" {
+		filter.WithAction(action)
+	}
+
+	if entityType := values.Get("entity_type"); entityType != "" {
+		filter.WithEntityType(entityType)
+	}
+
+	if entityID := values.Get("entity_id"); entityID != "" {
+		id, err := uuid.Parse(entityID)
+		if err != nil {
+			return audit.QueryFilter{}, validate.NewFieldsError("entity_id", err)
+		}
+		filter.WithEntityID(id)
+	}
+
+	if traceID := values.Get("trace_id"); traceID != "" {
+		filter.WithTraceID(traceID)
+	}
+
+	if startCreatedDate := values.Get("start_created_date"); startCreatedDate != "" {
+		t, err := time.Parse(time.RFC3339, startCreatedDate)
+		if err != nil {
+			return audit.QueryFilter{}, validate.NewFieldsError("start_created_date", err)
+		}
+		filter.WithStartCreatedDate(t)
+	}
+
+	if endCreatedDate := values.Get("end_created_date"); endCreatedDate != "" {
+		t, err := time.Parse(time.RFC3339, endCreatedDate)
+		if err != nil {
+			return audit.QueryFilter{}, validate.NewFieldsError("end_created_date", err)
+		}
+		filter.WithEndCreatedDate(t)
+	}
+
+	if err := filter.Validate(); err != nil {
+		return audit.QueryFilter{}, err
+	}
+
+	return filter, nil
+}
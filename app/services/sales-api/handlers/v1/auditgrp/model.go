@@ -0,0 +1,39 @@
+package auditgrp
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/aleury/service/business/core/audit"
+	"github.com/google/uuid"
+)
+
+// AppEntry represents a single recorded change made to an entity.
+type AppEntry struct {
+	ID          string          `json:"id"`
+	ActorID     string          `json:"actorId,omitempty"`
+	Action      string          `json:"action"`
+	EntityType  string          `json:"entityType"`
+	EntityID    string          `json:"entityId"`
+	Diff        json.RawMessage `json:"diff"`
+	TraceID     string          `json:"traceId"`
+	DateCreated string          `json:"dateCreated"`
+}
+
+func toAppEntry(e audit.Entry) AppEntry {
+	var actorID string
+	if e.ActorID != uuid.Nil {
+		actorID = e.ActorID.String()
+	}
+
+	return AppEntry{
+		ID:          e.ID.String(),
+		ActorID:     actorID,
+		Action:      e.Action,
+		EntityType:  e.EntityType,
+		EntityID:    e.EntityID.String(),
+		Diff:        e.Diff,
+		TraceID:     e.TraceID,
+		DateCreated: e.DateCreated.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,30 @@
+package auditgrp
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/aleury/service/business/core/audit"
+	"github.com/aleury/service/business/data/order"
+	"github.com/aleury/service/business/sys/validate"
+)
+
+var orderByFields = map[string]struct{}{
+	audit.OrderByID:          {},
+	audit.OrderByActorID:     {},
+	audit.OrderByEntityType:  {},
+	audit.OrderByDateCreated: {},
+}
+
+func parseOrder(r *http.Request) (order.By, error) {
+	orderBy, err := order.Parse(r, audit.DefaultOrderBy)
+	if err != nil {
+		return order.By{}, err
+	}
+
+	if _, exists := orderByFields[orderBy.Field]; !exists {
+		return order.By{}, validate.NewFieldsError(orderBy.Field, errors.New("order field does not exist"))
+	}
+
+	return orderBy, nil
+}
@@ -0,0 +1,247 @@
+// Package categorygrp maintains the group of handlers for category access.
+package categorygrp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/aleury/service/business/core/category"
+	"github.com/aleury/service/business/core/tenant"
+	v1 "github.com/aleury/service/business/web/v1"
+	"github.com/aleury/service/business/web/v1/mid"
+	"github.com/aleury/service/business/web/v1/paging"
+	"github.com/aleury/service/foundation/web"
+	"github.com/google/uuid"
+)
+
+// Handlers manages the set of category endpoints.
+type Handlers struct {
+	category *category.Core
+}
+
+// New constructs a handlers for route access.
+func New(category *category.Core) *Handlers {
+	return &Handlers{
+		category: category,
+	}
+}
+
+// Create adds a new category to the tree.
+func (h *Handlers) Create(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	var app AppNewCategory
+	if err := web.Decode(r, &app); err != nil {
+		return err
+	}
+
+	nc, err := toCoreNewCategory(app, tenant.GetTenantID(ctx))
+	if err != nil {
+		return v1.NewRequestError(err, http.StatusBadRequest)
+	}
+
+	cat, err := h.category.Create(ctx, nc)
+	if err != nil {
+		switch {
+		case errors.Is(err, category.ErrUniqueName):
+			return v1.NewRequestError(err, http.StatusConflict)
+		case errors.Is(err, category.ErrParentNotFound):
+			return v1.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return fmt.Errorf("create: app[%+v]: %w", app, err)
+		}
+	}
+
+	return web.Respond(ctx, w, toAppCategory(cat), http.StatusCreated)
+}
+
+// Update updates a category in the tree.
+func (h *Handlers) Update(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	var app AppUpdateCategory
+	if err := web.Decode(r, &app); err != nil {
+		return err
+	}
+
+	cat, err := h.queryByParam(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	cat, err = h.category.Update(ctx, cat, toCoreUpdateCategory(app))
+	if err != nil {
+		if errors.Is(err, category.ErrUniqueName) {
+			return v1.NewRequestError(err, http.StatusConflict)
+		}
+		return fmt.Errorf("update: categoryID[%s] app[%+v]: %w", cat.ID, app, err)
+	}
+
+	return web.Respond(ctx, w, toAppCategory(cat), http.StatusOK)
+}
+
+// Move puts a category and its subtree under another parent.
+func (h *Handlers) Move(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	var app AppMoveCategory
+	if err := web.Decode(r, &app); err != nil {
+		return err
+	}
+
+	parentID, err := parseParentID(app.ParentID)
+	if err != nil {
+		return v1.NewRequestError(err, http.StatusBadRequest)
+	}
+
+	cat, err := h.queryByParam(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	cat, err = h.category.Move(ctx, cat, parentID)
+	if err != nil {
+		switch {
+		case errors.Is(err, category.ErrUniqueName), errors.Is(err, category.ErrCycle):
+			return v1.NewRequestError(err, http.StatusConflict)
+		case errors.Is(err, category.ErrParentNotFound):
+			return v1.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return fmt.Errorf("move: categoryID[%s] parentID[%s]: %w", cat.ID, parentID, err)
+		}
+	}
+
+	return web.Respond(ctx, w, toAppCategory(cat), http.StatusOK)
+}
+
+// Delete removes a category from the tree. Categories that still have
+// subcategories can't be removed.
+func (h *Handlers) Delete(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	categoryID, err := uuid.Parse(web.Param(r, "category_id"))
+	if err != nil {
+		return v1.NewRequestError(mid.ErrInvalidID, http.StatusBadRequest)
+	}
+
+	cat, err := h.category.QueryByID(ctx, categoryID)
+	if err != nil {
+		switch {
+		case errors.Is(err, category.ErrNotFound):
+			return web.Respond(ctx, w, nil, http.StatusNoContent)
+		default:
+			return fmt.Errorf("querybyid: categoryID[%s]: %w", categoryID, err)
+		}
+	}
+
+	if err := h.category.Delete(ctx, cat); err != nil {
+		if errors.Is(err, category.ErrHasChildren) {
+			return v1.NewRequestError(err, http.StatusConflict)
+		}
+		return fmt.Errorf("delete: categoryID[%s]: %w", cat.ID, err)
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+// Query returns a list of categories with paging.
+func (h *Handlers) Query(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	page, err := paging.ParseRequest(r)
+	if err != nil {
+		return err
+	}
+
+	filter, err := parseFilter(r)
+	if err != nil {
+		return err
+	}
+
+	orderBy, err := parseOrder(r)
+	if err != nil {
+		return err
+	}
+
+	cats, err := h.category.Query(ctx, filter, orderBy, page.Number, page.RowsPerPage)
+	if err != nil {
+		return fmt.Errorf("query: %w", err)
+	}
+
+	total, err := h.category.Count(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("count: %w", err)
+	}
+
+	return web.Respond(ctx, w, paging.NewResponse(toAppCategories(cats), total, page.Number, page.RowsPerPage), http.StatusOK)
+}
+
+// QueryByID returns a category by its ID.
+func (h *Handlers) QueryByID(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	cat, err := h.queryByParam(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	return web.Respond(ctx, w, toAppCategory(cat), http.StatusOK)
+}
+
+// QueryByProduct returns the categories a product is assigned to.
+func (h *Handlers) QueryByProduct(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	productID, err := uuid.Parse(web.Param(r, "product_id"))
+	if err != nil {
+		return v1.NewRequestError(mid.ErrInvalidID, http.StatusBadRequest)
+	}
+
+	cats, err := h.category.QueryByProduct(ctx, productID)
+	if err != nil {
+		return fmt.Errorf("querybyproduct: productID[%s]: %w", productID, err)
+	}
+
+	return web.Respond(ctx, w, toAppCategories(cats), http.StatusOK)
+}
+
+// Assign puts the product in the category.
+func (h *Handlers) Assign(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	prd := mid.GetProduct(ctx)
+
+	cat, err := h.queryByParam(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	if err := h.category.Assign(ctx, prd.ID, cat); err != nil {
+		return fmt.Errorf("assign: productID[%s] categoryID[%s]: %w", prd.ID, cat.ID, err)
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+// Unassign takes the product out of the category.
+func (h *Handlers) Unassign(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	prd := mid.GetProduct(ctx)
+
+	categoryID, err := uuid.Parse(web.Param(r, "category_id"))
+	if err != nil {
+		return v1.NewRequestError(mid.ErrInvalidID, http.StatusBadRequest)
+	}
+
+	if err := h.category.Unassign(ctx, prd.ID, categoryID); err != nil {
+		return fmt.Errorf("unassign: productID[%s] categoryID[%s]: %w", prd.ID, categoryID, err)
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+// queryByParam loads the category identified by the category_id route
+// parameter.
+func (h *Handlers) queryByParam(ctx context.Context, r *http.Request) (category.Category, error) {
+	categoryID, err := uuid.Parse(web.Param(r, "category_id"))
+	if err != nil {
+		return category.Category{}, v1.NewRequestError(mid.ErrInvalidID, http.StatusBadRequest)
+	}
+
+	cat, err := h.category.QueryByID(ctx, categoryID)
+	if err != nil {
+		switch {
+		case errors.Is(err, category.ErrNotFound):
+			return category.Category{}, v1.NewRequestError(err, http.StatusNotFound)
+		default:
+			return category.Category{}, fmt.Errorf("querybyid: categoryID[%s]: %w", categoryID, err)
+		}
+	}
+
+	return cat, nil
+}
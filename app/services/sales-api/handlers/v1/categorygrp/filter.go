@@ -0,0 +1,46 @@
+package categorygrp
+
+import (
+	"net/http"
+
+	"github.com/aleury/service/business/core/category"
+	"github.com/aleury/service/business/sys/validate"
+	"github.com/google/uuid"
+)
+
+func parseFilter(r *http.Request) (category.QueryFilter, error) {
+	values := r.URL.Query()
+
+	var filter category.QueryFilter
+
+	if categoryID := values.Get("category_id"); categoryID != "" {
+		id, err := uuid.Parse(categoryID)
+		if err != nil {
+			return category.QueryFilter{}, validate.NewFieldsError("category_id", err)
+		}
+		filter.WithCategoryID(id)
+	}
+
+	// The top level categories are the children of the root.
+	switch parentID := values.Get("parent_id"); parentID {
+	case "":
+	case "root":
+		filter.WithParentID(uuid.Nil)
+	default:
+		id, err := uuid.Parse(parentID)
+		if err != nil {
+			return category.QueryFilter{}, validate.NewFieldsError("parent_id", err)
+		}
+		filter.WithParentID(id)
+	}
+
+	if name := values.Get("name"); name != "" {
+		filter.WithName(name)
+	}
+
+	if err := filter.Validate(); err != nil {
+		return category.QueryFilter{}, err
+	}
+
+	return filter, nil
+}
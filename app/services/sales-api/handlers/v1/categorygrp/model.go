@@ -0,0 +1,126 @@
+package categorygrp
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aleury/service/business/core/category"
+	"github.com/aleury/service/business/sys/validate"
+	"github.com/google/uuid"
+)
+
+// AppCategory represents an individual category.
+type AppCategory struct {
+	ID          string `json:"id"`
+	TenantID    string `json:"tenantId"`
+	ParentID    string `json:"parentId,omitempty"`
+	Name        string `json:"name"`
+	DateCreated string `json:"dateCreated"`
+	DateUpdated string `json:"dateUpdated"`
+}
+
+func toAppCategory(cat category.Category) AppCategory {
+	app := AppCategory{
+		ID:          cat.ID.String(),
+		TenantID:    cat.TenantID.String(),
+		Name:        cat.Name,
+		DateCreated: cat.DateCreated.Format(time.RFC3339),
+		DateUpdated: cat.DateUpdated.Format(time.RFC3339),
+	}
+
+	if cat.ParentID != uuid.Nil {
+		app.ParentID = cat.ParentID.String()
+	}
+
+	return app
+}
+
+func toAppCategories(cats []category.Category) []AppCategory {
+	items := make([]AppCategory, len(cats))
+	for i, cat := range cats {
+		items[i] = toAppCategory(cat)
+	}
+	return items
+}
+
+// =============================================================================
+
+// AppNewCategory is what we require from clients when adding a Category. It
+// is added at the top level of the tree without a parent.
+type AppNewCategory struct {
+	ParentID string `json:"parentId" validate:"omitempty,uuid"`
+	Name     string `json:"name" validate:"required,min=2"`
+}
+
+func toCoreNewCategory(app AppNewCategory, tenantID uuid.UUID) (category.NewCategory, error) {
+	parentID, err := parseParentID(app.ParentID)
+	if err != nil {
+		return category.NewCategory{}, err
+	}
+
+	nc := category.NewCategory{
+		TenantID: tenantID,
+		ParentID: parentID,
+		Name:     app.Name,
+	}
+	return nc, nil
+}
+
+// Validate checks the data in the model is considered clean.
+func (app AppNewCategory) Validate() error {
+	if err := validate.Check(app); err != nil {
+		return err
+	}
+	return nil
+}
+
+// =============================================================================
+
+// AppUpdateCategory contains information needed to update a category.
+type AppUpdateCategory struct {
+	Name *string `json:"name" validate:"omitempty,min=2"`
+}
+
+func toCoreUpdateCategory(app AppUpdateCategory) category.UpdateCategory {
+	return category.UpdateCategory{
+		Name: app.Name,
+	}
+}
+
+// Validate checks the data in the model is considered clean.
+func (app AppUpdateCategory) Validate() error {
+	if err := validate.Check(app); err != nil {
+		return err
+	}
+	return nil
+}
+
+// =============================================================================
+
+// AppMoveCategory names the new parent of a category. Without a parent the
+// category is moved to the top level of the tree.
+type AppMoveCategory struct {
+	ParentID string `json:"parentId" validate:"omitempty,uuid"`
+}
+
+// Validate checks the data in the model is considered clean.
+func (app AppMoveCategory) Validate() error {
+	if err := validate.Check(app); err != nil {
+		return err
+	}
+	return nil
+}
+
+// parseParentID returns uuid.Nil for a category without a parent.
+func parseParentID(parentID string) (uuid.UUID, error) {
+	if parentID == "" {
+		return uuid.Nil, nil
+	}
+
+	id, err := uuid.Parse(parentID)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("parsing parentId: %w", err)
+	}
+
+	return id, nil
+}
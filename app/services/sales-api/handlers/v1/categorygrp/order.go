@@ -0,0 +1,28 @@
+package categorygrp
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/aleury/service/business/core/category"
+	"github.com/aleury/service/business/data/order"
+	"github.com/aleury/service/business/sys/validate"
+)
+
+var orderByFields = map[string]struct{}{
+	category.OrderByID:   {},
+	category.OrderByName: {},
+}
+
+func parseOrder(r *http.Request) (order.By, error) {
+	orderBy, err := order.Parse(r, category.DefaultOrderBy)
+	if err != nil {
+		return order.By{}, err
+	}
+
+	if _, exists := orderByFields[orderBy.Field]; !exists {
+		return order.By{}, validate.NewFieldsError(orderBy.Field, errors.New("order field does not exist"))
+	}
+
+	return orderBy, nil
+}
@@ -0,0 +1,159 @@
+// Package departmentgrp maintains the group of handlers for department access.
+package departmentgrp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/aleury/service/business/core/department"
+	"github.com/aleury/service/business/core/tenant"
+	v1 "github.com/aleury/service/business/web/v1"
+	"github.com/aleury/service/business/web/v1/mid"
+	"github.com/aleury/service/business/web/v1/paging"
+	"github.com/aleury/service/foundation/web"
+	"github.com/google/uuid"
+)
+
+// Handlers manages the set of department endpoints.
+type Handlers struct {
+	department *department.Core
+}
+
+// New constructs a handlers for route access.
+func New(department *department.Core) *Handlers {
+	return &Handlers{
+		department: department,
+	}
+}
+
+// Create adds a new department to the system.
+func (h *Handlers) Create(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	var app AppNewDepartment
+	if err := web.Decode(r, &app); err != nil {
+		return err
+	}
+
+	dpt, err := h.department.Create(ctx, toCoreNewDepartment(app, tenant.GetTenantID(ctx)))
+	if err != nil {
+		if errors.Is(err, department.ErrUniqueName) {
+			return v1.NewRequestError(err, http.StatusConflict)
+		}
+		return fmt.Errorf("create: app[%+v]: %w", app, err)
+	}
+
+	return web.Respond(ctx, w, toAppDepartment(dpt), http.StatusCreated)
+}
+
+// Update updates a department in the system.
+func (h *Handlers) Update(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	var app AppUpdateDepartment
+	if err := web.Decode(r, &app); err != nil {
+		return err
+	}
+
+	dpt, err := h.queryByParam(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	dpt, err = h.department.Update(ctx, dpt, toCoreUpdateDepartment(app))
+	if err != nil {
+		if errors.Is(err, department.ErrUniqueName) {
+			return v1.NewRequestError(err, http.StatusConflict)
+		}
+		return fmt.Errorf("update: departmentID[%s] app[%+v]: %w", dpt.ID, app, err)
+	}
+
+	return web.Respond(ctx, w, toAppDepartment(dpt), http.StatusOK)
+}
+
+// Delete removes a department from the system. Departments that still have
+// users assigned can't be removed.
+func (h *Handlers) Delete(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	departmentID, err := uuid.Parse(web.Param(r, "department_id"))
+	if err != nil {
+		return v1.NewRequestError(mid.ErrInvalidID, http.StatusBadRequest)
+	}
+
+	dpt, err := h.department.QueryByID(ctx, departmentID)
+	if err != nil {
+		switch {
+		case errors.Is(err, department.ErrNotFound):
+			return web.Respond(ctx, w, nil, http.StatusNoContent)
+		default:
+			return fmt.Errorf("querybyid: departmentID[%s]: %w", departmentID, err)
+		}
+	}
+
+	if err := h.department.Delete(ctx, dpt); err != nil {
+		if errors.Is(err, department.ErrInUse) {
+			return v1.NewRequestError(err, http.StatusConflict)
+		}
+		return fmt.Errorf("delete: departmentID[%s]: %w", dpt.ID, err)
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+// Query returns a list of departments with paging.
+func (h *Handlers) Query(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	page, err := paging.ParseRequest(r)
+	if err != nil {
+		return err
+	}
+
+	filter, err := parseFilter(r)
+	if err != nil {
+		return err
+	}
+
+	orderBy, err := parseOrder(r)
+	if err != nil {
+		return err
+	}
+
+	dpts, err := h.department.Query(ctx, filter, orderBy, page.Number, page.RowsPerPage)
+	if err != nil {
+		return fmt.Errorf("query: %w", err)
+	}
+
+	total, err := h.department.Count(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("count: %w", err)
+	}
+
+	return web.Respond(ctx, w, paging.NewResponse(toAppDepartments(dpts), total, page.Number, page.RowsPerPage), http.StatusOK)
+}
+
+// QueryByID returns a department by its ID.
+func (h *Handlers) QueryByID(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	dpt, err := h.queryByParam(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	return web.Respond(ctx, w, toAppDepartment(dpt), http.StatusOK)
+}
+
+// queryByParam loads the department identified by the department_id route
+// parameter.
+func (h *Handlers) queryByParam(ctx context.Context, r *http.Request) (department.Department, error) {
+	departmentID, err := uuid.Parse(web.Param(r, "department_id"))
+	if err != nil {
+		return department.Department{}, v1.NewRequestError(mid.ErrInvalidID, http.StatusBadRequest)
+	}
+
+	dpt, err := h.department.QueryByID(ctx, departmentID)
+	if err != nil {
+		switch {
+		case errors.Is(err, department.ErrNotFound):
+			return department.Department{}, v1.NewRequestError(err, http.StatusNotFound)
+		default:
+			return department.Department{}, fmt.Errorf("querybyid: departmentID[%s]: %w", departmentID, err)
+		}
+	}
+
+	return dpt, nil
+}
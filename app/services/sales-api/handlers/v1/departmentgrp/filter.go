@@ -0,0 +1,33 @@
+package departmentgrp
+
+import (
+	"net/http"
+
+	"github.com/aleury/service/business/core/department"
+	"github.com/aleury/service/business/sys/validate"
+	"github.com/google/uuid"
+)
+
+func parseFilter(r *http.Request) (department.QueryFilter, error) {
+	values := r.URL.Query()
+
+	var filter department.QueryFilter
+
+	if departmentID := values.Get("department_id"); departmentID != "" {
+		id, err := uuid.Parse(departmentID)
+		if err != nil {
+			return department.QueryFilter{}, validate.NewFieldsError("department_id", err)
+		}
+		filter.WithDepartmentID(id)
+	}
+
+	if name := values.Get("name"); name != "" {
+		filter.WithName(name)
+	}
+
+	if err := filter.Validate(); err != nil {
+		return department.QueryFilter{}, err
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,79 @@
+package departmentgrp
+
+import (
+	"time"
+
+	"github.com/aleury/service/business/core/department"
+	"github.com/aleury/service/business/sys/validate"
+	"github.com/google/uuid"
+)
+
+// AppDepartment represents an individual department.
+type AppDepartment struct {
+	ID          string `json:"id"`
+	TenantID    string `json:"tenantId"`
+	Name        string `json:"name"`
+	DateCreated string `json:"dateCreated"`
+	DateUpdated string `json:"dateUpdated"`
+}
+
+func toAppDepartment(dpt department.Department) AppDepartment {
+	return AppDepartment{
+		ID:          dpt.ID.String(),
+		TenantID:    dpt.TenantID.String(),
+		Name:        dpt.Name,
+		DateCreated: dpt.DateCreated.Format(time.RFC3339),
+		DateUpdated: dpt.DateUpdated.Format(time.RFC3339),
+	}
+}
+
+func toAppDepartments(dpts []department.Department) []AppDepartment {
+	items := make([]AppDepartment, len(dpts))
+	for i, dpt := range dpts {
+		items[i] = toAppDepartment(dpt)
+	}
+	return items
+}
+
+// =============================================================================
+
+// AppNewDepartment is what we require from clients when adding a Department.
+type AppNewDepartment struct {
+	Name string `json:"name" validate:"required,min=2"`
+}
+
+func toCoreNewDepartment(app AppNewDepartment, tenantID uuid.UUID) department.NewDepartment {
+	return department.NewDepartment{
+		TenantID: tenantID,
+		Name:     app.Name,
+	}
+}
+
+// Validate checks the data in the model is considered clean.
+func (app AppNewDepartment) Validate() error {
+	if err := validate.Check(app); err != nil {
+		return err
+	}
+	return nil
+}
+
+// =============================================================================
+
+// AppUpdateDepartment contains information needed to update a department.
+type AppUpdateDepartment struct {
+	Name *string `json:"name" validate:"omitempty,min=2"`
+}
+
+func toCoreUpdateDepartment(app AppUpdateDepartment) department.UpdateDepartment {
+	return department.UpdateDepartment{
+		Name: app.Name,
+	}
+}
+
+// Validate checks the data in the model is considered clean.
+func (app AppUpdateDepartment) Validate() error {
+	if err := validate.Check(app); err != nil {
+		return err
+	}
+	return nil
+}
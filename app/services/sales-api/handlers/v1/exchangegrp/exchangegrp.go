@@ -0,0 +1,136 @@
+// Package exchangegrp maintains the group of handlers for exchange rate
+// access.
+package exchangegrp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/aleury/service/business/core/exchange"
+	"github.com/aleury/service/business/data/money"
+	v1 "github.com/aleury/service/business/web/v1"
+	"github.com/aleury/service/foundation/web"
+)
+
+// maxImportSize is the largest CSV document accepted by an import.
+const maxImportSize = 1 << 20
+
+// Handlers manages the set of exchange rate endpoints.
+type Handlers struct {
+	exchange *exchange.Core
+}
+
+// New constructs a handlers for route access.
+func New(exchange *exchange.Core) *Handlers {
+	return &Handlers{
+		exchange: exchange,
+	}
+}
+
+// Set records the rates in the request body, replacing the rates already
+// recorded for the same currencies.
+func (h *Handlers) Set(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	var app AppNewRates
+	if err := web.Decode(r, &app); err != nil {
+		return err
+	}
+
+	nrs, err := toCoreNewRates(app)
+	if err != nil {
+		return err
+	}
+
+	return h.set(ctx, w, nrs)
+}
+
+// Import records the rates in a CSV request body of base,quote,rate
+// records, replacing the rates already recorded for the same currencies.
+func (h *Handlers) Import(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	nrs, err := exchange.ParseCSV(http.MaxBytesReader(w, r.Body, maxImportSize))
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		switch {
+		case errors.Is(err, exchange.ErrInvalidCSV):
+			return v1.NewRequestError(err, http.StatusBadRequest)
+		case errors.As(err, &maxErr):
+			return v1.NewRequestError(err, http.StatusRequestEntityTooLarge)
+		default:
+			return fmt.Errorf("parsecsv: %w", err)
+		}
+	}
+
+	return h.set(ctx, w, nrs)
+}
+
+// Delete removes the rate between the currencies of the path.
+func (h *Handlers) Delete(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	base, quote, err := parseCurrencies(r)
+	if err != nil {
+		return err
+	}
+
+	if err := h.exchange.Delete(ctx, base, quote); err != nil {
+		return fmt.Errorf("delete: base[%s] quote[%s]: %w", base.Code(), quote.Code(), err)
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+// Query returns the recorded rates.
+func (h *Handlers) Query(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	rates, err := h.exchange.Query(ctx)
+	if err != nil {
+		return fmt.Errorf("query: %w", err)
+	}
+
+	return web.Respond(ctx, w, toAppRates(rates), http.StatusOK)
+}
+
+// QueryByCurrencies returns the rate between the currencies of the path.
+func (h *Handlers) QueryByCurrencies(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	base, quote, err := parseCurrencies(r)
+	if err != nil {
+		return err
+	}
+
+	rate, err := h.exchange.QueryRate(ctx, base, quote)
+	if err != nil {
+		if errors.Is(err, exchange.ErrNotFound) {
+			return v1.NewRequestError(err, http.StatusNotFound)
+		}
+		return fmt.Errorf("queryrate: base[%s] quote[%s]: %w", base.Code(), quote.Code(), err)
+	}
+
+	return web.Respond(ctx, w, toAppRate(rate), http.StatusOK)
+}
+
+// =============================================================================
+
+func (h *Handlers) set(ctx context.Context, w http.ResponseWriter, nrs []exchange.NewRate) error {
+	rates, err := h.exchange.Set(ctx, nrs)
+	if err != nil {
+		if errors.Is(err, exchange.ErrSameCurrency) {
+			return v1.NewRequestError(err, http.StatusBadRequest)
+		}
+		return fmt.Errorf("set: %w", err)
+	}
+
+	return web.Respond(ctx, w, toAppRates(rates), http.StatusOK)
+}
+
+func parseCurrencies(r *http.Request) (money.Currency, money.Currency, error) {
+	base, err := money.ParseCurrency(strings.ToUpper(web.Param(r, "base")))
+	if err != nil {
+		return money.Currency{}, money.Currency{}, v1.NewRequestError(err, http.StatusBadRequest)
+	}
+
+	quote, err := money.ParseCurrency(strings.ToUpper(web.Param(r, "quote")))
+	if err != nil {
+		return money.Currency{}, money.Currency{}, v1.NewRequestError(err, http.StatusBadRequest)
+	}
+
+	return base, quote, nil
+}
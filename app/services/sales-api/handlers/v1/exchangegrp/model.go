@@ -0,0 +1,88 @@
+package exchangegrp
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aleury/service/business/core/exchange"
+	"github.com/aleury/service/business/data/money"
+	"github.com/aleury/service/business/sys/validate"
+)
+
+// AppRate represents an individual exchange rate.
+type AppRate struct {
+	Base        string     `json:"base"`
+	Quote       string     `json:"quote"`
+	Rate        money.Rate `json:"rate"`
+	DateUpdated string     `json:"dateUpdated"`
+}
+
+func toAppRate(rate exchange.Rate) AppRate {
+	return AppRate{
+		Base:        rate.Base.Code(),
+		Quote:       rate.Quote.Code(),
+		Rate:        rate.Rate,
+		DateUpdated: rate.DateUpdated.Format(time.RFC3339),
+	}
+}
+
+func toAppRates(rates []exchange.Rate) []AppRate {
+	items := make([]AppRate, len(rates))
+	for i, rate := range rates {
+		items[i] = toAppRate(rate)
+	}
+	return items
+}
+
+// =============================================================================
+
+// AppNewRate is what we require from clients when recording a rate.
+type AppNewRate struct {
+	Base  string     `json:"base" validate:"required,len=3"`
+	Quote string     `json:"quote" validate:"required,len=3"`
+	Rate  money.Rate `json:"rate"`
+}
+
+// AppNewRates is a set of rates recorded together.
+type AppNewRates struct {
+	Rates []AppNewRate `json:"rates" validate:"required,min=1,dive"`
+}
+
+func toCoreNewRates(app AppNewRates) ([]exchange.NewRate, error) {
+	nrs := make([]exchange.NewRate, len(app.Rates))
+	for i, appRate := range app.Rates {
+		base, err := money.ParseCurrency(strings.ToUpper(appRate.Base))
+		if err != nil {
+			return nil, validate.NewFieldsError(fmt.Sprintf("rates[%d].base", i), err)
+		}
+
+		quote, err := money.ParseCurrency(strings.ToUpper(appRate.Quote))
+		if err != nil {
+			return nil, validate.NewFieldsError(fmt.Sprintf("rates[%d].quote", i), err)
+		}
+
+		nrs[i] = exchange.NewRate{
+			Base:  base,
+			Quote: quote,
+			Rate:  appRate.Rate,
+		}
+	}
+	return nrs, nil
+}
+
+// Validate checks the data in the model is considered clean.
+func (app AppNewRates) Validate() error {
+	if err := validate.Check(app); err != nil {
+		return err
+	}
+
+	for i, appRate := range app.Rates {
+		if appRate.Rate.IsZero() {
+			return validate.NewFieldsError(fmt.Sprintf("rates[%d].rate", i), errors.New("is a required field"))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,61 @@
+// Package gdprgrp maintains the group of handlers for data subject requests.
+package gdprgrp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/aleury/service/business/core/gdpr"
+	"github.com/aleury/service/business/core/user"
+	"github.com/aleury/service/business/web/auth"
+	v1 "github.com/aleury/service/business/web/v1"
+	"github.com/aleury/service/foundation/web"
+)
+
+// Handlers manages the set of data subject request endpoints.
+type Handlers struct {
+	gdpr *gdpr.Core
+}
+
+// New constructs a handlers for route access.
+func New(gdpr *gdpr.Core) *Handlers {
+	return &Handlers{
+		gdpr: gdpr,
+	}
+}
+
+// Export returns everything tied to a user as a single document.
+func (h *Handlers) Export(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	userID := auth.GetUserID(ctx)
+
+	exp, err := h.gdpr.Export(ctx, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, user.ErrNotFound):
+			return v1.NewRequestError(err, http.StatusNotFound)
+		default:
+			return fmt.Errorf("export: userID[%s]: %w", userID, err)
+		}
+	}
+
+	return web.Respond(ctx, w, toAppExport(exp), http.StatusOK)
+}
+
+// Erase anonymizes the personal data of a user.
+func (h *Handlers) Erase(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	userID := auth.GetUserID(ctx)
+
+	usr, err := h.gdpr.Erase(ctx, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, user.ErrNotFound):
+			return v1.NewRequestError(err, http.StatusNotFound)
+		default:
+			return fmt.Errorf("erase: userID[%s]: %w", userID, err)
+		}
+	}
+
+	return web.Respond(ctx, w, toAppUser(usr), http.StatusOK)
+}
@@ -0,0 +1,342 @@
+package gdprgrp
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/aleury/service/business/core/apitoken"
+	"github.com/aleury/service/business/core/audit"
+	"github.com/aleury/service/business/core/gdpr"
+	"github.com/aleury/service/business/core/identity"
+	"github.com/aleury/service/business/core/product"
+	"github.com/aleury/service/business/core/salesorder"
+	"github.com/aleury/service/business/core/session"
+	"github.com/aleury/service/business/core/user"
+	"github.com/aleury/service/business/data/money"
+	"github.com/google/uuid"
+)
+
+// AppExport represents the document returned for a data export request. It
+// carries more detail than the regular user and product models, such as
+// deletion and erasure times.
+type AppExport struct {
+	User         AppUser       `json:"user"`
+	Products     []AppProduct  `json:"products"`
+	Orders       []AppOrder    `json:"orders"`
+	Returns      []AppReturn   `json:"returns"`
+	Sessions     []AppSession  `json:"sessions"`
+	Identities   []AppIdentity `json:"identities"`
+	Tokens       []AppToken    `json:"tokens"`
+	Changes      []AppEntry    `json:"changes"`
+	Actions      []AppEntry    `json:"actions"`
+	DateExported string        `json:"dateExported"`
+}
+
+func toAppExport(exp gdpr.Export) AppExport {
+	prds := make([]AppProduct, len(exp.Products))
+	for i, prd := range exp.Products {
+		prds[i] = toAppProduct(prd)
+	}
+
+	ords := make([]AppOrder, len(exp.Orders))
+	for i, ord := range exp.Orders {
+		ords[i] = toAppOrder(ord)
+	}
+
+	rets := make([]AppReturn, len(exp.Returns))
+	for i, ret := range exp.Returns {
+		rets[i] = toAppReturn(ret)
+	}
+
+	sessions := make([]AppSession, len(exp.Sessions))
+	for i, sess := range exp.Sessions {
+		sessions[i] = toAppSession(sess)
+	}
+
+	idts := make([]AppIdentity, len(exp.Identities))
+	for i, idt := range exp.Identities {
+		idts[i] = toAppIdentity(idt)
+	}
+
+	toks := make([]AppToken, len(exp.Tokens))
+	for i, tok := range exp.Tokens {
+		toks[i] = toAppToken(tok)
+	}
+
+	return AppExport{
+		User:         toAppUser(exp.User),
+		Products:     prds,
+		Orders:       ords,
+		Returns:      rets,
+		Sessions:     sessions,
+		Identities:   idts,
+		Tokens:       toks,
+		Changes:      toAppEntries(exp.Changes),
+		Actions:      toAppEntries(exp.Actions),
+		DateExported: exp.DateExported.Format(time.RFC3339),
+	}
+}
+
+// =============================================================================
+
+// AppUser represents the exported information about a user.
+type AppUser struct {
+	ID           string   `json:"id"`
+	Name         string   `json:"name"`
+	Email        string   `json:"email"`
+	Roles        []string `json:"roles"`
+	DepartmentID string   `json:"departmentId"`
+	Enabled      bool     `json:"enabled"`
+	DateCreated  string   `json:"dateCreated"`
+	DateUpdated  string   `json:"dateUpdated"`
+	DateDeleted  string   `json:"dateDeleted,omitempty"`
+	DateErased   string   `json:"dateErased,omitempty"`
+}
+
+// formatDepartment renders the department id, leaving it empty when the user
+// doesn't belong to a department.
+func formatDepartment(departmentID uuid.UUID) string {
+	if departmentID == uuid.Nil {
+		return ""
+	}
+	return departmentID.String()
+}
+
+func toAppUser(usr user.User) AppUser {
+	roles := make([]string, len(usr.Roles))
+	for i, role := range usr.Roles {
+		roles[i] = role.Name()
+	}
+
+	return AppUser{
+		ID:           usr.ID.String(),
+		Name:         usr.Name,
+		Email:        usr.Email.Address,
+		Roles:        roles,
+		DepartmentID: formatDepartment(usr.DepartmentID),
+		Enabled:      usr.Enabled,
+		DateCreated:  usr.DateCreated.Format(time.RFC3339),
+		DateUpdated:  usr.DateUpdated.Format(time.RFC3339),
+		DateDeleted:  formatOptional(usr.DateDeleted),
+		DateErased:   formatOptional(usr.DateErased),
+	}
+}
+
+// AppProduct represents the exported information about a product.
+type AppProduct struct {
+	ID          string      `json:"id"`
+	Name        string      `json:"name"`
+	Cost        money.Money `json:"cost"`
+	Quantity    int         `json:"quantity"`
+	DateCreated string      `json:"dateCreated"`
+	DateUpdated string      `json:"dateUpdated"`
+	DateDeleted string      `json:"dateDeleted,omitempty"`
+}
+
+func toAppProduct(prd product.Product) AppProduct {
+	return AppProduct{
+		ID:          prd.ID.String(),
+		Name:        prd.Name,
+		Cost:        prd.Cost,
+		Quantity:    prd.Quantity,
+		DateCreated: prd.DateCreated.Format(time.RFC3339),
+		DateUpdated: prd.DateUpdated.Format(time.RFC3339),
+		DateDeleted: formatOptional(prd.DateDeleted),
+	}
+}
+
+// AppOrder represents the exported information about an order.
+type AppOrder struct {
+	ID           string      `json:"id"`
+	Status       string      `json:"status"`
+	Lines        []AppLine   `json:"lines"`
+	Discount     money.Money `json:"discount"`
+	Total        money.Money `json:"total"`
+	Refunded     money.Money `json:"refunded"`
+	Jurisdiction string      `json:"jurisdiction,omitempty"`
+	Tax          money.Money `json:"tax"`
+	DateCreated  string      `json:"dateCreated"`
+	DateUpdated  string      `json:"dateUpdated"`
+}
+
+// AppLine represents the exported information about a line of an order.
+type AppLine struct {
+	Number    int         `json:"number"`
+	ProductID string      `json:"productId,omitempty"`
+	Name      string      `json:"name"`
+	Quantity  int         `json:"quantity"`
+	UnitCost  money.Money `json:"unitCost"`
+	Total     money.Money `json:"total"`
+	Returned  int         `json:"returned"`
+	Refunded  money.Money `json:"refunded"`
+}
+
+func toAppOrder(ord salesorder.Order) AppOrder {
+	lines := make([]AppLine, len(ord.Lines))
+	for i, ln := range ord.Lines {
+		var productID string
+		if ln.ProductID != uuid.Nil {
+			productID = ln.ProductID.String()
+		}
+
+		lines[i] = AppLine{
+			Number:    ln.Number,
+			ProductID: productID,
+			Name:      ln.Name,
+			Quantity:  ln.Quantity,
+			UnitCost:  ln.UnitCost,
+			Total:     ln.Total,
+			Returned:  ln.Returned,
+			Refunded:  ln.Refunded,
+		}
+	}
+
+	return AppOrder{
+		ID:           ord.ID.String(),
+		Status:       ord.Status.Name(),
+		Lines:        lines,
+		Discount:     ord.Discount,
+		Total:        ord.Total,
+		Refunded:     ord.Refunded,
+		Jurisdiction: ord.Jurisdiction,
+		Tax:          ord.Tax,
+		DateCreated:  ord.DateCreated.Format(time.RFC3339),
+		DateUpdated:  ord.DateUpdated.Format(time.RFC3339),
+	}
+}
+
+// AppReturn represents the exported information about goods sent back from
+// an order.
+type AppReturn struct {
+	ID          string          `json:"id"`
+	OrderID     string          `json:"orderId"`
+	Reason      string          `json:"reason"`
+	Lines       []AppReturnLine `json:"lines"`
+	Amount      money.Money     `json:"amount"`
+	DateCreated string          `json:"dateCreated"`
+}
+
+// AppReturnLine represents the quantity returned of a line and the amount
+// refunded for it.
+type AppReturnLine struct {
+	LineNumber int         `json:"lineNumber"`
+	Quantity   int         `json:"quantity"`
+	Amount     money.Money `json:"amount"`
+}
+
+func toAppReturn(ret salesorder.Return) AppReturn {
+	lines := make([]AppReturnLine, len(ret.Lines))
+	for i, rl := range ret.Lines {
+		lines[i] = AppReturnLine{
+			LineNumber: rl.LineNumber,
+			Quantity:   rl.Quantity,
+			Amount:     rl.Amount,
+		}
+	}
+
+	return AppReturn{
+		ID:          ret.ID.String(),
+		OrderID:     ret.OrderID.String(),
+		Reason:      ret.Reason.Name(),
+		Lines:       lines,
+		Amount:      ret.Amount,
+		DateCreated: ret.DateCreated.Format(time.RFC3339),
+	}
+}
+
+// AppSession represents the exported information about a session. The hash
+// of its secret is left out.
+type AppSession struct {
+	ID           string `json:"id"`
+	UserAgent    string `json:"userAgent"`
+	IPAddress    string `json:"ipAddress"`
+	DateCreated  string `json:"dateCreated"`
+	DateLastSeen string `json:"dateLastSeen"`
+	DateExpires  string `json:"dateExpires"`
+	DateRevoked  string `json:"dateRevoked,omitempty"`
+}
+
+func toAppSession(sess session.Session) AppSession {
+	return AppSession{
+		ID:           sess.ID.String(),
+		UserAgent:    sess.UserAgent,
+		IPAddress:    sess.IPAddress,
+		DateCreated:  sess.DateCreated.Format(time.RFC3339),
+		DateLastSeen: sess.DateLastSeen.Format(time.RFC3339),
+		DateExpires:  sess.DateExpires.Format(time.RFC3339),
+		DateRevoked:  formatOptional(sess.DateRevoked),
+	}
+}
+
+// AppIdentity represents the exported information about an identity linked
+// to a user.
+type AppIdentity struct {
+	Issuer      string `json:"issuer"`
+	Subject     string `json:"subject"`
+	DateCreated string `json:"dateCreated"`
+}
+
+func toAppIdentity(idt identity.Identity) AppIdentity {
+	return AppIdentity{
+		Issuer:      idt.Issuer,
+		Subject:     idt.Subject,
+		DateCreated: idt.DateCreated.Format(time.RFC3339),
+	}
+}
+
+// AppToken represents the exported information about an API token issued to
+// a user.
+type AppToken struct {
+	ID          string `json:"id"`
+	Issuer      string `json:"issuer"`
+	DateCreated string `json:"dateCreated"`
+	DateExpires string `json:"dateExpires"`
+}
+
+func toAppToken(tok apitoken.Token) AppToken {
+	return AppToken{
+		ID:          tok.ID.String(),
+		Issuer:      tok.Issuer,
+		DateCreated: tok.DateCreated.Format(time.RFC3339),
+		DateExpires: tok.DateExpires.Format(time.RFC3339),
+	}
+}
+
+// AppEntry represents an exported audit entry.
+type AppEntry struct {
+	ID          string          `json:"id"`
+	ActorID     string          `json:"actorId,omitempty"`
+	Action      string          `json:"action"`
+	EntityType  string          `json:"entityType"`
+	EntityID    string          `json:"entityId"`
+	Diff        json.RawMessage `json:"diff"`
+	DateCreated string          `json:"dateCreated"`
+}
+
+func toAppEntries(entries []audit.Entry) []AppEntry {
+	items := make([]AppEntry, len(entries))
+	for i, e := range entries {
+		var actorID string
+		if e.ActorID != uuid.Nil {
+			actorID = e.ActorID.String()
+		}
+
+		items[i] = AppEntry{
+			ID:          e.ID.String(),
+			ActorID:     actorID,
+			Action:      e.Action,
+			EntityType:  e.EntityType,
+			EntityID:    e.EntityID.String(),
+			Diff:        e.Diff,
+			DateCreated: e.DateCreated.Format(time.RFC3339),
+		}
+	}
+	return items
+}
+
+func formatOptional(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
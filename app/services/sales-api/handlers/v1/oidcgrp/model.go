@@ -0,0 +1,44 @@
+package oidcgrp
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+)
+
+// AppToken represents the token issued at the end of the flow.
+type AppToken struct {
+	Token string `json:"token"`
+}
+
+// =============================================================================
+
+// loginState is kept in a cookie between the redirect to the provider and
+// the callback. It ties the callback to the browser that started the flow.
+type loginState struct {
+	State    string `json:"state"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+}
+
+func (ls loginState) encode() (string, error) {
+	data, err := json.Marshal(ls)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeLoginState(value string) (loginState, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return loginState{}, fmt.Errorf("decoding login state: %w", err)
+	}
+
+	var ls loginState
+	if err := json.Unmarshal(data, &ls); err != nil {
+		return loginState{}, fmt.Errorf("unmarshaling login state: %w", err)
+	}
+
+	return ls, nil
+}
@@ -0,0 +1,207 @@
+// Package oidcgrp maintains the group of handlers for signing in through an
+// OpenID Connect provider.
+package oidcgrp
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"time"
+
+	"github.com/aleury/service/business/core/apitoken"
+	"github.com/aleury/service/business/core/identity"
+	"github.com/aleury/service/business/core/tenant"
+	"github.com/aleury/service/business/core/user"
+	"github.com/aleury/service/business/web/auth"
+	v1 "github.com/aleury/service/business/web/v1"
+	"github.com/aleury/service/foundation/oidc"
+	"github.com/aleury/service/foundation/web"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+)
+
+// Set of error variables for the login flow.
+var (
+	ErrInvalidState = errors.New("login state is missing or does not match")
+)
+
+const (
+	stateCookie = "oidc_login"
+	stateTTL    = 10 * time.Minute
+)
+
+// Config contains what the handlers need to sign users in.
+type Config struct {
+	Provider  *oidc.Provider
+	Identity  *identity.Core
+	APIToken  *apitoken.Core
+	Auth      *auth.Auth
+	ActiveKID string
+	Issuer    string
+	TenantID  uuid.UUID
+	TokenTTL  time.Duration
+}
+
+// Handlers manages the set of OIDC endpoints.
+type Handlers struct {
+	cfg Config
+}
+
+// New constructs a handlers for route access.
+func New(cfg Config) *Handlers {
+	return &Handlers{
+		cfg: cfg,
+	}
+}
+
+// Login starts the authorization code flow by redirecting the browser to the
+// provider.
+func (h *Handlers) Login(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	var ls loginState
+	var err error
+
+	if ls.State, err = oidc.NewRandom(); err != nil {
+		return err
+	}
+	if ls.Nonce, err = oidc.NewRandom(); err != nil {
+		return err
+	}
+	if ls.Verifier, err = oidc.NewRandom(); err != nil {
+		return err
+	}
+
+	authURL, err := h.cfg.Provider.AuthCodeURL(ctx, ls.State, ls.Nonce, ls.Verifier)
+	if err != nil {
+		return fmt.Errorf("authcodeurl: %w", err)
+	}
+
+	value, err := ls.encode()
+	if err != nil {
+		return fmt.Errorf("encode: %w", err)
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     stateCookie,
+		Value:    value,
+		Path:     "/oidc",
+		MaxAge:   int(stateTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	web.SetStatusCode(ctx, http.StatusFound)
+	http.Redirect(w, r, authURL, http.StatusFound)
+
+	return nil
+}
+
+// Callback completes the flow. The code is exchanged for an ID token, the
+// external subject is resolved to a local user, and a token for this API is
+// issued.
+func (h *Handlers) Callback(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	cookie, err := r.Cookie(stateCookie)
+	if err != nil {
+		return v1.NewRequestError(ErrInvalidState, http.StatusBadRequest)
+	}
+
+	// The state can only be used once.
+	http.SetCookie(w, &http.Cookie{
+		Name:     stateCookie,
+		Path:     "/oidc",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	ls, err := decodeLoginState(cookie.Value)
+	if err != nil {
+		return v1.NewRequestError(ErrInvalidState, http.StatusBadRequest)
+	}
+
+	q := r.URL.Query()
+	if subtle.ConstantTimeCompare([]byte(q.Get("state")), []byte(ls.State)) != 1 {
+		return v1.NewRequestError(ErrInvalidState, http.StatusBadRequest)
+	}
+
+	if errCode := q.Get("error"); errCode != "" {
+		return auth.NewAuthError("oidc: provider returned %s: %s", errCode, q.Get("error_description"))
+	}
+
+	tokens, err := h.cfg.Provider.Exchange(ctx, q.Get("code"), ls.Verifier)
+	if err != nil {
+		return auth.NewAuthError("oidc: exchange: %s", err)
+	}
+
+	idt, err := h.cfg.Provider.Verify(ctx, tokens.IDToken, ls.Nonce)
+	if err != nil {
+		return auth.NewAuthError("oidc: verify: %s", err)
+	}
+
+	addr, err := mail.ParseAddress(idt.Email)
+	if err != nil {
+		return auth.NewAuthError("oidc: id token has no usable email: %s", err)
+	}
+
+	// Users signing in through the provider belong to the tenant it is
+	// configured for.
+	ctx = tenant.SetTenantID(ctx, h.cfg.TenantID)
+
+	ext := identity.External{
+		Issuer:        idt.Issuer,
+		Subject:       idt.Subject,
+		Email:         *addr,
+		EmailVerified: idt.EmailVerified,
+		Name:          idt.Name,
+		TenantID:      h.cfg.TenantID,
+	}
+
+	usr, err := h.cfg.Identity.Resolve(ctx, ext)
+	if err != nil {
+		switch {
+		case errors.Is(err, identity.ErrEmailNotVerified), errors.Is(err, identity.ErrUserDisabled):
+			return auth.NewAuthError("oidc: %s", err)
+		case errors.Is(err, user.ErrUniqueEmail):
+			return v1.NewRequestError(err, http.StatusConflict)
+		default:
+			return fmt.Errorf("resolve: issuer[%s] subject[%s]: %w", idt.Issuer, idt.Subject, err)
+		}
+	}
+
+	// The token is recorded before it is issued so every token the user
+	// holds can be accounted for.
+	nt := apitoken.NewToken{
+		TenantID: usr.TenantID,
+		UserID:   usr.ID,
+		Issuer:   h.cfg.Issuer,
+		TTL:      h.cfg.TokenTTL,
+	}
+
+	tok, err := h.cfg.APIToken.Create(ctx, nt)
+	if err != nil {
+		return fmt.Errorf("createtoken: userID[%s]: %w", usr.ID, err)
+	}
+
+	claims := auth.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tok.ID.String(),
+			Subject:   usr.ID.String(),
+			Issuer:    h.cfg.Issuer,
+			ExpiresAt: jwt.NewNumericDate(tok.DateExpires.UTC()),
+			IssuedAt:  jwt.NewNumericDate(tok.DateCreated.UTC()),
+		},
+		Roles:  usr.Roles,
+		Tenant: usr.TenantID,
+	}
+
+	token, err := h.cfg.Auth.GenerateToken(h.cfg.ActiveKID, claims)
+	if err != nil {
+		return fmt.Errorf("generatetoken: userID[%s]: %w", usr.ID, err)
+	}
+
+	return web.Respond(ctx, w, AppToken{Token: token}, http.StatusOK)
+}
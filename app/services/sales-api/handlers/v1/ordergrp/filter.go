@@ -0,0 +1,70 @@
+package ordergrp
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/aleury/service/business/core/salesorder"
+	"github.com/aleury/service/business/sys/validate"
+	"github.com/google/uuid"
+)
+
+func parseFilter(r *http.Request) (salesorder.QueryFilter, error) {
+	values := r.URL.Query()
+
+	var filter salesorder.QueryFilter
+
+	if orderID := values.Get("order_id"); orderID != "" {
+		id, err := uuid.Parse(orderID)
+		if err != nil {
+			return salesorder.QueryFilter{}, validate.NewFieldsError("order_id", err)
+		}
+		filter.WithOrderID(id)
+	}
+
+	if userID := values.Get("user_id"); userID != "" {
+		id, err := uuid.Parse(userID)
+		if err != nil {
+			return salesorder.QueryFilter{}, validate.NewFieldsError("user_id", err)
+		}
+		filter.WithUserID(id)
+	}
+
+	if productID := values.Get("product_id"); productID != "" {
+		id, err := uuid.Parse(productID)
+		if err != nil {
+			return salesorder.QueryFilter{}, validate.NewFieldsError("product_id", err)
+		}
+		filter.WithProductID(id)
+	}
+
+	if status := values.Get("status"); status != "" {
+		sts, err := salesorder.ParseStatus(status)
+		if err != nil {
+			return salesorder.QueryFilter{}, validate.NewFieldsError("status", err)
+		}
+		filter.WithStatus(sts)
+	}
+
+	if startCreatedDate := values.Get("start_created_date"); startCreatedDate != "" {
+		t, err := time.Parse(time.RFC3339, startCreatedDate)
+		if err != nil {
+			return salesorder.QueryFilter{}, validate.NewFieldsError("start_created_date", err)
+		}
+		filter.WithStartCreatedDate(t)
+	}
+
+	if endCreatedDate := values.Get("end_created_date"); endCreatedDate != "" {
+		t, err := time.Parse(time.RFC3339, endCreatedDate)
+		if err != nil {
+			return salesorder.QueryFilter{}, validate.NewFieldsError("end_created_date", err)
+		}
+		filter.WithEndCreatedDate(t)
+	}
+
+	if err := filter.Validate(); err != nil {
+		return salesorder.QueryFilter{}, err
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,306 @@
+package ordergrp
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aleury/service/business/core/salesorder"
+	"github.com/aleury/service/business/data/money"
+	"github.com/aleury/service/business/sys/validate"
+	"github.com/google/uuid"
+)
+
+// AppOrder represents information about an individual order.
+type AppOrder struct {
+	ID            string          `json:"id"`
+	UserID        string          `json:"userId"`
+	Status        string          `json:"status"`
+	Lines         []AppLine       `json:"lines"`
+	Transitions   []AppTransition `json:"transitions"`
+	Discounts     []AppDiscount   `json:"discounts,omitempty"`
+	TotalQuantity int             `json:"totalQuantity"`
+	Discount      money.Money     `json:"discount"`
+	Total         money.Money     `json:"total"`
+	Refunded      money.Money     `json:"refunded"`
+	Jurisdiction  string          `json:"jurisdiction,omitempty"`
+	TaxInclusive  bool            `json:"taxInclusive"`
+	Tax           money.Money     `json:"tax"`
+	DateCreated   string          `json:"dateCreated"`
+	DateUpdated   string          `json:"dateUpdated"`
+}
+
+// AppLine represents a product sold as part of an order.
+type AppLine struct {
+	Number    int         `json:"number"`
+	ProductID string      `json:"productId"`
+	SKU       string      `json:"sku,omitempty"`
+	Name      string      `json:"name"`
+	Quantity  int         `json:"quantity"`
+	UnitCost  money.Money `json:"unitCost"`
+	Total     money.Money `json:"total"`
+	Tax       money.Money `json:"tax"`
+	Returned  int         `json:"returned"`
+	Refunded  money.Money `json:"refunded"`
+}
+
+// AppDiscount represents the part of a line taken off by a promotion.
+type AppDiscount struct {
+	LineNumber  int         `json:"lineNumber"`
+	PromotionID string      `json:"promotionId,omitempty"`
+	Code        string      `json:"code"`
+	Amount      money.Money `json:"amount"`
+}
+
+// AppTransition represents a change of status of an order.
+type AppTransition struct {
+	From    string `json:"from"`
+	To      string `json:"to"`
+	ActorID string `json:"actorId"`
+	Date    string `json:"date"`
+}
+
+func toAppOrder(ord salesorder.Order) AppOrder {
+	lines := make([]AppLine, len(ord.Lines))
+	for i, ln := range ord.Lines {
+		var productID string
+		if ln.ProductID != uuid.Nil {
+			productID = ln.ProductID.String()
+		}
+
+		lines[i] = AppLine{
+			Number:    ln.Number,
+			ProductID: productID,
+			SKU:       ln.SKU,
+			Name:      ln.Name,
+			Quantity:  ln.Quantity,
+			UnitCost:  ln.UnitCost,
+			Total:     ln.Total,
+			Tax:       ln.Tax,
+			Returned:  ln.Returned,
+			Refunded:  ln.Refunded,
+		}
+	}
+
+	trs := make([]AppTransition, len(ord.Transitions))
+	for i, tr := range ord.Transitions {
+		var actorID string
+		if tr.ActorID != uuid.Nil {
+			actorID = tr.ActorID.String()
+		}
+
+		trs[i] = AppTransition{
+			From:    tr.From.Name(),
+			To:      tr.To.Name(),
+			ActorID: actorID,
+			Date:    tr.Date.Format(time.RFC3339),
+		}
+	}
+
+	var dscs []AppDiscount
+	for _, dsc := range ord.Discounts {
+		var promotionID string
+		if dsc.PromotionID != uuid.Nil {
+			promotionID = dsc.PromotionID.String()
+		}
+
+		dscs = append(dscs, AppDiscount{
+			LineNumber:  dsc.LineNumber,
+			PromotionID: promotionID,
+			Code:        dsc.Code,
+			Amount:      dsc.Amount,
+		})
+	}
+
+	return AppOrder{
+		ID:            ord.ID.String(),
+		UserID:        ord.UserID.String(),
+		Status:        ord.Status.Name(),
+		Lines:         lines,
+		Transitions:   trs,
+		Discounts:     dscs,
+		TotalQuantity: ord.TotalQuantity,
+		Discount:      ord.Discount,
+		Total:         ord.Total,
+		Refunded:      ord.Refunded,
+		Jurisdiction:  ord.Jurisdiction,
+		TaxInclusive:  ord.TaxInclusive,
+		Tax:           ord.Tax,
+		DateCreated:   ord.DateCreated.Format(time.RFC3339),
+		DateUpdated:   ord.DateUpdated.Format(time.RFC3339),
+	}
+}
+
+func toAppOrders(ords []salesorder.Order) []AppOrder {
+	items := make([]AppOrder, len(ords))
+	for i, ord := range ords {
+		items[i] = toAppOrder(ord)
+	}
+	return items
+}
+
+// =============================================================================
+
+// AppNewOrder is what we require from clients when placing an order. A
+// promotion code and the code of the tax jurisdiction are optional.
+type AppNewOrder struct {
+	Lines         []AppNewLine `json:"lines" validate:"required,min=1,dive"`
+	PromotionCode string       `json:"promotionCode" validate:"omitempty,max=64"`
+	Jurisdiction  string       `json:"jurisdiction"`
+}
+
+// AppNewLine is a product and the quantity of it being ordered, optionally
+// paid for with a reservation. The product is given by its ID or its SKU.
+type AppNewLine struct {
+	ProductID     string `json:"productId" validate:"required_without=SKU,omitempty,uuid"`
+	SKU           string `json:"sku" validate:"required_without=ProductID"`
+	Quantity      int    `json:"quantity" validate:"required,gte=1"`
+	ReservationID string `json:"reservationId" validate:"omitempty,uuid"`
+}
+
+func toCoreNewOrder(app AppNewOrder, userID uuid.UUID, tenantID uuid.UUID) (salesorder.NewOrder, error) {
+	lines := make([]salesorder.NewLine, len(app.Lines))
+	for i, ln := range app.Lines {
+		var productID uuid.UUID
+		var err error
+		if ln.ProductID != "" {
+			productID, err = uuid.Parse(ln.ProductID)
+			if err != nil {
+				return salesorder.NewOrder{}, fmt.Errorf("parsing productId: line[%d]: %w", i+1, err)
+			}
+		}
+
+		var reservationID uuid.UUID
+		if ln.ReservationID != "" {
+			reservationID, err = uuid.Parse(ln.ReservationID)
+			if err != nil {
+				return salesorder.NewOrder{}, fmt.Errorf("parsing reservationId: line[%d]: %w", i+1, err)
+			}
+		}
+
+		lines[i] = salesorder.NewLine{
+			ProductID:     productID,
+			SKU:           ln.SKU,
+			Quantity:      ln.Quantity,
+			ReservationID: reservationID,
+		}
+	}
+
+	no := salesorder.NewOrder{
+		UserID:        userID,
+		TenantID:      tenantID,
+		Lines:         lines,
+		PromotionCode: app.PromotionCode,
+		Jurisdiction:  app.Jurisdiction,
+	}
+	return no, nil
+}
+
+// Validate checks the data in the model is considered clean.
+func (app AppNewOrder) Validate() error {
+	if err := validate.Check(app); err != nil {
+		return err
+	}
+	return nil
+}
+
+// =============================================================================
+
+// AppReturn represents goods sent back from an order and the refund given
+// for them.
+type AppReturn struct {
+	ID          string          `json:"id"`
+	OrderID     string          `json:"orderId"`
+	Reason      string          `json:"reason"`
+	Restock     bool            `json:"restock"`
+	Lines       []AppReturnLine `json:"lines"`
+	Amount      money.Money     `json:"amount"`
+	ActorID     string          `json:"actorId,omitempty"`
+	DateCreated string          `json:"dateCreated"`
+}
+
+// AppReturnLine represents the quantity returned of a line and the amount
+// refunded for it.
+type AppReturnLine struct {
+	LineNumber int         `json:"lineNumber"`
+	Quantity   int         `json:"quantity"`
+	Amount     money.Money `json:"amount"`
+}
+
+func toAppReturn(ret salesorder.Return) AppReturn {
+	lines := make([]AppReturnLine, len(ret.Lines))
+	for i, rl := range ret.Lines {
+		lines[i] = AppReturnLine{
+			LineNumber: rl.LineNumber,
+			Quantity:   rl.Quantity,
+			Amount:     rl.Amount,
+		}
+	}
+
+	var actorID string
+	if ret.ActorID != uuid.Nil {
+		actorID = ret.ActorID.String()
+	}
+
+	return AppReturn{
+		ID:          ret.ID.String(),
+		OrderID:     ret.OrderID.String(),
+		Reason:      ret.Reason.Name(),
+		Restock:     ret.Restock,
+		Lines:       lines,
+		Amount:      ret.Amount,
+		ActorID:     actorID,
+		DateCreated: ret.DateCreated.Format(time.RFC3339),
+	}
+}
+
+func toAppReturns(rets []salesorder.Return) []AppReturn {
+	items := make([]AppReturn, len(rets))
+	for i, ret := range rets {
+		items[i] = toAppReturn(ret)
+	}
+	return items
+}
+
+// AppNewReturn is what we require to return goods from an order. Restocked
+// goods are put back into the stock of their products.
+type AppNewReturn struct {
+	Reason  string             `json:"reason" validate:"required"`
+	Restock bool               `json:"restock"`
+	Lines   []AppNewReturnLine `json:"lines" validate:"required,min=1,dive"`
+}
+
+// AppNewReturnLine is the quantity of a line of the order being returned.
+type AppNewReturnLine struct {
+	LineNumber int `json:"lineNumber" validate:"required,gte=1"`
+	Quantity   int `json:"quantity" validate:"required,gte=1"`
+}
+
+func toCoreNewReturn(app AppNewReturn) (salesorder.NewReturn, error) {
+	reason, err := salesorder.ParseReturnReason(app.Reason)
+	if err != nil {
+		return salesorder.NewReturn{}, fmt.Errorf("parsing reason: %w", err)
+	}
+
+	lines := make([]salesorder.NewReturnLine, len(app.Lines))
+	for i, ln := range app.Lines {
+		lines[i] = salesorder.NewReturnLine{
+			LineNumber: ln.LineNumber,
+			Quantity:   ln.Quantity,
+		}
+	}
+
+	nr := salesorder.NewReturn{
+		Reason:  reason,
+		Restock: app.Restock,
+		Lines:   lines,
+	}
+	return nr, nil
+}
+
+// Validate checks the data in the model is considered clean.
+func (app AppNewReturn) Validate() error {
+	if err := validate.Check(app); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+// Package ordergrp maintains the group of handlers for order access.
+package ordergrp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/aleury/service/business/core/product"
+	"github.com/aleury/service/business/core/promotion"
+	"github.com/aleury/service/business/core/reservation"
+	"github.com/aleury/service/business/core/salesorder"
+	"github.com/aleury/service/business/core/tax"
+	"github.com/aleury/service/business/core/tenant"
+	"github.com/aleury/service/business/core/user"
+	"github.com/aleury/service/business/data/money"
+	"github.com/aleury/service/business/web/auth"
+	v1 "github.com/aleury/service/business/web/v1"
+	"github.com/aleury/service/business/web/v1/mid"
+	"github.com/aleury/service/business/web/v1/paging"
+	"github.com/aleury/service/foundation/web"
+	"github.com/google/uuid"
+)
+
+// Handlers manages the set of order endpoints.
+type Handlers struct {
+	order *salesorder.Core
+}
+
+// New constructs a handlers for route access.
+func New(order *salesorder.Core) *Handlers {
+	return &Handlers{
+		order: order,
+	}
+}
+
+// Create places an order for the authenticated user.
+func (h *Handlers) Create(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	var app AppNewOrder
+	if err := web.Decode(r, &app); err != nil {
+		return err
+	}
+
+	userID, err := uuid.Parse(auth.GetClaims(ctx).Subject)
+	if err != nil {
+		return v1.NewRequestError(mid.ErrInvalidID, http.StatusBadRequest)
+	}
+
+	no, err := toCoreNewOrder(app, userID, tenant.GetTenantID(ctx))
+	if err != nil {
+		return v1.NewRequestError(err, http.StatusBadRequest)
+	}
+
+	ord, err := h.order.Create(ctx, no)
+	if err != nil {
+		switch {
+		case errors.Is(err, product.ErrNotFound), errors.Is(err, salesorder.ErrEmptyOrder),
+			errors.Is(err, reservation.ErrNotFound), errors.Is(err, salesorder.ErrMismatch),
+			errors.Is(err, promotion.ErrNotFound), errors.Is(err, promotion.ErrInactive),
+			errors.Is(err, promotion.ErrMinOrder), errors.Is(err, promotion.ErrNotApplicable),
+			errors.Is(err, tax.ErrNotFound), errors.Is(err, tax.ErrNoRate),
+			errors.Is(err, money.ErrOverflow), errors.Is(err, money.ErrCurrencyMismatch):
+			return v1.NewRequestError(err, http.StatusBadRequest)
+		case errors.Is(err, product.ErrInsufficientStock), errors.Is(err, product.ErrHasVariants),
+			errors.Is(err, reservation.ErrNotHeld), errors.Is(err, promotion.ErrUsedUp):
+			return v1.NewRequestError(err, http.StatusConflict)
+		default:
+			return fmt.Errorf("create: app[%+v]: %w", app, err)
+		}
+	}
+
+	return web.Respond(ctx, w, toAppOrder(ord), http.StatusCreated)
+}
+
+// Query returns a list of orders with paging. Users other than admins only
+// see their own orders.
+func (h *Handlers) Query(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	page, err := paging.ParseRequest(r)
+	if err != nil {
+		return err
+	}
+
+	filter, err := parseFilter(r)
+	if err != nil {
+		return err
+	}
+
+	if claims := auth.GetClaims(ctx); !claims.HasRole(user.RoleAdmin) {
+		userID, err := uuid.Parse(claims.Subject)
+		if err != nil {
+			return v1.NewRequestError(mid.ErrInvalidID, http.StatusBadRequest)
+		}
+		if filter.UserID != nil && *filter.UserID != userID {
+			return v1.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+		}
+		filter.WithUserID(userID)
+	}
+
+	orderBy, err := parseOrder(r)
+	if err != nil {
+		return err
+	}
+
+	ords, err := h.order.Query(ctx, filter, orderBy, page.Number, page.RowsPerPage)
+	if err != nil {
+		return fmt.Errorf("query: %w", err)
+	}
+
+	total, err := h.order.Count(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("count: %w", err)
+	}
+
+	return web.Respond(ctx, w, paging.NewResponse(toAppOrders(ords), total, page.Number, page.RowsPerPage), http.StatusOK)
+}
+
+// QueryByID returns the order loaded by the authorization middleware.
+func (h *Handlers) QueryByID(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	return web.Respond(ctx, w, toAppOrder(mid.GetOrder(ctx)), http.StatusOK)
+}
+
+// Transition returns a handler that moves the order loaded by the
+// authorization middleware to the specified status.
+func (h *Handlers) Transition(to salesorder.Status) web.Handler {
+	return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		ord := mid.GetOrder(ctx)
+
+		updOrd, err := h.order.Transition(ctx, ord, to)
+		if err != nil {
+			switch {
+			case salesorder.IsTransitionError(err):
+				return v1.NewRequestError(err, http.StatusConflict)
+			default:
+				return fmt.Errorf("transition: orderID[%s] to[%s]: %w", ord.ID, to.Name(), err)
+			}
+		}
+
+		return web.Respond(ctx, w, toAppOrder(updOrd), http.StatusOK)
+	}
+}
+
+// Return takes goods back from the order loaded by the authorization
+// middleware and refunds them.
+func (h *Handlers) Return(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	var app AppNewReturn
+	if err := web.Decode(r, &app); err != nil {
+		return err
+	}
+
+	nr, err := toCoreNewReturn(app)
+	if err != nil {
+		return v1.NewRequestError(err, http.StatusBadRequest)
+	}
+
+	ord := mid.GetOrder(ctx)
+
+	_, ret, err := h.order.Return(ctx, ord, nr)
+	if err != nil {
+		switch {
+		case errors.Is(err, salesorder.ErrEmptyReturn), errors.Is(err, salesorder.ErrReturnLine),
+			errors.Is(err, salesorder.ErrReturnQuantity):
+			return v1.NewRequestError(err, http.StatusBadRequest)
+		case errors.Is(err, salesorder.ErrNotReturnable), errors.Is(err, salesorder.ErrConflict):
+			return v1.NewRequestError(err, http.StatusConflict)
+		default:
+			return fmt.Errorf("return: orderID[%s] app[%+v]: %w", ord.ID, app, err)
+		}
+	}
+
+	return web.Respond(ctx, w, toAppReturn(ret), http.StatusCreated)
+}
+
+// QueryReturns returns the returns taken against the order loaded by the
+// authorization middleware.
+func (h *Handlers) QueryReturns(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	ord := mid.GetOrder(ctx)
+
+	rets, err := h.order.QueryReturns(ctx, ord.ID)
+	if err != nil {
+		return fmt.Errorf("queryreturns: orderID[%s]: %w", ord.ID, err)
+	}
+
+	return web.Respond(ctx, w, toAppReturns(rets), http.StatusOK)
+}
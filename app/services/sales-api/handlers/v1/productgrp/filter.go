@@ -0,0 +1,164 @@
+package productgrp
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aleury/service/business/core/product"
+	"github.com/aleury/service/business/data/money"
+	"github.com/aleury/service/business/sys/validate"
+	"github.com/google/uuid"
+)
+
+func parseFilter(r *http.Request) (product.QueryFilter, error) {
+	values := r.URL.Query()
+
+	var filter product.QueryFilter
+
+	if productID := values.Get("product_id"); productID != "" {
+		id, err := uuid.Parse(productID)
+		if err != nil {
+			return product.QueryFilter{}, validate.NewFieldsError("product_id", err)
+		}
+		filter.WithProductID(id)
+	}
+
+	if name := values.Get("name"); name != "" {
+		filter.WithName(name)
+	}
+
+	if text := values.Get("q"); text != "" {
+		filter.WithSearch(text)
+	}
+
+	if cost := values.Get("cost"); cost != "" {
+		cst, err := money.Parse(cost, money.DefaultCurrency)
+		if err != nil {
+			return product.QueryFilter{}, validate.NewFieldsError("cost", err)
+		}
+		filter.WithCost(cst)
+	}
+
+	if quantity := values.Get("quantity"); quantity != "" {
+		qua, err := strconv.Atoi(quantity)
+		if err != nil {
+			return product.QueryFilter{}, validate.NewFieldsError("quantity", err)
+		}
+		filter.WithQuantity(qua)
+	}
+
+	if userID := values.Get("user_id"); userID != "" {
+		id, err := uuid.Parse(userID)
+		if err != nil {
+			return product.QueryFilter{}, validate.NewFieldsError("user_id", err)
+		}
+		filter.WithUserID(id)
+	}
+
+	if categoryID := values.Get("category_id"); categoryID != "" {
+		id, err := uuid.Parse(categoryID)
+		if err != nil {
+			return product.QueryFilter{}, validate.NewFieldsError("category_id", err)
+		}
+		filter.WithCategoryID(id)
+	}
+
+	if parentID := values.Get("parent_id"); parentID != "" {
+		id, err := uuid.Parse(parentID)
+		if err != nil {
+			return product.QueryFilter{}, validate.NewFieldsError("parent_id", err)
+		}
+		filter.WithParentID(id)
+	}
+
+	// Variants are listed under their product unless asked for directly.
+	if filter.ID == nil && filter.ParentID == nil {
+		filter.WithParentID(uuid.Nil)
+	}
+
+	if includeDeleted := values.Get("include_deleted"); includeDeleted != "" {
+		inc, err := strconv.ParseBool(includeDeleted)
+		if err != nil {
+			return product.QueryFilter{}, validate.NewFieldsError("include_deleted", err)
+		}
+		filter.WithIncludeDeleted(inc)
+	}
+
+	if err := filter.Validate(); err != nil {
+		return product.QueryFilter{}, err
+	}
+
+	return filter, nil
+}
+
+// parseHighlight reports whether the request asks for the words matching
+// the search text to be marked in snippets. It only applies to searches.
+func parseHighlight(r *http.Request) (bool, error) {
+	values := r.URL.Query()
+
+	highlight := values.Get("highlight")
+	if highlight == "" {
+		return false, nil
+	}
+
+	hl, err := strconv.ParseBool(highlight)
+	if err != nil {
+		return false, validate.NewFieldsError("highlight", err)
+	}
+
+	if hl && values.Get("q") == "" {
+		return false, validate.NewFieldsError("highlight", errors.New("requires a search text in q"))
+	}
+
+	return hl, nil
+}
+
+// parseEmbed reports whether the request asks for the variants of the
+// products to be embedded in the response.
+func parseEmbed(r *http.Request) (bool, error) {
+	embed := r.URL.Query().Get("embed")
+	switch embed {
+	case "":
+		return false, nil
+	case "variants":
+		return true, nil
+	default:
+		return false, validate.NewFieldsError("embed", fmt.Errorf("unknown value %q", embed))
+	}
+}
+
+// parseAsOf parses the optional asOf query parameter. A zero time is returned
+// when the parameter is not provided.
+func parseAsOf(r *http.Request) (time.Time, error) {
+	asOf := r.URL.Query().Get("asOf")
+	if asOf == "" {
+		return time.Time{}, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, asOf)
+	if err != nil {
+		return time.Time{}, validate.NewFieldsError("asOf", err)
+	}
+
+	return t, nil
+}
+
+// parseCurrency reads the currency prices should be quoted in. It reports
+// false when the request doesn't ask for one.
+func parseCurrency(r *http.Request) (money.Currency, bool, error) {
+	code := r.URL.Query().Get("currency")
+	if code == "" {
+		return money.Currency{}, false, nil
+	}
+
+	currency, err := money.ParseCurrency(strings.ToUpper(code))
+	if err != nil {
+		return money.Currency{}, false, validate.NewFieldsError("currency", err)
+	}
+
+	return currency, true, nil
+}
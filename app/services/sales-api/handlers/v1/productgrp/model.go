@@ -0,0 +1,531 @@
+package productgrp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aleury/service/business/core/product"
+	"github.com/aleury/service/business/data/money"
+	"github.com/aleury/service/business/sys/validate"
+	"github.com/google/uuid"
+)
+
+// errCostCurrency is reported for a cost in a currency other than the one
+// products are costed in. Prices in other currencies are set separately.
+var errCostCurrency = fmt.Errorf("must be in %s", money.DefaultCurrency.Code())
+
+// AppProduct represents an individual product.
+type AppProduct struct {
+	ID               string      `json:"id"`
+	Name             string      `json:"name"`
+	Cost             money.Money `json:"cost"`
+	Quantity         int         `json:"quantity"`
+	Sold             int         `json:"sold"`
+	Revenue          money.Money `json:"revenue"`
+	UserID           string      `json:"userId"`
+	TaxClass         string      `json:"taxClass"`
+	ReorderThreshold int         `json:"reorderThreshold"`
+	Version          int         `json:"version,omitempty"`
+	DateCreated      string      `json:"dateCreated"`
+	DateUpdated      string      `json:"dateUpdated"`
+	Price            *AppQuote   `json:"price,omitempty"`
+
+	ParentID     string            `json:"parentId,omitempty"`
+	SKU          string            `json:"sku,omitempty"`
+	Options      []string          `json:"options,omitempty"`
+	OptionValues map[string]string `json:"optionValues,omitempty"`
+	Variants     []AppProduct      `json:"variants,omitempty"`
+	Snippet      string            `json:"snippet,omitempty"`
+}
+
+func toAppProduct(prd product.Product) AppProduct {
+	var parentID string
+	if prd.ParentID != uuid.Nil {
+		parentID = prd.ParentID.String()
+	}
+
+	return AppProduct{
+		ID:               prd.ID.String(),
+		Name:             prd.Name,
+		Cost:             prd.Cost,
+		Quantity:         prd.Quantity,
+		Sold:             prd.Sold,
+		Revenue:          prd.Revenue,
+		UserID:           prd.UserID.String(),
+		TaxClass:         prd.TaxClass,
+		ReorderThreshold: prd.ReorderThreshold,
+		Version:          prd.Version,
+		DateCreated:      prd.DateCreated.Format(time.RFC3339),
+		DateUpdated:      prd.DateUpdated.Format(time.RFC3339),
+
+		ParentID:     parentID,
+		SKU:          prd.SKU,
+		Options:      prd.Options,
+		OptionValues: prd.OptionValues,
+	}
+}
+
+func toAppProducts(prds []product.Product) []AppProduct {
+	items := make([]AppProduct, len(prds))
+	for i, prd := range prds {
+		items[i] = toAppProduct(prd)
+	}
+	return items
+}
+
+// AppQuote represents what a product sells for in a requested currency.
+type AppQuote struct {
+	Amount    money.Money `json:"amount"`
+	Currency  string      `json:"currency"`
+	Converted bool        `json:"converted"`
+	Rate      *AppRate    `json:"rate,omitempty"`
+}
+
+// AppRate represents the exchange rate an amount was converted at.
+type AppRate struct {
+	Base        string     `json:"base"`
+	Quote       string     `json:"quote"`
+	Rate        money.Rate `json:"rate"`
+	DateUpdated string     `json:"dateUpdated"`
+}
+
+func toAppQuote(qte product.Quote) *AppQuote {
+	app := AppQuote{
+		Amount:    qte.Amount,
+		Currency:  qte.Amount.Currency().Code(),
+		Converted: qte.Converted,
+	}
+
+	if qte.Converted {
+		app.Rate = &AppRate{
+			Base:        qte.Rate.Base.Code(),
+			Quote:       qte.Rate.Quote.Code(),
+			Rate:        qte.Rate.Rate,
+			DateUpdated: qte.Rate.DateUpdated.Format(time.RFC3339),
+		}
+	}
+
+	return &app
+}
+
+// AppPrice represents an explicit price of a product.
+type AppPrice struct {
+	Amount      money.Money `json:"amount"`
+	Currency    string      `json:"currency"`
+	DateUpdated string      `json:"dateUpdated"`
+}
+
+func toAppPrice(price product.Price) AppPrice {
+	return AppPrice{
+		Amount:      price.Amount,
+		Currency:    price.Amount.Currency().Code(),
+		DateUpdated: price.DateUpdated.Format(time.RFC3339),
+	}
+}
+
+func toAppPrices(prices []product.Price) []AppPrice {
+	items := make([]AppPrice, len(prices))
+	for i, price := range prices {
+		items[i] = toAppPrice(price)
+	}
+	return items
+}
+
+// AppVersion represents a recorded version of a product.
+type AppVersion struct {
+	Version   int        `json:"version"`
+	ValidFrom string     `json:"validFrom"`
+	Product   AppProduct `json:"product"`
+}
+
+func toAppVersions(versions []product.Version) []AppVersion {
+	items := make([]AppVersion, len(versions))
+	for i, ver := range versions {
+		items[i] = AppVersion{
+			Version:   ver.Number,
+			ValidFrom: ver.ValidFrom.Format(time.RFC3339),
+			Product:   toAppProduct(ver.Product),
+		}
+	}
+	return items
+}
+
+// =============================================================================
+
+// AppNewProduct is what we require from clients when adding a Product. A
+// product with options is stocked through its variants and takes no
+// quantity of its own.
+type AppNewProduct struct {
+	Name             string      `json:"name" validate:"required"`
+	Cost             money.Money `json:"cost"`
+	Quantity         int         `json:"quantity" validate:"required_without=Options,omitempty,gte=1"`
+	SKU              string      `json:"sku"`
+	Options          []string    `json:"options" validate:"omitempty,unique,dive,required"`
+	TaxClass         string      `json:"taxClass" validate:"omitempty,max=32"`
+	ReorderThreshold int         `json:"reorderThreshold" validate:"gte=0"`
+}
+
+func toCoreNewProduct(app AppNewProduct, userID uuid.UUID) product.NewProduct {
+	return product.NewProduct{
+		Name:     app.Name,
+		Cost:     app.Cost,
+		Quantity: app.Quantity,
+		UserID:   userID,
+		SKU:      app.SKU,
+		Options:  app.Options,
+		TaxClass: app.TaxClass,
+
+		ReorderThreshold: app.ReorderThreshold,
+	}
+}
+
+// Validate checks the data in the model is considered clean.
+func (app AppNewProduct) Validate() error {
+	if err := validate.Check(app); err != nil {
+		return err
+	}
+
+	switch {
+	case app.Cost.Equal(money.Money{}):
+		return validate.NewFieldsError("cost", errors.New("is a required field"))
+	case app.Cost.IsNegative():
+		return validate.NewFieldsError("cost", errors.New("must be 0 or greater"))
+	case !app.Cost.Currency().Equal(money.DefaultCurrency):
+		return validate.NewFieldsError("cost", errCostCurrency)
+	}
+
+	return nil
+}
+
+// =============================================================================
+
+// AppNewVariant is what we require from clients when adding a variant to a
+// product.
+type AppNewVariant struct {
+	SKU              string            `json:"sku" validate:"required"`
+	OptionValues     map[string]string `json:"optionValues" validate:"required,dive,required"`
+	Cost             money.Money       `json:"cost"`
+	Quantity         int               `json:"quantity" validate:"gte=0"`
+	ReorderThreshold int               `json:"reorderThreshold" validate:"gte=0"`
+}
+
+func toCoreNewVariant(app AppNewVariant) product.NewVariant {
+	return product.NewVariant{
+		SKU:          app.SKU,
+		OptionValues: app.OptionValues,
+		Cost:         app.Cost,
+		Quantity:     app.Quantity,
+
+		ReorderThreshold: app.ReorderThreshold,
+	}
+}
+
+// Validate checks the data in the model is considered clean.
+func (app AppNewVariant) Validate() error {
+	if err := validate.Check(app); err != nil {
+		return err
+	}
+
+	switch {
+	case app.Cost.Equal(money.Money{}):
+		return validate.NewFieldsError("cost", errors.New("is a required field"))
+	case app.Cost.IsNegative():
+		return validate.NewFieldsError("cost", errors.New("must be 0 or greater"))
+	case !app.Cost.Currency().Equal(money.DefaultCurrency):
+		return validate.NewFieldsError("cost", errCostCurrency)
+	}
+
+	return nil
+}
+
+// =============================================================================
+
+// AppUpdateProduct contains information needed to update a product. Stock
+// is changed by posting receipts and adjustments instead.
+type AppUpdateProduct struct {
+	Name             *string      `json:"name"`
+	Cost             *money.Money `json:"cost"`
+	TaxClass         *string      `json:"taxClass" validate:"omitempty,min=1,max=32"`
+	ReorderThreshold *int         `json:"reorderThreshold" validate:"omitempty,gte=0"`
+}
+
+func toCoreUpdateProduct(app AppUpdateProduct) product.UpdateProduct {
+	return product.UpdateProduct{
+		Name:     app.Name,
+		Cost:     app.Cost,
+		TaxClass: app.TaxClass,
+
+		ReorderThreshold: app.ReorderThreshold,
+	}
+}
+
+// Validate checks the data in the model is considered clean.
+func (app AppUpdateProduct) Validate() error {
+	if err := validate.Check(app); err != nil {
+		return err
+	}
+
+	if app.Cost != nil {
+		switch {
+		case app.Cost.IsNegative():
+			return validate.NewFieldsError("cost", errors.New("must be 0 or greater"))
+		case !app.Cost.Currency().Equal(money.DefaultCurrency):
+			return validate.NewFieldsError("cost", errCostCurrency)
+		}
+	}
+
+	return nil
+}
+
+// =============================================================================
+
+// AppNewPrice contains information needed to set the price of a product in
+// a currency. The currency is taken from the path.
+type AppNewPrice struct {
+	Amount json.Number `json:"amount" validate:"required"`
+}
+
+// Validate checks the data in the model is considered clean.
+func (app AppNewPrice) Validate() error {
+	if err := validate.Check(app); err != nil {
+		return err
+	}
+	return nil
+}
+
+// =============================================================================
+
+// AppPriceChange represents a change to the cost of a product scheduled to
+// take effect at a later time.
+type AppPriceChange struct {
+	ID           string       `json:"id"`
+	ProductID    string       `json:"productId"`
+	Cost         money.Money  `json:"cost"`
+	PreviousCost *money.Money `json:"previousCost,omitempty"`
+	Status       string       `json:"status"`
+	EffectiveAt  string       `json:"effectiveAt"`
+	CreatedBy    string       `json:"createdBy,omitempty"`
+	DateCreated  string       `json:"dateCreated"`
+	DateResolved string       `json:"dateResolved,omitempty"`
+}
+
+func toAppPriceChange(pc product.PriceChange) AppPriceChange {
+	app := AppPriceChange{
+		ID:          pc.ID.String(),
+		ProductID:   pc.ProductID.String(),
+		Cost:        pc.Cost,
+		Status:      pc.Status.Name(),
+		EffectiveAt: pc.EffectiveAt.Format(time.RFC3339),
+		DateCreated: pc.DateCreated.Format(time.RFC3339),
+	}
+
+	if pc.Status == product.ChangeApplied {
+		app.PreviousCost = &pc.PreviousCost
+	}
+	if pc.CreatedBy != uuid.Nil {
+		app.CreatedBy = pc.CreatedBy.String()
+	}
+	if !pc.DateResolved.IsZero() {
+		app.DateResolved = pc.DateResolved.Format(time.RFC3339)
+	}
+
+	return app
+}
+
+func toAppPriceChanges(pcs []product.PriceChange) []AppPriceChange {
+	items := make([]AppPriceChange, len(pcs))
+	for i, pc := range pcs {
+		items[i] = toAppPriceChange(pc)
+	}
+	return items
+}
+
+// AppNewPriceChange contains information needed to schedule a change to the
+// cost of a product.
+type AppNewPriceChange struct {
+	Cost        money.Money `json:"cost"`
+	EffectiveAt string      `json:"effectiveAt" validate:"required"`
+}
+
+func toCoreNewPriceChange(app AppNewPriceChange) (product.NewPriceChange, error) {
+	effectiveAt, err := time.Parse(time.RFC3339, app.EffectiveAt)
+	if err != nil {
+		return product.NewPriceChange{}, validate.NewFieldsError("effectiveAt", err)
+	}
+
+	npc := product.NewPriceChange{
+		Cost:        app.Cost,
+		EffectiveAt: effectiveAt,
+	}
+
+	return npc, nil
+}
+
+// Validate checks the data in the model is considered clean.
+func (app AppNewPriceChange) Validate() error {
+	if err := validate.Check(app); err != nil {
+		return err
+	}
+
+	switch {
+	case app.Cost.IsNegative():
+		return validate.NewFieldsError("cost", errors.New("must be 0 or greater"))
+	case !app.Cost.Equal(money.Money{}) && !app.Cost.Currency().Equal(money.DefaultCurrency):
+		return validate.NewFieldsError("cost", errCostCurrency)
+	}
+
+	return nil
+}
+
+// AppPriceRecord represents an entry in the price history of a product.
+type AppPriceRecord struct {
+	ID           string       `json:"id"`
+	ProductID    string       `json:"productId"`
+	Cost         money.Money  `json:"cost"`
+	PreviousCost *money.Money `json:"previousCost,omitempty"`
+	ChangeID     string       `json:"changeId,omitempty"`
+	ActorID      string       `json:"actorId,omitempty"`
+	DateCreated  string       `json:"dateCreated"`
+}
+
+func toAppPriceHistory(prs []product.PriceRecord) []AppPriceRecord {
+	items := make([]AppPriceRecord, len(prs))
+	for i, pr := range prs {
+		items[i] = AppPriceRecord{
+			ID:          pr.ID.String(),
+			ProductID:   pr.ProductID.String(),
+			Cost:        pr.Cost,
+			DateCreated: pr.DateCreated.Format(time.RFC3339),
+		}
+		if pr.PreviousCost != (money.Money{}) {
+			previous := pr.PreviousCost
+			items[i].PreviousCost = &previous
+		}
+		if pr.ChangeID != uuid.Nil {
+			items[i].ChangeID = pr.ChangeID.String()
+		}
+		if pr.ActorID != uuid.Nil {
+			items[i].ActorID = pr.ActorID.String()
+		}
+	}
+	return items
+}
+
+// =============================================================================
+
+// AppMovement represents an entry of the inventory ledger.
+type AppMovement struct {
+	ID          string `json:"id"`
+	ProductID   string `json:"productId"`
+	Kind        string `json:"kind"`
+	Quantity    int    `json:"quantity"`
+	Reason      string `json:"reason,omitempty"`
+	OrderID     string `json:"orderId,omitempty"`
+	ActorID     string `json:"actorId,omitempty"`
+	DateCreated string `json:"dateCreated"`
+}
+
+func toAppMovement(mv product.Movement) AppMovement {
+	app := AppMovement{
+		ID:          mv.ID.String(),
+		ProductID:   mv.ProductID.String(),
+		Kind:        mv.Kind.Name(),
+		Quantity:    mv.Quantity,
+		Reason:      mv.Reason.Name(),
+		DateCreated: mv.DateCreated.Format(time.RFC3339),
+	}
+
+	if mv.OrderID != uuid.Nil {
+		app.OrderID = mv.OrderID.String()
+	}
+	if mv.ActorID != uuid.Nil {
+		app.ActorID = mv.ActorID.String()
+	}
+
+	return app
+}
+
+func toAppMovements(mvs []product.Movement) []AppMovement {
+	items := make([]AppMovement, len(mvs))
+	for i, mv := range mvs {
+		items[i] = toAppMovement(mv)
+	}
+	return items
+}
+
+// AppStockChange is returned when stock is moved. It holds the recorded
+// movement and the stock on hand afterwards.
+type AppStockChange struct {
+	Movement AppMovement `json:"movement"`
+	Quantity int         `json:"quantity"`
+}
+
+// AppDiscrepancy represents a product whose stock on hand disagrees with the
+// inventory ledger.
+type AppDiscrepancy struct {
+	ProductID      string `json:"productId"`
+	Name           string `json:"name"`
+	Quantity       int    `json:"quantity"`
+	LedgerQuantity int    `json:"ledgerQuantity"`
+	Difference     int    `json:"difference"`
+}
+
+func toAppDiscrepancies(dcs []product.Discrepancy) []AppDiscrepancy {
+	items := make([]AppDiscrepancy, len(dcs))
+	for i, dc := range dcs {
+		items[i] = AppDiscrepancy{
+			ProductID:      dc.ProductID.String(),
+			Name:           dc.Name,
+			Quantity:       dc.Quantity,
+			LedgerQuantity: dc.LedgerQuantity,
+			Difference:     dc.Quantity - dc.LedgerQuantity,
+		}
+	}
+	return items
+}
+
+// =============================================================================
+
+// AppNewReceipt contains information needed to receive stock.
+type AppNewReceipt struct {
+	Quantity int `json:"quantity" validate:"required,gte=1"`
+}
+
+// Validate checks the data in the model is considered clean.
+func (app AppNewReceipt) Validate() error {
+	if err := validate.Check(app); err != nil {
+		return err
+	}
+	return nil
+}
+
+// AppNewAdjustment contains information needed to correct stock. The
+// quantity is negative when stock is written off.
+type AppNewAdjustment struct {
+	Quantity int    `json:"quantity" validate:"required"`
+	Reason   string `json:"reason" validate:"required"`
+}
+
+func toCoreNewAdjustment(app AppNewAdjustment) (product.NewAdjustment, error) {
+	reason, err := product.ParseReason(app.Reason)
+	if err != nil || reason == product.ReasonOpening {
+		return product.NewAdjustment{}, validate.NewFieldsError("reason", errors.New("must be one of DAMAGED, LOST, FOUND or COUNT"))
+	}
+
+	na := product.NewAdjustment{
+		Quantity: app.Quantity,
+		Reason:   reason,
+	}
+
+	return na, nil
+}
+
+// Validate checks the data in the model is considered clean.
+func (app AppNewAdjustment) Validate() error {
+	if err := validate.Check(app); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,622 @@
+// Package productgrp maintains the group of handlers for product access.
+package productgrp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/aleury/service/business/core/exchange"
+	"github.com/aleury/service/business/core/product"
+	"github.com/aleury/service/business/core/user"
+	"github.com/aleury/service/business/data/money"
+	"github.com/aleury/service/business/sys/validate"
+	"github.com/aleury/service/business/web/auth"
+	v1 "github.com/aleury/service/business/web/v1"
+	"github.com/aleury/service/business/web/v1/etag"
+	"github.com/aleury/service/business/web/v1/mid"
+	"github.com/aleury/service/business/web/v1/paging"
+	"github.com/aleury/service/foundation/web"
+	"github.com/google/uuid"
+)
+
+// Handlers manages the set of product endpoints.
+type Handlers struct {
+	product *product.Core
+}
+
+// New constructs a handlers for route access.
+func New(product *product.Core) *Handlers {
+	return &Handlers{
+		product: product,
+	}
+}
+
+// Create adds a new product to the system.
+func (h *Handlers) Create(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	var app AppNewProduct
+	if err := web.Decode(r, &app); err != nil {
+		return err
+	}
+
+	userID, err := uuid.Parse(auth.GetClaims(ctx).Subject)
+	if err != nil {
+		return v1.NewRequestError(mid.ErrInvalidID, http.StatusBadRequest)
+	}
+
+	prd, err := h.product.Create(ctx, toCoreNewProduct(app, userID))
+	if err != nil {
+		switch {
+		case errors.Is(err, product.ErrUniqueSKU):
+			return v1.NewRequestError(err, http.StatusConflict)
+		case errors.Is(err, product.ErrHasVariants), errors.Is(err, product.ErrCostCurrency):
+			return v1.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return fmt.Errorf("create: app[%+v]: %w", app, err)
+		}
+	}
+
+	return web.Respond(ctx, w, toAppProduct(prd), http.StatusCreated)
+}
+
+// CreateVariant adds a new variant to a product with options.
+func (h *Handlers) CreateVariant(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	var app AppNewVariant
+	if err := web.Decode(r, &app); err != nil {
+		return err
+	}
+
+	prd := mid.GetProduct(ctx)
+
+	vrt, err := h.product.CreateVariant(ctx, prd, toCoreNewVariant(app))
+	if err != nil {
+		switch {
+		case errors.Is(err, product.ErrNoOptions), errors.Is(err, product.ErrOptionValues), errors.Is(err, product.ErrCostCurrency):
+			return v1.NewRequestError(err, http.StatusBadRequest)
+		case errors.Is(err, product.ErrUniqueSKU), errors.Is(err, product.ErrDuplicateVariant):
+			return v1.NewRequestError(err, http.StatusConflict)
+		default:
+			return fmt.Errorf("createvariant: productID[%s] app[%+v]: %w", prd.ID, app, err)
+		}
+	}
+
+	return web.Respond(ctx, w, toAppProduct(vrt), http.StatusCreated)
+}
+
+// Update updates a product in the system.
+func (h *Handlers) Update(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	var app AppUpdateProduct
+	if err := web.Decode(r, &app); err != nil {
+		return err
+	}
+
+	prd := mid.GetProduct(ctx)
+
+	if err := etag.Check(r, prd.Version); err != nil {
+		return err
+	}
+
+	updPrd, err := h.product.Update(ctx, prd, toCoreUpdateProduct(app))
+	if err != nil {
+		switch {
+		case errors.Is(err, product.ErrConflict):
+			return v1.NewRequestError(etag.ErrPreconditionFailed, http.StatusPreconditionFailed)
+		case errors.Is(err, product.ErrVariantName), errors.Is(err, product.ErrHasVariants), errors.Is(err, product.ErrCostCurrency):
+			return v1.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return fmt.Errorf("update: productID[%s] app[%+v]: %w", prd.ID, app, err)
+		}
+	}
+
+	etag.Set(w, updPrd.Version)
+
+	return web.Respond(ctx, w, toAppProduct(updPrd), http.StatusOK)
+}
+
+// Delete removes a product from the system.
+func (h *Handlers) Delete(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	prd := mid.GetProduct(ctx)
+
+	if err := h.product.Delete(ctx, prd); err != nil {
+		return fmt.Errorf("delete: productID[%s]: %w", prd.ID, err)
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+// Restore brings back a product that was previously deleted.
+func (h *Handlers) Restore(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	productID, err := uuid.Parse(web.Param(r, "product_id"))
+	if err != nil {
+		return v1.NewRequestError(mid.ErrInvalidID, http.StatusBadRequest)
+	}
+
+	prd, err := h.product.Restore(ctx, productID)
+	if err != nil {
+		switch {
+		case errors.Is(err, product.ErrNotFound):
+			return v1.NewRequestError(err, http.StatusNotFound)
+		default:
+			return fmt.Errorf("restore: productID[%s]: %w", productID, err)
+		}
+	}
+
+	return web.Respond(ctx, w, toAppProduct(prd), http.StatusOK)
+}
+
+// Query returns a list of products with paging.
+func (h *Handlers) Query(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	page, err := paging.ParseRequest(r)
+	if err != nil {
+		return err
+	}
+
+	filter, err := parseFilter(r)
+	if err != nil {
+		return err
+	}
+
+	if filter.IncludeDeleted != nil && !auth.GetClaims(ctx).HasRole(user.RoleAdmin) {
+		return v1.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	orderBy, err := parseOrder(r)
+	if err != nil {
+		return err
+	}
+
+	currency, quote, err := parseCurrency(r)
+	if err != nil {
+		return err
+	}
+
+	embed, err := parseEmbed(r)
+	if err != nil {
+		return err
+	}
+
+	highlight, err := parseHighlight(r)
+	if err != nil {
+		return err
+	}
+
+	prds, err := h.product.Query(ctx, filter, orderBy, page.Number, page.RowsPerPage)
+	if err != nil {
+		return fmt.Errorf("query: %w", err)
+	}
+
+	total, err := h.product.Count(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("count: %w", err)
+	}
+
+	items, err := h.toAppProducts(ctx, prds, currency, quote)
+	if err != nil {
+		return err
+	}
+
+	if embed {
+		if err := h.embedVariants(ctx, items, prds, currency, quote); err != nil {
+			return err
+		}
+	}
+
+	if highlight {
+		productIDs := make([]uuid.UUID, len(prds))
+		for i, prd := range prds {
+			productIDs[i] = prd.ID
+		}
+
+		snippets, err := h.product.Highlight(ctx, productIDs, *filter.Search)
+		if err != nil {
+			return fmt.Errorf("highlight: %w", err)
+		}
+
+		for i, prd := range prds {
+			items[i].Snippet = snippets[prd.ID]
+		}
+	}
+
+	return web.Respond(ctx, w, paging.NewResponse(items, total, page.Number, page.RowsPerPage), http.StatusOK)
+}
+
+// QueryByID returns a product by its ID. If the asOf parameter is provided,
+// the product is reconstructed as it was at that time.
+func (h *Handlers) QueryByID(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	productID, err := uuid.Parse(web.Param(r, "product_id"))
+	if err != nil {
+		return v1.NewRequestError(mid.ErrInvalidID, http.StatusBadRequest)
+	}
+
+	asOf, err := parseAsOf(r)
+	if err != nil {
+		return err
+	}
+
+	currency, quote, err := parseCurrency(r)
+	if err != nil {
+		return err
+	}
+
+	embed, err := parseEmbed(r)
+	if err != nil {
+		return err
+	}
+
+	var prd product.Product
+	switch asOf.IsZero() {
+	case true:
+		prd, err = h.product.QueryByID(ctx, productID)
+	default:
+		prd, err = h.product.QueryByIDAsOf(ctx, productID, asOf)
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, product.ErrNotFound):
+			return v1.NewRequestError(err, http.StatusNotFound)
+		default:
+			return fmt.Errorf("querybyid: productID[%s]: %w", productID, err)
+		}
+	}
+
+	items, err := h.toAppProducts(ctx, []product.Product{prd}, currency, quote)
+	if err != nil {
+		return err
+	}
+
+	if embed {
+		if err := h.embedVariants(ctx, items, []product.Product{prd}, currency, quote); err != nil {
+			return err
+		}
+	}
+
+	if asOf.IsZero() {
+		etag.Set(w, prd.Version)
+
+		if err := etag.Check(r, prd.Version); err != nil {
+			return err
+		}
+	}
+
+	return web.Respond(ctx, w, items[0], http.StatusOK)
+}
+
+// QueryHistory returns the recorded versions of a product with paging.
+func (h *Handlers) QueryHistory(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	productID, err := uuid.Parse(web.Param(r, "product_id"))
+	if err != nil {
+		return v1.NewRequestError(mid.ErrInvalidID, http.StatusBadRequest)
+	}
+
+	page, err := paging.ParseRequest(r)
+	if err != nil {
+		return err
+	}
+
+	versions, err := h.product.QueryHistory(ctx, productID, page.Number, page.RowsPerPage)
+	if err != nil {
+		return fmt.Errorf("queryhistory: productID[%s]: %w", productID, err)
+	}
+
+	total, err := h.product.CountHistory(ctx, productID)
+	if err != nil {
+		return fmt.Errorf("counthistory: productID[%s]: %w", productID, err)
+	}
+
+	return web.Respond(ctx, w, paging.NewResponse(toAppVersions(versions), total, page.Number, page.RowsPerPage), http.StatusOK)
+}
+
+// QueryPrices returns the explicit prices of a product.
+func (h *Handlers) QueryPrices(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	productID, err := uuid.Parse(web.Param(r, "product_id"))
+	if err != nil {
+		return v1.NewRequestError(mid.ErrInvalidID, http.StatusBadRequest)
+	}
+
+	prices, err := h.product.QueryPrices(ctx, productID)
+	if err != nil {
+		return fmt.Errorf("queryprices: productID[%s]: %w", productID, err)
+	}
+
+	return web.Respond(ctx, w, toAppPrices(prices), http.StatusOK)
+}
+
+// SetPrice sets the price of a product in the currency of the path.
+func (h *Handlers) SetPrice(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	currency, err := money.ParseCurrency(strings.ToUpper(web.Param(r, "currency")))
+	if err != nil {
+		return v1.NewRequestError(err, http.StatusBadRequest)
+	}
+
+	var app AppNewPrice
+	if err := web.Decode(r, &app); err != nil {
+		return err
+	}
+
+	amount, err := money.Parse(app.Amount.String(), currency)
+	if err != nil {
+		return validate.NewFieldsError("amount", err)
+	}
+
+	prd := mid.GetProduct(ctx)
+
+	price, err := h.product.SetPrice(ctx, prd, amount)
+	if err != nil {
+		switch {
+		case errors.Is(err, product.ErrPriceCurrency), errors.Is(err, product.ErrNegativePrice):
+			return v1.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return fmt.Errorf("setprice: productID[%s] app[%+v]: %w", prd.ID, app, err)
+		}
+	}
+
+	return web.Respond(ctx, w, toAppPrice(price), http.StatusOK)
+}
+
+// DeletePrice removes the price of a product in the currency of the path.
+func (h *Handlers) DeletePrice(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	currency, err := money.ParseCurrency(strings.ToUpper(web.Param(r, "currency")))
+	if err != nil {
+		return v1.NewRequestError(err, http.StatusBadRequest)
+	}
+
+	prd := mid.GetProduct(ctx)
+
+	if err := h.product.DeletePrice(ctx, prd, currency); err != nil {
+		return fmt.Errorf("deleteprice: productID[%s] currency[%s]: %w", prd.ID, currency.Code(), err)
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+// SchedulePriceChange schedules a change to the cost of a product.
+func (h *Handlers) SchedulePriceChange(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	var app AppNewPriceChange
+	if err := web.Decode(r, &app); err != nil {
+		return err
+	}
+
+	npc, err := toCoreNewPriceChange(app)
+	if err != nil {
+		return err
+	}
+
+	prd := mid.GetProduct(ctx)
+
+	pc, err := h.product.SchedulePriceChange(ctx, prd, npc)
+	if err != nil {
+		switch {
+		case errors.Is(err, product.ErrEffectivePast), errors.Is(err, product.ErrCostCurrency):
+			return v1.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return fmt.Errorf("schedulepricechange: productID[%s] app[%+v]: %w", prd.ID, app, err)
+		}
+	}
+
+	return web.Respond(ctx, w, toAppPriceChange(pc), http.StatusCreated)
+}
+
+// QueryPriceChanges returns the price changes scheduled for a product with
+// paging.
+func (h *Handlers) QueryPriceChanges(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	page, err := paging.ParseRequest(r)
+	if err != nil {
+		return err
+	}
+
+	prd := mid.GetProduct(ctx)
+
+	pcs, err := h.product.QueryPriceChanges(ctx, prd.ID, page.Number, page.RowsPerPage)
+	if err != nil {
+		return fmt.Errorf("querypricechanges: productID[%s]: %w", prd.ID, err)
+	}
+
+	total, err := h.product.CountPriceChanges(ctx, prd.ID)
+	if err != nil {
+		return fmt.Errorf("countpricechanges: productID[%s]: %w", prd.ID, err)
+	}
+
+	return web.Respond(ctx, w, paging.NewResponse(toAppPriceChanges(pcs), total, page.Number, page.RowsPerPage), http.StatusOK)
+}
+
+// CancelPriceChange cancels a price change that hasn't been applied yet.
+func (h *Handlers) CancelPriceChange(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	changeID, err := uuid.Parse(web.Param(r, "change_id"))
+	if err != nil {
+		return v1.NewRequestError(mid.ErrInvalidID, http.StatusBadRequest)
+	}
+
+	prd := mid.GetProduct(ctx)
+
+	pc, err := h.product.QueryPriceChangeByID(ctx, changeID)
+	if err != nil {
+		switch {
+		case errors.Is(err, product.ErrChangeNotFound):
+			return v1.NewRequestError(err, http.StatusNotFound)
+		default:
+			return fmt.Errorf("querypricechangebyid: changeID[%s]: %w", changeID, err)
+		}
+	}
+
+	if pc.ProductID != prd.ID {
+		return v1.NewRequestError(product.ErrChangeNotFound, http.StatusNotFound)
+	}
+
+	pc, err = h.product.CancelPriceChange(ctx, pc)
+	if err != nil {
+		switch {
+		case errors.Is(err, product.ErrChangeResolved):
+			return v1.NewRequestError(err, http.StatusConflict)
+		default:
+			return fmt.Errorf("cancelpricechange: changeID[%s]: %w", changeID, err)
+		}
+	}
+
+	return web.Respond(ctx, w, toAppPriceChange(pc), http.StatusOK)
+}
+
+// QueryPriceHistory returns the costs a product had with paging.
+func (h *Handlers) QueryPriceHistory(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	productID, err := uuid.Parse(web.Param(r, "product_id"))
+	if err != nil {
+		return v1.NewRequestError(mid.ErrInvalidID, http.StatusBadRequest)
+	}
+
+	page, err := paging.ParseRequest(r)
+	if err != nil {
+		return err
+	}
+
+	prs, err := h.product.QueryPriceHistory(ctx, productID, page.Number, page.RowsPerPage)
+	if err != nil {
+		return fmt.Errorf("querypricehistory: productID[%s]: %w", productID, err)
+	}
+
+	total, err := h.product.CountPriceHistory(ctx, productID)
+	if err != nil {
+		return fmt.Errorf("countpricehistory: productID[%s]: %w", productID, err)
+	}
+
+	return web.Respond(ctx, w, paging.NewResponse(toAppPriceHistory(prs), total, page.Number, page.RowsPerPage), http.StatusOK)
+}
+
+// Receive adds delivered stock to a product.
+func (h *Handlers) Receive(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	var app AppNewReceipt
+	if err := web.Decode(r, &app); err != nil {
+		return err
+	}
+
+	prd := mid.GetProduct(ctx)
+
+	updPrd, mv, err := h.product.Receive(ctx, prd, app.Quantity)
+	if err != nil {
+		switch {
+		case errors.Is(err, product.ErrHasVariants):
+			return v1.NewRequestError(err, http.StatusConflict)
+		default:
+			return fmt.Errorf("receive: productID[%s] app[%+v]: %w", prd.ID, app, err)
+		}
+	}
+
+	return web.Respond(ctx, w, AppStockChange{Movement: toAppMovement(mv), Quantity: updPrd.Quantity}, http.StatusCreated)
+}
+
+// Adjust corrects the stock of a product for the reason given.
+func (h *Handlers) Adjust(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	var app AppNewAdjustment
+	if err := web.Decode(r, &app); err != nil {
+		return err
+	}
+
+	na, err := toCoreNewAdjustment(app)
+	if err != nil {
+		return err
+	}
+
+	prd := mid.GetProduct(ctx)
+
+	updPrd, mv, err := h.product.Adjust(ctx, prd, na)
+	if err != nil {
+		switch {
+		case errors.Is(err, product.ErrInsufficientStock), errors.Is(err, product.ErrHasVariants):
+			return v1.NewRequestError(err, http.StatusConflict)
+		default:
+			return fmt.Errorf("adjust: productID[%s] app[%+v]: %w", prd.ID, app, err)
+		}
+	}
+
+	return web.Respond(ctx, w, AppStockChange{Movement: toAppMovement(mv), Quantity: updPrd.Quantity}, http.StatusCreated)
+}
+
+// QueryMovements returns the inventory ledger of a product with paging.
+func (h *Handlers) QueryMovements(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	page, err := paging.ParseRequest(r)
+	if err != nil {
+		return err
+	}
+
+	prd := mid.GetProduct(ctx)
+
+	mvs, err := h.product.QueryMovements(ctx, prd.ID, page.Number, page.RowsPerPage)
+	if err != nil {
+		return fmt.Errorf("querymovements: productID[%s]: %w", prd.ID, err)
+	}
+
+	total, err := h.product.CountMovements(ctx, prd.ID)
+	if err != nil {
+		return fmt.Errorf("countmovements: productID[%s]: %w", prd.ID, err)
+	}
+
+	return web.Respond(ctx, w, paging.NewResponse(toAppMovements(mvs), total, page.Number, page.RowsPerPage), http.StatusOK)
+}
+
+// Reconcile reports the products whose stock on hand disagrees with the
+// inventory ledger.
+func (h *Handlers) Reconcile(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	dcs, err := h.product.Reconcile(ctx)
+	if err != nil {
+		return fmt.Errorf("reconcile: %w", err)
+	}
+
+	return web.Respond(ctx, w, toAppDiscrepancies(dcs), http.StatusOK)
+}
+
+// =============================================================================
+
+// toAppProducts converts the products for the response, quoting them in the
+// currency when the request asked for one.
+func (h *Handlers) toAppProducts(ctx context.Context, prds []product.Product, currency money.Currency, quote bool) ([]AppProduct, error) {
+	items := toAppProducts(prds)
+	if !quote {
+		return items, nil
+	}
+
+	quotes, err := h.product.Quote(ctx, prds, currency)
+	if err != nil {
+		if errors.Is(err, exchange.ErrNotFound) {
+			return nil, v1.NewRequestError(fmt.Errorf("no exchange rate to %s", currency.Code()), http.StatusBadRequest)
+		}
+		return nil, fmt.Errorf("quote: %w", err)
+	}
+
+	for i, qte := range quotes {
+		items[i].Price = toAppQuote(qte)
+	}
+
+	return items, nil
+}
+
+// embedVariants adds the variants of the products to their items, quoted
+// like the products themselves.
+func (h *Handlers) embedVariants(ctx context.Context, items []AppProduct, prds []product.Product, currency money.Currency, quote bool) error {
+	var parentIDs []uuid.UUID
+	for _, prd := range prds {
+		if prd.HasVariants() {
+			parentIDs = append(parentIDs, prd.ID)
+		}
+	}
+
+	vrts, err := h.product.QueryVariants(ctx, parentIDs)
+	if err != nil {
+		return fmt.Errorf("queryvariants: %w", err)
+	}
+
+	vrtItems, err := h.toAppProducts(ctx, vrts, currency, quote)
+	if err != nil {
+		return err
+	}
+
+	byParent := make(map[uuid.UUID][]AppProduct, len(parentIDs))
+	for i, vrt := range vrts {
+		byParent[vrt.ParentID] = append(byParent[vrt.ParentID], vrtItems[i])
+	}
+
+	for i, prd := range prds {
+		items[i].Variants = byParent[prd.ID]
+	}
+
+	return nil
+}
@@ -0,0 +1,42 @@
+package promotiongrp
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/aleury/service/business/core/promotion"
+	"github.com/aleury/service/business/sys/validate"
+	"github.com/google/uuid"
+)
+
+func parseFilter(r *http.Request) (promotion.QueryFilter, error) {
+	values := r.URL.Query()
+
+	var filter promotion.QueryFilter
+
+	if promotionID := values.Get("promotion_id"); promotionID != "" {
+		id, err := uuid.Parse(promotionID)
+		if err != nil {
+			return promotion.QueryFilter{}, validate.NewFieldsError("promotion_id", err)
+		}
+		filter.WithPromotionID(id)
+	}
+
+	if code := values.Get("code"); code != "" {
+		filter.WithCode(code)
+	}
+
+	if enabled := values.Get("enabled"); enabled != "" {
+		b, err := strconv.ParseBool(enabled)
+		if err != nil {
+			return promotion.QueryFilter{}, validate.NewFieldsError("enabled", err)
+		}
+		filter.WithEnabled(b)
+	}
+
+	if err := filter.Validate(); err != nil {
+		return promotion.QueryFilter{}, err
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,230 @@
+package promotiongrp
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aleury/service/business/core/promotion"
+	"github.com/aleury/service/business/data/money"
+	"github.com/aleury/service/business/sys/validate"
+	"github.com/google/uuid"
+)
+
+// AppPromotion represents an individual promotion.
+type AppPromotion struct {
+	ID             string      `json:"id"`
+	TenantID       string      `json:"tenantId"`
+	Code           string      `json:"code"`
+	Kind           string      `json:"kind"`
+	Percent        int         `json:"percent,omitempty"`
+	Amount         money.Money `json:"amount"`
+	MinOrder       money.Money `json:"minOrder"`
+	MaxUses        int         `json:"maxUses,omitempty"`
+	MaxUsesPerUser int         `json:"maxUsesPerUser,omitempty"`
+	Uses           int         `json:"uses"`
+	ProductIDs     []string    `json:"productIds,omitempty"`
+	CategoryIDs    []string    `json:"categoryIds,omitempty"`
+	Enabled        bool        `json:"enabled"`
+	DateStarts     string      `json:"dateStarts"`
+	DateEnds       string      `json:"dateEnds,omitempty"`
+	DateCreated    string      `json:"dateCreated"`
+	DateUpdated    string      `json:"dateUpdated"`
+}
+
+func toAppPromotion(promo promotion.Promotion) AppPromotion {
+	app := AppPromotion{
+		ID:             promo.ID.String(),
+		TenantID:       promo.TenantID.String(),
+		Code:           promo.Code,
+		Kind:           promo.Kind.Name(),
+		Percent:        promo.Percent,
+		Amount:         promo.Amount,
+		MinOrder:       promo.MinOrder,
+		MaxUses:        promo.MaxUses,
+		MaxUsesPerUser: promo.MaxUsesPerUser,
+		Uses:           promo.Uses,
+		ProductIDs:     toAppIDs(promo.ProductIDs),
+		CategoryIDs:    toAppIDs(promo.CategoryIDs),
+		Enabled:        promo.Enabled,
+		DateStarts:     promo.DateStarts.Format(time.RFC3339),
+		DateCreated:    promo.DateCreated.Format(time.RFC3339),
+		DateUpdated:    promo.DateUpdated.Format(time.RFC3339),
+	}
+
+	if !promo.DateEnds.IsZero() {
+		app.DateEnds = promo.DateEnds.Format(time.RFC3339)
+	}
+
+	return app
+}
+
+func toAppPromotions(promos []promotion.Promotion) []AppPromotion {
+	items := make([]AppPromotion, len(promos))
+	for i, promo := range promos {
+		items[i] = toAppPromotion(promo)
+	}
+	return items
+}
+
+func toAppIDs(ids []uuid.UUID) []string {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	items := make([]string, len(ids))
+	for i, id := range ids {
+		items[i] = id.String()
+	}
+	return items
+}
+
+// =============================================================================
+
+// AppNewPromotion is what we require from clients when adding a Promotion.
+// A PERCENT promotion needs a percent and a FIXED one an amount. Without
+// products or categories the promotion applies to every product.
+type AppNewPromotion struct {
+	Code           string      `json:"code" validate:"required,min=2,max=64"`
+	Kind           string      `json:"kind" validate:"required,oneof=PERCENT FIXED"`
+	Percent        int         `json:"percent" validate:"omitempty,gte=1,lte=100"`
+	Amount         money.Money `json:"amount"`
+	MinOrder       money.Money `json:"minOrder"`
+	MaxUses        int         `json:"maxUses" validate:"gte=0"`
+	MaxUsesPerUser int         `json:"maxUsesPerUser" validate:"gte=0"`
+	ProductIDs     []string    `json:"productIds" validate:"omitempty,unique,dive,uuid"`
+	CategoryIDs    []string    `json:"categoryIds" validate:"omitempty,unique,dive,uuid"`
+	DateStarts     string      `json:"dateStarts"`
+	DateEnds       string      `json:"dateEnds"`
+}
+
+func toCoreNewPromotion(app AppNewPromotion, tenantID uuid.UUID) (promotion.NewPromotion, error) {
+	kind, err := promotion.ParseKind(app.Kind)
+	if err != nil {
+		return promotion.NewPromotion{}, fmt.Errorf("parsing kind: %w", err)
+	}
+
+	productIDs, err := toCoreIDs(app.ProductIDs)
+	if err != nil {
+		return promotion.NewPromotion{}, fmt.Errorf("parsing productIds: %w", err)
+	}
+
+	categoryIDs, err := toCoreIDs(app.CategoryIDs)
+	if err != nil {
+		return promotion.NewPromotion{}, fmt.Errorf("parsing categoryIds: %w", err)
+	}
+
+	dateStarts, err := parseDate(app.DateStarts)
+	if err != nil {
+		return promotion.NewPromotion{}, fmt.Errorf("parsing dateStarts: %w", err)
+	}
+
+	dateEnds, err := parseDate(app.DateEnds)
+	if err != nil {
+		return promotion.NewPromotion{}, fmt.Errorf("parsing dateEnds: %w", err)
+	}
+
+	np := promotion.NewPromotion{
+		TenantID:       tenantID,
+		Code:           app.Code,
+		Kind:           kind,
+		Percent:        app.Percent,
+		Amount:         app.Amount,
+		MinOrder:       app.MinOrder,
+		MaxUses:        app.MaxUses,
+		MaxUsesPerUser: app.MaxUsesPerUser,
+		ProductIDs:     productIDs,
+		CategoryIDs:    categoryIDs,
+		DateStarts:     dateStarts,
+		DateEnds:       dateEnds,
+	}
+	return np, nil
+}
+
+// Validate checks the data in the model is considered clean.
+func (app AppNewPromotion) Validate() error {
+	if err := validate.Check(app); err != nil {
+		return err
+	}
+
+	if app.MinOrder.IsNegative() {
+		return validate.NewFieldsError("minOrder", errors.New("must be 0 or greater"))
+	}
+
+	return nil
+}
+
+// =============================================================================
+
+// AppUpdatePromotion contains information needed to update a promotion. An
+// empty end date removes the end of the promotion.
+type AppUpdatePromotion struct {
+	Enabled        *bool        `json:"enabled"`
+	MinOrder       *money.Money `json:"minOrder"`
+	MaxUses        *int         `json:"maxUses" validate:"omitempty,gte=0"`
+	MaxUsesPerUser *int         `json:"maxUsesPerUser" validate:"omitempty,gte=0"`
+	DateStarts     *string      `json:"dateStarts"`
+	DateEnds       *string      `json:"dateEnds"`
+}
+
+func toCoreUpdatePromotion(app AppUpdatePromotion) (promotion.UpdatePromotion, error) {
+	up := promotion.UpdatePromotion{
+		Enabled:        app.Enabled,
+		MinOrder:       app.MinOrder,
+		MaxUses:        app.MaxUses,
+		MaxUsesPerUser: app.MaxUsesPerUser,
+	}
+
+	if app.DateStarts != nil {
+		dateStarts, err := parseDate(*app.DateStarts)
+		if err != nil {
+			return promotion.UpdatePromotion{}, fmt.Errorf("parsing dateStarts: %w", err)
+		}
+		up.DateStarts = &dateStarts
+	}
+
+	if app.DateEnds != nil {
+		dateEnds, err := parseDate(*app.DateEnds)
+		if err != nil {
+			return promotion.UpdatePromotion{}, fmt.Errorf("parsing dateEnds: %w", err)
+		}
+		up.DateEnds = &dateEnds
+	}
+
+	return up, nil
+}
+
+// Validate checks the data in the model is considered clean.
+func (app AppUpdatePromotion) Validate() error {
+	if err := validate.Check(app); err != nil {
+		return err
+	}
+
+	if app.MinOrder != nil && app.MinOrder.IsNegative() {
+		return validate.NewFieldsError("minOrder", errors.New("must be 0 or greater"))
+	}
+
+	return nil
+}
+
+// =============================================================================
+
+func toCoreIDs(ids []string) ([]uuid.UUID, error) {
+	items := make([]uuid.UUID, len(ids))
+	for i, id := range ids {
+		var err error
+		items[i], err = uuid.Parse(id)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return items, nil
+}
+
+// parseDate returns the zero time for an empty date.
+func parseDate(date string) (time.Time, error) {
+	if date == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, date)
+}
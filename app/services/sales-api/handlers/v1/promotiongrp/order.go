@@ -0,0 +1,31 @@
+package promotiongrp
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/aleury/service/business/core/promotion"
+	"github.com/aleury/service/business/data/order"
+	"github.com/aleury/service/business/sys/validate"
+)
+
+var orderByFields = map[string]struct{}{
+	promotion.OrderByID:         {},
+	promotion.OrderByCode:       {},
+	promotion.OrderByUses:       {},
+	promotion.OrderByDateStarts: {},
+	promotion.OrderByDateEnds:   {},
+}
+
+func parseOrder(r *http.Request) (order.By, error) {
+	orderBy, err := order.Parse(r, promotion.DefaultOrderBy)
+	if err != nil {
+		return order.By{}, err
+	}
+
+	if _, exists := orderByFields[orderBy.Field]; !exists {
+		return order.By{}, validate.NewFieldsError(orderBy.Field, errors.New("order field does not exist"))
+	}
+
+	return orderBy, nil
+}
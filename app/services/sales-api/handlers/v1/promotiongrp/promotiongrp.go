@@ -0,0 +1,169 @@
+// Package promotiongrp maintains the group of handlers for promotion access.
+package promotiongrp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/aleury/service/business/core/promotion"
+	"github.com/aleury/service/business/core/tenant"
+	v1 "github.com/aleury/service/business/web/v1"
+	"github.com/aleury/service/business/web/v1/mid"
+	"github.com/aleury/service/business/web/v1/paging"
+	"github.com/aleury/service/foundation/web"
+	"github.com/google/uuid"
+)
+
+// Handlers manages the set of promotion endpoints.
+type Handlers struct {
+	promotion *promotion.Core
+}
+
+// New constructs a handlers for route access.
+func New(promotion *promotion.Core) *Handlers {
+	return &Handlers{
+		promotion: promotion,
+	}
+}
+
+// Create adds a new promotion to the system.
+func (h *Handlers) Create(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	var app AppNewPromotion
+	if err := web.Decode(r, &app); err != nil {
+		return err
+	}
+
+	np, err := toCoreNewPromotion(app, tenant.GetTenantID(ctx))
+	if err != nil {
+		return v1.NewRequestError(err, http.StatusBadRequest)
+	}
+
+	promo, err := h.promotion.Create(ctx, np)
+	if err != nil {
+		switch {
+		case errors.Is(err, promotion.ErrUniqueCode):
+			return v1.NewRequestError(err, http.StatusConflict)
+		case errors.Is(err, promotion.ErrInvalidValue), errors.Is(err, promotion.ErrInvalidDates):
+			return v1.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return fmt.Errorf("create: app[%+v]: %w", app, err)
+		}
+	}
+
+	return web.Respond(ctx, w, toAppPromotion(promo), http.StatusCreated)
+}
+
+// Update updates a promotion in the system.
+func (h *Handlers) Update(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	var app AppUpdatePromotion
+	if err := web.Decode(r, &app); err != nil {
+		return err
+	}
+
+	up, err := toCoreUpdatePromotion(app)
+	if err != nil {
+		return v1.NewRequestError(err, http.StatusBadRequest)
+	}
+
+	promo, err := h.queryByParam(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	promo, err = h.promotion.Update(ctx, promo, up)
+	if err != nil {
+		if errors.Is(err, promotion.ErrInvalidDates) {
+			return v1.NewRequestError(err, http.StatusBadRequest)
+		}
+		return fmt.Errorf("update: promotionID[%s] app[%+v]: %w", promo.ID, app, err)
+	}
+
+	return web.Respond(ctx, w, toAppPromotion(promo), http.StatusOK)
+}
+
+// Delete removes a promotion from the system.
+func (h *Handlers) Delete(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	promotionID, err := uuid.Parse(web.Param(r, "promotion_id"))
+	if err != nil {
+		return v1.NewRequestError(mid.ErrInvalidID, http.StatusBadRequest)
+	}
+
+	promo, err := h.promotion.QueryByID(ctx, promotionID)
+	if err != nil {
+		switch {
+		case errors.Is(err, promotion.ErrNotFound):
+			return web.Respond(ctx, w, nil, http.StatusNoContent)
+		default:
+			return fmt.Errorf("querybyid: promotionID[%s]: %w", promotionID, err)
+		}
+	}
+
+	if err := h.promotion.Delete(ctx, promo); err != nil {
+		return fmt.Errorf("delete: promotionID[%s]: %w", promo.ID, err)
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+// Query returns a list of promotions with paging.
+func (h *Handlers) Query(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	page, err := paging.ParseRequest(r)
+	if err != nil {
+		return err
+	}
+
+	filter, err := parseFilter(r)
+	if err != nil {
+		return err
+	}
+
+	orderBy, err := parseOrder(r)
+	if err != nil {
+		return err
+	}
+
+	promos, err := h.promotion.Query(ctx, filter, orderBy, page.Number, page.RowsPerPage)
+	if err != nil {
+		return fmt.Errorf("query: %w", err)
+	}
+
+	total, err := h.promotion.Count(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("count: %w", err)
+	}
+
+	return web.Respond(ctx, w, paging.NewResponse(toAppPromotions(promos), total, page.Number, page.RowsPerPage), http.StatusOK)
+}
+
+// QueryByID returns a promotion by its ID.
+func (h *Handlers) QueryByID(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	promo, err := h.queryByParam(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	return web.Respond(ctx, w, toAppPromotion(promo), http.StatusOK)
+}
+
+// queryByParam loads the promotion identified by the promotion_id route
+// parameter.
+func (h *Handlers) queryByParam(ctx context.Context, r *http.Request) (promotion.Promotion, error) {
+	promotionID, err := uuid.Parse(web.Param(r, "promotion_id"))
+	if err != nil {
+		return promotion.Promotion{}, v1.NewRequestError(mid.ErrInvalidID, http.StatusBadRequest)
+	}
+
+	promo, err := h.promotion.QueryByID(ctx, promotionID)
+	if err != nil {
+		switch {
+		case errors.Is(err, promotion.ErrNotFound):
+			return promotion.Promotion{}, v1.NewRequestError(err, http.StatusNotFound)
+		default:
+			return promotion.Promotion{}, fmt.Errorf("querybyid: promotionID[%s]: %w", promotionID, err)
+		}
+	}
+
+	return promo, nil
+}
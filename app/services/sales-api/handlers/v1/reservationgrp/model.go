@@ -0,0 +1,87 @@
+package reservationgrp
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aleury/service/business/core/reservation"
+	"github.com/aleury/service/business/sys/validate"
+	"github.com/google/uuid"
+)
+
+// AppReservation represents stock held for a user.
+type AppReservation struct {
+	ID           string `json:"id"`
+	ProductID    string `json:"productId"`
+	UserID       string `json:"userId"`
+	Quantity     int    `json:"quantity"`
+	Status       string `json:"status"`
+	OrderID      string `json:"orderId,omitempty"`
+	DateCreated  string `json:"dateCreated"`
+	DateExpires  string `json:"dateExpires"`
+	DateResolved string `json:"dateResolved,omitempty"`
+}
+
+func toAppReservation(res reservation.Reservation) AppReservation {
+	app := AppReservation{
+		ID:          res.ID.String(),
+		ProductID:   res.ProductID.String(),
+		UserID:      res.UserID.String(),
+		Quantity:    res.Quantity,
+		Status:      res.Status.Name(),
+		DateCreated: res.DateCreated.Format(time.RFC3339),
+		DateExpires: res.DateExpires.Format(time.RFC3339),
+	}
+
+	if res.OrderID != uuid.Nil {
+		app.OrderID = res.OrderID.String()
+	}
+	if !res.DateResolved.IsZero() {
+		app.DateResolved = res.DateResolved.Format(time.RFC3339)
+	}
+
+	return app
+}
+
+// =============================================================================
+
+// AppNewReservation contains information needed to hold stock. The TTL is a
+// duration like "10m" and defaults to the one configured for the service.
+type AppNewReservation struct {
+	ProductID string `json:"productId" validate:"required,uuid"`
+	Quantity  int    `json:"quantity" validate:"required,gte=1"`
+	TTL       string `json:"ttl"`
+}
+
+func toCoreNewReservation(app AppNewReservation, userID uuid.UUID, ttl time.Duration, maxTTL time.Duration) (reservation.NewReservation, error) {
+	productID, err := uuid.Parse(app.ProductID)
+	if err != nil {
+		return reservation.NewReservation{}, fmt.Errorf("parsing productId: %w", err)
+	}
+
+	if app.TTL != "" {
+		ttl, err = time.ParseDuration(app.TTL)
+		if err != nil {
+			return reservation.NewReservation{}, validate.NewFieldsError("ttl", err)
+		}
+		if ttl <= 0 || ttl > maxTTL {
+			return reservation.NewReservation{}, validate.NewFieldsError("ttl", fmt.Errorf("must be between 0s and %s", maxTTL))
+		}
+	}
+
+	nr := reservation.NewReservation{
+		ProductID: productID,
+		UserID:    userID,
+		Quantity:  app.Quantity,
+		TTL:       ttl,
+	}
+	return nr, nil
+}
+
+// Validate checks the data in the model is considered clean.
+func (app AppNewReservation) Validate() error {
+	if err := validate.Check(app); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+// Package reservationgrp maintains the group of handlers for stock
+// reservations.
+package reservationgrp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/aleury/service/business/core/product"
+	"github.com/aleury/service/business/core/reservation"
+	"github.com/aleury/service/business/core/user"
+	"github.com/aleury/service/business/web/auth"
+	v1 "github.com/aleury/service/business/web/v1"
+	"github.com/aleury/service/business/web/v1/mid"
+	"github.com/aleury/service/business/web/v1/paging"
+	"github.com/aleury/service/foundation/web"
+	"github.com/google/uuid"
+)
+
+// Config contains the settings for holding stock.
+type Config struct {
+	TTL    time.Duration
+	MaxTTL time.Duration
+}
+
+// Handlers manages the set of reservation endpoints.
+type Handlers struct {
+	reservation *reservation.Core
+	cfg         Config
+}
+
+// New constructs a handlers for route access.
+func New(reservation *reservation.Core, cfg Config) *Handlers {
+	return &Handlers{
+		reservation: reservation,
+		cfg:         cfg,
+	}
+}
+
+// Create holds stock of a product for the authenticated user.
+func (h *Handlers) Create(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	var app AppNewReservation
+	if err := web.Decode(r, &app); err != nil {
+		return err
+	}
+
+	userID, err := uuid.Parse(auth.GetClaims(ctx).Subject)
+	if err != nil {
+		return v1.NewRequestError(mid.ErrInvalidID, http.StatusBadRequest)
+	}
+
+	nr, err := toCoreNewReservation(app, userID, h.cfg.TTL, h.cfg.MaxTTL)
+	if err != nil {
+		return v1.NewRequestError(err, http.StatusBadRequest)
+	}
+
+	res, err := h.reservation.Reserve(ctx, nr)
+	if err != nil {
+		switch {
+		case errors.Is(err, product.ErrNotFound):
+			return v1.NewRequestError(err, http.StatusBadRequest)
+		case errors.Is(err, reservation.ErrUnavailable), errors.Is(err, product.ErrHasVariants):
+			return v1.NewRequestError(err, http.StatusConflict)
+		default:
+			return fmt.Errorf("reserve: app[%+v]: %w", app, err)
+		}
+	}
+
+	return web.Respond(ctx, w, toAppReservation(res), http.StatusCreated)
+}
+
+// Query returns the reservations of the authenticated user with paging.
+func (h *Handlers) Query(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	page, err := paging.ParseRequest(r)
+	if err != nil {
+		return err
+	}
+
+	userID, err := uuid.Parse(auth.GetClaims(ctx).Subject)
+	if err != nil {
+		return v1.NewRequestError(mid.ErrInvalidID, http.StatusBadRequest)
+	}
+
+	rsvs, err := h.reservation.QueryByUser(ctx, userID, page.Number, page.RowsPerPage)
+	if err != nil {
+		return fmt.Errorf("querybyuser: userID[%s]: %w", userID, err)
+	}
+
+	items := make([]AppReservation, len(rsvs))
+	for i, res := range rsvs {
+		items[i] = toAppReservation(res)
+	}
+
+	total, err := h.reservation.CountByUser(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("countbyuser: userID[%s]: %w", userID, err)
+	}
+
+	return web.Respond(ctx, w, paging.NewResponse(items, total, page.Number, page.RowsPerPage), http.StatusOK)
+}
+
+// QueryByID returns a reservation by its ID.
+func (h *Handlers) QueryByID(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	res, err := h.queryByID(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	return web.Respond(ctx, w, toAppReservation(res), http.StatusOK)
+}
+
+// Release gives the stock held by a reservation back.
+func (h *Handlers) Release(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	res, err := h.queryByID(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	res, err = h.reservation.Release(ctx, res)
+	if err != nil {
+		switch {
+		case errors.Is(err, reservation.ErrNotHeld):
+			return v1.NewRequestError(err, http.StatusConflict)
+		default:
+			return fmt.Errorf("release: reservationID[%s]: %w", res.ID, err)
+		}
+	}
+
+	return web.Respond(ctx, w, toAppReservation(res), http.StatusOK)
+}
+
+// queryByID loads the reservation named in the path. Users other than admins
+// can only access their own reservations.
+func (h *Handlers) queryByID(ctx context.Context, r *http.Request) (reservation.Reservation, error) {
+	reservationID, err := uuid.Parse(web.Param(r, "reservation_id"))
+	if err != nil {
+		return reservation.Reservation{}, v1.NewRequestError(mid.ErrInvalidID, http.StatusBadRequest)
+	}
+
+	res, err := h.reservation.QueryByID(ctx, reservationID)
+	if err != nil {
+		switch {
+		case errors.Is(err, reservation.ErrNotFound):
+			return reservation.Reservation{}, v1.NewRequestError(err, http.StatusNotFound)
+		default:
+			return reservation.Reservation{}, fmt.Errorf("querybyid: reservationID[%s]: %w", reservationID, err)
+		}
+	}
+
+	claims := auth.GetClaims(ctx)
+	if !claims.HasRole(user.RoleAdmin) && claims.Subject != res.UserID.String() {
+		return reservation.Reservation{}, v1.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,168 @@
+package scimgrp
+
+import (
+	"fmt"
+	"net/http"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/aleury/service/business/core/user"
+	"github.com/aleury/service/business/web/scim"
+	"github.com/google/uuid"
+)
+
+// parseUserFilter translates the filter query parameter into a user filter.
+// Only the comparisons the user store can answer exactly are supported.
+func parseUserFilter(r *http.Request) (user.QueryFilter, error) {
+	var filter user.QueryFilter
+
+	expr := r.URL.Query().Get("filter")
+	if expr == "" {
+		return filter, nil
+	}
+
+	conds, err := scim.ParseFilter(expr)
+	if err != nil {
+		return user.QueryFilter{}, err
+	}
+
+	for _, cond := range conds {
+		switch {
+		case cond.Operator == scim.OpEqual && (cond.Attribute == "username" || cond.Attribute == "emails" || cond.Attribute == "emails.value"):
+			s, err := stringValue(cond)
+			if err != nil {
+				return user.QueryFilter{}, err
+			}
+			addr, err := mail.ParseAddress(s)
+			if err != nil {
+				return user.QueryFilter{}, invalidFilter("%s: %s", cond.Attribute, err)
+			}
+			filter.WithEmail(*addr)
+
+		case cond.Operator == scim.OpEqual && cond.Attribute == "id":
+			s, err := stringValue(cond)
+			if err != nil {
+				return user.QueryFilter{}, err
+			}
+			id, err := uuid.Parse(s)
+			if err != nil {
+				return user.QueryFilter{}, invalidFilter("id: %s", err)
+			}
+			filter.WithUserID(id)
+
+		case cond.Operator == scim.OpContains && (cond.Attribute == "displayname" || cond.Attribute == "name.formatted"):
+			s, err := stringValue(cond)
+			if err != nil {
+				return user.QueryFilter{}, err
+			}
+			filter.WithName(s)
+
+		case cond.Operator == scim.OpEqual && cond.Attribute == "active":
+			b, ok := cond.Value.(bool)
+			if !ok {
+				return user.QueryFilter{}, invalidFilter("active: expected a boolean")
+			}
+			filter.WithEnabled(b)
+
+		case cond.Attribute == "meta.created" && cond.Operator == scim.OpGreaterOrEq:
+			t, err := timeValue(cond)
+			if err != nil {
+				return user.QueryFilter{}, err
+			}
+			filter.WithStartCreatedDate(t)
+
+		case cond.Attribute == "meta.created" && cond.Operator == scim.OpLessOrEqualTo:
+			t, err := timeValue(cond)
+			if err != nil {
+				return user.QueryFilter{}, err
+			}
+			filter.WithEndCreatedDate(t)
+
+		case cond.Attribute == "groups" || cond.Attribute == "groups.value":
+			if cond.Operator != scim.OpEqual {
+				return user.QueryFilter{}, invalidFilter("%s: only eq is supported", cond.Attribute)
+			}
+			s, err := stringValue(cond)
+			if err != nil {
+				return user.QueryFilter{}, err
+			}
+			role, err := user.ParseRole(s)
+			if err != nil {
+				return user.QueryFilter{}, invalidFilter("%s: %s", cond.Attribute, err)
+			}
+			filter.WithRole(role)
+
+		default:
+			return user.QueryFilter{}, invalidFilter("unsupported filter on %q with %q", cond.Attribute, cond.Operator)
+		}
+	}
+
+	if err := filter.Validate(); err != nil {
+		return user.QueryFilter{}, invalidFilter("%s", err)
+	}
+
+	return filter, nil
+}
+
+// parseGroupFilter returns the groups selected by the filter query parameter.
+func parseGroupFilter(r *http.Request) ([]user.Role, error) {
+	expr := r.URL.Query().Get("filter")
+	if expr == "" {
+		return groups, nil
+	}
+
+	conds, err := scim.ParseFilter(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	selected := groups
+	for _, cond := range conds {
+		if cond.Operator != scim.OpEqual || (cond.Attribute != "displayname" && cond.Attribute != "id") {
+			return nil, invalidFilter("unsupported filter on %q with %q", cond.Attribute, cond.Operator)
+		}
+
+		s, err := stringValue(cond)
+		if err != nil {
+			return nil, err
+		}
+
+		var matched []user.Role
+		for _, role := range selected {
+			if strings.EqualFold(role.Name(), s) {
+				matched = append(matched, role)
+			}
+		}
+		selected = matched
+	}
+
+	return selected, nil
+}
+
+// =============================================================================
+
+func stringValue(cond scim.Condition) (string, error) {
+	s, ok := cond.Value.(string)
+	if !ok {
+		return "", invalidFilter("%s: expected a string", cond.Attribute)
+	}
+	return s, nil
+}
+
+func timeValue(cond scim.Condition) (time.Time, error) {
+	s, err := stringValue(cond)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, invalidFilter("%s: %s", cond.Attribute, err)
+	}
+	return t, nil
+}
+
+func invalidFilter(format string, args ...any) error {
+	return scim.NewError(http.StatusBadRequest, scim.TypeInvalidFilter, fmt.Sprintf(format, args...))
+}
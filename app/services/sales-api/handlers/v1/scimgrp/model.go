@@ -0,0 +1,227 @@
+package scimgrp
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/aleury/service/business/core/user"
+	"github.com/aleury/service/business/web/scim"
+)
+
+// groups are the roles exposed as SCIM groups. Roles are fixed, so groups
+// can't be created or deleted, only have their members changed.
+var groups = []user.Role{user.RoleAdmin, user.RoleDepartmentAdmin, user.RoleUser}
+
+// AppMeta represents the resource metadata.
+type AppMeta struct {
+	ResourceType string `json:"resourceType"`
+	Created      string `json:"created,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+	Location     string `json:"location"`
+}
+
+// AppName represents the components of a user's name.
+type AppName struct {
+	Formatted  string `json:"formatted,omitempty"`
+	GivenName  string `json:"givenName,omitempty"`
+	FamilyName string `json:"familyName,omitempty"`
+}
+
+// AppEmail represents an email address of a user.
+type AppEmail struct {
+	Value   string `json:"value"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+// AppGroupRef represents a group a user is a member of.
+type AppGroupRef struct {
+	Value   string `json:"value"`
+	Display string `json:"display,omitempty"`
+	Ref     string `json:"$ref,omitempty"`
+}
+
+// AppUser represents a user as a SCIM resource. The userName is the email
+// address the user signs in with.
+type AppUser struct {
+	Schemas     []string      `json:"schemas"`
+	ID          string        `json:"id"`
+	UserName    string        `json:"userName"`
+	Name        AppName       `json:"name"`
+	DisplayName string        `json:"displayName"`
+	Emails      []AppEmail    `json:"emails"`
+	Active      bool          `json:"active"`
+	Groups      []AppGroupRef `json:"groups"`
+	Meta        AppMeta       `json:"meta"`
+}
+
+func toAppUser(usr user.User) AppUser {
+	grps := make([]AppGroupRef, len(usr.Roles))
+	for i, role := range usr.Roles {
+		grps[i] = AppGroupRef{
+			Value:   role.Name(),
+			Display: role.Name(),
+			Ref:     groupLocation(role),
+		}
+	}
+
+	return AppUser{
+		Schemas:     []string{scim.SchemaUser},
+		ID:          usr.ID.String(),
+		UserName:    usr.Email.Address,
+		Name:        AppName{Formatted: usr.Name},
+		DisplayName: usr.Name,
+		Emails:      []AppEmail{{Value: usr.Email.Address, Type: "work", Primary: true}},
+		Active:      usr.Enabled,
+		Groups:      grps,
+		Meta: AppMeta{
+			ResourceType: "User",
+			Created:      usr.DateCreated.UTC().Format(time.RFC3339),
+			LastModified: usr.DateUpdated.UTC().Format(time.RFC3339),
+			Location:     "/scim/v2/Users/" + usr.ID.String(),
+		},
+	}
+}
+
+// =============================================================================
+
+// AppNewUser contains the information a client provides when creating or
+// replacing a user. Attributes the service doesn't store are ignored.
+type AppNewUser struct {
+	Schemas     []string   `json:"schemas"`
+	UserName    string     `json:"userName" validate:"required"`
+	Name        AppName    `json:"name"`
+	DisplayName string     `json:"displayName"`
+	Emails      []AppEmail `json:"emails"`
+	Active      *bool      `json:"active"`
+	Password    string     `json:"password"`
+}
+
+// Validate checks the data in the model is considered clean.
+func (app AppNewUser) Validate() error {
+	if _, err := mail.ParseAddress(app.UserName); err != nil {
+		return scim.NewError(http.StatusBadRequest, scim.TypeInvalidValue, fmt.Sprintf("userName must be an email address: %s", err))
+	}
+	return nil
+}
+
+// displayName picks the best available name for the user.
+func (app AppNewUser) displayName() string {
+	switch {
+	case app.DisplayName != "":
+		return app.DisplayName
+	case app.Name.Formatted != "":
+		return app.Name.Formatted
+	case app.Name.GivenName != "" || app.Name.FamilyName != "":
+		return strings.TrimSpace(app.Name.GivenName + " " + app.Name.FamilyName)
+	default:
+		return app.UserName
+	}
+}
+
+func toCoreNewUser(app AppNewUser) (user.NewUser, error) {
+	addr, err := mail.ParseAddress(app.UserName)
+	if err != nil {
+		return user.NewUser{}, fmt.Errorf("parsing userName: %w", err)
+	}
+
+	// Provisioned users usually sign in through the identity provider, so
+	// when no password is given they receive one nobody knows.
+	password := app.Password
+	if password == "" {
+		password, err = randomPassword()
+		if err != nil {
+			return user.NewUser{}, err
+		}
+	}
+
+	nu := user.NewUser{
+		Name:            app.displayName(),
+		Email:           *addr,
+		Roles:           []user.Role{user.RoleUser},
+		Password:        password,
+		PasswordConfirm: password,
+	}
+	return nu, nil
+}
+
+func toCoreReplaceUser(app AppNewUser) (user.UpdateUser, error) {
+	addr, err := mail.ParseAddress(app.UserName)
+	if err != nil {
+		return user.UpdateUser{}, fmt.Errorf("parsing userName: %w", err)
+	}
+
+	name := app.displayName()
+	uu := user.UpdateUser{
+		Name:  &name,
+		Email: addr,
+	}
+
+	if app.Active != nil {
+		uu.Enabled = app.Active
+	}
+
+	if app.Password != "" {
+		uu.Password = &app.Password
+		uu.PasswordConfirm = &app.Password
+	}
+
+	return uu, nil
+}
+
+func randomPassword() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generating password: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// =============================================================================
+
+// AppMember represents a member of a group.
+type AppMember struct {
+	Value   string `json:"value"`
+	Display string `json:"display,omitempty"`
+	Ref     string `json:"$ref,omitempty"`
+}
+
+// AppGroup represents a role as a SCIM group resource.
+type AppGroup struct {
+	Schemas     []string    `json:"schemas"`
+	ID          string      `json:"id"`
+	DisplayName string      `json:"displayName"`
+	Members     []AppMember `json:"members,omitempty"`
+	Meta        AppMeta     `json:"meta"`
+}
+
+func toAppGroup(role user.Role, members []user.User) AppGroup {
+	mbrs := make([]AppMember, len(members))
+	for i, usr := range members {
+		mbrs[i] = AppMember{
+			Value:   usr.ID.String(),
+			Display: usr.Name,
+			Ref:     "/scim/v2/Users/" + usr.ID.String(),
+		}
+	}
+
+	return AppGroup{
+		Schemas:     []string{scim.SchemaGroup},
+		ID:          role.Name(),
+		DisplayName: role.Name(),
+		Members:     mbrs,
+		Meta: AppMeta{
+			ResourceType: "Group",
+			Location:     groupLocation(role),
+		},
+	}
+}
+
+func groupLocation(role user.Role) string {
+	return "/scim/v2/Groups/" + role.Name()
+}
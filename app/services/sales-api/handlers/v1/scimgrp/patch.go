@@ -0,0 +1,210 @@
+package scimgrp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"strings"
+
+	"github.com/aleury/service/business/core/user"
+	"github.com/aleury/service/business/web/scim"
+	"github.com/google/uuid"
+)
+
+// toCoreUserPatch translates the operations of a user PATCH request into a
+// user update. Operations without a path carry an object of attributes to
+// replace, which is the form several identity providers use.
+func toCoreUserPatch(pr scim.PatchRequest) (user.UpdateUser, error) {
+	var uu user.UpdateUser
+
+	for _, op := range pr.Operations {
+		if op.Op == scim.PatchRemove {
+			return user.UpdateUser{}, scim.NewError(http.StatusBadRequest, scim.TypeMutability, fmt.Sprintf("%s: attribute can't be removed", op.Path))
+		}
+
+		if op.Path != "" {
+			if err := applyUserAttribute(&uu, op.Path, op.Value); err != nil {
+				return user.UpdateUser{}, err
+			}
+			continue
+		}
+
+		var attrs map[string]json.RawMessage
+		if err := json.Unmarshal(op.Value, &attrs); err != nil {
+			return user.UpdateUser{}, scim.NewError(http.StatusBadRequest, scim.TypeInvalidValue, "value must be an object when no path is provided")
+		}
+
+		for path, value := range attrs {
+			if err := applyUserAttribute(&uu, path, value); err != nil {
+				return user.UpdateUser{}, err
+			}
+		}
+	}
+
+	return uu, nil
+}
+
+func applyUserAttribute(uu *user.UpdateUser, path string, value json.RawMessage) error {
+	switch strings.ToLower(path) {
+	case "active":
+		var active bool
+		if err := decodeValue(path, value, &active); err != nil {
+			return err
+		}
+		uu.Enabled = &active
+
+	case "username":
+		var s string
+		if err := decodeValue(path, value, &s); err != nil {
+			return err
+		}
+		addr, err := mail.ParseAddress(s)
+		if err != nil {
+			return scim.NewError(http.StatusBadRequest, scim.TypeInvalidValue, fmt.Sprintf("%s: %s", path, err))
+		}
+		uu.Email = addr
+
+	case "displayname", "name.formatted":
+		var s string
+		if err := decodeValue(path, value, &s); err != nil {
+			return err
+		}
+		uu.Name = &s
+
+	case "name":
+		var name AppName
+		if err := decodeValue(path, value, &name); err != nil {
+			return err
+		}
+		s := name.Formatted
+		if s == "" {
+			s = strings.TrimSpace(name.GivenName + " " + name.FamilyName)
+		}
+		if s != "" {
+			uu.Name = &s
+		}
+
+	case "password":
+		var s string
+		if err := decodeValue(path, value, &s); err != nil {
+			return err
+		}
+		uu.Password = &s
+		uu.PasswordConfirm = &s
+
+	default:
+		return scim.NewError(http.StatusBadRequest, scim.TypeInvalidPath, fmt.Sprintf("%s: attribute can't be modified", path))
+	}
+
+	return nil
+}
+
+func decodeValue(path string, value json.RawMessage, v any) error {
+	if err := json.Unmarshal(value, v); err != nil {
+		return scim.NewError(http.StatusBadRequest, scim.TypeInvalidValue, fmt.Sprintf("%s: %s", path, err))
+	}
+	return nil
+}
+
+// =============================================================================
+
+// memberChange describes how a group PATCH operation changes membership.
+// A nil IDs slice on a removal means every member is removed.
+type memberChange struct {
+	Op  string
+	IDs []uuid.UUID
+}
+
+// toMemberChanges translates the operations of a group PATCH request.
+// Members are addressed either through the value or through a path of the
+// form members[value eq "id"].
+func toMemberChanges(pr scim.PatchRequest) ([]memberChange, error) {
+	changes := make([]memberChange, 0, len(pr.Operations))
+
+	for _, op := range pr.Operations {
+		path := strings.ToLower(op.Path)
+
+		switch {
+		case path == "members" || (path == "" && op.Op != scim.PatchRemove):
+			ids, err := memberValues(op)
+			if err != nil {
+				return nil, err
+			}
+			if op.Op == scim.PatchRemove && len(ids) == 0 {
+				ids = nil
+			}
+			changes = append(changes, memberChange{Op: op.Op, IDs: ids})
+
+		case strings.HasPrefix(path, "members[") && strings.HasSuffix(path, "]"):
+			if op.Op != scim.PatchRemove {
+				return nil, scim.NewError(http.StatusBadRequest, scim.TypeInvalidPath, fmt.Sprintf("%s: only remove is supported", op.Path))
+			}
+			id, err := memberPath(op.Path)
+			if err != nil {
+				return nil, err
+			}
+			changes = append(changes, memberChange{Op: op.Op, IDs: []uuid.UUID{id}})
+
+		default:
+			return nil, scim.NewError(http.StatusBadRequest, scim.TypeInvalidPath, fmt.Sprintf("%s: only members can be modified", op.Path))
+		}
+	}
+
+	return changes, nil
+}
+
+func memberValues(op scim.PatchOperation) ([]uuid.UUID, error) {
+	if len(op.Value) == 0 {
+		return []uuid.UUID{}, nil
+	}
+
+	// Without a path the value is an object holding the members.
+	value := op.Value
+	if op.Path == "" {
+		var attrs struct {
+			Members json.RawMessage `json:"members"`
+		}
+		if err := json.Unmarshal(value, &attrs); err != nil || attrs.Members == nil {
+			return nil, scim.NewError(http.StatusBadRequest, scim.TypeInvalidValue, "value must hold members when no path is provided")
+		}
+		value = attrs.Members
+	}
+
+	var members []AppMember
+	if err := json.Unmarshal(value, &members); err != nil {
+		return nil, scim.NewError(http.StatusBadRequest, scim.TypeInvalidValue, fmt.Sprintf("members: %s", err))
+	}
+
+	ids := make([]uuid.UUID, len(members))
+	for i, mbr := range members {
+		id, err := uuid.Parse(mbr.Value)
+		if err != nil {
+			return nil, scim.NewError(http.StatusBadRequest, scim.TypeInvalidValue, fmt.Sprintf("members: %s", err))
+		}
+		ids[i] = id
+	}
+
+	return ids, nil
+}
+
+func memberPath(path string) (uuid.UUID, error) {
+	expr := path[strings.Index(path, "[")+1 : len(path)-1]
+
+	conds, err := scim.ParseFilter(expr)
+	if err != nil {
+		return uuid.UUID{}, scim.NewError(http.StatusBadRequest, scim.TypeInvalidPath, fmt.Sprintf("%s: %s", path, err))
+	}
+
+	if len(conds) != 1 || conds[0].Attribute != "value" || conds[0].Operator != scim.OpEqual {
+		return uuid.UUID{}, scim.NewError(http.StatusBadRequest, scim.TypeInvalidPath, fmt.Sprintf("%s: only value eq is supported", path))
+	}
+
+	s, _ := conds[0].Value.(string)
+	id, err := uuid.Parse(s)
+	if err != nil {
+		return uuid.UUID{}, scim.NewError(http.StatusBadRequest, scim.TypeInvalidPath, fmt.Sprintf("%s: %s", path, err))
+	}
+
+	return id, nil
+}
@@ -0,0 +1,416 @@
+// Package scimgrp maintains the group of handlers for SCIM 2.0 provisioning
+// of users and their roles.
+package scimgrp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/aleury/service/business/core/tenant"
+	"github.com/aleury/service/business/core/user"
+	"github.com/aleury/service/business/web/scim"
+	"github.com/aleury/service/foundation/web"
+	"github.com/google/uuid"
+)
+
+// Handlers manages the set of SCIM endpoints.
+type Handlers struct {
+	user *user.Core
+}
+
+// New constructs a handlers for route access.
+func New(user *user.Core) *Handlers {
+	return &Handlers{
+		user: user,
+	}
+}
+
+// CreateUser provisions a new user. Users start with the USER role; other
+// roles are granted through group membership.
+func (h *Handlers) CreateUser(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	var app AppNewUser
+	if err := scim.Decode(r, &app); err != nil {
+		return err
+	}
+
+	nu, err := toCoreNewUser(app)
+	if err != nil {
+		return scim.NewError(http.StatusBadRequest, scim.TypeInvalidValue, err.Error())
+	}
+	nu.TenantID = tenant.GetTenantID(ctx)
+
+	usr, err := h.user.Create(ctx, nu)
+	if err != nil {
+		if errors.Is(err, user.ErrUniqueEmail) {
+			return scim.NewError(http.StatusConflict, scim.TypeUniqueness, err.Error())
+		}
+		return fmt.Errorf("create: email[%s]: %w", nu.Email.Address, err)
+	}
+
+	if app.Active != nil && !*app.Active {
+		usr, err = h.user.Update(ctx, usr, user.UpdateUser{Enabled: app.Active})
+		if err != nil {
+			return fmt.Errorf("update: userID[%s]: %w", usr.ID, err)
+		}
+	}
+
+	return scim.Respond(ctx, w, toAppUser(usr), http.StatusCreated)
+}
+
+// ReplaceUser replaces the attributes of a user.
+func (h *Handlers) ReplaceUser(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	var app AppNewUser
+	if err := scim.Decode(r, &app); err != nil {
+		return err
+	}
+
+	usr, err := h.queryUser(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	uu, err := toCoreReplaceUser(app)
+	if err != nil {
+		return scim.NewError(http.StatusBadRequest, scim.TypeInvalidValue, err.Error())
+	}
+
+	return h.updateUser(ctx, w, usr, uu)
+}
+
+// PatchUser applies a set of PATCH operations to a user.
+func (h *Handlers) PatchUser(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	var pr scim.PatchRequest
+	if err := scim.Decode(r, &pr); err != nil {
+		return err
+	}
+
+	usr, err := h.queryUser(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	uu, err := toCoreUserPatch(pr)
+	if err != nil {
+		return err
+	}
+
+	return h.updateUser(ctx, w, usr, uu)
+}
+
+// DeleteUser removes a user. The user is deleted softly like through the
+// rest of the API, so it can still be restored by an admin.
+func (h *Handlers) DeleteUser(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	usr, err := h.queryUser(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	if err := h.user.Delete(ctx, usr); err != nil {
+		return fmt.Errorf("delete: userID[%s]: %w", usr.ID, err)
+	}
+
+	return scim.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+// QueryUsers returns a page of users matching the filter.
+func (h *Handlers) QueryUsers(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	page, err := scim.ParsePage(r)
+	if err != nil {
+		return err
+	}
+
+	filter, err := parseUserFilter(r)
+	if err != nil {
+		return err
+	}
+
+	usrs, total, err := h.queryPage(ctx, filter, page)
+	if err != nil {
+		return err
+	}
+
+	items := make([]AppUser, len(usrs))
+	for i, usr := range usrs {
+		items[i] = toAppUser(usr)
+	}
+
+	return scim.Respond(ctx, w, scim.NewListResponse(items, total, page.StartIndex), http.StatusOK)
+}
+
+// QueryUserByID returns a single user.
+func (h *Handlers) QueryUserByID(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	usr, err := h.queryUser(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	return scim.Respond(ctx, w, toAppUser(usr), http.StatusOK)
+}
+
+// =============================================================================
+
+// QueryGroups returns the groups matching the filter. There are only a few
+// fixed groups, so paging applies to the list of groups in memory.
+func (h *Handlers) QueryGroups(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	page, err := scim.ParsePage(r)
+	if err != nil {
+		return err
+	}
+
+	roles, err := parseGroupFilter(r)
+	if err != nil {
+		return err
+	}
+
+	total := len(roles)
+	start := min(page.StartIndex-1, total)
+	end := min(start+page.Count, total)
+
+	items := make([]AppGroup, 0, end-start)
+	for _, role := range roles[start:end] {
+		grp, err := h.group(ctx, role, r)
+		if err != nil {
+			return err
+		}
+		items = append(items, grp)
+	}
+
+	return scim.Respond(ctx, w, scim.NewListResponse(items, total, page.StartIndex), http.StatusOK)
+}
+
+// QueryGroupByID returns a single group.
+func (h *Handlers) QueryGroupByID(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	role, err := parseGroupID(r)
+	if err != nil {
+		return err
+	}
+
+	grp, err := h.group(ctx, role, r)
+	if err != nil {
+		return err
+	}
+
+	return scim.Respond(ctx, w, grp, http.StatusOK)
+}
+
+// PatchGroup changes the members of a group, which grants or revokes the
+// role for the users involved.
+func (h *Handlers) PatchGroup(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	var pr scim.PatchRequest
+	if err := scim.Decode(r, &pr); err != nil {
+		return err
+	}
+
+	role, err := parseGroupID(r)
+	if err != nil {
+		return err
+	}
+
+	changes, err := toMemberChanges(pr)
+	if err != nil {
+		return err
+	}
+
+	for _, chg := range changes {
+		if err := h.applyMemberChange(ctx, role, chg); err != nil {
+			return err
+		}
+	}
+
+	grp, err := h.group(ctx, role, r)
+	if err != nil {
+		return err
+	}
+
+	return scim.Respond(ctx, w, grp, http.StatusOK)
+}
+
+// =============================================================================
+
+// queryUser loads the user identified by the id route parameter.
+func (h *Handlers) queryUser(ctx context.Context, r *http.Request) (user.User, error) {
+	id := web.Param(r, "id")
+
+	userID, err := uuid.Parse(id)
+	if err != nil {
+		return user.User{}, scim.NewError(http.StatusNotFound, "", fmt.Sprintf("user %s not found", id))
+	}
+
+	usr, err := h.user.QueryByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, user.ErrNotFound) {
+			return user.User{}, scim.NewError(http.StatusNotFound, "", fmt.Sprintf("user %s not found", id))
+		}
+		return user.User{}, fmt.Errorf("querybyid: userID[%s]: %w", userID, err)
+	}
+
+	return usr, nil
+}
+
+func (h *Handlers) updateUser(ctx context.Context, w http.ResponseWriter, usr user.User, uu user.UpdateUser) error {
+	usr, err := h.user.Update(ctx, usr, uu)
+	if err != nil {
+		if errors.Is(err, user.ErrUniqueEmail) {
+			return scim.NewError(http.StatusConflict, scim.TypeUniqueness, err.Error())
+		}
+		return fmt.Errorf("update: userID[%s]: %w", usr.ID, err)
+	}
+
+	return scim.Respond(ctx, w, toAppUser(usr), http.StatusOK)
+}
+
+// queryPage returns the window of users requested by a SCIM page. The store
+// pages by page number, so windows that don't line up with a page are read
+// from the start and trimmed.
+func (h *Handlers) queryPage(ctx context.Context, filter user.QueryFilter, page scim.Page) ([]user.User, int, error) {
+	total, err := h.user.Count(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("count: %w", err)
+	}
+
+	offset := page.StartIndex - 1
+	if page.Count == 0 || offset >= total {
+		return nil, total, nil
+	}
+
+	if offset%page.Count == 0 {
+		usrs, err := h.user.Query(ctx, filter, user.DefaultOrderBy, offset/page.Count+1, page.Count)
+		if err != nil {
+			return nil, 0, fmt.Errorf("query: %w", err)
+		}
+		return usrs, total, nil
+	}
+
+	usrs, err := h.user.Query(ctx, filter, user.DefaultOrderBy, 1, offset+page.Count)
+	if err != nil {
+		return nil, 0, fmt.Errorf("query: %w", err)
+	}
+
+	return usrs[min(offset, len(usrs)):], total, nil
+}
+
+// group builds the group for a role. Members are left out when the client
+// excludes them, which avoids loading every user of a large group.
+func (h *Handlers) group(ctx context.Context, role user.Role, r *http.Request) (AppGroup, error) {
+	if strings.Contains(strings.ToLower(r.URL.Query().Get("excludedAttributes")), "members") {
+		return toAppGroup(role, nil), nil
+	}
+
+	members, err := h.members(ctx, role)
+	if err != nil {
+		return AppGroup{}, err
+	}
+
+	return toAppGroup(role, members), nil
+}
+
+// members returns every user holding the role.
+func (h *Handlers) members(ctx context.Context, role user.Role) ([]user.User, error) {
+	var filter user.QueryFilter
+	filter.WithRole(role)
+
+	var members []user.User
+	for pageNumber := 1; ; pageNumber++ {
+		usrs, err := h.user.Query(ctx, filter, user.DefaultOrderBy, pageNumber, scim.MaxCount)
+		if err != nil {
+			return nil, fmt.Errorf("query: role[%s]: %w", role.Name(), err)
+		}
+
+		members = append(members, usrs...)
+		if len(usrs) < scim.MaxCount {
+			return members, nil
+		}
+	}
+}
+
+func (h *Handlers) applyMemberChange(ctx context.Context, role user.Role, chg memberChange) error {
+	ids := make(map[uuid.UUID]bool, len(chg.IDs))
+	for _, id := range chg.IDs {
+		ids[id] = true
+	}
+
+	// Removing without naming members and replacing both act on everyone
+	// currently holding the role.
+	if chg.Op == scim.PatchReplace || (chg.Op == scim.PatchRemove && chg.IDs == nil) {
+		members, err := h.members(ctx, role)
+		if err != nil {
+			return err
+		}
+
+		for _, usr := range members {
+			if chg.Op == scim.PatchReplace && ids[usr.ID] {
+				delete(ids, usr.ID)
+				continue
+			}
+			if err := h.setRole(ctx, usr, role, false); err != nil {
+				return err
+			}
+		}
+
+		if chg.Op == scim.PatchRemove {
+			return nil
+		}
+	}
+
+	grant := chg.Op != scim.PatchRemove
+	for id := range ids {
+		usr, err := h.user.QueryByID(ctx, id)
+		if err != nil {
+			if errors.Is(err, user.ErrNotFound) {
+				return scim.NewError(http.StatusBadRequest, scim.TypeInvalidValue, fmt.Sprintf("member %s not found", id))
+			}
+			return fmt.Errorf("querybyid: userID[%s]: %w", id, err)
+		}
+
+		if err := h.setRole(ctx, usr, role, grant); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// setRole grants or revokes the role for the user, leaving the user alone
+// when nothing changes.
+func (h *Handlers) setRole(ctx context.Context, usr user.User, role user.Role, grant bool) error {
+	roles := make([]user.Role, 0, len(usr.Roles)+1)
+	var has bool
+	for _, r := range usr.Roles {
+		if r.Equal(role) {
+			has = true
+			if !grant {
+				continue
+			}
+		}
+		roles = append(roles, r)
+	}
+
+	if has == grant {
+		return nil
+	}
+	if grant {
+		roles = append(roles, role)
+	}
+
+	if _, err := h.user.Update(ctx, usr, user.UpdateUser{Roles: roles}); err != nil {
+		return fmt.Errorf("update: userID[%s]: %w", usr.ID, err)
+	}
+
+	return nil
+}
+
+// parseGroupID returns the role identified by the id route parameter.
+func parseGroupID(r *http.Request) (user.Role, error) {
+	id := web.Param(r, "id")
+
+	for _, role := range groups {
+		if strings.EqualFold(role.Name(), id) {
+			return role, nil
+		}
+	}
+
+	return user.Role{}, scim.NewError(http.StatusNotFound, "", fmt.Sprintf("group %s not found", id))
+}
@@ -0,0 +1,55 @@
+package sessiongrp
+
+import (
+	"time"
+
+	"github.com/aleury/service/business/core/session"
+	"github.com/aleury/service/business/sys/validate"
+)
+
+// AppSession represents information about a browser session.
+type AppSession struct {
+	ID           string `json:"id"`
+	UserID       string `json:"userId"`
+	UserAgent    string `json:"userAgent"`
+	IPAddress    string `json:"ipAddress"`
+	DateCreated  string `json:"dateCreated"`
+	DateLastSeen string `json:"dateLastSeen"`
+	DateExpires  string `json:"dateExpires"`
+}
+
+func toAppSession(sess session.Session) AppSession {
+	return AppSession{
+		ID:           sess.ID.String(),
+		UserID:       sess.UserID.String(),
+		UserAgent:    sess.UserAgent,
+		IPAddress:    sess.IPAddress,
+		DateCreated:  sess.DateCreated.Format(time.RFC3339),
+		DateLastSeen: sess.DateLastSeen.Format(time.RFC3339),
+		DateExpires:  sess.DateExpires.Format(time.RFC3339),
+	}
+}
+
+// AppSignedIn is returned when a session is started. The CSRF token is also
+// set as a cookie and has to be sent back in the CSRF header on requests
+// that change state.
+type AppSignedIn struct {
+	Session   AppSession `json:"session"`
+	CSRFToken string     `json:"csrfToken"`
+}
+
+// =============================================================================
+
+// AppLogin contains the credentials needed to start a session.
+type AppLogin struct {
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required"`
+}
+
+// Validate checks the data in the model is considered clean.
+func (app AppLogin) Validate() error {
+	if err := validate.Check(app); err != nil {
+		return err
+	}
+	return nil
+}
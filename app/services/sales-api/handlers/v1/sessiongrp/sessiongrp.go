@@ -0,0 +1,251 @@
+// Package sessiongrp maintains the group of handlers for browser sessions.
+package sessiongrp
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/mail"
+	"time"
+
+	"github.com/aleury/service/business/core/session"
+	"github.com/aleury/service/business/core/user"
+	"github.com/aleury/service/business/web/auth"
+	v1 "github.com/aleury/service/business/web/v1"
+	"github.com/aleury/service/business/web/v1/mid"
+	"github.com/aleury/service/business/web/v1/paging"
+	"github.com/aleury/service/foundation/web"
+	"github.com/google/uuid"
+)
+
+// Set of error variables for handling session group errors.
+var (
+	ErrNoSession = errors.New("request is not authenticated with a session")
+)
+
+// Handlers manages the set of session endpoints.
+type Handlers struct {
+	session *session.Core
+	user    *user.Core
+	ttl     time.Duration
+}
+
+// New constructs a handlers for route access.
+func New(session *session.Core, user *user.Core, ttl time.Duration) *Handlers {
+	return &Handlers{
+		session: session,
+		user:    user,
+		ttl:     ttl,
+	}
+}
+
+// Create signs a user in with their email and password and starts a browser
+// session.
+func (h *Handlers) Create(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	var app AppLogin
+	if err := web.Decode(r, &app); err != nil {
+		return err
+	}
+
+	addr, err := mail.ParseAddress(app.Email)
+	if err != nil {
+		return v1.NewRequestError(err, http.StatusBadRequest)
+	}
+
+	usr, err := h.user.Authenticate(ctx, *addr, app.Password)
+	if err != nil {
+		switch {
+		case errors.Is(err, user.ErrNotFound), errors.Is(err, user.ErrAuthenticationFailure):
+			return auth.NewAuthError("session: %s", user.ErrAuthenticationFailure)
+		default:
+			return fmt.Errorf("authenticate: %w", err)
+		}
+	}
+
+	if !usr.Enabled {
+		return auth.NewAuthError("session: user is disabled")
+	}
+
+	ns := session.NewSession{
+		UserID:    usr.ID,
+		TenantID:  usr.TenantID,
+		UserAgent: r.UserAgent(),
+		IPAddress: remoteIP(r),
+		TTL:       h.ttl,
+	}
+
+	sess, secret, err := h.session.Create(ctx, ns)
+	if err != nil {
+		return fmt.Errorf("create: userID[%s]: %w", usr.ID, err)
+	}
+
+	csrf, err := newCSRFToken()
+	if err != nil {
+		return err
+	}
+
+	setCookies(w, secret, csrf, sess.DateExpires)
+
+	return web.Respond(ctx, w, AppSignedIn{Session: toAppSession(sess), CSRFToken: csrf}, http.StatusCreated)
+}
+
+// DeleteCurrent signs the browser out by revoking the session it used.
+func (h *Handlers) DeleteCurrent(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	sessionID := mid.GetSessionID(ctx)
+	if sessionID == uuid.Nil {
+		return v1.NewRequestError(ErrNoSession, http.StatusBadRequest)
+	}
+
+	sess, err := h.session.QueryByID(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("querybyid: sessionID[%s]: %w", sessionID, err)
+	}
+
+	if _, err := h.session.Revoke(ctx, sess); err != nil {
+		return fmt.Errorf("revoke: sessionID[%s]: %w", sessionID, err)
+	}
+
+	clearCookies(w)
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+// QueryByUser returns the active sessions of a user with paging.
+func (h *Handlers) QueryByUser(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	userID := auth.GetUserID(ctx)
+
+	page, err := paging.ParseRequest(r)
+	if err != nil {
+		return err
+	}
+
+	sessions, err := h.session.QueryByUser(ctx, userID, page.Number, page.RowsPerPage)
+	if err != nil {
+		return fmt.Errorf("querybyuser: userID[%s]: %w", userID, err)
+	}
+
+	items := make([]AppSession, len(sessions))
+	for i, sess := range sessions {
+		items[i] = toAppSession(sess)
+	}
+
+	total, err := h.session.CountByUser(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("countbyuser: userID[%s]: %w", userID, err)
+	}
+
+	return web.Respond(ctx, w, paging.NewResponse(items, total, page.Number, page.RowsPerPage), http.StatusOK)
+}
+
+// Revoke ends one session of a user.
+func (h *Handlers) Revoke(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	userID := auth.GetUserID(ctx)
+
+	sessionID, err := uuid.Parse(web.Param(r, "session_id"))
+	if err != nil {
+		return v1.NewRequestError(mid.ErrInvalidID, http.StatusBadRequest)
+	}
+
+	sess, err := h.session.QueryByID(ctx, sessionID)
+	if err != nil {
+		switch {
+		case errors.Is(err, session.ErrNotFound):
+			return web.Respond(ctx, w, nil, http.StatusNoContent)
+		default:
+			return fmt.Errorf("querybyid: sessionID[%s]: %w", sessionID, err)
+		}
+	}
+
+	if sess.UserID != userID {
+		return web.Respond(ctx, w, nil, http.StatusNoContent)
+	}
+
+	if _, err := h.session.Revoke(ctx, sess); err != nil {
+		return fmt.Errorf("revoke: sessionID[%s]: %w", sessionID, err)
+	}
+
+	if sessionID == mid.GetSessionID(ctx) {
+		clearCookies(w)
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+// RevokeAll ends every session of a user.
+func (h *Handlers) RevokeAll(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	userID := auth.GetUserID(ctx)
+
+	if err := h.session.RevokeAll(ctx, userID); err != nil {
+		return fmt.Errorf("revokeall: userID[%s]: %w", userID, err)
+	}
+
+	if mid.GetSessionID(ctx) != uuid.Nil && auth.GetClaims(ctx).Subject == userID.String() {
+		clearCookies(w)
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+// =============================================================================
+
+// setCookies hands the session secret and the CSRF token to the browser.
+// Only the CSRF token is readable by scripts.
+func setCookies(w http.ResponseWriter, secret string, csrf string, expires time.Time) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     mid.SessionCookie,
+		Value:    secret,
+		Path:     "/",
+		Expires:  expires,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteStrictMode,
+	})
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     mid.CSRFCookie,
+		Value:    csrf,
+		Path:     "/",
+		Expires:  expires,
+		Secure:   true,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+func clearCookies(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     mid.SessionCookie,
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteStrictMode,
+	})
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     mid.CSRFCookie,
+		Path:     "/",
+		MaxAge:   -1,
+		Secure:   true,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+func newCSRFToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generating csrf token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
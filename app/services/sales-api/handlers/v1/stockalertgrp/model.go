@@ -0,0 +1,38 @@
+package stockalertgrp
+
+import (
+	"time"
+
+	"github.com/aleury/service/business/core/stockalert"
+)
+
+// AppLowStock represents a product below its reorder threshold.
+type AppLowStock struct {
+	ProductID   string `json:"productId"`
+	UserID      string `json:"userId"`
+	Name        string `json:"name"`
+	SKU         string `json:"sku,omitempty"`
+	Quantity    int    `json:"quantity"`
+	Threshold   int    `json:"reorderThreshold"`
+	Shortfall   int    `json:"shortfall"`
+	DateAlerted string `json:"dateAlerted,omitempty"`
+}
+
+func toAppLowStock(items []stockalert.LowStock) []AppLowStock {
+	apps := make([]AppLowStock, len(items))
+	for i, ls := range items {
+		apps[i] = AppLowStock{
+			ProductID: ls.ProductID.String(),
+			UserID:    ls.UserID.String(),
+			Name:      ls.Name,
+			SKU:       ls.SKU,
+			Quantity:  ls.Quantity,
+			Threshold: ls.Threshold,
+			Shortfall: ls.Shortfall(),
+		}
+		if !ls.DateAlerted.IsZero() {
+			apps[i].DateAlerted = ls.DateAlerted.Format(time.RFC3339)
+		}
+	}
+	return apps
+}
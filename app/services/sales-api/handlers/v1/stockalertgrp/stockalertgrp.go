@@ -0,0 +1,45 @@
+// Package stockalertgrp maintains the group of handlers for stock alert
+// access.
+package stockalertgrp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/aleury/service/business/core/stockalert"
+	"github.com/aleury/service/business/web/v1/paging"
+	"github.com/aleury/service/foundation/web"
+)
+
+// Handlers manages the set of stock alert endpoints.
+type Handlers struct {
+	stockAlert *stockalert.Core
+}
+
+// New constructs a handlers for route access.
+func New(stockAlert *stockalert.Core) *Handlers {
+	return &Handlers{
+		stockAlert: stockAlert,
+	}
+}
+
+// LowStock reports the products below their reorder threshold with paging.
+func (h *Handlers) LowStock(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	page, err := paging.ParseRequest(r)
+	if err != nil {
+		return err
+	}
+
+	items, err := h.stockAlert.QueryLowStock(ctx, page.Number, page.RowsPerPage)
+	if err != nil {
+		return fmt.Errorf("querylowstock: %w", err)
+	}
+
+	total, err := h.stockAlert.CountLowStock(ctx)
+	if err != nil {
+		return fmt.Errorf("countlowstock: %w", err)
+	}
+
+	return web.Respond(ctx, w, paging.NewResponse(toAppLowStock(items), total, page.Number, page.RowsPerPage), http.StatusOK)
+}
@@ -0,0 +1,152 @@
+package taxgrp
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aleury/service/business/core/tax"
+	"github.com/aleury/service/business/data/money"
+	"github.com/aleury/service/business/sys/validate"
+	"github.com/google/uuid"
+)
+
+// AppJurisdiction represents a region with its own tax rates.
+type AppJurisdiction struct {
+	Code        string    `json:"code"`
+	Name        string    `json:"name"`
+	Inclusive   bool      `json:"inclusive"`
+	Rounding    string    `json:"rounding"`
+	Rates       []AppRate `json:"rates"`
+	DateUpdated string    `json:"dateUpdated"`
+}
+
+// AppRate represents the percentage a tax class is taxed at.
+type AppRate struct {
+	Class   string        `json:"class"`
+	Percent money.Percent `json:"percent"`
+}
+
+func toAppJurisdiction(jur tax.Jurisdiction) AppJurisdiction {
+	rates := make([]AppRate, len(jur.Rates))
+	for i, rate := range jur.Rates {
+		rates[i] = AppRate{
+			Class:   rate.Class,
+			Percent: rate.Percent,
+		}
+	}
+
+	return AppJurisdiction{
+		Code:        jur.Code,
+		Name:        jur.Name,
+		Inclusive:   jur.Inclusive,
+		Rounding:    jur.Rounding.Name(),
+		Rates:       rates,
+		DateUpdated: jur.DateUpdated.Format(time.RFC3339),
+	}
+}
+
+func toAppJurisdictions(jurs []tax.Jurisdiction) []AppJurisdiction {
+	items := make([]AppJurisdiction, len(jurs))
+	for i, jur := range jurs {
+		items[i] = toAppJurisdiction(jur)
+	}
+	return items
+}
+
+// =============================================================================
+
+// AppQuote represents the tax due on a prospective order.
+type AppQuote struct {
+	Jurisdiction string         `json:"jurisdiction"`
+	Inclusive    bool           `json:"inclusive"`
+	Lines        []AppQuoteLine `json:"lines"`
+	Net          money.Money    `json:"net"`
+	Tax          money.Money    `json:"tax"`
+	Gross        money.Money    `json:"gross"`
+}
+
+// AppQuoteLine represents the tax due on a line of a quote.
+type AppQuoteLine struct {
+	Number    int           `json:"number"`
+	ProductID string        `json:"productId"`
+	SKU       string        `json:"sku,omitempty"`
+	Quantity  int           `json:"quantity"`
+	TaxClass  string        `json:"taxClass"`
+	Rate      money.Percent `json:"rate"`
+	Net       money.Money   `json:"net"`
+	Tax       money.Money   `json:"tax"`
+	Gross     money.Money   `json:"gross"`
+}
+
+func toAppQuote(qte tax.Quote) AppQuote {
+	lines := make([]AppQuoteLine, len(qte.Lines))
+	for i, ln := range qte.Lines {
+		lines[i] = AppQuoteLine{
+			Number:    ln.Number,
+			ProductID: ln.ProductID.String(),
+			SKU:       ln.SKU,
+			Quantity:  ln.Quantity,
+			TaxClass:  ln.TaxClass,
+			Rate:      ln.Rate,
+			Net:       ln.Net,
+			Tax:       ln.Tax,
+			Gross:     ln.Gross,
+		}
+	}
+
+	return AppQuote{
+		Jurisdiction: qte.Jurisdiction.Code,
+		Inclusive:    qte.Jurisdiction.Inclusive,
+		Lines:        lines,
+		Net:          qte.Net,
+		Tax:          qte.Tax,
+		Gross:        qte.Gross,
+	}
+}
+
+// =============================================================================
+
+// AppNewQuote is what we require from clients when asking for the tax due
+// on a prospective order.
+type AppNewQuote struct {
+	Jurisdiction string            `json:"jurisdiction" validate:"required"`
+	Lines        []AppNewQuoteLine `json:"lines" validate:"required,min=1,dive"`
+}
+
+// AppNewQuoteLine is a product and the quantity of it being quoted. The
+// product is given by its ID or its SKU.
+type AppNewQuoteLine struct {
+	ProductID string `json:"productId" validate:"required_without=SKU,omitempty,uuid"`
+	SKU       string `json:"sku" validate:"required_without=ProductID"`
+	Quantity  int    `json:"quantity" validate:"required,gte=1"`
+}
+
+func toCoreNewLines(app AppNewQuote) ([]tax.NewLine, error) {
+	lines := make([]tax.NewLine, len(app.Lines))
+	for i, ln := range app.Lines {
+		var productID uuid.UUID
+		if ln.ProductID != "" {
+			var err error
+			productID, err = uuid.Parse(ln.ProductID)
+			if err != nil {
+				return nil, fmt.Errorf("parsing productId: line[%d]: %w", i+1, err)
+			}
+		}
+
+		lines[i] = tax.NewLine{
+			ProductID: productID,
+			SKU:       ln.SKU,
+			Quantity:  ln.Quantity,
+		}
+	}
+
+	return lines, nil
+}
+
+// Validate checks the data in the model is considered clean.
+func (app AppNewQuote) Validate() error {
+	if err := validate.Check(app); err != nil {
+		return err
+	}
+	return nil
+}
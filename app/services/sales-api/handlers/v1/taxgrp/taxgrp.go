@@ -0,0 +1,110 @@
+// Package taxgrp maintains the group of handlers for tax access.
+package taxgrp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/aleury/service/business/core/product"
+	"github.com/aleury/service/business/core/tax"
+	"github.com/aleury/service/business/data/money"
+	v1 "github.com/aleury/service/business/web/v1"
+	"github.com/aleury/service/foundation/web"
+)
+
+// maxImportSize is the largest CSV document accepted by an import.
+const maxImportSize = 1 << 20
+
+// Handlers manages the set of tax endpoints.
+type Handlers struct {
+	tax *tax.Core
+}
+
+// New constructs a handlers for route access.
+func New(tax *tax.Core) *Handlers {
+	return &Handlers{
+		tax: tax,
+	}
+}
+
+// Import loads the jurisdictions in a CSV request body of
+// jurisdiction,name,inclusive,rounding,class,rate records, replacing the
+// rates of the jurisdictions already known.
+func (h *Handlers) Import(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	njs, err := tax.ParseCSV(http.MaxBytesReader(w, r.Body, maxImportSize))
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		switch {
+		case errors.Is(err, tax.ErrInvalidCSV):
+			return v1.NewRequestError(err, http.StatusBadRequest)
+		case errors.As(err, &maxErr):
+			return v1.NewRequestError(err, http.StatusRequestEntityTooLarge)
+		default:
+			return fmt.Errorf("parsecsv: %w", err)
+		}
+	}
+
+	jurs, err := h.tax.Load(ctx, njs)
+	if err != nil {
+		if errors.Is(err, tax.ErrDuplicate) {
+			return v1.NewRequestError(err, http.StatusBadRequest)
+		}
+		return fmt.Errorf("load: %w", err)
+	}
+
+	return web.Respond(ctx, w, toAppJurisdictions(jurs), http.StatusOK)
+}
+
+// Query returns the jurisdictions and their rates.
+func (h *Handlers) Query(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	jurs, err := h.tax.Query(ctx)
+	if err != nil {
+		return fmt.Errorf("query: %w", err)
+	}
+
+	return web.Respond(ctx, w, toAppJurisdictions(jurs), http.StatusOK)
+}
+
+// QueryByCode returns the jurisdiction with the code of the path.
+func (h *Handlers) QueryByCode(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	code := web.Param(r, "code")
+
+	jur, err := h.tax.QueryByCode(ctx, code)
+	if err != nil {
+		if errors.Is(err, tax.ErrNotFound) {
+			return v1.NewRequestError(err, http.StatusNotFound)
+		}
+		return fmt.Errorf("querybycode: code[%s]: %w", code, err)
+	}
+
+	return web.Respond(ctx, w, toAppJurisdiction(jur), http.StatusOK)
+}
+
+// Quote returns the tax due on every line of a prospective order.
+func (h *Handlers) Quote(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	var app AppNewQuote
+	if err := web.Decode(r, &app); err != nil {
+		return err
+	}
+
+	nls, err := toCoreNewLines(app)
+	if err != nil {
+		return v1.NewRequestError(err, http.StatusBadRequest)
+	}
+
+	qte, err := h.tax.Quote(ctx, app.Jurisdiction, nls)
+	if err != nil {
+		switch {
+		case errors.Is(err, tax.ErrNotFound), errors.Is(err, tax.ErrNoRate),
+			errors.Is(err, product.ErrNotFound), errors.Is(err, product.ErrHasVariants),
+			errors.Is(err, money.ErrOverflow):
+			return v1.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return fmt.Errorf("quote: app[%+v]: %w", app, err)
+		}
+	}
+
+	return web.Respond(ctx, w, toAppQuote(qte), http.StatusOK)
+}
@@ -1,12 +1,16 @@
 package usergrp
 
 import (
+	"errors"
 	"net/http"
 	"net/mail"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/aleury/service/business/core/user"
 	"github.com/aleury/service/business/core/usersummary"
+	"github.com/aleury/service/business/data/money"
 	"github.com/aleury/service/business/sys/validate"
 	"github.com/google/uuid"
 )
@@ -28,6 +32,18 @@ func parseFilter(r *http.Request) (user.QueryFilter, error) {
 		filter.WithName(name)
 	}
 
+	if text := values.Get("q"); text != "" {
+		filter.WithSearch(text)
+	}
+
+	if departmentID := values.Get("department_id"); departmentID != "" {
+		id, err := uuid.Parse(departmentID)
+		if err != nil {
+			return user.QueryFilter{}, validate.NewFieldsError("department_id", err)
+		}
+		filter.WithDepartmentID(id)
+	}
+
 	if email := values.Get("email"); email != "" {
 		addr, err := mail.ParseAddress(email)
 		if err != nil {
@@ -52,6 +68,14 @@ func parseFilter(r *http.Request) (user.QueryFilter, error) {
 		filter.WithEndCreatedDate(t)
 	}
 
+	if includeDeleted := values.Get("include_deleted"); includeDeleted != "" {
+		inc, err := strconv.ParseBool(includeDeleted)
+		if err != nil {
+			return user.QueryFilter{}, validate.NewFieldsError("include_deleted", err)
+		}
+		filter.WithIncludeDeleted(inc)
+	}
+
 	if err := filter.Validate(); err != nil {
 		return user.QueryFilter{}, err
 	}
@@ -59,6 +83,44 @@ func parseFilter(r *http.Request) (user.QueryFilter, error) {
 	return filter, nil
 }
 
+// parseHighlight reports whether the request asks for the words matching
+// the search text to be marked in snippets. It only applies to searches.
+func parseHighlight(r *http.Request) (bool, error) {
+	values := r.URL.Query()
+
+	highlight := values.Get("highlight")
+	if highlight == "" {
+		return false, nil
+	}
+
+	hl, err := strconv.ParseBool(highlight)
+	if err != nil {
+		return false, validate.NewFieldsError("highlight", err)
+	}
+
+	if hl && values.Get("q") == "" {
+		return false, validate.NewFieldsError("highlight", errors.New("requires a search text in q"))
+	}
+
+	return hl, nil
+}
+
+// parseAsOf parses the optional asOf query parameter. A zero time is returned
+// when the parameter is not provided.
+func parseAsOf(r *http.Request) (time.Time, error) {
+	asOf := r.URL.Query().Get("asOf")
+	if asOf == "" {
+		return time.Time{}, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, asOf)
+	if err != nil {
+		return time.Time{}, validate.NewFieldsError("asOf", err)
+	}
+
+	return t, nil
+}
+
 // =============================================================================
 
 func parseSummaryFilter(r *http.Request) (usersummary.QueryFilter, error) {
@@ -78,9 +140,33 @@ func parseSummaryFilter(r *http.Request) (usersummary.QueryFilter, error) {
 		filter.WithUserName(userName)
 	}
 
+	if departmentID := values.Get("department_id"); departmentID != "" {
+		id, err := uuid.Parse(departmentID)
+		if err != nil {
+			return usersummary.QueryFilter{}, validate.NewFieldsError("department_id", err)
+		}
+		filter.WithDepartmentID(id)
+	}
+
 	if err := filter.Validate(); err != nil {
 		return usersummary.QueryFilter{}, err
 	}
 
 	return filter, nil
 }
+
+// parseCurrency reads the currency amounts should be reported in. It reports
+// false when the request doesn't ask for one.
+func parseCurrency(r *http.Request) (money.Currency, bool, error) {
+	code := r.URL.Query().Get("currency")
+	if code == "" {
+		return money.Currency{}, false, nil
+	}
+
+	currency, err := money.ParseCurrency(strings.ToUpper(code))
+	if err != nil {
+		return money.Currency{}, false, validate.NewFieldsError("currency", err)
+	}
+
+	return currency, true, nil
+}
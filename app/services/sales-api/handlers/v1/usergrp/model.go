@@ -7,20 +7,25 @@ import (
 
 	"github.com/aleury/service/business/core/user"
 	"github.com/aleury/service/business/core/usersummary"
+	"github.com/aleury/service/business/data/money"
 	"github.com/aleury/service/business/sys/validate"
+	"github.com/google/uuid"
 )
 
 // AppUser represents information about an individual user.
 type AppUser struct {
 	ID           string   `json:"id"`
+	TenantID     string   `json:"tenantId"`
 	Name         string   `json:"name"`
 	Email        string   `json:"email"`
 	Roles        []string `json:"roles"`
 	PasswordHash []byte   `json:"-"`
-	Department   string   `json:"department"`
+	DepartmentID string   `json:"departmentId"`
 	Enabled      bool     `json:"enabled"`
+	Version      int      `json:"version,omitempty"`
 	DateCreated  string   `json:"dateCreated"`
 	DateUpdated  string   `json:"dateUpdated"`
+	Snippet      string   `json:"snippet,omitempty"`
 }
 
 func toAppUser(usr user.User) AppUser {
@@ -29,19 +34,41 @@ func toAppUser(usr user.User) AppUser {
 		roles[i] = role.Name()
 	}
 
+	var departmentID string
+	if usr.DepartmentID != uuid.Nil {
+		departmentID = usr.DepartmentID.String()
+	}
+
 	return AppUser{
 		ID:           usr.ID.String(),
+		TenantID:     usr.TenantID.String(),
 		Name:         usr.Name,
 		Email:        usr.Email.Address,
 		Roles:        roles,
 		PasswordHash: usr.PasswordHash,
-		Department:   usr.Department,
+		DepartmentID: departmentID,
 		Enabled:      usr.Enabled,
+		Version:      usr.Version,
 		DateCreated:  usr.DateCreated.Format(time.RFC3339),
 		DateUpdated:  usr.DateUpdated.Format(time.RFC3339),
 	}
 }
 
+// AppVersion represents a recorded version of a user.
+type AppVersion struct {
+	Version   int     `json:"version"`
+	ValidFrom string  `json:"validFrom"`
+	User      AppUser `json:"user"`
+}
+
+func toAppVersion(ver user.Version) AppVersion {
+	return AppVersion{
+		Version:   ver.Number,
+		ValidFrom: ver.ValidFrom.Format(time.RFC3339),
+		User:      toAppUser(ver.User),
+	}
+}
+
 // =============================================================================
 
 // AppNewUser contains information needed to create a new user.
@@ -49,7 +76,7 @@ type AppNewUser struct {
 	Name            string   `json:"name" validate:"required"`
 	Email           string   `json:"email" validate:"required,email"`
 	Roles           []string `json:"roles" validate:"required"`
-	Department      string   `json:"department"`
+	DepartmentID    string   `json:"departmentId" validate:"omitempty,uuid"`
 	Password        string   `json:"password" validate:"required"`
 	PasswordConfirm string   `json:"passwordConfirm" validate:"eqfield=Password"`
 }
@@ -69,11 +96,19 @@ func toCoreNewUser(appUsr AppNewUser) (user.NewUser, error) {
 		return user.NewUser{}, fmt.Errorf("parsing email: %w", err)
 	}
 
+	var departmentID uuid.UUID
+	if appUsr.DepartmentID != "" {
+		departmentID, err = uuid.Parse(appUsr.DepartmentID)
+		if err != nil {
+			return user.NewUser{}, fmt.Errorf("parsing departmentId: %w", err)
+		}
+	}
+
 	usr := user.NewUser{
 		Name:            appUsr.Name,
 		Email:           *addr,
 		Roles:           roles,
-		Department:      appUsr.Department,
+		DepartmentID:    departmentID,
 		Password:        appUsr.Password,
 		PasswordConfirm: appUsr.PasswordConfirm,
 	}
@@ -95,7 +130,7 @@ type AppUpdateUser struct {
 	Name            *string  `json:"name"`
 	Email           *string  `json:"email" validate:"omitempty,email"`
 	Roles           []string `json:"roles"`
-	Department      *string  `json:"department"`
+	DepartmentID    *string  `json:"departmentId" validate:"omitempty,uuid"`
 	Password        *string  `json:"password"`
 	PasswordConfirm *string  `json:"passwordConfirm" validate:"omitempty,eqfield=Password"`
 	Enabled         *bool    `json:"enabled"`
@@ -123,11 +158,20 @@ func toCoreUpdateUser(appUsr AppUpdateUser) (user.UpdateUser, error) {
 		}
 	}
 
+	var departmentID *uuid.UUID
+	if appUsr.DepartmentID != nil {
+		id, err := uuid.Parse(*appUsr.DepartmentID)
+		if err != nil {
+			return user.UpdateUser{}, fmt.Errorf("parsing departmentId: %w", err)
+		}
+		departmentID = &id
+	}
+
 	usr := user.UpdateUser{
 		Name:            appUsr.Name,
 		Email:           addr,
 		Roles:           roles,
-		Department:      appUsr.Department,
+		DepartmentID:    departmentID,
 		Password:        appUsr.Password,
 		PasswordConfirm: appUsr.PasswordConfirm,
 		Enabled:         appUsr.Enabled,
@@ -147,17 +191,46 @@ func (appUsr AppUpdateUser) Validate() error {
 
 // AppSummary repesents informatin about an individual user and their products.
 type AppSummary struct {
-	UserID     string  `json:"userId"`
-	UserName   string  `json:"userName"`
-	TotalCount int     `json:"totalCount"`
-	TotalCost  float64 `json:"totalCost"`
+	UserID       string      `json:"userId"`
+	UserName     string      `json:"userName"`
+	DepartmentID string      `json:"departmentId"`
+	TotalCount   int         `json:"totalCount"`
+	TotalCost    money.Money `json:"totalCost"`
+	Currency     string      `json:"currency"`
+	Rate         *AppRate    `json:"rate,omitempty"`
+}
+
+// AppRate represents the exchange rate an amount was converted at.
+type AppRate struct {
+	Base        string     `json:"base"`
+	Quote       string     `json:"quote"`
+	Rate        money.Rate `json:"rate"`
+	DateUpdated string     `json:"dateUpdated"`
 }
 
 func toAppSummary(sum usersummary.Summary) AppSummary {
-	return AppSummary{
-		UserID:     sum.UserID.String(),
-		UserName:   sum.UserName,
-		TotalCount: sum.TotalCount,
-		TotalCost:  sum.TotalCost,
+	var departmentID string
+	if sum.DepartmentID != uuid.Nil {
+		departmentID = sum.DepartmentID.String()
 	}
+
+	app := AppSummary{
+		UserID:       sum.UserID.String(),
+		UserName:     sum.UserName,
+		DepartmentID: departmentID,
+		TotalCount:   sum.TotalCount,
+		TotalCost:    sum.TotalCost,
+		Currency:     sum.TotalCost.Currency().Code(),
+	}
+
+	if !sum.Rate.DateUpdated.IsZero() {
+		app.Rate = &AppRate{
+			Base:        sum.Rate.Base.Code(),
+			Quote:       sum.Rate.Quote.Code(),
+			Rate:        sum.Rate.Rate,
+			DateUpdated: sum.Rate.DateUpdated.Format(time.RFC3339),
+		}
+	}
+
+	return app
 }
@@ -6,22 +6,29 @@ import (
 	"fmt"
 	"net/http"
 
+	"github.com/aleury/service/business/core/exchange"
+	"github.com/aleury/service/business/core/tenant"
 	"github.com/aleury/service/business/core/user"
+	"github.com/aleury/service/business/core/usersummary"
 	"github.com/aleury/service/business/web/auth"
 	v1 "github.com/aleury/service/business/web/v1"
+	"github.com/aleury/service/business/web/v1/etag"
 	"github.com/aleury/service/business/web/v1/paging"
 	"github.com/aleury/service/foundation/web"
+	"github.com/google/uuid"
 )
 
 // Handlers manages the set of user endpoints.
 type Handlers struct {
-	user *user.Core
+	user    *user.Core
+	summary *usersummary.Core
 }
 
 // New constructs a hanlers for the route access.
-func New(user *user.Core) *Handlers {
+func New(user *user.Core, summary *usersummary.Core) *Handlers {
 	return &Handlers{
-		user: user,
+		user:    user,
+		summary: summary,
 	}
 }
 
@@ -36,11 +43,19 @@ func (h *Handlers) Create(ctx context.Context, w http.ResponseWriter, r *http.Re
 	if err != nil {
 		return v1.NewRequestError(err, http.StatusBadRequest)
 	}
+	newUser.TenantID = tenant.GetTenantID(ctx)
+
+	if err := h.canAssign(ctx, newUser.Roles, &newUser.DepartmentID); err != nil {
+		return err
+	}
 
 	usr, err := h.user.Create(ctx, newUser)
 	if err != nil {
-		if errors.Is(err, user.ErrUniqueEmail) {
+		switch {
+		case errors.Is(err, user.ErrUniqueEmail):
 			return v1.NewRequestError(err, http.StatusConflict)
+		case errors.Is(err, user.ErrDepartmentNotFound):
+			return v1.NewRequestError(err, http.StatusBadRequest)
 		}
 		return fmt.Errorf("create: usr[%+v]: %w", usr, err)
 	}
@@ -68,19 +83,36 @@ func (h *Handlers) Update(ctx context.Context, w http.ResponseWriter, r *http.Re
 		}
 	}
 
+	if err := etag.Check(r, usr.Version); err != nil {
+		return err
+	}
+
 	updateUser, err := toCoreUpdateUser(appUser)
 	if err != nil {
 		return v1.NewRequestError(err, http.StatusBadRequest)
 	}
 
+	if updateUser.Roles != nil || updateUser.DepartmentID != nil {
+		if err := h.canAssign(ctx, updateUser.Roles, updateUser.DepartmentID); err != nil {
+			return err
+		}
+	}
+
 	usr, err = h.user.Update(ctx, usr, updateUser)
 	if err != nil {
-		if errors.Is(err, user.ErrUniqueEmail) {
+		switch {
+		case errors.Is(err, user.ErrUniqueEmail):
 			return v1.NewRequestError(err, http.StatusConflict)
+		case errors.Is(err, user.ErrDepartmentNotFound):
+			return v1.NewRequestError(err, http.StatusBadRequest)
+		case errors.Is(err, user.ErrConflict):
+			return v1.NewRequestError(etag.ErrPreconditionFailed, http.StatusPreconditionFailed)
 		}
 		return fmt.Errorf("update: usr[%s] updateUser[%+v]: %w", userID, updateUser, err)
 	}
 
+	etag.Set(w, usr.Version)
+
 	return web.Respond(ctx, w, toAppUser(usr), http.StatusOK)
 }
 
@@ -105,6 +137,23 @@ func (h *Handlers) Delete(ctx context.Context, w http.ResponseWriter, r *http.Re
 	return web.Respond(ctx, w, nil, http.StatusNoContent)
 }
 
+// Restore brings back a user that was previously deleted.
+func (h *Handlers) Restore(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	userID := auth.GetUserID(ctx)
+
+	usr, err := h.user.Restore(ctx, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, user.ErrNotFound):
+			return v1.NewRequestError(err, http.StatusNotFound)
+		default:
+			return fmt.Errorf("restore: userID[%s]: %w", userID, err)
+		}
+	}
+
+	return web.Respond(ctx, w, toAppUser(usr), http.StatusOK)
+}
+
 // Query returns a list of users with paging.
 func (h *Handlers) Query(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
 	page, err := paging.ParseRequest(r)
@@ -117,11 +166,20 @@ func (h *Handlers) Query(ctx context.Context, w http.ResponseWriter, r *http.Req
 		return err
 	}
 
+	if filter.IncludeDeleted != nil && !auth.GetClaims(ctx).HasRole(user.RoleAdmin) {
+		return v1.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+
 	orderBy, err := parseOrder(r)
 	if err != nil {
 		return err
 	}
 
+	highlight, err := parseHighlight(r)
+	if err != nil {
+		return err
+	}
+
 	users, err := h.user.Query(ctx, filter, orderBy, page.Number, page.RowsPerPage)
 	if err != nil {
 		return fmt.Errorf("query: %w", err)
@@ -132,6 +190,22 @@ func (h *Handlers) Query(ctx context.Context, w http.ResponseWriter, r *http.Req
 		items[i] = toAppUser(usr)
 	}
 
+	if highlight {
+		userIDs := make([]uuid.UUID, len(users))
+		for i, usr := range users {
+			userIDs[i] = usr.ID
+		}
+
+		snippets, err := h.user.Highlight(ctx, userIDs, *filter.Search)
+		if err != nil {
+			return fmt.Errorf("highlight: %w", err)
+		}
+
+		for i, usr := range users {
+			items[i].Snippet = snippets[usr.ID]
+		}
+	}
+
 	total, err := h.user.Count(ctx, filter)
 	if err != nil {
 		return fmt.Errorf("count: %w", err)
@@ -141,3 +215,169 @@ func (h *Handlers) Query(ctx context.Context, w http.ResponseWriter, r *http.Req
 
 	return web.Respond(ctx, w, response, http.StatusOK)
 }
+
+// QueryByID returns a user by its ID. If the asOf parameter is provided, the
+// user is reconstructed as it was at that time.
+func (h *Handlers) QueryByID(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	userID := auth.GetUserID(ctx)
+
+	asOf, err := parseAsOf(r)
+	if err != nil {
+		return err
+	}
+
+	var usr user.User
+	switch asOf.IsZero() {
+	case true:
+		usr, err = h.user.QueryByID(ctx, userID)
+	default:
+		usr, err = h.user.QueryByIDAsOf(ctx, userID, asOf)
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, user.ErrNotFound):
+			return v1.NewRequestError(err, http.StatusNotFound)
+		default:
+			return fmt.Errorf("querybyid: userID[%s]: %w", userID, err)
+		}
+	}
+
+	if asOf.IsZero() {
+		etag.Set(w, usr.Version)
+
+		if err := etag.Check(r, usr.Version); err != nil {
+			return err
+		}
+	}
+
+	return web.Respond(ctx, w, toAppUser(usr), http.StatusOK)
+}
+
+// QueryHistory returns the recorded versions of a user with paging.
+func (h *Handlers) QueryHistory(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	userID := auth.GetUserID(ctx)
+
+	page, err := paging.ParseRequest(r)
+	if err != nil {
+		return err
+	}
+
+	versions, err := h.user.QueryHistory(ctx, userID, page.Number, page.RowsPerPage)
+	if err != nil {
+		return fmt.Errorf("queryhistory: userID[%s]: %w", userID, err)
+	}
+
+	items := make([]AppVersion, len(versions))
+	for i, ver := range versions {
+		items[i] = toAppVersion(ver)
+	}
+
+	total, err := h.user.CountHistory(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("counthistory: userID[%s]: %w", userID, err)
+	}
+
+	return web.Respond(ctx, w, paging.NewResponse(items, total, page.Number, page.RowsPerPage), http.StatusOK)
+}
+
+// QuerySummary returns a list of user summaries with paging.
+func (h *Handlers) QuerySummary(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	page, err := paging.ParseRequest(r)
+	if err != nil {
+		return err
+	}
+
+	filter, err := parseSummaryFilter(r)
+	if err != nil {
+		return err
+	}
+
+	orderBy, err := parseSummaryOrder(r)
+	if err != nil {
+		return err
+	}
+
+	currency, convert, err := parseCurrency(r)
+	if err != nil {
+		return err
+	}
+
+	summaries, err := h.summary.Query(ctx, filter, orderBy, page.Number, page.RowsPerPage)
+	if err != nil {
+		return fmt.Errorf("query: %w", err)
+	}
+
+	if convert {
+		summaries, err = h.summary.Convert(ctx, summaries, currency)
+		if err != nil {
+			if errors.Is(err, exchange.ErrNotFound) {
+				return v1.NewRequestError(fmt.Errorf("no exchange rate to %s", currency.Code()), http.StatusBadRequest)
+			}
+			return fmt.Errorf("convert: %w", err)
+		}
+	}
+
+	items := make([]AppSummary, len(summaries))
+	for i, sum := range summaries {
+		items[i] = toAppSummary(sum)
+	}
+
+	total, err := h.summary.Count(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("count: %w", err)
+	}
+
+	return web.Respond(ctx, w, paging.NewResponse(items, total, page.Number, page.RowsPerPage), http.StatusOK)
+}
+
+// =============================================================================
+
+// canAssign checks the caller is allowed to give a user the specified roles
+// and department. Admins can assign anything. Department admins can only
+// assign their own department and can't grant the admin role. Anyone else
+// can't change roles or departments at all. When a department admin leaves
+// the department unset, it defaults to their own.
+func (h *Handlers) canAssign(ctx context.Context, roles []user.Role, departmentID *uuid.UUID) error {
+	claims := auth.GetClaims(ctx)
+
+	switch {
+	case claims.HasRole(user.RoleAdmin):
+		return nil
+
+	case claims.HasRole(user.RoleDepartmentAdmin):
+		for _, role := range roles {
+			if role.Equal(user.RoleAdmin) {
+				return v1.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+			}
+		}
+
+		subjectID, err := uuid.Parse(claims.Subject)
+		if err != nil {
+			return v1.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+		}
+
+		subject, err := h.user.QueryByID(ctx, subjectID)
+		if err != nil {
+			return fmt.Errorf("querybyid: subjectID[%s]: %w", subjectID, err)
+		}
+
+		if subject.DepartmentID == uuid.Nil {
+			return v1.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+		}
+
+		if departmentID != nil {
+			switch *departmentID {
+			case uuid.Nil:
+				*departmentID = subject.DepartmentID
+			case subject.DepartmentID:
+			default:
+				return v1.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+			}
+		}
+
+		return nil
+
+	default:
+		return v1.NewRequestError(auth.ErrForbidden, http.StatusForbidden)
+	}
+}
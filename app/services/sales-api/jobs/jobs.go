@@ -0,0 +1,53 @@
+// Package jobs binds the background jobs of the service to a worker.
+package jobs
+
+import (
+	"time"
+
+	"github.com/aleury/service/business/core/audit"
+	"github.com/aleury/service/business/core/audit/stores/auditdb"
+	"github.com/aleury/service/business/core/exchange"
+	"github.com/aleury/service/business/core/exchange/stores/exchangedb"
+	"github.com/aleury/service/business/core/product"
+	"github.com/aleury/service/business/core/product/stores/productdb"
+	"github.com/aleury/service/business/core/reservation"
+	"github.com/aleury/service/business/core/reservation/stores/reservationdb"
+	"github.com/aleury/service/business/core/session"
+	"github.com/aleury/service/business/core/session/stores/sessiondb"
+	"github.com/aleury/service/business/core/stockalert"
+	"github.com/aleury/service/business/core/stockalert/stores/stockalertdb"
+	"github.com/aleury/service/business/core/user"
+	"github.com/aleury/service/business/core/user/stores/userdb"
+	"github.com/aleury/service/foundation/worker"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// Config contains all the mandatory systems required by the jobs.
+type Config struct {
+	Log                      *zap.SugaredLogger
+	DB                       *sqlx.DB
+	PurgeInterval            time.Duration
+	PurgeRetention           time.Duration
+	ReservationSweepInterval time.Duration
+	PriceChangeInterval      time.Duration
+	StockAlertInterval       time.Duration
+	StockAlertNotifiers      []stockalert.Notifier
+}
+
+// Start launches all the background jobs on the specified worker.
+func Start(wrk *worker.Worker, cfg Config) {
+	auditCore := audit.NewCore(auditdb.NewStore(cfg.Log, cfg.DB))
+	usrCore := user.NewCore(userdb.NewStore(cfg.Log, cfg.DB), auditCore)
+	exchCore := exchange.NewCore(exchangedb.NewStore(cfg.Log, cfg.DB))
+	prdCore := product.NewCore(cfg.Log, usrCore, auditCore, exchCore, productdb.NewStore(cfg.Log, cfg.DB))
+	sesCore := session.NewCore(sessiondb.NewStore(cfg.Log, cfg.DB), usrCore)
+	resCore := reservation.NewCore(reservationdb.NewStore(cfg.Log, cfg.DB), prdCore)
+	stkCore := stockalert.NewCore(stockalertdb.NewStore(cfg.Log, cfg.DB), cfg.StockAlertNotifiers)
+
+	wrk.Start("purge", cfg.PurgeInterval, purge(usrCore, prdCore, cfg.PurgeRetention))
+	wrk.Start("sessions", cfg.PurgeInterval, purgeSessions(sesCore))
+	wrk.Start("reservations", cfg.ReservationSweepInterval, expireReservations(resCore))
+	wrk.Start("prices", cfg.PriceChangeInterval, applyPriceChanges(prdCore))
+	wrk.Start("stockalerts", cfg.StockAlertInterval, checkStock(stkCore))
+}
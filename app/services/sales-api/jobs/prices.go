@@ -0,0 +1,21 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aleury/service/business/core/product"
+	"github.com/aleury/service/foundation/worker"
+)
+
+// applyPriceChanges applies the scheduled price changes that are due. A
+// change is applied at most one interval after it takes effect.
+func applyPriceChanges(prdCore *product.Core) worker.Job {
+	return func(ctx context.Context) error {
+		if _, err := prdCore.ApplyPriceChanges(ctx, time.Now()); err != nil {
+			return fmt.Errorf("apply price changes: %w", err)
+		}
+		return nil
+	}
+}
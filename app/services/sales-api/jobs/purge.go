@@ -0,0 +1,29 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aleury/service/business/core/product"
+	"github.com/aleury/service/business/core/user"
+	"github.com/aleury/service/foundation/worker"
+)
+
+// purge permanently removes users and products that have been soft deleted
+// for longer than the retention period.
+func purge(usrCore *user.Core, prdCore *product.Core, retention time.Duration) worker.Job {
+	return func(ctx context.Context) error {
+		before := time.Now().Add(-retention)
+
+		if err := prdCore.Purge(ctx, before); err != nil {
+			return fmt.Errorf("purge products: %w", err)
+		}
+
+		if err := usrCore.Purge(ctx, before); err != nil {
+			return fmt.Errorf("purge users: %w", err)
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,21 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aleury/service/business/core/reservation"
+	"github.com/aleury/service/foundation/worker"
+)
+
+// expireReservations closes the holds that ran out. They stop counting
+// against stock when they expire, so this only keeps their status honest.
+func expireReservations(resCore *reservation.Core) worker.Job {
+	return func(ctx context.Context) error {
+		if _, err := resCore.Expire(ctx, time.Now()); err != nil {
+			return fmt.Errorf("expire reservations: %w", err)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,21 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aleury/service/business/core/session"
+	"github.com/aleury/service/foundation/worker"
+)
+
+// purgeSessions removes browser sessions that expired or were revoked. They
+// can't be used anymore, so there is no reason to keep them around.
+func purgeSessions(sesCore *session.Core) worker.Job {
+	return func(ctx context.Context) error {
+		if err := sesCore.Purge(ctx, time.Now()); err != nil {
+			return fmt.Errorf("purge sessions: %w", err)
+		}
+		return nil
+	}
+}
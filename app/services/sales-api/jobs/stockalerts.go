@@ -0,0 +1,21 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aleury/service/business/core/stockalert"
+	"github.com/aleury/service/foundation/worker"
+)
+
+// checkStock alerts on the products that ran low on stock since the last
+// run and clears the alerts of products that were restocked.
+func checkStock(stkCore *stockalert.Core) worker.Job {
+	return func(ctx context.Context) error {
+		if _, err := stkCore.Check(ctx, time.Now()); err != nil {
+			return fmt.Errorf("check stock: %w", err)
+		}
+		return nil
+	}
+}
@@ -12,12 +12,20 @@ import (
 	"time"
 
 	"github.com/aleury/service/app/services/sales-api/handlers"
+	"github.com/aleury/service/app/services/sales-api/jobs"
+	"github.com/aleury/service/business/core/stockalert"
+	"github.com/aleury/service/business/core/stockalert/notifiers/emailnotifier"
+	"github.com/aleury/service/business/core/stockalert/notifiers/lognotifier"
+	"github.com/aleury/service/business/core/stockalert/notifiers/webhooknotifier"
 	database "github.com/aleury/service/business/sys/database/pgx"
 	"github.com/aleury/service/business/web/auth"
 	"github.com/aleury/service/business/web/v1/debug"
 	"github.com/aleury/service/foundation/keystore"
 	"github.com/aleury/service/foundation/logger"
+	"github.com/aleury/service/foundation/oidc"
+	"github.com/aleury/service/foundation/worker"
 	"github.com/ardanlabs/conf/v3"
+	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
 
@@ -53,6 +61,7 @@ func run(log *zap.SugaredLogger) error {
 			ShutdownTimeout time.Duration `conf:"default:20s"`
 			APIHost         string        `conf:"default:0.0.0.0:3000"`
 			DebugHost       string        `conf:"default:0.0.0.0:4000"`
+			RequireIfMatch  bool          `conf:"default:false"`
 		}
 		DB struct {
 			User         string `conf:"default:postgres"`
@@ -68,6 +77,36 @@ func run(log *zap.SugaredLogger) error {
 			ActiveKID  string `conf:"default:54bb2165-71e1-41a6-af3e-7da4a0e1e2c1"`
 			Issuer     string `conf:"default:service project"`
 		}
+		Purge struct {
+			Interval  time.Duration `conf:"default:1h"`
+			Retention time.Duration `conf:"default:720h"`
+		}
+		OIDC struct {
+			Issuer       string
+			ClientID     string
+			ClientSecret string        `conf:"mask"`
+			RedirectURL  string        `conf:"default:http://localhost:3000/oidc/callback"`
+			TenantID     string        `conf:"default:d0c2b8a6-7d4e-4a55-9c1e-3f5b0e6a1c01"`
+			TokenTTL     time.Duration `conf:"default:1h"`
+		}
+		Session struct {
+			Enabled bool          `conf:"default:false"`
+			TTL     time.Duration `conf:"default:12h"`
+		}
+		Reservation struct {
+			TTL           time.Duration `conf:"default:15m"`
+			MaxTTL        time.Duration `conf:"default:1h"`
+			SweepInterval time.Duration `conf:"default:1m"`
+		}
+		PriceChange struct {
+			Interval time.Duration `conf:"default:1m"`
+		}
+		StockAlert struct {
+			Interval       time.Duration `conf:"default:5m"`
+			Notifiers      []string      `conf:"default:log,help:log webhook or email separated by semicolons"`
+			WebhookURL     string
+			WebhookTimeout time.Duration `conf:"default:10s"`
+		}
 	}{
 		Version: conf.Version{
 			Build: build,
@@ -146,6 +185,34 @@ func run(log *zap.SugaredLogger) error {
 		return fmt.Errorf("constructing auth: %w", err)
 	}
 
+	// -------------------------------------------------------------------------
+	// Initialize OIDC Support
+
+	// Signing in through an identity provider is only offered when one is
+	// configured.
+	oidcCfg := handlers.OIDCConfig{
+		ActiveKID: cfg.Auth.ActiveKID,
+		Issuer:    cfg.Auth.Issuer,
+		TokenTTL:  cfg.OIDC.TokenTTL,
+	}
+
+	if cfg.OIDC.Issuer != "" {
+		log.Infow("startup", "status", "initializing oidc support", "issuer", cfg.OIDC.Issuer)
+
+		tenantID, err := uuid.Parse(cfg.OIDC.TenantID)
+		if err != nil {
+			return fmt.Errorf("parsing oidc tenant id: %w", err)
+		}
+
+		oidcCfg.TenantID = tenantID
+		oidcCfg.Provider = oidc.New(oidc.Config{
+			Issuer:       cfg.OIDC.Issuer,
+			ClientID:     cfg.OIDC.ClientID,
+			ClientSecret: cfg.OIDC.ClientSecret,
+			RedirectURL:  cfg.OIDC.RedirectURL,
+		})
+	}
+
 	// -------------------------------------------------------------------------
 	// Start Debug Service
 
@@ -157,6 +224,60 @@ func run(log *zap.SugaredLogger) error {
 		}
 	}()
 
+	// -------------------------------------------------------------------------
+	// Initialize Stock Alert Support
+
+	log.Infow("startup", "status", "initializing stock alert support", "notifiers", cfg.StockAlert.Notifiers)
+
+	var notifiers []stockalert.Notifier
+	for _, name := range cfg.StockAlert.Notifiers {
+		switch name {
+		case "log":
+			notifiers = append(notifiers, lognotifier.New(log))
+		case "webhook":
+			if cfg.StockAlert.WebhookURL == "" {
+				return errors.New("stock alert webhook notifier requires a webhook url")
+			}
+			notifiers = append(notifiers, webhooknotifier.New(webhooknotifier.Config{
+				URL:    cfg.StockAlert.WebhookURL,
+				Client: &http.Client{Timeout: cfg.StockAlert.WebhookTimeout},
+			}))
+		case "email":
+			notifiers = append(notifiers, emailnotifier.New(log, db))
+		default:
+			return fmt.Errorf("unknown stock alert notifier %q", name)
+		}
+	}
+
+	// -------------------------------------------------------------------------
+	// Start Background Jobs
+
+	log.Infow("startup", "status", "initializing background jobs")
+
+	wrk := worker.New(log)
+
+	jobs.Start(wrk, jobs.Config{
+		Log:                      log,
+		DB:                       db,
+		PurgeInterval:            cfg.Purge.Interval,
+		PurgeRetention:           cfg.Purge.Retention,
+		ReservationSweepInterval: cfg.Reservation.SweepInterval,
+		PriceChangeInterval:      cfg.PriceChange.Interval,
+		StockAlertInterval:       cfg.StockAlert.Interval,
+		StockAlertNotifiers:      notifiers,
+	})
+
+	defer func() {
+		log.Infow("shutdown", "status", "stopping background jobs")
+
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.Web.ShutdownTimeout)
+		defer cancel()
+
+		if err := wrk.Shutdown(ctx); err != nil {
+			log.Errorw("shutdown", "status", "background jobs did not stop gracefully", "ERROR", err)
+		}
+	}()
+
 	// -------------------------------------------------------------------------
 	// Start API Service
 
@@ -170,6 +291,16 @@ func run(log *zap.SugaredLogger) error {
 		Log:      log,
 		Auth:     auth,
 		DB:       db,
+		OIDC:     oidcCfg,
+		Session: handlers.SessionConfig{
+			Enabled: cfg.Session.Enabled,
+			TTL:     cfg.Session.TTL,
+		},
+		Reservation: handlers.ReservationConfig{
+			TTL:    cfg.Reservation.TTL,
+			MaxTTL: cfg.Reservation.MaxTTL,
+		},
+		RequireIfMatch: cfg.Web.RequireIfMatch,
 	})
 
 	api := http.Server{
@@ -2,12 +2,31 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net/mail"
+	"os"
 	"time"
 
+	"github.com/aleury/service/business/core/audit"
+	"github.com/aleury/service/business/core/audit/stores/auditdb"
+	"github.com/aleury/service/business/core/exchange"
+	"github.com/aleury/service/business/core/exchange/stores/exchangedb"
+	"github.com/aleury/service/business/core/product"
+	"github.com/aleury/service/business/core/product/stores/productdb"
+	"github.com/aleury/service/business/core/scimtoken"
+	"github.com/aleury/service/business/core/scimtoken/stores/scimtokendb"
+	"github.com/aleury/service/business/core/tax"
+	"github.com/aleury/service/business/core/tax/stores/taxdb"
+	"github.com/aleury/service/business/core/tenant"
+	"github.com/aleury/service/business/core/tenant/stores/tenantdb"
+	"github.com/aleury/service/business/core/user"
+	"github.com/aleury/service/business/core/user/stores/userdb"
 	"github.com/aleury/service/business/data/dbmigrate"
 	database "github.com/aleury/service/business/sys/database/pgx"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
 )
 
 var build = "develop"
@@ -29,12 +48,68 @@ func run() error {
 		DisableTLS:   true,
 	}
 
-	if err := migrate(cfg); err != nil {
-		return fmt.Errorf("migrate: %w", err)
+	args := os.Args[1:]
+	if len(args) == 0 {
+		if err := migrate(cfg); err != nil {
+			return fmt.Errorf("migrate: %w", err)
+		}
+
+		if err := seed(cfg); err != nil {
+			return fmt.Errorf("seed: %w", err)
+		}
+
+		return nil
 	}
 
-	if err := seed(cfg); err != nil {
-		return fmt.Errorf("seed: %w", err)
+	switch args[0] {
+	case "migrate":
+		if err := migrate(cfg); err != nil {
+			return fmt.Errorf("migrate: %w", err)
+		}
+
+	case "seed":
+		if err := seed(cfg); err != nil {
+			return fmt.Errorf("seed: %w", err)
+		}
+
+	case "tenant":
+		if len(args) != 5 {
+			return errors.New("usage: admin tenant <name> <admin name> <admin email> <admin password>")
+		}
+
+		if err := createTenant(cfg, args[1], args[2], args[3], args[4]); err != nil {
+			return fmt.Errorf("tenant: %w", err)
+		}
+
+	case "scim-token":
+		if len(args) != 3 {
+			return errors.New("usage: admin scim-token <tenant id> <name>")
+		}
+
+		if err := createSCIMToken(cfg, args[1], args[2]); err != nil {
+			return fmt.Errorf("scim-token: %w", err)
+		}
+
+	case "scim-revoke":
+		if len(args) != 2 {
+			return errors.New("usage: admin scim-revoke <token id>")
+		}
+
+		if err := revokeSCIMToken(cfg, args[1]); err != nil {
+			return fmt.Errorf("scim-revoke: %w", err)
+		}
+
+	case "tax-rates":
+		if len(args) != 3 {
+			return errors.New("usage: admin tax-rates <tenant id> <csv file>")
+		}
+
+		if err := loadTaxRates(cfg, args[1], args[2]); err != nil {
+			return fmt.Errorf("tax-rates: %w", err)
+		}
+
+	default:
+		return fmt.Errorf("unknown command %q", args[0])
 	}
 
 	return nil
@@ -75,3 +150,174 @@ func seed(cfg database.Config) error {
 	fmt.Println("seed data complete")
 	return nil
 }
+
+func createTenant(cfg database.Config, name string, adminName string, adminEmail string, password string) error {
+	db, err := database.Open(cfg)
+	if err != nil {
+		return fmt.Errorf("connect database: %w", err)
+	}
+	defer db.Close()
+
+	addr, err := mail.ParseAddress(adminEmail)
+	if err != nil {
+		return fmt.Errorf("parsing email: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	log := zap.NewNop().Sugar()
+
+	tenantCore := tenant.NewCore(tenantdb.NewStore(log, db))
+	usrCore := user.NewCore(userdb.NewStore(log, db), audit.NewCore(auditdb.NewStore(log, db)))
+
+	// The tenant and its first admin are created together so a failure does
+	// not leave behind a tenant nobody can sign in to.
+	var tnt tenant.Tenant
+	var usr user.User
+	tran := func(ctx context.Context) error {
+		var err error
+		tnt, err = tenantCore.Create(ctx, tenant.NewTenant{Name: name})
+		if err != nil {
+			return fmt.Errorf("create tenant: %w", err)
+		}
+
+		nu := user.NewUser{
+			TenantID:        tnt.ID,
+			Name:            adminName,
+			Email:           *addr,
+			Roles:           []user.Role{user.RoleAdmin, user.RoleUser},
+			Password:        password,
+			PasswordConfirm: password,
+		}
+
+		usr, err = usrCore.Create(tenant.SetTenantID(ctx, tnt.ID), nu)
+		if err != nil {
+			return fmt.Errorf("create admin: %w", err)
+		}
+
+		return nil
+	}
+
+	if err := database.WithinTran(ctx, log, db, tran); err != nil {
+		return err
+	}
+
+	fmt.Printf("tenant created: id[%s] name[%s]\n", tnt.ID, tnt.Name)
+	fmt.Printf("admin created: id[%s] email[%s]\n", usr.ID, usr.Email.Address)
+	return nil
+}
+
+func createSCIMToken(cfg database.Config, tenantID string, name string) error {
+	tntID, err := uuid.Parse(tenantID)
+	if err != nil {
+		return fmt.Errorf("parsing tenant id: %w", err)
+	}
+
+	db, err := database.Open(cfg)
+	if err != nil {
+		return fmt.Errorf("connect database: %w", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	log := zap.NewNop().Sugar()
+
+	tenantCore := tenant.NewCore(tenantdb.NewStore(log, db))
+	if _, err := tenantCore.QueryByID(ctx, tntID); err != nil {
+		return fmt.Errorf("query tenant: %w", err)
+	}
+
+	tokCore := scimtoken.NewCore(scimtokendb.NewStore(log, db))
+
+	tok, secret, err := tokCore.Create(ctx, scimtoken.NewToken{TenantID: tntID, Name: name})
+	if err != nil {
+		return fmt.Errorf("create token: %w", err)
+	}
+
+	fmt.Printf("token created: id[%s] name[%s]\n", tok.ID, tok.Name)
+	fmt.Printf("secret (shown only once): %s\n", secret)
+	return nil
+}
+
+func revokeSCIMToken(cfg database.Config, tokenID string) error {
+	tokID, err := uuid.Parse(tokenID)
+	if err != nil {
+		return fmt.Errorf("parsing token id: %w", err)
+	}
+
+	db, err := database.Open(cfg)
+	if err != nil {
+		return fmt.Errorf("connect database: %w", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tokCore := scimtoken.NewCore(scimtokendb.NewStore(zap.NewNop().Sugar(), db))
+
+	tok, err := tokCore.QueryByID(ctx, tokID)
+	if err != nil {
+		return fmt.Errorf("query token: %w", err)
+	}
+
+	if _, err := tokCore.Revoke(ctx, tok); err != nil {
+		return fmt.Errorf("revoke token: %w", err)
+	}
+
+	fmt.Printf("token revoked: id[%s] name[%s]\n", tok.ID, tok.Name)
+	return nil
+}
+
+func loadTaxRates(cfg database.Config, tenantID string, path string) error {
+	tntID, err := uuid.Parse(tenantID)
+	if err != nil {
+		return fmt.Errorf("parsing tenant id: %w", err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open csv: %w", err)
+	}
+	defer f.Close()
+
+	njs, err := tax.ParseCSV(f)
+	if err != nil {
+		return fmt.Errorf("parse csv: %w", err)
+	}
+
+	db, err := database.Open(cfg)
+	if err != nil {
+		return fmt.Errorf("connect database: %w", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	log := zap.NewNop().Sugar()
+
+	tenantCore := tenant.NewCore(tenantdb.NewStore(log, db))
+	if _, err := tenantCore.QueryByID(ctx, tntID); err != nil {
+		return fmt.Errorf("query tenant: %w", err)
+	}
+
+	auditCore := audit.NewCore(auditdb.NewStore(log, db))
+	usrCore := user.NewCore(userdb.NewStore(log, db), auditCore)
+	exchCore := exchange.NewCore(exchangedb.NewStore(log, db))
+	prdCore := product.NewCore(log, usrCore, auditCore, exchCore, productdb.NewStore(log, db))
+	taxCore := tax.NewCore(taxdb.NewStore(log, db), prdCore)
+
+	jurs, err := taxCore.Load(tenant.SetTenantID(ctx, tntID), njs)
+	if err != nil {
+		return fmt.Errorf("load rates: %w", err)
+	}
+
+	for _, jur := range jurs {
+		fmt.Printf("jurisdiction loaded: code[%s] name[%s] rates[%d]\n", jur.Code, jur.Name, len(jur.Rates))
+	}
+	return nil
+}
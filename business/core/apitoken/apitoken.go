@@ -0,0 +1,61 @@
+// Package apitoken provides the core business API for keeping track of the
+// bearer tokens issued to users for the API.
+package apitoken
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Storer interface declares the behavior this package needs to persist and
+// retrieve data.
+type Storer interface {
+	Create(ctx context.Context, tok Token) error
+	QueryByUser(ctx context.Context, userID uuid.UUID) ([]Token, error)
+}
+
+// Core manages the set of APIs for token access.
+type Core struct {
+	storer Storer
+}
+
+// NewCore constructs a core for token api access.
+func NewCore(storer Storer) *Core {
+	return &Core{
+		storer: storer,
+	}
+}
+
+// Create records a token about to be issued to a user. The token is signed
+// with the ID and dates of the record.
+func (c *Core) Create(ctx context.Context, nt NewToken) (Token, error) {
+	now := time.Now()
+
+	tok := Token{
+		ID:          uuid.New(),
+		TenantID:    nt.TenantID,
+		UserID:      nt.UserID,
+		Issuer:      nt.Issuer,
+		DateCreated: now,
+		DateExpires: now.Add(nt.TTL),
+	}
+
+	if err := c.storer.Create(ctx, tok); err != nil {
+		return Token{}, fmt.Errorf("create: %w", err)
+	}
+
+	return tok, nil
+}
+
+// QueryByUser retrieves every token issued to a user, expired ones included,
+// newest first.
+func (c *Core) QueryByUser(ctx context.Context, userID uuid.UUID) ([]Token, error) {
+	toks, err := c.storer.QueryByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("query: userID[%s]: %w", userID, err)
+	}
+	return toks, nil
+}
@@ -0,0 +1,27 @@
+package apitoken
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Token represents a bearer token issued to a user for the API. The token is
+// signed and never stored, only what it was issued for. The ID is carried by
+// the token as its JWT ID.
+type Token struct {
+	ID          uuid.UUID
+	TenantID    uuid.UUID
+	UserID      uuid.UUID
+	Issuer      string
+	DateCreated time.Time
+	DateExpires time.Time
+}
+
+// NewToken contains information needed to issue a new token.
+type NewToken struct {
+	TenantID uuid.UUID
+	UserID   uuid.UUID
+	Issuer   string
+	TTL      time.Duration
+}
@@ -0,0 +1,70 @@
+// Package apitokendb contains API token related CRUD functionality.
+package apitokendb
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/aleury/service/business/core/apitoken"
+	"github.com/aleury/service/business/core/tenant"
+	database "github.com/aleury/service/business/sys/database/pgx"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// Store manages the set of APIs for token database access.
+type Store struct {
+	log *zap.SugaredLogger
+	db  *sqlx.DB
+}
+
+// NewStore constructs the api for data access.
+func NewStore(log *zap.SugaredLogger, db *sqlx.DB) *Store {
+	return &Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// Create inserts a new token into the database.
+func (s *Store) Create(ctx context.Context, tok apitoken.Token) error {
+	const q = `
+	INSERT INTO api_tokens
+		(token_id, tenant_id, user_id, issuer, date_created, date_expires)
+	VALUES
+		(:token_id, :tenant_id, :user_id, :issuer, :date_created, :date_expires)`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, toDBToken(tok)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// QueryByUser retrieves every token issued to a user, newest first.
+func (s *Store) QueryByUser(ctx context.Context, userID uuid.UUID) ([]apitoken.Token, error) {
+	data := map[string]any{
+		"user_id": userID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		api_tokens
+	WHERE
+		user_id = :user_id`
+
+	buf := bytes.NewBufferString(q)
+	buf.WriteString(tenant.Scope(ctx, data, "tenant_id"))
+	buf.WriteString(" ORDER BY date_created DESC")
+
+	var dbToks []dbToken
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, buf.String(), data, &dbToks); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toCoreTokenSlice(dbToks), nil
+}
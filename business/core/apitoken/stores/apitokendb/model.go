@@ -0,0 +1,49 @@
+package apitokendb
+
+import (
+	"time"
+
+	"github.com/aleury/service/business/core/apitoken"
+	"github.com/google/uuid"
+)
+
+// dbToken represents the structure we need for moving data
+// between the app and the database.
+type dbToken struct {
+	ID          uuid.UUID `db:"token_id"`
+	TenantID    uuid.UUID `db:"tenant_id"`
+	UserID      uuid.UUID `db:"user_id"`
+	Issuer      string    `db:"issuer"`
+	DateCreated time.Time `db:"date_created"`
+	DateExpires time.Time `db:"date_expires"`
+}
+
+func toDBToken(tok apitoken.Token) dbToken {
+	return dbToken{
+		ID:          tok.ID,
+		TenantID:    tok.TenantID,
+		UserID:      tok.UserID,
+		Issuer:      tok.Issuer,
+		DateCreated: tok.DateCreated.UTC(),
+		DateExpires: tok.DateExpires.UTC(),
+	}
+}
+
+func toCoreToken(dbTok dbToken) apitoken.Token {
+	return apitoken.Token{
+		ID:          dbTok.ID,
+		TenantID:    dbTok.TenantID,
+		UserID:      dbTok.UserID,
+		Issuer:      dbTok.Issuer,
+		DateCreated: dbTok.DateCreated.In(time.Local),
+		DateExpires: dbTok.DateExpires.In(time.Local),
+	}
+}
+
+func toCoreTokenSlice(dbToks []dbToken) []apitoken.Token {
+	toks := make([]apitoken.Token, len(dbToks))
+	for i, dbTok := range dbToks {
+		toks[i] = toCoreToken(dbTok)
+	}
+	return toks
+}
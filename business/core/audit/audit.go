@@ -0,0 +1,145 @@
+// Package audit provides a core business API for recording who changed what
+// across the system.
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/aleury/service/business/core/tenant"
+	"github.com/aleury/service/business/data/order"
+	"github.com/aleury/service/foundation/web"
+	"github.com/google/uuid"
+)
+
+// Storer interface declares the behavior this package needs to persist and
+// retrieve data.
+type Storer interface {
+	Create(ctx context.Context, e Entry) error
+	Query(ctx context.Context, filter QueryFilter, orderBy order.By, pageNumber int, rowsPerPage int) ([]Entry, error)
+	Count(ctx context.Context, filter QueryFilter) (int, error)
+	Redact(ctx context.Context, entityType string, entityID uuid.UUID, fields []string) error
+}
+
+// Core manages the set of APIs for audit access.
+type Core struct {
+	storer Storer
+}
+
+// NewCore constructs a core for audit api access.
+func NewCore(storer Storer) *Core {
+	return &Core{
+		storer: storer,
+	}
+}
+
+// Record stores an entry describing a change made to an entity. The actor
+// and trace id are taken from the context. To have the entry written in the
+// same transaction as the change, call Record with the context handed out by
+// the store's WithinTran.
+func (c *Core) Record(ctx context.Context, ne NewEntry) (Entry, error) {
+	diff, err := Diff(ne.Before, ne.After)
+	if err != nil {
+		return Entry{}, fmt.Errorf("diff: %w", err)
+	}
+
+	e := Entry{
+		ID:          uuid.New(),
+		TenantID:    tenant.GetTenantID(ctx),
+		ActorID:     GetActorID(ctx),
+		Action:      ne.Action,
+		EntityType:  ne.EntityType,
+		EntityID:    ne.EntityID,
+		Diff:        diff,
+		TraceID:     web.GetTraceID(ctx),
+		DateCreated: time.Now(),
+	}
+
+	if err := c.storer.Create(ctx, e); err != nil {
+		return Entry{}, fmt.Errorf("create: %w", err)
+	}
+
+	return e, nil
+}
+
+// Query retrieves a list of existing audit entries from the database.
+func (c *Core) Query(ctx context.Context, filter QueryFilter, orderBy order.By, pageNumber int, rowsPerPage int) ([]Entry, error) {
+	entries, err := c.storer.Query(ctx, filter, orderBy, pageNumber, rowsPerPage)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+	return entries, nil
+}
+
+// Count returns the total number of audit entries in the store.
+func (c *Core) Count(ctx context.Context, filter QueryFilter) (int, error) {
+	count, err := c.storer.Count(ctx, filter)
+	if err != nil {
+		return 0, fmt.Errorf("count: %w", err)
+	}
+	return count, nil
+}
+
+// Redact removes the specified fields from the recorded changes of an entity.
+// This is used when the values of those fields must no longer be retained.
+func (c *Core) Redact(ctx context.Context, entityType string, entityID uuid.UUID, fields []string) error {
+	if err := c.storer.Redact(ctx, entityType, entityID, fields); err != nil {
+		return fmt.Errorf("redact: entityType[%s] entityID[%s]: %w", entityType, entityID, err)
+	}
+	return nil
+}
+
+// =============================================================================
+
+// Diff compares two snapshots of an entity and returns a JSON document holding
+// the before and after value of every field that changed. Values are compared
+// by their JSON encoding.
+func Diff(before map[string]any, after map[string]any) (json.RawMessage, error) {
+	changes := make(map[string]Change)
+
+	for field, a := range after {
+		b, exists := before[field]
+		if !exists {
+			changes[field] = Change{Before: nil, After: a}
+			continue
+		}
+
+		equal, err := jsonEqual(b, a)
+		if err != nil {
+			return nil, fmt.Errorf("field[%s]: %w", field, err)
+		}
+		if !equal {
+			changes[field] = Change{Before: b, After: a}
+		}
+	}
+
+	for field, b := range before {
+		if _, exists := after[field]; !exists {
+			changes[field] = Change{Before: b, After: nil}
+		}
+	}
+
+	data, err := json.Marshal(changes)
+	if err != nil {
+		return nil, fmt.Errorf("marshal: %w", err)
+	}
+
+	return data, nil
+}
+
+func jsonEqual(a any, b any) (bool, error) {
+	aData, err := json.Marshal(a)
+	if err != nil {
+		return false, err
+	}
+
+	bData, err := json.Marshal(b)
+	if err != nil {
+		return false, err
+	}
+
+	return bytes.Equal(aData, bData), nil
+}
@@ -0,0 +1,195 @@
+package audit_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"runtime/debug"
+	"testing"
+	"time"
+
+	"github.com/aleury/service/business/core/audit"
+	"github.com/aleury/service/business/core/user"
+	"github.com/aleury/service/business/data/dbtest"
+	"github.com/aleury/service/foundation/docker"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+)
+
+var c *docker.Container
+
+func TestMain(m *testing.M) {
+	var err error
+	c, err = dbtest.StartDB()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer dbtest.StopDB(c)
+
+	m.Run()
+}
+
+func Test_Audit(t *testing.T) {
+	t.Run("diff", diff)
+	t.Run("record", record)
+}
+
+// =============================================================================
+
+func diff(t *testing.T) {
+	tests := []struct {
+		name   string
+		before map[string]any
+		after  map[string]any
+		want   map[string]audit.Change
+	}{
+		{
+			"create",
+			nil,
+			map[string]any{"name": "Comic Books"},
+			map[string]audit.Change{"name": {Before: nil, After: "Comic Books"}},
+		},
+		{
+			"unchanged",
+			map[string]any{"name": "Comic Books", "quantity": 5},
+			map[string]any{"name": "Comic Books", "quantity": 5.0},
+			map[string]audit.Change{},
+		},
+		{
+			"update",
+			map[string]any{"name": "Comic Books", "quantity": 5, "sku": "CB-1"},
+			map[string]any{"name": "Comic Books", "quantity": 4, "roles": []string{"USER"}},
+			map[string]audit.Change{
+				"quantity": {Before: 5.0, After: 4.0},
+				"sku":      {Before: "CB-1", After: nil},
+				"roles":    {Before: nil, After: []any{"USER"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		data, err := audit.Diff(tt.before, tt.after)
+		if err != nil {
+			t.Fatalf("Should be able to diff %s: %s.", tt.name, err)
+		}
+
+		var got map[string]audit.Change
+		if err := json.Unmarshal(data, &got); err != nil {
+			t.Fatalf("Should be able to unmarshal the diff of %s: %s.", tt.name, err)
+		}
+
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Errorf("Should only hold the fields that changed for %s. diff:\n%s", tt.name, diff)
+		}
+	}
+}
+
+func record(t *testing.T) {
+	test := dbtest.NewTest(t, c)
+	defer func() {
+		if r := recover(); r != nil {
+			t.Log(r)
+			t.Error(string(debug.Stack()))
+		}
+		test.Teardown()
+	}()
+
+	api := test.CoreAPIs
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	t.Log("Go seeding...")
+
+	actor, err := seed(ctx, api)
+	if err != nil {
+		t.Fatalf("Seeding error: %s", err)
+	}
+
+	// -------------------------------------------------------------------------
+
+	actorCtx := audit.SetActorID(ctx, actor.ID)
+	entityID := uuid.New()
+
+	ne := audit.NewEntry{
+		Action:     audit.ActionUpdate,
+		EntityType: audit.EntityProduct,
+		EntityID:   entityID,
+		Before:     map[string]any{"name": "Comic Books", "quantity": 5},
+		After:      map[string]any{"name": "Comic Books", "quantity": 4},
+	}
+
+	e, err := api.Audit.Record(actorCtx, ne)
+	if err != nil {
+		t.Fatalf("Should be able to record an entry: %s.", err)
+	}
+
+	if e.ActorID != actor.ID {
+		t.Errorf("Should take the actor from the context: got %s want %s", e.ActorID, actor.ID)
+	}
+
+	system, err := api.Audit.Record(ctx, ne)
+	if err != nil {
+		t.Fatalf("Should be able to record an entry: %s.", err)
+	}
+
+	if system.ActorID != uuid.Nil {
+		t.Errorf("Should record changes without an actor as made by the system: got %s", system.ActorID)
+	}
+
+	// -------------------------------------------------------------------------
+
+	var filter audit.QueryFilter
+	filter.WithEntityType(audit.EntityProduct)
+	filter.WithEntityID(entityID)
+
+	entries, err := api.Audit.Query(ctx, filter, audit.DefaultOrderBy, 1, 10)
+	if err != nil {
+		t.Fatalf("Should be able to query entries: %s.", err)
+	}
+
+	if len(entries) != 2 {
+		t.Fatalf("Should get back both entries of the entity: got %d", len(entries))
+	}
+
+	var changes map[string]audit.Change
+	if err := json.Unmarshal(entries[0].Diff, &changes); err != nil {
+		t.Fatalf("Should be able to unmarshal the diff: %s.", err)
+	}
+
+	if diff := cmp.Diff(map[string]audit.Change{"quantity": {Before: 5.0, After: 4.0}}, changes); diff != "" {
+		t.Errorf("Should have stored the diff of the entry. diff:\n%s", diff)
+	}
+
+	filter.WithActorID(actor.ID)
+
+	n, err := api.Audit.Count(ctx, filter)
+	if err != nil {
+		t.Fatalf("Should be able to count entries: %s.", err)
+	}
+
+	if n != 1 {
+		t.Errorf("Should only count the entries of the actor: got %d", n)
+	}
+
+	filter = audit.QueryFilter{}
+	filter.WithEntityID(entityID)
+	filter.WithEndCreatedDate(e.DateCreated.Add(-time.Hour))
+
+	if n, err := api.Audit.Count(ctx, filter); err != nil || n != 0 {
+		t.Errorf("Should NOT count entries created after the end date: got %d: %v", n, err)
+	}
+}
+
+// =============================================================================
+
+// seed returns a seeded user to act as the author of the changes.
+func seed(ctx context.Context, api dbtest.CoreAPIs) (user.User, error) {
+	usrs, err := api.User.Query(ctx, user.QueryFilter{}, user.DefaultOrderBy, 1, 1)
+	if err != nil {
+		return user.User{}, fmt.Errorf("seeding users: %w", err)
+	}
+
+	return usrs[0], nil
+}
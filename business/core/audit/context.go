@@ -0,0 +1,28 @@
+package audit
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// ctxKey represents the type of value for the context key.
+type ctxKey int
+
+// actorKey is used to store/retrieve the actor id from a context.Context.
+const actorKey ctxKey = 1
+
+// SetActorID stores the id of the user performing changes in the context.
+func SetActorID(ctx context.Context, actorID uuid.UUID) context.Context {
+	return context.WithValue(ctx, actorKey, actorID)
+}
+
+// GetActorID returns the id of the user performing changes. A zero value id
+// is returned for changes made by the system.
+func GetActorID(ctx context.Context) uuid.UUID {
+	v, ok := ctx.Value(actorKey).(uuid.UUID)
+	if !ok {
+		return uuid.UUID{}
+	}
+	return v
+}
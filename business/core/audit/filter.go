@@ -0,0 +1,65 @@
+package audit
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aleury/service/business/sys/validate"
+	"github.com/google/uuid"
+)
+
+// QueryFilter holds the available fields a query can be filtered on.
+type QueryFilter struct {
+	ActorID          *uuid.UUID `validate:"omitempty"`
+	Action           *string    `validate:"omitempty"`
+	EntityType       *string    `validate:"omitempty"`
+	EntityID         *uuid.UUID `validate:"omitempty"`
+	TraceID          *string    `validate:"omitempty"`
+	StartCreatedDate *time.Time `validate:"omitempty"`
+	EndCreatedDate   *time.Time `validate:"omitempty"`
+}
+
+// Validate checks the data in the model is considered clean.
+func (qf *QueryFilter) Validate() error {
+	if err := validate.Check(qf); err != nil {
+		return fmt.Errorf("validate: %w", err)
+	}
+	return nil
+}
+
+// WithActorID sets the ActorID field of the QueryFilter value.
+func (qf *QueryFilter) WithActorID(actorID uuid.UUID) {
+	qf.ActorID = &actorID
+}
+
+// WithAction sets the Action field of the QueryFilter value.
+func (qf *QueryFilter) WithAction(action string) {
+	qf.Action = &action
+}
+
+// WithEntityType sets the EntityType field of the QueryFilter value.
+func (qf *QueryFilter) WithEntityType(entityType string) {
+	qf.EntityType = &entityType
+}
+
+// WithEntityID sets the EntityID field of the QueryFilter value.
+func (qf *QueryFilter) WithEntityID(entityID uuid.UUID) {
+	qf.EntityID = &entityID
+}
+
+// WithTraceID sets the TraceID field of the QueryFilter value.
+func (qf *QueryFilter) WithTraceID(traceID string) {
+	qf.TraceID = &traceID
+}
+
+// WithStartCreatedDate sets the StartCreatedDate field of the QueryFilter value.
+func (qf *QueryFilter) WithStartCreatedDate(startDate time.Time) {
+	d := startDate.UTC()
+	qf.StartCreatedDate = &d
+}
+
+// WithEndCreatedDate sets the EndCreatedDate field of the QueryFilter value.
+func (qf *QueryFilter) WithEndCreatedDate(endDate time.Time) {
+	d := endDate.UTC()
+	qf.EndCreatedDate = &d
+}
@@ -0,0 +1,58 @@
+package audit
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Set of actions that can be recorded against an entity.
+const (
+	ActionCreate  = "create"
+	ActionUpdate  = "update"
+	ActionDelete  = "delete"
+	ActionRestore = "restore"
+	ActionErase   = "erase"
+	ActionExport  = "export"
+)
+
+// Set of entity types that are audited.
+const (
+	EntityUser       = "user"
+	EntityProduct    = "product"
+	EntityDepartment = "department"
+	EntityOrder      = "order"
+	EntityCategory   = "category"
+	EntityPromotion  = "promotion"
+)
+
+// Entry represents a single recorded change made to an entity.
+type Entry struct {
+	ID          uuid.UUID
+	TenantID    uuid.UUID
+	ActorID     uuid.UUID
+	Action      string
+	EntityType  string
+	EntityID    uuid.UUID
+	Diff        json.RawMessage
+	TraceID     string
+	DateCreated time.Time
+}
+
+// NewEntry contains information needed to record a change. Before and After
+// hold a snapshot of the entity's fields prior to and following the change.
+// Before is nil for a create.
+type NewEntry struct {
+	Action     string
+	EntityType string
+	EntityID   uuid.UUID
+	Before     map[string]any
+	After      map[string]any
+}
+
+// Change represents the before and after value of a single field.
+type Change struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
@@ -0,0 +1,133 @@
+// Package auditdb contains audit related CRUD functionality.
+package auditdb
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+
+	"github.com/aleury/service/business/core/audit"
+	"github.com/aleury/service/business/data/order"
+	database "github.com/aleury/service/business/sys/database/pgx"
+	"github.com/aleury/service/business/sys/database/pgx/dbarray"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// Store manages the set of APIs for audit database access.
+type Store struct {
+	log *zap.SugaredLogger
+	db  *sqlx.DB
+}
+
+// NewStore constructs the api for data access.
+func NewStore(log *zap.SugaredLogger, db *sqlx.DB) *Store {
+	return &Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// Create inserts a new audit entry into the database.
+func (s *Store) Create(ctx context.Context, e audit.Entry) error {
+	const q = `
+	INSERT INTO audits
+		(audit_id, tenant_id, actor_id, action, entity_type, entity_id, diff, trace_id, date_created)
+	VALUES
+		(:audit_id, :tenant_id, :actor_id, :action, :entity_type, :entity_id, :diff, :trace_id, :date_created)`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, toDBEntry(e)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Redact removes the specified fields from the diff of every entry recorded
+// for the entity.
+func (s *Store) Redact(ctx context.Context, entityType string, entityID uuid.UUID, fields []string) error {
+	data := struct {
+		EntityType string `db:"entity_type"`
+		EntityID   string `db:"entity_id"`
+		Fields     interface {
+			driver.Valuer
+			sql.Scanner
+		} `db:"fields"`
+	}{
+		EntityType: entityType,
+		EntityID:   entityID.String(),
+		Fields:     dbarray.Array(fields),
+	}
+
+	const q = `
+	UPDATE
+		audits
+	SET
+		diff = diff - CAST(:fields AS TEXT[])
+	WHERE
+		entity_type = :entity_type AND entity_id = :entity_id`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Query retrieves a list of existing audit entries from the database.
+func (s *Store) Query(ctx context.Context, filter audit.QueryFilter, orderBy order.By, pageNumber int, rowsPerPage int) ([]audit.Entry, error) {
+	data := map[string]any{
+		"offset":        (pageNumber - 1) * rowsPerPage,
+		"rows_per_page": rowsPerPage,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		audits`
+
+	buf := bytes.NewBufferString(q)
+	s.applyFilter(ctx, filter, data, buf)
+
+	orderByClause, err := orderByClause(orderBy)
+	if err != nil {
+		return nil, err
+	}
+
+	buf.WriteString(orderByClause)
+	buf.WriteString(" OFFSET :offset LIMIT :rows_per_page")
+
+	var dbEntries []dbEntry
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, buf.String(), data, &dbEntries); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toCoreEntrySlice(dbEntries), nil
+}
+
+// Count returns the total number of audit entries in the DB.
+func (s *Store) Count(ctx context.Context, filter audit.QueryFilter) (int, error) {
+	data := map[string]any{}
+
+	const q = `
+	SELECT
+		COUNT(*)
+	FROM
+		audits`
+
+	buf := bytes.NewBufferString(q)
+	s.applyFilter(ctx, filter, data, buf)
+
+	var result struct {
+		Count int `db:"count"`
+	}
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, buf.String(), data, &result); err != nil {
+		return 0, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return result.Count, nil
+}
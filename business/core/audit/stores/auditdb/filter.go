@@ -0,0 +1,60 @@
+package auditdb
+
+import (
+	"bytes"
+	"context"
+	"strings"
+
+	"github.com/aleury/service/business/core/audit"
+	"github.com/aleury/service/business/core/tenant"
+	"github.com/google/uuid"
+)
+
+func (s *Store) applyFilter(ctx context.Context, filter audit.QueryFilter, data map[string]any, buf *bytes.Buffer) {
+	var wc []string
+
+	if tenantID := tenant.GetTenantID(ctx); tenantID != uuid.Nil {
+		data["tenant_id"] = tenantID
+		wc = append(wc, "tenant_id = :tenant_id")
+	}
+
+	if filter.ActorID != nil {
+		data["actor_id"] = *filter.ActorID
+		wc = append(wc, "actor_id = :actor_id")
+	}
+
+	if filter.Action != nil {
+		data["action"] = *filter.Action
+		wc = append(wc, "action = :action")
+	}
+
+	if filter.EntityType != nil {
+		data["entity_type"] = *filter.EntityType
+		wc = append(wc, "entity_type = :entity_type")
+	}
+
+	if filter.EntityID != nil {
+		data["entity_id"] = *filter.EntityID
+		wc = append(wc, "entity_id = :entity_id")
+	}
+
+	if filter.TraceID != nil {
+		data["trace_id"] = *filter.TraceID
+		wc = append(wc, "trace_id = :trace_id")
+	}
+
+	if filter.StartCreatedDate != nil {
+		data["start_date_created"] = *filter.StartCreatedDate
+		wc = append(wc, "date_created >= :start_date_created")
+	}
+
+	if filter.EndCreatedDate != nil {
+		data["end_date_created"] = *filter.EndCreatedDate
+		wc = append(wc, "date_created <= :end_date_created")
+	}
+
+	if len(wc) > 0 {
+		buf.WriteString(" WHERE ")
+		buf.WriteString(strings.Join(wc, " AND "))
+	}
+}
@@ -0,0 +1,65 @@
+package auditdb
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/aleury/service/business/core/audit"
+	"github.com/google/uuid"
+)
+
+// dbEntry represents the structure we need for moving data
+// between the app and the database.
+type dbEntry struct {
+	ID          uuid.UUID     `db:"audit_id"`
+	TenantID    uuid.NullUUID `db:"tenant_id"`
+	ActorID     uuid.NullUUID `db:"actor_id"`
+	Action      string        `db:"action"`
+	EntityType  string        `db:"entity_type"`
+	EntityID    uuid.UUID     `db:"entity_id"`
+	Diff        string        `db:"diff"`
+	TraceID     string        `db:"trace_id"`
+	DateCreated time.Time     `db:"date_created"`
+}
+
+func toDBEntry(e audit.Entry) dbEntry {
+	return dbEntry{
+		ID: e.ID,
+		TenantID: uuid.NullUUID{
+			UUID:  e.TenantID,
+			Valid: e.TenantID != uuid.Nil,
+		},
+		ActorID: uuid.NullUUID{
+			UUID:  e.ActorID,
+			Valid: e.ActorID != uuid.Nil,
+		},
+		Action:      e.Action,
+		EntityType:  e.EntityType,
+		EntityID:    e.EntityID,
+		Diff:        string(e.Diff),
+		TraceID:     e.TraceID,
+		DateCreated: e.DateCreated.UTC(),
+	}
+}
+
+func toCoreEntry(dbE dbEntry) audit.Entry {
+	return audit.Entry{
+		ID:          dbE.ID,
+		TenantID:    dbE.TenantID.UUID,
+		ActorID:     dbE.ActorID.UUID,
+		Action:      dbE.Action,
+		EntityType:  dbE.EntityType,
+		EntityID:    dbE.EntityID,
+		Diff:        json.RawMessage(dbE.Diff),
+		TraceID:     dbE.TraceID,
+		DateCreated: dbE.DateCreated.In(time.Local),
+	}
+}
+
+func toCoreEntrySlice(dbEntries []dbEntry) []audit.Entry {
+	entries := make([]audit.Entry, len(dbEntries))
+	for i, dbE := range dbEntries {
+		entries[i] = toCoreEntry(dbE)
+	}
+	return entries
+}
@@ -0,0 +1,23 @@
+package auditdb
+
+import (
+	"fmt"
+
+	"github.com/aleury/service/business/core/audit"
+	"github.com/aleury/service/business/data/order"
+)
+
+var orderByFields = map[string]string{
+	audit.OrderByID:          "audit_id",
+	audit.OrderByActorID:     "actor_id",
+	audit.OrderByEntityType:  "entity_type",
+	audit.OrderByDateCreated: "date_created",
+}
+
+func orderByClause(orderBy order.By) (string, error) {
+	by, exists := orderByFields[orderBy.Field]
+	if !exists {
+		return "", fmt.Errorf("field %q does not exist", orderBy.Field)
+	}
+	return fmt.Sprintf(" ORDER BY %s %s", by, orderBy.Direction), nil
+}
@@ -0,0 +1,243 @@
+// Package category provides the core business API for the tree of categories
+// products are organized in.
+package category
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aleury/service/business/core/audit"
+	"github.com/aleury/service/business/data/order"
+	"github.com/google/uuid"
+)
+
+// Set of error variables for CRUD operations.
+var (
+	ErrNotFound       = errors.New("category not found")
+	ErrParentNotFound = errors.New("parent category not found")
+	ErrUniqueName     = errors.New("name is not unique among its siblings")
+	ErrHasChildren    = errors.New("category still has subcategories")
+	ErrCycle          = errors.New("category can't be moved under itself or one of its descendants")
+)
+
+// Storer interface declares the behavior this package needs to persist and
+// retrieve data.
+type Storer interface {
+	WithinTran(ctx context.Context, fn func(ctx context.Context) error) error
+	Create(ctx context.Context, cat Category) error
+	Update(ctx context.Context, cat Category) error
+	Move(ctx context.Context, cat Category) error
+	Delete(ctx context.Context, cat Category) error
+	Query(ctx context.Context, filter QueryFilter, orderBy order.By, pageNumber int, rowsPerPage int) ([]Category, error)
+	Count(ctx context.Context, filter QueryFilter) (int, error)
+	QueryByID(ctx context.Context, categoryID uuid.UUID) (Category, error)
+	Assign(ctx context.Context, productID uuid.UUID, cat Category, now time.Time) error
+	Unassign(ctx context.Context, productID uuid.UUID, categoryID uuid.UUID) error
+	QueryByProduct(ctx context.Context, productID uuid.UUID) ([]Category, error)
+}
+
+// Core manages the set of APIs for category access.
+type Core struct {
+	storer    Storer
+	auditCore *audit.Core
+}
+
+// NewCore constructs a core for category api access.
+func NewCore(storer Storer, auditCore *audit.Core) *Core {
+	return &Core{
+		storer:    storer,
+		auditCore: auditCore,
+	}
+}
+
+// Create inserts a new category into the database. It is added at the top
+// level of the tree when it has no parent.
+func (c *Core) Create(ctx context.Context, nc NewCategory) (Category, error) {
+	now := time.Now()
+
+	cat := Category{
+		ID:          uuid.New(),
+		TenantID:    nc.TenantID,
+		ParentID:    nc.ParentID,
+		Name:        nc.Name,
+		DateCreated: now,
+		DateUpdated: now,
+	}
+
+	tran := func(ctx context.Context) error {
+		if err := c.storer.Create(ctx, cat); err != nil {
+			return fmt.Errorf("create: %w", err)
+		}
+		return c.record(ctx, audit.ActionCreate, Category{}, cat)
+	}
+
+	if err := c.storer.WithinTran(ctx, tran); err != nil {
+		return Category{}, err
+	}
+
+	return cat, nil
+}
+
+// Update replaces a category document in the database.
+func (c *Core) Update(ctx context.Context, cat Category, uc UpdateCategory) (Category, error) {
+	before := cat
+
+	if uc.Name != nil {
+		cat.Name = *uc.Name
+	}
+	cat.DateUpdated = time.Now()
+
+	tran := func(ctx context.Context) error {
+		if err := c.storer.Update(ctx, cat); err != nil {
+			return fmt.Errorf("update: %w", err)
+		}
+		return c.record(ctx, audit.ActionUpdate, before, cat)
+	}
+
+	if err := c.storer.WithinTran(ctx, tran); err != nil {
+		return Category{}, err
+	}
+
+	return cat, nil
+}
+
+// Move puts a category and everything below it under another parent, or at
+// the top level of the tree when the parent is uuid.Nil. It fails with
+// ErrCycle when the parent is the category itself or one of its descendants.
+func (c *Core) Move(ctx context.Context, cat Category, parentID uuid.UUID) (Category, error) {
+	if parentID == cat.ID {
+		return Category{}, ErrCycle
+	}
+
+	before := cat
+	cat.ParentID = parentID
+	cat.DateUpdated = time.Now()
+
+	tran := func(ctx context.Context) error {
+		if err := c.storer.Move(ctx, cat); err != nil {
+			return fmt.Errorf("move: %w", err)
+		}
+		return c.record(ctx, audit.ActionUpdate, before, cat)
+	}
+
+	if err := c.storer.WithinTran(ctx, tran); err != nil {
+		return Category{}, err
+	}
+
+	return cat, nil
+}
+
+// Delete removes a category from the database. A category can only be
+// deleted once it has no subcategories. Products assigned to it are not
+// deleted.
+func (c *Core) Delete(ctx context.Context, cat Category) error {
+	tran := func(ctx context.Context) error {
+		if err := c.storer.Delete(ctx, cat); err != nil {
+			return fmt.Errorf("delete: %w", err)
+		}
+
+		ne := audit.NewEntry{
+			Action:     audit.ActionDelete,
+			EntityType: audit.EntityCategory,
+			EntityID:   cat.ID,
+			Before:     auditFields(cat),
+		}
+		if _, err := c.auditCore.Record(ctx, ne); err != nil {
+			return fmt.Errorf("audit: %w", err)
+		}
+
+		return nil
+	}
+
+	return c.storer.WithinTran(ctx, tran)
+}
+
+// Query retrieves a list of existing categories from the database.
+func (c *Core) Query(ctx context.Context, filter QueryFilter, orderBy order.By, pageNumber int, rowsPerPage int) ([]Category, error) {
+	cats, err := c.storer.Query(ctx, filter, orderBy, pageNumber, rowsPerPage)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+	return cats, nil
+}
+
+// Count returns the total number of categories in the store.
+func (c *Core) Count(ctx context.Context, filter QueryFilter) (int, error) {
+	count, err := c.storer.Count(ctx, filter)
+	if err != nil {
+		return 0, fmt.Errorf("count: %w", err)
+	}
+	return count, nil
+}
+
+// QueryByID gets the specified category from the database.
+func (c *Core) QueryByID(ctx context.Context, categoryID uuid.UUID) (Category, error) {
+	cat, err := c.storer.QueryByID(ctx, categoryID)
+	if err != nil {
+		return Category{}, fmt.Errorf("query: categoryID[%s]: %w", categoryID, err)
+	}
+	return cat, nil
+}
+
+// Assign puts a product in a category. Assigning it again has no effect.
+func (c *Core) Assign(ctx context.Context, productID uuid.UUID, cat Category) error {
+	if err := c.storer.Assign(ctx, productID, cat, time.Now()); err != nil {
+		return fmt.Errorf("assign: productID[%s] categoryID[%s]: %w", productID, cat.ID, err)
+	}
+	return nil
+}
+
+// Unassign takes a product out of a category.
+func (c *Core) Unassign(ctx context.Context, productID uuid.UUID, categoryID uuid.UUID) error {
+	if err := c.storer.Unassign(ctx, productID, categoryID); err != nil {
+		return fmt.Errorf("unassign: productID[%s] categoryID[%s]: %w", productID, categoryID, err)
+	}
+	return nil
+}
+
+// QueryByProduct retrieves the categories a product is assigned to.
+func (c *Core) QueryByProduct(ctx context.Context, productID uuid.UUID) ([]Category, error) {
+	cats, err := c.storer.QueryByProduct(ctx, productID)
+	if err != nil {
+		return nil, fmt.Errorf("query: productID[%s]: %w", productID, err)
+	}
+	return cats, nil
+}
+
+// =============================================================================
+
+// record writes an audit entry describing the change between the two
+// versions of the category. A zero value before represents a newly created
+// category.
+func (c *Core) record(ctx context.Context, action string, before Category, after Category) error {
+	ne := audit.NewEntry{
+		Action:     action,
+		EntityType: audit.EntityCategory,
+		EntityID:   after.ID,
+		After:      auditFields(after),
+	}
+	if before.ID != uuid.Nil {
+		ne.Before = auditFields(before)
+	}
+
+	if _, err := c.auditCore.Record(ctx, ne); err != nil {
+		return fmt.Errorf("audit: %w", err)
+	}
+
+	return nil
+}
+
+// auditFields returns the set of category fields that are tracked by the
+// audit log.
+func auditFields(cat Category) map[string]any {
+	fields := map[string]any{
+		"name":     cat.Name,
+		"parentId": nil,
+	}
+	if cat.ParentID != uuid.Nil {
+		fields["parentId"] = cat.ParentID.String()
+	}
+	return fields
+}
@@ -0,0 +1,257 @@
+package category_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"testing"
+	"time"
+
+	"github.com/aleury/service/business/core/category"
+	"github.com/aleury/service/business/core/product"
+	"github.com/aleury/service/business/core/user"
+	"github.com/aleury/service/business/data/dbtest"
+	"github.com/aleury/service/business/data/money"
+	"github.com/aleury/service/foundation/docker"
+	"github.com/google/uuid"
+)
+
+var c *docker.Container
+
+func TestMain(m *testing.M) {
+	var err error
+	c, err = dbtest.StartDB()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer dbtest.StopDB(c)
+
+	m.Run()
+}
+
+func Test_Category(t *testing.T) {
+	t.Run("tree", tree)
+	t.Run("products", products)
+}
+
+// =============================================================================
+
+func tree(t *testing.T) {
+	test := dbtest.NewTest(t, c)
+	defer func() {
+		if r := recover(); r != nil {
+			t.Log(r)
+			t.Error(string(debug.Stack()))
+		}
+		test.Teardown()
+	}()
+
+	api := test.CoreAPIs
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	t.Log("Go seeding...")
+
+	usr, err := seed(ctx, api)
+	if err != nil {
+		t.Fatalf("Seeding error: %s", err)
+	}
+
+	newCategory := func(parentID uuid.UUID, name string) category.Category {
+		cat, err := api.Category.Create(ctx, category.NewCategory{
+			TenantID: usr.TenantID,
+			ParentID: parentID,
+			Name:     name,
+		})
+		if err != nil {
+			t.Fatalf("Should be able to create category %q: %s.", name, err)
+		}
+		return cat
+	}
+
+	// -------------------------------------------------------------------------
+
+	books := newCategory(uuid.Nil, "Books")
+	comics := newCategory(books.ID, "Comics")
+	manga := newCategory(comics.ID, "Manga")
+	toys := newCategory(uuid.Nil, "Toys")
+
+	if _, err := api.Category.Create(ctx, category.NewCategory{TenantID: usr.TenantID, ParentID: books.ID, Name: "Comics"}); !errors.Is(err, category.ErrUniqueName) {
+		t.Errorf("Should NOT be able to create a category with the name of a sibling: %v.", err)
+	}
+
+	if _, err := api.Category.Create(ctx, category.NewCategory{TenantID: usr.TenantID, ParentID: uuid.New(), Name: "Orphans"}); !errors.Is(err, category.ErrParentNotFound) {
+		t.Errorf("Should NOT be able to create a category under a parent that doesn't exist: %v.", err)
+	}
+
+	var filter category.QueryFilter
+	filter.WithParentID(uuid.Nil)
+
+	top, err := api.Category.Query(ctx, filter, category.DefaultOrderBy, 1, 10)
+	if err != nil {
+		t.Fatalf("Should be able to query the top level categories: %s.", err)
+	}
+
+	if len(top) != 2 || top[0].ID != books.ID || top[1].ID != toys.ID {
+		t.Errorf("Should find the top level categories by name: %+v", top)
+	}
+
+	name := "Graphic Novels"
+	if _, err := api.Category.Update(ctx, comics, category.UpdateCategory{Name: &name}); err != nil {
+		t.Fatalf("Should be able to update category: %s.", err)
+	}
+
+	// -------------------------------------------------------------------------
+
+	if _, err := api.Category.Move(ctx, books, manga.ID); !errors.Is(err, category.ErrCycle) {
+		t.Errorf("Should NOT be able to move a category under one of its descendants: %v.", err)
+	}
+
+	if _, err := api.Category.Move(ctx, books, books.ID); !errors.Is(err, category.ErrCycle) {
+		t.Errorf("Should NOT be able to move a category under itself: %v.", err)
+	}
+
+	if _, err := api.Category.Move(ctx, manga, toys.ID); err != nil {
+		t.Fatalf("Should be able to move a category: %s.", err)
+	}
+
+	saved, err := api.Category.QueryByID(ctx, manga.ID)
+	if err != nil {
+		t.Fatalf("Should be able to retrieve category by ID: %s.", err)
+	}
+
+	if saved.ParentID != toys.ID {
+		t.Errorf("Should have moved the category: got %s want %s", saved.ParentID, toys.ID)
+	}
+
+	// -------------------------------------------------------------------------
+
+	if err := api.Category.Delete(ctx, toys); !errors.Is(err, category.ErrHasChildren) {
+		t.Errorf("Should NOT be able to delete a category with subcategories: %v.", err)
+	}
+
+	if err := api.Category.Delete(ctx, saved); err != nil {
+		t.Fatalf("Should be able to delete category: %s.", err)
+	}
+
+	if _, err := api.Category.QueryByID(ctx, manga.ID); !errors.Is(err, category.ErrNotFound) {
+		t.Errorf("Should NOT be able to retrieve deleted category: %v.", err)
+	}
+}
+
+func products(t *testing.T) {
+	test := dbtest.NewTest(t, c)
+	defer func() {
+		if r := recover(); r != nil {
+			t.Log(r)
+			t.Error(string(debug.Stack()))
+		}
+		test.Teardown()
+	}()
+
+	api := test.CoreAPIs
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	t.Log("Go seeding...")
+
+	usr, err := seed(ctx, api)
+	if err != nil {
+		t.Fatalf("Seeding error: %s", err)
+	}
+
+	// -------------------------------------------------------------------------
+
+	books, err := api.Category.Create(ctx, category.NewCategory{TenantID: usr.TenantID, Name: "Books"})
+	if err != nil {
+		t.Fatalf("Should be able to create category: %s.", err)
+	}
+
+	comics, err := api.Category.Create(ctx, category.NewCategory{TenantID: usr.TenantID, ParentID: books.ID, Name: "Comics"})
+	if err != nil {
+		t.Fatalf("Should be able to create category: %s.", err)
+	}
+
+	newProduct := func(name string) product.Product {
+		prd, err := api.Product.Create(ctx, product.NewProduct{
+			Name:     name,
+			Cost:     money.MustParse("5.00", money.USD),
+			Quantity: 1,
+			UserID:   usr.ID,
+		})
+		if err != nil {
+			t.Fatalf("Should be able to create product: %s.", err)
+		}
+		return prd
+	}
+
+	novel := newProduct("Novel")
+	comic := newProduct("Comic Book")
+	newProduct("McDonalds Toys")
+
+	if err := api.Category.Assign(ctx, novel.ID, books); err != nil {
+		t.Fatalf("Should be able to assign product: %s.", err)
+	}
+
+	if err := api.Category.Assign(ctx, comic.ID, comics); err != nil {
+		t.Fatalf("Should be able to assign product: %s.", err)
+	}
+
+	if err := api.Category.Assign(ctx, comic.ID, comics); err != nil {
+		t.Errorf("Should be able to assign a product again: %s.", err)
+	}
+
+	// -------------------------------------------------------------------------
+
+	count := func(cat category.Category) int {
+		var filter product.QueryFilter
+		filter.WithCategoryID(cat.ID)
+
+		n, err := api.Product.Count(ctx, filter)
+		if err != nil {
+			t.Fatalf("Should be able to count products by category: %s.", err)
+		}
+		return n
+	}
+
+	if n := count(books); n != 2 {
+		t.Errorf("Should find the products of the category and its subcategories: got %d", n)
+	}
+
+	if n := count(comics); n != 1 {
+		t.Errorf("Should find only the products of the subcategory: got %d", n)
+	}
+
+	cats, err := api.Category.QueryByProduct(ctx, comic.ID)
+	if err != nil {
+		t.Fatalf("Should be able to query the categories of a product: %s.", err)
+	}
+
+	if len(cats) != 1 || cats[0].ID != comics.ID {
+		t.Errorf("Should find the category the product is assigned to: %+v", cats)
+	}
+
+	if err := api.Category.Unassign(ctx, comic.ID, comics.ID); err != nil {
+		t.Fatalf("Should be able to unassign product: %s.", err)
+	}
+
+	if n := count(books); n != 1 {
+		t.Errorf("Should NOT find an unassigned product: got %d", n)
+	}
+}
+
+// =============================================================================
+
+// seed returns a seeded user to own the categories and products of the
+// tests.
+func seed(ctx context.Context, api dbtest.CoreAPIs) (user.User, error) {
+	usrs, err := api.User.Query(ctx, user.QueryFilter{}, user.DefaultOrderBy, 1, 1)
+	if err != nil {
+		return user.User{}, fmt.Errorf("seeding users: %w", err)
+	}
+	return usrs[0], nil
+}
@@ -0,0 +1,39 @@
+package category
+
+import (
+	"fmt"
+
+	"github.com/aleury/service/business/sys/validate"
+	"github.com/google/uuid"
+)
+
+// QueryFilter holds the available fields a query can be filtered on.
+type QueryFilter struct {
+	ID       *uuid.UUID `validate:"omitempty"`
+	ParentID *uuid.UUID `validate:"omitempty"`
+	Name     *string    `validate:"omitempty,min=2"`
+}
+
+// Validate checks the data in the model is considered clean.
+func (qf *QueryFilter) Validate() error {
+	if err := validate.Check(qf); err != nil {
+		return fmt.Errorf("validate: %w", err)
+	}
+	return nil
+}
+
+// WithCategoryID sets the ID field of the QueryFilter value.
+func (qf *QueryFilter) WithCategoryID(categoryID uuid.UUID) {
+	qf.ID = &categoryID
+}
+
+// WithParentID sets the ParentID field of the QueryFilter value. Use
+// uuid.Nil to select the top level categories.
+func (qf *QueryFilter) WithParentID(parentID uuid.UUID) {
+	qf.ParentID = &parentID
+}
+
+// WithName sets the Name field of the QueryFilter value.
+func (qf *QueryFilter) WithName(name string) {
+	qf.Name = &name
+}
@@ -0,0 +1,30 @@
+package category
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Category represents a node in the tree products are organized in. Top
+// level categories have no parent.
+type Category struct {
+	ID          uuid.UUID
+	TenantID    uuid.UUID
+	ParentID    uuid.UUID
+	Name        string
+	DateCreated time.Time
+	DateUpdated time.Time
+}
+
+// NewCategory contains information needed to create a new category.
+type NewCategory struct {
+	TenantID uuid.UUID
+	ParentID uuid.UUID
+	Name     string
+}
+
+// UpdateCategory contains information needed to update a category.
+type UpdateCategory struct {
+	Name *string
+}
@@ -0,0 +1,13 @@
+package category
+
+import "github.com/aleury/service/business/data/order"
+
+// DefaultOrderBy represents the default way we sort.
+var DefaultOrderBy = order.NewBy(OrderByName, order.ASC)
+
+// Set of fields that the results can be ordered by. These are the names
+// that should be used by the application layer.
+const (
+	OrderByID   = "categoryid"
+	OrderByName = "name"
+)
@@ -0,0 +1,322 @@
+// Package categorydb contains category related CRUD functionality.
+package categorydb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aleury/service/business/core/category"
+	"github.com/aleury/service/business/core/tenant"
+	"github.com/aleury/service/business/data/order"
+	database "github.com/aleury/service/business/sys/database/pgx"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// Store manages the set of APIs for category database access.
+type Store struct {
+	log *zap.SugaredLogger
+	db  *sqlx.DB
+}
+
+// NewStore constructs the api for data access.
+func NewStore(log *zap.SugaredLogger, db *sqlx.DB) *Store {
+	return &Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// WithinTran runs passed function and does commit/rollback at the end. Every
+// store call made with the context handed to the function joins the
+// transaction.
+func (s *Store) WithinTran(ctx context.Context, fn func(ctx context.Context) error) error {
+	return database.WithinTran(ctx, s.log, s.db, fn)
+}
+
+// Create inserts a new category into the database.
+func (s *Store) Create(ctx context.Context, cat category.Category) error {
+	const q = `
+	INSERT INTO categories
+		(category_id, tenant_id, parent_id, name, date_created, date_updated)
+	VALUES
+		(:category_id, :tenant_id, :parent_id, :name, :date_created, :date_updated)`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, toDBCategory(cat)); err != nil {
+		switch {
+		case errors.Is(err, database.ErrDBDuplicatedEntry):
+			return fmt.Errorf("namedexeccontext: %w", category.ErrUniqueName)
+		case errors.Is(err, database.ErrDBForeignKey):
+			return fmt.Errorf("namedexeccontext: %w", category.ErrParentNotFound)
+		}
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Update replaces a category document in the database.
+func (s *Store) Update(ctx context.Context, cat category.Category) error {
+	const q = `
+	UPDATE
+		categories
+	SET
+		"name" = :name,
+		"date_updated" = :date_updated
+	WHERE
+		category_id = :category_id`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, toDBCategory(cat)); err != nil {
+		if errors.Is(err, database.ErrDBDuplicatedEntry) {
+			return fmt.Errorf("namedexeccontext: %w", category.ErrUniqueName)
+		}
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Move changes the parent of a category. It has to run in a transaction:
+// moves within a tenant are serialized so two concurrent moves can't each
+// pass the check for cycles and together create one.
+func (s *Store) Move(ctx context.Context, cat category.Category) error {
+	lock := map[string]any{
+		"lock_key": "categories:" + cat.TenantID.String(),
+	}
+
+	const qLock = `
+	SELECT
+		pg_advisory_xact_lock(hashtext(:lock_key))`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, qLock, lock); err != nil {
+		return fmt.Errorf("lock: %w", err)
+	}
+
+	if cat.ParentID != uuid.Nil {
+		const qCycle = `
+		WITH RECURSIVE subtree AS (
+			SELECT
+				category_id
+			FROM
+				categories
+			WHERE
+				category_id = :category_id
+			UNION
+			SELECT
+				c.category_id
+			FROM
+				categories AS c
+			JOIN
+				subtree AS s ON c.parent_id = s.category_id
+		)
+		SELECT
+			EXISTS (SELECT 1 FROM subtree WHERE category_id = :parent_id) AS cycle`
+
+		var result struct {
+			Cycle bool `db:"cycle"`
+		}
+		if err := database.NamedQueryStruct(ctx, s.log, s.db, qCycle, toDBCategory(cat), &result); err != nil {
+			return fmt.Errorf("namedquerystruct: %w", err)
+		}
+		if result.Cycle {
+			return category.ErrCycle
+		}
+	}
+
+	const q = `
+	UPDATE
+		categories
+	SET
+		"parent_id" = :parent_id,
+		"date_updated" = :date_updated
+	WHERE
+		category_id = :category_id`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, toDBCategory(cat)); err != nil {
+		switch {
+		case errors.Is(err, database.ErrDBDuplicatedEntry):
+			return fmt.Errorf("namedexeccontext: %w", category.ErrUniqueName)
+		case errors.Is(err, database.ErrDBForeignKey):
+			return fmt.Errorf("namedexeccontext: %w", category.ErrParentNotFound)
+		}
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes a category from the database.
+func (s *Store) Delete(ctx context.Context, cat category.Category) error {
+	const q = `
+	DELETE FROM
+		categories
+	WHERE
+		category_id = :category_id`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, toDBCategory(cat)); err != nil {
+		if errors.Is(err, database.ErrDBForeignKey) {
+			return fmt.Errorf("namedexeccontext: %w", category.ErrHasChildren)
+		}
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Query retrieves a list of existing categories from the database.
+func (s *Store) Query(ctx context.Context, filter category.QueryFilter, orderBy order.By, pageNumber int, rowsPerPage int) ([]category.Category, error) {
+	data := map[string]any{
+		"offset":        (pageNumber - 1) * rowsPerPage,
+		"rows_per_page": rowsPerPage,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		categories`
+
+	buf := bytes.NewBufferString(q)
+	s.applyFilter(ctx, filter, data, buf)
+
+	orderByClause, err := orderByClause(orderBy)
+	if err != nil {
+		return nil, err
+	}
+
+	buf.WriteString(orderByClause)
+	buf.WriteString(" OFFSET :offset LIMIT :rows_per_page")
+
+	var dbCats []dbCategory
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, buf.String(), data, &dbCats); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toCoreCategorySlice(dbCats), nil
+}
+
+// Count returns the total number of categories in the DB.
+func (s *Store) Count(ctx context.Context, filter category.QueryFilter) (int, error) {
+	data := map[string]any{}
+
+	const q = `
+	SELECT
+		COUNT(*)
+	FROM
+		categories`
+
+	buf := bytes.NewBufferString(q)
+	s.applyFilter(ctx, filter, data, buf)
+
+	var result struct {
+		Count int `db:"count"`
+	}
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, buf.String(), data, &result); err != nil {
+		return 0, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return result.Count, nil
+}
+
+// QueryByID gets the specified category from the database.
+func (s *Store) QueryByID(ctx context.Context, categoryID uuid.UUID) (category.Category, error) {
+	data := map[string]any{
+		"category_id": categoryID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		categories
+	WHERE
+		category_id = :category_id`
+
+	var dbCat dbCategory
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q+tenant.Scope(ctx, data, "tenant_id"), data, &dbCat); err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return category.Category{}, fmt.Errorf("namedquerystruct: %w", category.ErrNotFound)
+		}
+		return category.Category{}, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return toCoreCategory(dbCat), nil
+}
+
+// Assign puts a product in a category.
+func (s *Store) Assign(ctx context.Context, productID uuid.UUID, cat category.Category, now time.Time) error {
+	data := map[string]any{
+		"product_id":   productID,
+		"category_id":  cat.ID,
+		"tenant_id":    cat.TenantID,
+		"date_created": now.UTC(),
+	}
+
+	const q = `
+	INSERT INTO product_categories
+		(product_id, category_id, tenant_id, date_created)
+	VALUES
+		(:product_id, :category_id, :tenant_id, :date_created)
+	ON CONFLICT (product_id, category_id) DO NOTHING`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		if errors.Is(err, database.ErrDBForeignKey) {
+			return fmt.Errorf("namedexeccontext: %w", category.ErrNotFound)
+		}
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Unassign takes a product out of a category.
+func (s *Store) Unassign(ctx context.Context, productID uuid.UUID, categoryID uuid.UUID) error {
+	data := map[string]any{
+		"product_id":  productID,
+		"category_id": categoryID,
+	}
+
+	const q = `
+	DELETE FROM
+		product_categories
+	WHERE
+		product_id = :product_id AND category_id = :category_id`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// QueryByProduct retrieves the categories a product is assigned to.
+func (s *Store) QueryByProduct(ctx context.Context, productID uuid.UUID) ([]category.Category, error) {
+	data := map[string]any{
+		"product_id": productID,
+	}
+
+	const q = `
+	SELECT
+		c.*
+	FROM
+		categories AS c
+	JOIN
+		product_categories AS pc USING (category_id, tenant_id)
+	WHERE
+		pc.product_id = :product_id`
+
+	buf := bytes.NewBufferString(q)
+	buf.WriteString(tenant.Scope(ctx, data, "tenant_id"))
+	buf.WriteString(" ORDER BY c.name")
+
+	var dbCats []dbCategory
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, buf.String(), data, &dbCats); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toCoreCategorySlice(dbCats), nil
+}
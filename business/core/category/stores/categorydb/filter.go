@@ -0,0 +1,46 @@
+package categorydb
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aleury/service/business/core/category"
+	"github.com/aleury/service/business/core/tenant"
+	"github.com/google/uuid"
+)
+
+func (s *Store) applyFilter(ctx context.Context, filter category.QueryFilter, data map[string]any, buf *bytes.Buffer) {
+	var wc []string
+
+	if tenantID := tenant.GetTenantID(ctx); tenantID != uuid.Nil {
+		data["tenant_id"] = tenantID
+		wc = append(wc, "tenant_id = :tenant_id")
+	}
+
+	if filter.ID != nil {
+		data["category_id"] = *filter.ID
+		wc = append(wc, "category_id = :category_id")
+	}
+
+	if filter.ParentID != nil {
+		switch *filter.ParentID {
+		case uuid.Nil:
+			wc = append(wc, "parent_id IS NULL")
+		default:
+			data["parent_id"] = *filter.ParentID
+			wc = append(wc, "parent_id = :parent_id")
+		}
+	}
+
+	if filter.Name != nil {
+		data["name"] = fmt.Sprintf("%%%s%%", *filter.Name)
+		wc = append(wc, "name ILIKE :name")
+	}
+
+	if len(wc) > 0 {
+		buf.WriteString(" WHERE ")
+		buf.WriteString(strings.Join(wc, " AND "))
+	}
+}
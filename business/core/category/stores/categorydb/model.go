@@ -0,0 +1,52 @@
+package categorydb
+
+import (
+	"time"
+
+	"github.com/aleury/service/business/core/category"
+	"github.com/google/uuid"
+)
+
+// dbCategory represents the structure we need for moving data
+// between the app and the database.
+type dbCategory struct {
+	ID          uuid.UUID     `db:"category_id"`
+	TenantID    uuid.UUID     `db:"tenant_id"`
+	ParentID    uuid.NullUUID `db:"parent_id"`
+	Name        string        `db:"name"`
+	DateCreated time.Time     `db:"date_created"`
+	DateUpdated time.Time     `db:"date_updated"`
+}
+
+func toDBCategory(cat category.Category) dbCategory {
+	return dbCategory{
+		ID:       cat.ID,
+		TenantID: cat.TenantID,
+		ParentID: uuid.NullUUID{
+			UUID:  cat.ParentID,
+			Valid: cat.ParentID != uuid.Nil,
+		},
+		Name:        cat.Name,
+		DateCreated: cat.DateCreated.UTC(),
+		DateUpdated: cat.DateUpdated.UTC(),
+	}
+}
+
+func toCoreCategory(dbCat dbCategory) category.Category {
+	return category.Category{
+		ID:          dbCat.ID,
+		TenantID:    dbCat.TenantID,
+		ParentID:    dbCat.ParentID.UUID,
+		Name:        dbCat.Name,
+		DateCreated: dbCat.DateCreated.In(time.Local),
+		DateUpdated: dbCat.DateUpdated.In(time.Local),
+	}
+}
+
+func toCoreCategorySlice(dbCategories []dbCategory) []category.Category {
+	cats := make([]category.Category, len(dbCategories))
+	for i, dbCat := range dbCategories {
+		cats[i] = toCoreCategory(dbCat)
+	}
+	return cats
+}
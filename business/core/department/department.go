@@ -0,0 +1,178 @@
+// Package department provides the core business API for departments, the
+// units of an organization that users belong to.
+package department
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aleury/service/business/core/audit"
+	"github.com/aleury/service/business/data/order"
+	"github.com/google/uuid"
+)
+
+// Set of error variables for CRUD operations.
+var (
+	ErrNotFound   = errors.New("department not found")
+	ErrUniqueName = errors.New("name is not unique")
+	ErrInUse      = errors.New("department still has users")
+)
+
+// Storer interface declares the behavior this package needs to persist and
+// retrieve data.
+type Storer interface {
+	WithinTran(ctx context.Context, fn func(ctx context.Context) error) error
+	Create(ctx context.Context, dpt Department) error
+	Update(ctx context.Context, dpt Department) error
+	Delete(ctx context.Context, dpt Department) error
+	Query(ctx context.Context, filter QueryFilter, orderBy order.By, pageNumber int, rowsPerPage int) ([]Department, error)
+	Count(ctx context.Context, filter QueryFilter) (int, error)
+	QueryByID(ctx context.Context, departmentID uuid.UUID) (Department, error)
+}
+
+// Core manages the set of APIs for department access.
+type Core struct {
+	storer    Storer
+	auditCore *audit.Core
+}
+
+// NewCore constructs a core for department api access.
+func NewCore(storer Storer, auditCore *audit.Core) *Core {
+	return &Core{
+		storer:    storer,
+		auditCore: auditCore,
+	}
+}
+
+// Create inserts a new department into the database.
+func (c *Core) Create(ctx context.Context, nd NewDepartment) (Department, error) {
+	now := time.Now()
+
+	dpt := Department{
+		ID:          uuid.New(),
+		TenantID:    nd.TenantID,
+		Name:        nd.Name,
+		DateCreated: now,
+		DateUpdated: now,
+	}
+
+	tran := func(ctx context.Context) error {
+		if err := c.storer.Create(ctx, dpt); err != nil {
+			return fmt.Errorf("create: %w", err)
+		}
+		return c.record(ctx, audit.ActionCreate, Department{}, dpt)
+	}
+
+	if err := c.storer.WithinTran(ctx, tran); err != nil {
+		return Department{}, err
+	}
+
+	return dpt, nil
+}
+
+// Update replaces a department document in the database.
+func (c *Core) Update(ctx context.Context, dpt Department, ud UpdateDepartment) (Department, error) {
+	before := dpt
+
+	if ud.Name != nil {
+		dpt.Name = *ud.Name
+	}
+	dpt.DateUpdated = time.Now()
+
+	tran := func(ctx context.Context) error {
+		if err := c.storer.Update(ctx, dpt); err != nil {
+			return fmt.Errorf("update: %w", err)
+		}
+		return c.record(ctx, audit.ActionUpdate, before, dpt)
+	}
+
+	if err := c.storer.WithinTran(ctx, tran); err != nil {
+		return Department{}, err
+	}
+
+	return dpt, nil
+}
+
+// Delete removes a department from the database. A department can only be
+// deleted once no users belong to it.
+func (c *Core) Delete(ctx context.Context, dpt Department) error {
+	tran := func(ctx context.Context) error {
+		if err := c.storer.Delete(ctx, dpt); err != nil {
+			return fmt.Errorf("delete: %w", err)
+		}
+
+		ne := audit.NewEntry{
+			Action:     audit.ActionDelete,
+			EntityType: audit.EntityDepartment,
+			EntityID:   dpt.ID,
+			Before:     auditFields(dpt),
+		}
+		if _, err := c.auditCore.Record(ctx, ne); err != nil {
+			return fmt.Errorf("audit: %w", err)
+		}
+
+		return nil
+	}
+
+	return c.storer.WithinTran(ctx, tran)
+}
+
+// Query retrieves a list of existing departments from the database.
+func (c *Core) Query(ctx context.Context, filter QueryFilter, orderBy order.By, pageNumber int, rowsPerPage int) ([]Department, error) {
+	dpts, err := c.storer.Query(ctx, filter, orderBy, pageNumber, rowsPerPage)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+	return dpts, nil
+}
+
+// Count returns the total number of departments in the store.
+func (c *Core) Count(ctx context.Context, filter QueryFilter) (int, error) {
+	count, err := c.storer.Count(ctx, filter)
+	if err != nil {
+		return 0, fmt.Errorf("count: %w", err)
+	}
+	return count, nil
+}
+
+// QueryByID gets the specified department from the database.
+func (c *Core) QueryByID(ctx context.Context, departmentID uuid.UUID) (Department, error) {
+	dpt, err := c.storer.QueryByID(ctx, departmentID)
+	if err != nil {
+		return Department{}, fmt.Errorf("query: departmentID[%s]: %w", departmentID, err)
+	}
+	return dpt, nil
+}
+
+// =============================================================================
+
+// record writes an audit entry describing the change between the two
+// versions of the department. A zero value before represents a newly created
+// department.
+func (c *Core) record(ctx context.Context, action string, before Department, after Department) error {
+	ne := audit.NewEntry{
+		Action:     action,
+		EntityType: audit.EntityDepartment,
+		EntityID:   after.ID,
+		After:      auditFields(after),
+	}
+	if before.ID != uuid.Nil {
+		ne.Before = auditFields(before)
+	}
+
+	if _, err := c.auditCore.Record(ctx, ne); err != nil {
+		return fmt.Errorf("audit: %w", err)
+	}
+
+	return nil
+}
+
+// auditFields returns the set of department fields that are tracked by the
+// audit log.
+func auditFields(dpt Department) map[string]any {
+	return map[string]any{
+		"name": dpt.Name,
+	}
+}
@@ -0,0 +1,212 @@
+package department_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"testing"
+	"time"
+
+	"github.com/aleury/service/business/core/department"
+	"github.com/aleury/service/business/core/user"
+	"github.com/aleury/service/business/data/dbtest"
+	"github.com/aleury/service/foundation/docker"
+	"github.com/google/go-cmp/cmp"
+)
+
+var c *docker.Container
+
+func TestMain(m *testing.M) {
+	var err error
+	c, err = dbtest.StartDB()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer dbtest.StopDB(c)
+
+	m.Run()
+}
+
+func Test_Department(t *testing.T) {
+	t.Run("crud", crud)
+	t.Run("users", users)
+}
+
+// =============================================================================
+
+func crud(t *testing.T) {
+	test := dbtest.NewTest(t, c)
+	defer func() {
+		if r := recover(); r != nil {
+			t.Log(r)
+			t.Error(string(debug.Stack()))
+		}
+		test.Teardown()
+	}()
+
+	api := test.CoreAPIs
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	t.Log("Go seeding...")
+
+	usrs, err := api.User.Query(ctx, user.QueryFilter{}, user.DefaultOrderBy, 1, 1)
+	if err != nil || len(usrs) != 1 {
+		t.Fatalf("Seeding error: %v", err)
+	}
+
+	// -------------------------------------------------------------------------
+
+	nd := department.NewDepartment{
+		TenantID: usrs[0].TenantID,
+		Name:     "development",
+	}
+
+	dpt, err := api.Department.Create(ctx, nd)
+	if err != nil {
+		t.Fatalf("Should be able to create department: %s.", err)
+	}
+
+	saved, err := api.Department.QueryByID(ctx, dpt.ID)
+	if err != nil {
+		t.Fatalf("Should be able to retrieve department by ID: %s.", err)
+	}
+
+	dpt.DateCreated = time.Time{}
+	dpt.DateUpdated = time.Time{}
+	saved.DateCreated = time.Time{}
+	saved.DateUpdated = time.Time{}
+
+	if diff := cmp.Diff(dpt, saved); diff != "" {
+		t.Fatalf("Should get back the same department. diff:\n%s", diff)
+	}
+
+	if _, err := api.Department.Create(ctx, nd); !errors.Is(err, department.ErrUniqueName) {
+		t.Errorf("Should NOT be able to create a department with the same name: %v.", err)
+	}
+
+	// -------------------------------------------------------------------------
+
+	upd := department.UpdateDepartment{
+		Name: dbtest.StringPointer("engineering"),
+	}
+
+	if _, err := api.Department.Update(ctx, saved, upd); err != nil {
+		t.Fatalf("Should be able to update department: %s.", err)
+	}
+
+	var filter department.QueryFilter
+	filter.WithName(*upd.Name)
+
+	dpts, err := api.Department.Query(ctx, filter, department.DefaultOrderBy, 1, 10)
+	if err != nil {
+		t.Fatalf("Should be able to query departments by name: %s.", err)
+	}
+
+	if len(dpts) != 1 || dpts[0].ID != dpt.ID {
+		t.Fatalf("Should find the department by its new name: %+v", dpts)
+	}
+
+	count, err := api.Department.Count(ctx, filter)
+	if err != nil {
+		t.Fatalf("Should be able to count departments by name: %s.", err)
+	}
+
+	if count != 1 {
+		t.Errorf("Should count one department with the new name: got %d", count)
+	}
+
+	// -------------------------------------------------------------------------
+
+	if err := api.Department.Delete(ctx, dpts[0]); err != nil {
+		t.Fatalf("Should be able to delete department: %s.", err)
+	}
+
+	if _, err := api.Department.QueryByID(ctx, dpt.ID); !errors.Is(err, department.ErrNotFound) {
+		t.Fatalf("Should NOT be able to retrieve deleted department: %s.", err)
+	}
+}
+
+func users(t *testing.T) {
+	test := dbtest.NewTest(t, c)
+	defer func() {
+		if r := recover(); r != nil {
+			t.Log(r)
+			t.Error(string(debug.Stack()))
+		}
+		test.Teardown()
+	}()
+
+	api := test.CoreAPIs
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	t.Log("Go seeding...")
+
+	usrs, err := api.User.Query(ctx, user.QueryFilter{}, user.DefaultOrderBy, 1, 1)
+	if err != nil || len(usrs) != 1 {
+		t.Fatalf("Seeding error: %v", err)
+	}
+
+	// -------------------------------------------------------------------------
+
+	dpt, err := api.Department.Create(ctx, department.NewDepartment{
+		TenantID: usrs[0].TenantID,
+		Name:     "development",
+	})
+	if err != nil {
+		t.Fatalf("Should be able to create department: %s.", err)
+	}
+
+	upd := user.UpdateUser{
+		DepartmentID: &dpt.ID,
+	}
+
+	usr, err := api.User.Update(ctx, usrs[0], upd)
+	if err != nil {
+		t.Fatalf("Should be able to add the user to the department: %s.", err)
+	}
+
+	saved, err := api.User.QueryByID(ctx, usr.ID)
+	if err != nil {
+		t.Fatalf("Should be able to retrieve user by ID: %s.", err)
+	}
+
+	if saved.DepartmentID != dpt.ID {
+		t.Logf("got:  %v", saved.DepartmentID)
+		t.Logf("want: %v", dpt.ID)
+		t.Error("Should be able to see updates to DepartmentID")
+	}
+
+	var filter user.QueryFilter
+	filter.WithDepartmentID(dpt.ID)
+
+	count, err := api.User.Count(ctx, filter)
+	if err != nil {
+		t.Fatalf("Should be able to count users by department: %s.", err)
+	}
+
+	if count != 1 {
+		t.Errorf("Should find one user in the department: got %d", count)
+	}
+
+	filter.WithRole(saved.Roles[0])
+	filter.WithEnabled(true)
+
+	count, err = api.User.Count(ctx, filter)
+	if err != nil {
+		t.Fatalf("Should be able to count users by role: %s.", err)
+	}
+
+	if count != 1 {
+		t.Errorf("Should find one enabled user with the role in the department: got %d", count)
+	}
+
+	if err := api.Department.Delete(ctx, dpt); !errors.Is(err, department.ErrInUse) {
+		t.Errorf("Should NOT be able to delete a department with users: %s.", err)
+	}
+}
@@ -0,0 +1,27 @@
+package department
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Department represents a department users can belong to.
+type Department struct {
+	ID          uuid.UUID
+	TenantID    uuid.UUID
+	Name        string
+	DateCreated time.Time
+	DateUpdated time.Time
+}
+
+// NewDepartment contains information needed to create a new department.
+type NewDepartment struct {
+	TenantID uuid.UUID
+	Name     string
+}
+
+// UpdateDepartment contains information needed to update a department.
+type UpdateDepartment struct {
+	Name *string
+}
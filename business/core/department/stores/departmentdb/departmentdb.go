@@ -0,0 +1,175 @@
+// Package departmentdb contains department related CRUD functionality.
+package departmentdb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aleury/service/business/core/department"
+	"github.com/aleury/service/business/core/tenant"
+	"github.com/aleury/service/business/data/order"
+	database "github.com/aleury/service/business/sys/database/pgx"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// Store manages the set of APIs for department database access.
+type Store struct {
+	log *zap.SugaredLogger
+	db  *sqlx.DB
+}
+
+// NewStore constructs the api for data access.
+func NewStore(log *zap.SugaredLogger, db *sqlx.DB) *Store {
+	return &Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// WithinTran runs passed function and does commit/rollback at the end. Every
+// store call made with the context handed to the function joins the
+// transaction.
+func (s *Store) WithinTran(ctx context.Context, fn func(ctx context.Context) error) error {
+	return database.WithinTran(ctx, s.log, s.db, fn)
+}
+
+// Create inserts a new department into the database.
+func (s *Store) Create(ctx context.Context, dpt department.Department) error {
+	const q = `
+	INSERT INTO departments
+		(department_id, tenant_id, name, date_created, date_updated)
+	VALUES
+		(:department_id, :tenant_id, :name, :date_created, :date_updated)`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, toDBDepartment(dpt)); err != nil {
+		if errors.Is(err, database.ErrDBDuplicatedEntry) {
+			return fmt.Errorf("namedexeccontext: %w", department.ErrUniqueName)
+		}
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Update replaces a department document in the database.
+func (s *Store) Update(ctx context.Context, dpt department.Department) error {
+	const q = `
+	UPDATE
+		departments
+	SET
+		"name" = :name,
+		"date_updated" = :date_updated
+	WHERE
+		department_id = :department_id`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, toDBDepartment(dpt)); err != nil {
+		if errors.Is(err, database.ErrDBDuplicatedEntry) {
+			return fmt.Errorf("namedexeccontext: %w", department.ErrUniqueName)
+		}
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes a department from the database.
+func (s *Store) Delete(ctx context.Context, dpt department.Department) error {
+	const q = `
+	DELETE FROM
+		departments
+	WHERE
+		department_id = :department_id`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, toDBDepartment(dpt)); err != nil {
+		if errors.Is(err, database.ErrDBForeignKey) {
+			return fmt.Errorf("namedexeccontext: %w", department.ErrInUse)
+		}
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Query retrieves a list of existing departments from the database.
+func (s *Store) Query(ctx context.Context, filter department.QueryFilter, orderBy order.By, pageNumber int, rowsPerPage int) ([]department.Department, error) {
+	data := map[string]any{
+		"offset":        (pageNumber - 1) * rowsPerPage,
+		"rows_per_page": rowsPerPage,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		departments`
+
+	buf := bytes.NewBufferString(q)
+	s.applyFilter(ctx, filter, data, buf)
+
+	orderByClause, err := orderByClause(orderBy)
+	if err != nil {
+		return nil, err
+	}
+
+	buf.WriteString(orderByClause)
+	buf.WriteString(" OFFSET :offset LIMIT :rows_per_page")
+
+	var dbDpts []dbDepartment
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, buf.String(), data, &dbDpts); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toCoreDepartmentSlice(dbDpts), nil
+}
+
+// Count returns the total number of departments in the DB.
+func (s *Store) Count(ctx context.Context, filter department.QueryFilter) (int, error) {
+	data := map[string]any{}
+
+	const q = `
+	SELECT
+		COUNT(*)
+	FROM
+		departments`
+
+	buf := bytes.NewBufferString(q)
+	s.applyFilter(ctx, filter, data, buf)
+
+	var result struct {
+		Count int `db:"count"`
+	}
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, buf.String(), data, &result); err != nil {
+		return 0, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return result.Count, nil
+}
+
+// QueryByID gets the specified department from the database.
+func (s *Store) QueryByID(ctx context.Context, departmentID uuid.UUID) (department.Department, error) {
+	data := map[string]any{
+		"department_id": departmentID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		departments
+	WHERE
+		department_id = :department_id`
+
+	var dbDpt dbDepartment
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q+tenant.Scope(ctx, data, "tenant_id"), data, &dbDpt); err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return department.Department{}, fmt.Errorf("namedquerystruct: %w", department.ErrNotFound)
+		}
+		return department.Department{}, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return toCoreDepartment(dbDpt), nil
+}
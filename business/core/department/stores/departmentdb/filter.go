@@ -0,0 +1,36 @@
+package departmentdb
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aleury/service/business/core/department"
+	"github.com/aleury/service/business/core/tenant"
+	"github.com/google/uuid"
+)
+
+func (s *Store) applyFilter(ctx context.Context, filter department.QueryFilter, data map[string]any, buf *bytes.Buffer) {
+	var wc []string
+
+	if tenantID := tenant.GetTenantID(ctx); tenantID != uuid.Nil {
+		data["tenant_id"] = tenantID
+		wc = append(wc, "tenant_id = :tenant_id")
+	}
+
+	if filter.ID != nil {
+		data["department_id"] = *filter.ID
+		wc = append(wc, "department_id = :department_id")
+	}
+
+	if filter.Name != nil {
+		data["name"] = fmt.Sprintf("%%%s%%", *filter.Name)
+		wc = append(wc, "name ILIKE :name")
+	}
+
+	if len(wc) > 0 {
+		buf.WriteString(" WHERE ")
+		buf.WriteString(strings.Join(wc, " AND "))
+	}
+}
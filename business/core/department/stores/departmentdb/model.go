@@ -0,0 +1,46 @@
+package departmentdb
+
+import (
+	"time"
+
+	"github.com/aleury/service/business/core/department"
+	"github.com/google/uuid"
+)
+
+// dbDepartment represents the structure we need for moving data
+// between the app and the database.
+type dbDepartment struct {
+	ID          uuid.UUID `db:"department_id"`
+	TenantID    uuid.UUID `db:"tenant_id"`
+	Name        string    `db:"name"`
+	DateCreated time.Time `db:"date_created"`
+	DateUpdated time.Time `db:"date_updated"`
+}
+
+func toDBDepartment(dpt department.Department) dbDepartment {
+	return dbDepartment{
+		ID:          dpt.ID,
+		TenantID:    dpt.TenantID,
+		Name:        dpt.Name,
+		DateCreated: dpt.DateCreated.UTC(),
+		DateUpdated: dpt.DateUpdated.UTC(),
+	}
+}
+
+func toCoreDepartment(dbDpt dbDepartment) department.Department {
+	return department.Department{
+		ID:          dbDpt.ID,
+		TenantID:    dbDpt.TenantID,
+		Name:        dbDpt.Name,
+		DateCreated: dbDpt.DateCreated.In(time.Local),
+		DateUpdated: dbDpt.DateUpdated.In(time.Local),
+	}
+}
+
+func toCoreDepartmentSlice(dbDepartments []dbDepartment) []department.Department {
+	dpts := make([]department.Department, len(dbDepartments))
+	for i, dbDpt := range dbDepartments {
+		dpts[i] = toCoreDepartment(dbDpt)
+	}
+	return dpts
+}
@@ -0,0 +1,219 @@
+// Package exchange provides the core business API for the exchange rates
+// used to convert amounts between currencies.
+package exchange
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aleury/service/business/core/tenant"
+	"github.com/aleury/service/business/data/money"
+)
+
+// Set of error variables for CRUD operations.
+var (
+	ErrNotFound     = errors.New("exchange rate not found")
+	ErrSameCurrency = errors.New("base and quote currency must differ")
+	ErrInvalidCSV   = errors.New("invalid csv")
+)
+
+// Storer interface declares the behavior this package needs to persist and
+// retrieve data.
+type Storer interface {
+	WithinTran(ctx context.Context, fn func(ctx context.Context) error) error
+	Upsert(ctx context.Context, rate Rate) error
+	Delete(ctx context.Context, base money.Currency, quote money.Currency) error
+	Query(ctx context.Context) ([]Rate, error)
+	QueryByCurrencies(ctx context.Context, base money.Currency, quote money.Currency) (Rate, error)
+}
+
+// Core manages the set of APIs for exchange rate access.
+type Core struct {
+	storer Storer
+}
+
+// NewCore constructs a core for exchange rate api access.
+func NewCore(storer Storer) *Core {
+	return &Core{
+		storer: storer,
+	}
+}
+
+// Set records the rates for the tenant of the request, replacing any rates
+// already recorded for the same currencies. Either all of the rates are
+// recorded or none are. When a pair of currencies is listed more than once
+// the last rate wins.
+func (c *Core) Set(ctx context.Context, nrs []NewRate) ([]Rate, error) {
+	now := time.Now()
+
+	rates := make([]Rate, len(nrs))
+	for i, nr := range nrs {
+		if nr.Base.Equal(nr.Quote) {
+			return nil, fmt.Errorf("rate[%d]: %s: %w", i, nr.Base.Code(), ErrSameCurrency)
+		}
+
+		rates[i] = Rate{
+			TenantID:    tenant.GetTenantID(ctx),
+			Base:        nr.Base,
+			Quote:       nr.Quote,
+			Rate:        nr.Rate,
+			DateUpdated: now,
+		}
+	}
+
+	// Rates are written in currency order so concurrent imports lock the rows
+	// in the same order and can't deadlock.
+	sort.SliceStable(rates, func(i, j int) bool {
+		if rates[i].Base.Code() != rates[j].Base.Code() {
+			return rates[i].Base.Code() < rates[j].Base.Code()
+		}
+		return rates[i].Quote.Code() < rates[j].Quote.Code()
+	})
+
+	tran := func(ctx context.Context) error {
+		for _, rate := range rates {
+			if err := c.storer.Upsert(ctx, rate); err != nil {
+				return fmt.Errorf("upsert: base[%s] quote[%s]: %w", rate.Base.Code(), rate.Quote.Code(), err)
+			}
+		}
+		return nil
+	}
+
+	if err := c.storer.WithinTran(ctx, tran); err != nil {
+		return nil, err
+	}
+
+	return rates, nil
+}
+
+// Delete removes the rate between the currencies.
+func (c *Core) Delete(ctx context.Context, base money.Currency, quote money.Currency) error {
+	if err := c.storer.Delete(ctx, base, quote); err != nil {
+		return fmt.Errorf("delete: %w", err)
+	}
+	return nil
+}
+
+// Query retrieves the rates of the tenant of the request ordered by
+// currency.
+func (c *Core) Query(ctx context.Context) ([]Rate, error) {
+	rates, err := c.storer.Query(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+	return rates, nil
+}
+
+// QueryRate gets the rate from the base to the quote currency. A currency
+// converts to itself at the identity rate.
+func (c *Core) QueryRate(ctx context.Context, base money.Currency, quote money.Currency) (Rate, error) {
+	if base.Equal(quote) {
+		return Rate{
+			Base:  base,
+			Quote: quote,
+			Rate:  money.One,
+		}, nil
+	}
+
+	rate, err := c.storer.QueryByCurrencies(ctx, base, quote)
+	if err != nil {
+		return Rate{}, fmt.Errorf("query: base[%s] quote[%s]: %w", base.Code(), quote.Code(), err)
+	}
+
+	return rate, nil
+}
+
+// Convert converts the amount into the currency at the recorded rate.
+func (c *Core) Convert(ctx context.Context, amount money.Money, to money.Currency) (Conversion, error) {
+	rate, err := c.QueryRate(ctx, amount.Currency(), to)
+	if err != nil {
+		return Conversion{}, err
+	}
+
+	converted, err := amount.Convert(to, rate.Rate)
+	if err != nil {
+		return Conversion{}, fmt.Errorf("convert: %w", err)
+	}
+
+	cnv := Conversion{
+		Amount: converted,
+		Rate:   rate,
+	}
+
+	return cnv, nil
+}
+
+// =============================================================================
+
+// ParseCSV reads rates from CSV records of the form base,quote,rate such as
+// "USD,EUR,0.92". A first record of column names is skipped. Errors name the
+// line of the record that couldn't be read.
+func ParseCSV(r io.Reader) ([]NewRate, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = 3
+	cr.TrimLeadingSpace = true
+
+	var nrs []NewRate
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				return nil, fmt.Errorf("%w: %s", ErrInvalidCSV, err)
+			}
+			return nil, fmt.Errorf("read: %w", err)
+		}
+
+		line, _ := cr.FieldPos(0)
+		if line == 1 && strings.EqualFold(record[0], "base") {
+			continue
+		}
+
+		nr, err := parseRecord(record)
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %s", ErrInvalidCSV, line, err)
+		}
+
+		nrs = append(nrs, nr)
+	}
+
+	if len(nrs) == 0 {
+		return nil, fmt.Errorf("%w: no rates", ErrInvalidCSV)
+	}
+
+	return nrs, nil
+}
+
+func parseRecord(record []string) (NewRate, error) {
+	base, err := money.ParseCurrency(strings.ToUpper(strings.TrimSpace(record[0])))
+	if err != nil {
+		return NewRate{}, fmt.Errorf("base: %w", err)
+	}
+
+	quote, err := money.ParseCurrency(strings.ToUpper(strings.TrimSpace(record[1])))
+	if err != nil {
+		return NewRate{}, fmt.Errorf("quote: %w", err)
+	}
+
+	rate, err := money.ParseRate(record[2])
+	if err != nil {
+		return NewRate{}, fmt.Errorf("rate: %w", err)
+	}
+
+	nr := NewRate{
+		Base:  base,
+		Quote: quote,
+		Rate:  rate,
+	}
+
+	return nr, nil
+}
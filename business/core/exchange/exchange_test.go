@@ -0,0 +1,178 @@
+package exchange_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aleury/service/business/core/exchange"
+	"github.com/aleury/service/business/core/tenant"
+	"github.com/aleury/service/business/core/user"
+	"github.com/aleury/service/business/data/dbtest"
+	"github.com/aleury/service/business/data/money"
+	"github.com/aleury/service/foundation/docker"
+)
+
+var c *docker.Container
+
+func TestMain(m *testing.M) {
+	var err error
+	c, err = dbtest.StartDB()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer dbtest.StopDB(c)
+
+	m.Run()
+}
+
+func Test_Exchange(t *testing.T) {
+	t.Run("csv", csv)
+	t.Run("crud", crud)
+}
+
+// =============================================================================
+
+func csv(t *testing.T) {
+	nrs, err := exchange.ParseCSV(strings.NewReader("base,quote,rate\nUSD,EUR,0.92\n gbp , JPY , 190.5\n"))
+	if err != nil {
+		t.Fatalf("Should be able to parse the rates: %s.", err)
+	}
+
+	want := []exchange.NewRate{
+		{Base: money.USD, Quote: money.EUR, Rate: money.MustParseRate("0.92")},
+		{Base: money.GBP, Quote: money.JPY, Rate: money.MustParseRate("190.5")},
+	}
+
+	if len(nrs) != len(want) {
+		t.Fatalf("Should get back every rate: got %d want %d", len(nrs), len(want))
+	}
+
+	for i, nr := range nrs {
+		if !nr.Base.Equal(want[i].Base) || !nr.Quote.Equal(want[i].Quote) || !nr.Rate.Equal(want[i].Rate) {
+			t.Errorf("Should get back the same rate %d: got %+v want %+v", i, nr, want[i])
+		}
+	}
+
+	for _, data := range []string{
+		"",
+		"base,quote,rate\n",
+		"USD,EUR\n",
+		"USD,XXX,0.92\n",
+		"USD,EUR,abc\n",
+	} {
+		if _, err := exchange.ParseCSV(strings.NewReader(data)); !errors.Is(err, exchange.ErrInvalidCSV) {
+			t.Errorf("Should NOT be able to parse %q: %v.", data, err)
+		}
+	}
+}
+
+func crud(t *testing.T) {
+	test := dbtest.NewTest(t, c)
+	defer func() {
+		if r := recover(); r != nil {
+			t.Log(r)
+			t.Error(string(debug.Stack()))
+		}
+		test.Teardown()
+	}()
+
+	api := test.CoreAPIs
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	t.Log("Go seeding...")
+
+	usr, err := seed(ctx, api)
+	if err != nil {
+		t.Fatalf("Seeding error: %s", err)
+	}
+
+	ctx = tenant.SetTenantID(ctx, usr.TenantID)
+
+	// -------------------------------------------------------------------------
+
+	nrs := []exchange.NewRate{
+		{Base: money.USD, Quote: money.EUR, Rate: money.MustParseRate("0.90")},
+		{Base: money.USD, Quote: money.JPY, Rate: money.MustParseRate("150")},
+		{Base: money.USD, Quote: money.EUR, Rate: money.MustParseRate("0.92")},
+	}
+
+	if _, err := api.Exchange.Set(ctx, nrs); err != nil {
+		t.Fatalf("Should be able to set rates: %s.", err)
+	}
+
+	rates, err := api.Exchange.Query(ctx)
+	if err != nil {
+		t.Fatalf("Should be able to query rates: %s.", err)
+	}
+
+	if len(rates) != 2 {
+		t.Fatalf("Should record one rate per pair of currencies: got %d", len(rates))
+	}
+
+	rate, err := api.Exchange.QueryRate(ctx, money.USD, money.EUR)
+	if err != nil {
+		t.Fatalf("Should be able to retrieve the rate: %s.", err)
+	}
+
+	if want := money.MustParseRate("0.92"); !rate.Rate.Equal(want) || rate.TenantID != usr.TenantID {
+		t.Errorf("Should keep the last rate listed for the currencies: got %+v want %s", rate, want)
+	}
+
+	// -------------------------------------------------------------------------
+
+	cnv, err := api.Exchange.Convert(ctx, money.MustParse("10.00", money.USD), money.JPY)
+	if err != nil {
+		t.Fatalf("Should be able to convert an amount: %s.", err)
+	}
+
+	if want := money.MustParse("1500", money.JPY); !cnv.Amount.Equal(want) {
+		t.Errorf("Should convert at the recorded rate: got %s want %s", cnv.Amount, want)
+	}
+
+	same, err := api.Exchange.Convert(ctx, money.MustParse("10.00", money.USD), money.USD)
+	if err != nil {
+		t.Fatalf("Should be able to convert an amount into its own currency: %s.", err)
+	}
+
+	if !same.Amount.Equal(money.MustParse("10.00", money.USD)) || !same.Rate.Rate.Equal(money.One) {
+		t.Errorf("Should convert at the identity rate: got %+v", same)
+	}
+
+	if _, err := api.Exchange.Convert(ctx, money.MustParse("10.00", money.EUR), money.USD); !errors.Is(err, exchange.ErrNotFound) {
+		t.Errorf("Should NOT be able to convert without a rate: %v.", err)
+	}
+
+	// -------------------------------------------------------------------------
+
+	if _, err := api.Exchange.Set(ctx, []exchange.NewRate{{Base: money.USD, Quote: money.USD, Rate: money.One}}); !errors.Is(err, exchange.ErrSameCurrency) {
+		t.Errorf("Should NOT be able to set a rate between a currency and itself: %v.", err)
+	}
+
+	if err := api.Exchange.Delete(ctx, money.USD, money.EUR); err != nil {
+		t.Fatalf("Should be able to delete a rate: %s.", err)
+	}
+
+	if _, err := api.Exchange.QueryRate(ctx, money.USD, money.EUR); !errors.Is(err, exchange.ErrNotFound) {
+		t.Errorf("Should NOT be able to retrieve a deleted rate: %v.", err)
+	}
+}
+
+// =============================================================================
+
+// seed returns a seeded user whose tenant the rates are recorded for.
+func seed(ctx context.Context, api dbtest.CoreAPIs) (user.User, error) {
+	usrs, err := api.User.Query(ctx, user.QueryFilter{}, user.DefaultOrderBy, 1, 1)
+	if err != nil {
+		return user.User{}, fmt.Errorf("seeding users: %w", err)
+	}
+
+	return usrs[0], nil
+}
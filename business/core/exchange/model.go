@@ -0,0 +1,33 @@
+package exchange
+
+import (
+	"time"
+
+	"github.com/aleury/service/business/data/money"
+	"github.com/google/uuid"
+)
+
+// Rate represents what one unit of the base currency buys in the quote
+// currency, and when that was last recorded.
+type Rate struct {
+	TenantID    uuid.UUID
+	Base        money.Currency
+	Quote       money.Currency
+	Rate        money.Rate
+	DateUpdated time.Time
+}
+
+// NewRate is what we require to record an exchange rate.
+type NewRate struct {
+	Base  money.Currency
+	Quote money.Currency
+	Rate  money.Rate
+}
+
+// Conversion is an amount converted into another currency along with the
+// rate that was used. The rate is the identity rate with no date when the
+// amount was already in that currency.
+type Conversion struct {
+	Amount money.Money
+	Rate   Rate
+}
@@ -0,0 +1,123 @@
+// Package exchangedb contains exchange rate related CRUD functionality.
+package exchangedb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aleury/service/business/core/exchange"
+	"github.com/aleury/service/business/core/tenant"
+	"github.com/aleury/service/business/data/money"
+	database "github.com/aleury/service/business/sys/database/pgx"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// Store manages the set of APIs for exchange rate database access.
+type Store struct {
+	log *zap.SugaredLogger
+	db  *sqlx.DB
+}
+
+// NewStore constructs the api for data access.
+func NewStore(log *zap.SugaredLogger, db *sqlx.DB) *Store {
+	return &Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// WithinTran runs passed function and does commit/rollback at the end. Every
+// store call made with the context handed to the function joins the
+// transaction.
+func (s *Store) WithinTran(ctx context.Context, fn func(ctx context.Context) error) error {
+	return database.WithinTran(ctx, s.log, s.db, fn)
+}
+
+// Upsert adds a rate to the database or replaces the rate already recorded
+// for the currencies.
+func (s *Store) Upsert(ctx context.Context, rate exchange.Rate) error {
+	const q = `
+	INSERT INTO exchange_rates
+		(tenant_id, base_currency, quote_currency, rate, date_updated)
+	VALUES
+		(:tenant_id, :base_currency, :quote_currency, :rate, :date_updated)
+	ON CONFLICT (tenant_id, base_currency, quote_currency) DO UPDATE SET
+		"rate" = EXCLUDED.rate,
+		"date_updated" = EXCLUDED.date_updated`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, toDBRate(rate)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes the rate between the currencies from the database.
+func (s *Store) Delete(ctx context.Context, base money.Currency, quote money.Currency) error {
+	data := map[string]any{
+		"base_currency":  base.Code(),
+		"quote_currency": quote.Code(),
+	}
+
+	const q = `
+	DELETE FROM
+		exchange_rates
+	WHERE
+		base_currency = :base_currency AND quote_currency = :quote_currency`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q+tenant.Scope(ctx, data, "tenant_id"), data); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Query retrieves the rates from the database ordered by currency.
+func (s *Store) Query(ctx context.Context) ([]exchange.Rate, error) {
+	data := map[string]any{}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		exchange_rates
+	WHERE
+		TRUE`
+
+	const orderBy = " ORDER BY base_currency, quote_currency"
+
+	var dbRates []dbRate
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q+tenant.Scope(ctx, data, "tenant_id")+orderBy, data, &dbRates); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toCoreRateSlice(dbRates), nil
+}
+
+// QueryByCurrencies gets the rate between the currencies from the database.
+func (s *Store) QueryByCurrencies(ctx context.Context, base money.Currency, quote money.Currency) (exchange.Rate, error) {
+	data := map[string]any{
+		"base_currency":  base.Code(),
+		"quote_currency": quote.Code(),
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		exchange_rates
+	WHERE
+		base_currency = :base_currency AND quote_currency = :quote_currency`
+
+	var dbRate dbRate
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q+tenant.Scope(ctx, data, "tenant_id"), data, &dbRate); err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return exchange.Rate{}, fmt.Errorf("namedquerystruct: %w", exchange.ErrNotFound)
+		}
+		return exchange.Rate{}, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return toCoreRate(dbRate), nil
+}
@@ -0,0 +1,47 @@
+package exchangedb
+
+import (
+	"time"
+
+	"github.com/aleury/service/business/core/exchange"
+	"github.com/aleury/service/business/data/money"
+	"github.com/google/uuid"
+)
+
+// dbRate represents the structure we need for moving data
+// between the app and the database.
+type dbRate struct {
+	TenantID    uuid.UUID  `db:"tenant_id"`
+	Base        string     `db:"base_currency"`
+	Quote       string     `db:"quote_currency"`
+	Rate        money.Rate `db:"rate"`
+	DateUpdated time.Time  `db:"date_updated"`
+}
+
+func toDBRate(rate exchange.Rate) dbRate {
+	return dbRate{
+		TenantID:    rate.TenantID,
+		Base:        rate.Base.Code(),
+		Quote:       rate.Quote.Code(),
+		Rate:        rate.Rate,
+		DateUpdated: rate.DateUpdated.UTC(),
+	}
+}
+
+func toCoreRate(dbRate dbRate) exchange.Rate {
+	return exchange.Rate{
+		TenantID:    dbRate.TenantID,
+		Base:        money.MustParseCurrency(dbRate.Base),
+		Quote:       money.MustParseCurrency(dbRate.Quote),
+		Rate:        dbRate.Rate,
+		DateUpdated: dbRate.DateUpdated.In(time.Local),
+	}
+}
+
+func toCoreRateSlice(dbRates []dbRate) []exchange.Rate {
+	rates := make([]exchange.Rate, len(dbRates))
+	for i, dbRate := range dbRates {
+		rates[i] = toCoreRate(dbRate)
+	}
+	return rates
+}
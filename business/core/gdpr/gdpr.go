@@ -0,0 +1,195 @@
+// Package gdpr provides a core business API for answering data subject
+// requests by exporting or erasing the data tied to a user.
+package gdpr
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aleury/service/business/core/apitoken"
+	"github.com/aleury/service/business/core/audit"
+	"github.com/aleury/service/business/core/identity"
+	"github.com/aleury/service/business/core/product"
+	"github.com/aleury/service/business/core/salesorder"
+	"github.com/aleury/service/business/core/session"
+	"github.com/aleury/service/business/core/user"
+	"github.com/aleury/service/business/data/order"
+	"github.com/google/uuid"
+)
+
+// Core manages the set of APIs for data subject requests.
+type Core struct {
+	userCore     *user.Core
+	productCore  *product.Core
+	orderCore    *salesorder.Core
+	sessionCore  *session.Core
+	identityCore *identity.Core
+	tokenCore    *apitoken.Core
+	auditCore    *audit.Core
+}
+
+// NewCore constructs a core for data subject request api access.
+func NewCore(userCore *user.Core, productCore *product.Core, orderCore *salesorder.Core, sessionCore *session.Core, identityCore *identity.Core, tokenCore *apitoken.Core, auditCore *audit.Core) *Core {
+	return &Core{
+		userCore:     userCore,
+		productCore:  productCore,
+		orderCore:    orderCore,
+		sessionCore:  sessionCore,
+		identityCore: identityCore,
+		tokenCore:    tokenCore,
+		auditCore:    auditCore,
+	}
+}
+
+// Export collects everything tied to the specified user into a single
+// document. Deleted users and products, and revoked and expired sessions and
+// tokens are included. Every export is recorded in the audit log.
+func (c *Core) Export(ctx context.Context, userID uuid.UUID) (Export, error) {
+	usr, err := c.queryUser(ctx, userID)
+	if err != nil {
+		return Export{}, err
+	}
+
+	var prdFilter product.QueryFilter
+	prdFilter.WithUserID(userID)
+	prdFilter.WithIncludeDeleted(true)
+
+	prds, err := queryAll(ctx, c.productCore.Count, c.productCore.Query, prdFilter, product.DefaultOrderBy)
+	if err != nil {
+		return Export{}, fmt.Errorf("products: userID[%s]: %w", userID, err)
+	}
+
+	var ordFilter salesorder.QueryFilter
+	ordFilter.WithUserID(userID)
+
+	ords, err := queryAll(ctx, c.orderCore.Count, c.orderCore.Query, ordFilter, salesorder.DefaultOrderBy)
+	if err != nil {
+		return Export{}, fmt.Errorf("orders: userID[%s]: %w", userID, err)
+	}
+
+	rets := []salesorder.Return{}
+	for _, ord := range ords {
+		ordRets, err := c.orderCore.QueryReturns(ctx, ord.ID)
+		if err != nil {
+			return Export{}, fmt.Errorf("returns: userID[%s]: %w", userID, err)
+		}
+		rets = append(rets, ordRets...)
+	}
+
+	sessions, err := c.sessionCore.QueryAllByUser(ctx, userID)
+	if err != nil {
+		return Export{}, fmt.Errorf("sessions: userID[%s]: %w", userID, err)
+	}
+
+	idts, err := c.identityCore.QueryByUser(ctx, userID)
+	if err != nil {
+		return Export{}, fmt.Errorf("identities: userID[%s]: %w", userID, err)
+	}
+
+	toks, err := c.tokenCore.QueryByUser(ctx, userID)
+	if err != nil {
+		return Export{}, fmt.Errorf("tokens: userID[%s]: %w", userID, err)
+	}
+
+	var changesFilter audit.QueryFilter
+	changesFilter.WithEntityType(audit.EntityUser)
+	changesFilter.WithEntityID(userID)
+
+	changes, err := queryAll(ctx, c.auditCore.Count, c.auditCore.Query, changesFilter, audit.DefaultOrderBy)
+	if err != nil {
+		return Export{}, fmt.Errorf("changes: userID[%s]: %w", userID, err)
+	}
+
+	var actionsFilter audit.QueryFilter
+	actionsFilter.WithActorID(userID)
+
+	actions, err := queryAll(ctx, c.auditCore.Count, c.auditCore.Query, actionsFilter, audit.DefaultOrderBy)
+	if err != nil {
+		return Export{}, fmt.Errorf("actions: userID[%s]: %w", userID, err)
+	}
+
+	now := time.Now()
+
+	ne := audit.NewEntry{
+		Action:     audit.ActionExport,
+		EntityType: audit.EntityUser,
+		EntityID:   userID,
+		After:      map[string]any{"dateExported": now.UTC()},
+	}
+	if _, err := c.auditCore.Record(ctx, ne); err != nil {
+		return Export{}, fmt.Errorf("audit: userID[%s]: %w", userID, err)
+	}
+
+	exp := Export{
+		User:         usr,
+		Products:     prds,
+		Orders:       ords,
+		Returns:      rets,
+		Sessions:     sessions,
+		Identities:   idts,
+		Tokens:       toks,
+		Changes:      changes,
+		Actions:      actions,
+		DateExported: now,
+	}
+
+	return exp, nil
+}
+
+// Erase anonymizes the personal data of the specified user. Products and
+// aggregate sales numbers are left untouched. Erasing a user twice has no
+// additional effect, but every erasure is recorded in the audit log.
+func (c *Core) Erase(ctx context.Context, userID uuid.UUID) (user.User, error) {
+	usr, err := c.queryUser(ctx, userID)
+	if err != nil {
+		return user.User{}, err
+	}
+
+	usr, err = c.userCore.Erase(ctx, usr)
+	if err != nil {
+		return user.User{}, fmt.Errorf("erase: userID[%s]: %w", userID, err)
+	}
+
+	return usr, nil
+}
+
+// =============================================================================
+
+// queryUser finds the specified user, including a user that was deleted.
+func (c *Core) queryUser(ctx context.Context, userID uuid.UUID) (user.User, error) {
+	var filter user.QueryFilter
+	filter.WithUserID(userID)
+	filter.WithIncludeDeleted(true)
+
+	usrs, err := c.userCore.Query(ctx, filter, user.DefaultOrderBy, 1, 1)
+	if err != nil {
+		return user.User{}, fmt.Errorf("query: userID[%s]: %w", userID, err)
+	}
+
+	if len(usrs) == 0 {
+		return user.User{}, fmt.Errorf("query: userID[%s]: %w", userID, user.ErrNotFound)
+	}
+
+	return usrs[0], nil
+}
+
+// queryAll retrieves every record matching the filter in a single page.
+func queryAll[T any, F any](
+	ctx context.Context,
+	count func(context.Context, F) (int, error),
+	query func(context.Context, F, order.By, int, int) ([]T, error),
+	filter F,
+	orderBy order.By,
+) ([]T, error) {
+	n, err := count(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("count: %w", err)
+	}
+
+	if n == 0 {
+		return []T{}, nil
+	}
+
+	return query(ctx, filter, orderBy, 1, n)
+}
@@ -0,0 +1,345 @@
+package gdpr_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"testing"
+	"time"
+
+	"github.com/aleury/service/business/core/apitoken"
+	"github.com/aleury/service/business/core/audit"
+	"github.com/aleury/service/business/core/gdpr"
+	"github.com/aleury/service/business/core/identity"
+	"github.com/aleury/service/business/core/product"
+	"github.com/aleury/service/business/core/salesorder"
+	"github.com/aleury/service/business/core/session"
+	"github.com/aleury/service/business/core/tenant"
+	"github.com/aleury/service/business/core/user"
+	"github.com/aleury/service/business/data/dbtest"
+	"github.com/aleury/service/business/data/money"
+	"github.com/aleury/service/foundation/docker"
+	"github.com/google/uuid"
+)
+
+var c *docker.Container
+
+func TestMain(m *testing.M) {
+	var err error
+	c, err = dbtest.StartDB()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer dbtest.StopDB(c)
+
+	m.Run()
+}
+
+func Test_GDPR(t *testing.T) {
+	t.Run("export", export)
+	t.Run("erase", erase)
+}
+
+// =============================================================================
+
+func export(t *testing.T) {
+	test := dbtest.NewTest(t, c)
+	defer func() {
+		if r := recover(); r != nil {
+			t.Log(r)
+			t.Error(string(debug.Stack()))
+		}
+		test.Teardown()
+	}()
+
+	api := test.CoreAPIs
+	core := gdpr.NewCore(api.User, api.Product, api.SalesOrder, api.Session, api.Identity, api.APIToken, api.Audit)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	t.Log("Go seeding...")
+
+	usr, prds, err := seed(ctx, api)
+	if err != nil {
+		t.Fatalf("Seeding error: %s", err)
+	}
+
+	// -------------------------------------------------------------------------
+
+	ord, err := api.SalesOrder.Create(ctx, salesorder.NewOrder{
+		UserID:   usr.ID,
+		TenantID: usr.TenantID,
+		Lines:    []salesorder.NewLine{{ProductID: prds[0].ID, Quantity: 2}},
+	})
+	if err != nil {
+		t.Fatalf("Should be able to order for the user: %s.", err)
+	}
+
+	if ord, err = api.SalesOrder.Transition(ctx, ord, salesorder.StatusPaid); err != nil {
+		t.Fatalf("Should be able to pay for the order: %s.", err)
+	}
+
+	nr := salesorder.NewReturn{
+		Reason: salesorder.ReasonDamaged,
+		Lines:  []salesorder.NewReturnLine{{LineNumber: 1, Quantity: 1}},
+	}
+	if _, _, err := api.SalesOrder.Return(ctx, ord, nr); err != nil {
+		t.Fatalf("Should be able to return goods from the order: %s.", err)
+	}
+
+	ns := session.NewSession{
+		UserID:    usr.ID,
+		TenantID:  usr.TenantID,
+		UserAgent: "gopher/1.0",
+		IPAddress: "10.0.0.1",
+		TTL:       time.Hour,
+	}
+	sess, _, err := api.Session.Create(ctx, ns)
+	if err != nil {
+		t.Fatalf("Should be able to start a session for the user: %s.", err)
+	}
+
+	if _, err := api.Session.Revoke(ctx, sess); err != nil {
+		t.Fatalf("Should be able to revoke the session: %s.", err)
+	}
+
+	ext := identity.External{
+		Issuer:        "https://idp.example.com",
+		Subject:       "external-1",
+		Email:         usr.Email,
+		EmailVerified: true,
+		Name:          usr.Name,
+		TenantID:      usr.TenantID,
+	}
+	if _, err := api.Identity.Resolve(tenant.SetTenantID(ctx, usr.TenantID), ext); err != nil {
+		t.Fatalf("Should be able to link an identity to the user: %s.", err)
+	}
+
+	nt := apitoken.NewToken{
+		UserID:   usr.ID,
+		TenantID: usr.TenantID,
+		Issuer:   "sales api",
+		TTL:      time.Hour,
+	}
+	tok, err := api.APIToken.Create(ctx, nt)
+	if err != nil {
+		t.Fatalf("Should be able to issue a token to the user: %s.", err)
+	}
+
+	// -------------------------------------------------------------------------
+
+	exp, err := core.Export(ctx, usr.ID)
+	if err != nil {
+		t.Fatalf("Should be able to export the data of the user: %s.", err)
+	}
+
+	if exp.User.ID != usr.ID || exp.User.Name != usr.Name {
+		t.Errorf("Should export the user record: got %+v", exp.User)
+	}
+
+	if len(exp.Products) != len(prds) {
+		t.Errorf("Should export every product of the user, deleted ones included: got %d want %d", len(exp.Products), len(prds))
+	}
+
+	if len(exp.Orders) != 1 || exp.Orders[0].ID != ord.ID {
+		t.Errorf("Should export the orders of the user: %+v", exp.Orders)
+	}
+
+	if len(exp.Returns) != 1 || exp.Returns[0].OrderID != ord.ID {
+		t.Errorf("Should export the returns from the orders of the user: %+v", exp.Returns)
+	}
+
+	if len(exp.Sessions) != 1 || exp.Sessions[0].ID != sess.ID || exp.Sessions[0].IPAddress != ns.IPAddress {
+		t.Errorf("Should export the sessions of the user, revoked ones included: %+v", exp.Sessions)
+	}
+
+	if len(exp.Identities) != 1 || exp.Identities[0].Subject != ext.Subject {
+		t.Errorf("Should export the identities linked to the user: %+v", exp.Identities)
+	}
+
+	if len(exp.Tokens) != 1 || exp.Tokens[0].ID != tok.ID {
+		t.Errorf("Should export the tokens issued to the user: %+v", exp.Tokens)
+	}
+
+	if !hasAction(exp.Changes, audit.ActionUpdate) {
+		t.Errorf("Should export the changes made to the user: %+v", exp.Changes)
+	}
+
+	if len(exp.Actions) != len(prds)+2 {
+		t.Errorf("Should export the changes the user made: got %d want %d", len(exp.Actions), len(prds)+2)
+	}
+
+	if _, err := core.Export(ctx, usr.ID); err != nil {
+		t.Fatalf("Should be able to export the data of the user again: %s.", err)
+	}
+
+	if n := count(ctx, t, api, usr.ID, audit.ActionExport); n != 2 {
+		t.Errorf("Should record every export: got %d", n)
+	}
+
+	if _, err := core.Export(ctx, uuid.New()); !errors.Is(err, user.ErrNotFound) {
+		t.Errorf("Should NOT be able to export an unknown user: %v.", err)
+	}
+}
+
+func erase(t *testing.T) {
+	test := dbtest.NewTest(t, c)
+	defer func() {
+		if r := recover(); r != nil {
+			t.Log(r)
+			t.Error(string(debug.Stack()))
+		}
+		test.Teardown()
+	}()
+
+	api := test.CoreAPIs
+	core := gdpr.NewCore(api.User, api.Product, api.SalesOrder, api.Session, api.Identity, api.APIToken, api.Audit)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	t.Log("Go seeding...")
+
+	usr, prds, err := seed(ctx, api)
+	if err != nil {
+		t.Fatalf("Seeding error: %s", err)
+	}
+
+	// -------------------------------------------------------------------------
+
+	erased, err := core.Erase(ctx, usr.ID)
+	if err != nil {
+		t.Fatalf("Should be able to erase the user: %s.", err)
+	}
+
+	saved, err := api.User.QueryByID(ctx, usr.ID)
+	if err != nil {
+		t.Fatalf("Should be able to retrieve the erased user by ID: %s.", err)
+	}
+
+	if saved.Name == usr.Name || saved.Email.Address == usr.Email.Address || saved.DepartmentID != uuid.Nil {
+		t.Errorf("Should have anonymized the personal data of the user: %+v", saved)
+	}
+
+	if saved.Enabled || saved.DateErased.IsZero() {
+		t.Errorf("Should have disabled the erased user: enabled %t erased %v", saved.Enabled, saved.DateErased)
+	}
+
+	past, err := api.User.QueryByIDAsOf(ctx, usr.ID, usr.DateUpdated)
+	if err != nil {
+		t.Fatalf("Should be able to retrieve the user as it was before the erasure: %s.", err)
+	}
+
+	if past.Name != saved.Name || past.Email.Address != saved.Email.Address {
+		t.Errorf("Should have anonymized the history of the user: %+v", past)
+	}
+
+	if _, err := api.Product.QueryByID(ctx, prds[0].ID); err != nil {
+		t.Errorf("Should have kept the products of the user: %v.", err)
+	}
+
+	// -------------------------------------------------------------------------
+
+	var filter audit.QueryFilter
+	filter.WithEntityType(audit.EntityUser)
+	filter.WithEntityID(usr.ID)
+
+	entries, err := api.Audit.Query(ctx, filter, audit.DefaultOrderBy, 1, 100)
+	if err != nil {
+		t.Fatalf("Should be able to query the audit entries of the user: %s.", err)
+	}
+
+	for _, e := range entries {
+		var changes map[string]audit.Change
+		if err := json.Unmarshal(e.Diff, &changes); err != nil {
+			t.Fatalf("Should be able to unmarshal the diff: %s.", err)
+		}
+
+		for _, field := range []string{"name", "email", "department", "departmentId"} {
+			if _, exists := changes[field]; exists {
+				t.Errorf("Should have redacted %s from the %s entry: %s", field, e.Action, e.Diff)
+			}
+		}
+	}
+
+	// -------------------------------------------------------------------------
+
+	again, err := core.Erase(ctx, usr.ID)
+	if err != nil {
+		t.Fatalf("Should be able to erase the user again: %s.", err)
+	}
+
+	if !again.DateErased.Equal(erased.DateErased) {
+		t.Errorf("Should NOT erase a user twice: got %v want %v", again.DateErased, erased.DateErased)
+	}
+
+	if n := count(ctx, t, api, usr.ID, audit.ActionErase); n != 2 {
+		t.Errorf("Should record every erasure: got %d", n)
+	}
+}
+
+// =============================================================================
+
+// count returns the number of audit entries recorded against the user for
+// the action.
+func count(ctx context.Context, t *testing.T, api dbtest.CoreAPIs, userID uuid.UUID, action string) int {
+	var filter audit.QueryFilter
+	filter.WithEntityType(audit.EntityUser)
+	filter.WithEntityID(userID)
+	filter.WithAction(action)
+
+	n, err := api.Audit.Count(ctx, filter)
+	if err != nil {
+		t.Fatalf("Should be able to count the audit entries of the user: %s.", err)
+	}
+
+	return n
+}
+
+func hasAction(entries []audit.Entry, action string) bool {
+	for _, e := range entries {
+		if e.Action == action {
+			return true
+		}
+	}
+	return false
+}
+
+// seed gives a seeded user a change to its record and two products, one of
+// them deleted, all made by the user itself.
+func seed(ctx context.Context, api dbtest.CoreAPIs) (user.User, []product.Product, error) {
+	name := "User Gopher"
+	usrs, err := api.User.Query(ctx, user.QueryFilter{Name: &name}, user.DefaultOrderBy, 1, 1)
+	if err != nil {
+		return user.User{}, nil, fmt.Errorf("seeding users: %w", err)
+	}
+
+	ctx = audit.SetActorID(ctx, usrs[0].ID)
+
+	usr, err := api.User.Update(ctx, usrs[0], user.UpdateUser{Name: dbtest.StringPointer("Gopher User")})
+	if err != nil {
+		return user.User{}, nil, fmt.Errorf("seeding user: %w", err)
+	}
+
+	nps := []product.NewProduct{
+		{Name: "Comic Books", Cost: money.MustParse("10.00", money.USD), Quantity: 5, UserID: usr.ID},
+		{Name: "McDonalds Toys", Cost: money.MustParse("5.00", money.USD), Quantity: 2, UserID: usr.ID},
+	}
+
+	prds := make([]product.Product, len(nps))
+	for i, np := range nps {
+		if prds[i], err = api.Product.Create(ctx, np); err != nil {
+			return user.User{}, nil, fmt.Errorf("seeding products: %w", err)
+		}
+	}
+
+	if err := api.Product.Delete(ctx, prds[1]); err != nil {
+		return user.User{}, nil, fmt.Errorf("seeding products: %w", err)
+	}
+
+	return usr, prds, nil
+}
@@ -0,0 +1,29 @@
+package gdpr
+
+import (
+	"time"
+
+	"github.com/aleury/service/business/core/apitoken"
+	"github.com/aleury/service/business/core/audit"
+	"github.com/aleury/service/business/core/identity"
+	"github.com/aleury/service/business/core/product"
+	"github.com/aleury/service/business/core/salesorder"
+	"github.com/aleury/service/business/core/session"
+	"github.com/aleury/service/business/core/user"
+)
+
+// Export represents everything the system holds that is tied to a user.
+// Tokens are the API tokens issued to the user, which are never stored
+// themselves, only what they were issued for.
+type Export struct {
+	User         user.User
+	Products     []product.Product
+	Orders       []salesorder.Order
+	Returns      []salesorder.Return
+	Sessions     []session.Session
+	Identities   []identity.Identity
+	Tokens       []apitoken.Token
+	Changes      []audit.Entry
+	Actions      []audit.Entry
+	DateExported time.Time
+}
@@ -0,0 +1,160 @@
+// Package identity provides the core business API for linking users of
+// external identity providers to local users.
+package identity
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aleury/service/business/core/user"
+	"github.com/google/uuid"
+)
+
+// Set of error variables for CRUD operations.
+var (
+	ErrNotFound         = errors.New("identity not found")
+	ErrEmailNotVerified = errors.New("email is not verified by the identity provider")
+	ErrUserDisabled     = errors.New("user is disabled")
+)
+
+// Storer interface declares the behavior this package needs to persist and
+// retrieve data.
+type Storer interface {
+	WithinTran(ctx context.Context, fn func(ctx context.Context) error) error
+	Create(ctx context.Context, idt Identity) error
+	QueryBySubject(ctx context.Context, issuer string, subject string) (Identity, error)
+	QueryByUser(ctx context.Context, userID uuid.UUID) ([]Identity, error)
+}
+
+// Core manages the set of APIs for identity access.
+type Core struct {
+	storer  Storer
+	usrCore *user.Core
+}
+
+// NewCore constructs a core for identity api access.
+func NewCore(storer Storer, usrCore *user.Core) *Core {
+	return &Core{
+		storer:  storer,
+		usrCore: usrCore,
+	}
+}
+
+// Resolve returns the local user for an external identity. The first time
+// a subject signs in it is linked to the user with the same verified email,
+// or to a new user created just in time with the USER role.
+func (c *Core) Resolve(ctx context.Context, ext External) (user.User, error) {
+	idt, err := c.storer.QueryBySubject(ctx, ext.Issuer, ext.Subject)
+	switch {
+	case err == nil:
+		return c.linkedUser(ctx, idt)
+	case !errors.Is(err, ErrNotFound):
+		return user.User{}, fmt.Errorf("query: issuer[%s] subject[%s]: %w", ext.Issuer, ext.Subject, err)
+	}
+
+	// Linking by email is only safe when the provider vouches for it.
+	if !ext.EmailVerified {
+		return user.User{}, ErrEmailNotVerified
+	}
+
+	var usr user.User
+	tran := func(ctx context.Context) error {
+		var err error
+		usr, err = c.usrCore.QueryByEmail(ctx, ext.Email)
+		switch {
+		case errors.Is(err, user.ErrNotFound):
+			usr, err = c.createUser(ctx, ext)
+			if err != nil {
+				return err
+			}
+		case err != nil:
+			return fmt.Errorf("querybyemail: %w", err)
+		}
+
+		if !usr.Enabled {
+			return ErrUserDisabled
+		}
+
+		idt := Identity{
+			Issuer:      ext.Issuer,
+			Subject:     ext.Subject,
+			UserID:      usr.ID,
+			TenantID:    usr.TenantID,
+			DateCreated: time.Now(),
+		}
+
+		if err := c.storer.Create(ctx, idt); err != nil {
+			return fmt.Errorf("create: %w", err)
+		}
+
+		return nil
+	}
+
+	if err := c.storer.WithinTran(ctx, tran); err != nil {
+		return user.User{}, err
+	}
+
+	return usr, nil
+}
+
+// QueryByUser retrieves the identities linked to a user, oldest first.
+func (c *Core) QueryByUser(ctx context.Context, userID uuid.UUID) ([]Identity, error) {
+	idts, err := c.storer.QueryByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("query: userID[%s]: %w", userID, err)
+	}
+	return idts, nil
+}
+
+// linkedUser returns the user an identity is linked to, as long as the user
+// can still sign in.
+func (c *Core) linkedUser(ctx context.Context, idt Identity) (user.User, error) {
+	usr, err := c.usrCore.QueryByID(ctx, idt.UserID)
+	if err != nil {
+		if errors.Is(err, user.ErrNotFound) {
+			return user.User{}, ErrUserDisabled
+		}
+		return user.User{}, fmt.Errorf("querybyid: userID[%s]: %w", idt.UserID, err)
+	}
+
+	if !usr.Enabled {
+		return user.User{}, ErrUserDisabled
+	}
+
+	return usr, nil
+}
+
+// createUser creates the local user for an external identity. The user signs
+// in through the provider, so the password is random and never disclosed.
+func (c *Core) createUser(ctx context.Context, ext External) (user.User, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return user.User{}, fmt.Errorf("generating password: %w", err)
+	}
+	password := base64.RawURLEncoding.EncodeToString(b)
+
+	name := ext.Name
+	if name == "" {
+		name = ext.Email.Address
+	}
+
+	nu := user.NewUser{
+		TenantID:        ext.TenantID,
+		Name:            name,
+		Email:           ext.Email,
+		Roles:           []user.Role{user.RoleUser},
+		Password:        password,
+		PasswordConfirm: password,
+	}
+
+	usr, err := c.usrCore.Create(ctx, nu)
+	if err != nil {
+		return user.User{}, fmt.Errorf("create user: %w", err)
+	}
+
+	return usr, nil
+}
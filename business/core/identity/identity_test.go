@@ -0,0 +1,178 @@
+package identity_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"runtime/debug"
+	"testing"
+	"time"
+
+	"github.com/aleury/service/business/core/identity"
+	"github.com/aleury/service/business/core/tenant"
+	"github.com/aleury/service/business/core/user"
+	"github.com/aleury/service/business/data/dbtest"
+	"github.com/aleury/service/foundation/docker"
+)
+
+var c *docker.Container
+
+func TestMain(m *testing.M) {
+	var err error
+	c, err = dbtest.StartDB()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer dbtest.StopDB(c)
+
+	m.Run()
+}
+
+func Test_Identity(t *testing.T) {
+	t.Run("resolve", resolve)
+	t.Run("disabled", disabled)
+}
+
+// =============================================================================
+
+func resolve(t *testing.T) {
+	test := dbtest.NewTest(t, c)
+	defer func() {
+		if r := recover(); r != nil {
+			t.Log(r)
+			t.Error(string(debug.Stack()))
+		}
+		test.Teardown()
+	}()
+
+	api := test.CoreAPIs
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	t.Log("Go seeding...")
+
+	usrs, err := api.User.Query(ctx, user.QueryFilter{}, user.DefaultOrderBy, 1, 1)
+	if err != nil || len(usrs) != 1 {
+		t.Fatalf("Seeding error: %v", err)
+	}
+	saved := usrs[0]
+
+	// -------------------------------------------------------------------------
+
+	idpCtx := tenant.SetTenantID(ctx, saved.TenantID)
+
+	ext := identity.External{
+		Issuer:        "https://idp.example.com",
+		Subject:       "external-1",
+		Email:         saved.Email,
+		EmailVerified: true,
+		Name:          saved.Name,
+		TenantID:      saved.TenantID,
+	}
+
+	linked, err := api.Identity.Resolve(idpCtx, ext)
+	if err != nil {
+		t.Fatalf("Should be able to link an identity by email: %s.", err)
+	}
+
+	if linked.ID != saved.ID {
+		t.Errorf("Should link the identity to the user with the email: got %s want %s", linked.ID, saved.ID)
+	}
+
+	ext.EmailVerified = false
+	if linked, err = api.Identity.Resolve(idpCtx, ext); err != nil || linked.ID != saved.ID {
+		t.Errorf("Should resolve a linked identity without the email: %v.", err)
+	}
+
+	// -------------------------------------------------------------------------
+
+	ext.Subject = "external-2"
+	ext.Email = mail.Address{Name: "Jit Gopher", Address: "jit@example.com"}
+	if _, err := api.Identity.Resolve(idpCtx, ext); !errors.Is(err, identity.ErrEmailNotVerified) {
+		t.Errorf("Should NOT link an identity with an unverified email: %v.", err)
+	}
+
+	ext.EmailVerified = true
+	jit, err := api.Identity.Resolve(idpCtx, ext)
+	if err != nil {
+		t.Fatalf("Should be able to create a user just in time: %s.", err)
+	}
+
+	if jit.ID == saved.ID || len(jit.Roles) != 1 || !jit.Roles[0].Equal(user.RoleUser) {
+		t.Errorf("Should create a new user with the USER role: %+v", jit)
+	}
+
+	if jit.TenantID != saved.TenantID {
+		t.Errorf("Should create the user in the tenant of the identity: got %s want %s", jit.TenantID, saved.TenantID)
+	}
+
+	again, err := api.Identity.Resolve(idpCtx, ext)
+	if err != nil || again.ID != jit.ID {
+		t.Errorf("Should resolve the identity to the user created just in time: %v.", err)
+	}
+}
+
+func disabled(t *testing.T) {
+	test := dbtest.NewTest(t, c)
+	defer func() {
+		if r := recover(); r != nil {
+			t.Log(r)
+			t.Error(string(debug.Stack()))
+		}
+		test.Teardown()
+	}()
+
+	api := test.CoreAPIs
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	t.Log("Go seeding...")
+
+	usrs, err := api.User.Query(ctx, user.QueryFilter{}, user.DefaultOrderBy, 1, 2)
+	if err != nil || len(usrs) != 2 {
+		t.Fatalf("Seeding error: %v", err)
+	}
+
+	// -------------------------------------------------------------------------
+
+	idpCtx := tenant.SetTenantID(ctx, usrs[0].TenantID)
+
+	ext := identity.External{
+		Issuer:        "https://idp.example.com",
+		Subject:       "external-1",
+		Email:         usrs[0].Email,
+		EmailVerified: true,
+		TenantID:      usrs[0].TenantID,
+	}
+
+	if _, err := api.Identity.Resolve(idpCtx, ext); err != nil {
+		t.Fatalf("Should be able to link an identity by email: %s.", err)
+	}
+
+	enabled := false
+	if _, err := api.User.Update(ctx, usrs[0], user.UpdateUser{Enabled: &enabled}); err != nil {
+		t.Fatalf("Should be able to disable user: %s.", err)
+	}
+
+	if _, err := api.Identity.Resolve(idpCtx, ext); !errors.Is(err, identity.ErrUserDisabled) {
+		t.Errorf("Should NOT resolve an identity linked to a disabled user: %v.", err)
+	}
+
+	// -------------------------------------------------------------------------
+
+	if _, err := api.User.Update(ctx, usrs[1], user.UpdateUser{Enabled: &enabled}); err != nil {
+		t.Fatalf("Should be able to disable user: %s.", err)
+	}
+
+	ext.Subject = "external-2"
+	ext.Email = usrs[1].Email
+	ext.TenantID = usrs[1].TenantID
+
+	if _, err := api.Identity.Resolve(idpCtx, ext); !errors.Is(err, identity.ErrUserDisabled) {
+		t.Errorf("Should NOT link an identity to a disabled user: %v.", err)
+	}
+}
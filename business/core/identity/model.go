@@ -0,0 +1,29 @@
+package identity
+
+import (
+	"net/mail"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Identity links the subject of an external identity provider to a local
+// user.
+type Identity struct {
+	Issuer      string
+	Subject     string
+	UserID      uuid.UUID
+	TenantID    uuid.UUID
+	DateCreated time.Time
+}
+
+// External contains the information an identity provider asserted about a
+// user who signed in.
+type External struct {
+	Issuer        string
+	Subject       string
+	Email         mail.Address
+	EmailVerified bool
+	Name          string
+	TenantID      uuid.UUID
+}
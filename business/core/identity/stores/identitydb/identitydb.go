@@ -0,0 +1,104 @@
+// Package identitydb contains identity related CRUD functionality.
+package identitydb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aleury/service/business/core/identity"
+	"github.com/aleury/service/business/core/tenant"
+	database "github.com/aleury/service/business/sys/database/pgx"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// Store manages the set of APIs for identity database access.
+type Store struct {
+	log *zap.SugaredLogger
+	db  *sqlx.DB
+}
+
+// NewStore constructs the api for data access.
+func NewStore(log *zap.SugaredLogger, db *sqlx.DB) *Store {
+	return &Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// WithinTran runs passed function and does commit/rollback at the end. Every
+// store call made with the context handed to the function joins the
+// transaction.
+func (s *Store) WithinTran(ctx context.Context, fn func(ctx context.Context) error) error {
+	return database.WithinTran(ctx, s.log, s.db, fn)
+}
+
+// Create inserts a new identity into the database.
+func (s *Store) Create(ctx context.Context, idt identity.Identity) error {
+	const q = `
+	INSERT INTO user_identities
+		(issuer, subject, user_id, tenant_id, date_created)
+	VALUES
+		(:issuer, :subject, :user_id, :tenant_id, :date_created)`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, toDBIdentity(idt)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// QueryBySubject gets the identity for the subject of the issuer.
+func (s *Store) QueryBySubject(ctx context.Context, issuer string, subject string) (identity.Identity, error) {
+	data := map[string]any{
+		"issuer":  issuer,
+		"subject": subject,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		user_identities
+	WHERE
+		issuer = :issuer AND subject = :subject`
+
+	var dbIdt dbIdentity
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q+tenant.Scope(ctx, data, "tenant_id"), data, &dbIdt); err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return identity.Identity{}, fmt.Errorf("namedquerystruct: %w", identity.ErrNotFound)
+		}
+		return identity.Identity{}, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return toCoreIdentity(dbIdt), nil
+}
+
+// QueryByUser retrieves the identities linked to a user, oldest first.
+func (s *Store) QueryByUser(ctx context.Context, userID uuid.UUID) ([]identity.Identity, error) {
+	data := map[string]any{
+		"user_id": userID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		user_identities
+	WHERE
+		user_id = :user_id`
+
+	buf := bytes.NewBufferString(q)
+	buf.WriteString(tenant.Scope(ctx, data, "tenant_id"))
+	buf.WriteString(" ORDER BY date_created")
+
+	var dbIdts []dbIdentity
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, buf.String(), data, &dbIdts); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toCoreIdentitySlice(dbIdts), nil
+}
@@ -0,0 +1,46 @@
+package identitydb
+
+import (
+	"time"
+
+	"github.com/aleury/service/business/core/identity"
+	"github.com/google/uuid"
+)
+
+// dbIdentity represents the structure we need for moving data
+// between the app and the database.
+type dbIdentity struct {
+	Issuer      string    `db:"issuer"`
+	Subject     string    `db:"subject"`
+	UserID      uuid.UUID `db:"user_id"`
+	TenantID    uuid.UUID `db:"tenant_id"`
+	DateCreated time.Time `db:"date_created"`
+}
+
+func toDBIdentity(idt identity.Identity) dbIdentity {
+	return dbIdentity{
+		Issuer:      idt.Issuer,
+		Subject:     idt.Subject,
+		UserID:      idt.UserID,
+		TenantID:    idt.TenantID,
+		DateCreated: idt.DateCreated.UTC(),
+	}
+}
+
+func toCoreIdentity(dbIdt dbIdentity) identity.Identity {
+	return identity.Identity{
+		Issuer:      dbIdt.Issuer,
+		Subject:     dbIdt.Subject,
+		UserID:      dbIdt.UserID,
+		TenantID:    dbIdt.TenantID,
+		DateCreated: dbIdt.DateCreated.In(time.Local),
+	}
+}
+
+func toCoreIdentitySlice(dbIdts []dbIdentity) []identity.Identity {
+	idts := make([]identity.Identity, len(dbIdts))
+	for i, dbIdt := range dbIdts {
+		idts[i] = toCoreIdentity(dbIdt)
+	}
+	return idts
+}
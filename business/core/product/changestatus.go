@@ -0,0 +1,65 @@
+package product
+
+import (
+	"errors"
+)
+
+// Set of statuses of scheduled price changes.
+var (
+	ChangeScheduled = ChangeStatus{"SCHEDULED"}
+	ChangeApplied   = ChangeStatus{"APPLIED"}
+	ChangeCancelled = ChangeStatus{"CANCELLED"}
+)
+
+// Set of known change statuses.
+var changeStatuses = map[string]ChangeStatus{
+	ChangeScheduled.name: ChangeScheduled,
+	ChangeApplied.name:   ChangeApplied,
+	ChangeCancelled.name: ChangeCancelled,
+}
+
+// ChangeStatus represents where a scheduled price change is in its life.
+type ChangeStatus struct {
+	name string
+}
+
+// ParseChangeStatus parses the string value and returns a change status if
+// one exists.
+func ParseChangeStatus(value string) (ChangeStatus, error) {
+	status, exists := changeStatuses[value]
+	if !exists {
+		return ChangeStatus{}, errors.New("invalid change status")
+	}
+	return status, nil
+}
+
+// MustParseChangeStatus parses the string value and returns a change status
+// if one exists. If an error occurs the function panics.
+func MustParseChangeStatus(value string) ChangeStatus {
+	status, err := ParseChangeStatus(value)
+	if err != nil {
+		panic(err)
+	}
+	return status
+}
+
+// Name returns the name of the change status.
+func (s ChangeStatus) Name() string {
+	return s.name
+}
+
+// UnmarshalText implements the unmarshal interface for JSON conversions.
+func (s *ChangeStatus) UnmarshalText(data []byte) error {
+	s.name = string(data)
+	return nil
+}
+
+// MarshalText implements the marshal interface for JSON conversions.
+func (s ChangeStatus) MarshalText() ([]byte, error) {
+	return []byte(s.name), nil
+}
+
+// Equal provides support for the go-cmp package and testing.
+func (s ChangeStatus) Equal(s2 ChangeStatus) bool {
+	return s.name == s2.name
+}
@@ -3,16 +3,22 @@ package product
 import (
 	"fmt"
 
+	"github.com/aleury/service/business/data/money"
 	"github.com/aleury/service/business/sys/validate"
 	"github.com/google/uuid"
 )
 
 // QueryFilter holds the available fields a query can be filtered on.
 type QueryFilter struct {
-	ID       *uuid.UUID `validate:"omitempty"`
-	Name     *string    `validate:"omitempty,min=3"`
-	Cost     *float64   `validate:"omitempty,numeric"`
-	Quantity *int       `validate:"omitempty,numeric"`
+	ID             *uuid.UUID `validate:"omitempty"`
+	Name           *string    `validate:"omitempty,min=3"`
+	Cost           *money.Money
+	Quantity       *int       `validate:"omitempty,numeric"`
+	UserID         *uuid.UUID `validate:"omitempty"`
+	CategoryID     *uuid.UUID `validate:"omitempty"`
+	ParentID       *uuid.UUID `validate:"omitempty"`
+	Search         *string    `validate:"omitempty,min=1"`
+	IncludeDeleted *bool      `validate:"omitempty"`
 }
 
 // Validate checks the data in the model is considered clean.
@@ -34,7 +40,7 @@ func (qf *QueryFilter) WithName(name string) {
 }
 
 // WithCost sets the Cost field of the QueryFilter value.
-func (qf *QueryFilter) WithCost(cost float64) {
+func (qf *QueryFilter) WithCost(cost money.Money) {
 	qf.Cost = &cost
 }
 
@@ -42,3 +48,32 @@ func (qf *QueryFilter) WithCost(cost float64) {
 func (qf *QueryFilter) WithQuantity(quantity int) {
 	qf.Quantity = &quantity
 }
+
+// WithUserID sets the UserID field of the QueryFilter value.
+func (qf *QueryFilter) WithUserID(userID uuid.UUID) {
+	qf.UserID = &userID
+}
+
+// WithCategoryID sets the CategoryID field of the QueryFilter value. Products
+// in the descendants of the category match as well.
+func (qf *QueryFilter) WithCategoryID(categoryID uuid.UUID) {
+	qf.CategoryID = &categoryID
+}
+
+// WithParentID sets the ParentID field of the QueryFilter value. Passing
+// uuid.Nil matches the products that aren't variants of another one.
+func (qf *QueryFilter) WithParentID(parentID uuid.UUID) {
+	qf.ParentID = &parentID
+}
+
+// WithSearch sets the Search field of the QueryFilter value. Products are
+// matched on the words of their name and SKU, or on a name close to the
+// text, and are returned most relevant first.
+func (qf *QueryFilter) WithSearch(text string) {
+	qf.Search = &text
+}
+
+// WithIncludeDeleted sets the IncludeDeleted field of the QueryFilter value.
+func (qf *QueryFilter) WithIncludeDeleted(includeDeleted bool) {
+	qf.IncludeDeleted = &includeDeleted
+}
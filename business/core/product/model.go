@@ -3,28 +3,101 @@ package product
 import (
 	"time"
 
+	"github.com/aleury/service/business/core/exchange"
+	"github.com/aleury/service/business/data/money"
 	"github.com/google/uuid"
 )
 
 // Product represents an individual product.
 type Product struct {
 	ID          uuid.UUID
+	TenantID    uuid.UUID
 	Name        string
-	Cost        float64
+	Cost        money.Money
 	Quantity    int
 	Sold        int
-	Revenue     int
+	Revenue     money.Money
 	UserID      uuid.UUID
+	Version     int
 	DateCreated time.Time
 	DateUpdated time.Time
+	DateDeleted time.Time
+
+	// TaxClass names the rate the product is taxed at in every jurisdiction.
+	TaxClass string
+
+	// ReorderThreshold is the stock on hand below which the product is
+	// reported as low on stock. Zero turns the alerts off.
+	ReorderThreshold int
+
+	// A product with options is sold through its variants. A variant has the
+	// product as its parent and picks a value for each of its options.
+	ParentID     uuid.UUID
+	SKU          string
+	Options      []string
+	OptionValues map[string]string
+}
+
+// HasVariants reports whether the product is sold through variants.
+func (p Product) HasVariants() bool {
+	return len(p.Options) > 0
+}
+
+// IsVariant reports whether the product is a variant of another one.
+func (p Product) IsVariant() bool {
+	return p.ParentID != uuid.Nil
+}
+
+// Version represents the state of a product from the moment it was recorded
+// until the next version of the product was recorded.
+type Version struct {
+	Number    int
+	ValidFrom time.Time
+	Product   Product
 }
 
-// NewProduct is what we require from clients when adding a Product.
+// Price represents the explicit price of a product in a currency other than
+// the one its cost is kept in.
+type Price struct {
+	ProductID   uuid.UUID
+	TenantID    uuid.UUID
+	Amount      money.Money
+	DateUpdated time.Time
+}
+
+// Quote is what a product sells for in a requested currency. An explicit
+// price is used when the product has one, otherwise the cost is converted at
+// the recorded exchange rate and Converted is set.
+type Quote struct {
+	ProductID uuid.UUID
+	Amount    money.Money
+	Converted bool
+	Rate      exchange.Rate
+}
+
+// NewProduct is what we require from clients when adding a Product. A
+// product with options can't be stocked itself, its variants are. Products
+// without a tax class are taxed at the standard rate.
 type NewProduct struct {
-	Name     string
-	Cost     float64
-	Quantity int
-	UserID   uuid.UUID
+	Name             string
+	Cost             money.Money
+	Quantity         int
+	UserID           uuid.UUID
+	SKU              string
+	Options          []string
+	TaxClass         string
+	ReorderThreshold int
+}
+
+// NewVariant is what we require from clients when adding a variant to a
+// product. The option values pick one value for each option of the product.
+// The variant starts out in the tax class of the product.
+type NewVariant struct {
+	SKU              string
+	OptionValues     map[string]string
+	Cost             money.Money
+	Quantity         int
+	ReorderThreshold int
 }
 
 // UpdateProduct defines what information may be provided to modify an
@@ -33,8 +106,80 @@ type NewProduct struct {
 // between a field taht was not provided and a field that was provided as
 // explicitly blank. Otherwise we wouldn't want to use pointers to basic types
 // but we make exceptions around marshalling/unmarshalling.
+//
+// Stock on hand can't be updated. It only changes through movements recorded
+// in the inventory ledger.
 type UpdateProduct struct {
-	Name     *string
-	Cost     *float64
-	Quantity *int
+	Name             *string
+	Cost             *money.Money
+	TaxClass         *string
+	ReorderThreshold *int
+}
+
+// PriceChange represents a change to the cost of a product scheduled to
+// take effect at a later time. PreviousCost and DateResolved are set once
+// the change is applied, DateResolved also when it's cancelled.
+type PriceChange struct {
+	ID           uuid.UUID
+	TenantID     uuid.UUID
+	ProductID    uuid.UUID
+	Cost         money.Money
+	PreviousCost money.Money
+	Status       ChangeStatus
+	EffectiveAt  time.Time
+	CreatedBy    uuid.UUID
+	DateCreated  time.Time
+	DateResolved time.Time
+}
+
+// NewPriceChange is what we require from clients when scheduling a change to
+// the cost of a product.
+type NewPriceChange struct {
+	Cost        money.Money
+	EffectiveAt time.Time
+}
+
+// PriceRecord represents an entry in the price history of a product. The
+// first entry of a product has no previous cost. ChangeID is set when the
+// cost was set by a scheduled change.
+type PriceRecord struct {
+	ID           uuid.UUID
+	TenantID     uuid.UUID
+	ProductID    uuid.UUID
+	Cost         money.Money
+	PreviousCost money.Money
+	ChangeID     uuid.UUID
+	ActorID      uuid.UUID
+	DateCreated  time.Time
+}
+
+// Movement represents an immutable entry in the inventory ledger. Quantity is
+// positive for stock coming in and negative for stock going out. Reason is
+// only set for adjustments and OrderID for sales and returns.
+type Movement struct {
+	ID          uuid.UUID
+	TenantID    uuid.UUID
+	ProductID   uuid.UUID
+	Kind        MovementKind
+	Quantity    int
+	Reason      Reason
+	OrderID     uuid.UUID
+	ActorID     uuid.UUID
+	DateCreated time.Time
+}
+
+// NewAdjustment is what we require to correct the stock of a product.
+type NewAdjustment struct {
+	Quantity int
+	Reason   Reason
+}
+
+// Discrepancy represents a product whose stock on hand disagrees with the sum
+// of its movements in the ledger.
+type Discrepancy struct {
+	ProductID      uuid.UUID
+	TenantID       uuid.UUID
+	Name           string
+	Quantity       int
+	LedgerQuantity int
 }
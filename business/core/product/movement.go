@@ -0,0 +1,134 @@
+package product
+
+import (
+	"errors"
+)
+
+// Set of kinds of stock movements.
+var (
+	MovementReceipt    = MovementKind{"RECEIPT"}
+	MovementSale       = MovementKind{"SALE"}
+	MovementAdjustment = MovementKind{"ADJUSTMENT"}
+	MovementReturn     = MovementKind{"RETURN"}
+)
+
+// Set of known movement kinds.
+var movementKinds = map[string]MovementKind{
+	MovementReceipt.name:    MovementReceipt,
+	MovementSale.name:       MovementSale,
+	MovementAdjustment.name: MovementAdjustment,
+	MovementReturn.name:     MovementReturn,
+}
+
+// MovementKind represents why stock came in or went out.
+type MovementKind struct {
+	name string
+}
+
+// ParseMovementKind parses the string value and returns a movement kind if
+// one exists.
+func ParseMovementKind(value string) (MovementKind, error) {
+	kind, exists := movementKinds[value]
+	if !exists {
+		return MovementKind{}, errors.New("invalid movement kind")
+	}
+	return kind, nil
+}
+
+// MustParseMovementKind parses the string value and returns a movement kind
+// if one exists. If an error occurs the function panics.
+func MustParseMovementKind(value string) MovementKind {
+	kind, err := ParseMovementKind(value)
+	if err != nil {
+		panic(err)
+	}
+	return kind
+}
+
+// Name returns the name of the movement kind.
+func (k MovementKind) Name() string {
+	return k.name
+}
+
+// UnmarshalText implements the unmarshal interface for JSON conversions.
+func (k *MovementKind) UnmarshalText(data []byte) error {
+	k.name = string(data)
+	return nil
+}
+
+// MarshalText implements the marshal interface for JSON conversions.
+func (k MovementKind) MarshalText() ([]byte, error) {
+	return []byte(k.name), nil
+}
+
+// Equal provides support for the go-cmp package and testing.
+func (k MovementKind) Equal(k2 MovementKind) bool {
+	return k.name == k2.name
+}
+
+// =============================================================================
+
+// Set of reasons stock is adjusted for. ReasonOpening marks the balances
+// recorded when the ledger was introduced and can't be used for new
+// adjustments.
+var (
+	ReasonDamaged = Reason{"DAMAGED"}
+	ReasonLost    = Reason{"LOST"}
+	ReasonFound   = Reason{"FOUND"}
+	ReasonCount   = Reason{"COUNT"}
+	ReasonOpening = Reason{"OPENING"}
+)
+
+// Set of known reasons.
+var reasons = map[string]Reason{
+	ReasonDamaged.name: ReasonDamaged,
+	ReasonLost.name:    ReasonLost,
+	ReasonFound.name:   ReasonFound,
+	ReasonCount.name:   ReasonCount,
+	ReasonOpening.name: ReasonOpening,
+}
+
+// Reason represents the reason code of a stock adjustment.
+type Reason struct {
+	name string
+}
+
+// ParseReason parses the string value and returns a reason if one exists.
+func ParseReason(value string) (Reason, error) {
+	reason, exists := reasons[value]
+	if !exists {
+		return Reason{}, errors.New("invalid reason")
+	}
+	return reason, nil
+}
+
+// MustParseReason parses the string value and returns a reason if one
+// exists. If an error occurs the function panics.
+func MustParseReason(value string) Reason {
+	reason, err := ParseReason(value)
+	if err != nil {
+		panic(err)
+	}
+	return reason
+}
+
+// Name returns the name of the reason.
+func (r Reason) Name() string {
+	return r.name
+}
+
+// UnmarshalText implements the unmarshal interface for JSON conversions.
+func (r *Reason) UnmarshalText(data []byte) error {
+	r.name = string(data)
+	return nil
+}
+
+// MarshalText implements the marshal interface for JSON conversions.
+func (r Reason) MarshalText() ([]byte, error) {
+	return []byte(r.name), nil
+}
+
+// Equal provides support for the go-cmp package and testing.
+func (r Reason) Equal(r2 Reason) bool {
+	return r.name == r2.name
+}
@@ -0,0 +1,169 @@
+package product
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aleury/service/business/core/audit"
+	"github.com/aleury/service/business/core/exchange"
+	"github.com/aleury/service/business/data/money"
+	"github.com/google/uuid"
+)
+
+// SetPrice sets the explicit price of a product in the currency of the
+// amount, replacing the price it already has in that currency.
+func (c *Core) SetPrice(ctx context.Context, p Product, amount money.Money) (Price, error) {
+	cur := amount.Currency()
+
+	switch {
+	case cur.Equal(p.Cost.Currency()):
+		return Price{}, fmt.Errorf("currency[%s]: %w", cur.Code(), ErrPriceCurrency)
+	case amount.IsNegative():
+		return Price{}, fmt.Errorf("amount[%s]: %w", amount, ErrNegativePrice)
+	}
+
+	price := Price{
+		ProductID:   p.ID,
+		TenantID:    p.TenantID,
+		Amount:      amount,
+		DateUpdated: time.Now(),
+	}
+
+	tran := func(ctx context.Context) error {
+		before, err := c.storer.QueryPricesByCurrency(ctx, []uuid.UUID{p.ID}, cur)
+		if err != nil {
+			return fmt.Errorf("query: %w", err)
+		}
+
+		if err := c.storer.UpsertPrice(ctx, price); err != nil {
+			return fmt.Errorf("upsert: %w", err)
+		}
+
+		return c.recordPrice(ctx, p, before, []Price{price})
+	}
+
+	if err := c.storer.WithinTran(ctx, tran); err != nil {
+		return Price{}, err
+	}
+
+	return price, nil
+}
+
+// DeletePrice removes the explicit price of a product in the currency, after
+// which the product is quoted at its converted cost.
+func (c *Core) DeletePrice(ctx context.Context, p Product, currency money.Currency) error {
+	tran := func(ctx context.Context) error {
+		before, err := c.storer.QueryPricesByCurrency(ctx, []uuid.UUID{p.ID}, currency)
+		if err != nil {
+			return fmt.Errorf("query: %w", err)
+		}
+
+		if len(before) == 0 {
+			return nil
+		}
+
+		if err := c.storer.DeletePrice(ctx, p.ID, currency); err != nil {
+			return fmt.Errorf("delete: %w", err)
+		}
+
+		return c.recordPrice(ctx, p, before, nil)
+	}
+
+	return c.storer.WithinTran(ctx, tran)
+}
+
+// QueryPrices retrieves the explicit prices of a product ordered by
+// currency.
+func (c *Core) QueryPrices(ctx context.Context, productID uuid.UUID) ([]Price, error) {
+	prices, err := c.storer.QueryPrices(ctx, productID)
+	if err != nil {
+		return nil, fmt.Errorf("query: productID[%s]: %w", productID, err)
+	}
+	return prices, nil
+}
+
+// Quote returns what each of the products sells for in the currency, in the
+// order of the products. Products without an explicit price are quoted at
+// their cost converted at the recorded exchange rate, and an
+// exchange.ErrNotFound is returned when there is no such rate.
+func (c *Core) Quote(ctx context.Context, prds []Product, currency money.Currency) ([]Quote, error) {
+	ids := make([]uuid.UUID, len(prds))
+	for i, p := range prds {
+		ids[i] = p.ID
+	}
+
+	prices, err := c.storer.QueryPricesByCurrency(ctx, ids, currency)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	explicit := make(map[uuid.UUID]money.Money, len(prices))
+	for _, price := range prices {
+		explicit[price.ProductID] = price.Amount
+	}
+
+	rates := make(map[money.Currency]exchange.Rate)
+
+	quotes := make([]Quote, len(prds))
+	for i, p := range prds {
+		if amount, exists := explicit[p.ID]; exists {
+			quotes[i] = Quote{
+				ProductID: p.ID,
+				Amount:    amount,
+			}
+			continue
+		}
+
+		from := p.Cost.Currency()
+
+		rate, exists := rates[from]
+		if !exists {
+			rate, err = c.exchCore.QueryRate(ctx, from, currency)
+			if err != nil {
+				return nil, fmt.Errorf("rate: productID[%s]: %w", p.ID, err)
+			}
+			rates[from] = rate
+		}
+
+		amount, err := p.Cost.Convert(currency, rate.Rate)
+		if err != nil {
+			return nil, fmt.Errorf("convert: productID[%s]: %w", p.ID, err)
+		}
+
+		quotes[i] = Quote{
+			ProductID: p.ID,
+			Amount:    amount,
+			Converted: !from.Equal(currency),
+			Rate:      rate,
+		}
+	}
+
+	return quotes, nil
+}
+
+// recordPrice writes an audit entry for a change to the explicit prices of a
+// product. Prices are recorded as fields named after their currency.
+func (c *Core) recordPrice(ctx context.Context, p Product, before []Price, after []Price) error {
+	fields := func(prices []Price) map[string]any {
+		m := make(map[string]any, len(prices))
+		for _, price := range prices {
+			m["price"+price.Amount.Currency().Code()] = price.Amount
+		}
+		return m
+	}
+
+	ne := audit.NewEntry{
+		Action:     audit.ActionUpdate,
+		EntityType: audit.EntityProduct,
+		EntityID:   p.ID,
+		Before:     fields(before),
+		After:      fields(after),
+	}
+
+	if _, err := c.auditCore.Record(ctx, ne); err != nil {
+		return fmt.Errorf("audit: %w", err)
+	}
+
+	return nil
+}
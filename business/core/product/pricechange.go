@@ -0,0 +1,207 @@
+package product
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aleury/service/business/core/audit"
+	"github.com/aleury/service/business/core/tenant"
+	"github.com/google/uuid"
+)
+
+// SchedulePriceChange schedules the cost of a product to change at the
+// effective time, which has to be in the future. The change is applied by
+// ApplyPriceChanges once it's due.
+func (c *Core) SchedulePriceChange(ctx context.Context, p Product, npc NewPriceChange) (PriceChange, error) {
+	now := time.Now()
+
+	if !npc.EffectiveAt.After(now) {
+		return PriceChange{}, fmt.Errorf("effectiveAt[%s]: %w", npc.EffectiveAt, ErrEffectivePast)
+	}
+
+	if err := checkCost(npc.Cost); err != nil {
+		return PriceChange{}, err
+	}
+
+	pc := PriceChange{
+		ID:          uuid.New(),
+		TenantID:    p.TenantID,
+		ProductID:   p.ID,
+		Cost:        npc.Cost,
+		Status:      ChangeScheduled,
+		EffectiveAt: npc.EffectiveAt,
+		CreatedBy:   audit.GetActorID(ctx),
+		DateCreated: now,
+	}
+
+	if err := c.storer.CreatePriceChange(ctx, pc); err != nil {
+		return PriceChange{}, fmt.Errorf("create: %w", err)
+	}
+
+	return pc, nil
+}
+
+// CancelPriceChange cancels a scheduled price change. It fails with
+// ErrChangeResolved when the change was already applied or cancelled.
+func (c *Core) CancelPriceChange(ctx context.Context, pc PriceChange) (PriceChange, error) {
+	if pc.Status != ChangeScheduled {
+		return PriceChange{}, ErrChangeResolved
+	}
+
+	pc.Status = ChangeCancelled
+	pc.DateResolved = time.Now()
+
+	if err := c.storer.ResolvePriceChange(ctx, pc); err != nil {
+		return PriceChange{}, fmt.Errorf("resolve: changeID[%s]: %w", pc.ID, err)
+	}
+
+	return pc, nil
+}
+
+// QueryPriceChanges retrieves the price changes scheduled for a product,
+// latest effective first.
+func (c *Core) QueryPriceChanges(ctx context.Context, productID uuid.UUID, pageNumber int, rowsPerPage int) ([]PriceChange, error) {
+	pcs, err := c.storer.QueryPriceChanges(ctx, productID, pageNumber, rowsPerPage)
+	if err != nil {
+		return nil, fmt.Errorf("query: productID[%s]: %w", productID, err)
+	}
+	return pcs, nil
+}
+
+// CountPriceChanges returns the number of price changes scheduled for a
+// product.
+func (c *Core) CountPriceChanges(ctx context.Context, productID uuid.UUID) (int, error) {
+	count, err := c.storer.CountPriceChanges(ctx, productID)
+	if err != nil {
+		return 0, fmt.Errorf("count: productID[%s]: %w", productID, err)
+	}
+	return count, nil
+}
+
+// QueryPriceChangeByID finds the price change identified by a given ID.
+func (c *Core) QueryPriceChangeByID(ctx context.Context, changeID uuid.UUID) (PriceChange, error) {
+	pc, err := c.storer.QueryPriceChangeByID(ctx, changeID)
+	if err != nil {
+		return PriceChange{}, fmt.Errorf("query: changeID[%s]: %w", changeID, err)
+	}
+	return pc, nil
+}
+
+// ApplyPriceChanges applies the scheduled price changes that are due at the
+// specified time, oldest first, and returns how many were applied. Each
+// change is applied in a transaction of its own on behalf of the user who
+// scheduled it. Changes to products that are deleted wait until the product
+// is restored, and changes to products updated while being applied are
+// retried on the next call.
+func (c *Core) ApplyPriceChanges(ctx context.Context, now time.Time) (int, error) {
+	pcs, err := c.storer.QueryDuePriceChanges(ctx, now)
+	if err != nil {
+		return 0, fmt.Errorf("query: now[%s]: %w", now, err)
+	}
+
+	var applied int
+	for _, pc := range pcs {
+		ctx := tenant.SetTenantID(ctx, pc.TenantID)
+		ctx = audit.SetActorID(ctx, pc.CreatedBy)
+
+		if err := c.applyPriceChange(ctx, pc, now); err != nil {
+			switch {
+			case errors.Is(err, ErrChangeResolved), errors.Is(err, ErrConflict):
+			default:
+				c.log.Errorw("apply price change", "change_id", pc.ID, "product_id", pc.ProductID, "ERROR", err)
+			}
+			continue
+		}
+
+		applied++
+	}
+
+	return applied, nil
+}
+
+// QueryPriceHistory retrieves the costs a product had, newest first.
+func (c *Core) QueryPriceHistory(ctx context.Context, productID uuid.UUID, pageNumber int, rowsPerPage int) ([]PriceRecord, error) {
+	prs, err := c.storer.QueryPriceHistory(ctx, productID, pageNumber, rowsPerPage)
+	if err != nil {
+		return nil, fmt.Errorf("query: productID[%s]: %w", productID, err)
+	}
+	return prs, nil
+}
+
+// CountPriceHistory returns the number of entries in the price history of a
+// product.
+func (c *Core) CountPriceHistory(ctx context.Context, productID uuid.UUID) (int, error) {
+	count, err := c.storer.CountPriceHistory(ctx, productID)
+	if err != nil {
+		return 0, fmt.Errorf("count: productID[%s]: %w", productID, err)
+	}
+	return count, nil
+}
+
+// =============================================================================
+
+// applyPriceChange sets the cost of the product to the one of the change and
+// marks the change applied, both or neither. The change is resolved first so
+// a concurrent cancellation either waits for it or wins.
+func (c *Core) applyPriceChange(ctx context.Context, pc PriceChange, now time.Time) error {
+	tran := func(ctx context.Context) error {
+		p, err := c.storer.QueryByID(ctx, pc.ProductID)
+		if err != nil {
+			return fmt.Errorf("query: productID[%s]: %w", pc.ProductID, err)
+		}
+
+		pc.PreviousCost = p.Cost
+		pc.Status = ChangeApplied
+		pc.DateResolved = now
+
+		if err := c.storer.ResolvePriceChange(ctx, pc); err != nil {
+			return fmt.Errorf("resolve: changeID[%s]: %w", pc.ID, err)
+		}
+
+		before := p
+
+		p.Cost = pc.Cost
+		p.Version++
+		p.DateUpdated = now
+
+		if err := c.storer.Update(ctx, p); err != nil {
+			return fmt.Errorf("update: productID[%s]: %w", p.ID, err)
+		}
+
+		if err := c.recordCost(ctx, before, p, pc.ID); err != nil {
+			return err
+		}
+
+		return c.record(ctx, audit.ActionUpdate, before, p)
+	}
+
+	return c.storer.WithinTran(ctx, tran)
+}
+
+// recordCost adds the cost of the product to its price history when it
+// differs from the cost it had before. A zero value before represents a new
+// product.
+func (c *Core) recordCost(ctx context.Context, before Product, after Product, changeID uuid.UUID) error {
+	if before.ID != uuid.Nil && before.Cost.Equal(after.Cost) {
+		return nil
+	}
+
+	pr := PriceRecord{
+		ID:           uuid.New(),
+		TenantID:     after.TenantID,
+		ProductID:    after.ID,
+		Cost:         after.Cost,
+		PreviousCost: before.Cost,
+		ChangeID:     changeID,
+		ActorID:      audit.GetActorID(ctx),
+		DateCreated:  after.DateUpdated,
+	}
+
+	if err := c.storer.CreatePriceRecord(ctx, pr); err != nil {
+		return fmt.Errorf("pricehistory: %w", err)
+	}
+
+	return nil
+}
@@ -4,9 +4,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
+	"strings"
 	"time"
 
+	"github.com/aleury/service/business/core/audit"
+	"github.com/aleury/service/business/core/exchange"
 	"github.com/aleury/service/business/core/user"
+	"github.com/aleury/service/business/data/money"
 	"github.com/aleury/service/business/data/order"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
@@ -14,34 +19,84 @@ import (
 
 // Set of error variables for CRUD operations.
 var (
-	ErrNotFound = errors.New("product not found")
+	ErrNotFound          = errors.New("product not found")
+	ErrInsufficientStock = errors.New("insufficient stock")
+	ErrInvalidQuantity   = errors.New("invalid quantity")
+	ErrPriceCurrency     = errors.New("price must be in a currency other than the cost")
+	ErrCostCurrency      = errors.New("cost must be in the store currency")
+	ErrNegativePrice     = errors.New("price must be 0 or greater")
+	ErrInvalidReason     = errors.New("reason can't be used for adjustments")
+	ErrConflict          = errors.New("product was changed by another request")
+	ErrUniqueSKU         = errors.New("sku is not unique")
+	ErrHasVariants       = errors.New("product is stocked through its variants")
+	ErrNoOptions         = errors.New("product has no options to vary in")
+	ErrOptionValues      = errors.New("option values must pick one value for every option of the product")
+	ErrDuplicateVariant  = errors.New("product already has a variant with these option values")
+	ErrVariantName       = errors.New("variants are named after their product")
+	ErrChangeNotFound    = errors.New("price change not found")
+	ErrChangeResolved    = errors.New("price change was already applied or cancelled")
+	ErrEffectivePast     = errors.New("price change must take effect in the future")
 )
 
+// DefaultTaxClass is the tax class of products that weren't given one.
+const DefaultTaxClass = "STANDARD"
+
 // Storer interface declares the behavior this package needs to persist and
 // retrieve data.
 type Storer interface {
+	WithinTran(ctx context.Context, fn func(ctx context.Context) error) error
 	Create(ctx context.Context, p Product) error
 	Update(ctx context.Context, p Product) error
 	Delete(ctx context.Context, p Product) error
+	Restore(ctx context.Context, productID uuid.UUID, now time.Time) (Product, error)
+	MoveStock(ctx context.Context, mv Movement) (Product, error)
+	Purge(ctx context.Context, before time.Time) error
 	Query(ctx context.Context, filter QueryFilter, orderBy order.By, pageNumber int, rowsPerPage int) ([]Product, error)
 	Count(ctx context.Context, filter QueryFilter) (int, error)
 	QueryByID(ctx context.Context, productID uuid.UUID) (Product, error)
+	QueryBySKU(ctx context.Context, sku string) (Product, error)
+	QueryVariants(ctx context.Context, parentIDs []uuid.UUID) ([]Product, error)
+	Highlight(ctx context.Context, productIDs []uuid.UUID, text string) (map[uuid.UUID]string, error)
+	RenameVariants(ctx context.Context, p Product) error
 	QueryByUserID(ctx context.Context, userID uuid.UUID) ([]Product, error)
+	QueryByIDAsOf(ctx context.Context, productID uuid.UUID, asOf time.Time) (Product, error)
+	QueryHistory(ctx context.Context, productID uuid.UUID, pageNumber int, rowsPerPage int) ([]Version, error)
+	CountHistory(ctx context.Context, productID uuid.UUID) (int, error)
+	UpsertPrice(ctx context.Context, price Price) error
+	DeletePrice(ctx context.Context, productID uuid.UUID, currency money.Currency) error
+	QueryPrices(ctx context.Context, productID uuid.UUID) ([]Price, error)
+	QueryPricesByCurrency(ctx context.Context, productIDs []uuid.UUID, currency money.Currency) ([]Price, error)
+	QueryMovements(ctx context.Context, productID uuid.UUID, pageNumber int, rowsPerPage int) ([]Movement, error)
+	CountMovements(ctx context.Context, productID uuid.UUID) (int, error)
+	QueryDiscrepancies(ctx context.Context) ([]Discrepancy, error)
+	CreatePriceChange(ctx context.Context, pc PriceChange) error
+	ResolvePriceChange(ctx context.Context, pc PriceChange) error
+	QueryPriceChanges(ctx context.Context, productID uuid.UUID, pageNumber int, rowsPerPage int) ([]PriceChange, error)
+	CountPriceChanges(ctx context.Context, productID uuid.UUID) (int, error)
+	QueryPriceChangeByID(ctx context.Context, changeID uuid.UUID) (PriceChange, error)
+	QueryDuePriceChanges(ctx context.Context, now time.Time) ([]PriceChange, error)
+	CreatePriceRecord(ctx context.Context, pr PriceRecord) error
+	QueryPriceHistory(ctx context.Context, productID uuid.UUID, pageNumber int, rowsPerPage int) ([]PriceRecord, error)
+	CountPriceHistory(ctx context.Context, productID uuid.UUID) (int, error)
 }
 
 // Core manages the set of APIs for product access.
 type Core struct {
-	log      *zap.SugaredLogger
-	userCore *user.Core
-	storer   Storer
+	log       *zap.SugaredLogger
+	userCore  *user.Core
+	auditCore *audit.Core
+	exchCore  *exchange.Core
+	storer    Storer
 }
 
 // NewCore constructs a Core for product api access.
-func NewCore(log *zap.SugaredLogger, userCore *user.Core, storer Storer) *Core {
+func NewCore(log *zap.SugaredLogger, userCore *user.Core, auditCore *audit.Core, exchCore *exchange.Core, storer Storer) *Core {
 	core := Core{
-		log:      log,
-		userCore: userCore,
-		storer:   storer,
+		log:       log,
+		userCore:  userCore,
+		auditCore: auditCore,
+		exchCore:  exchCore,
+		storer:    storer,
 	}
 	return &core
 }
@@ -49,50 +104,251 @@ func NewCore(log *zap.SugaredLogger, userCore *user.Core, storer Storer) *Core {
 // Create adds a Product to the database. It returns the crated Product with
 // fields like ID and DateCreated populated.
 func (c *Core) Create(ctx context.Context, np NewProduct) (Product, error) {
+	if len(np.Options) > 0 && (np.Quantity != 0 || np.ReorderThreshold != 0) {
+		return Product{}, ErrHasVariants
+	}
+
+	if err := checkCost(np.Cost); err != nil {
+		return Product{}, err
+	}
+
+	usr, err := c.userCore.QueryByID(ctx, np.UserID)
+	if err != nil {
+		return Product{}, fmt.Errorf("user: %w", err)
+	}
+
 	now := time.Now()
 
 	p := Product{
 		ID:          uuid.New(),
+		TenantID:    usr.TenantID,
 		Name:        np.Name,
 		Cost:        np.Cost,
-		Quantity:    np.Quantity,
 		UserID:      np.UserID,
+		Version:     1,
 		DateCreated: now,
 		DateUpdated: now,
+		TaxClass:    normalizeTaxClass(np.TaxClass),
+		SKU:         np.SKU,
+		Options:     np.Options,
+
+		ReorderThreshold: np.ReorderThreshold,
+	}
+
+	return c.create(ctx, p, np.Quantity)
+}
+
+// CreateVariant adds a variant to a product with options. The variant is a
+// product of its own, named after the product and owned by the same user,
+// with its own cost and stock. It fails with ErrDuplicateVariant when the
+// product already has a variant with the same option values.
+func (c *Core) CreateVariant(ctx context.Context, parent Product, nv NewVariant) (Product, error) {
+	if !parent.HasVariants() {
+		return Product{}, ErrNoOptions
+	}
+
+	if err := checkCost(nv.Cost); err != nil {
+		return Product{}, err
 	}
 
-	if err := c.storer.Create(ctx, p); err != nil {
-		return Product{}, fmt.Errorf("create: %w", err)
+	if len(nv.OptionValues) != len(parent.Options) {
+		return Product{}, ErrOptionValues
+	}
+	for _, opt := range parent.Options {
+		if nv.OptionValues[opt] == "" {
+			return Product{}, fmt.Errorf("option[%s]: %w", opt, ErrOptionValues)
+		}
+	}
+
+	siblings, err := c.storer.QueryVariants(ctx, []uuid.UUID{parent.ID})
+	if err != nil {
+		return Product{}, fmt.Errorf("query: parentID[%s]: %w", parent.ID, err)
+	}
+	for _, sib := range siblings {
+		if maps.Equal(sib.OptionValues, nv.OptionValues) {
+			return Product{}, fmt.Errorf("variant[%s]: %w", sib.SKU, ErrDuplicateVariant)
+		}
+	}
+
+	now := time.Now()
+
+	p := Product{
+		ID:           uuid.New(),
+		TenantID:     parent.TenantID,
+		Name:         parent.Name,
+		Cost:         nv.Cost,
+		UserID:       parent.UserID,
+		Version:      1,
+		DateCreated:  now,
+		DateUpdated:  now,
+		TaxClass:     parent.TaxClass,
+		ParentID:     parent.ID,
+		SKU:          nv.SKU,
+		OptionValues: nv.OptionValues,
+
+		ReorderThreshold: nv.ReorderThreshold,
+	}
+
+	return c.create(ctx, p, nv.Quantity)
+}
+
+// create stores a new product and receives its initial stock.
+func (c *Core) create(ctx context.Context, p Product, quantity int) (Product, error) {
+	// The product starts out with nothing on hand so the initial stock is
+	// recorded in the ledger like any other receipt.
+	tran := func(ctx context.Context) error {
+		if err := c.storer.Create(ctx, p); err != nil {
+			return fmt.Errorf("create: %w", err)
+		}
+
+		if err := c.recordCost(ctx, Product{}, p, uuid.Nil); err != nil {
+			return err
+		}
+
+		if err := c.record(ctx, audit.ActionCreate, Product{}, p); err != nil {
+			return err
+		}
+
+		if quantity == 0 {
+			return nil
+		}
+
+		var err error
+		if p, _, err = c.move(ctx, p.ID, MovementReceipt, quantity, Reason{}, uuid.Nil); err != nil {
+			return fmt.Errorf("receive: %w", err)
+		}
+
+		return nil
+	}
+
+	if err := c.storer.WithinTran(ctx, tran); err != nil {
+		return Product{}, err
 	}
 
 	return p, nil
 }
 
 // Update modifies data about a Product. It will error if the specified ID is
-// invalid or does not reference an existing Product.
+// invalid or does not reference an existing Product, and with ErrConflict
+// when the product was updated by someone else since it was read.
 func (c *Core) Update(ctx context.Context, p Product, up UpdateProduct) (Product, error) {
+	if up.Name != nil && p.IsVariant() {
+		return Product{}, ErrVariantName
+	}
+
+	if up.ReorderThreshold != nil && *up.ReorderThreshold != 0 && p.HasVariants() {
+		return Product{}, ErrHasVariants
+	}
+
+	if up.Cost != nil {
+		if err := checkCost(*up.Cost); err != nil {
+			return Product{}, err
+		}
+	}
+
+	before := p
+
 	if up.Name != nil {
 		p.Name = *up.Name
 	}
 	if up.Cost != nil {
 		p.Cost = *up.Cost
 	}
-	if up.Quantity != nil {
-		p.Quantity = *up.Quantity
+	if up.TaxClass != nil {
+		p.TaxClass = normalizeTaxClass(*up.TaxClass)
 	}
+	if up.ReorderThreshold != nil {
+		p.ReorderThreshold = *up.ReorderThreshold
+	}
+	p.Version++
 	p.DateUpdated = time.Now()
 
-	if err := c.storer.Update(ctx, p); err != nil {
-		return Product{}, fmt.Errorf("update: %w", err)
+	tran := func(ctx context.Context) error {
+		if err := c.storer.Update(ctx, p); err != nil {
+			return fmt.Errorf("update: %w", err)
+		}
+
+		if p.HasVariants() && p.Name != before.Name {
+			if err := c.storer.RenameVariants(ctx, p); err != nil {
+				return fmt.Errorf("renamevariants: %w", err)
+			}
+		}
+
+		if err := c.recordCost(ctx, before, p, uuid.Nil); err != nil {
+			return err
+		}
+
+		return c.record(ctx, audit.ActionUpdate, before, p)
+	}
+
+	if err := c.storer.WithinTran(ctx, tran); err != nil {
+		return Product{}, err
 	}
 
 	return p, nil
 }
 
-// Delete removes the product identified by a given ID.
+// Delete marks the product identified by a given ID as deleted. The product
+// is no longer returned by queries but can be restored until it is purged.
 func (c *Core) Delete(ctx context.Context, p Product) error {
-	if err := c.storer.Delete(ctx, p); err != nil {
-		return fmt.Errorf("delete: %w", err)
+	before := p
+	p.DateDeleted = time.Now()
+
+	tran := func(ctx context.Context) error {
+		if err := c.storer.Delete(ctx, p); err != nil {
+			return fmt.Errorf("delete: %w", err)
+		}
+		return c.record(ctx, audit.ActionDelete, before, p)
+	}
+
+	return c.storer.WithinTran(ctx, tran)
+}
+
+// Restore brings back a product that was previously deleted.
+func (c *Core) Restore(ctx context.Context, productID uuid.UUID) (Product, error) {
+	var p Product
+
+	tran := func(ctx context.Context) error {
+		var filter QueryFilter
+		filter.WithProductID(productID)
+		filter.WithIncludeDeleted(true)
+
+		prds, err := c.storer.Query(ctx, filter, DefaultOrderBy, 1, 1)
+		if err != nil {
+			return fmt.Errorf("query: productID[%s]: %w", productID, err)
+		}
+		if len(prds) == 0 {
+			return fmt.Errorf("query: productID[%s]: %w", productID, ErrNotFound)
+		}
+
+		// A variant can only be restored while its product exists.
+		if prds[0].IsVariant() {
+			if _, err := c.storer.QueryByID(ctx, prds[0].ParentID); err != nil {
+				return fmt.Errorf("query: parentID[%s]: %w", prds[0].ParentID, err)
+			}
+		}
+
+		p, err = c.storer.Restore(ctx, productID, time.Now())
+		if err != nil {
+			return fmt.Errorf("restore: productID[%s]: %w", productID, err)
+		}
+
+		return c.record(ctx, audit.ActionRestore, prds[0], p)
+	}
+
+	if err := c.storer.WithinTran(ctx, tran); err != nil {
+		return Product{}, err
+	}
+
+	return p, nil
+}
+
+// Purge permanently removes products that were deleted before the specified
+// time. The inventory ledger is append-only, so products that ever had stock
+// moved, themselves or through their variants, are kept.
+func (c *Core) Purge(ctx context.Context, before time.Time) error {
+	if err := c.storer.Purge(ctx, before); err != nil {
+		return fmt.Errorf("purge: before[%s]: %w", before, err)
 	}
 	return nil
 }
@@ -120,6 +376,35 @@ func (c *Core) QueryByID(ctx context.Context, productID uuid.UUID) (Product, err
 	return product, nil
 }
 
+// QueryBySKU finds the product identified by a given SKU.
+func (c *Core) QueryBySKU(ctx context.Context, sku string) (Product, error) {
+	product, err := c.storer.QueryBySKU(ctx, sku)
+	if err != nil {
+		return Product{}, fmt.Errorf("query: sku[%s]: %w", sku, err)
+	}
+	return product, nil
+}
+
+// QueryVariants retrieves the variants of the specified products.
+func (c *Core) QueryVariants(ctx context.Context, parentIDs []uuid.UUID) ([]Product, error) {
+	variants, err := c.storer.QueryVariants(ctx, parentIDs)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+	return variants, nil
+}
+
+// Highlight returns the names of the specified products with the words
+// matching the search text marked, keyed by product ID. The snippets are
+// escaped HTML with the matching words wrapped in <mark> elements.
+func (c *Core) Highlight(ctx context.Context, productIDs []uuid.UUID, text string) (map[uuid.UUID]string, error) {
+	snippets, err := c.storer.Highlight(ctx, productIDs, text)
+	if err != nil {
+		return nil, fmt.Errorf("highlight: %w", err)
+	}
+	return snippets, nil
+}
+
 // QueryByUserID finds the products for a given user.
 func (c *Core) QueryByUserID(ctx context.Context, userID uuid.UUID) ([]Product, error) {
 	products, err := c.storer.QueryByUserID(ctx, userID)
@@ -128,3 +413,98 @@ func (c *Core) QueryByUserID(ctx context.Context, userID uuid.UUID) ([]Product,
 	}
 	return products, nil
 }
+
+// QueryByIDAsOf reconstructs the product identified by a given ID as it was
+// at the specified time. It returns ErrNotFound if the product did not exist
+// or was deleted at that time.
+func (c *Core) QueryByIDAsOf(ctx context.Context, productID uuid.UUID, asOf time.Time) (Product, error) {
+	product, err := c.storer.QueryByIDAsOf(ctx, productID, asOf)
+	if err != nil {
+		return Product{}, fmt.Errorf("query: productID[%s] asOf[%s]: %w", productID, asOf, err)
+	}
+	return product, nil
+}
+
+// QueryHistory retrieves the recorded versions of a product, newest first.
+func (c *Core) QueryHistory(ctx context.Context, productID uuid.UUID, pageNumber int, rowsPerPage int) ([]Version, error) {
+	versions, err := c.storer.QueryHistory(ctx, productID, pageNumber, rowsPerPage)
+	if err != nil {
+		return nil, fmt.Errorf("query: productID[%s]: %w", productID, err)
+	}
+	return versions, nil
+}
+
+// CountHistory returns the number of recorded versions of a product.
+func (c *Core) CountHistory(ctx context.Context, productID uuid.UUID) (int, error) {
+	count, err := c.storer.CountHistory(ctx, productID)
+	if err != nil {
+		return 0, fmt.Errorf("count: productID[%s]: %w", productID, err)
+	}
+	return count, nil
+}
+
+// =============================================================================
+
+// record writes an audit entry for the change between the before and after
+// versions of the product. A zero value before represents a new product.
+func (c *Core) record(ctx context.Context, action string, before Product, after Product) error {
+	ne := audit.NewEntry{
+		Action:     action,
+		EntityType: audit.EntityProduct,
+		EntityID:   after.ID,
+		After:      auditFields(after),
+	}
+	if before.ID != uuid.Nil {
+		ne.Before = auditFields(before)
+	}
+
+	if _, err := c.auditCore.Record(ctx, ne); err != nil {
+		return fmt.Errorf("audit: %w", err)
+	}
+
+	return nil
+}
+
+// auditFields returns the set of product fields that are tracked by the
+// audit log.
+func auditFields(p Product) map[string]any {
+	fields := map[string]any{
+		"name":     p.Name,
+		"cost":     p.Cost,
+		"quantity": p.Quantity,
+		"userId":   p.UserID,
+		"taxClass": p.TaxClass,
+	}
+	if p.SKU != "" {
+		fields["sku"] = p.SKU
+	}
+	if p.ReorderThreshold != 0 {
+		fields["reorderThreshold"] = p.ReorderThreshold
+	}
+	if !p.DateDeleted.IsZero() {
+		fields["dateDeleted"] = p.DateDeleted.UTC()
+	}
+
+	return fields
+}
+
+// normalizeTaxClass stores tax classes in upper case so they match the
+// classes of the tax rates however they were typed.
+func normalizeTaxClass(class string) string {
+	class = strings.ToUpper(strings.TrimSpace(class))
+	if class == "" {
+		return DefaultTaxClass
+	}
+	return class
+}
+
+// checkCost makes sure the cost is in the store currency. Costs are stored
+// without a currency and read back in the store currency, so any other
+// currency would be silently relabelled. Prices in other currencies are set
+// with SetPrice instead.
+func checkCost(cost money.Money) error {
+	if cost.Equal(money.Money{}) || cost.Currency().Equal(money.DefaultCurrency) {
+		return nil
+	}
+	return fmt.Errorf("cost[%s %s]: %w", cost, cost.Currency().Code(), ErrCostCurrency)
+}
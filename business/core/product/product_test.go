@@ -0,0 +1,633 @@
+package product_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"testing"
+	"time"
+
+	"github.com/aleury/service/business/core/product"
+	"github.com/aleury/service/business/core/user"
+	"github.com/aleury/service/business/data/dbtest"
+	"github.com/aleury/service/business/data/money"
+	"github.com/aleury/service/foundation/docker"
+	"github.com/google/uuid"
+)
+
+var c *docker.Container
+
+func TestMain(m *testing.M) {
+	var err error
+	c, err = dbtest.StartDB()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer dbtest.StopDB(c)
+
+	m.Run()
+}
+
+func Test_Product(t *testing.T) {
+	t.Run("ledger", ledger)
+	t.Run("variants", variants)
+	t.Run("search", search)
+	t.Run("pricechanges", priceChanges)
+	t.Run("cost", cost)
+}
+
+// =============================================================================
+
+func ledger(t *testing.T) {
+	test := dbtest.NewTest(t, c)
+	defer func() {
+		if r := recover(); r != nil {
+			t.Log(r)
+			t.Error(string(debug.Stack()))
+		}
+		test.Teardown()
+	}()
+
+	api := test.CoreAPIs
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	t.Log("Go seeding...")
+
+	usr, err := seed(ctx, api)
+	if err != nil {
+		t.Fatalf("Seeding error: %s", err)
+	}
+
+	// -------------------------------------------------------------------------
+
+	prd, err := api.Product.Create(ctx, product.NewProduct{
+		Name:     "Comic Books",
+		Cost:     money.MustParse("5.00", money.USD),
+		Quantity: 10,
+		UserID:   usr.ID,
+	})
+	if err != nil {
+		t.Fatalf("Should be able to create product: %s.", err)
+	}
+
+	prd, _, err = api.Product.Receive(ctx, prd, 5)
+	if err != nil {
+		t.Fatalf("Should be able to receive stock: %s.", err)
+	}
+
+	prd, mv, err := api.Product.Adjust(ctx, prd, product.NewAdjustment{Quantity: -3, Reason: product.ReasonDamaged})
+	if err != nil {
+		t.Fatalf("Should be able to adjust stock: %s.", err)
+	}
+
+	if prd.Quantity != 12 {
+		t.Errorf("Should have moved the stock of the product: got %d want %d", prd.Quantity, 12)
+	}
+
+	if mv.Kind != product.MovementAdjustment || mv.Quantity != -3 || mv.Reason != product.ReasonDamaged {
+		t.Errorf("Should have recorded the adjustment: %+v", mv)
+	}
+
+	if _, _, err := api.Product.Adjust(ctx, prd, product.NewAdjustment{Quantity: 1, Reason: product.ReasonOpening}); !errors.Is(err, product.ErrInvalidReason) {
+		t.Errorf("Should NOT be able to adjust stock for the opening balance: %v.", err)
+	}
+
+	if _, _, err := api.Product.Adjust(ctx, prd, product.NewAdjustment{Quantity: -13, Reason: product.ReasonLost}); !errors.Is(err, product.ErrInsufficientStock) {
+		t.Errorf("Should NOT be able to write off more than is on hand: %v.", err)
+	}
+
+	if _, _, err := api.Product.Receive(ctx, prd, 0); !errors.Is(err, product.ErrInvalidQuantity) {
+		t.Errorf("Should NOT be able to receive nothing: %v.", err)
+	}
+
+	// -------------------------------------------------------------------------
+
+	mvs, err := api.Product.QueryMovements(ctx, prd.ID, 1, 10)
+	if err != nil {
+		t.Fatalf("Should be able to query the movements of the product: %s.", err)
+	}
+
+	if len(mvs) != 3 {
+		t.Fatalf("Should have recorded every movement: got %d want %d", len(mvs), 3)
+	}
+
+	var sum int
+	for _, mv := range mvs {
+		sum += mv.Quantity
+	}
+
+	if sum != prd.Quantity {
+		t.Errorf("Should add up the movements to the stock on hand: got %d want %d", sum, prd.Quantity)
+	}
+
+	dcs, err := api.Product.Reconcile(ctx)
+	if err != nil {
+		t.Fatalf("Should be able to reconcile the ledger: %s.", err)
+	}
+
+	if len(dcs) != 0 {
+		t.Errorf("Should find no discrepancies: %+v", dcs)
+	}
+
+	// -------------------------------------------------------------------------
+
+	if _, err := test.DB.ExecContext(ctx, `DELETE FROM stock_movements WHERE product_id = $1`, prd.ID); err == nil {
+		t.Error("Should NOT be able to delete movements from the ledger.")
+	}
+
+	if _, err := test.DB.ExecContext(ctx, `UPDATE products SET quantity = quantity + 1 WHERE product_id = $1`, prd.ID); err != nil {
+		t.Fatalf("Should be able to change the stock outside of the ledger: %s.", err)
+	}
+
+	dcs, err = api.Product.Reconcile(ctx)
+	if err != nil {
+		t.Fatalf("Should be able to reconcile the ledger: %s.", err)
+	}
+
+	if len(dcs) != 1 || dcs[0].ProductID != prd.ID || dcs[0].Quantity != 13 || dcs[0].LedgerQuantity != 12 {
+		t.Errorf("Should report the stock changed outside of the ledger: %+v", dcs)
+	}
+
+	// -------------------------------------------------------------------------
+
+	if err := api.Product.Delete(ctx, prd); err != nil {
+		t.Fatalf("Should be able to delete product: %s.", err)
+	}
+
+	if err := api.Product.Purge(ctx, time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("Should be able to purge products: %s.", err)
+	}
+
+	if _, err := api.Product.Restore(ctx, prd.ID); err != nil {
+		t.Errorf("Should have kept the product with movements in the ledger: %v.", err)
+	}
+}
+
+func variants(t *testing.T) {
+	test := dbtest.NewTest(t, c)
+	defer func() {
+		if r := recover(); r != nil {
+			t.Log(r)
+			t.Error(string(debug.Stack()))
+		}
+		test.Teardown()
+	}()
+
+	api := test.CoreAPIs
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	t.Log("Go seeding...")
+
+	usr, err := seed(ctx, api)
+	if err != nil {
+		t.Fatalf("Seeding error: %s", err)
+	}
+
+	// -------------------------------------------------------------------------
+
+	np := product.NewProduct{
+		Name:     "T-Shirt",
+		Cost:     money.MustParse("15.00", money.USD),
+		Quantity: 10,
+		UserID:   usr.ID,
+		SKU:      "TSHIRT",
+		Options:  []string{"size", "color"},
+	}
+
+	if _, err := api.Product.Create(ctx, np); !errors.Is(err, product.ErrHasVariants) {
+		t.Errorf("Should NOT be able to stock a product with options: %v.", err)
+	}
+
+	np.Quantity = 0
+	shirt, err := api.Product.Create(ctx, np)
+	if err != nil {
+		t.Fatalf("Should be able to create a product with options: %s.", err)
+	}
+
+	nv := product.NewVariant{
+		SKU:          "TSHIRT-M-RED",
+		OptionValues: map[string]string{"size": "M", "color": "red"},
+		Cost:         money.MustParse("16.00", money.USD),
+		Quantity:     4,
+	}
+
+	red, err := api.Product.CreateVariant(ctx, shirt, nv)
+	if err != nil {
+		t.Fatalf("Should be able to create a variant: %s.", err)
+	}
+
+	if red.ParentID != shirt.ID || red.Name != shirt.Name || red.Quantity != 4 {
+		t.Errorf("Should create the variant under the product with its own stock: %+v", red)
+	}
+
+	nv.SKU = "TSHIRT-M-RED-2"
+	if _, err := api.Product.CreateVariant(ctx, shirt, nv); !errors.Is(err, product.ErrDuplicateVariant) {
+		t.Errorf("Should NOT be able to create a variant with the same option values: %v.", err)
+	}
+
+	nv.OptionValues = map[string]string{"size": "L"}
+	if _, err := api.Product.CreateVariant(ctx, shirt, nv); !errors.Is(err, product.ErrOptionValues) {
+		t.Errorf("Should NOT be able to create a variant missing an option: %v.", err)
+	}
+
+	nv.SKU = "TSHIRT-M-RED"
+	nv.OptionValues = map[string]string{"size": "L", "color": "red"}
+	if _, err := api.Product.CreateVariant(ctx, shirt, nv); !errors.Is(err, product.ErrUniqueSKU) {
+		t.Errorf("Should NOT be able to create a variant with the SKU of another product: %v.", err)
+	}
+
+	if _, err := api.Product.CreateVariant(ctx, red, nv); !errors.Is(err, product.ErrNoOptions) {
+		t.Errorf("Should NOT be able to create a variant of a product without options: %v.", err)
+	}
+
+	// -------------------------------------------------------------------------
+
+	if _, _, err := api.Product.Receive(ctx, shirt, 1); !errors.Is(err, product.ErrHasVariants) {
+		t.Errorf("Should NOT be able to receive stock for a product with options: %v.", err)
+	}
+
+	name := "Red T-Shirt"
+	if _, err := api.Product.Update(ctx, red, product.UpdateProduct{Name: &name}); !errors.Is(err, product.ErrVariantName) {
+		t.Errorf("Should NOT be able to rename a variant: %v.", err)
+	}
+
+	saved, err := api.Product.QueryBySKU(ctx, "TSHIRT-M-RED")
+	if err != nil {
+		t.Fatalf("Should be able to retrieve a variant by SKU: %s.", err)
+	}
+
+	if saved.ID != red.ID || saved.OptionValues["color"] != "red" {
+		t.Errorf("Should get back the variant with its option values: %+v", saved)
+	}
+
+	vars, err := api.Product.QueryVariants(ctx, []uuid.UUID{shirt.ID})
+	if err != nil {
+		t.Fatalf("Should be able to query the variants of a product: %s.", err)
+	}
+
+	if len(vars) != 1 || vars[0].ID != red.ID {
+		t.Errorf("Should find the variants of the product: %+v", vars)
+	}
+
+	var filter product.QueryFilter
+	filter.WithParentID(uuid.Nil)
+
+	n, err := api.Product.Count(ctx, filter)
+	if err != nil {
+		t.Fatalf("Should be able to count products: %s.", err)
+	}
+
+	if n != 1 {
+		t.Errorf("Should leave variants out of the products without a parent: got %d", n)
+	}
+}
+
+func search(t *testing.T) {
+	test := dbtest.NewTest(t, c)
+	defer func() {
+		if r := recover(); r != nil {
+			t.Log(r)
+			t.Error(string(debug.Stack()))
+		}
+		test.Teardown()
+	}()
+
+	api := test.CoreAPIs
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	t.Log("Go seeding...")
+
+	usr, err := seed(ctx, api)
+	if err != nil {
+		t.Fatalf("Seeding error: %s", err)
+	}
+
+	prds := make(map[string]product.Product)
+	for _, name := range []string{"Blue Comic Books", "Comic Strips", "Garden Hose", "<img src=x onerror=alert(1)> Comic"} {
+		prd, err := api.Product.Create(ctx, product.NewProduct{
+			Name:     name,
+			Cost:     money.MustParse("5.00", money.USD),
+			Quantity: 1,
+			UserID:   usr.ID,
+		})
+		if err != nil {
+			t.Fatalf("Should be able to create product: %s.", err)
+		}
+		prds[name] = prd
+	}
+
+	query := func(text string) []product.Product {
+		var filter product.QueryFilter
+		filter.WithSearch(text)
+
+		found, err := api.Product.Query(ctx, filter, product.DefaultOrderBy, 1, 10)
+		if err != nil {
+			t.Fatalf("Should be able to search products for %q: %s.", text, err)
+		}
+		return found
+	}
+
+	// -------------------------------------------------------------------------
+
+	found := query("comic books")
+	if len(found) == 0 || found[0].ID != prds["Blue Comic Books"].ID {
+		t.Fatalf("Should find the product matching every word first: %+v", found)
+	}
+
+	for _, prd := range found {
+		if prd.ID == prds["Garden Hose"].ID {
+			t.Errorf("Should NOT find a product matching none of the words: %+v", prd)
+		}
+	}
+
+	found = query("garden hoze")
+	if len(found) == 0 || found[0].ID != prds["Garden Hose"].ID {
+		t.Errorf("Should find the product close to a misspelled search: %+v", found)
+	}
+
+	// -------------------------------------------------------------------------
+
+	id := prds["Blue Comic Books"].ID
+
+	snippets, err := api.Product.Highlight(ctx, []uuid.UUID{id}, "comic")
+	if err != nil {
+		t.Fatalf("Should be able to highlight the search results: %s.", err)
+	}
+
+	if want := "Blue <mark>Comic</mark> Books"; snippets[id] != want {
+		t.Errorf("Should mark the words matching the search: got %q want %q", snippets[id], want)
+	}
+
+	id = prds["<img src=x onerror=alert(1)> Comic"].ID
+
+	snippets, err = api.Product.Highlight(ctx, []uuid.UUID{id}, "comic")
+	if err != nil {
+		t.Fatalf("Should be able to highlight the search results: %s.", err)
+	}
+
+	if want := "&lt;img src=x onerror=alert(1)&gt; <mark>Comic</mark>"; snippets[id] != want {
+		t.Errorf("Should escape the markup of the name: got %q want %q", snippets[id], want)
+	}
+}
+
+func priceChanges(t *testing.T) {
+	test := dbtest.NewTest(t, c)
+	defer func() {
+		if r := recover(); r != nil {
+			t.Log(r)
+			t.Error(string(debug.Stack()))
+		}
+		test.Teardown()
+	}()
+
+	api := test.CoreAPIs
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	t.Log("Go seeding...")
+
+	usr, err := seed(ctx, api)
+	if err != nil {
+		t.Fatalf("Seeding error: %s", err)
+	}
+
+	prd, err := api.Product.Create(ctx, product.NewProduct{
+		Name:     "Comic Books",
+		Cost:     money.MustParse("10.00", money.USD),
+		Quantity: 1,
+		UserID:   usr.ID,
+	})
+	if err != nil {
+		t.Fatalf("Should be able to create product: %s.", err)
+	}
+
+	apply := func(now time.Time) int {
+		n, err := api.Product.ApplyPriceChanges(ctx, now)
+		if err != nil {
+			t.Fatalf("Should be able to apply the due price changes: %s.", err)
+		}
+		return n
+	}
+
+	// -------------------------------------------------------------------------
+
+	npc := product.NewPriceChange{
+		Cost:        money.MustParse("12.00", money.USD),
+		EffectiveAt: time.Now().Add(-time.Minute),
+	}
+
+	if _, err := api.Product.SchedulePriceChange(ctx, prd, npc); !errors.Is(err, product.ErrEffectivePast) {
+		t.Errorf("Should NOT be able to schedule a price change in the past: %v.", err)
+	}
+
+	npc.EffectiveAt = time.Now().Add(time.Hour)
+	pc, err := api.Product.SchedulePriceChange(ctx, prd, npc)
+	if err != nil {
+		t.Fatalf("Should be able to schedule a price change: %s.", err)
+	}
+
+	if n := apply(time.Now()); n != 0 {
+		t.Errorf("Should NOT apply a price change before it is due: got %d", n)
+	}
+
+	due := npc.EffectiveAt.Add(time.Minute)
+
+	// -------------------------------------------------------------------------
+
+	if err := api.Product.Delete(ctx, prd); err != nil {
+		t.Fatalf("Should be able to delete product: %s.", err)
+	}
+
+	if n := apply(due); n != 0 {
+		t.Errorf("Should NOT apply a price change to a deleted product: got %d", n)
+	}
+
+	if prd, err = api.Product.Restore(ctx, prd.ID); err != nil {
+		t.Fatalf("Should be able to restore product: %s.", err)
+	}
+
+	// -------------------------------------------------------------------------
+
+	// Another request updates the product while the change is applied. The
+	// change is rolled back and stays scheduled.
+	tx, err := test.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		t.Fatalf("Should be able to begin a transaction: %s.", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE products SET version = version + 1 WHERE product_id = $1`, prd.ID); err != nil {
+		tx.Rollback()
+		t.Fatalf("Should be able to update the product: %s.", err)
+	}
+
+	applied := make(chan int, 1)
+	go func() {
+		n, _ := api.Product.ApplyPriceChanges(ctx, due)
+		applied <- n
+	}()
+
+	time.Sleep(200 * time.Millisecond)
+
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Should be able to commit the update: %s.", err)
+	}
+
+	if n := <-applied; n != 0 {
+		t.Errorf("Should NOT apply a price change to a product updated meanwhile: got %d", n)
+	}
+
+	saved, err := api.Product.QueryPriceChangeByID(ctx, pc.ID)
+	if err != nil {
+		t.Fatalf("Should be able to retrieve price change by ID: %s.", err)
+	}
+
+	if !saved.Status.Equal(product.ChangeScheduled) {
+		t.Errorf("Should leave a conflicting price change scheduled: got %s", saved.Status.Name())
+	}
+
+	// -------------------------------------------------------------------------
+
+	if n := apply(due); n != 1 {
+		t.Fatalf("Should apply the price change on the next try: got %d", n)
+	}
+
+	updated, err := api.Product.QueryByID(ctx, prd.ID)
+	if err != nil {
+		t.Fatalf("Should be able to retrieve product by ID: %s.", err)
+	}
+
+	if !updated.Cost.Equal(npc.Cost) {
+		t.Errorf("Should have changed the cost of the product: got %s want %s", updated.Cost, npc.Cost)
+	}
+
+	if saved, err = api.Product.QueryPriceChangeByID(ctx, pc.ID); err != nil {
+		t.Fatalf("Should be able to retrieve price change by ID: %s.", err)
+	}
+
+	if !saved.Status.Equal(product.ChangeApplied) || !saved.PreviousCost.Equal(prd.Cost) {
+		t.Errorf("Should have recorded the cost the change replaced: %+v", saved)
+	}
+
+	if n, err := api.Product.CountPriceHistory(ctx, prd.ID); err != nil || n != 2 {
+		t.Errorf("Should have added the new cost to the price history: got %d: %v", n, err)
+	}
+
+	if n := apply(due); n != 0 {
+		t.Errorf("Should NOT apply a price change twice: got %d", n)
+	}
+
+	if _, err := api.Product.CancelPriceChange(ctx, saved); !errors.Is(err, product.ErrChangeResolved) {
+		t.Errorf("Should NOT be able to cancel an applied price change: %v.", err)
+	}
+
+	// -------------------------------------------------------------------------
+
+	pc, err = api.Product.SchedulePriceChange(ctx, updated, npc)
+	if err != nil {
+		t.Fatalf("Should be able to schedule a price change: %s.", err)
+	}
+
+	if _, err := api.Product.CancelPriceChange(ctx, pc); err != nil {
+		t.Fatalf("Should be able to cancel a scheduled price change: %s.", err)
+	}
+
+	if n := apply(due); n != 0 {
+		t.Errorf("Should NOT apply a cancelled price change: got %d", n)
+	}
+}
+
+func cost(t *testing.T) {
+	test := dbtest.NewTest(t, c)
+	defer func() {
+		if r := recover(); r != nil {
+			t.Log(r)
+			t.Error(string(debug.Stack()))
+		}
+		test.Teardown()
+	}()
+
+	api := test.CoreAPIs
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	t.Log("Go seeding...")
+
+	usr, err := seed(ctx, api)
+	if err != nil {
+		t.Fatalf("Seeding error: %s", err)
+	}
+
+	// -------------------------------------------------------------------------
+
+	np := product.NewProduct{
+		Name:    "T-Shirt",
+		Cost:    money.MustParse("15.00", money.EUR),
+		UserID:  usr.ID,
+		SKU:     "TSHIRT",
+		Options: []string{"size"},
+	}
+
+	if _, err := api.Product.Create(ctx, np); !errors.Is(err, product.ErrCostCurrency) {
+		t.Errorf("Should NOT be able to cost a product in another currency: %v.", err)
+	}
+
+	np.Cost = money.MustParse("15.00", money.USD)
+	shirt, err := api.Product.Create(ctx, np)
+	if err != nil {
+		t.Fatalf("Should be able to create product: %s.", err)
+	}
+
+	nv := product.NewVariant{
+		SKU:          "TSHIRT-M",
+		OptionValues: map[string]string{"size": "M"},
+		Cost:         money.MustParse("2000", money.JPY),
+	}
+
+	if _, err := api.Product.CreateVariant(ctx, shirt, nv); !errors.Is(err, product.ErrCostCurrency) {
+		t.Errorf("Should NOT be able to cost a variant in another currency: %v.", err)
+	}
+
+	eur := money.MustParse("14.00", money.EUR)
+	if _, err := api.Product.Update(ctx, shirt, product.UpdateProduct{Cost: &eur}); !errors.Is(err, product.ErrCostCurrency) {
+		t.Errorf("Should NOT be able to change the cost to another currency: %v.", err)
+	}
+
+	npc := product.NewPriceChange{
+		Cost:        eur,
+		EffectiveAt: time.Now().Add(time.Hour),
+	}
+
+	if _, err := api.Product.SchedulePriceChange(ctx, shirt, npc); !errors.Is(err, product.ErrCostCurrency) {
+		t.Errorf("Should NOT be able to schedule a cost in another currency: %v.", err)
+	}
+
+	saved, err := api.Product.QueryByID(ctx, shirt.ID)
+	if err != nil {
+		t.Fatalf("Should be able to retrieve product by ID: %s.", err)
+	}
+
+	if !saved.Cost.Equal(np.Cost) {
+		t.Errorf("Should have kept the cost of the product: got %s %s", saved.Cost, saved.Cost.Currency().Code())
+	}
+}
+
+// =============================================================================
+
+// seed returns a seeded user to own the products of the tests.
+func seed(ctx context.Context, api dbtest.CoreAPIs) (user.User, error) {
+	usrs, err := api.User.Query(ctx, user.QueryFilter{}, user.DefaultOrderBy, 1, 1)
+	if err != nil {
+		return user.User{}, fmt.Errorf("seeding users: %w", err)
+	}
+	return usrs[0], nil
+}
@@ -0,0 +1,149 @@
+package product
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aleury/service/business/core/audit"
+	"github.com/google/uuid"
+)
+
+// TakeStock removes the quantity from the stock of a product as part of a
+// sale and returns the product as it is afterwards. It fails with
+// ErrInsufficientStock when less is on hand. Call it with the context of the
+// transaction recording the sale so the stock is only taken if it commits.
+func (c *Core) TakeStock(ctx context.Context, productID uuid.UUID, quantity int, orderID uuid.UUID) (Product, error) {
+	if quantity < 1 {
+		return Product{}, ErrInvalidQuantity
+	}
+
+	p, _, err := c.move(ctx, productID, MovementSale, -quantity, Reason{}, orderID)
+	return p, err
+}
+
+// ReturnStock puts the quantity back into the stock of a product when a sale
+// is undone. Like TakeStock, call it with the context of the transaction
+// undoing the sale.
+func (c *Core) ReturnStock(ctx context.Context, productID uuid.UUID, quantity int, orderID uuid.UUID) (Product, error) {
+	if quantity < 1 {
+		return Product{}, ErrInvalidQuantity
+	}
+
+	p, _, err := c.move(ctx, productID, MovementReturn, quantity, Reason{}, orderID)
+	return p, err
+}
+
+// Receive adds delivered stock to a product.
+func (c *Core) Receive(ctx context.Context, p Product, quantity int) (Product, Movement, error) {
+	if quantity < 1 {
+		return Product{}, Movement{}, ErrInvalidQuantity
+	}
+
+	if p.HasVariants() {
+		return Product{}, Movement{}, ErrHasVariants
+	}
+
+	return c.move(ctx, p.ID, MovementReceipt, quantity, Reason{}, uuid.Nil)
+}
+
+// Adjust corrects the stock of a product by the quantity, which is negative
+// when stock is written off, for the reason given. It fails with
+// ErrInsufficientStock when the adjustment would leave less on hand than is
+// held by reservations.
+func (c *Core) Adjust(ctx context.Context, p Product, na NewAdjustment) (Product, Movement, error) {
+	if na.Quantity == 0 {
+		return Product{}, Movement{}, ErrInvalidQuantity
+	}
+
+	if p.HasVariants() {
+		return Product{}, Movement{}, ErrHasVariants
+	}
+
+	if _, err := ParseReason(na.Reason.Name()); err != nil || na.Reason == ReasonOpening {
+		return Product{}, Movement{}, fmt.Errorf("reason[%s]: %w", na.Reason.Name(), ErrInvalidReason)
+	}
+
+	return c.move(ctx, p.ID, MovementAdjustment, na.Quantity, na.Reason, uuid.Nil)
+}
+
+// QueryMovements retrieves the movements of a product, newest first.
+func (c *Core) QueryMovements(ctx context.Context, productID uuid.UUID, pageNumber int, rowsPerPage int) ([]Movement, error) {
+	mvs, err := c.storer.QueryMovements(ctx, productID, pageNumber, rowsPerPage)
+	if err != nil {
+		return nil, fmt.Errorf("query: productID[%s]: %w", productID, err)
+	}
+	return mvs, nil
+}
+
+// CountMovements returns the number of movements of a product.
+func (c *Core) CountMovements(ctx context.Context, productID uuid.UUID) (int, error) {
+	count, err := c.storer.CountMovements(ctx, productID)
+	if err != nil {
+		return 0, fmt.Errorf("count: productID[%s]: %w", productID, err)
+	}
+	return count, nil
+}
+
+// Reconcile returns the products whose stock on hand disagrees with the sum
+// of their movements. Stock only changes through the ledger, so any product
+// reported was changed outside of it.
+func (c *Core) Reconcile(ctx context.Context) ([]Discrepancy, error) {
+	dcs, err := c.storer.QueryDiscrepancies(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+	return dcs, nil
+}
+
+// move changes the stock of a product by the quantity and records the
+// movement in the ledger, both or neither.
+func (c *Core) move(ctx context.Context, productID uuid.UUID, kind MovementKind, quantity int, reason Reason, orderID uuid.UUID) (Product, Movement, error) {
+	mv := Movement{
+		ID:          uuid.New(),
+		ProductID:   productID,
+		Kind:        kind,
+		Quantity:    quantity,
+		Reason:      reason,
+		OrderID:     orderID,
+		ActorID:     audit.GetActorID(ctx),
+		DateCreated: time.Now(),
+	}
+
+	var p Product
+
+	tran := func(ctx context.Context) error {
+		var err error
+		p, err = c.storer.MoveStock(ctx, mv)
+		if err != nil {
+			if !errors.Is(err, ErrNotFound) {
+				return fmt.Errorf("movestock: productID[%s]: %w", productID, err)
+			}
+
+			// Nothing was moved, either because the product doesn't exist
+			// or because there isn't enough of it.
+			prd, err := c.storer.QueryByID(ctx, productID)
+			if err != nil {
+				return fmt.Errorf("query: productID[%s]: %w", productID, err)
+			}
+			if prd.HasVariants() {
+				return fmt.Errorf("movestock: productID[%s]: %w", productID, ErrHasVariants)
+			}
+			return fmt.Errorf("movestock: productID[%s]: %w", productID, ErrInsufficientStock)
+		}
+
+		before := p
+		before.Quantity -= quantity
+
+		return c.record(ctx, audit.ActionUpdate, before, p)
+	}
+
+	if err := c.storer.WithinTran(ctx, tran); err != nil {
+		return Product{}, Movement{}, err
+	}
+
+	mv.TenantID = p.TenantID
+
+	return p, mv, nil
+}
@@ -0,0 +1,92 @@
+package productdb
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aleury/service/business/core/product"
+	"github.com/aleury/service/business/core/tenant"
+	"github.com/google/uuid"
+)
+
+// inCategory matches the products assigned to a category or any category
+// below it in the tree.
+const inCategory = `product_id IN (
+		WITH RECURSIVE subtree AS (
+			SELECT category_id FROM categories WHERE category_id = :category_id
+			UNION
+			SELECT c.category_id FROM categories AS c JOIN subtree AS s ON c.parent_id = s.category_id
+		)
+		SELECT pc.product_id FROM product_categories AS pc JOIN subtree USING (category_id)
+	)`
+
+// matchProducts matches the products whose words match the search text or
+// whose name is close to it, which catches misspelled queries.
+const matchProducts = `(search @@ websearch_to_tsquery('english', :search) OR name % :search)`
+
+// rankProducts scores how well a product matches the search text.
+const rankProducts = `ts_rank(search, websearch_to_tsquery('english', :search)) + similarity(name, :search)`
+
+func (s *Store) applyFilter(ctx context.Context, filter product.QueryFilter, data map[string]any, buf *bytes.Buffer) {
+	var wc []string
+
+	if tenantID := tenant.GetTenantID(ctx); tenantID != uuid.Nil {
+		data["tenant_id"] = tenantID
+		wc = append(wc, "tenant_id = :tenant_id")
+	}
+
+	if filter.ID != nil {
+		data["product_id"] = *filter.ID
+		wc = append(wc, "product_id = :product_id")
+	}
+
+	if filter.Name != nil {
+		data["name"] = fmt.Sprintf("%%%s%%", *filter.Name)
+		wc = append(wc, "name ILIKE :name")
+	}
+
+	if filter.Cost != nil {
+		data["cost"] = *filter.Cost
+		wc = append(wc, "cost = :cost")
+	}
+
+	if filter.Quantity != nil {
+		data["quantity"] = *filter.Quantity
+		wc = append(wc, "quantity = :quantity")
+	}
+
+	if filter.UserID != nil {
+		data["user_id"] = *filter.UserID
+		wc = append(wc, "user_id = :user_id")
+	}
+
+	if filter.ParentID != nil {
+		if *filter.ParentID == uuid.Nil {
+			wc = append(wc, "parent_id IS NULL")
+		} else {
+			data["parent_id"] = *filter.ParentID
+			wc = append(wc, "parent_id = :parent_id")
+		}
+	}
+
+	if filter.Search != nil {
+		data["search"] = *filter.Search
+		wc = append(wc, matchProducts)
+	}
+
+	if filter.CategoryID != nil {
+		data["category_id"] = *filter.CategoryID
+		wc = append(wc, inCategory)
+	}
+
+	if filter.IncludeDeleted == nil || !*filter.IncludeDeleted {
+		wc = append(wc, "deleted_at IS NULL")
+	}
+
+	if len(wc) > 0 {
+		buf.WriteString(" WHERE ")
+		buf.WriteString(strings.Join(wc, " AND "))
+	}
+}
@@ -0,0 +1,433 @@
+package productdb
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/aleury/service/business/core/product"
+	"github.com/aleury/service/business/data/money"
+	"github.com/aleury/service/business/sys/database/pgx/dbarray"
+	"github.com/google/uuid"
+)
+
+// dbProduct represents the structure we need for moving data
+// between the app and the database.
+type dbProduct struct {
+	ID          uuid.UUID    `db:"product_id"`
+	TenantID    uuid.UUID    `db:"tenant_id"`
+	Name        string       `db:"name"`
+	Cost        money.Money  `db:"cost"`
+	Quantity    int          `db:"quantity"`
+	Sold        int          `db:"sold"`
+	Revenue     money.Money  `db:"revenue"`
+	UserID      uuid.UUID    `db:"user_id"`
+	Version     int          `db:"version"`
+	DateCreated time.Time    `db:"date_created"`
+	DateUpdated time.Time    `db:"date_updated"`
+	DateDeleted sql.NullTime `db:"deleted_at"`
+	TaxClass    string       `db:"tax_class"`
+
+	ReorderThreshold int `db:"reorder_threshold"`
+
+	ParentID     uuid.NullUUID  `db:"parent_id"`
+	SKU          sql.NullString `db:"sku"`
+	Options      dbarray.String `db:"options"`
+	OptionValues dbOptionValues `db:"option_values"`
+
+	// Search holds the words the product is searched by. It is generated by
+	// the database and only read along with the rest of the row.
+	Search sql.NullString `db:"search"`
+}
+
+func toDBProduct(prd product.Product) dbProduct {
+	options := prd.Options
+	if options == nil {
+		options = []string{}
+	}
+
+	return dbProduct{
+		ID:          prd.ID,
+		TenantID:    prd.TenantID,
+		Name:        prd.Name,
+		Cost:        prd.Cost,
+		Quantity:    prd.Quantity,
+		UserID:      prd.UserID,
+		Version:     prd.Version,
+		DateCreated: prd.DateCreated.UTC(),
+		DateUpdated: prd.DateUpdated.UTC(),
+		DateDeleted: sql.NullTime{
+			Time:  prd.DateDeleted.UTC(),
+			Valid: !prd.DateDeleted.IsZero(),
+		},
+		TaxClass:         prd.TaxClass,
+		ReorderThreshold: prd.ReorderThreshold,
+		ParentID: uuid.NullUUID{
+			UUID:  prd.ParentID,
+			Valid: prd.ParentID != uuid.Nil,
+		},
+		SKU: sql.NullString{
+			String: prd.SKU,
+			Valid:  prd.SKU != "",
+		},
+		Options:      dbarray.String(options),
+		OptionValues: dbOptionValues(prd.OptionValues),
+	}
+}
+
+func toCoreProduct(dbPrd dbProduct) product.Product {
+	prd := product.Product{
+		ID:          dbPrd.ID,
+		TenantID:    dbPrd.TenantID,
+		Name:        dbPrd.Name,
+		Cost:        dbPrd.Cost,
+		Quantity:    dbPrd.Quantity,
+		Sold:        dbPrd.Sold,
+		Revenue:     dbPrd.Revenue,
+		UserID:      dbPrd.UserID,
+		Version:     dbPrd.Version,
+		DateCreated: dbPrd.DateCreated.In(time.Local),
+		DateUpdated: dbPrd.DateUpdated.In(time.Local),
+		TaxClass:    dbPrd.TaxClass,
+		ParentID:    dbPrd.ParentID.UUID,
+		SKU:         dbPrd.SKU.String,
+
+		ReorderThreshold: dbPrd.ReorderThreshold,
+	}
+
+	if dbPrd.DateDeleted.Valid {
+		prd.DateDeleted = dbPrd.DateDeleted.Time.In(time.Local)
+	}
+
+	if len(dbPrd.Options) > 0 {
+		prd.Options = []string(dbPrd.Options)
+	}
+
+	if len(dbPrd.OptionValues) > 0 {
+		prd.OptionValues = map[string]string(dbPrd.OptionValues)
+	}
+
+	return prd
+}
+
+func toCoreProductSlice(dbProducts []dbProduct) []product.Product {
+	prds := make([]product.Product, len(dbProducts))
+	for i, dbPrd := range dbProducts {
+		prds[i] = toCoreProduct(dbPrd)
+	}
+	return prds
+}
+
+// dbOptionValues stores the option values of a variant as a JSON object.
+type dbOptionValues map[string]string
+
+// Scan implements the sql.Scanner interface.
+func (ov *dbOptionValues) Scan(src any) error {
+	var data []byte
+	switch src := src.(type) {
+	case nil:
+		*ov = nil
+		return nil
+	case []byte:
+		data = src
+	case string:
+		data = []byte(src)
+	default:
+		return fmt.Errorf("unsupported option values type %T", src)
+	}
+
+	return json.Unmarshal(data, (*map[string]string)(ov))
+}
+
+// Value implements the driver.Valuer interface.
+func (ov dbOptionValues) Value() (driver.Value, error) {
+	if len(ov) == 0 {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(map[string]string(ov))
+	if err != nil {
+		return nil, err
+	}
+
+	return string(data), nil
+}
+
+// dbSnippet represents the highlighted name of a product.
+type dbSnippet struct {
+	ID      uuid.UUID `db:"product_id"`
+	Snippet string    `db:"snippet"`
+}
+
+// =============================================================================
+
+// dbVersion represents a recorded version of a product in the history table.
+type dbVersion struct {
+	Version   int       `db:"version"`
+	ValidFrom time.Time `db:"valid_from"`
+	dbProduct
+}
+
+func toCoreVersionSlice(dbVersions []dbVersion) []product.Version {
+	versions := make([]product.Version, len(dbVersions))
+	for i, dbVer := range dbVersions {
+		versions[i] = product.Version{
+			Number:    dbVer.Version,
+			ValidFrom: dbVer.ValidFrom.In(time.Local),
+			Product:   toCoreProduct(dbVer.dbProduct),
+		}
+	}
+	return versions
+}
+
+// =============================================================================
+
+// dbPrice represents an explicit price of a product in the database. The
+// amount is read as text since its currency is only known from the row.
+type dbPrice struct {
+	ProductID   uuid.UUID `db:"product_id"`
+	Currency    string    `db:"currency"`
+	TenantID    uuid.UUID `db:"tenant_id"`
+	Amount      string    `db:"amount"`
+	DateUpdated time.Time `db:"date_updated"`
+}
+
+func toDBPrice(price product.Price) dbPrice {
+	return dbPrice{
+		ProductID:   price.ProductID,
+		Currency:    price.Amount.Currency().Code(),
+		TenantID:    price.TenantID,
+		Amount:      price.Amount.String(),
+		DateUpdated: price.DateUpdated.UTC(),
+	}
+}
+
+func toCorePrice(dbPrice dbPrice) product.Price {
+	return product.Price{
+		ProductID:   dbPrice.ProductID,
+		TenantID:    dbPrice.TenantID,
+		Amount:      money.MustParse(dbPrice.Amount, money.MustParseCurrency(dbPrice.Currency)),
+		DateUpdated: dbPrice.DateUpdated.In(time.Local),
+	}
+}
+
+func toCorePriceSlice(dbPrices []dbPrice) []product.Price {
+	prices := make([]product.Price, len(dbPrices))
+	for i, dbPrice := range dbPrices {
+		prices[i] = toCorePrice(dbPrice)
+	}
+	return prices
+}
+
+// =============================================================================
+
+// dbMovement represents an entry of the inventory ledger in the database.
+type dbMovement struct {
+	ID          uuid.UUID      `db:"movement_id"`
+	TenantID    uuid.UUID      `db:"tenant_id"`
+	ProductID   uuid.UUID      `db:"product_id"`
+	Kind        string         `db:"kind"`
+	Quantity    int            `db:"quantity"`
+	Reason      sql.NullString `db:"reason"`
+	OrderID     uuid.NullUUID  `db:"order_id"`
+	ActorID     uuid.NullUUID  `db:"actor_id"`
+	DateCreated time.Time      `db:"date_created"`
+}
+
+func toDBMovement(mv product.Movement) dbMovement {
+	return dbMovement{
+		ID:        mv.ID,
+		TenantID:  mv.TenantID,
+		ProductID: mv.ProductID,
+		Kind:      mv.Kind.Name(),
+		Quantity:  mv.Quantity,
+		Reason: sql.NullString{
+			String: mv.Reason.Name(),
+			Valid:  mv.Reason.Name() != "",
+		},
+		OrderID: uuid.NullUUID{
+			UUID:  mv.OrderID,
+			Valid: mv.OrderID != uuid.Nil,
+		},
+		ActorID: uuid.NullUUID{
+			UUID:  mv.ActorID,
+			Valid: mv.ActorID != uuid.Nil,
+		},
+		DateCreated: mv.DateCreated.UTC(),
+	}
+}
+
+func toCoreMovement(dbMv dbMovement) product.Movement {
+	mv := product.Movement{
+		ID:          dbMv.ID,
+		TenantID:    dbMv.TenantID,
+		ProductID:   dbMv.ProductID,
+		Kind:        product.MustParseMovementKind(dbMv.Kind),
+		Quantity:    dbMv.Quantity,
+		OrderID:     dbMv.OrderID.UUID,
+		ActorID:     dbMv.ActorID.UUID,
+		DateCreated: dbMv.DateCreated.In(time.Local),
+	}
+
+	if dbMv.Reason.Valid {
+		mv.Reason = product.MustParseReason(dbMv.Reason.String)
+	}
+
+	return mv
+}
+
+func toCoreMovementSlice(dbMovements []dbMovement) []product.Movement {
+	mvs := make([]product.Movement, len(dbMovements))
+	for i, dbMv := range dbMovements {
+		mvs[i] = toCoreMovement(dbMv)
+	}
+	return mvs
+}
+
+// dbDiscrepancy represents a product whose stock disagrees with the ledger.
+type dbDiscrepancy struct {
+	ProductID      uuid.UUID `db:"product_id"`
+	TenantID       uuid.UUID `db:"tenant_id"`
+	Name           string    `db:"name"`
+	Quantity       int       `db:"quantity"`
+	LedgerQuantity int       `db:"ledger_quantity"`
+}
+
+func toCoreDiscrepancySlice(dbDiscrepancies []dbDiscrepancy) []product.Discrepancy {
+	dcs := make([]product.Discrepancy, len(dbDiscrepancies))
+	for i, dbDc := range dbDiscrepancies {
+		dcs[i] = product.Discrepancy{
+			ProductID:      dbDc.ProductID,
+			TenantID:       dbDc.TenantID,
+			Name:           dbDc.Name,
+			Quantity:       dbDc.Quantity,
+			LedgerQuantity: dbDc.LedgerQuantity,
+		}
+	}
+	return dcs
+}
+
+// =============================================================================
+
+// dbPriceChange represents a scheduled price change in the database.
+type dbPriceChange struct {
+	ID           uuid.UUID      `db:"change_id"`
+	TenantID     uuid.UUID      `db:"tenant_id"`
+	ProductID    uuid.UUID      `db:"product_id"`
+	Cost         money.Money    `db:"cost"`
+	PreviousCost sql.NullString `db:"previous_cost"`
+	Status       string         `db:"status"`
+	EffectiveAt  time.Time      `db:"effective_at"`
+	CreatedBy    uuid.NullUUID  `db:"created_by"`
+	DateCreated  time.Time      `db:"date_created"`
+	DateResolved sql.NullTime   `db:"date_resolved"`
+}
+
+func toDBPriceChange(pc product.PriceChange) dbPriceChange {
+	return dbPriceChange{
+		ID:        pc.ID,
+		TenantID:  pc.TenantID,
+		ProductID: pc.ProductID,
+		Cost:      pc.Cost,
+		PreviousCost: sql.NullString{
+			String: pc.PreviousCost.String(),
+			Valid:  pc.Status == product.ChangeApplied,
+		},
+		Status:      pc.Status.Name(),
+		EffectiveAt: pc.EffectiveAt.UTC(),
+		CreatedBy: uuid.NullUUID{
+			UUID:  pc.CreatedBy,
+			Valid: pc.CreatedBy != uuid.Nil,
+		},
+		DateCreated: pc.DateCreated.UTC(),
+		DateResolved: sql.NullTime{
+			Time:  pc.DateResolved.UTC(),
+			Valid: !pc.DateResolved.IsZero(),
+		},
+	}
+}
+
+func toCorePriceChange(dbPc dbPriceChange) product.PriceChange {
+	pc := product.PriceChange{
+		ID:          dbPc.ID,
+		TenantID:    dbPc.TenantID,
+		ProductID:   dbPc.ProductID,
+		Cost:        dbPc.Cost,
+		Status:      product.MustParseChangeStatus(dbPc.Status),
+		EffectiveAt: dbPc.EffectiveAt.In(time.Local),
+		CreatedBy:   dbPc.CreatedBy.UUID,
+		DateCreated: dbPc.DateCreated.In(time.Local),
+	}
+
+	if dbPc.PreviousCost.Valid {
+		pc.PreviousCost = money.MustParse(dbPc.PreviousCost.String, dbPc.Cost.Currency())
+	}
+	if dbPc.DateResolved.Valid {
+		pc.DateResolved = dbPc.DateResolved.Time.In(time.Local)
+	}
+
+	return pc
+}
+
+func toCorePriceChangeSlice(dbPriceChanges []dbPriceChange) []product.PriceChange {
+	pcs := make([]product.PriceChange, len(dbPriceChanges))
+	for i, dbPc := range dbPriceChanges {
+		pcs[i] = toCorePriceChange(dbPc)
+	}
+	return pcs
+}
+
+// dbPriceRecord represents an entry of the price history in the database.
+type dbPriceRecord struct {
+	ID           uuid.UUID      `db:"entry_id"`
+	TenantID     uuid.UUID      `db:"tenant_id"`
+	ProductID    uuid.UUID      `db:"product_id"`
+	Cost         money.Money    `db:"cost"`
+	PreviousCost sql.NullString `db:"previous_cost"`
+	ChangeID     uuid.NullUUID  `db:"change_id"`
+	ActorID      uuid.NullUUID  `db:"actor_id"`
+	DateCreated  time.Time      `db:"date_created"`
+}
+
+func toDBPriceRecord(pr product.PriceRecord) dbPriceRecord {
+	return dbPriceRecord{
+		ID:        pr.ID,
+		TenantID:  pr.TenantID,
+		ProductID: pr.ProductID,
+		Cost:      pr.Cost,
+		PreviousCost: sql.NullString{
+			String: pr.PreviousCost.String(),
+			Valid:  pr.PreviousCost != (money.Money{}),
+		},
+		ChangeID: uuid.NullUUID{
+			UUID:  pr.ChangeID,
+			Valid: pr.ChangeID != uuid.Nil,
+		},
+		ActorID: uuid.NullUUID{
+			UUID:  pr.ActorID,
+			Valid: pr.ActorID != uuid.Nil,
+		},
+		DateCreated: pr.DateCreated.UTC(),
+	}
+}
+
+func toCorePriceRecordSlice(dbPriceRecords []dbPriceRecord) []product.PriceRecord {
+	prs := make([]product.PriceRecord, len(dbPriceRecords))
+	for i, dbPr := range dbPriceRecords {
+		prs[i] = product.PriceRecord{
+			ID:          dbPr.ID,
+			TenantID:    dbPr.TenantID,
+			ProductID:   dbPr.ProductID,
+			Cost:        dbPr.Cost,
+			ChangeID:    dbPr.ChangeID.UUID,
+			ActorID:     dbPr.ActorID.UUID,
+			DateCreated: dbPr.DateCreated.In(time.Local),
+		}
+		if dbPr.PreviousCost.Valid {
+			prs[i].PreviousCost = money.MustParse(dbPr.PreviousCost.String, dbPr.Cost.Currency())
+		}
+	}
+	return prs
+}
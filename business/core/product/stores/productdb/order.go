@@ -0,0 +1,36 @@
+package productdb
+
+import (
+	"fmt"
+
+	"github.com/aleury/service/business/core/product"
+	"github.com/aleury/service/business/data/order"
+)
+
+var orderByFields = map[string]string{
+	product.OrderByID:       "product_id",
+	product.OrderByName:     "name",
+	product.OrderByCost:     "cost",
+	product.OrderByQuantity: "quantity",
+	product.OrderBySold:     "sold",
+	product.OrderByRevenue:  "revenue",
+	product.OrderByUserID:   "user_id",
+}
+
+func orderByClause(orderBy order.By) (string, error) {
+	by, exists := orderByFields[orderBy.Field]
+	if !exists {
+		return "", fmt.Errorf("field %q does not exist", orderBy.Field)
+	}
+	return fmt.Sprintf(" ORDER BY %s %s", by, orderBy.Direction), nil
+}
+
+// rankedOrderByClause orders search results by relevance first and then by
+// the requested field.
+func rankedOrderByClause(rank string, orderBy order.By) (string, error) {
+	by, exists := orderByFields[orderBy.Field]
+	if !exists {
+		return "", fmt.Errorf("field %q does not exist", orderBy.Field)
+	}
+	return fmt.Sprintf(" ORDER BY %s DESC, %s %s", rank, by, orderBy.Direction), nil
+}
@@ -0,0 +1,109 @@
+package productdb
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aleury/service/business/core/product"
+	"github.com/aleury/service/business/core/tenant"
+	"github.com/aleury/service/business/data/money"
+	database "github.com/aleury/service/business/sys/database/pgx"
+	"github.com/aleury/service/business/sys/database/pgx/dbarray"
+	"github.com/google/uuid"
+)
+
+// UpsertPrice adds an explicit price to the database or replaces the price
+// the product already has in that currency.
+func (s *Store) UpsertPrice(ctx context.Context, price product.Price) error {
+	const q = `
+	INSERT INTO product_prices
+		(product_id, currency, tenant_id, amount, date_updated)
+	VALUES
+		(:product_id, :currency, :tenant_id, :amount, :date_updated)
+	ON CONFLICT (product_id, currency) DO UPDATE SET
+		"amount" = EXCLUDED.amount,
+		"date_updated" = EXCLUDED.date_updated`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, toDBPrice(price)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// DeletePrice removes the explicit price of a product in the currency from
+// the database.
+func (s *Store) DeletePrice(ctx context.Context, productID uuid.UUID, currency money.Currency) error {
+	data := map[string]any{
+		"product_id": productID,
+		"currency":   currency.Code(),
+	}
+
+	const q = `
+	DELETE FROM
+		product_prices
+	WHERE
+		product_id = :product_id AND currency = :currency`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q+tenant.Scope(ctx, data, "tenant_id"), data); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// QueryPrices retrieves the explicit prices of a product ordered by
+// currency.
+func (s *Store) QueryPrices(ctx context.Context, productID uuid.UUID) ([]product.Price, error) {
+	data := map[string]any{
+		"product_id": productID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		product_prices
+	WHERE
+		product_id = :product_id`
+
+	var dbPrices []dbPrice
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q+tenant.Scope(ctx, data, "tenant_id")+" ORDER BY currency", data, &dbPrices); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toCorePriceSlice(dbPrices), nil
+}
+
+// QueryPricesByCurrency retrieves the explicit prices the products have in
+// the currency. Products without one are left out.
+func (s *Store) QueryPricesByCurrency(ctx context.Context, productIDs []uuid.UUID, currency money.Currency) ([]product.Price, error) {
+	if len(productIDs) == 0 {
+		return []product.Price{}, nil
+	}
+
+	ids := make([]string, len(productIDs))
+	for i, id := range productIDs {
+		ids[i] = id.String()
+	}
+
+	data := map[string]any{
+		"product_ids": dbarray.Array(ids),
+		"currency":    currency.Code(),
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		product_prices
+	WHERE
+		product_id = ANY(:product_ids) AND currency = :currency`
+
+	var dbPrices []dbPrice
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q+tenant.Scope(ctx, data, "tenant_id"), data, &dbPrices); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toCorePriceSlice(dbPrices), nil
+}
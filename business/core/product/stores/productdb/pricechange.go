@@ -0,0 +1,234 @@
+package productdb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aleury/service/business/core/product"
+	"github.com/aleury/service/business/core/tenant"
+	database "github.com/aleury/service/business/sys/database/pgx"
+	"github.com/google/uuid"
+)
+
+// CreatePriceChange adds a scheduled price change to the database.
+func (s *Store) CreatePriceChange(ctx context.Context, pc product.PriceChange) error {
+	const q = `
+	INSERT INTO price_changes
+		(change_id, tenant_id, product_id, cost, previous_cost, status, effective_at, created_by, date_created, date_resolved)
+	VALUES
+		(:change_id, :tenant_id, :product_id, :cost, :previous_cost, :status, :effective_at, :created_by, :date_created, :date_resolved)`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, toDBPriceChange(pc)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// ResolvePriceChange records that a scheduled price change was applied or
+// cancelled. ErrChangeResolved is returned when the change isn't scheduled
+// anymore.
+func (s *Store) ResolvePriceChange(ctx context.Context, pc product.PriceChange) error {
+	const q = `
+	UPDATE
+		price_changes
+	SET
+		"status" = :status,
+		"previous_cost" = :previous_cost,
+		"date_resolved" = :date_resolved
+	WHERE
+		change_id = :change_id AND status = 'SCHEDULED'
+	RETURNING
+		change_id`
+
+	var result struct {
+		ID uuid.UUID `db:"change_id"`
+	}
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, toDBPriceChange(pc), &result); err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return fmt.Errorf("namedquerystruct: %w", product.ErrChangeResolved)
+		}
+		return fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return nil
+}
+
+// QueryPriceChanges retrieves the price changes of a product, latest
+// effective first.
+func (s *Store) QueryPriceChanges(ctx context.Context, productID uuid.UUID, pageNumber int, rowsPerPage int) ([]product.PriceChange, error) {
+	data := map[string]any{
+		"product_id":    productID,
+		"offset":        (pageNumber - 1) * rowsPerPage,
+		"rows_per_page": rowsPerPage,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		price_changes
+	WHERE
+		product_id = :product_id`
+
+	buf := bytes.NewBufferString(q)
+	buf.WriteString(tenant.Scope(ctx, data, "tenant_id"))
+	buf.WriteString(" ORDER BY effective_at DESC, change_id OFFSET :offset LIMIT :rows_per_page")
+
+	var dbPriceChanges []dbPriceChange
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, buf.String(), data, &dbPriceChanges); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toCorePriceChangeSlice(dbPriceChanges), nil
+}
+
+// CountPriceChanges returns the number of price changes of a product.
+func (s *Store) CountPriceChanges(ctx context.Context, productID uuid.UUID) (int, error) {
+	data := map[string]any{
+		"product_id": productID,
+	}
+
+	const q = `
+	SELECT
+		COUNT(*)
+	FROM
+		price_changes
+	WHERE
+		product_id = :product_id`
+
+	var result struct {
+		Count int `db:"count"`
+	}
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q+tenant.Scope(ctx, data, "tenant_id"), data, &result); err != nil {
+		return 0, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return result.Count, nil
+}
+
+// QueryPriceChangeByID finds the price change identified by a given ID.
+func (s *Store) QueryPriceChangeByID(ctx context.Context, changeID uuid.UUID) (product.PriceChange, error) {
+	data := map[string]any{
+		"change_id": changeID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		price_changes
+	WHERE
+		change_id = :change_id`
+
+	var dbPc dbPriceChange
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q+tenant.Scope(ctx, data, "tenant_id"), data, &dbPc); err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return product.PriceChange{}, fmt.Errorf("namedquerystruct: %w", product.ErrChangeNotFound)
+		}
+		return product.PriceChange{}, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return toCorePriceChange(dbPc), nil
+}
+
+// QueryDuePriceChanges retrieves the scheduled price changes of products
+// that aren't deleted which take effect at or before the specified time,
+// oldest first.
+func (s *Store) QueryDuePriceChanges(ctx context.Context, now time.Time) ([]product.PriceChange, error) {
+	data := map[string]any{
+		"now": now.UTC(),
+	}
+
+	const q = `
+	SELECT
+		c.*
+	FROM
+		price_changes AS c
+	JOIN
+		products AS p USING (product_id)
+	WHERE
+		c.status = 'SCHEDULED' AND c.effective_at <= :now AND p.deleted_at IS NULL`
+
+	buf := bytes.NewBufferString(q)
+	buf.WriteString(tenant.Scope(ctx, data, "c.tenant_id"))
+	buf.WriteString(" ORDER BY c.effective_at, c.date_created")
+
+	var dbPriceChanges []dbPriceChange
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, buf.String(), data, &dbPriceChanges); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toCorePriceChangeSlice(dbPriceChanges), nil
+}
+
+// CreatePriceRecord adds an entry to the price history of a product.
+func (s *Store) CreatePriceRecord(ctx context.Context, pr product.PriceRecord) error {
+	const q = `
+	INSERT INTO price_history
+		(entry_id, tenant_id, product_id, cost, previous_cost, change_id, actor_id, date_created)
+	VALUES
+		(:entry_id, :tenant_id, :product_id, :cost, :previous_cost, :change_id, :actor_id, :date_created)`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, toDBPriceRecord(pr)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// QueryPriceHistory retrieves the price history of a product, newest first.
+func (s *Store) QueryPriceHistory(ctx context.Context, productID uuid.UUID, pageNumber int, rowsPerPage int) ([]product.PriceRecord, error) {
+	data := map[string]any{
+		"product_id":    productID,
+		"offset":        (pageNumber - 1) * rowsPerPage,
+		"rows_per_page": rowsPerPage,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		price_history
+	WHERE
+		product_id = :product_id`
+
+	buf := bytes.NewBufferString(q)
+	buf.WriteString(tenant.Scope(ctx, data, "tenant_id"))
+	buf.WriteString(" ORDER BY date_created DESC, entry_id OFFSET :offset LIMIT :rows_per_page")
+
+	var dbPriceRecords []dbPriceRecord
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, buf.String(), data, &dbPriceRecords); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toCorePriceRecordSlice(dbPriceRecords), nil
+}
+
+// CountPriceHistory returns the number of entries in the price history of a
+// product.
+func (s *Store) CountPriceHistory(ctx context.Context, productID uuid.UUID) (int, error) {
+	data := map[string]any{
+		"product_id": productID,
+	}
+
+	const q = `
+	SELECT
+		COUNT(*)
+	FROM
+		price_history
+	WHERE
+		product_id = :product_id`
+
+	var result struct {
+		Count int `db:"count"`
+	}
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q+tenant.Scope(ctx, data, "tenant_id"), data, &result); err != nil {
+		return 0, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return result.Count, nil
+}
@@ -0,0 +1,488 @@
+// Package productdb contains product related CRUD functionality.
+package productdb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aleury/service/business/core/product"
+	"github.com/aleury/service/business/core/tenant"
+	"github.com/aleury/service/business/data/order"
+	database "github.com/aleury/service/business/sys/database/pgx"
+	"github.com/aleury/service/business/sys/database/pgx/dbarray"
+	"github.com/aleury/service/business/sys/database/pgx/dbheadline"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// selectProducts reads products along with the totals of the orders they
+// were sold in.
+const selectProducts = `
+	SELECT
+		products.*,
+		COALESCE(s.sold, 0) AS sold,
+		COALESCE(s.revenue, 0) AS revenue
+	FROM
+		products
+	LEFT JOIN
+		product_sales AS s USING (product_id)`
+
+// Store manages the set of APIs for product database access.
+type Store struct {
+	log *zap.SugaredLogger
+	db  *sqlx.DB
+}
+
+// NewStore constructs the api for data access.
+func NewStore(log *zap.SugaredLogger, db *sqlx.DB) *Store {
+	return &Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// WithinTran runs passed function and does commit/rollback at the end. Every
+// store call made with the context handed to the function joins the
+// transaction.
+func (s *Store) WithinTran(ctx context.Context, fn func(ctx context.Context) error) error {
+	return database.WithinTran(ctx, s.log, s.db, fn)
+}
+
+// Create adds a Product to the database.
+func (s *Store) Create(ctx context.Context, prd product.Product) error {
+	const q = `
+	INSERT INTO products
+		(product_id, tenant_id, user_id, name, cost, quantity, version, date_created, date_updated,
+		 tax_class, reorder_threshold, parent_id, sku, options, option_values)
+	VALUES
+		(:product_id, :tenant_id, :user_id, :name, :cost, :quantity, :version, :date_created, :date_updated,
+		 :tax_class, :reorder_threshold, :parent_id, :sku, :options, :option_values)`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, toDBProduct(prd)); err != nil {
+		if errors.Is(err, database.ErrDBDuplicatedEntry) {
+			return fmt.Errorf("namedexeccontext: %w", product.ErrUniqueSKU)
+		}
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Update modifies data about a Product. The product carries the version it
+// is updated to, which has to follow the stored one. ErrConflict is returned
+// when it doesn't.
+func (s *Store) Update(ctx context.Context, prd product.Product) error {
+	const q = `
+	UPDATE
+		products
+	SET
+		"name" = :name,
+		"cost" = :cost,
+		"tax_class" = :tax_class,
+		"reorder_threshold" = :reorder_threshold,
+		"version" = :version,
+		"date_updated" = :date_updated
+	WHERE
+		product_id = :product_id AND version = :version - 1
+	RETURNING
+		product_id`
+
+	var result struct {
+		ID uuid.UUID `db:"product_id"`
+	}
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, toDBProduct(prd), &result); err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return fmt.Errorf("namedquerystruct: %w", product.ErrConflict)
+		}
+		return fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return nil
+}
+
+// RenameVariants gives the variants of a product the name of the product.
+func (s *Store) RenameVariants(ctx context.Context, prd product.Product) error {
+	const q = `
+	UPDATE
+		products
+	SET
+		"name" = :name,
+		"date_updated" = :date_updated
+	WHERE
+		parent_id = :product_id`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, toDBProduct(prd)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Delete marks the product, along with its variants, as deleted in the
+// database.
+func (s *Store) Delete(ctx context.Context, prd product.Product) error {
+	const q = `
+	UPDATE
+		products
+	SET
+		"deleted_at" = :deleted_at
+	WHERE
+		(product_id = :product_id OR parent_id = :product_id) AND deleted_at IS NULL`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, toDBProduct(prd)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Restore clears the deleted mark from a product and returns the restored
+// product. The variants deleted along with the product are restored too.
+func (s *Store) Restore(ctx context.Context, productID uuid.UUID, now time.Time) (product.Product, error) {
+	data := map[string]any{
+		"product_id":   productID,
+		"date_updated": now.UTC(),
+	}
+
+	const qv = `
+	UPDATE
+		products AS v
+	SET
+		"deleted_at" = NULL,
+		"date_updated" = :date_updated
+	FROM
+		products AS p
+	WHERE
+		p.product_id = :product_id AND
+		v.parent_id = p.product_id AND
+		v.deleted_at = p.deleted_at`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, qv, data); err != nil {
+		return product.Product{}, fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	const q = `
+	UPDATE
+		products
+	SET
+		"deleted_at" = NULL,
+		"date_updated" = :date_updated
+	WHERE
+		product_id = :product_id AND deleted_at IS NOT NULL`
+
+	buf := bytes.NewBufferString(q)
+	buf.WriteString(tenant.Scope(ctx, data, "tenant_id"))
+	buf.WriteString(" RETURNING *")
+
+	var dbPrd dbProduct
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, buf.String(), data, &dbPrd); err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return product.Product{}, fmt.Errorf("namedquerystruct: %w", product.ErrNotFound)
+		}
+		return product.Product{}, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return toCoreProduct(dbPrd), nil
+}
+
+// Purge permanently removes products that were deleted before the specified
+// time. Products with movements in the inventory ledger, or with variants
+// that have some, are kept.
+func (s *Store) Purge(ctx context.Context, before time.Time) error {
+	data := struct {
+		Before time.Time `db:"before"`
+	}{
+		Before: before.UTC(),
+	}
+
+	const q = `
+	DELETE FROM
+		products AS p
+	WHERE
+		p.deleted_at < :before AND
+		NOT EXISTS (
+			SELECT 1 FROM stock_movements AS m
+			JOIN products AS v USING (product_id)
+			WHERE v.product_id = p.product_id OR v.parent_id = p.product_id
+		)`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Query retrieves a list of existing products from the database.
+func (s *Store) Query(ctx context.Context, filter product.QueryFilter, orderBy order.By, pageNumber int, rowsPerPage int) ([]product.Product, error) {
+	data := map[string]any{
+		"offset":        (pageNumber - 1) * rowsPerPage,
+		"rows_per_page": rowsPerPage,
+	}
+
+	buf := bytes.NewBufferString(selectProducts)
+	s.applyFilter(ctx, filter, data, buf)
+
+	orderByClause, err := orderByClause(orderBy)
+	if filter.Search != nil {
+		orderByClause, err = rankedOrderByClause(rankProducts, orderBy)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	buf.WriteString(orderByClause)
+	buf.WriteString(" OFFSET :offset LIMIT :rows_per_page")
+
+	var dbPrds []dbProduct
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, buf.String(), data, &dbPrds); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toCoreProductSlice(dbPrds), nil
+}
+
+// Count returns the total number of products in the DB.
+func (s *Store) Count(ctx context.Context, filter product.QueryFilter) (int, error) {
+	data := map[string]any{}
+
+	const q = `
+	SELECT
+		COUNT(*)
+	FROM
+		products`
+
+	buf := bytes.NewBufferString(q)
+	s.applyFilter(ctx, filter, data, buf)
+
+	var result struct {
+		Count int `db:"count"`
+	}
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, buf.String(), data, &result); err != nil {
+		return 0, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return result.Count, nil
+}
+
+// QueryByID finds the product identified by a given ID.
+func (s *Store) QueryByID(ctx context.Context, productID uuid.UUID) (product.Product, error) {
+	data := map[string]any{
+		"product_id": productID,
+	}
+
+	const q = selectProducts + `
+	WHERE
+		product_id = :product_id AND deleted_at IS NULL`
+
+	var dbPrd dbProduct
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q+tenant.Scope(ctx, data, "tenant_id"), data, &dbPrd); err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return product.Product{}, fmt.Errorf("namedquerystruct: %w", product.ErrNotFound)
+		}
+		return product.Product{}, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return toCoreProduct(dbPrd), nil
+}
+
+// QueryBySKU finds the product identified by a given SKU.
+func (s *Store) QueryBySKU(ctx context.Context, sku string) (product.Product, error) {
+	data := map[string]any{
+		"sku": sku,
+	}
+
+	const q = selectProducts + `
+	WHERE
+		sku = :sku AND deleted_at IS NULL`
+
+	var dbPrd dbProduct
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q+tenant.Scope(ctx, data, "tenant_id"), data, &dbPrd); err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return product.Product{}, fmt.Errorf("namedquerystruct: %w", product.ErrNotFound)
+		}
+		return product.Product{}, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return toCoreProduct(dbPrd), nil
+}
+
+// QueryVariants retrieves the variants of the specified products.
+func (s *Store) QueryVariants(ctx context.Context, parentIDs []uuid.UUID) ([]product.Product, error) {
+	if len(parentIDs) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]string, len(parentIDs))
+	for i, id := range parentIDs {
+		ids[i] = id.String()
+	}
+
+	data := map[string]any{
+		"parent_ids": dbarray.Array(ids),
+	}
+
+	const q = selectProducts + `
+	WHERE
+		parent_id = ANY(:parent_ids) AND deleted_at IS NULL`
+
+	buf := bytes.NewBufferString(q)
+	buf.WriteString(tenant.Scope(ctx, data, "tenant_id"))
+	buf.WriteString(" ORDER BY sku")
+
+	var dbPrds []dbProduct
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, buf.String(), data, &dbPrds); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toCoreProductSlice(dbPrds), nil
+}
+
+// Highlight returns the names of the specified products with the words
+// matching the search text marked.
+func (s *Store) Highlight(ctx context.Context, productIDs []uuid.UUID, text string) (map[uuid.UUID]string, error) {
+	if len(productIDs) == 0 {
+		return map[uuid.UUID]string{}, nil
+	}
+
+	ids := make([]string, len(productIDs))
+	for i, id := range productIDs {
+		ids[i] = id.String()
+	}
+
+	data := map[string]any{
+		"product_ids":      dbarray.Array(ids),
+		"search":           text,
+		"headline_options": dbheadline.Options,
+	}
+
+	const q = `
+	SELECT
+		product_id,
+		ts_headline('english', name, websearch_to_tsquery('english', :search), :headline_options) AS snippet
+	FROM
+		products
+	WHERE
+		product_id = ANY(:product_ids)`
+
+	var dbSnps []dbSnippet
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q+tenant.Scope(ctx, data, "tenant_id"), data, &dbSnps); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	snippets := make(map[uuid.UUID]string, len(dbSnps))
+	for _, dbSnp := range dbSnps {
+		snippets[dbSnp.ID] = dbheadline.Mark(dbSnp.Snippet)
+	}
+
+	return snippets, nil
+}
+
+// QueryByUserID finds the products for a given user.
+func (s *Store) QueryByUserID(ctx context.Context, userID uuid.UUID) ([]product.Product, error) {
+	data := map[string]any{
+		"user_id": userID,
+	}
+
+	const q = selectProducts + `
+	WHERE
+		user_id = :user_id AND deleted_at IS NULL`
+
+	var dbPrds []dbProduct
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q+tenant.Scope(ctx, data, "tenant_id"), data, &dbPrds); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toCoreProductSlice(dbPrds), nil
+}
+
+// QueryByIDAsOf reconstructs the product identified by a given ID from the
+// latest version recorded at or before the specified time.
+func (s *Store) QueryByIDAsOf(ctx context.Context, productID uuid.UUID, asOf time.Time) (product.Product, error) {
+	data := map[string]any{
+		"product_id": productID,
+		"as_of":      asOf.UTC(),
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		products_history
+	WHERE
+		product_id = :product_id AND valid_from <= :as_of`
+
+	buf := bytes.NewBufferString(q)
+	buf.WriteString(tenant.Scope(ctx, data, "tenant_id"))
+	buf.WriteString(" ORDER BY version DESC LIMIT 1")
+
+	var dbVer dbVersion
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, buf.String(), data, &dbVer); err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return product.Product{}, fmt.Errorf("namedquerystruct: %w", product.ErrNotFound)
+		}
+		return product.Product{}, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	if dbVer.DateDeleted.Valid {
+		return product.Product{}, product.ErrNotFound
+	}
+
+	return toCoreProduct(dbVer.dbProduct), nil
+}
+
+// QueryHistory retrieves the recorded versions of a product, newest first.
+func (s *Store) QueryHistory(ctx context.Context, productID uuid.UUID, pageNumber int, rowsPerPage int) ([]product.Version, error) {
+	data := map[string]any{
+		"product_id":    productID,
+		"offset":        (pageNumber - 1) * rowsPerPage,
+		"rows_per_page": rowsPerPage,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		products_history
+	WHERE
+		product_id = :product_id`
+
+	buf := bytes.NewBufferString(q)
+	buf.WriteString(tenant.Scope(ctx, data, "tenant_id"))
+	buf.WriteString(" ORDER BY version DESC OFFSET :offset LIMIT :rows_per_page")
+
+	var dbVers []dbVersion
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, buf.String(), data, &dbVers); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toCoreVersionSlice(dbVers), nil
+}
+
+// CountHistory returns the number of recorded versions of a product.
+func (s *Store) CountHistory(ctx context.Context, productID uuid.UUID) (int, error) {
+	data := map[string]any{
+		"product_id": productID,
+	}
+
+	const q = `
+	SELECT
+		COUNT(*)
+	FROM
+		products_history
+	WHERE
+		product_id = :product_id`
+
+	var result struct {
+		Count int `db:"count"`
+	}
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q+tenant.Scope(ctx, data, "tenant_id"), data, &result); err != nil {
+		return 0, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return result.Count, nil
+}
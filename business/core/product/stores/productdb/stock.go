@@ -0,0 +1,117 @@
+package productdb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aleury/service/business/core/product"
+	"github.com/aleury/service/business/core/tenant"
+	database "github.com/aleury/service/business/sys/database/pgx"
+	"github.com/google/uuid"
+)
+
+// MoveStock changes the stock of a product by the quantity of the movement
+// and records the movement in the ledger. ErrNotFound is returned when
+// nothing was moved.
+func (s *Store) MoveStock(ctx context.Context, mv product.Movement) (product.Product, error) {
+	const q = `
+	SELECT
+		*
+	FROM
+		app_move_stock(:movement_id, :product_id, :kind, :quantity, :reason, :order_id, :actor_id, :date_created)`
+
+	var dbPrd dbProduct
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, toDBMovement(mv), &dbPrd); err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return product.Product{}, fmt.Errorf("namedquerystruct: %w", product.ErrNotFound)
+		}
+		return product.Product{}, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return toCoreProduct(dbPrd), nil
+}
+
+// QueryMovements retrieves the movements of a product, newest first.
+func (s *Store) QueryMovements(ctx context.Context, productID uuid.UUID, pageNumber int, rowsPerPage int) ([]product.Movement, error) {
+	data := map[string]any{
+		"product_id":    productID,
+		"offset":        (pageNumber - 1) * rowsPerPage,
+		"rows_per_page": rowsPerPage,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		stock_movements
+	WHERE
+		product_id = :product_id`
+
+	buf := bytes.NewBufferString(q)
+	buf.WriteString(tenant.Scope(ctx, data, "tenant_id"))
+	buf.WriteString(" ORDER BY date_created DESC, movement_id OFFSET :offset LIMIT :rows_per_page")
+
+	var dbMovements []dbMovement
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, buf.String(), data, &dbMovements); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toCoreMovementSlice(dbMovements), nil
+}
+
+// CountMovements returns the number of movements of a product.
+func (s *Store) CountMovements(ctx context.Context, productID uuid.UUID) (int, error) {
+	data := map[string]any{
+		"product_id": productID,
+	}
+
+	const q = `
+	SELECT
+		COUNT(*)
+	FROM
+		stock_movements
+	WHERE
+		product_id = :product_id`
+
+	var result struct {
+		Count int `db:"count"`
+	}
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q+tenant.Scope(ctx, data, "tenant_id"), data, &result); err != nil {
+		return 0, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return result.Count, nil
+}
+
+// QueryDiscrepancies retrieves the products whose stock on hand differs
+// from the sum of their movements.
+func (s *Store) QueryDiscrepancies(ctx context.Context) ([]product.Discrepancy, error) {
+	data := map[string]any{}
+
+	const q = `
+	SELECT
+		p.product_id,
+		p.tenant_id,
+		p.name,
+		p.quantity,
+		COALESCE(SUM(m.quantity), 0) AS ledger_quantity
+	FROM
+		products AS p
+	LEFT JOIN
+		stock_movements AS m USING (product_id)
+	WHERE
+		TRUE`
+
+	buf := bytes.NewBufferString(q)
+	buf.WriteString(tenant.Scope(ctx, data, "p.tenant_id"))
+	buf.WriteString(" GROUP BY p.product_id HAVING p.quantity <> COALESCE(SUM(m.quantity), 0) ORDER BY p.name")
+
+	var dbDiscrepancies []dbDiscrepancy
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, buf.String(), data, &dbDiscrepancies); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toCoreDiscrepancySlice(dbDiscrepancies), nil
+}
@@ -0,0 +1,87 @@
+package promotion
+
+import (
+	"fmt"
+
+	"github.com/aleury/service/business/data/money"
+)
+
+// discount calculates what the promotion takes off each of the items. Only
+// the eligible items are discounted. A fixed amount is spread over them in
+// proportion to their totals and never exceeds what they add up to.
+func discount(p Promotion, items []Item, eligible []bool) ([]money.Money, error) {
+	amounts := make([]money.Money, len(items))
+
+	switch p.Kind {
+	case KindPercent:
+		for i, item := range items {
+			if !eligible[i] {
+				continue
+			}
+			amounts[i] = money.New(percentOf(item.Total.Amount(), p.Percent), item.Total.Currency())
+		}
+
+	case KindFixed:
+		var subtotal money.Money
+		for i, item := range items {
+			if !eligible[i] {
+				continue
+			}
+
+			var err error
+			if subtotal, err = subtotal.Add(item.Total); err != nil {
+				return nil, fmt.Errorf("subtotal: %w", err)
+			}
+		}
+
+		off := p.Amount
+		cmp, err := off.Cmp(subtotal)
+		if err != nil {
+			return nil, fmt.Errorf("amount: %w", err)
+		}
+		if cmp > 0 {
+			off = subtotal
+		}
+
+		if subtotal.Amount() == 0 {
+			return amounts, nil
+		}
+
+		// Every item gets its share rounded down, the minor units left over
+		// go one by one to the first eligible items.
+		left := off.Amount()
+		for i, item := range items {
+			if !eligible[i] {
+				continue
+			}
+			share := off.Amount() * item.Total.Amount() / subtotal.Amount()
+			amounts[i] = money.New(share, item.Total.Currency())
+			left -= share
+		}
+		for i := range items {
+			if left == 0 {
+				break
+			}
+			if !eligible[i] || amounts[i].Amount() == items[i].Total.Amount() {
+				continue
+			}
+			amounts[i] = money.New(amounts[i].Amount()+1, items[i].Total.Currency())
+			left--
+		}
+
+	default:
+		return nil, fmt.Errorf("unknown kind %q", p.Kind.Name())
+	}
+
+	return amounts, nil
+}
+
+// percentOf returns the percentage of an amount of minor units, rounded half
+// away from zero.
+func percentOf(minor int64, percent int) int64 {
+	num := minor * int64(percent)
+	if num < 0 {
+		return -((-num + 50) / 100)
+	}
+	return (num + 50) / 100
+}
@@ -0,0 +1,38 @@
+package promotion
+
+import (
+	"fmt"
+
+	"github.com/aleury/service/business/sys/validate"
+	"github.com/google/uuid"
+)
+
+// QueryFilter holds the available fields a query can be filtered on.
+type QueryFilter struct {
+	ID      *uuid.UUID `validate:"omitempty"`
+	Code    *string    `validate:"omitempty,min=2"`
+	Enabled *bool      `validate:"omitempty"`
+}
+
+// Validate checks the data in the model is considered clean.
+func (qf *QueryFilter) Validate() error {
+	if err := validate.Check(qf); err != nil {
+		return fmt.Errorf("validate: %w", err)
+	}
+	return nil
+}
+
+// WithPromotionID sets the ID field of the QueryFilter value.
+func (qf *QueryFilter) WithPromotionID(promotionID uuid.UUID) {
+	qf.ID = &promotionID
+}
+
+// WithCode sets the Code field of the QueryFilter value.
+func (qf *QueryFilter) WithCode(code string) {
+	qf.Code = &code
+}
+
+// WithEnabled sets the Enabled field of the QueryFilter value.
+func (qf *QueryFilter) WithEnabled(enabled bool) {
+	qf.Enabled = &enabled
+}
@@ -0,0 +1,99 @@
+package promotion
+
+import (
+	"time"
+
+	"github.com/aleury/service/business/data/money"
+	"github.com/google/uuid"
+)
+
+// Promotion represents a discount customers redeem with a code at checkout.
+// A percentage promotion takes Percent percent off the products it applies
+// to, a fixed one takes Amount off them in total. A promotion without
+// products or categories applies to every product. Zero limits and a zero
+// end date mean there is no limit.
+type Promotion struct {
+	ID             uuid.UUID
+	TenantID       uuid.UUID
+	Code           string
+	Kind           Kind
+	Percent        int
+	Amount         money.Money
+	MinOrder       money.Money
+	MaxUses        int
+	MaxUsesPerUser int
+	Uses           int
+	ProductIDs     []uuid.UUID
+	CategoryIDs    []uuid.UUID
+	Enabled        bool
+	DateStarts     time.Time
+	DateEnds       time.Time
+	DateCreated    time.Time
+	DateUpdated    time.Time
+}
+
+// Active reports whether the promotion can be redeemed at the given time.
+func (p Promotion) Active(now time.Time) bool {
+	if !p.Enabled || now.Before(p.DateStarts) {
+		return false
+	}
+	return p.DateEnds.IsZero() || now.Before(p.DateEnds)
+}
+
+// Scoped reports whether the promotion only applies to some products.
+func (p Promotion) Scoped() bool {
+	return len(p.ProductIDs) > 0 || len(p.CategoryIDs) > 0
+}
+
+// NewPromotion contains information needed to create a new promotion. A
+// promotion starts right away when no start date is given.
+type NewPromotion struct {
+	TenantID       uuid.UUID
+	Code           string
+	Kind           Kind
+	Percent        int
+	Amount         money.Money
+	MinOrder       money.Money
+	MaxUses        int
+	MaxUsesPerUser int
+	ProductIDs     []uuid.UUID
+	CategoryIDs    []uuid.UUID
+	DateStarts     time.Time
+	DateEnds       time.Time
+}
+
+// UpdatePromotion contains information needed to update a promotion. How
+// much a promotion takes off and what it applies to can't be changed once
+// it may have been redeemed.
+type UpdatePromotion struct {
+	Enabled        *bool
+	MinOrder       *money.Money
+	MaxUses        *int
+	MaxUsesPerUser *int
+	DateStarts     *time.Time
+	DateEnds       *time.Time
+}
+
+// Item is a product being bought and the total paid for it before any
+// discount.
+type Item struct {
+	ProductID uuid.UUID
+	Total     money.Money
+}
+
+// Application is the discount a promotion gives on a set of items. Amounts
+// holds the discount of every item, in the order of the items.
+type Application struct {
+	Promotion Promotion
+	Amounts   []money.Money
+	Total     money.Money
+}
+
+// Redemption records the use of a promotion in an order.
+type Redemption struct {
+	PromotionID uuid.UUID
+	OrderID     uuid.UUID
+	UserID      uuid.UUID
+	Amount      money.Money
+	DateCreated time.Time
+}
@@ -0,0 +1,16 @@
+package promotion
+
+import "github.com/aleury/service/business/data/order"
+
+// DefaultOrderBy represents the default way we sort.
+var DefaultOrderBy = order.NewBy(OrderByCode, order.ASC)
+
+// Set of fields that the results can be ordered by. These are the names
+// that should be used by the application layer.
+const (
+	OrderByID         = "promotionid"
+	OrderByCode       = "code"
+	OrderByUses       = "uses"
+	OrderByDateStarts = "datestarts"
+	OrderByDateEnds   = "dateends"
+)
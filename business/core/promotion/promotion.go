@@ -0,0 +1,413 @@
+// Package promotion provides the core business API for discount codes
+// redeemed at checkout.
+package promotion
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aleury/service/business/core/audit"
+	"github.com/aleury/service/business/data/money"
+	"github.com/aleury/service/business/data/order"
+	"github.com/google/uuid"
+)
+
+// Set of error variables for CRUD operations.
+var (
+	ErrNotFound      = errors.New("promotion not found")
+	ErrUniqueCode    = errors.New("code is not unique")
+	ErrInvalidValue  = errors.New("promotion needs a percent between 1 and 100 or an amount greater than 0")
+	ErrInvalidDates  = errors.New("promotion has to start before it ends")
+	ErrInactive      = errors.New("promotion is not active")
+	ErrMinOrder      = errors.New("order doesn't reach the minimum value of the promotion")
+	ErrNotApplicable = errors.New("promotion doesn't apply to any product of the order")
+	ErrUsedUp        = errors.New("promotion has reached its usage limit")
+)
+
+// Storer interface declares the behavior this package needs to persist and
+// retrieve data.
+type Storer interface {
+	WithinTran(ctx context.Context, fn func(ctx context.Context) error) error
+	Create(ctx context.Context, promo Promotion) error
+	Update(ctx context.Context, promo Promotion) error
+	Delete(ctx context.Context, promo Promotion) error
+	Query(ctx context.Context, filter QueryFilter, orderBy order.By, pageNumber int, rowsPerPage int) ([]Promotion, error)
+	Count(ctx context.Context, filter QueryFilter) (int, error)
+	QueryByID(ctx context.Context, promotionID uuid.UUID) (Promotion, error)
+	QueryByCode(ctx context.Context, code string) (Promotion, error)
+	InScope(ctx context.Context, promo Promotion, productIDs []uuid.UUID) ([]uuid.UUID, error)
+	CountRedemptions(ctx context.Context, promotionID uuid.UUID, userID uuid.UUID) (int, error)
+	Redeem(ctx context.Context, rdm Redemption) error
+	Release(ctx context.Context, orderID uuid.UUID) error
+}
+
+// Core manages the set of APIs for promotion access.
+type Core struct {
+	storer    Storer
+	auditCore *audit.Core
+}
+
+// NewCore constructs a core for promotion api access.
+func NewCore(storer Storer, auditCore *audit.Core) *Core {
+	return &Core{
+		storer:    storer,
+		auditCore: auditCore,
+	}
+}
+
+// Create inserts a new promotion into the database. It is enabled right
+// away.
+func (c *Core) Create(ctx context.Context, np NewPromotion) (Promotion, error) {
+	switch np.Kind {
+	case KindPercent:
+		if np.Percent < 1 || np.Percent > 100 {
+			return Promotion{}, ErrInvalidValue
+		}
+		np.Amount = money.Money{}
+	case KindFixed:
+		if np.Amount.IsNegative() || np.Amount.IsZero() {
+			return Promotion{}, ErrInvalidValue
+		}
+		np.Percent = 0
+	default:
+		return Promotion{}, ErrInvalidValue
+	}
+
+	now := time.Now()
+
+	if np.DateStarts.IsZero() {
+		np.DateStarts = now
+	}
+	if !np.DateEnds.IsZero() && !np.DateEnds.After(np.DateStarts) {
+		return Promotion{}, ErrInvalidDates
+	}
+
+	promo := Promotion{
+		ID:             uuid.New(),
+		TenantID:       np.TenantID,
+		Code:           normalizeCode(np.Code),
+		Kind:           np.Kind,
+		Percent:        np.Percent,
+		Amount:         np.Amount,
+		MinOrder:       np.MinOrder,
+		MaxUses:        np.MaxUses,
+		MaxUsesPerUser: np.MaxUsesPerUser,
+		ProductIDs:     np.ProductIDs,
+		CategoryIDs:    np.CategoryIDs,
+		Enabled:        true,
+		DateStarts:     np.DateStarts,
+		DateEnds:       np.DateEnds,
+		DateCreated:    now,
+		DateUpdated:    now,
+	}
+
+	tran := func(ctx context.Context) error {
+		if err := c.storer.Create(ctx, promo); err != nil {
+			return fmt.Errorf("create: %w", err)
+		}
+		return c.record(ctx, audit.ActionCreate, Promotion{}, promo)
+	}
+
+	if err := c.storer.WithinTran(ctx, tran); err != nil {
+		return Promotion{}, err
+	}
+
+	return promo, nil
+}
+
+// Update replaces a promotion document in the database.
+func (c *Core) Update(ctx context.Context, promo Promotion, up UpdatePromotion) (Promotion, error) {
+	before := promo
+
+	if up.Enabled != nil {
+		promo.Enabled = *up.Enabled
+	}
+	if up.MinOrder != nil {
+		promo.MinOrder = *up.MinOrder
+	}
+	if up.MaxUses != nil {
+		promo.MaxUses = *up.MaxUses
+	}
+	if up.MaxUsesPerUser != nil {
+		promo.MaxUsesPerUser = *up.MaxUsesPerUser
+	}
+	if up.DateStarts != nil {
+		promo.DateStarts = *up.DateStarts
+	}
+	if up.DateEnds != nil {
+		promo.DateEnds = *up.DateEnds
+	}
+
+	if !promo.DateEnds.IsZero() && !promo.DateEnds.After(promo.DateStarts) {
+		return Promotion{}, ErrInvalidDates
+	}
+
+	promo.DateUpdated = time.Now()
+
+	tran := func(ctx context.Context) error {
+		if err := c.storer.Update(ctx, promo); err != nil {
+			return fmt.Errorf("update: %w", err)
+		}
+		return c.record(ctx, audit.ActionUpdate, before, promo)
+	}
+
+	if err := c.storer.WithinTran(ctx, tran); err != nil {
+		return Promotion{}, err
+	}
+
+	return promo, nil
+}
+
+// Delete removes a promotion from the database. Orders keep the discounts
+// they were given.
+func (c *Core) Delete(ctx context.Context, promo Promotion) error {
+	tran := func(ctx context.Context) error {
+		if err := c.storer.Delete(ctx, promo); err != nil {
+			return fmt.Errorf("delete: %w", err)
+		}
+
+		ne := audit.NewEntry{
+			Action:     audit.ActionDelete,
+			EntityType: audit.EntityPromotion,
+			EntityID:   promo.ID,
+			Before:     auditFields(promo),
+		}
+		if _, err := c.auditCore.Record(ctx, ne); err != nil {
+			return fmt.Errorf("audit: %w", err)
+		}
+
+		return nil
+	}
+
+	return c.storer.WithinTran(ctx, tran)
+}
+
+// Query retrieves a list of existing promotions from the database.
+func (c *Core) Query(ctx context.Context, filter QueryFilter, orderBy order.By, pageNumber int, rowsPerPage int) ([]Promotion, error) {
+	promos, err := c.storer.Query(ctx, filter, orderBy, pageNumber, rowsPerPage)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+	return promos, nil
+}
+
+// Count returns the total number of promotions in the store.
+func (c *Core) Count(ctx context.Context, filter QueryFilter) (int, error) {
+	count, err := c.storer.Count(ctx, filter)
+	if err != nil {
+		return 0, fmt.Errorf("count: %w", err)
+	}
+	return count, nil
+}
+
+// QueryByID gets the specified promotion from the database.
+func (c *Core) QueryByID(ctx context.Context, promotionID uuid.UUID) (Promotion, error) {
+	promo, err := c.storer.QueryByID(ctx, promotionID)
+	if err != nil {
+		return Promotion{}, fmt.Errorf("query: promotionID[%s]: %w", promotionID, err)
+	}
+	return promo, nil
+}
+
+// QueryByCode gets the promotion redeemed with the code. Codes are not case
+// sensitive.
+func (c *Core) QueryByCode(ctx context.Context, code string) (Promotion, error) {
+	promo, err := c.storer.QueryByCode(ctx, normalizeCode(code))
+	if err != nil {
+		return Promotion{}, fmt.Errorf("query: code[%s]: %w", code, err)
+	}
+	return promo, nil
+}
+
+// Apply works out the discount the promotion redeemed with the code gives a
+// user on the items. Nothing is recorded, call Redeem once the order is
+// stored. It fails with ErrInactive, ErrUsedUp, ErrMinOrder or
+// ErrNotApplicable when the promotion can't be used for the items.
+func (c *Core) Apply(ctx context.Context, code string, userID uuid.UUID, items []Item) (Application, error) {
+	promo, err := c.QueryByCode(ctx, code)
+	if err != nil {
+		return Application{}, err
+	}
+
+	if !promo.Active(time.Now()) {
+		return Application{}, fmt.Errorf("code[%s]: %w", promo.Code, ErrInactive)
+	}
+
+	if promo.MaxUses > 0 && promo.Uses >= promo.MaxUses {
+		return Application{}, fmt.Errorf("code[%s]: %w", promo.Code, ErrUsedUp)
+	}
+
+	if promo.MaxUsesPerUser > 0 {
+		n, err := c.storer.CountRedemptions(ctx, promo.ID, userID)
+		if err != nil {
+			return Application{}, fmt.Errorf("countredemptions: code[%s]: %w", promo.Code, err)
+		}
+		if n >= promo.MaxUsesPerUser {
+			return Application{}, fmt.Errorf("code[%s]: %w", promo.Code, ErrUsedUp)
+		}
+	}
+
+	var subtotal money.Money
+	for _, item := range items {
+		if subtotal, err = subtotal.Add(item.Total); err != nil {
+			return Application{}, fmt.Errorf("subtotal: %w", err)
+		}
+	}
+
+	if !promo.MinOrder.IsZero() {
+		cmp, err := subtotal.Cmp(promo.MinOrder)
+		if err != nil {
+			return Application{}, fmt.Errorf("minorder: %w", err)
+		}
+		if cmp < 0 {
+			return Application{}, fmt.Errorf("code[%s]: %w", promo.Code, ErrMinOrder)
+		}
+	}
+
+	eligible, err := c.eligible(ctx, promo, items)
+	if err != nil {
+		return Application{}, err
+	}
+
+	amounts, err := discount(promo, items, eligible)
+	if err != nil {
+		return Application{}, fmt.Errorf("discount: code[%s]: %w", promo.Code, err)
+	}
+
+	app := Application{
+		Promotion: promo,
+		Amounts:   amounts,
+	}
+	for _, amount := range amounts {
+		if app.Total, err = app.Total.Add(amount); err != nil {
+			return Application{}, fmt.Errorf("total: %w", err)
+		}
+	}
+
+	if app.Total.IsZero() {
+		return Application{}, fmt.Errorf("code[%s]: %w", promo.Code, ErrNotApplicable)
+	}
+
+	return app, nil
+}
+
+// Redeem records that the promotion of the application was used in an
+// order. Call it with the context of the transaction the order is stored in,
+// after storing it. It fails with ErrUsedUp when a usage limit was reached
+// in the meantime.
+func (c *Core) Redeem(ctx context.Context, app Application, orderID uuid.UUID, userID uuid.UUID) error {
+	rdm := Redemption{
+		PromotionID: app.Promotion.ID,
+		OrderID:     orderID,
+		UserID:      userID,
+		Amount:      app.Total,
+		DateCreated: time.Now(),
+	}
+
+	if err := c.storer.Redeem(ctx, rdm); err != nil {
+		return fmt.Errorf("redeem: code[%s] orderID[%s]: %w", app.Promotion.Code, orderID, err)
+	}
+
+	return nil
+}
+
+// Release gives back the uses of the promotions redeemed in a cancelled
+// order.
+func (c *Core) Release(ctx context.Context, orderID uuid.UUID) error {
+	if err := c.storer.Release(ctx, orderID); err != nil {
+		return fmt.Errorf("release: orderID[%s]: %w", orderID, err)
+	}
+	return nil
+}
+
+// =============================================================================
+
+// eligible reports which of the items the promotion applies to.
+func (c *Core) eligible(ctx context.Context, promo Promotion, items []Item) ([]bool, error) {
+	eligible := make([]bool, len(items))
+
+	if !promo.Scoped() {
+		for i := range eligible {
+			eligible[i] = true
+		}
+		return eligible, nil
+	}
+
+	productIDs := make([]uuid.UUID, len(items))
+	for i, item := range items {
+		productIDs[i] = item.ProductID
+	}
+
+	inScope, err := c.storer.InScope(ctx, promo, productIDs)
+	if err != nil {
+		return nil, fmt.Errorf("inscope: code[%s]: %w", promo.Code, err)
+	}
+
+	scoped := make(map[uuid.UUID]bool, len(inScope))
+	for _, id := range inScope {
+		scoped[id] = true
+	}
+
+	for i, item := range items {
+		eligible[i] = scoped[item.ProductID]
+	}
+
+	return eligible, nil
+}
+
+// record writes an audit entry describing the change between the two
+// versions of the promotion. A zero value before represents a newly created
+// promotion.
+func (c *Core) record(ctx context.Context, action string, before Promotion, after Promotion) error {
+	ne := audit.NewEntry{
+		Action:     action,
+		EntityType: audit.EntityPromotion,
+		EntityID:   after.ID,
+		After:      auditFields(after),
+	}
+	if before.ID != uuid.Nil {
+		ne.Before = auditFields(before)
+	}
+
+	if _, err := c.auditCore.Record(ctx, ne); err != nil {
+		return fmt.Errorf("audit: %w", err)
+	}
+
+	return nil
+}
+
+// auditFields returns the set of promotion fields that are tracked by the
+// audit log.
+func auditFields(promo Promotion) map[string]any {
+	fields := map[string]any{
+		"code":           promo.Code,
+		"kind":           promo.Kind.Name(),
+		"enabled":        promo.Enabled,
+		"minOrder":       promo.MinOrder,
+		"maxUses":        promo.MaxUses,
+		"maxUsesPerUser": promo.MaxUsesPerUser,
+		"dateStarts":     promo.DateStarts,
+		"dateEnds":       nil,
+	}
+
+	switch promo.Kind {
+	case KindPercent:
+		fields["percent"] = promo.Percent
+	case KindFixed:
+		fields["amount"] = promo.Amount
+	}
+
+	if !promo.DateEnds.IsZero() {
+		fields["dateEnds"] = promo.DateEnds
+	}
+
+	return fields
+}
+
+// normalizeCode returns the form codes are stored and looked up in.
+func normalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
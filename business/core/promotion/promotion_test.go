@@ -0,0 +1,255 @@
+package promotion_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"runtime/debug"
+	"testing"
+	"time"
+
+	"github.com/aleury/service/business/core/product"
+	"github.com/aleury/service/business/core/promotion"
+	"github.com/aleury/service/business/core/salesorder"
+	"github.com/aleury/service/business/core/user"
+	"github.com/aleury/service/business/data/dbtest"
+	"github.com/aleury/service/business/data/money"
+	"github.com/aleury/service/foundation/docker"
+)
+
+var c *docker.Container
+
+func TestMain(m *testing.M) {
+	var err error
+	c, err = dbtest.StartDB()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer dbtest.StopDB(c)
+
+	m.Run()
+}
+
+func Test_Promotion(t *testing.T) {
+	t.Run("create", create)
+	t.Run("limits", limits)
+}
+
+// =============================================================================
+
+func create(t *testing.T) {
+	test := dbtest.NewTest(t, c)
+	defer func() {
+		if r := recover(); r != nil {
+			t.Log(r)
+			t.Error(string(debug.Stack()))
+		}
+		test.Teardown()
+	}()
+
+	api := test.CoreAPIs
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	t.Log("Go seeding...")
+
+	usrs, _, err := seed(ctx, api)
+	if err != nil {
+		t.Fatalf("Seeding error: %s", err)
+	}
+
+	// -------------------------------------------------------------------------
+
+	np := promotion.NewPromotion{
+		TenantID: usrs[0].TenantID,
+		Code:     "save10",
+		Kind:     promotion.KindPercent,
+	}
+
+	if _, err := api.Promotion.Create(ctx, np); !errors.Is(err, promotion.ErrInvalidValue) {
+		t.Errorf("Should NOT be able to create a percentage promotion without a percent: %v.", err)
+	}
+
+	np.Percent = 10
+	np.DateStarts = time.Now().Add(time.Hour)
+	np.DateEnds = time.Now()
+	if _, err := api.Promotion.Create(ctx, np); !errors.Is(err, promotion.ErrInvalidDates) {
+		t.Errorf("Should NOT be able to create a promotion that ends before it starts: %v.", err)
+	}
+
+	np.DateStarts = time.Time{}
+	np.DateEnds = time.Time{}
+	promo, err := api.Promotion.Create(ctx, np)
+	if err != nil {
+		t.Fatalf("Should be able to create promotion: %s.", err)
+	}
+
+	if promo.Code != "SAVE10" || !promo.Enabled {
+		t.Errorf("Should create an enabled promotion with the code in upper case: %+v", promo)
+	}
+
+	if _, err := api.Promotion.Create(ctx, np); !errors.Is(err, promotion.ErrUniqueCode) {
+		t.Errorf("Should NOT be able to create a promotion with the same code: %v.", err)
+	}
+
+	saved, err := api.Promotion.QueryByCode(ctx, "Save10")
+	if err != nil {
+		t.Fatalf("Should be able to retrieve promotion by code: %s.", err)
+	}
+
+	if saved.ID != promo.ID {
+		t.Errorf("Should find the promotion regardless of the case of the code: got %s want %s", saved.ID, promo.ID)
+	}
+
+	// -------------------------------------------------------------------------
+
+	enabled := false
+	if _, err := api.Promotion.Update(ctx, saved, promotion.UpdatePromotion{Enabled: &enabled}); err != nil {
+		t.Fatalf("Should be able to disable promotion: %s.", err)
+	}
+
+	if _, err := api.Promotion.Apply(ctx, "SAVE10", usrs[0].ID, nil); !errors.Is(err, promotion.ErrInactive) {
+		t.Errorf("Should NOT be able to apply a disabled promotion: %v.", err)
+	}
+}
+
+func limits(t *testing.T) {
+	test := dbtest.NewTest(t, c)
+	defer func() {
+		if r := recover(); r != nil {
+			t.Log(r)
+			t.Error(string(debug.Stack()))
+		}
+		test.Teardown()
+	}()
+
+	api := test.CoreAPIs
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	t.Log("Go seeding...")
+
+	usrs, prds, err := seed(ctx, api)
+	if err != nil {
+		t.Fatalf("Seeding error: %s", err)
+	}
+
+	promo, err := api.Promotion.Create(ctx, promotion.NewPromotion{
+		TenantID:       usrs[0].TenantID,
+		Code:           "SAVE10",
+		Kind:           promotion.KindPercent,
+		Percent:        10,
+		MinOrder:       money.MustParse("15.00", money.USD),
+		MaxUses:        2,
+		MaxUsesPerUser: 1,
+	})
+	if err != nil {
+		t.Fatalf("Should be able to create promotion: %s.", err)
+	}
+
+	order := func(usr user.User, prd product.Product) (salesorder.Order, error) {
+		return api.SalesOrder.Create(ctx, salesorder.NewOrder{
+			UserID:        usr.ID,
+			TenantID:      usr.TenantID,
+			Lines:         []salesorder.NewLine{{ProductID: prd.ID, Quantity: 1}},
+			PromotionCode: promo.Code,
+		})
+	}
+
+	// -------------------------------------------------------------------------
+
+	first, err := order(usrs[0], prds[0])
+	if err != nil {
+		t.Fatalf("Should be able to order with the promotion: %s.", err)
+	}
+
+	if want := money.MustParse("18.00", money.USD); !first.Total.Equal(want) {
+		t.Errorf("Should take the discount off the order: got %s want %s", first.Total, want)
+	}
+
+	if _, err := order(usrs[0], prds[0]); !errors.Is(err, promotion.ErrUsedUp) {
+		t.Errorf("Should NOT be able to redeem a promotion more often than a user may: %v.", err)
+	}
+
+	if _, err := order(usrs[1], prds[1]); !errors.Is(err, promotion.ErrMinOrder) {
+		t.Errorf("Should NOT be able to redeem a promotion below its minimum order: %v.", err)
+	}
+
+	if _, err := order(usrs[1], prds[0]); err != nil {
+		t.Fatalf("Should be able to order with the promotion: %s.", err)
+	}
+
+	if _, err := order(usrs[2], prds[0]); !errors.Is(err, promotion.ErrUsedUp) {
+		t.Errorf("Should NOT be able to redeem a promotion more often than it may: %v.", err)
+	}
+
+	saved, err := api.Promotion.QueryByID(ctx, promo.ID)
+	if err != nil {
+		t.Fatalf("Should be able to retrieve promotion by ID: %s.", err)
+	}
+
+	if saved.Uses != 2 {
+		t.Errorf("Should count the uses of the promotion: got %d", saved.Uses)
+	}
+
+	// -------------------------------------------------------------------------
+
+	if _, err := api.SalesOrder.Transition(ctx, first, salesorder.StatusCancelled); err != nil {
+		t.Fatalf("Should be able to cancel an order: %s.", err)
+	}
+
+	if _, err := order(usrs[2], prds[0]); err != nil {
+		t.Errorf("Should be able to redeem the use given back by a cancelled order: %s.", err)
+	}
+
+	prd, err := api.Product.QueryByID(ctx, prds[0].ID)
+	if err != nil {
+		t.Fatalf("Should be able to retrieve product by ID: %s.", err)
+	}
+
+	if prd.Quantity != 8 {
+		t.Errorf("Should NOT take any stock for orders the promotion was refused for: got %d", prd.Quantity)
+	}
+}
+
+// =============================================================================
+
+// seed returns three users of the seeded tenant and a product above and
+// below the minimum order of the tests.
+func seed(ctx context.Context, api dbtest.CoreAPIs) ([]user.User, []product.Product, error) {
+	usrs, err := api.User.Query(ctx, user.QueryFilter{}, user.DefaultOrderBy, 1, 2)
+	if err != nil {
+		return nil, nil, fmt.Errorf("seeding users: %w", err)
+	}
+
+	usr, err := api.User.Create(ctx, user.NewUser{
+		TenantID:        usrs[0].TenantID,
+		Name:            "Carol Gopher",
+		Email:           mail.Address{Address: "carol@example.com"},
+		Roles:           []user.Role{user.RoleUser},
+		Password:        "gophers",
+		PasswordConfirm: "gophers",
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("seeding users: %w", err)
+	}
+	usrs = append(usrs, usr)
+
+	nps := []product.NewProduct{
+		{Name: "McDonalds Toys", Cost: money.MustParse("20.00", money.USD), Quantity: 10, UserID: usrs[0].ID},
+		{Name: "Comic Books", Cost: money.MustParse("5.00", money.USD), Quantity: 10, UserID: usrs[0].ID},
+	}
+
+	prds := make([]product.Product, len(nps))
+	for i, np := range nps {
+		if prds[i], err = api.Product.Create(ctx, np); err != nil {
+			return nil, nil, fmt.Errorf("seeding products: %w", err)
+		}
+	}
+
+	return usrs, prds, nil
+}
@@ -0,0 +1,41 @@
+package promotiondb
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aleury/service/business/core/promotion"
+	"github.com/aleury/service/business/core/tenant"
+	"github.com/google/uuid"
+)
+
+func (s *Store) applyFilter(ctx context.Context, filter promotion.QueryFilter, data map[string]any, buf *bytes.Buffer) {
+	var wc []string
+
+	if tenantID := tenant.GetTenantID(ctx); tenantID != uuid.Nil {
+		data["tenant_id"] = tenantID
+		wc = append(wc, "tenant_id = :tenant_id")
+	}
+
+	if filter.ID != nil {
+		data["promotion_id"] = *filter.ID
+		wc = append(wc, "promotion_id = :promotion_id")
+	}
+
+	if filter.Code != nil {
+		data["code"] = fmt.Sprintf("%%%s%%", *filter.Code)
+		wc = append(wc, "code ILIKE :code")
+	}
+
+	if filter.Enabled != nil {
+		data["enabled"] = *filter.Enabled
+		wc = append(wc, "enabled = :enabled")
+	}
+
+	if len(wc) > 0 {
+		buf.WriteString(" WHERE ")
+		buf.WriteString(strings.Join(wc, " AND "))
+	}
+}
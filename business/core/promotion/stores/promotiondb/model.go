@@ -0,0 +1,152 @@
+package promotiondb
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/aleury/service/business/core/promotion"
+	"github.com/aleury/service/business/data/money"
+	"github.com/aleury/service/business/sys/database/pgx/dbarray"
+	"github.com/google/uuid"
+)
+
+// dbPromotion represents the structure we need for moving data
+// between the app and the database.
+type dbPromotion struct {
+	ID             uuid.UUID      `db:"promotion_id"`
+	TenantID       uuid.UUID      `db:"tenant_id"`
+	Code           string         `db:"code"`
+	Kind           string         `db:"kind"`
+	Percent        sql.NullInt32  `db:"percent"`
+	Amount         *money.Money   `db:"amount"`
+	MinOrder       money.Money    `db:"min_order"`
+	MaxUses        sql.NullInt32  `db:"max_uses"`
+	MaxUsesPerUser sql.NullInt32  `db:"max_uses_per_user"`
+	Uses           int            `db:"uses"`
+	ProductIDs     dbarray.String `db:"product_ids"`
+	CategoryIDs    dbarray.String `db:"category_ids"`
+	Enabled        bool           `db:"enabled"`
+	DateStarts     time.Time      `db:"date_starts"`
+	DateEnds       sql.NullTime   `db:"date_ends"`
+	DateCreated    time.Time      `db:"date_created"`
+	DateUpdated    time.Time      `db:"date_updated"`
+}
+
+func toDBPromotion(promo promotion.Promotion) dbPromotion {
+	dbPromo := dbPromotion{
+		ID:       promo.ID,
+		TenantID: promo.TenantID,
+		Code:     promo.Code,
+		Kind:     promo.Kind.Name(),
+		Percent: sql.NullInt32{
+			Int32: int32(promo.Percent),
+			Valid: promo.Percent != 0,
+		},
+		MinOrder: promo.MinOrder,
+		MaxUses: sql.NullInt32{
+			Int32: int32(promo.MaxUses),
+			Valid: promo.MaxUses != 0,
+		},
+		MaxUsesPerUser: sql.NullInt32{
+			Int32: int32(promo.MaxUsesPerUser),
+			Valid: promo.MaxUsesPerUser != 0,
+		},
+		Uses:        promo.Uses,
+		ProductIDs:  toDBIDs(promo.ProductIDs),
+		CategoryIDs: toDBIDs(promo.CategoryIDs),
+		Enabled:     promo.Enabled,
+		DateStarts:  promo.DateStarts.UTC(),
+		DateEnds: sql.NullTime{
+			Time:  promo.DateEnds.UTC(),
+			Valid: !promo.DateEnds.IsZero(),
+		},
+		DateCreated: promo.DateCreated.UTC(),
+		DateUpdated: promo.DateUpdated.UTC(),
+	}
+
+	if promo.Kind == promotion.KindFixed {
+		amount := promo.Amount
+		dbPromo.Amount = &amount
+	}
+
+	return dbPromo
+}
+
+func toCorePromotion(dbPromo dbPromotion) promotion.Promotion {
+	promo := promotion.Promotion{
+		ID:             dbPromo.ID,
+		TenantID:       dbPromo.TenantID,
+		Code:           dbPromo.Code,
+		Kind:           promotion.MustParseKind(dbPromo.Kind),
+		Percent:        int(dbPromo.Percent.Int32),
+		MinOrder:       dbPromo.MinOrder,
+		MaxUses:        int(dbPromo.MaxUses.Int32),
+		MaxUsesPerUser: int(dbPromo.MaxUsesPerUser.Int32),
+		Uses:           dbPromo.Uses,
+		ProductIDs:     toCoreIDs(dbPromo.ProductIDs),
+		CategoryIDs:    toCoreIDs(dbPromo.CategoryIDs),
+		Enabled:        dbPromo.Enabled,
+		DateStarts:     dbPromo.DateStarts.In(time.Local),
+		DateCreated:    dbPromo.DateCreated.In(time.Local),
+		DateUpdated:    dbPromo.DateUpdated.In(time.Local),
+	}
+
+	if dbPromo.Amount != nil {
+		promo.Amount = *dbPromo.Amount
+	}
+
+	if dbPromo.DateEnds.Valid {
+		promo.DateEnds = dbPromo.DateEnds.Time.In(time.Local)
+	}
+
+	return promo
+}
+
+func toCorePromotionSlice(dbPromotions []dbPromotion) []promotion.Promotion {
+	promos := make([]promotion.Promotion, len(dbPromotions))
+	for i, dbPromo := range dbPromotions {
+		promos[i] = toCorePromotion(dbPromo)
+	}
+	return promos
+}
+
+func toDBIDs(ids []uuid.UUID) dbarray.String {
+	strs := make([]string, len(ids))
+	for i, id := range ids {
+		strs[i] = id.String()
+	}
+	return dbarray.String(strs)
+}
+
+func toCoreIDs(strs dbarray.String) []uuid.UUID {
+	if len(strs) == 0 {
+		return nil
+	}
+
+	ids := make([]uuid.UUID, len(strs))
+	for i, str := range strs {
+		ids[i] = uuid.MustParse(str)
+	}
+	return ids
+}
+
+// =============================================================================
+
+// dbRedemption represents the use of a promotion in an order.
+type dbRedemption struct {
+	PromotionID uuid.UUID   `db:"promotion_id"`
+	OrderID     uuid.UUID   `db:"order_id"`
+	UserID      uuid.UUID   `db:"user_id"`
+	Amount      money.Money `db:"amount"`
+	DateCreated time.Time   `db:"date_created"`
+}
+
+func toDBRedemption(rdm promotion.Redemption) dbRedemption {
+	return dbRedemption{
+		PromotionID: rdm.PromotionID,
+		OrderID:     rdm.OrderID,
+		UserID:      rdm.UserID,
+		Amount:      rdm.Amount,
+		DateCreated: rdm.DateCreated.UTC(),
+	}
+}
@@ -0,0 +1,24 @@
+package promotiondb
+
+import (
+	"fmt"
+
+	"github.com/aleury/service/business/core/promotion"
+	"github.com/aleury/service/business/data/order"
+)
+
+var orderByFields = map[string]string{
+	promotion.OrderByID:         "promotion_id",
+	promotion.OrderByCode:       "code",
+	promotion.OrderByUses:       "uses",
+	promotion.OrderByDateStarts: "date_starts",
+	promotion.OrderByDateEnds:   "date_ends",
+}
+
+func orderByClause(orderBy order.By) (string, error) {
+	by, exists := orderByFields[orderBy.Field]
+	if !exists {
+		return "", fmt.Errorf("field %q does not exist", orderBy.Field)
+	}
+	return fmt.Sprintf(" ORDER BY %s %s", by, orderBy.Direction), nil
+}
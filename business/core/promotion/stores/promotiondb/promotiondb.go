@@ -0,0 +1,330 @@
+// Package promotiondb contains promotion related CRUD functionality.
+package promotiondb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aleury/service/business/core/promotion"
+	"github.com/aleury/service/business/core/tenant"
+	"github.com/aleury/service/business/data/order"
+	database "github.com/aleury/service/business/sys/database/pgx"
+	"github.com/aleury/service/business/sys/database/pgx/dbarray"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// Store manages the set of APIs for promotion database access.
+type Store struct {
+	log *zap.SugaredLogger
+	db  *sqlx.DB
+}
+
+// NewStore constructs the api for data access.
+func NewStore(log *zap.SugaredLogger, db *sqlx.DB) *Store {
+	return &Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// WithinTran runs passed function and does commit/rollback at the end. Every
+// store call made with the context handed to the function joins the
+// transaction.
+func (s *Store) WithinTran(ctx context.Context, fn func(ctx context.Context) error) error {
+	return database.WithinTran(ctx, s.log, s.db, fn)
+}
+
+// Create inserts a new promotion into the database.
+func (s *Store) Create(ctx context.Context, promo promotion.Promotion) error {
+	const q = `
+	INSERT INTO promotions
+		(promotion_id, tenant_id, code, kind, percent, amount, min_order, max_uses, max_uses_per_user,
+		 uses, product_ids, category_ids, enabled, date_starts, date_ends, date_created, date_updated)
+	VALUES
+		(:promotion_id, :tenant_id, :code, :kind, :percent, :amount, :min_order, :max_uses, :max_uses_per_user,
+		 :uses, :product_ids, :category_ids, :enabled, :date_starts, :date_ends, :date_created, :date_updated)`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, toDBPromotion(promo)); err != nil {
+		if errors.Is(err, database.ErrDBDuplicatedEntry) {
+			return fmt.Errorf("namedexeccontext: %w", promotion.ErrUniqueCode)
+		}
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Update replaces a promotion document in the database. The number of uses
+// is kept by redemptions and left alone.
+func (s *Store) Update(ctx context.Context, promo promotion.Promotion) error {
+	const q = `
+	UPDATE
+		promotions
+	SET
+		"enabled" = :enabled,
+		"min_order" = :min_order,
+		"max_uses" = :max_uses,
+		"max_uses_per_user" = :max_uses_per_user,
+		"date_starts" = :date_starts,
+		"date_ends" = :date_ends,
+		"date_updated" = :date_updated
+	WHERE
+		promotion_id = :promotion_id`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, toDBPromotion(promo)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes a promotion from the database.
+func (s *Store) Delete(ctx context.Context, promo promotion.Promotion) error {
+	data := map[string]any{
+		"promotion_id": promo.ID,
+	}
+
+	const q = `
+	DELETE FROM
+		promotions
+	WHERE
+		promotion_id = :promotion_id`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Query retrieves a list of existing promotions from the database.
+func (s *Store) Query(ctx context.Context, filter promotion.QueryFilter, orderBy order.By, pageNumber int, rowsPerPage int) ([]promotion.Promotion, error) {
+	data := map[string]any{
+		"offset":        (pageNumber - 1) * rowsPerPage,
+		"rows_per_page": rowsPerPage,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		promotions`
+
+	buf := bytes.NewBufferString(q)
+	s.applyFilter(ctx, filter, data, buf)
+
+	orderByClause, err := orderByClause(orderBy)
+	if err != nil {
+		return nil, err
+	}
+
+	buf.WriteString(orderByClause)
+	buf.WriteString(" OFFSET :offset LIMIT :rows_per_page")
+
+	var dbPromos []dbPromotion
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, buf.String(), data, &dbPromos); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toCorePromotionSlice(dbPromos), nil
+}
+
+// Count returns the total number of promotions in the DB.
+func (s *Store) Count(ctx context.Context, filter promotion.QueryFilter) (int, error) {
+	data := map[string]any{}
+
+	const q = `
+	SELECT
+		COUNT(*)
+	FROM
+		promotions`
+
+	buf := bytes.NewBufferString(q)
+	s.applyFilter(ctx, filter, data, buf)
+
+	var result struct {
+		Count int `db:"count"`
+	}
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, buf.String(), data, &result); err != nil {
+		return 0, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return result.Count, nil
+}
+
+// QueryByID gets the specified promotion from the database.
+func (s *Store) QueryByID(ctx context.Context, promotionID uuid.UUID) (promotion.Promotion, error) {
+	data := map[string]any{
+		"promotion_id": promotionID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		promotions
+	WHERE
+		promotion_id = :promotion_id`
+
+	var dbPromo dbPromotion
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q+tenant.Scope(ctx, data, "tenant_id"), data, &dbPromo); err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return promotion.Promotion{}, fmt.Errorf("namedquerystruct: %w", promotion.ErrNotFound)
+		}
+		return promotion.Promotion{}, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return toCorePromotion(dbPromo), nil
+}
+
+// QueryByCode gets the promotion with the specified code from the database.
+func (s *Store) QueryByCode(ctx context.Context, code string) (promotion.Promotion, error) {
+	data := map[string]any{
+		"code": code,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		promotions
+	WHERE
+		code = :code`
+
+	var dbPromo dbPromotion
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q+tenant.Scope(ctx, data, "tenant_id"), data, &dbPromo); err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return promotion.Promotion{}, fmt.Errorf("namedquerystruct: %w", promotion.ErrNotFound)
+		}
+		return promotion.Promotion{}, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return toCorePromotion(dbPromo), nil
+}
+
+// InScope returns which of the products the promotion applies to. A product
+// is in scope when it or the product it is a variant of is named by the
+// promotion, or is assigned to one of its categories or a category below
+// them.
+func (s *Store) InScope(ctx context.Context, promo promotion.Promotion, productIDs []uuid.UUID) ([]uuid.UUID, error) {
+	if len(productIDs) == 0 {
+		return nil, nil
+	}
+
+	data := map[string]any{
+		"product_ids":       dbarray.Array([]string(toDBIDs(productIDs))),
+		"scope_product_ids": dbarray.Array([]string(toDBIDs(promo.ProductIDs))),
+		"category_ids":      dbarray.Array([]string(toDBIDs(promo.CategoryIDs))),
+	}
+
+	const q = `
+	WITH RECURSIVE subtree AS (
+		SELECT
+			category_id
+		FROM
+			categories
+		WHERE
+			category_id = ANY(:category_ids)
+		UNION
+		SELECT
+			c.category_id
+		FROM
+			categories AS c
+		JOIN
+			subtree AS s ON c.parent_id = s.category_id
+	)
+	SELECT
+		p.product_id
+	FROM
+		products AS p
+	WHERE
+		p.product_id = ANY(:product_ids) AND (
+			p.product_id = ANY(:scope_product_ids) OR
+			p.parent_id = ANY(:scope_product_ids) OR
+			EXISTS (
+				SELECT 1 FROM product_categories AS pc JOIN subtree USING (category_id)
+				WHERE pc.product_id = COALESCE(p.parent_id, p.product_id)
+			)
+		)`
+
+	var result []struct {
+		ID uuid.UUID `db:"product_id"`
+	}
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &result); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	ids := make([]uuid.UUID, len(result))
+	for i, r := range result {
+		ids[i] = r.ID
+	}
+
+	return ids, nil
+}
+
+// CountRedemptions returns how many times a user has redeemed a promotion.
+func (s *Store) CountRedemptions(ctx context.Context, promotionID uuid.UUID, userID uuid.UUID) (int, error) {
+	data := map[string]any{
+		"promotion_id": promotionID,
+		"user_id":      userID,
+	}
+
+	const q = `
+	SELECT
+		COUNT(*)
+	FROM
+		promotion_redemptions
+	WHERE
+		promotion_id = :promotion_id AND user_id = :user_id`
+
+	var result struct {
+		Count int `db:"count"`
+	}
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &result); err != nil {
+		return 0, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return result.Count, nil
+}
+
+// Redeem records the use of a promotion in an order. ErrUsedUp is returned
+// when a usage limit of the promotion has been reached.
+func (s *Store) Redeem(ctx context.Context, rdm promotion.Redemption) error {
+	const q = `
+	SELECT
+		app_redeem_promotion(:promotion_id, :order_id, :user_id, :amount, :date_created) AS redeemed`
+
+	var result struct {
+		Redeemed bool `db:"redeemed"`
+	}
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, toDBRedemption(rdm), &result); err != nil {
+		return fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	if !result.Redeemed {
+		return promotion.ErrUsedUp
+	}
+
+	return nil
+}
+
+// Release removes the redemptions of a cancelled order.
+func (s *Store) Release(ctx context.Context, orderID uuid.UUID) error {
+	data := map[string]any{
+		"order_id": orderID,
+	}
+
+	const q = `
+	SELECT
+		app_release_promotions(:order_id)`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,36 @@
+package reservation
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Reservation represents stock of a product held for a user until it is
+// confirmed by an order, released or runs out.
+type Reservation struct {
+	ID           uuid.UUID
+	TenantID     uuid.UUID
+	ProductID    uuid.UUID
+	UserID       uuid.UUID
+	Quantity     int
+	Status       Status
+	OrderID      uuid.UUID
+	DateCreated  time.Time
+	DateExpires  time.Time
+	DateResolved time.Time
+}
+
+// Active reports whether the reservation still holds stock at the specified
+// time.
+func (r Reservation) Active(now time.Time) bool {
+	return r.Status == StatusHeld && now.Before(r.DateExpires)
+}
+
+// NewReservation is what we require to hold stock for a user.
+type NewReservation struct {
+	ProductID uuid.UUID
+	UserID    uuid.UUID
+	Quantity  int
+	TTL       time.Duration
+}
@@ -0,0 +1,172 @@
+// Package reservation provides the core business API for holding stock while
+// users check out.
+package reservation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aleury/service/business/core/product"
+	"github.com/google/uuid"
+)
+
+// Set of error variables for CRUD operations.
+var (
+	ErrNotFound     = errors.New("reservation not found")
+	ErrUnavailable  = errors.New("not enough stock available to reserve")
+	ErrNotHeld      = errors.New("reservation is no longer held")
+	ErrInvalidTTL   = errors.New("reservation ttl must be positive")
+	ErrInvalidQuant = errors.New("reservation quantity must be positive")
+)
+
+// Storer interface declares the behavior this package needs to persist and
+// retrieve data.
+type Storer interface {
+	Reserve(ctx context.Context, res Reservation) (Reservation, error)
+	Resolve(ctx context.Context, res Reservation) (Reservation, error)
+	Expire(ctx context.Context, now time.Time) (int, error)
+	QueryByID(ctx context.Context, reservationID uuid.UUID) (Reservation, error)
+	QueryByUser(ctx context.Context, userID uuid.UUID, pageNumber int, rowsPerPage int) ([]Reservation, error)
+	CountByUser(ctx context.Context, userID uuid.UUID) (int, error)
+}
+
+// Core manages the set of APIs for reservation access.
+type Core struct {
+	storer  Storer
+	prdCore *product.Core
+}
+
+// NewCore constructs a core for reservation api access.
+func NewCore(storer Storer, prdCore *product.Core) *Core {
+	return &Core{
+		storer:  storer,
+		prdCore: prdCore,
+	}
+}
+
+// Reserve holds stock of a product for a user for the TTL. It fails with
+// ErrUnavailable when less than the quantity is on hand and not already held
+// by other reservations. Parallel reservations of a product are serialized
+// by the database, so stock is never held twice.
+func (c *Core) Reserve(ctx context.Context, nr NewReservation) (Reservation, error) {
+	switch {
+	case nr.Quantity < 1:
+		return Reservation{}, ErrInvalidQuant
+	case nr.TTL <= 0:
+		return Reservation{}, ErrInvalidTTL
+	}
+
+	now := time.Now()
+
+	res := Reservation{
+		ID:          uuid.New(),
+		ProductID:   nr.ProductID,
+		UserID:      nr.UserID,
+		Quantity:    nr.Quantity,
+		Status:      StatusHeld,
+		DateCreated: now,
+		DateExpires: now.Add(nr.TTL),
+	}
+
+	res, err := c.storer.Reserve(ctx, res)
+	if err != nil {
+		if !errors.Is(err, ErrUnavailable) {
+			return Reservation{}, fmt.Errorf("reserve: productID[%s]: %w", nr.ProductID, err)
+		}
+
+		// Nothing was reserved, either because the product doesn't exist or
+		// because there isn't enough of it.
+		prd, err := c.prdCore.QueryByID(ctx, nr.ProductID)
+		if err != nil {
+			return Reservation{}, err
+		}
+		if prd.HasVariants() {
+			return Reservation{}, fmt.Errorf("reserve: productID[%s]: %w", nr.ProductID, product.ErrHasVariants)
+		}
+		return Reservation{}, fmt.Errorf("reserve: productID[%s]: %w", nr.ProductID, ErrUnavailable)
+	}
+
+	return res, nil
+}
+
+// Confirm marks a held reservation as used by an order. Call it with the
+// context of the transaction taking the stock for the order, before the
+// stock is taken, so the hold stops counting against it. It fails with
+// ErrNotHeld when the reservation was resolved or has expired.
+func (c *Core) Confirm(ctx context.Context, res Reservation, orderID uuid.UUID) (Reservation, error) {
+	now := time.Now()
+	if !res.Active(now) {
+		return Reservation{}, fmt.Errorf("confirm: reservationID[%s]: %w", res.ID, ErrNotHeld)
+	}
+
+	res.Status = StatusConfirmed
+	res.OrderID = orderID
+	res.DateResolved = now
+
+	return c.resolve(ctx, res)
+}
+
+// Release gives the stock held by a reservation back before it expires.
+func (c *Core) Release(ctx context.Context, res Reservation) (Reservation, error) {
+	if res.Status != StatusHeld {
+		return Reservation{}, fmt.Errorf("release: reservationID[%s]: %w", res.ID, ErrNotHeld)
+	}
+
+	res.Status = StatusReleased
+	res.DateResolved = time.Now()
+
+	return c.resolve(ctx, res)
+}
+
+// Expire marks the held reservations that ran out by the specified time as
+// expired and returns how many there were. Expired holds already stop
+// counting against stock, this only records that they ended.
+func (c *Core) Expire(ctx context.Context, now time.Time) (int, error) {
+	n, err := c.storer.Expire(ctx, now)
+	if err != nil {
+		return 0, fmt.Errorf("expire: now[%s]: %w", now, err)
+	}
+	return n, nil
+}
+
+// QueryByID gets the specified reservation.
+func (c *Core) QueryByID(ctx context.Context, reservationID uuid.UUID) (Reservation, error) {
+	res, err := c.storer.QueryByID(ctx, reservationID)
+	if err != nil {
+		return Reservation{}, fmt.Errorf("query: reservationID[%s]: %w", reservationID, err)
+	}
+	return res, nil
+}
+
+// QueryByUser retrieves the reservations of a user, newest first.
+func (c *Core) QueryByUser(ctx context.Context, userID uuid.UUID, pageNumber int, rowsPerPage int) ([]Reservation, error) {
+	rsvs, err := c.storer.QueryByUser(ctx, userID, pageNumber, rowsPerPage)
+	if err != nil {
+		return nil, fmt.Errorf("query: userID[%s]: %w", userID, err)
+	}
+	return rsvs, nil
+}
+
+// CountByUser returns the number of reservations of a user.
+func (c *Core) CountByUser(ctx context.Context, userID uuid.UUID) (int, error) {
+	count, err := c.storer.CountByUser(ctx, userID)
+	if err != nil {
+		return 0, fmt.Errorf("count: userID[%s]: %w", userID, err)
+	}
+	return count, nil
+}
+
+// resolve stores the outcome of a held reservation. ErrNotHeld is returned
+// when another request resolved it first.
+func (c *Core) resolve(ctx context.Context, res Reservation) (Reservation, error) {
+	res, err := c.storer.Resolve(ctx, res)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return Reservation{}, fmt.Errorf("resolve: reservationID[%s]: %w", res.ID, ErrNotHeld)
+		}
+		return Reservation{}, fmt.Errorf("resolve: reservationID[%s]: %w", res.ID, err)
+	}
+	return res, nil
+}
@@ -0,0 +1,313 @@
+package reservation_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"testing"
+	"time"
+
+	"github.com/aleury/service/business/core/product"
+	"github.com/aleury/service/business/core/reservation"
+	"github.com/aleury/service/business/core/salesorder"
+	"github.com/aleury/service/business/core/user"
+	"github.com/aleury/service/business/data/dbtest"
+	"github.com/aleury/service/business/data/money"
+	"github.com/aleury/service/foundation/docker"
+)
+
+var c *docker.Container
+
+func TestMain(m *testing.M) {
+	var err error
+	c, err = dbtest.StartDB()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer dbtest.StopDB(c)
+
+	m.Run()
+}
+
+func Test_Reservation(t *testing.T) {
+	t.Run("oversell", oversell)
+	t.Run("expiry", expiry)
+	t.Run("adjust", adjust)
+}
+
+// =============================================================================
+
+func oversell(t *testing.T) {
+	test := dbtest.NewTest(t, c)
+	defer func() {
+		if r := recover(); r != nil {
+			t.Log(r)
+			t.Error(string(debug.Stack()))
+		}
+		test.Teardown()
+	}()
+
+	api := test.CoreAPIs
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	t.Log("Go seeding...")
+
+	usrs, prd, err := seed(ctx, api)
+	if err != nil {
+		t.Fatalf("Seeding error: %s", err)
+	}
+
+	// -------------------------------------------------------------------------
+
+	res, err := api.Reservation.Reserve(ctx, reservation.NewReservation{
+		ProductID: prd.ID,
+		UserID:    usrs[0].ID,
+		Quantity:  3,
+		TTL:       time.Minute,
+	})
+	if err != nil {
+		t.Fatalf("Should be able to reserve stock: %s.", err)
+	}
+
+	if !res.Status.Equal(reservation.StatusHeld) {
+		t.Errorf("Should hold the stock: got %s", res.Status.Name())
+	}
+
+	nr := reservation.NewReservation{
+		ProductID: prd.ID,
+		UserID:    usrs[1].ID,
+		Quantity:  3,
+		TTL:       time.Minute,
+	}
+
+	if _, err := api.Reservation.Reserve(ctx, nr); !errors.Is(err, reservation.ErrUnavailable) {
+		t.Errorf("Should NOT be able to reserve stock held by another reservation: %v.", err)
+	}
+
+	no := salesorder.NewOrder{
+		UserID:   usrs[1].ID,
+		TenantID: usrs[1].TenantID,
+		Lines:    []salesorder.NewLine{{ProductID: prd.ID, Quantity: 3}},
+	}
+
+	if _, err := api.SalesOrder.Create(ctx, no); !errors.Is(err, product.ErrInsufficientStock) {
+		t.Errorf("Should NOT be able to order stock held by a reservation: %v.", err)
+	}
+
+	// -------------------------------------------------------------------------
+
+	no.Lines[0].ReservationID = res.ID
+	if _, err := api.SalesOrder.Create(ctx, no); !errors.Is(err, salesorder.ErrMismatch) {
+		t.Errorf("Should NOT be able to order with the reservation of another user: %v.", err)
+	}
+
+	no.UserID = usrs[0].ID
+	ord, err := api.SalesOrder.Create(ctx, no)
+	if err != nil {
+		t.Fatalf("Should be able to order the reserved stock: %s.", err)
+	}
+
+	saved, err := api.Reservation.QueryByID(ctx, res.ID)
+	if err != nil {
+		t.Fatalf("Should be able to retrieve reservation by ID: %s.", err)
+	}
+
+	if !saved.Status.Equal(reservation.StatusConfirmed) || saved.OrderID != ord.ID {
+		t.Errorf("Should have confirmed the reservation for the order: %+v", saved)
+	}
+
+	if _, err := api.SalesOrder.Create(ctx, no); !errors.Is(err, reservation.ErrNotHeld) {
+		t.Errorf("Should NOT be able to order with a confirmed reservation: %v.", err)
+	}
+
+	// -------------------------------------------------------------------------
+
+	nr.Quantity = 2
+	res, err = api.Reservation.Reserve(ctx, nr)
+	if err != nil {
+		t.Fatalf("Should be able to reserve what is left: %s.", err)
+	}
+
+	nr.Quantity = 1
+	if _, err := api.Reservation.Reserve(ctx, nr); !errors.Is(err, reservation.ErrUnavailable) {
+		t.Errorf("Should NOT be able to reserve more than is on hand: %v.", err)
+	}
+
+	if _, err := api.Reservation.Release(ctx, res); err != nil {
+		t.Fatalf("Should be able to release a reservation: %s.", err)
+	}
+
+	if _, err := api.Reservation.Reserve(ctx, nr); err != nil {
+		t.Errorf("Should be able to reserve stock that was released: %v.", err)
+	}
+
+	if _, err := api.Reservation.Release(ctx, res); !errors.Is(err, reservation.ErrNotHeld) {
+		t.Errorf("Should NOT be able to release a reservation twice: %v.", err)
+	}
+}
+
+func expiry(t *testing.T) {
+	test := dbtest.NewTest(t, c)
+	defer func() {
+		if r := recover(); r != nil {
+			t.Log(r)
+			t.Error(string(debug.Stack()))
+		}
+		test.Teardown()
+	}()
+
+	api := test.CoreAPIs
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	t.Log("Go seeding...")
+
+	usrs, prd, err := seed(ctx, api)
+	if err != nil {
+		t.Fatalf("Seeding error: %s", err)
+	}
+
+	// -------------------------------------------------------------------------
+
+	res, err := api.Reservation.Reserve(ctx, reservation.NewReservation{
+		ProductID: prd.ID,
+		UserID:    usrs[0].ID,
+		Quantity:  5,
+		TTL:       50 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("Should be able to reserve stock: %s.", err)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	other, err := api.Reservation.Reserve(ctx, reservation.NewReservation{
+		ProductID: prd.ID,
+		UserID:    usrs[1].ID,
+		Quantity:  5,
+		TTL:       time.Minute,
+	})
+	if err != nil {
+		t.Fatalf("Should be able to reserve stock held by an expired reservation: %s.", err)
+	}
+
+	no := salesorder.NewOrder{
+		UserID:   usrs[0].ID,
+		TenantID: usrs[0].TenantID,
+		Lines:    []salesorder.NewLine{{ProductID: prd.ID, Quantity: 5, ReservationID: res.ID}},
+	}
+
+	if _, err := api.SalesOrder.Create(ctx, no); !errors.Is(err, reservation.ErrNotHeld) {
+		t.Errorf("Should NOT be able to order with an expired reservation: %v.", err)
+	}
+
+	// -------------------------------------------------------------------------
+
+	n, err := api.Reservation.Expire(ctx, time.Now())
+	if err != nil {
+		t.Fatalf("Should be able to expire reservations: %s.", err)
+	}
+
+	if n != 1 {
+		t.Errorf("Should expire the reservation that ran out: got %d", n)
+	}
+
+	saved, err := api.Reservation.QueryByID(ctx, res.ID)
+	if err != nil {
+		t.Fatalf("Should be able to retrieve reservation by ID: %s.", err)
+	}
+
+	if !saved.Status.Equal(reservation.StatusExpired) {
+		t.Errorf("Should have marked the reservation expired: got %s", saved.Status.Name())
+	}
+
+	saved, err = api.Reservation.QueryByID(ctx, other.ID)
+	if err != nil {
+		t.Fatalf("Should be able to retrieve reservation by ID: %s.", err)
+	}
+
+	if !saved.Status.Equal(reservation.StatusHeld) {
+		t.Errorf("Should NOT expire a reservation that is still held: got %s", saved.Status.Name())
+	}
+}
+
+func adjust(t *testing.T) {
+	test := dbtest.NewTest(t, c)
+	defer func() {
+		if r := recover(); r != nil {
+			t.Log(r)
+			t.Error(string(debug.Stack()))
+		}
+		test.Teardown()
+	}()
+
+	api := test.CoreAPIs
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	t.Log("Go seeding...")
+
+	usrs, prd, err := seed(ctx, api)
+	if err != nil {
+		t.Fatalf("Seeding error: %s", err)
+	}
+
+	// -------------------------------------------------------------------------
+
+	if _, err := api.Reservation.Reserve(ctx, reservation.NewReservation{
+		ProductID: prd.ID,
+		UserID:    usrs[0].ID,
+		Quantity:  3,
+		TTL:       time.Minute,
+	}); err != nil {
+		t.Fatalf("Should be able to reserve stock: %s.", err)
+	}
+
+	na := product.NewAdjustment{Quantity: -3, Reason: product.ReasonLost}
+	if _, _, err := api.Product.Adjust(ctx, prd, na); !errors.Is(err, product.ErrInsufficientStock) {
+		t.Errorf("Should NOT be able to write off stock held by a reservation: %v.", err)
+	}
+
+	na.Quantity = -2
+	after, _, err := api.Product.Adjust(ctx, prd, na)
+	if err != nil {
+		t.Fatalf("Should be able to write off stock that isn't held: %s.", err)
+	}
+
+	if after.Quantity != 3 {
+		t.Errorf("Should leave the held stock on hand: got %d want %d", after.Quantity, 3)
+	}
+
+	na = product.NewAdjustment{Quantity: 1, Reason: product.ReasonFound}
+	if _, _, err := api.Product.Adjust(ctx, after, na); err != nil {
+		t.Errorf("Should be able to add stock while some is held: %s.", err)
+	}
+}
+
+// =============================================================================
+
+// seed returns two seeded users and a product with five in stock.
+func seed(ctx context.Context, api dbtest.CoreAPIs) ([]user.User, product.Product, error) {
+	usrs, err := api.User.Query(ctx, user.QueryFilter{}, user.DefaultOrderBy, 1, 2)
+	if err != nil {
+		return nil, product.Product{}, fmt.Errorf("seeding users: %w", err)
+	}
+
+	prd, err := api.Product.Create(ctx, product.NewProduct{
+		Name:     "Comic Books",
+		Cost:     money.MustParse("5.00", money.USD),
+		Quantity: 5,
+		UserID:   usrs[0].ID,
+	})
+	if err != nil {
+		return nil, product.Product{}, fmt.Errorf("seeding products: %w", err)
+	}
+
+	return usrs, prd, nil
+}
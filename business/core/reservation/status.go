@@ -0,0 +1,67 @@
+package reservation
+
+import (
+	"errors"
+)
+
+// Set of states a reservation can be in. Only held reservations keep stock
+// from being sold, and only until they expire.
+var (
+	StatusHeld      = Status{"HELD"}
+	StatusConfirmed = Status{"CONFIRMED"}
+	StatusReleased  = Status{"RELEASED"}
+	StatusExpired   = Status{"EXPIRED"}
+)
+
+// Set of known statuses.
+var statuses = map[string]Status{
+	StatusHeld.name:      StatusHeld,
+	StatusConfirmed.name: StatusConfirmed,
+	StatusReleased.name:  StatusReleased,
+	StatusExpired.name:   StatusExpired,
+}
+
+// Status represents the state of a reservation.
+type Status struct {
+	name string
+}
+
+// ParseStatus parses the string value and returns a status if one exists.
+func ParseStatus(value string) (Status, error) {
+	status, exists := statuses[value]
+	if !exists {
+		return Status{}, errors.New("invalid status")
+	}
+	return status, nil
+}
+
+// MustParseStatus parses the string value and returns a status if one
+// exists. If an error occurs the function panics.
+func MustParseStatus(value string) Status {
+	status, err := ParseStatus(value)
+	if err != nil {
+		panic(err)
+	}
+	return status
+}
+
+// Name returns the name of the status.
+func (s Status) Name() string {
+	return s.name
+}
+
+// UnmarshalText implements the unmarshal interface for JSON conversions.
+func (s *Status) UnmarshalText(data []byte) error {
+	s.name = string(data)
+	return nil
+}
+
+// MarshalText implements the marshal interface for JSON conversions.
+func (s Status) MarshalText() ([]byte, error) {
+	return []byte(s.name), nil
+}
+
+// Equal provides support for the go-cmp package and testing.
+func (s Status) Equal(s2 Status) bool {
+	return s.name == s2.name
+}
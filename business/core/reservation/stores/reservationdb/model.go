@@ -0,0 +1,72 @@
+package reservationdb
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/aleury/service/business/core/reservation"
+	"github.com/google/uuid"
+)
+
+// dbReservation represents a stock reservation in the database.
+type dbReservation struct {
+	ID           uuid.UUID     `db:"reservation_id"`
+	TenantID     uuid.UUID     `db:"tenant_id"`
+	ProductID    uuid.UUID     `db:"product_id"`
+	UserID       uuid.UUID     `db:"user_id"`
+	Quantity     int           `db:"quantity"`
+	Status       string        `db:"status"`
+	OrderID      uuid.NullUUID `db:"order_id"`
+	DateCreated  time.Time     `db:"date_created"`
+	DateExpires  time.Time     `db:"date_expires"`
+	DateResolved sql.NullTime  `db:"date_resolved"`
+}
+
+func toDBReservation(res reservation.Reservation) dbReservation {
+	return dbReservation{
+		ID:        res.ID,
+		TenantID:  res.TenantID,
+		ProductID: res.ProductID,
+		UserID:    res.UserID,
+		Quantity:  res.Quantity,
+		Status:    res.Status.Name(),
+		OrderID: uuid.NullUUID{
+			UUID:  res.OrderID,
+			Valid: res.OrderID != uuid.Nil,
+		},
+		DateCreated: res.DateCreated.UTC(),
+		DateExpires: res.DateExpires.UTC(),
+		DateResolved: sql.NullTime{
+			Time:  res.DateResolved.UTC(),
+			Valid: !res.DateResolved.IsZero(),
+		},
+	}
+}
+
+func toCoreReservation(dbRes dbReservation) reservation.Reservation {
+	res := reservation.Reservation{
+		ID:          dbRes.ID,
+		TenantID:    dbRes.TenantID,
+		ProductID:   dbRes.ProductID,
+		UserID:      dbRes.UserID,
+		Quantity:    dbRes.Quantity,
+		Status:      reservation.MustParseStatus(dbRes.Status),
+		OrderID:     dbRes.OrderID.UUID,
+		DateCreated: dbRes.DateCreated.In(time.Local),
+		DateExpires: dbRes.DateExpires.In(time.Local),
+	}
+
+	if dbRes.DateResolved.Valid {
+		res.DateResolved = dbRes.DateResolved.Time.In(time.Local)
+	}
+
+	return res
+}
+
+func toCoreReservationSlice(dbReservations []dbReservation) []reservation.Reservation {
+	rsvs := make([]reservation.Reservation, len(dbReservations))
+	for i, dbRes := range dbReservations {
+		rsvs[i] = toCoreReservation(dbRes)
+	}
+	return rsvs
+}
@@ -0,0 +1,191 @@
+// Package reservationdb contains reservation related CRUD functionality.
+package reservationdb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aleury/service/business/core/reservation"
+	"github.com/aleury/service/business/core/tenant"
+	database "github.com/aleury/service/business/sys/database/pgx"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// Store manages the set of APIs for reservation database access.
+type Store struct {
+	log *zap.SugaredLogger
+	db  *sqlx.DB
+}
+
+// NewStore constructs the api for data access.
+func NewStore(log *zap.SugaredLogger, db *sqlx.DB) *Store {
+	return &Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// Reserve holds stock of a product for a user. ErrUnavailable is returned
+// when nothing was reserved.
+func (s *Store) Reserve(ctx context.Context, res reservation.Reservation) (reservation.Reservation, error) {
+	const q = `
+	SELECT
+		*
+	FROM
+		app_reserve_stock(:reservation_id, :product_id, :user_id, :quantity, :date_created, :date_expires)`
+
+	var dbRes dbReservation
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, toDBReservation(res), &dbRes); err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return reservation.Reservation{}, fmt.Errorf("namedquerystruct: %w", reservation.ErrUnavailable)
+		}
+		return reservation.Reservation{}, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return toCoreReservation(dbRes), nil
+}
+
+// Resolve records the outcome of a held reservation. A reservation can only
+// be confirmed before it expires. ErrNotFound is returned when the
+// reservation isn't held anymore.
+func (s *Store) Resolve(ctx context.Context, res reservation.Reservation) (reservation.Reservation, error) {
+	const q = `
+	UPDATE
+		stock_reservations
+	SET
+		"status" = :status,
+		"order_id" = :order_id,
+		"date_resolved" = :date_resolved
+	WHERE
+		reservation_id = :reservation_id AND
+		status = 'HELD' AND
+		(:status <> 'CONFIRMED' OR date_expires > :date_resolved)
+	RETURNING
+		*`
+
+	var dbRes dbReservation
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, toDBReservation(res), &dbRes); err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return reservation.Reservation{}, fmt.Errorf("namedquerystruct: %w", reservation.ErrNotFound)
+		}
+		return reservation.Reservation{}, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return toCoreReservation(dbRes), nil
+}
+
+// Expire marks the held reservations that ran out by the specified time as
+// expired and returns how many there were.
+func (s *Store) Expire(ctx context.Context, now time.Time) (int, error) {
+	data := map[string]any{
+		"now": now.UTC(),
+	}
+
+	const q = `
+	WITH expired AS (
+		UPDATE
+			stock_reservations
+		SET
+			"status" = 'EXPIRED',
+			"date_resolved" = date_expires
+		WHERE
+			status = 'HELD' AND date_expires <= :now
+		RETURNING
+			reservation_id
+	)
+	SELECT
+		COUNT(*)
+	FROM
+		expired`
+
+	var result struct {
+		Count int `db:"count"`
+	}
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &result); err != nil {
+		return 0, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return result.Count, nil
+}
+
+// QueryByID gets the specified reservation from the database.
+func (s *Store) QueryByID(ctx context.Context, reservationID uuid.UUID) (reservation.Reservation, error) {
+	data := map[string]any{
+		"reservation_id": reservationID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		stock_reservations
+	WHERE
+		reservation_id = :reservation_id`
+
+	var dbRes dbReservation
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q+tenant.Scope(ctx, data, "tenant_id"), data, &dbRes); err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return reservation.Reservation{}, fmt.Errorf("namedquerystruct: %w", reservation.ErrNotFound)
+		}
+		return reservation.Reservation{}, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return toCoreReservation(dbRes), nil
+}
+
+// QueryByUser retrieves the reservations of a user, newest first.
+func (s *Store) QueryByUser(ctx context.Context, userID uuid.UUID, pageNumber int, rowsPerPage int) ([]reservation.Reservation, error) {
+	data := map[string]any{
+		"user_id":       userID,
+		"offset":        (pageNumber - 1) * rowsPerPage,
+		"rows_per_page": rowsPerPage,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		stock_reservations
+	WHERE
+		user_id = :user_id`
+
+	buf := bytes.NewBufferString(q)
+	buf.WriteString(tenant.Scope(ctx, data, "tenant_id"))
+	buf.WriteString(" ORDER BY date_created DESC, reservation_id OFFSET :offset LIMIT :rows_per_page")
+
+	var dbReservations []dbReservation
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, buf.String(), data, &dbReservations); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toCoreReservationSlice(dbReservations), nil
+}
+
+// CountByUser returns the number of reservations of a user.
+func (s *Store) CountByUser(ctx context.Context, userID uuid.UUID) (int, error) {
+	data := map[string]any{
+		"user_id": userID,
+	}
+
+	const q = `
+	SELECT
+		COUNT(*)
+	FROM
+		stock_reservations
+	WHERE
+		user_id = :user_id`
+
+	var result struct {
+		Count int `db:"count"`
+	}
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q+tenant.Scope(ctx, data, "tenant_id"), data, &result); err != nil {
+		return 0, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return result.Count, nil
+}
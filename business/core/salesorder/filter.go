@@ -0,0 +1,60 @@
+package salesorder
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aleury/service/business/sys/validate"
+	"github.com/google/uuid"
+)
+
+// QueryFilter holds the available fields a query can be filtered on.
+type QueryFilter struct {
+	ID               *uuid.UUID `validate:"omitempty"`
+	UserID           *uuid.UUID `validate:"omitempty"`
+	ProductID        *uuid.UUID `validate:"omitempty"`
+	Status           *Status    `validate:"omitempty"`
+	StartCreatedDate *time.Time `validate:"omitempty"`
+	EndCreatedDate   *time.Time `validate:"omitempty"`
+}
+
+// Validate checks the data in the model is considered clean.
+func (qf *QueryFilter) Validate() error {
+	if err := validate.Check(qf); err != nil {
+		return fmt.Errorf("validate: %w", err)
+	}
+	return nil
+}
+
+// WithOrderID sets the ID field of the QueryFilter value.
+func (qf *QueryFilter) WithOrderID(orderID uuid.UUID) {
+	qf.ID = &orderID
+}
+
+// WithUserID sets the UserID field of the QueryFilter value.
+func (qf *QueryFilter) WithUserID(userID uuid.UUID) {
+	qf.UserID = &userID
+}
+
+// WithProductID sets the ProductID field of the QueryFilter value. Orders
+// with at least one line for the product match.
+func (qf *QueryFilter) WithProductID(productID uuid.UUID) {
+	qf.ProductID = &productID
+}
+
+// WithStatus sets the Status field of the QueryFilter value.
+func (qf *QueryFilter) WithStatus(status Status) {
+	qf.Status = &status
+}
+
+// WithStartCreatedDate sets the StartCreatedDate field of the QueryFilter value.
+func (qf *QueryFilter) WithStartCreatedDate(startDate time.Time) {
+	d := startDate.UTC()
+	qf.StartCreatedDate = &d
+}
+
+// WithEndCreatedDate sets the EndCreatedDate field of the QueryFilter value.
+func (qf *QueryFilter) WithEndCreatedDate(endDate time.Time) {
+	d := endDate.UTC()
+	qf.EndCreatedDate = &d
+}
@@ -0,0 +1,124 @@
+package salesorder
+
+import (
+	"time"
+
+	"github.com/aleury/service/business/data/money"
+	"github.com/google/uuid"
+)
+
+// Order represents a sale of one or more products to a user. Orders placed
+// in a tax jurisdiction carry the tax due on them. The tax is part of the
+// total when the jurisdiction is inclusive and due on top of it otherwise.
+type Order struct {
+	ID            uuid.UUID
+	TenantID      uuid.UUID
+	UserID        uuid.UUID
+	Status        Status
+	Lines         []Line
+	Transitions   []Transition
+	TotalQuantity int
+	Discount      money.Money
+	Discounts     []Discount
+	Total         money.Money
+	Refunded      money.Money
+	Jurisdiction  string
+	TaxInclusive  bool
+	Tax           money.Money
+	DateCreated   time.Time
+	DateUpdated   time.Time
+}
+
+// Discount is the part of a line taken off by a promotion. The total of an
+// order is what its lines add up to less its discount. The promotion ID is
+// nil once the promotion has been deleted.
+type Discount struct {
+	LineNumber  int
+	PromotionID uuid.UUID
+	Code        string
+	Amount      money.Money
+}
+
+// Line represents a product sold as part of an order. The name and unit cost
+// are copied from the product at the time of the sale. The product ID is nil
+// once the product has been purged. Tax is what is due on the line in the
+// jurisdiction of the order. Returned and Refunded add up the returns taken
+// against the line.
+type Line struct {
+	Number    int
+	ProductID uuid.UUID
+	SKU       string
+	Name      string
+	Quantity  int
+	UnitCost  money.Money
+	Total     money.Money
+	Tax       money.Money
+	Returned  int
+	Refunded  money.Money
+}
+
+// Transition records an order moving from one status to another and who
+// moved it.
+type Transition struct {
+	From    Status
+	To      Status
+	ActorID uuid.UUID
+	Date    time.Time
+}
+
+// NewOrder is what we require from clients when placing an order, along
+// with the code of a promotion to apply and the code of the tax jurisdiction
+// the order is placed in, if any.
+type NewOrder struct {
+	UserID        uuid.UUID
+	TenantID      uuid.UUID
+	Lines         []NewLine
+	PromotionCode string
+	Jurisdiction  string
+}
+
+// NewLine is a product and the quantity of it being ordered. The product is
+// identified either by its ID or by its SKU. The stock may come from a
+// reservation of exactly that quantity.
+type NewLine struct {
+	ProductID     uuid.UUID
+	SKU           string
+	Quantity      int
+	ReservationID uuid.UUID
+}
+
+// Return records goods sent back from an order and the refund given for
+// them. Restocked returns put the goods back into the stock of their
+// products.
+type Return struct {
+	ID          uuid.UUID
+	OrderID     uuid.UUID
+	TenantID    uuid.UUID
+	Reason      ReturnReason
+	Restock     bool
+	Lines       []ReturnLine
+	Amount      money.Money
+	ActorID     uuid.UUID
+	DateCreated time.Time
+}
+
+// ReturnLine is the quantity returned of a line of the order and the amount
+// refunded for it.
+type ReturnLine struct {
+	LineNumber int
+	Quantity   int
+	Amount     money.Money
+}
+
+// NewReturn is what we require to return goods from an order.
+type NewReturn struct {
+	Reason  ReturnReason
+	Restock bool
+	Lines   []NewReturnLine
+}
+
+// NewReturnLine is the quantity of a line of the order being returned.
+type NewReturnLine struct {
+	LineNumber int
+	Quantity   int
+}
@@ -0,0 +1,16 @@
+package salesorder
+
+import "github.com/aleury/service/business/data/order"
+
+// DefaultOrderBy represents the default way we sort.
+var DefaultOrderBy = order.NewBy(OrderByDateCreated, order.DESC)
+
+// Set of fields that the results can be ordered by. These are the names
+// that should be used by the application layer.
+const (
+	OrderByID          = "orderid"
+	OrderByUserID      = "userid"
+	OrderByStatus      = "status"
+	OrderByTotal       = "total"
+	OrderByDateCreated = "datecreated"
+)
@@ -0,0 +1,71 @@
+package salesorder
+
+import (
+	"errors"
+)
+
+// Set of reasons goods are returned for.
+var (
+	ReasonDamaged        = ReturnReason{"DAMAGED"}
+	ReasonDefective      = ReturnReason{"DEFECTIVE"}
+	ReasonWrongItem      = ReturnReason{"WRONG_ITEM"}
+	ReasonNotAsDescribed = ReturnReason{"NOT_AS_DESCRIBED"}
+	ReasonNoLongerWanted = ReturnReason{"NO_LONGER_WANTED"}
+	ReasonOther          = ReturnReason{"OTHER"}
+)
+
+// Set of known return reasons.
+var returnReasons = map[string]ReturnReason{
+	ReasonDamaged.name:        ReasonDamaged,
+	ReasonDefective.name:      ReasonDefective,
+	ReasonWrongItem.name:      ReasonWrongItem,
+	ReasonNotAsDescribed.name: ReasonNotAsDescribed,
+	ReasonNoLongerWanted.name: ReasonNoLongerWanted,
+	ReasonOther.name:          ReasonOther,
+}
+
+// ReturnReason represents why goods were returned.
+type ReturnReason struct {
+	name string
+}
+
+// ParseReturnReason parses the string value and returns a return reason if
+// one exists.
+func ParseReturnReason(value string) (ReturnReason, error) {
+	reason, exists := returnReasons[value]
+	if !exists {
+		return ReturnReason{}, errors.New("invalid return reason")
+	}
+	return reason, nil
+}
+
+// MustParseReturnReason parses the string value and returns a return reason
+// if one exists. If an error occurs the function panics.
+func MustParseReturnReason(value string) ReturnReason {
+	reason, err := ParseReturnReason(value)
+	if err != nil {
+		panic(err)
+	}
+	return reason
+}
+
+// Name returns the name of the return reason.
+func (r ReturnReason) Name() string {
+	return r.name
+}
+
+// UnmarshalText implements the unmarshal interface for JSON conversions.
+func (r *ReturnReason) UnmarshalText(data []byte) error {
+	r.name = string(data)
+	return nil
+}
+
+// MarshalText implements the marshal interface for JSON conversions.
+func (r ReturnReason) MarshalText() ([]byte, error) {
+	return []byte(r.name), nil
+}
+
+// Equal provides support for the go-cmp package and testing.
+func (r ReturnReason) Equal(r2 ReturnReason) bool {
+	return r.name == r2.name
+}
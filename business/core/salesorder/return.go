@@ -0,0 +1,289 @@
+package salesorder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aleury/service/business/core/audit"
+	"github.com/aleury/service/business/core/product"
+	"github.com/aleury/service/business/data/money"
+	"github.com/google/uuid"
+)
+
+// Return takes goods back from a paid or fulfilled order and refunds them.
+// Each line is refunded its share of what was paid for it after discounts,
+// tax added on top of it included, and the last units returned of a line get
+// what is left of it, so refunds never add up to more than was paid.
+// Restocked returns put the goods back into the stock of their products. The
+// order moves to REFUNDED with the return that takes back the last of its
+// goods, which is the only way an order gets refunded. It fails with
+// ErrReturnQuantity when more is returned than is left of a line and with
+// ErrConflict when the order was returned or moved by another request since
+// it was read.
+func (c *Core) Return(ctx context.Context, ord Order, nr NewReturn) (Order, Return, error) {
+	if ord.Status != StatusPaid && ord.Status != StatusFulfilled {
+		return Order{}, Return{}, ErrNotReturnable
+	}
+
+	if len(nr.Lines) == 0 {
+		return Order{}, Return{}, ErrEmptyReturn
+	}
+
+	now := time.Now()
+
+	ret := Return{
+		ID:          uuid.New(),
+		OrderID:     ord.ID,
+		TenantID:    ord.TenantID,
+		Reason:      nr.Reason,
+		Restock:     nr.Restock,
+		Lines:       make([]ReturnLine, len(nr.Lines)),
+		ActorID:     audit.GetActorID(ctx),
+		DateCreated: now,
+	}
+
+	after := ord
+	after.Lines = append([]Line{}, ord.Lines...)
+	after.DateUpdated = now
+
+	seen := make(map[int]bool, len(nr.Lines))
+	for i, nrl := range nr.Lines {
+		idx := lineIndex(ord, nrl.LineNumber)
+		if idx < 0 || seen[nrl.LineNumber] {
+			return Order{}, Return{}, fmt.Errorf("line[%d]: %w", nrl.LineNumber, ErrReturnLine)
+		}
+		seen[nrl.LineNumber] = true
+
+		ln := ord.Lines[idx]
+		if nrl.Quantity < 1 || nrl.Quantity > ln.Quantity-ln.Returned {
+			return Order{}, Return{}, fmt.Errorf("line[%d]: %w", ln.Number, ErrReturnQuantity)
+		}
+
+		amount, err := refund(ord, ln, nrl.Quantity)
+		if err != nil {
+			return Order{}, Return{}, fmt.Errorf("refund: line[%d]: %w", ln.Number, err)
+		}
+
+		ret.Lines[i] = ReturnLine{
+			LineNumber: ln.Number,
+			Quantity:   nrl.Quantity,
+			Amount:     amount,
+		}
+
+		if ret.Amount, err = ret.Amount.Add(amount); err != nil {
+			return Order{}, Return{}, fmt.Errorf("amount: line[%d]: %w", ln.Number, err)
+		}
+
+		ln.Returned += nrl.Quantity
+		if ln.Refunded, err = ln.Refunded.Add(amount); err != nil {
+			return Order{}, Return{}, fmt.Errorf("refunded: line[%d]: %w", ln.Number, err)
+		}
+		after.Lines[idx] = ln
+	}
+
+	var err error
+	if after.Refunded, err = ord.Refunded.Add(ret.Amount); err != nil {
+		return Order{}, Return{}, fmt.Errorf("refunded: %w", err)
+	}
+
+	var tr Transition
+	if fullyReturned(after) {
+		tr = Transition{
+			From:    ord.Status,
+			To:      StatusRefunded,
+			ActorID: ret.ActorID,
+			Date:    now,
+		}
+
+		after.Status = StatusRefunded
+		after.Transitions = append(append([]Transition{}, ord.Transitions...), tr)
+	}
+
+	tran := func(ctx context.Context) error {
+		if err := c.storer.CreateReturn(ctx, ord, ret); err != nil {
+			if errors.Is(err, ErrNotFound) {
+				return ErrConflict
+			}
+			return fmt.Errorf("createreturn: %w", err)
+		}
+
+		if after.Status != ord.Status {
+			if err := c.storer.UpdateStatus(ctx, after, ord.Status); err != nil {
+				if errors.Is(err, ErrNotFound) {
+					return ErrConflict
+				}
+				return fmt.Errorf("updatestatus: %w", err)
+			}
+
+			if err := c.storer.CreateTransition(ctx, after, tr); err != nil {
+				return fmt.Errorf("createtransition: %w", err)
+			}
+		}
+
+		if ret.Restock {
+			if err := c.restock(ctx, ord, ret); err != nil {
+				return err
+			}
+		}
+
+		return c.recordReturn(ctx, ord, after, ret)
+	}
+
+	if err := c.storer.WithinTran(ctx, tran); err != nil {
+		return Order{}, Return{}, err
+	}
+
+	return after, ret, nil
+}
+
+// QueryReturns retrieves the returns taken against an order, oldest first.
+func (c *Core) QueryReturns(ctx context.Context, orderID uuid.UUID) ([]Return, error) {
+	rets, err := c.storer.QueryReturns(ctx, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("query: orderID[%s]: %w", orderID, err)
+	}
+	return rets, nil
+}
+
+// restock puts the returned goods back into the stock of their products.
+// Products that were purged in the meantime are skipped.
+func (c *Core) restock(ctx context.Context, ord Order, ret Return) error {
+	lines := append([]ReturnLine{}, ret.Lines...)
+	sort.SliceStable(lines, func(a, b int) bool {
+		return ord.Lines[lineIndex(ord, lines[a].LineNumber)].ProductID.String() <
+			ord.Lines[lineIndex(ord, lines[b].LineNumber)].ProductID.String()
+	})
+
+	for _, rl := range lines {
+		ln := ord.Lines[lineIndex(ord, rl.LineNumber)]
+		if ln.ProductID == uuid.Nil {
+			continue
+		}
+
+		if _, err := c.prdCore.ReturnStock(ctx, ln.ProductID, rl.Quantity, ord.ID); err != nil {
+			if errors.Is(err, product.ErrNotFound) {
+				continue
+			}
+			return fmt.Errorf("returnstock: line[%d]: %w", ln.Number, err)
+		}
+	}
+
+	return nil
+}
+
+// recordReturn writes an audit entry for the refund given by the return.
+func (c *Core) recordReturn(ctx context.Context, before Order, after Order, ret Return) error {
+	ne := audit.NewEntry{
+		Action:     audit.ActionUpdate,
+		EntityType: audit.EntityOrder,
+		EntityID:   after.ID,
+		Before: map[string]any{
+			"refunded": before.Refunded,
+			"status":   before.Status.Name(),
+		},
+		After: map[string]any{
+			"refunded": after.Refunded,
+			"status":   after.Status.Name(),
+			"returnId": ret.ID,
+			"reason":   ret.Reason.Name(),
+			"restock":  ret.Restock,
+		},
+	}
+
+	if _, err := c.auditCore.Record(ctx, ne); err != nil {
+		return fmt.Errorf("audit: %w", err)
+	}
+
+	return nil
+}
+
+// =============================================================================
+
+// refund returns the amount refunded for a quantity of a line. The units
+// share what was paid for the line evenly, rounded down, and the last units
+// returned get what is left of it. What was paid includes the tax of the line
+// when it was added on top of the price rather than included in it.
+func refund(ord Order, ln Line, quantity int) (money.Money, error) {
+	discount, err := lineDiscount(ord, ln.Number)
+	if err != nil {
+		return money.Money{}, fmt.Errorf("discount: %w", err)
+	}
+
+	paid, err := ln.Total.Sub(discount)
+	if err != nil {
+		return money.Money{}, fmt.Errorf("paid: %w", err)
+	}
+
+	if !ord.TaxInclusive {
+		if paid, err = paid.Add(ln.Tax); err != nil {
+			return money.Money{}, fmt.Errorf("tax: %w", err)
+		}
+	}
+
+	left, err := paid.Sub(ln.Refunded)
+	if err != nil {
+		return money.Money{}, fmt.Errorf("left: %w", err)
+	}
+
+	if quantity == ln.Quantity-ln.Returned {
+		return left, nil
+	}
+
+	share, err := paid.Mul(int64(quantity))
+	if err != nil {
+		return money.Money{}, fmt.Errorf("share: %w", err)
+	}
+
+	amount := money.New(share.Amount()/int64(ln.Quantity), share.Currency())
+
+	cmp, err := amount.Cmp(left)
+	if err != nil {
+		return money.Money{}, fmt.Errorf("cmp: %w", err)
+	}
+
+	if cmp > 0 {
+		return left, nil
+	}
+
+	return amount, nil
+}
+
+// lineDiscount returns the discounts taken off the line with the number.
+func lineDiscount(ord Order, number int) (money.Money, error) {
+	var discount money.Money
+	for _, dsc := range ord.Discounts {
+		if dsc.LineNumber != number {
+			continue
+		}
+
+		var err error
+		if discount, err = discount.Add(dsc.Amount); err != nil {
+			return money.Money{}, err
+		}
+	}
+	return discount, nil
+}
+
+// fullyReturned reports whether every unit sold on the order was returned.
+func fullyReturned(ord Order) bool {
+	for _, ln := range ord.Lines {
+		if ln.Returned < ln.Quantity {
+			return false
+		}
+	}
+	return true
+}
+
+// lineIndex returns the index of the line with the number, or -1 when the
+// order has no such line.
+func lineIndex(ord Order, number int) int {
+	for i, ln := range ord.Lines {
+		if ln.Number == number {
+			return i
+		}
+	}
+	return -1
+}
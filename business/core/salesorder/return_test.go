@@ -0,0 +1,113 @@
+package salesorder
+
+import (
+	"testing"
+
+	"github.com/aleury/service/business/data/money"
+)
+
+func Test_Refund(t *testing.T) {
+	usd := func(value string) money.Money {
+		return money.MustParse(value, money.USD)
+	}
+
+	tests := []struct {
+		name      string
+		total     string
+		quantity  int
+		discounts []Discount
+		returned  int
+		refunded  string
+		units     int
+		want      string
+	}{
+		{"one unit", "10.00", 3, nil, 0, "0.00", 1, "3.33"},
+		{"two units", "10.00", 3, nil, 0, "0.00", 2, "6.66"},
+		{"every unit", "10.00", 3, nil, 0, "0.00", 3, "10.00"},
+		{"second unit", "10.00", 3, nil, 1, "3.33", 1, "3.33"},
+		{"remainder", "10.00", 3, nil, 2, "6.66", 1, "3.34"},
+		{"remainder of many", "10.00", 3, nil, 1, "3.33", 2, "6.67"},
+		{"discounted", "10.00", 3, []Discount{{LineNumber: 1, Amount: usd("1.00")}}, 0, "0.00", 1, "3.00"},
+		{"discounted remainder", "10.00", 3, []Discount{{LineNumber: 1, Amount: usd("1.00")}}, 2, "6.00", 1, "3.00"},
+		{"other line discounted", "10.00", 3, []Discount{{LineNumber: 2, Amount: usd("1.00")}}, 0, "0.00", 1, "3.33"},
+		{"fully discounted", "10.00", 2, []Discount{{LineNumber: 1, Amount: usd("10.00")}}, 0, "0.00", 1, "0.00"},
+	}
+
+	for _, tt := range tests {
+		ord := Order{Discounts: tt.discounts}
+		ln := Line{
+			Number:   1,
+			Quantity: tt.quantity,
+			Total:    usd(tt.total),
+			Returned: tt.returned,
+			Refunded: usd(tt.refunded),
+		}
+
+		got, err := refund(ord, ln, tt.units)
+		if err != nil {
+			t.Errorf("%s: Should be able to work out the refund: %s.", tt.name, err)
+			continue
+		}
+
+		if !got.Equal(usd(tt.want)) {
+			t.Errorf("%s: Should refund %s: got %s", tt.name, tt.want, got)
+		}
+	}
+
+	taxed := []struct {
+		name      string
+		inclusive bool
+		returned  int
+		refunded  string
+		units     int
+		want      string
+	}{
+		{"exclusive", false, 0, "0.00", 1, "3.44"},
+		{"exclusive remainder", false, 2, "6.88", 1, "3.45"},
+		{"inclusive", true, 0, "0.00", 1, "3.33"},
+	}
+
+	for _, tt := range taxed {
+		ord := Order{TaxInclusive: tt.inclusive}
+		ln := Line{
+			Number:   1,
+			Quantity: 3,
+			Total:    usd("10.00"),
+			Tax:      usd("0.33"),
+			Returned: tt.returned,
+			Refunded: usd(tt.refunded),
+		}
+
+		got, err := refund(ord, ln, tt.units)
+		if err != nil {
+			t.Errorf("%s: Should be able to work out the refund: %s.", tt.name, err)
+			continue
+		}
+
+		if !got.Equal(usd(tt.want)) {
+			t.Errorf("%s: Should refund %s with its share of the tax: got %s", tt.name, tt.want, got)
+		}
+	}
+
+	// Refunding a line in any number of steps adds up to what was paid for it.
+	for _, steps := range [][]int{{1, 1, 1, 1, 1, 1, 1}, {3, 4}, {2, 2, 3}, {6, 1}} {
+		ord := Order{Discounts: []Discount{{LineNumber: 1, Amount: usd("0.05")}}}
+		ln := Line{Number: 1, Quantity: 7, Total: usd("9.99"), Refunded: usd("0.00")}
+
+		for _, units := range steps {
+			amount, err := refund(ord, ln, units)
+			if err != nil {
+				t.Fatalf("%v: Should be able to work out the refund: %s.", steps, err)
+			}
+
+			ln.Returned += units
+			if ln.Refunded, err = ln.Refunded.Add(amount); err != nil {
+				t.Fatalf("%v: Should be able to add up the refunds: %s.", steps, err)
+			}
+		}
+
+		if !ln.Refunded.Equal(usd("9.94")) {
+			t.Errorf("%v: Should refund what was paid for the line: got %s", steps, ln.Refunded)
+		}
+	}
+}
@@ -0,0 +1,456 @@
+// Package salesorder provides the core business API for recording sales.
+package salesorder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aleury/service/business/core/audit"
+	"github.com/aleury/service/business/core/product"
+	"github.com/aleury/service/business/core/promotion"
+	"github.com/aleury/service/business/core/reservation"
+	"github.com/aleury/service/business/core/tax"
+	"github.com/aleury/service/business/data/order"
+	"github.com/google/uuid"
+)
+
+// Set of error variables for CRUD operations.
+var (
+	ErrNotFound   = errors.New("order not found")
+	ErrEmptyOrder = errors.New("order has no lines")
+	ErrMismatch   = errors.New("reservation doesn't match the order line")
+
+	ErrNotReturnable  = errors.New("only paid or fulfilled orders can be returned")
+	ErrEmptyReturn    = errors.New("return has no lines")
+	ErrReturnLine     = errors.New("order has no such line or it is listed more than once")
+	ErrReturnQuantity = errors.New("return quantity exceeds what is left of the line")
+	ErrConflict       = errors.New("order was changed by another request")
+)
+
+// Storer interface declares the behavior this package needs to persist and
+// retrieve data.
+type Storer interface {
+	WithinTran(ctx context.Context, fn func(ctx context.Context) error) error
+	Create(ctx context.Context, ord Order) error
+	UpdateStatus(ctx context.Context, ord Order, from Status) error
+	CreateTransition(ctx context.Context, ord Order, tr Transition) error
+	Query(ctx context.Context, filter QueryFilter, orderBy order.By, pageNumber int, rowsPerPage int) ([]Order, error)
+	Count(ctx context.Context, filter QueryFilter) (int, error)
+	QueryByID(ctx context.Context, orderID uuid.UUID) (Order, error)
+	CreateReturn(ctx context.Context, ord Order, ret Return) error
+	QueryReturns(ctx context.Context, orderID uuid.UUID) ([]Return, error)
+}
+
+// Core manages the set of APIs for order access.
+type Core struct {
+	storer    Storer
+	prdCore   *product.Core
+	resCore   *reservation.Core
+	promoCore *promotion.Core
+	taxCore   *tax.Core
+	auditCore *audit.Core
+}
+
+// NewCore constructs a core for order api access.
+func NewCore(storer Storer, prdCore *product.Core, resCore *reservation.Core, promoCore *promotion.Core, taxCore *tax.Core, auditCore *audit.Core) *Core {
+	return &Core{
+		storer:    storer,
+		prdCore:   prdCore,
+		resCore:   resCore,
+		promoCore: promoCore,
+		taxCore:   taxCore,
+		auditCore: auditCore,
+	}
+}
+
+// Create checks out a new order. Stock is taken from every product and the
+// order is recorded in a single transaction, so either the whole order goes
+// through or nothing changes. It fails with product.ErrNotFound or
+// product.ErrInsufficientStock when a line can't be filled. Lines paid for
+// with a reservation confirm it first, so the stock held by it can be taken.
+// Those fail with ErrMismatch when the reservation is for another user,
+// product or quantity and with reservation.ErrNotHeld once it's gone. An
+// order placed with a promotion code gets its discount or fails with the
+// promotion error explaining why it can't have it. An order placed in a tax
+// jurisdiction is quoted the tax due on every line with the stock it took,
+// or fails with the tax error explaining why it can't be.
+func (c *Core) Create(ctx context.Context, no NewOrder) (Order, error) {
+	if len(no.Lines) == 0 {
+		return Order{}, ErrEmptyOrder
+	}
+
+	now := time.Now()
+
+	ord := Order{
+		ID:          uuid.New(),
+		TenantID:    no.TenantID,
+		UserID:      no.UserID,
+		Status:      StatusPending,
+		Lines:       make([]Line, len(no.Lines)),
+		DateCreated: now,
+		DateUpdated: now,
+	}
+
+	// Lines naming a SKU are resolved to the product it identifies.
+	lines := make([]NewLine, len(no.Lines))
+	for i, nl := range no.Lines {
+		if nl.ProductID == uuid.Nil && nl.SKU != "" {
+			prd, err := c.prdCore.QueryBySKU(ctx, nl.SKU)
+			if err != nil {
+				return Order{}, fmt.Errorf("querybysku: line[%d]: %w", i+1, err)
+			}
+			nl.ProductID = prd.ID
+		}
+		lines[i] = nl
+	}
+	no.Lines = lines
+
+	// Stock is taken in product order so concurrent checkouts lock the
+	// products in the same order and can't deadlock.
+	idx := make([]int, len(no.Lines))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(a, b int) bool {
+		return no.Lines[idx[a]].ProductID.String() < no.Lines[idx[b]].ProductID.String()
+	})
+
+	tran := func(ctx context.Context) error {
+		for _, i := range idx {
+			nl := no.Lines[i]
+
+			if nl.ReservationID != uuid.Nil {
+				if err := c.confirm(ctx, no.UserID, nl, ord.ID); err != nil {
+					return fmt.Errorf("confirm: line[%d]: %w", i+1, err)
+				}
+			}
+
+			prd, err := c.prdCore.TakeStock(ctx, nl.ProductID, nl.Quantity, ord.ID)
+			if err != nil {
+				return fmt.Errorf("takestock: line[%d]: %w", i+1, err)
+			}
+
+			total, err := prd.Cost.Mul(int64(nl.Quantity))
+			if err != nil {
+				return fmt.Errorf("total: line[%d]: %w", i+1, err)
+			}
+
+			ord.Lines[i] = Line{
+				Number:    i + 1,
+				ProductID: prd.ID,
+				SKU:       prd.SKU,
+				Name:      prd.Name,
+				Quantity:  nl.Quantity,
+				UnitCost:  prd.Cost,
+				Total:     total,
+			}
+		}
+
+		for _, ln := range ord.Lines {
+			total, err := ord.Total.Add(ln.Total)
+			if err != nil {
+				return fmt.Errorf("total: line[%d]: %w", ln.Number, err)
+			}
+
+			ord.TotalQuantity += ln.Quantity
+			ord.Total = total
+		}
+
+		// The promotion comes first so tax is due on what is actually paid.
+		var app promotion.Application
+		if no.PromotionCode != "" {
+			var err error
+			if app, err = c.applyPromotion(ctx, no.PromotionCode, &ord); err != nil {
+				return fmt.Errorf("applypromotion: %w", err)
+			}
+		}
+
+		if no.Jurisdiction != "" {
+			if err := c.applyTax(ctx, no.Jurisdiction, &ord); err != nil {
+				return fmt.Errorf("applytax: %w", err)
+			}
+		}
+
+		if err := c.storer.Create(ctx, ord); err != nil {
+			return fmt.Errorf("create: %w", err)
+		}
+
+		if no.PromotionCode != "" {
+			if err := c.promoCore.Redeem(ctx, app, ord.ID, ord.UserID); err != nil {
+				return err
+			}
+		}
+
+		return c.record(ctx, audit.ActionCreate, ord)
+	}
+
+	if err := c.storer.WithinTran(ctx, tran); err != nil {
+		return Order{}, err
+	}
+
+	return ord, nil
+}
+
+// Transition moves the order to the specified status and records when and by
+// whom it was moved. A TransitionError is returned when the order can't make
+// that move from its current status, including when another request moved it
+// first. Cancelling an order puts its stock back.
+func (c *Core) Transition(ctx context.Context, ord Order, to Status) (Order, error) {
+	from := ord.Status
+	if !from.CanTransition(to) {
+		return Order{}, &TransitionError{From: from, To: to}
+	}
+
+	now := time.Now()
+
+	tr := Transition{
+		From:    from,
+		To:      to,
+		ActorID: audit.GetActorID(ctx),
+		Date:    now,
+	}
+
+	before := ord
+	ord.Status = to
+	ord.DateUpdated = now
+	ord.Transitions = append(append([]Transition{}, ord.Transitions...), tr)
+
+	tran := func(ctx context.Context) error {
+		if err := c.storer.UpdateStatus(ctx, ord, from); err != nil {
+			if errors.Is(err, ErrNotFound) {
+				return &TransitionError{From: from, To: to}
+			}
+			return fmt.Errorf("updatestatus: %w", err)
+		}
+
+		if err := c.storer.CreateTransition(ctx, ord, tr); err != nil {
+			return fmt.Errorf("createtransition: %w", err)
+		}
+
+		if to == StatusCancelled {
+			if err := c.returnStock(ctx, ord); err != nil {
+				return err
+			}
+			if err := c.promoCore.Release(ctx, ord.ID); err != nil {
+				return err
+			}
+		}
+
+		return c.recordUpdate(ctx, before, ord)
+	}
+
+	if err := c.storer.WithinTran(ctx, tran); err != nil {
+		return Order{}, err
+	}
+
+	return ord, nil
+}
+
+// confirm marks the reservation of a line as used by the order.
+func (c *Core) confirm(ctx context.Context, userID uuid.UUID, nl NewLine, orderID uuid.UUID) error {
+	res, err := c.resCore.QueryByID(ctx, nl.ReservationID)
+	if err != nil {
+		return err
+	}
+
+	if res.UserID != userID || res.ProductID != nl.ProductID || res.Quantity != nl.Quantity {
+		return ErrMismatch
+	}
+
+	if _, err := c.resCore.Confirm(ctx, res, orderID); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// applyTax records the tax quoted in the jurisdiction with the code on every
+// line of the order. The lines are quoted at the cost of their products, the
+// same cost they were sold at, less the discounts already taken off them.
+func (c *Core) applyTax(ctx context.Context, code string, ord *Order) error {
+	nls := make([]tax.NewLine, len(ord.Lines))
+	for i, ln := range ord.Lines {
+		discount, err := lineDiscount(*ord, ln.Number)
+		if err != nil {
+			return fmt.Errorf("discount: line[%d]: %w", ln.Number, err)
+		}
+
+		nls[i] = tax.NewLine{
+			ProductID: ln.ProductID,
+			Quantity:  ln.Quantity,
+			Discount:  discount,
+		}
+	}
+
+	qte, err := c.taxCore.Quote(ctx, code, nls)
+	if err != nil {
+		return err
+	}
+
+	for i, ln := range qte.Lines {
+		ord.Lines[i].Tax = ln.Tax
+	}
+
+	ord.Jurisdiction = qte.Jurisdiction.Code
+	ord.TaxInclusive = qte.Jurisdiction.Inclusive
+	ord.Tax = qte.Tax
+
+	return nil
+}
+
+// applyPromotion takes the discount of the promotion redeemed with the code
+// off the order and records it by line.
+func (c *Core) applyPromotion(ctx context.Context, code string, ord *Order) (promotion.Application, error) {
+	items := make([]promotion.Item, len(ord.Lines))
+	for i, ln := range ord.Lines {
+		items[i] = promotion.Item{
+			ProductID: ln.ProductID,
+			Total:     ln.Total,
+		}
+	}
+
+	app, err := c.promoCore.Apply(ctx, code, ord.UserID, items)
+	if err != nil {
+		return promotion.Application{}, err
+	}
+
+	for i, amount := range app.Amounts {
+		if amount.IsZero() {
+			continue
+		}
+
+		ord.Discounts = append(ord.Discounts, Discount{
+			LineNumber:  ord.Lines[i].Number,
+			PromotionID: app.Promotion.ID,
+			Code:        app.Promotion.Code,
+			Amount:      amount,
+		})
+	}
+
+	total, err := ord.Total.Sub(app.Total)
+	if err != nil {
+		return promotion.Application{}, fmt.Errorf("total: %w", err)
+	}
+
+	ord.Discount = app.Total
+	ord.Total = total
+
+	return app, nil
+}
+
+// Query retrieves a list of existing orders from the database.
+func (c *Core) Query(ctx context.Context, filter QueryFilter, orderBy order.By, pageNumber int, rowsPerPage int) ([]Order, error) {
+	orders, err := c.storer.Query(ctx, filter, orderBy, pageNumber, rowsPerPage)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+	return orders, nil
+}
+
+// Count returns the total number of orders in the store.
+func (c *Core) Count(ctx context.Context, filter QueryFilter) (int, error) {
+	return c.storer.Count(ctx, filter)
+}
+
+// QueryByID finds the order identified by a given ID.
+func (c *Core) QueryByID(ctx context.Context, orderID uuid.UUID) (Order, error) {
+	ord, err := c.storer.QueryByID(ctx, orderID)
+	if err != nil {
+		return Order{}, fmt.Errorf("query: orderID[%s]: %w", orderID, err)
+	}
+	return ord, nil
+}
+
+// =============================================================================
+
+// returnStock puts the stock of every line back into its product. Products
+// that were purged in the meantime are skipped.
+func (c *Core) returnStock(ctx context.Context, ord Order) error {
+	lines := append([]Line{}, ord.Lines...)
+	sort.SliceStable(lines, func(a, b int) bool {
+		return lines[a].ProductID.String() < lines[b].ProductID.String()
+	})
+
+	for _, ln := range lines {
+		if ln.ProductID == uuid.Nil {
+			continue
+		}
+
+		if _, err := c.prdCore.ReturnStock(ctx, ln.ProductID, ln.Quantity, ord.ID); err != nil {
+			if errors.Is(err, product.ErrNotFound) {
+				continue
+			}
+			return fmt.Errorf("returnstock: line[%d]: %w", ln.Number, err)
+		}
+	}
+
+	return nil
+}
+
+// recordUpdate writes an audit entry for a change of status.
+func (c *Core) recordUpdate(ctx context.Context, before Order, after Order) error {
+	ne := audit.NewEntry{
+		Action:     audit.ActionUpdate,
+		EntityType: audit.EntityOrder,
+		EntityID:   after.ID,
+		Before:     map[string]any{"status": before.Status.Name()},
+		After:      map[string]any{"status": after.Status.Name()},
+	}
+
+	if _, err := c.auditCore.Record(ctx, ne); err != nil {
+		return fmt.Errorf("audit: %w", err)
+	}
+
+	return nil
+}
+
+// record writes an audit entry for the order.
+func (c *Core) record(ctx context.Context, action string, ord Order) error {
+	ne := audit.NewEntry{
+		Action:     action,
+		EntityType: audit.EntityOrder,
+		EntityID:   ord.ID,
+		After:      auditFields(ord),
+	}
+
+	if _, err := c.auditCore.Record(ctx, ne); err != nil {
+		return fmt.Errorf("audit: %w", err)
+	}
+
+	return nil
+}
+
+// auditFields returns the set of order fields that are tracked by the audit
+// log.
+func auditFields(ord Order) map[string]any {
+	lines := make([]map[string]any, len(ord.Lines))
+	for i, ln := range ord.Lines {
+		lines[i] = map[string]any{
+			"productId": ln.ProductID,
+			"quantity":  ln.Quantity,
+			"unitCost":  ln.UnitCost,
+		}
+	}
+
+	fields := map[string]any{
+		"userId": ord.UserID,
+		"status": ord.Status.Name(),
+		"lines":  lines,
+		"total":  ord.Total,
+	}
+
+	if len(ord.Discounts) > 0 {
+		fields["discount"] = ord.Discount
+		fields["promotionCode"] = ord.Discounts[0].Code
+	}
+
+	if ord.Jurisdiction != "" {
+		fields["jurisdiction"] = ord.Jurisdiction
+		fields["tax"] = ord.Tax
+	}
+
+	return fields
+}
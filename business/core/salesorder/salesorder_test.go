@@ -0,0 +1,553 @@
+package salesorder_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"testing"
+	"time"
+
+	"github.com/aleury/service/business/core/product"
+	"github.com/aleury/service/business/core/promotion"
+	"github.com/aleury/service/business/core/salesorder"
+	"github.com/aleury/service/business/core/tax"
+	"github.com/aleury/service/business/core/tenant"
+	"github.com/aleury/service/business/core/user"
+	"github.com/aleury/service/business/data/dbtest"
+	"github.com/aleury/service/business/data/money"
+	"github.com/aleury/service/foundation/docker"
+)
+
+var c *docker.Container
+
+func TestMain(m *testing.M) {
+	var err error
+	c, err = dbtest.StartDB()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer dbtest.StopDB(c)
+
+	m.Run()
+}
+
+func Test_SalesOrder(t *testing.T) {
+	t.Run("create", create)
+	t.Run("transition", transition)
+	t.Run("return", returns)
+	t.Run("tax", taxes)
+}
+
+// =============================================================================
+
+func create(t *testing.T) {
+	test := dbtest.NewTest(t, c)
+	defer func() {
+		if r := recover(); r != nil {
+			t.Log(r)
+			t.Error(string(debug.Stack()))
+		}
+		test.Teardown()
+	}()
+
+	api := test.CoreAPIs
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	t.Log("Go seeding...")
+
+	usr, prds, err := seed(ctx, api)
+	if err != nil {
+		t.Fatalf("Seeding error: %s", err)
+	}
+
+	// -------------------------------------------------------------------------
+
+	no := salesorder.NewOrder{
+		UserID:   usr.ID,
+		TenantID: usr.TenantID,
+		Lines: []salesorder.NewLine{
+			{ProductID: prds[0].ID, Quantity: 3},
+			{ProductID: prds[1].ID, Quantity: 1},
+		},
+	}
+
+	ord, err := api.SalesOrder.Create(ctx, no)
+	if err != nil {
+		t.Fatalf("Should be able to create order: %s.", err)
+	}
+
+	if !ord.Status.Equal(salesorder.StatusPending) {
+		t.Errorf("Should start out PENDING: got %s", ord.Status.Name())
+	}
+
+	if ord.TotalQuantity != 4 {
+		t.Errorf("Should add up the quantity of the lines: got %d", ord.TotalQuantity)
+	}
+
+	if want := money.MustParse("35.00", money.USD); !ord.Total.Equal(want) {
+		t.Errorf("Should add up the total of the lines: got %s want %s", ord.Total, want)
+	}
+
+	saved, err := api.SalesOrder.QueryByID(ctx, ord.ID)
+	if err != nil {
+		t.Fatalf("Should be able to retrieve order by ID: %s.", err)
+	}
+
+	if saved.ID != ord.ID || !saved.Status.Equal(ord.Status) || !saved.Total.Equal(ord.Total) || saved.TotalQuantity != ord.TotalQuantity {
+		t.Fatalf("Should get back the same order: got %+v want %+v", saved, ord)
+	}
+
+	if len(saved.Lines) != len(ord.Lines) {
+		t.Fatalf("Should get back every line of the order: got %d want %d", len(saved.Lines), len(ord.Lines))
+	}
+
+	for i, ln := range ord.Lines {
+		got := saved.Lines[i]
+		if got.Number != ln.Number || got.ProductID != ln.ProductID || got.Quantity != ln.Quantity || !got.UnitCost.Equal(ln.UnitCost) || !got.Total.Equal(ln.Total) {
+			t.Errorf("Should get back the same line %d: got %+v want %+v", ln.Number, got, ln)
+		}
+	}
+
+	for i, want := range []int{7, 4} {
+		prd, err := api.Product.QueryByID(ctx, prds[i].ID)
+		if err != nil {
+			t.Fatalf("Should be able to retrieve product by ID: %s.", err)
+		}
+
+		if prd.Quantity != want {
+			t.Errorf("Should have taken the stock of the order: got %d want %d", prd.Quantity, want)
+		}
+	}
+
+	// -------------------------------------------------------------------------
+
+	if _, err := api.SalesOrder.Create(ctx, salesorder.NewOrder{UserID: usr.ID, TenantID: usr.TenantID}); !errors.Is(err, salesorder.ErrEmptyOrder) {
+		t.Errorf("Should NOT be able to create an order without lines: %v.", err)
+	}
+
+	no.Lines = []salesorder.NewLine{
+		{ProductID: prds[0].ID, Quantity: 1},
+		{ProductID: prds[1].ID, Quantity: 5},
+	}
+
+	if _, err := api.SalesOrder.Create(ctx, no); !errors.Is(err, product.ErrInsufficientStock) {
+		t.Fatalf("Should NOT be able to order more than is in stock: %v.", err)
+	}
+
+	prd, err := api.Product.QueryByID(ctx, prds[0].ID)
+	if err != nil {
+		t.Fatalf("Should be able to retrieve product by ID: %s.", err)
+	}
+
+	if prd.Quantity != 7 {
+		t.Errorf("Should NOT take any stock for an order that failed: got %d", prd.Quantity)
+	}
+
+	count, err := api.SalesOrder.Count(ctx, salesorder.QueryFilter{})
+	if err != nil {
+		t.Fatalf("Should be able to count orders: %s.", err)
+	}
+
+	if count != 1 {
+		t.Errorf("Should only have recorded the order that went through: got %d", count)
+	}
+}
+
+func transition(t *testing.T) {
+	test := dbtest.NewTest(t, c)
+	defer func() {
+		if r := recover(); r != nil {
+			t.Log(r)
+			t.Error(string(debug.Stack()))
+		}
+		test.Teardown()
+	}()
+
+	api := test.CoreAPIs
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	t.Log("Go seeding...")
+
+	usr, prds, err := seed(ctx, api)
+	if err != nil {
+		t.Fatalf("Seeding error: %s", err)
+	}
+
+	newOrder := func() salesorder.Order {
+		ord, err := api.SalesOrder.Create(ctx, salesorder.NewOrder{
+			UserID:   usr.ID,
+			TenantID: usr.TenantID,
+			Lines:    []salesorder.NewLine{{ProductID: prds[0].ID, Quantity: 2}},
+		})
+		if err != nil {
+			t.Fatalf("Should be able to create order: %s.", err)
+		}
+		return ord
+	}
+
+	// -------------------------------------------------------------------------
+
+	ord := newOrder()
+
+	paid, err := api.SalesOrder.Transition(ctx, ord, salesorder.StatusPaid)
+	if err != nil {
+		t.Fatalf("Should be able to pay for a pending order: %s.", err)
+	}
+
+	if _, err := api.SalesOrder.Transition(ctx, ord, salesorder.StatusCancelled); !salesorder.IsTransitionError(err) {
+		t.Errorf("Should NOT be able to move an order another request moved first: %v.", err)
+	}
+
+	if _, err := api.SalesOrder.Transition(ctx, paid, salesorder.StatusCancelled); !salesorder.IsTransitionError(err) {
+		t.Errorf("Should NOT be able to cancel a paid order: %v.", err)
+	}
+
+	if _, err := api.SalesOrder.Transition(ctx, paid, salesorder.StatusRefunded); !salesorder.IsTransitionError(err) {
+		t.Errorf("Should NOT be able to refund an order without returns: %v.", err)
+	}
+
+	fulfilled, err := api.SalesOrder.Transition(ctx, paid, salesorder.StatusFulfilled)
+	if err != nil {
+		t.Fatalf("Should be able to fulfil a paid order: %s.", err)
+	}
+
+	saved, err := api.SalesOrder.QueryByID(ctx, fulfilled.ID)
+	if err != nil {
+		t.Fatalf("Should be able to retrieve order by ID: %s.", err)
+	}
+
+	if !saved.Status.Equal(salesorder.StatusFulfilled) || len(saved.Transitions) != 2 {
+		t.Errorf("Should have recorded both transitions: got %s with %d", saved.Status.Name(), len(saved.Transitions))
+	}
+
+	// -------------------------------------------------------------------------
+
+	ord = newOrder()
+
+	if _, err := api.SalesOrder.Transition(ctx, ord, salesorder.StatusCancelled); err != nil {
+		t.Fatalf("Should be able to cancel a pending order: %s.", err)
+	}
+
+	prd, err := api.Product.QueryByID(ctx, prds[0].ID)
+	if err != nil {
+		t.Fatalf("Should be able to retrieve product by ID: %s.", err)
+	}
+
+	if prd.Quantity != 8 {
+		t.Errorf("Should have put the stock of the cancelled order back: got %d", prd.Quantity)
+	}
+}
+
+func returns(t *testing.T) {
+	test := dbtest.NewTest(t, c)
+	defer func() {
+		if r := recover(); r != nil {
+			t.Log(r)
+			t.Error(string(debug.Stack()))
+		}
+		test.Teardown()
+	}()
+
+	api := test.CoreAPIs
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	t.Log("Go seeding...")
+
+	usr, prds, err := seed(ctx, api)
+	if err != nil {
+		t.Fatalf("Seeding error: %s", err)
+	}
+
+	// -------------------------------------------------------------------------
+
+	ord, err := api.SalesOrder.Create(ctx, salesorder.NewOrder{
+		UserID:   usr.ID,
+		TenantID: usr.TenantID,
+		Lines: []salesorder.NewLine{
+			{ProductID: prds[0].ID, Quantity: 3},
+			{ProductID: prds[1].ID, Quantity: 1},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Should be able to create order: %s.", err)
+	}
+
+	nr := salesorder.NewReturn{
+		Reason:  salesorder.ReasonDamaged,
+		Restock: true,
+		Lines:   []salesorder.NewReturnLine{{LineNumber: 1, Quantity: 1}},
+	}
+
+	if _, _, err := api.SalesOrder.Return(ctx, ord, nr); !errors.Is(err, salesorder.ErrNotReturnable) {
+		t.Errorf("Should NOT be able to return goods from a pending order: %v.", err)
+	}
+
+	ord, err = api.SalesOrder.Transition(ctx, ord, salesorder.StatusPaid)
+	if err != nil {
+		t.Fatalf("Should be able to pay for a pending order: %s.", err)
+	}
+
+	nr.Lines[0].Quantity = 4
+	if _, _, err := api.SalesOrder.Return(ctx, ord, nr); !errors.Is(err, salesorder.ErrReturnQuantity) {
+		t.Errorf("Should NOT be able to return more than was sold: %v.", err)
+	}
+
+	nr.Lines[0].Quantity = 1
+	after, ret, err := api.SalesOrder.Return(ctx, ord, nr)
+	if err != nil {
+		t.Fatalf("Should be able to return goods from a paid order: %s.", err)
+	}
+
+	if want := money.MustParse("5.00", money.USD); !ret.Amount.Equal(want) {
+		t.Errorf("Should refund the unit returned: got %s want %s", ret.Amount, want)
+	}
+
+	if !after.Status.Equal(salesorder.StatusPaid) {
+		t.Errorf("Should NOT refund an order with goods left on it: got %s", after.Status.Name())
+	}
+
+	if _, _, err := api.SalesOrder.Return(ctx, ord, nr); !errors.Is(err, salesorder.ErrConflict) {
+		t.Errorf("Should NOT be able to return goods from an order returned since it was read: %v.", err)
+	}
+
+	prd, err := api.Product.QueryByID(ctx, prds[0].ID)
+	if err != nil {
+		t.Fatalf("Should be able to retrieve product by ID: %s.", err)
+	}
+
+	if prd.Quantity != 8 {
+		t.Errorf("Should have restocked the unit returned: got %d", prd.Quantity)
+	}
+
+	// -------------------------------------------------------------------------
+
+	nr = salesorder.NewReturn{
+		Reason: salesorder.ReasonNoLongerWanted,
+		Lines: []salesorder.NewReturnLine{
+			{LineNumber: 1, Quantity: 2},
+			{LineNumber: 2, Quantity: 1},
+		},
+	}
+
+	after, _, err = api.SalesOrder.Return(ctx, after, nr)
+	if err != nil {
+		t.Fatalf("Should be able to return the rest of the order: %s.", err)
+	}
+
+	saved, err := api.SalesOrder.QueryByID(ctx, after.ID)
+	if err != nil {
+		t.Fatalf("Should be able to retrieve order by ID: %s.", err)
+	}
+
+	if !saved.Status.Equal(salesorder.StatusRefunded) {
+		t.Errorf("Should refund the order once everything was returned: got %s", saved.Status.Name())
+	}
+
+	if !saved.Refunded.Equal(saved.Total) {
+		t.Errorf("Should refund what was paid for the order: got %s want %s", saved.Refunded, saved.Total)
+	}
+
+	rets, err := api.SalesOrder.QueryReturns(ctx, saved.ID)
+	if err != nil {
+		t.Fatalf("Should be able to retrieve the returns of the order: %s.", err)
+	}
+
+	if len(rets) != 2 {
+		t.Errorf("Should have recorded both returns: got %d", len(rets))
+	}
+
+	prd, err = api.Product.QueryByID(ctx, prds[0].ID)
+	if err != nil {
+		t.Fatalf("Should be able to retrieve product by ID: %s.", err)
+	}
+
+	if prd.Quantity != 8 {
+		t.Errorf("Should NOT restock goods returned without restocking: got %d", prd.Quantity)
+	}
+}
+
+func taxes(t *testing.T) {
+	test := dbtest.NewTest(t, c)
+	defer func() {
+		if r := recover(); r != nil {
+			t.Log(r)
+			t.Error(string(debug.Stack()))
+		}
+		test.Teardown()
+	}()
+
+	api := test.CoreAPIs
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	t.Log("Go seeding...")
+
+	usr, prds, err := seed(ctx, api)
+	if err != nil {
+		t.Fatalf("Seeding error: %s", err)
+	}
+
+	ctx = tenant.SetTenantID(ctx, usr.TenantID)
+
+	nj := tax.NewJurisdiction{
+		Code:     "US-NY",
+		Name:     "New York",
+		Rounding: money.RoundHalfUp,
+		Rates:    []tax.Rate{{Class: "STANDARD", Percent: money.MustParsePercent("8.875")}},
+	}
+
+	if _, err := api.Tax.Load(ctx, []tax.NewJurisdiction{nj}); err != nil {
+		t.Fatalf("Should be able to load the tax jurisdiction: %s.", err)
+	}
+
+	// -------------------------------------------------------------------------
+
+	no := salesorder.NewOrder{
+		UserID:   usr.ID,
+		TenantID: usr.TenantID,
+		Lines: []salesorder.NewLine{
+			{ProductID: prds[0].ID, Quantity: 3},
+			{ProductID: prds[1].ID, Quantity: 1},
+		},
+		Jurisdiction: "us-ny",
+	}
+
+	ord, err := api.SalesOrder.Create(ctx, no)
+	if err != nil {
+		t.Fatalf("Should be able to create an order in the jurisdiction: %s.", err)
+	}
+
+	saved, err := api.SalesOrder.QueryByID(ctx, ord.ID)
+	if err != nil {
+		t.Fatalf("Should be able to retrieve order by ID: %s.", err)
+	}
+
+	if saved.Jurisdiction != "US-NY" || saved.TaxInclusive {
+		t.Errorf("Should record the jurisdiction of the order: got %q inclusive %t", saved.Jurisdiction, saved.TaxInclusive)
+	}
+
+	for i, want := range []string{"1.33", "1.78"} {
+		if got := saved.Lines[i].Tax; !got.Equal(money.MustParse(want, money.USD)) {
+			t.Errorf("Should record the tax due on line %d: got %s want %s", i+1, got, want)
+		}
+	}
+
+	if want := money.MustParse("3.11", money.USD); !saved.Tax.Equal(want) {
+		t.Errorf("Should record the tax due on the order: got %s want %s", saved.Tax, want)
+	}
+
+	if want := money.MustParse("35.00", money.USD); !saved.Total.Equal(want) {
+		t.Errorf("Should NOT add exclusive tax to the total of the lines: got %s want %s", saved.Total, want)
+	}
+
+	// -------------------------------------------------------------------------
+
+	no.Jurisdiction = "GB"
+	if _, err := api.SalesOrder.Create(ctx, no); !errors.Is(err, tax.ErrNotFound) {
+		t.Fatalf("Should NOT be able to create an order in an unknown jurisdiction: %v.", err)
+	}
+
+	prd, err := api.Product.QueryByID(ctx, prds[0].ID)
+	if err != nil {
+		t.Fatalf("Should be able to retrieve product by ID: %s.", err)
+	}
+
+	if prd.Quantity != 7 {
+		t.Errorf("Should NOT take any stock for an order that couldn't be taxed: got %d", prd.Quantity)
+	}
+
+	// -------------------------------------------------------------------------
+
+	np := promotion.NewPromotion{
+		TenantID: usr.TenantID,
+		Code:     "SAVE10",
+		Kind:     promotion.KindPercent,
+		Percent:  10,
+	}
+
+	if _, err := api.Promotion.Create(ctx, np); err != nil {
+		t.Fatalf("Should be able to create promotion: %s.", err)
+	}
+
+	no.Jurisdiction = "US-NY"
+	no.PromotionCode = "SAVE10"
+
+	ord, err = api.SalesOrder.Create(ctx, no)
+	if err != nil {
+		t.Fatalf("Should be able to create a discounted order in the jurisdiction: %s.", err)
+	}
+
+	for i, want := range []string{"1.20", "1.60"} {
+		if got := ord.Lines[i].Tax; !got.Equal(money.MustParse(want, money.USD)) {
+			t.Errorf("Should tax line %d after the discount: got %s want %s", i+1, got, want)
+		}
+	}
+
+	if want := money.MustParse("2.80", money.USD); !ord.Tax.Equal(want) {
+		t.Errorf("Should tax the order after the discount: got %s want %s", ord.Tax, want)
+	}
+
+	if want := money.MustParse("31.50", money.USD); !ord.Total.Equal(want) {
+		t.Errorf("Should take the discount off the total: got %s want %s", ord.Total, want)
+	}
+
+	// -------------------------------------------------------------------------
+
+	paid, err := api.SalesOrder.Transition(ctx, saved, salesorder.StatusPaid)
+	if err != nil {
+		t.Fatalf("Should be able to pay for a pending order: %s.", err)
+	}
+
+	nr := salesorder.NewReturn{
+		Reason: salesorder.ReasonNoLongerWanted,
+		Lines:  []salesorder.NewReturnLine{{LineNumber: 1, Quantity: 1}},
+	}
+
+	after, ret, err := api.SalesOrder.Return(ctx, paid, nr)
+	if err != nil {
+		t.Fatalf("Should be able to return goods from a taxed order: %s.", err)
+	}
+
+	want := money.MustParse("5.44", money.USD)
+
+	if !ret.Amount.Equal(want) || !ret.Lines[0].Amount.Equal(want) {
+		t.Errorf("Should refund the unit returned with its share of the tax: got %s and %s want %s", ret.Amount, ret.Lines[0].Amount, want)
+	}
+
+	if !after.Refunded.Equal(want) {
+		t.Errorf("Should add the tax refunded to the order: got %s want %s", after.Refunded, want)
+	}
+}
+
+// =============================================================================
+
+// seed creates the products the tests order from, owned by a seeded user.
+func seed(ctx context.Context, api dbtest.CoreAPIs) (user.User, []product.Product, error) {
+	usrs, err := api.User.Query(ctx, user.QueryFilter{}, user.DefaultOrderBy, 1, 1)
+	if err != nil {
+		return user.User{}, nil, fmt.Errorf("seeding users: %w", err)
+	}
+
+	nps := []product.NewProduct{
+		{Name: "Comic Books", Cost: money.MustParse("5.00", money.USD), Quantity: 10, UserID: usrs[0].ID},
+		{Name: "McDonalds Toys", Cost: money.MustParse("20.00", money.USD), Quantity: 5, UserID: usrs[0].ID},
+	}
+
+	prds := make([]product.Product, len(nps))
+	for i, np := range nps {
+		if prds[i], err = api.Product.Create(ctx, np); err != nil {
+			return user.User{}, nil, fmt.Errorf("seeding products: %w", err)
+		}
+	}
+
+	return usrs[0], prds, nil
+}
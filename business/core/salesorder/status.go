@@ -0,0 +1,108 @@
+package salesorder
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Set of states an order moves through.
+var (
+	StatusPending   = Status{"PENDING"}
+	StatusPaid      = Status{"PAID"}
+	StatusFulfilled = Status{"FULFILLED"}
+	StatusCancelled = Status{"CANCELLED"}
+	StatusRefunded  = Status{"REFUNDED"}
+)
+
+// Set of known statuses.
+var statuses = map[string]Status{
+	StatusPending.name:   StatusPending,
+	StatusPaid.name:      StatusPaid,
+	StatusFulfilled.name: StatusFulfilled,
+	StatusCancelled.name: StatusCancelled,
+	StatusRefunded.name:  StatusRefunded,
+}
+
+// transitions lists the statuses an order can be moved to from each status.
+// Cancelled and refunded orders are final. An order is only refunded by
+// returning everything on it, so no status can be moved to REFUNDED.
+var transitions = map[Status][]Status{
+	StatusPending: {StatusPaid, StatusCancelled},
+	StatusPaid:    {StatusFulfilled},
+}
+
+// Status represents the state of an order in its lifecycle.
+type Status struct {
+	name string
+}
+
+// ParseStatus parses the string value and returns a status if one exists.
+func ParseStatus(value string) (Status, error) {
+	status, exists := statuses[value]
+	if !exists {
+		return Status{}, errors.New("invalid status")
+	}
+	return status, nil
+}
+
+// MustParseStatus parses the string value and returns a status if one
+// exists. If an error occurs the function panics.
+func MustParseStatus(value string) Status {
+	status, err := ParseStatus(value)
+	if err != nil {
+		panic(err)
+	}
+	return status
+}
+
+// Name returns the name of the status.
+func (s Status) Name() string {
+	return s.name
+}
+
+// CanTransition reports whether an order in this status can move to the
+// specified status.
+func (s Status) CanTransition(to Status) bool {
+	for _, next := range transitions[s] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// UnmarshalText implements the unmarshal interface for JSON conversions.
+func (s *Status) UnmarshalText(data []byte) error {
+	s.name = string(data)
+	return nil
+}
+
+// MarshalText implements the marshal interface for JSON conversions.
+func (s Status) MarshalText() ([]byte, error) {
+	return []byte(s.name), nil
+}
+
+// Equal provides support for the go-cmp package and testing.
+func (s Status) Equal(s2 Status) bool {
+	return s.name == s2.name
+}
+
+// =============================================================================
+
+// TransitionError is returned when an order can't move to the requested
+// status from the one it is in.
+type TransitionError struct {
+	From Status
+	To   Status
+}
+
+// Error implements the error interface.
+func (te *TransitionError) Error() string {
+	return fmt.Sprintf("order can't move from %s to %s", te.From.name, te.To.name)
+}
+
+// IsTransitionError checks if an error of type TransitionError exists.
+func IsTransitionError(err error) bool {
+	var te *TransitionError
+	return errors.As(err, &te)
+}
@@ -0,0 +1,34 @@
+package salesorder_test
+
+import (
+	"testing"
+
+	"github.com/aleury/service/business/core/salesorder"
+)
+
+func Test_CanTransition(t *testing.T) {
+	var (
+		pending   = salesorder.StatusPending
+		paid      = salesorder.StatusPaid
+		fulfilled = salesorder.StatusFulfilled
+		cancelled = salesorder.StatusCancelled
+		refunded  = salesorder.StatusRefunded
+	)
+
+	all := []salesorder.Status{pending, paid, fulfilled, cancelled, refunded}
+
+	allowed := map[[2]salesorder.Status]bool{
+		{pending, paid}:      true,
+		{pending, cancelled}: true,
+		{paid, fulfilled}:    true,
+	}
+
+	for _, from := range all {
+		for _, to := range all {
+			want := allowed[[2]salesorder.Status{from, to}]
+			if got := from.CanTransition(to); got != want {
+				t.Errorf("%s -> %s: Should report %t: got %t", from.Name(), to.Name(), want, got)
+			}
+		}
+	}
+}
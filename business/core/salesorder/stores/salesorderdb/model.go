@@ -0,0 +1,283 @@
+package salesorderdb
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/aleury/service/business/core/salesorder"
+	"github.com/aleury/service/business/data/money"
+	"github.com/google/uuid"
+)
+
+// dbOrder represents the structure we need for moving data
+// between the app and the database.
+type dbOrder struct {
+	ID            uuid.UUID      `db:"order_id"`
+	TenantID      uuid.UUID      `db:"tenant_id"`
+	UserID        uuid.UUID      `db:"user_id"`
+	Status        string         `db:"status"`
+	TotalQuantity int            `db:"total_quantity"`
+	Discount      money.Money    `db:"discount"`
+	Total         money.Money    `db:"total"`
+	Refunded      money.Money    `db:"refunded"`
+	Jurisdiction  sql.NullString `db:"jurisdiction"`
+	TaxInclusive  bool           `db:"tax_inclusive"`
+	Tax           money.Money    `db:"tax"`
+	DateCreated   time.Time      `db:"date_created"`
+	DateUpdated   time.Time      `db:"date_updated"`
+}
+
+func toDBOrder(ord salesorder.Order) dbOrder {
+	return dbOrder{
+		ID:            ord.ID,
+		TenantID:      ord.TenantID,
+		UserID:        ord.UserID,
+		Status:        ord.Status.Name(),
+		TotalQuantity: ord.TotalQuantity,
+		Discount:      ord.Discount,
+		Total:         ord.Total,
+		Refunded:      ord.Refunded,
+		Jurisdiction: sql.NullString{
+			String: ord.Jurisdiction,
+			Valid:  ord.Jurisdiction != "",
+		},
+		TaxInclusive: ord.TaxInclusive,
+		Tax:          ord.Tax,
+		DateCreated:  ord.DateCreated.UTC(),
+		DateUpdated:  ord.DateUpdated.UTC(),
+	}
+}
+
+func toCoreOrder(dbOrd dbOrder, dbLines []dbLine, dbTrs []dbTransition, dbDscs []dbDiscount) salesorder.Order {
+	lines := make([]salesorder.Line, len(dbLines))
+	for i, dbLn := range dbLines {
+		lines[i] = toCoreLine(dbLn)
+	}
+
+	trs := make([]salesorder.Transition, len(dbTrs))
+	for i, dbTr := range dbTrs {
+		trs[i] = toCoreTransition(dbTr)
+	}
+
+	var dscs []salesorder.Discount
+	for _, dbDsc := range dbDscs {
+		dscs = append(dscs, toCoreDiscount(dbDsc))
+	}
+
+	return salesorder.Order{
+		ID:            dbOrd.ID,
+		TenantID:      dbOrd.TenantID,
+		UserID:        dbOrd.UserID,
+		Status:        salesorder.MustParseStatus(dbOrd.Status),
+		Lines:         lines,
+		Transitions:   trs,
+		TotalQuantity: dbOrd.TotalQuantity,
+		Discount:      dbOrd.Discount,
+		Discounts:     dscs,
+		Total:         dbOrd.Total,
+		Refunded:      dbOrd.Refunded,
+		Jurisdiction:  dbOrd.Jurisdiction.String,
+		TaxInclusive:  dbOrd.TaxInclusive,
+		Tax:           dbOrd.Tax,
+		DateCreated:   dbOrd.DateCreated.In(time.Local),
+		DateUpdated:   dbOrd.DateUpdated.In(time.Local),
+	}
+}
+
+// =============================================================================
+
+// dbLine represents a line of an order in the database.
+type dbLine struct {
+	OrderID   uuid.UUID      `db:"order_id"`
+	Number    int            `db:"line_number"`
+	TenantID  uuid.UUID      `db:"tenant_id"`
+	ProductID uuid.NullUUID  `db:"product_id"`
+	Name      string         `db:"name"`
+	Quantity  int            `db:"quantity"`
+	UnitCost  money.Money    `db:"unit_cost"`
+	Total     money.Money    `db:"total"`
+	Tax       money.Money    `db:"tax"`
+	SKU       sql.NullString `db:"sku"`
+	Returned  int            `db:"returned"`
+	Refunded  money.Money    `db:"refunded"`
+}
+
+func toDBLine(ord salesorder.Order, ln salesorder.Line) dbLine {
+	return dbLine{
+		OrderID:  ord.ID,
+		Number:   ln.Number,
+		TenantID: ord.TenantID,
+		ProductID: uuid.NullUUID{
+			UUID:  ln.ProductID,
+			Valid: ln.ProductID != uuid.Nil,
+		},
+		Name:     ln.Name,
+		Quantity: ln.Quantity,
+		UnitCost: ln.UnitCost,
+		Total:    ln.Total,
+		Tax:      ln.Tax,
+		SKU: sql.NullString{
+			String: ln.SKU,
+			Valid:  ln.SKU != "",
+		},
+	}
+}
+
+func toCoreLine(dbLn dbLine) salesorder.Line {
+	return salesorder.Line{
+		Number:    dbLn.Number,
+		ProductID: dbLn.ProductID.UUID,
+		Name:      dbLn.Name,
+		Quantity:  dbLn.Quantity,
+		UnitCost:  dbLn.UnitCost,
+		Total:     dbLn.Total,
+		Tax:       dbLn.Tax,
+		SKU:       dbLn.SKU.String,
+		Returned:  dbLn.Returned,
+		Refunded:  dbLn.Refunded,
+	}
+}
+
+// =============================================================================
+
+// dbDiscount represents the part of a line taken off by a promotion.
+type dbDiscount struct {
+	OrderID     uuid.UUID     `db:"order_id"`
+	LineNumber  int           `db:"line_number"`
+	TenantID    uuid.UUID     `db:"tenant_id"`
+	PromotionID uuid.NullUUID `db:"promotion_id"`
+	Code        string        `db:"code"`
+	Amount      money.Money   `db:"amount"`
+}
+
+func toDBDiscount(ord salesorder.Order, dsc salesorder.Discount) dbDiscount {
+	return dbDiscount{
+		OrderID:    ord.ID,
+		LineNumber: dsc.LineNumber,
+		TenantID:   ord.TenantID,
+		PromotionID: uuid.NullUUID{
+			UUID:  dsc.PromotionID,
+			Valid: dsc.PromotionID != uuid.Nil,
+		},
+		Code:   dsc.Code,
+		Amount: dsc.Amount,
+	}
+}
+
+func toCoreDiscount(dbDsc dbDiscount) salesorder.Discount {
+	return salesorder.Discount{
+		LineNumber:  dbDsc.LineNumber,
+		PromotionID: dbDsc.PromotionID.UUID,
+		Code:        dbDsc.Code,
+		Amount:      dbDsc.Amount,
+	}
+}
+
+// =============================================================================
+
+// dbTransition represents a recorded change of status of an order.
+type dbTransition struct {
+	OrderID     uuid.UUID     `db:"order_id"`
+	TenantID    uuid.UUID     `db:"tenant_id"`
+	From        string        `db:"from_status"`
+	To          string        `db:"to_status"`
+	ActorID     uuid.NullUUID `db:"actor_id"`
+	DateCreated time.Time     `db:"date_created"`
+}
+
+func toDBTransition(ord salesorder.Order, tr salesorder.Transition) dbTransition {
+	return dbTransition{
+		OrderID:  ord.ID,
+		TenantID: ord.TenantID,
+		From:     tr.From.Name(),
+		To:       tr.To.Name(),
+		ActorID: uuid.NullUUID{
+			UUID:  tr.ActorID,
+			Valid: tr.ActorID != uuid.Nil,
+		},
+		DateCreated: tr.Date.UTC(),
+	}
+}
+
+func toCoreTransition(dbTr dbTransition) salesorder.Transition {
+	return salesorder.Transition{
+		From:    salesorder.MustParseStatus(dbTr.From),
+		To:      salesorder.MustParseStatus(dbTr.To),
+		ActorID: dbTr.ActorID.UUID,
+		Date:    dbTr.DateCreated.In(time.Local),
+	}
+}
+
+// =============================================================================
+
+// dbReturn represents a return taken against an order.
+type dbReturn struct {
+	ID          uuid.UUID     `db:"return_id"`
+	OrderID     uuid.UUID     `db:"order_id"`
+	TenantID    uuid.UUID     `db:"tenant_id"`
+	Reason      string        `db:"reason"`
+	Restock     bool          `db:"restock"`
+	Amount      money.Money   `db:"amount"`
+	ActorID     uuid.NullUUID `db:"actor_id"`
+	DateCreated time.Time     `db:"date_created"`
+}
+
+func toDBReturn(ret salesorder.Return) dbReturn {
+	return dbReturn{
+		ID:       ret.ID,
+		OrderID:  ret.OrderID,
+		TenantID: ret.TenantID,
+		Reason:   ret.Reason.Name(),
+		Restock:  ret.Restock,
+		Amount:   ret.Amount,
+		ActorID: uuid.NullUUID{
+			UUID:  ret.ActorID,
+			Valid: ret.ActorID != uuid.Nil,
+		},
+		DateCreated: ret.DateCreated.UTC(),
+	}
+}
+
+func toCoreReturn(dbRet dbReturn, dbRetLines []dbReturnLine) salesorder.Return {
+	lines := make([]salesorder.ReturnLine, len(dbRetLines))
+	for i, dbRl := range dbRetLines {
+		lines[i] = salesorder.ReturnLine{
+			LineNumber: dbRl.LineNumber,
+			Quantity:   dbRl.Quantity,
+			Amount:     dbRl.Amount,
+		}
+	}
+
+	return salesorder.Return{
+		ID:          dbRet.ID,
+		OrderID:     dbRet.OrderID,
+		TenantID:    dbRet.TenantID,
+		Reason:      salesorder.MustParseReturnReason(dbRet.Reason),
+		Restock:     dbRet.Restock,
+		Lines:       lines,
+		Amount:      dbRet.Amount,
+		ActorID:     dbRet.ActorID.UUID,
+		DateCreated: dbRet.DateCreated.In(time.Local),
+	}
+}
+
+// dbReturnLine represents the quantity returned of a line of an order.
+type dbReturnLine struct {
+	ReturnID   uuid.UUID   `db:"return_id"`
+	LineNumber int         `db:"line_number"`
+	OrderID    uuid.UUID   `db:"order_id"`
+	TenantID   uuid.UUID   `db:"tenant_id"`
+	Quantity   int         `db:"quantity"`
+	Amount     money.Money `db:"amount"`
+}
+
+func toDBReturnLine(ret salesorder.Return, rl salesorder.ReturnLine) dbReturnLine {
+	return dbReturnLine{
+		ReturnID:   ret.ID,
+		LineNumber: rl.LineNumber,
+		OrderID:    ret.OrderID,
+		TenantID:   ret.TenantID,
+		Quantity:   rl.Quantity,
+		Amount:     rl.Amount,
+	}
+}
@@ -0,0 +1,24 @@
+package salesorderdb
+
+import (
+	"fmt"
+
+	"github.com/aleury/service/business/core/salesorder"
+	"github.com/aleury/service/business/data/order"
+)
+
+var orderByFields = map[string]string{
+	salesorder.OrderByID:          "order_id",
+	salesorder.OrderByUserID:      "user_id",
+	salesorder.OrderByStatus:      "status",
+	salesorder.OrderByTotal:       "total",
+	salesorder.OrderByDateCreated: "date_created",
+}
+
+func orderByClause(orderBy order.By) (string, error) {
+	by, exists := orderByFields[orderBy.Field]
+	if !exists {
+		return "", fmt.Errorf("field %q does not exist", orderBy.Field)
+	}
+	return fmt.Sprintf(" ORDER BY %s %s", by, orderBy.Direction), nil
+}
@@ -0,0 +1,443 @@
+// Package salesorderdb contains order related CRUD functionality.
+package salesorderdb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aleury/service/business/core/salesorder"
+	"github.com/aleury/service/business/core/tenant"
+	"github.com/aleury/service/business/data/order"
+	database "github.com/aleury/service/business/sys/database/pgx"
+	"github.com/aleury/service/business/sys/database/pgx/dbarray"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// Store manages the set of APIs for order database access.
+type Store struct {
+	log *zap.SugaredLogger
+	db  *sqlx.DB
+}
+
+// NewStore constructs the api for data access.
+func NewStore(log *zap.SugaredLogger, db *sqlx.DB) *Store {
+	return &Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// WithinTran runs passed function and does commit/rollback at the end. Every
+// store call made with the context handed to the function joins the
+// transaction.
+func (s *Store) WithinTran(ctx context.Context, fn func(ctx context.Context) error) error {
+	return database.WithinTran(ctx, s.log, s.db, fn)
+}
+
+// Create inserts a new order and its lines into the database.
+func (s *Store) Create(ctx context.Context, ord salesorder.Order) error {
+	const q = `
+	INSERT INTO orders
+		(order_id, tenant_id, user_id, status, total_quantity, discount, total, jurisdiction, tax_inclusive, tax, date_created, date_updated)
+	VALUES
+		(:order_id, :tenant_id, :user_id, :status, :total_quantity, :discount, :total, :jurisdiction, :tax_inclusive, :tax, :date_created, :date_updated)`
+
+	const ql = `
+	INSERT INTO order_lines
+		(order_id, line_number, tenant_id, product_id, sku, name, quantity, unit_cost, total, tax)
+	VALUES
+		(:order_id, :line_number, :tenant_id, :product_id, :sku, :name, :quantity, :unit_cost, :total, :tax)`
+
+	const qd = `
+	INSERT INTO order_discounts
+		(order_id, line_number, tenant_id, promotion_id, code, amount)
+	VALUES
+		(:order_id, :line_number, :tenant_id, :promotion_id, :code, :amount)`
+
+	tran := func(ctx context.Context) error {
+		if err := database.NamedExecContext(ctx, s.log, s.db, q, toDBOrder(ord)); err != nil {
+			return fmt.Errorf("namedexeccontext: %w", err)
+		}
+
+		for _, ln := range ord.Lines {
+			if err := database.NamedExecContext(ctx, s.log, s.db, ql, toDBLine(ord, ln)); err != nil {
+				return fmt.Errorf("namedexeccontext: line[%d]: %w", ln.Number, err)
+			}
+		}
+
+		for _, dsc := range ord.Discounts {
+			if err := database.NamedExecContext(ctx, s.log, s.db, qd, toDBDiscount(ord, dsc)); err != nil {
+				return fmt.Errorf("namedexeccontext: discount line[%d]: %w", dsc.LineNumber, err)
+			}
+		}
+
+		return nil
+	}
+
+	return s.WithinTran(ctx, tran)
+}
+
+// UpdateStatus changes the status of an order that is still in the from
+// status. ErrNotFound is returned when it isn't.
+func (s *Store) UpdateStatus(ctx context.Context, ord salesorder.Order, from salesorder.Status) error {
+	data := map[string]any{
+		"order_id":     ord.ID,
+		"status":       ord.Status.Name(),
+		"from_status":  from.Name(),
+		"date_updated": ord.DateUpdated.UTC(),
+	}
+
+	const q = `
+	UPDATE
+		orders
+	SET
+		"status" = :status,
+		"date_updated" = :date_updated
+	WHERE
+		order_id = :order_id AND status = :from_status
+	RETURNING
+		order_id`
+
+	var result struct {
+		ID uuid.UUID `db:"order_id"`
+	}
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &result); err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return fmt.Errorf("namedquerystruct: %w", salesorder.ErrNotFound)
+		}
+		return fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return nil
+}
+
+// CreateTransition records a change of status of an order.
+func (s *Store) CreateTransition(ctx context.Context, ord salesorder.Order, tr salesorder.Transition) error {
+	const q = `
+	INSERT INTO order_transitions
+		(order_id, tenant_id, from_status, to_status, actor_id, date_created)
+	VALUES
+		(:order_id, :tenant_id, :from_status, :to_status, :actor_id, :date_created)`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, toDBTransition(ord, tr)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// CreateReturn records a return taken against the order as it was read. The
+// refunded amounts are added to the order and its lines only if no other
+// return was taken and the status didn't change in the meantime, otherwise
+// salesorder.ErrNotFound is returned. The caller is expected to run it in a
+// transaction.
+func (s *Store) CreateReturn(ctx context.Context, ord salesorder.Order, ret salesorder.Return) error {
+	data := map[string]any{
+		"order_id":     ord.ID,
+		"refunded":     ord.Refunded,
+		"amount":       ret.Amount,
+		"date_updated": ret.DateCreated.UTC(),
+	}
+
+	const qo = `
+	UPDATE
+		orders
+	SET
+		"refunded" = refunded + :amount,
+		"date_updated" = :date_updated
+	WHERE
+		order_id = :order_id AND refunded = :refunded AND status IN ('PAID', 'FULFILLED')
+	RETURNING
+		order_id`
+
+	var result struct {
+		ID uuid.UUID `db:"order_id"`
+	}
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, qo, data, &result); err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return fmt.Errorf("namedquerystruct: %w", salesorder.ErrNotFound)
+		}
+		return fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	const ql = `
+	UPDATE
+		order_lines
+	SET
+		"returned" = returned + :quantity,
+		"refunded" = refunded + :amount
+	WHERE
+		order_id = :order_id AND line_number = :line_number AND returned = :returned
+	RETURNING
+		order_id`
+
+	for _, rl := range ret.Lines {
+		var returned int
+		for _, ln := range ord.Lines {
+			if ln.Number == rl.LineNumber {
+				returned = ln.Returned
+			}
+		}
+
+		data := map[string]any{
+			"order_id":    ord.ID,
+			"line_number": rl.LineNumber,
+			"quantity":    rl.Quantity,
+			"amount":      rl.Amount,
+			"returned":    returned,
+		}
+
+		if err := database.NamedQueryStruct(ctx, s.log, s.db, ql, data, &result); err != nil {
+			if errors.Is(err, database.ErrDBNotFound) {
+				return fmt.Errorf("namedquerystruct: line[%d]: %w", rl.LineNumber, salesorder.ErrNotFound)
+			}
+			return fmt.Errorf("namedquerystruct: line[%d]: %w", rl.LineNumber, err)
+		}
+	}
+
+	const qr = `
+	INSERT INTO order_returns
+		(return_id, order_id, tenant_id, reason, restock, amount, actor_id, date_created)
+	VALUES
+		(:return_id, :order_id, :tenant_id, :reason, :restock, :amount, :actor_id, :date_created)`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, qr, toDBReturn(ret)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	const qrl = `
+	INSERT INTO order_return_lines
+		(return_id, line_number, order_id, tenant_id, quantity, amount)
+	VALUES
+		(:return_id, :line_number, :order_id, :tenant_id, :quantity, :amount)`
+
+	for _, rl := range ret.Lines {
+		if err := database.NamedExecContext(ctx, s.log, s.db, qrl, toDBReturnLine(ret, rl)); err != nil {
+			return fmt.Errorf("namedexeccontext: line[%d]: %w", rl.LineNumber, err)
+		}
+	}
+
+	return nil
+}
+
+// QueryReturns retrieves the returns taken against an order, oldest first.
+func (s *Store) QueryReturns(ctx context.Context, orderID uuid.UUID) ([]salesorder.Return, error) {
+	data := map[string]any{
+		"order_id": orderID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		order_returns
+	WHERE
+		order_id = :order_id
+	ORDER BY
+		date_created`
+
+	var dbRets []dbReturn
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &dbRets); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	const ql = `
+	SELECT
+		*
+	FROM
+		order_return_lines
+	WHERE
+		order_id = :order_id
+	ORDER BY
+		return_id, line_number`
+
+	var dbRetLines []dbReturnLine
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, ql, data, &dbRetLines); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	linesByReturn := make(map[uuid.UUID][]dbReturnLine, len(dbRets))
+	for _, dbRl := range dbRetLines {
+		linesByReturn[dbRl.ReturnID] = append(linesByReturn[dbRl.ReturnID], dbRl)
+	}
+
+	rets := make([]salesorder.Return, len(dbRets))
+	for i, dbRet := range dbRets {
+		rets[i] = toCoreReturn(dbRet, linesByReturn[dbRet.ID])
+	}
+
+	return rets, nil
+}
+
+// Query retrieves a list of existing orders from the database.
+func (s *Store) Query(ctx context.Context, filter salesorder.QueryFilter, orderBy order.By, pageNumber int, rowsPerPage int) ([]salesorder.Order, error) {
+	data := map[string]any{
+		"offset":        (pageNumber - 1) * rowsPerPage,
+		"rows_per_page": rowsPerPage,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		orders`
+
+	buf := bytes.NewBufferString(q)
+	s.applyFilter(ctx, filter, data, buf)
+
+	orderByClause, err := orderByClause(orderBy)
+	if err != nil {
+		return nil, err
+	}
+
+	buf.WriteString(orderByClause)
+	buf.WriteString(" OFFSET :offset LIMIT :rows_per_page")
+
+	var dbOrds []dbOrder
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, buf.String(), data, &dbOrds); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return s.withDetails(ctx, dbOrds)
+}
+
+// Count returns the total number of orders in the DB.
+func (s *Store) Count(ctx context.Context, filter salesorder.QueryFilter) (int, error) {
+	data := map[string]any{}
+
+	const q = `
+	SELECT
+		COUNT(*)
+	FROM
+		orders`
+
+	buf := bytes.NewBufferString(q)
+	s.applyFilter(ctx, filter, data, buf)
+
+	var result struct {
+		Count int `db:"count"`
+	}
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, buf.String(), data, &result); err != nil {
+		return 0, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return result.Count, nil
+}
+
+// QueryByID gets the specified order from the database.
+func (s *Store) QueryByID(ctx context.Context, orderID uuid.UUID) (salesorder.Order, error) {
+	data := map[string]any{
+		"order_id": orderID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		orders
+	WHERE
+		order_id = :order_id`
+
+	var dbOrd dbOrder
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q+tenant.Scope(ctx, data, "tenant_id"), data, &dbOrd); err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return salesorder.Order{}, fmt.Errorf("namedquerystruct: %w", salesorder.ErrNotFound)
+		}
+		return salesorder.Order{}, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	ords, err := s.withDetails(ctx, []dbOrder{dbOrd})
+	if err != nil {
+		return salesorder.Order{}, err
+	}
+
+	return ords[0], nil
+}
+
+// withDetails loads the lines, transitions and discounts of the orders and
+// returns the complete orders.
+func (s *Store) withDetails(ctx context.Context, dbOrds []dbOrder) ([]salesorder.Order, error) {
+	if len(dbOrds) == 0 {
+		return []salesorder.Order{}, nil
+	}
+
+	ids := make([]string, len(dbOrds))
+	for i, dbOrd := range dbOrds {
+		ids[i] = dbOrd.ID.String()
+	}
+
+	data := map[string]any{
+		"order_ids": dbarray.Array(ids),
+	}
+
+	const ql = `
+	SELECT
+		*
+	FROM
+		order_lines
+	WHERE
+		order_id = ANY(:order_ids)
+	ORDER BY
+		order_id, line_number`
+
+	var dbLines []dbLine
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, ql, data, &dbLines); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	const qt = `
+	SELECT
+		*
+	FROM
+		order_transitions
+	WHERE
+		order_id = ANY(:order_ids)
+	ORDER BY
+		order_id, date_created`
+
+	var dbTrs []dbTransition
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, qt, data, &dbTrs); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	const qd = `
+	SELECT
+		*
+	FROM
+		order_discounts
+	WHERE
+		order_id = ANY(:order_ids)
+	ORDER BY
+		order_id, line_number, code`
+
+	var dbDscs []dbDiscount
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, qd, data, &dbDscs); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	linesByOrder := make(map[uuid.UUID][]dbLine, len(dbOrds))
+	for _, dbLn := range dbLines {
+		linesByOrder[dbLn.OrderID] = append(linesByOrder[dbLn.OrderID], dbLn)
+	}
+
+	trsByOrder := make(map[uuid.UUID][]dbTransition, len(dbOrds))
+	for _, dbTr := range dbTrs {
+		trsByOrder[dbTr.OrderID] = append(trsByOrder[dbTr.OrderID], dbTr)
+	}
+
+	dscsByOrder := make(map[uuid.UUID][]dbDiscount, len(dbOrds))
+	for _, dbDsc := range dbDscs {
+		dscsByOrder[dbDsc.OrderID] = append(dscsByOrder[dbDsc.OrderID], dbDsc)
+	}
+
+	ords := make([]salesorder.Order, len(dbOrds))
+	for i, dbOrd := range dbOrds {
+		ords[i] = toCoreOrder(dbOrd, linesByOrder[dbOrd.ID], trsByOrder[dbOrd.ID], dscsByOrder[dbOrd.ID])
+	}
+
+	return ords, nil
+}
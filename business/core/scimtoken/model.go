@@ -0,0 +1,24 @@
+package scimtoken
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Token represents a credential a provisioning client uses to access the
+// SCIM endpoints of a tenant. Only a hash of the secret is kept.
+type Token struct {
+	ID          uuid.UUID
+	TenantID    uuid.UUID
+	Name        string
+	Hash        string
+	DateCreated time.Time
+	DateRevoked time.Time
+}
+
+// NewToken contains information needed to create a new token.
+type NewToken struct {
+	TenantID uuid.UUID
+	Name     string
+}
@@ -0,0 +1,126 @@
+// Package scimtoken provides the core business API for the bearer
+// credentials used by provisioning clients to access the SCIM endpoints.
+package scimtoken
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Set of error variables for CRUD operations.
+var (
+	ErrNotFound     = errors.New("token not found")
+	ErrInvalidToken = errors.New("token is invalid")
+)
+
+// secretPrefix marks a secret as a SCIM credential so it can't be mistaken
+// for a JWT.
+const secretPrefix = "scim_"
+
+// Storer interface declares the behavior this package needs to persist and
+// retrieve data.
+type Storer interface {
+	Create(ctx context.Context, tok Token) error
+	Update(ctx context.Context, tok Token) error
+	QueryByID(ctx context.Context, tokenID uuid.UUID) (Token, error)
+	QueryByHash(ctx context.Context, hash string) (Token, error)
+}
+
+// Core manages the set of APIs for token access.
+type Core struct {
+	storer Storer
+}
+
+// NewCore constructs a core for token api access.
+func NewCore(storer Storer) *Core {
+	return &Core{
+		storer: storer,
+	}
+}
+
+// Create generates a new token for the tenant. The secret is returned only
+// once and must be handed to the provisioning client.
+func (c *Core) Create(ctx context.Context, nt NewToken) (Token, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return Token{}, "", fmt.Errorf("generating secret: %w", err)
+	}
+	secret := secretPrefix + base64.RawURLEncoding.EncodeToString(b)
+
+	tok := Token{
+		ID:          uuid.New(),
+		TenantID:    nt.TenantID,
+		Name:        nt.Name,
+		Hash:        hash(secret),
+		DateCreated: time.Now(),
+	}
+
+	if err := c.storer.Create(ctx, tok); err != nil {
+		return Token{}, "", fmt.Errorf("create: %w", err)
+	}
+
+	return tok, secret, nil
+}
+
+// Revoke marks the token as revoked so it can no longer be used.
+func (c *Core) Revoke(ctx context.Context, tok Token) (Token, error) {
+	if !tok.DateRevoked.IsZero() {
+		return tok, nil
+	}
+
+	tok.DateRevoked = time.Now()
+
+	if err := c.storer.Update(ctx, tok); err != nil {
+		return Token{}, fmt.Errorf("update: %w", err)
+	}
+
+	return tok, nil
+}
+
+// QueryByID gets the specified token from the database.
+func (c *Core) QueryByID(ctx context.Context, tokenID uuid.UUID) (Token, error) {
+	tok, err := c.storer.QueryByID(ctx, tokenID)
+	if err != nil {
+		return Token{}, fmt.Errorf("query: tokenID[%s]: %w", tokenID, err)
+	}
+
+	return tok, nil
+}
+
+// Authenticate finds the token that matches the secret. Unknown and revoked
+// tokens are both reported as invalid.
+func (c *Core) Authenticate(ctx context.Context, secret string) (Token, error) {
+	if !strings.HasPrefix(secret, secretPrefix) {
+		return Token{}, ErrInvalidToken
+	}
+
+	tok, err := c.storer.QueryByHash(ctx, hash(secret))
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return Token{}, ErrInvalidToken
+		}
+		return Token{}, fmt.Errorf("query: %w", err)
+	}
+
+	if !tok.DateRevoked.IsZero() {
+		return Token{}, ErrInvalidToken
+	}
+
+	return tok, nil
+}
+
+// hash returns the form of the secret that is stored. The secrets carry
+// enough entropy that a fast hash is sufficient.
+func hash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,122 @@
+package scimtoken_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"testing"
+	"time"
+
+	"github.com/aleury/service/business/core/scimtoken"
+	"github.com/aleury/service/business/core/scimtoken/stores/scimtokendb"
+	"github.com/aleury/service/business/core/user"
+	"github.com/aleury/service/business/data/dbtest"
+	"github.com/aleury/service/foundation/docker"
+	"github.com/google/uuid"
+)
+
+var c *docker.Container
+
+func TestMain(m *testing.M) {
+	var err error
+	c, err = dbtest.StartDB()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer dbtest.StopDB(c)
+
+	m.Run()
+}
+
+func Test_SCIMToken(t *testing.T) {
+	t.Run("authenticate", authenticate)
+}
+
+// =============================================================================
+
+func authenticate(t *testing.T) {
+	test := dbtest.NewTest(t, c)
+	defer func() {
+		if r := recover(); r != nil {
+			t.Log(r)
+			t.Error(string(debug.Stack()))
+		}
+		test.Teardown()
+	}()
+
+	api := test.CoreAPIs
+	core := scimtoken.NewCore(scimtokendb.NewStore(test.Log, test.DB))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	t.Log("Go seeding...")
+
+	tenantID, err := seed(ctx, api)
+	if err != nil {
+		t.Fatalf("Seeding error: %s", err)
+	}
+
+	// -------------------------------------------------------------------------
+
+	tok, secret, err := core.Create(ctx, scimtoken.NewToken{TenantID: tenantID, Name: "Okta"})
+	if err != nil {
+		t.Fatalf("Should be able to create token: %s.", err)
+	}
+
+	if tok.Hash == secret {
+		t.Errorf("Should NOT store the secret of the token.")
+	}
+
+	got, err := core.Authenticate(ctx, secret)
+	if err != nil {
+		t.Fatalf("Should be able to authenticate with the secret: %s.", err)
+	}
+
+	if got.ID != tok.ID || got.TenantID != tenantID {
+		t.Errorf("Should get back the token of the tenant: got %+v", got)
+	}
+
+	for _, s := range []string{"", secret[:len(secret)-1], secret[len("scim_"):], "scim_" + uuid.NewString()} {
+		if _, err := core.Authenticate(ctx, s); !errors.Is(err, scimtoken.ErrInvalidToken) {
+			t.Errorf("Should NOT be able to authenticate with %q: %v.", s, err)
+		}
+	}
+
+	// -------------------------------------------------------------------------
+
+	if _, err := core.Revoke(ctx, tok); err != nil {
+		t.Fatalf("Should be able to revoke token: %s.", err)
+	}
+
+	saved, err := core.QueryByID(ctx, tok.ID)
+	if err != nil {
+		t.Fatalf("Should be able to retrieve token by ID: %s.", err)
+	}
+
+	if saved.DateRevoked.IsZero() {
+		t.Errorf("Should have recorded when the token was revoked.")
+	}
+
+	if _, err := core.Authenticate(ctx, secret); !errors.Is(err, scimtoken.ErrInvalidToken) {
+		t.Errorf("Should NOT be able to authenticate with a revoked token: %v.", err)
+	}
+
+	if _, err := core.QueryByID(ctx, uuid.New()); !errors.Is(err, scimtoken.ErrNotFound) {
+		t.Errorf("Should NOT be able to retrieve an unknown token: %v.", err)
+	}
+}
+
+// =============================================================================
+
+// seed returns the tenant of the seeded users.
+func seed(ctx context.Context, api dbtest.CoreAPIs) (uuid.UUID, error) {
+	usrs, err := api.User.Query(ctx, user.QueryFilter{}, user.DefaultOrderBy, 1, 1)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("seeding users: %w", err)
+	}
+
+	return usrs[0].TenantID, nil
+}
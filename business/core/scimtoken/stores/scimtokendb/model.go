@@ -0,0 +1,52 @@
+package scimtokendb
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/aleury/service/business/core/scimtoken"
+	"github.com/google/uuid"
+)
+
+// dbToken represents the structure we need for moving data
+// between the app and the database.
+type dbToken struct {
+	ID          uuid.UUID    `db:"token_id"`
+	TenantID    uuid.UUID    `db:"tenant_id"`
+	Name        string       `db:"name"`
+	Hash        string       `db:"token_hash"`
+	DateCreated time.Time    `db:"date_created"`
+	DateRevoked sql.NullTime `db:"date_revoked"`
+}
+
+func toDBToken(tok scimtoken.Token) dbToken {
+	var revoked sql.NullTime
+	if !tok.DateRevoked.IsZero() {
+		revoked = sql.NullTime{Time: tok.DateRevoked.UTC(), Valid: true}
+	}
+
+	return dbToken{
+		ID:          tok.ID,
+		TenantID:    tok.TenantID,
+		Name:        tok.Name,
+		Hash:        tok.Hash,
+		DateCreated: tok.DateCreated.UTC(),
+		DateRevoked: revoked,
+	}
+}
+
+func toCoreToken(dbTok dbToken) scimtoken.Token {
+	var revoked time.Time
+	if dbTok.DateRevoked.Valid {
+		revoked = dbTok.DateRevoked.Time.In(time.Local)
+	}
+
+	return scimtoken.Token{
+		ID:          dbTok.ID,
+		TenantID:    dbTok.TenantID,
+		Name:        dbTok.Name,
+		Hash:        dbTok.Hash,
+		DateCreated: dbTok.DateCreated.In(time.Local),
+		DateRevoked: revoked,
+	}
+}
@@ -0,0 +1,115 @@
+// Package scimtokendb contains SCIM token related CRUD functionality.
+package scimtokendb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aleury/service/business/core/scimtoken"
+	database "github.com/aleury/service/business/sys/database/pgx"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// Store manages the set of APIs for token database access.
+type Store struct {
+	log *zap.SugaredLogger
+	db  *sqlx.DB
+}
+
+// NewStore constructs the api for data access.
+func NewStore(log *zap.SugaredLogger, db *sqlx.DB) *Store {
+	return &Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// Create inserts a new token into the database.
+func (s *Store) Create(ctx context.Context, tok scimtoken.Token) error {
+	const q = `
+	INSERT INTO scim_tokens
+		(token_id, tenant_id, name, token_hash, date_created, date_revoked)
+	VALUES
+		(:token_id, :tenant_id, :name, :token_hash, :date_created, :date_revoked)`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, toDBToken(tok)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Update replaces a token document in the database.
+func (s *Store) Update(ctx context.Context, tok scimtoken.Token) error {
+	const q = `
+	UPDATE
+		scim_tokens
+	SET
+		"name" = :name,
+		"date_revoked" = :date_revoked
+	WHERE
+		token_id = :token_id`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, toDBToken(tok)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// QueryByID gets the specified token from the database.
+func (s *Store) QueryByID(ctx context.Context, tokenID uuid.UUID) (scimtoken.Token, error) {
+	data := struct {
+		ID string `db:"token_id"`
+	}{
+		ID: tokenID.String(),
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		scim_tokens
+	WHERE
+		token_id = :token_id`
+
+	var dbTok dbToken
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbTok); err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return scimtoken.Token{}, fmt.Errorf("namedquerystruct: %w", scimtoken.ErrNotFound)
+		}
+		return scimtoken.Token{}, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return toCoreToken(dbTok), nil
+}
+
+// QueryByHash gets the token with the specified secret hash from the database.
+func (s *Store) QueryByHash(ctx context.Context, hash string) (scimtoken.Token, error) {
+	data := struct {
+		Hash string `db:"token_hash"`
+	}{
+		Hash: hash,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		scim_tokens
+	WHERE
+		token_hash = :token_hash`
+
+	var dbTok dbToken
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbTok); err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return scimtoken.Token{}, fmt.Errorf("namedquerystruct: %w", scimtoken.ErrNotFound)
+		}
+		return scimtoken.Token{}, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return toCoreToken(dbTok), nil
+}
@@ -0,0 +1,36 @@
+package session
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Session represents a signed in browser. Only a hash of the secret held by
+// the browser is kept.
+type Session struct {
+	ID           uuid.UUID
+	TenantID     uuid.UUID
+	UserID       uuid.UUID
+	Hash         string
+	UserAgent    string
+	IPAddress    string
+	DateCreated  time.Time
+	DateLastSeen time.Time
+	DateExpires  time.Time
+	DateRevoked  time.Time
+}
+
+// Active reports whether the session can still be used at the specified time.
+func (s Session) Active(now time.Time) bool {
+	return s.DateRevoked.IsZero() && now.Before(s.DateExpires)
+}
+
+// NewSession contains information needed to create a new session.
+type NewSession struct {
+	UserID    uuid.UUID
+	TenantID  uuid.UUID
+	UserAgent string
+	IPAddress string
+	TTL       time.Duration
+}
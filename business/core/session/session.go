@@ -0,0 +1,198 @@
+// Package session provides the core business API for server-side browser
+// sessions.
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aleury/service/business/core/user"
+	"github.com/google/uuid"
+)
+
+// Set of error variables for CRUD operations.
+var (
+	ErrNotFound       = errors.New("session not found")
+	ErrInvalidSession = errors.New("session is invalid")
+)
+
+// lastSeenInterval limits how often using a session is written back.
+const lastSeenInterval = time.Minute
+
+// Storer interface declares the behavior this package needs to persist and
+// retrieve data.
+type Storer interface {
+	Create(ctx context.Context, sess Session) error
+	Update(ctx context.Context, sess Session) error
+	RevokeByUser(ctx context.Context, userID uuid.UUID, now time.Time) error
+	Purge(ctx context.Context, before time.Time) error
+	QueryByID(ctx context.Context, sessionID uuid.UUID) (Session, error)
+	QueryByHash(ctx context.Context, hash string) (Session, error)
+	QueryByUser(ctx context.Context, userID uuid.UUID, pageNumber int, rowsPerPage int) ([]Session, error)
+	QueryAllByUser(ctx context.Context, userID uuid.UUID) ([]Session, error)
+	CountByUser(ctx context.Context, userID uuid.UUID) (int, error)
+}
+
+// Core manages the set of APIs for session access.
+type Core struct {
+	storer  Storer
+	usrCore *user.Core
+}
+
+// NewCore constructs a core for session api access.
+func NewCore(storer Storer, usrCore *user.Core) *Core {
+	return &Core{
+		storer:  storer,
+		usrCore: usrCore,
+	}
+}
+
+// Create starts a new session for a user. The secret is returned only once
+// and is what the browser presents on every request.
+func (c *Core) Create(ctx context.Context, ns NewSession) (Session, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return Session{}, "", fmt.Errorf("generating secret: %w", err)
+	}
+	secret := base64.RawURLEncoding.EncodeToString(b)
+
+	now := time.Now()
+
+	sess := Session{
+		ID:           uuid.New(),
+		TenantID:     ns.TenantID,
+		UserID:       ns.UserID,
+		Hash:         hash(secret),
+		UserAgent:    ns.UserAgent,
+		IPAddress:    ns.IPAddress,
+		DateCreated:  now,
+		DateLastSeen: now,
+		DateExpires:  now.Add(ns.TTL),
+	}
+
+	if err := c.storer.Create(ctx, sess); err != nil {
+		return Session{}, "", fmt.Errorf("create: %w", err)
+	}
+
+	return sess, secret, nil
+}
+
+// Authenticate finds the active session for the secret and the user it
+// belongs to. Sessions of users that were disabled or deleted are invalid.
+func (c *Core) Authenticate(ctx context.Context, secret string) (Session, user.User, error) {
+	sess, err := c.storer.QueryByHash(ctx, hash(secret))
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return Session{}, user.User{}, ErrInvalidSession
+		}
+		return Session{}, user.User{}, fmt.Errorf("query: %w", err)
+	}
+
+	now := time.Now()
+	if !sess.Active(now) {
+		return Session{}, user.User{}, ErrInvalidSession
+	}
+
+	usr, err := c.usrCore.QueryByID(ctx, sess.UserID)
+	if err != nil {
+		if errors.Is(err, user.ErrNotFound) {
+			return Session{}, user.User{}, ErrInvalidSession
+		}
+		return Session{}, user.User{}, fmt.Errorf("querybyid: userID[%s]: %w", sess.UserID, err)
+	}
+
+	if !usr.Enabled {
+		return Session{}, user.User{}, ErrInvalidSession
+	}
+
+	if now.Sub(sess.DateLastSeen) > lastSeenInterval {
+		sess.DateLastSeen = now
+		if err := c.storer.Update(ctx, sess); err != nil {
+			return Session{}, user.User{}, fmt.Errorf("update: sessionID[%s]: %w", sess.ID, err)
+		}
+	}
+
+	return sess, usr, nil
+}
+
+// Revoke ends the session.
+func (c *Core) Revoke(ctx context.Context, sess Session) (Session, error) {
+	if !sess.DateRevoked.IsZero() {
+		return sess, nil
+	}
+
+	sess.DateRevoked = time.Now()
+
+	if err := c.storer.Update(ctx, sess); err != nil {
+		return Session{}, fmt.Errorf("update: sessionID[%s]: %w", sess.ID, err)
+	}
+
+	return sess, nil
+}
+
+// RevokeAll ends every active session of the user.
+func (c *Core) RevokeAll(ctx context.Context, userID uuid.UUID) error {
+	if err := c.storer.RevokeByUser(ctx, userID, time.Now()); err != nil {
+		return fmt.Errorf("revokebyuser: userID[%s]: %w", userID, err)
+	}
+	return nil
+}
+
+// Purge permanently removes sessions that expired or were revoked before
+// the specified time.
+func (c *Core) Purge(ctx context.Context, before time.Time) error {
+	if err := c.storer.Purge(ctx, before); err != nil {
+		return fmt.Errorf("purge: before[%s]: %w", before, err)
+	}
+	return nil
+}
+
+// QueryByID gets the specified session from the database.
+func (c *Core) QueryByID(ctx context.Context, sessionID uuid.UUID) (Session, error) {
+	sess, err := c.storer.QueryByID(ctx, sessionID)
+	if err != nil {
+		return Session{}, fmt.Errorf("query: sessionID[%s]: %w", sessionID, err)
+	}
+	return sess, nil
+}
+
+// QueryByUser retrieves the active sessions of a user, newest first.
+func (c *Core) QueryByUser(ctx context.Context, userID uuid.UUID, pageNumber int, rowsPerPage int) ([]Session, error) {
+	sessions, err := c.storer.QueryByUser(ctx, userID, pageNumber, rowsPerPage)
+	if err != nil {
+		return nil, fmt.Errorf("query: userID[%s]: %w", userID, err)
+	}
+	return sessions, nil
+}
+
+// QueryAllByUser retrieves every session kept for a user, revoked and expired
+// ones included, newest first.
+func (c *Core) QueryAllByUser(ctx context.Context, userID uuid.UUID) ([]Session, error) {
+	sessions, err := c.storer.QueryAllByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("query: userID[%s]: %w", userID, err)
+	}
+	return sessions, nil
+}
+
+// CountByUser returns the number of active sessions of a user.
+func (c *Core) CountByUser(ctx context.Context, userID uuid.UUID) (int, error) {
+	count, err := c.storer.CountByUser(ctx, userID)
+	if err != nil {
+		return 0, fmt.Errorf("count: userID[%s]: %w", userID, err)
+	}
+	return count, nil
+}
+
+// hash returns the form of the secret that is stored. The secrets carry
+// enough entropy that a fast hash is sufficient.
+func hash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
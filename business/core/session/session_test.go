@@ -0,0 +1,187 @@
+package session_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"testing"
+	"time"
+
+	"github.com/aleury/service/business/core/session"
+	"github.com/aleury/service/business/core/user"
+	"github.com/aleury/service/business/data/dbtest"
+	"github.com/aleury/service/foundation/docker"
+)
+
+var c *docker.Container
+
+func TestMain(m *testing.M) {
+	var err error
+	c, err = dbtest.StartDB()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer dbtest.StopDB(c)
+
+	m.Run()
+}
+
+func Test_Session(t *testing.T) {
+	t.Run("crud", crud)
+	t.Run("invalid", invalid)
+}
+
+// =============================================================================
+
+func crud(t *testing.T) {
+	test := dbtest.NewTest(t, c)
+	defer func() {
+		if r := recover(); r != nil {
+			t.Log(r)
+			t.Error(string(debug.Stack()))
+		}
+		test.Teardown()
+	}()
+
+	api := test.CoreAPIs
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	t.Log("Go seeding...")
+
+	usrs, err := api.User.Query(ctx, user.QueryFilter{}, user.DefaultOrderBy, 1, 1)
+	if err != nil || len(usrs) != 1 {
+		t.Fatalf("Seeding error: %v", err)
+	}
+	saved := usrs[0]
+
+	// -------------------------------------------------------------------------
+
+	sess, secret, err := api.Session.Create(ctx, session.NewSession{
+		UserID:   saved.ID,
+		TenantID: saved.TenantID,
+		TTL:      time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("Should be able to create a session: %s.", err)
+	}
+
+	if sess.Hash == secret {
+		t.Error("Should NOT store the session secret.")
+	}
+
+	if _, sesUsr, err := api.Session.Authenticate(ctx, secret); err != nil || sesUsr.ID != saved.ID {
+		t.Errorf("Should be able to authenticate with the session secret: %v.", err)
+	}
+
+	if _, err := api.Session.QueryByID(ctx, sess.ID); err != nil {
+		t.Fatalf("Should be able to retrieve session by ID: %s.", err)
+	}
+
+	count, err := api.Session.CountByUser(ctx, saved.ID)
+	if err != nil {
+		t.Fatalf("Should be able to count the sessions of the user: %s.", err)
+	}
+
+	if count != 1 {
+		t.Errorf("Should count one active session: got %d", count)
+	}
+
+	// -------------------------------------------------------------------------
+
+	if _, err := api.Session.Revoke(ctx, sess); err != nil {
+		t.Fatalf("Should be able to revoke a session: %s.", err)
+	}
+
+	if _, _, err := api.Session.Authenticate(ctx, secret); !errors.Is(err, session.ErrInvalidSession) {
+		t.Errorf("Should NOT be able to authenticate with a revoked session: %v.", err)
+	}
+
+	if count, err = api.Session.CountByUser(ctx, saved.ID); err != nil || count != 0 {
+		t.Errorf("Should count no active sessions after revoking: got %d: %v", count, err)
+	}
+
+	if err := api.Session.Purge(ctx, time.Now().Add(time.Minute)); err != nil {
+		t.Fatalf("Should be able to purge sessions: %s.", err)
+	}
+
+	if _, err := api.Session.QueryByID(ctx, sess.ID); !errors.Is(err, session.ErrNotFound) {
+		t.Errorf("Should have purged the revoked session: %v.", err)
+	}
+}
+
+func invalid(t *testing.T) {
+	test := dbtest.NewTest(t, c)
+	defer func() {
+		if r := recover(); r != nil {
+			t.Log(r)
+			t.Error(string(debug.Stack()))
+		}
+		test.Teardown()
+	}()
+
+	api := test.CoreAPIs
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	t.Log("Go seeding...")
+
+	usrs, err := api.User.Query(ctx, user.QueryFilter{}, user.DefaultOrderBy, 1, 1)
+	if err != nil || len(usrs) != 1 {
+		t.Fatalf("Seeding error: %v", err)
+	}
+	saved := usrs[0]
+
+	newSession := func(ttl time.Duration) string {
+		_, secret, err := api.Session.Create(ctx, session.NewSession{
+			UserID:   saved.ID,
+			TenantID: saved.TenantID,
+			TTL:      ttl,
+		})
+		if err != nil {
+			t.Fatalf("Should be able to create a session: %s.", err)
+		}
+		return secret
+	}
+
+	// -------------------------------------------------------------------------
+
+	if _, _, err := api.Session.Authenticate(ctx, "unknown"); !errors.Is(err, session.ErrInvalidSession) {
+		t.Errorf("Should NOT be able to authenticate with an unknown secret: %v.", err)
+	}
+
+	expired := newSession(-time.Minute)
+	if _, _, err := api.Session.Authenticate(ctx, expired); !errors.Is(err, session.ErrInvalidSession) {
+		t.Errorf("Should NOT be able to authenticate with an expired session: %v.", err)
+	}
+
+	first := newSession(time.Hour)
+	second := newSession(time.Hour)
+
+	if err := api.Session.RevokeAll(ctx, saved.ID); err != nil {
+		t.Fatalf("Should be able to revoke every session of the user: %s.", err)
+	}
+
+	for _, secret := range []string{first, second} {
+		if _, _, err := api.Session.Authenticate(ctx, secret); !errors.Is(err, session.ErrInvalidSession) {
+			t.Errorf("Should NOT be able to authenticate after revoking every session: %v.", err)
+		}
+	}
+
+	// -------------------------------------------------------------------------
+
+	active := newSession(time.Hour)
+
+	enabled := false
+	if _, err := api.User.Update(ctx, saved, user.UpdateUser{Enabled: &enabled}); err != nil {
+		t.Fatalf("Should be able to disable user: %s.", err)
+	}
+
+	if _, _, err := api.Session.Authenticate(ctx, active); !errors.Is(err, session.ErrInvalidSession) {
+		t.Errorf("Should NOT be able to authenticate as a disabled user: %v.", err)
+	}
+}
@@ -0,0 +1,72 @@
+package sessiondb
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/aleury/service/business/core/session"
+	"github.com/google/uuid"
+)
+
+// dbSession represents the structure we need for moving data
+// between the app and the database.
+type dbSession struct {
+	ID           uuid.UUID    `db:"session_id"`
+	TenantID     uuid.UUID    `db:"tenant_id"`
+	UserID       uuid.UUID    `db:"user_id"`
+	Hash         string       `db:"token_hash"`
+	UserAgent    string       `db:"user_agent"`
+	IPAddress    string       `db:"ip_address"`
+	DateCreated  time.Time    `db:"date_created"`
+	DateLastSeen time.Time    `db:"date_last_seen"`
+	DateExpires  time.Time    `db:"date_expires"`
+	DateRevoked  sql.NullTime `db:"date_revoked"`
+}
+
+func toDBSession(sess session.Session) dbSession {
+	var revoked sql.NullTime
+	if !sess.DateRevoked.IsZero() {
+		revoked = sql.NullTime{Time: sess.DateRevoked.UTC(), Valid: true}
+	}
+
+	return dbSession{
+		ID:           sess.ID,
+		TenantID:     sess.TenantID,
+		UserID:       sess.UserID,
+		Hash:         sess.Hash,
+		UserAgent:    sess.UserAgent,
+		IPAddress:    sess.IPAddress,
+		DateCreated:  sess.DateCreated.UTC(),
+		DateLastSeen: sess.DateLastSeen.UTC(),
+		DateExpires:  sess.DateExpires.UTC(),
+		DateRevoked:  revoked,
+	}
+}
+
+func toCoreSession(dbSess dbSession) session.Session {
+	var revoked time.Time
+	if dbSess.DateRevoked.Valid {
+		revoked = dbSess.DateRevoked.Time.In(time.Local)
+	}
+
+	return session.Session{
+		ID:           dbSess.ID,
+		TenantID:     dbSess.TenantID,
+		UserID:       dbSess.UserID,
+		Hash:         dbSess.Hash,
+		UserAgent:    dbSess.UserAgent,
+		IPAddress:    dbSess.IPAddress,
+		DateCreated:  dbSess.DateCreated.In(time.Local),
+		DateLastSeen: dbSess.DateLastSeen.In(time.Local),
+		DateExpires:  dbSess.DateExpires.In(time.Local),
+		DateRevoked:  revoked,
+	}
+}
+
+func toCoreSessionSlice(dbSessions []dbSession) []session.Session {
+	sessions := make([]session.Session, len(dbSessions))
+	for i, dbSess := range dbSessions {
+		sessions[i] = toCoreSession(dbSess)
+	}
+	return sessions
+}
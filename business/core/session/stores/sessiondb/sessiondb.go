@@ -0,0 +1,241 @@
+// Package sessiondb contains session related CRUD functionality.
+package sessiondb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aleury/service/business/core/session"
+	"github.com/aleury/service/business/core/tenant"
+	database "github.com/aleury/service/business/sys/database/pgx"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// Store manages the set of APIs for session database access.
+type Store struct {
+	log *zap.SugaredLogger
+	db  *sqlx.DB
+}
+
+// NewStore constructs the api for data access.
+func NewStore(log *zap.SugaredLogger, db *sqlx.DB) *Store {
+	return &Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// Create inserts a new session into the database.
+func (s *Store) Create(ctx context.Context, sess session.Session) error {
+	const q = `
+	INSERT INTO sessions
+		(session_id, tenant_id, user_id, token_hash, user_agent, ip_address, date_created, date_last_seen, date_expires, date_revoked)
+	VALUES
+		(:session_id, :tenant_id, :user_id, :token_hash, :user_agent, :ip_address, :date_created, :date_last_seen, :date_expires, :date_revoked)`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, toDBSession(sess)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Update replaces a session document in the database.
+func (s *Store) Update(ctx context.Context, sess session.Session) error {
+	const q = `
+	UPDATE
+		sessions
+	SET
+		"date_last_seen" = :date_last_seen,
+		"date_revoked" = :date_revoked
+	WHERE
+		session_id = :session_id`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, toDBSession(sess)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// RevokeByUser marks every active session of the user as revoked.
+func (s *Store) RevokeByUser(ctx context.Context, userID uuid.UUID, now time.Time) error {
+	data := map[string]any{
+		"user_id": userID,
+		"now":     now.UTC(),
+	}
+
+	const q = `
+	UPDATE
+		sessions
+	SET
+		"date_revoked" = :now
+	WHERE
+		user_id = :user_id AND date_revoked IS NULL AND date_expires > :now`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q+tenant.Scope(ctx, data, "tenant_id"), data); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Purge deletes the sessions that expired or were revoked before the
+// specified time.
+func (s *Store) Purge(ctx context.Context, before time.Time) error {
+	data := struct {
+		Before time.Time `db:"before"`
+	}{
+		Before: before.UTC(),
+	}
+
+	const q = `
+	DELETE FROM
+		sessions
+	WHERE
+		date_expires < :before OR date_revoked < :before`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// QueryByID gets the specified session from the database.
+func (s *Store) QueryByID(ctx context.Context, sessionID uuid.UUID) (session.Session, error) {
+	data := map[string]any{
+		"session_id": sessionID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		sessions
+	WHERE
+		session_id = :session_id`
+
+	var dbSess dbSession
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q+tenant.Scope(ctx, data, "tenant_id"), data, &dbSess); err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return session.Session{}, fmt.Errorf("namedquerystruct: %w", session.ErrNotFound)
+		}
+		return session.Session{}, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return toCoreSession(dbSess), nil
+}
+
+// QueryByHash gets the session with the specified secret hash from the
+// database.
+func (s *Store) QueryByHash(ctx context.Context, hash string) (session.Session, error) {
+	data := struct {
+		Hash string `db:"token_hash"`
+	}{
+		Hash: hash,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		sessions
+	WHERE
+		token_hash = :token_hash`
+
+	var dbSess dbSession
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbSess); err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return session.Session{}, fmt.Errorf("namedquerystruct: %w", session.ErrNotFound)
+		}
+		return session.Session{}, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return toCoreSession(dbSess), nil
+}
+
+// QueryByUser retrieves the active sessions of a user, newest first.
+func (s *Store) QueryByUser(ctx context.Context, userID uuid.UUID, pageNumber int, rowsPerPage int) ([]session.Session, error) {
+	data := map[string]any{
+		"user_id":       userID,
+		"now":           time.Now().UTC(),
+		"offset":        (pageNumber - 1) * rowsPerPage,
+		"rows_per_page": rowsPerPage,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		sessions
+	WHERE
+		user_id = :user_id AND date_revoked IS NULL AND date_expires > :now`
+
+	buf := bytes.NewBufferString(q)
+	buf.WriteString(tenant.Scope(ctx, data, "tenant_id"))
+	buf.WriteString(" ORDER BY date_created DESC OFFSET :offset LIMIT :rows_per_page")
+
+	var dbSessions []dbSession
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, buf.String(), data, &dbSessions); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toCoreSessionSlice(dbSessions), nil
+}
+
+// QueryAllByUser retrieves every session of a user, newest first.
+func (s *Store) QueryAllByUser(ctx context.Context, userID uuid.UUID) ([]session.Session, error) {
+	data := map[string]any{
+		"user_id": userID,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		sessions
+	WHERE
+		user_id = :user_id`
+
+	buf := bytes.NewBufferString(q)
+	buf.WriteString(tenant.Scope(ctx, data, "tenant_id"))
+	buf.WriteString(" ORDER BY date_created DESC")
+
+	var dbSessions []dbSession
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, buf.String(), data, &dbSessions); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toCoreSessionSlice(dbSessions), nil
+}
+
+// CountByUser returns the number of active sessions of a user.
+func (s *Store) CountByUser(ctx context.Context, userID uuid.UUID) (int, error) {
+	data := map[string]any{
+		"user_id": userID,
+		"now":     time.Now().UTC(),
+	}
+
+	const q = `
+	SELECT
+		COUNT(*)
+	FROM
+		sessions
+	WHERE
+		user_id = :user_id AND date_revoked IS NULL AND date_expires > :now`
+
+	var result struct {
+		Count int `db:"count"`
+	}
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q+tenant.Scope(ctx, data, "tenant_id"), data, &result); err != nil {
+		return 0, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return result.Count, nil
+}
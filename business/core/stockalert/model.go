@@ -0,0 +1,41 @@
+package stockalert
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Alert represents a product that ran low on stock. The name, SKU and owner
+// of the product are recorded as they were when it alerted. An alert stays
+// open until the product is restocked, after which DateCleared is set.
+type Alert struct {
+	ID          uuid.UUID
+	TenantID    uuid.UUID
+	ProductID   uuid.UUID
+	UserID      uuid.UUID
+	Name        string
+	SKU         string
+	Quantity    int
+	Threshold   int
+	DateCreated time.Time
+	DateCleared time.Time
+}
+
+// LowStock represents a product whose stock on hand is below its reorder
+// threshold. DateAlerted is zero while the product hasn't alerted yet.
+type LowStock struct {
+	ProductID   uuid.UUID
+	TenantID    uuid.UUID
+	UserID      uuid.UUID
+	Name        string
+	SKU         string
+	Quantity    int
+	Threshold   int
+	DateAlerted time.Time
+}
+
+// Shortfall returns how many units are missing to reach the threshold.
+func (ls LowStock) Shortfall() int {
+	return ls.Threshold - ls.Quantity
+}
@@ -0,0 +1,85 @@
+// Package emailnotifier delivers stock alerts by queueing an email to the
+// owner of each product in the email outbox.
+package emailnotifier
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aleury/service/business/core/stockalert"
+	database "github.com/aleury/service/business/sys/database/pgx"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// Notifier queues stock alerts as emails in the outbox.
+type Notifier struct {
+	log *zap.SugaredLogger
+	db  *sqlx.DB
+}
+
+// New constructs a notifier that writes to the email outbox.
+func New(log *zap.SugaredLogger, db *sqlx.DB) *Notifier {
+	return &Notifier{
+		log: log,
+		db:  db,
+	}
+}
+
+// Notify queues an email for each of the alerts, addressed to the owner of
+// the product. Called with the context of the transaction recording the
+// alerts, the emails are only queued if the alerts are. Alerts of products
+// whose owner no longer exists are skipped.
+func (n *Notifier) Notify(ctx context.Context, alerts []stockalert.Alert) error {
+	// The values are cast since their types can't be inferred from the
+	// select list.
+	const q = `
+	INSERT INTO email_outbox
+		(email_id, tenant_id, recipient, subject, body, date_created)
+	SELECT
+		CAST(:email_id AS UUID), CAST(:tenant_id AS UUID), u.email,
+		CAST(:subject AS TEXT), CAST(:body AS TEXT), CAST(:date_created AS TIMESTAMP)
+	FROM
+		users AS u
+	WHERE
+		u.user_id = :user_id AND u.deleted_at IS NULL`
+
+	for _, a := range alerts {
+		data := map[string]any{
+			"email_id":     uuid.New(),
+			"tenant_id":    a.TenantID,
+			"user_id":      a.UserID,
+			"subject":      subject(a),
+			"body":         body(a),
+			"date_created": time.Now().UTC(),
+		}
+
+		if err := database.NamedExecContext(ctx, n.log, n.db, q, data); err != nil {
+			return fmt.Errorf("namedexeccontext: alertID[%s]: %w", a.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// =============================================================================
+
+func subject(a stockalert.Alert) string {
+	return fmt.Sprintf("Low stock: %s", a.Name)
+}
+
+func body(a stockalert.Alert) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%s is running low on stock.\n\n", a.Name)
+	if a.SKU != "" {
+		fmt.Fprintf(&b, "SKU: %s\n", a.SKU)
+	}
+	fmt.Fprintf(&b, "On hand: %d\n", a.Quantity)
+	fmt.Fprintf(&b, "Reorder threshold: %d\n", a.Threshold)
+
+	return b.String()
+}
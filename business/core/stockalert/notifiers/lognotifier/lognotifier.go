@@ -0,0 +1,36 @@
+// Package lognotifier delivers stock alerts by writing them to the log.
+package lognotifier
+
+import (
+	"context"
+
+	"github.com/aleury/service/business/core/stockalert"
+	"go.uber.org/zap"
+)
+
+// Notifier writes stock alerts to the log.
+type Notifier struct {
+	log *zap.SugaredLogger
+}
+
+// New constructs a notifier that writes to the log.
+func New(log *zap.SugaredLogger) *Notifier {
+	return &Notifier{
+		log: log,
+	}
+}
+
+// Notify writes a warning for each of the alerts.
+func (n *Notifier) Notify(ctx context.Context, alerts []stockalert.Alert) error {
+	for _, a := range alerts {
+		n.log.Warnw("low stock",
+			"tenant_id", a.TenantID,
+			"product_id", a.ProductID,
+			"name", a.Name,
+			"sku", a.SKU,
+			"quantity", a.Quantity,
+			"threshold", a.Threshold,
+		)
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+// Package webhooknotifier delivers stock alerts by posting them to a
+// webhook.
+package webhooknotifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/aleury/service/business/core/stockalert"
+)
+
+// Config represents the information required to post to a webhook.
+type Config struct {
+	URL    string
+	Client *http.Client
+}
+
+// Notifier posts stock alerts to a webhook.
+type Notifier struct {
+	url    string
+	client *http.Client
+}
+
+// New constructs a notifier for the specified configuration.
+func New(cfg Config) *Notifier {
+	client := cfg.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	return &Notifier{
+		url:    cfg.URL,
+		client: client,
+	}
+}
+
+// Notify posts the alerts to the webhook in a single request. Any status
+// other than a 2xx is treated as a failed delivery.
+func (n *Notifier) Notify(ctx context.Context, alerts []stockalert.Alert) error {
+	payload := struct {
+		Alerts []alert `json:"alerts"`
+	}{
+		Alerts: toAlerts(alerts),
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("encoding alerts: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("creating webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Drain the body so the connection can be reused.
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook request: status[%d]", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// =============================================================================
+
+// alert is the representation of an alert posted to the webhook.
+type alert struct {
+	ID          string `json:"id"`
+	TenantID    string `json:"tenantId"`
+	ProductID   string `json:"productId"`
+	UserID      string `json:"userId"`
+	Name        string `json:"name"`
+	SKU         string `json:"sku,omitempty"`
+	Quantity    int    `json:"quantity"`
+	Threshold   int    `json:"threshold"`
+	DateCreated string `json:"dateCreated"`
+}
+
+func toAlerts(alerts []stockalert.Alert) []alert {
+	items := make([]alert, len(alerts))
+	for i, a := range alerts {
+		items[i] = alert{
+			ID:          a.ID.String(),
+			TenantID:    a.TenantID.String(),
+			ProductID:   a.ProductID.String(),
+			UserID:      a.UserID.String(),
+			Name:        a.Name,
+			SKU:         a.SKU,
+			Quantity:    a.Quantity,
+			Threshold:   a.Threshold,
+			DateCreated: a.DateCreated.Format(time.RFC3339),
+		}
+	}
+	return items
+}
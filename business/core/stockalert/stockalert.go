@@ -0,0 +1,100 @@
+// Package stockalert provides the core business API for alerting on
+// products that run low on stock.
+package stockalert
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Storer interface declares the behavior this package needs to persist and
+// retrieve data.
+type Storer interface {
+	WithinTran(ctx context.Context, fn func(ctx context.Context) error) error
+	Raise(ctx context.Context, now time.Time) ([]Alert, error)
+	Clear(ctx context.Context, now time.Time) (int, error)
+	QueryLowStock(ctx context.Context, pageNumber int, rowsPerPage int) ([]LowStock, error)
+	CountLowStock(ctx context.Context) (int, error)
+}
+
+// Notifier interface declares the behavior this package needs to deliver
+// alerts.
+type Notifier interface {
+	Notify(ctx context.Context, alerts []Alert) error
+}
+
+// Core manages the set of APIs for stock alert access.
+type Core struct {
+	storer    Storer
+	notifiers []Notifier
+}
+
+// NewCore constructs a core for stock alert api access. Alerts are
+// delivered through every one of the notifiers.
+func NewCore(storer Storer, notifiers []Notifier) *Core {
+	return &Core{
+		storer:    storer,
+		notifiers: notifiers,
+	}
+}
+
+// Check clears the alerts of products that were restocked and raises an
+// alert for every product that is below its reorder threshold and doesn't
+// have an open alert yet, so a product alerts once each time it runs low.
+// The new alerts are only recorded once they were delivered, which means
+// alerts that failed to be delivered are raised again by the next check.
+// It returns the number of alerts raised.
+func (c *Core) Check(ctx context.Context, now time.Time) (int, error) {
+	var alerts []Alert
+
+	tran := func(ctx context.Context) error {
+		if _, err := c.storer.Clear(ctx, now); err != nil {
+			return fmt.Errorf("clear: %w", err)
+		}
+
+		var err error
+		alerts, err = c.storer.Raise(ctx, now)
+		if err != nil {
+			return fmt.Errorf("raise: %w", err)
+		}
+
+		if len(alerts) == 0 {
+			return nil
+		}
+
+		for _, n := range c.notifiers {
+			if err := n.Notify(ctx, alerts); err != nil {
+				return fmt.Errorf("notify: %w", err)
+			}
+		}
+
+		return nil
+	}
+
+	if err := c.storer.WithinTran(ctx, tran); err != nil {
+		return 0, err
+	}
+
+	return len(alerts), nil
+}
+
+// QueryLowStock retrieves the products that are below their reorder
+// threshold, furthest below first.
+func (c *Core) QueryLowStock(ctx context.Context, pageNumber int, rowsPerPage int) ([]LowStock, error) {
+	items, err := c.storer.QueryLowStock(ctx, pageNumber, rowsPerPage)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+	return items, nil
+}
+
+// CountLowStock returns the number of products that are below their reorder
+// threshold.
+func (c *Core) CountLowStock(ctx context.Context) (int, error) {
+	count, err := c.storer.CountLowStock(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("count: %w", err)
+	}
+	return count, nil
+}
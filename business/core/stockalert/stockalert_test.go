@@ -0,0 +1,188 @@
+package stockalert_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"testing"
+	"time"
+
+	"github.com/aleury/service/business/core/product"
+	"github.com/aleury/service/business/core/stockalert"
+	"github.com/aleury/service/business/core/stockalert/stores/stockalertdb"
+	"github.com/aleury/service/business/core/user"
+	"github.com/aleury/service/business/data/dbtest"
+	"github.com/aleury/service/business/data/money"
+	"github.com/aleury/service/foundation/docker"
+)
+
+var c *docker.Container
+
+func TestMain(m *testing.M) {
+	var err error
+	c, err = dbtest.StartDB()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer dbtest.StopDB(c)
+
+	m.Run()
+}
+
+func Test_StockAlert(t *testing.T) {
+	t.Run("check", check)
+}
+
+// =============================================================================
+
+func check(t *testing.T) {
+	test := dbtest.NewTest(t, c)
+	defer func() {
+		if r := recover(); r != nil {
+			t.Log(r)
+			t.Error(string(debug.Stack()))
+		}
+		test.Teardown()
+	}()
+
+	api := test.CoreAPIs
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	t.Log("Go seeding...")
+
+	low, err := seed(ctx, api)
+	if err != nil {
+		t.Fatalf("Seeding error: %s", err)
+	}
+
+	store := stockalertdb.NewStore(test.Log, test.DB)
+	rec := &recorder{}
+	core := stockalert.NewCore(store, []stockalert.Notifier{rec})
+
+	// -------------------------------------------------------------------------
+
+	failing := stockalert.NewCore(store, []stockalert.Notifier{rec, failer{}})
+
+	if _, err := failing.Check(ctx, time.Now()); err == nil {
+		t.Fatalf("Should NOT be able to check when an alert can't be delivered.")
+	}
+
+	items, err := core.QueryLowStock(ctx, 1, 10)
+	if err != nil {
+		t.Fatalf("Should be able to query low stock: %s.", err)
+	}
+
+	if len(items) != 1 || items[0].ProductID != low.ID || !items[0].DateAlerted.IsZero() {
+		t.Fatalf("Should NOT have recorded an alert that wasn't delivered: %+v", items)
+	}
+
+	// -------------------------------------------------------------------------
+
+	rec.alerts = nil
+
+	n, err := core.Check(ctx, time.Now())
+	if err != nil {
+		t.Fatalf("Should be able to check for low stock: %s.", err)
+	}
+
+	if n != 1 || len(rec.alerts) != 1 {
+		t.Fatalf("Should raise one alert for the product below its threshold: got %d delivered %d", n, len(rec.alerts))
+	}
+
+	alert := rec.alerts[0]
+	if alert.ProductID != low.ID || alert.Name != low.Name || alert.Quantity != 2 || alert.Threshold != 5 {
+		t.Errorf("Should have recorded the product as it was when it alerted: %+v", alert)
+	}
+
+	if n, err := core.Check(ctx, time.Now()); err != nil || n != 0 {
+		t.Errorf("Should NOT alert again while the alert is open: got %d: %v", n, err)
+	}
+
+	items, err = core.QueryLowStock(ctx, 1, 10)
+	if err != nil {
+		t.Fatalf("Should be able to query low stock: %s.", err)
+	}
+
+	if len(items) != 1 || items[0].DateAlerted.IsZero() || items[0].Shortfall() != 3 {
+		t.Errorf("Should list the product with its open alert: %+v", items)
+	}
+
+	if n, err := core.CountLowStock(ctx); err != nil || n != 1 {
+		t.Errorf("Should count one product below its threshold: got %d: %v", n, err)
+	}
+
+	// -------------------------------------------------------------------------
+
+	low, _, err = api.Product.Receive(ctx, low, 5)
+	if err != nil {
+		t.Fatalf("Should be able to receive stock: %s.", err)
+	}
+
+	if n, err := core.Check(ctx, time.Now()); err != nil || n != 0 {
+		t.Errorf("Should NOT alert for a restocked product: got %d: %v", n, err)
+	}
+
+	if n, err := core.CountLowStock(ctx); err != nil || n != 0 {
+		t.Errorf("Should NOT count a restocked product: got %d: %v", n, err)
+	}
+
+	if _, _, err := api.Product.Adjust(ctx, low, product.NewAdjustment{Quantity: -5, Reason: product.ReasonDamaged}); err != nil {
+		t.Fatalf("Should be able to adjust stock: %s.", err)
+	}
+
+	if n, err := core.Check(ctx, time.Now()); err != nil || n != 1 {
+		t.Errorf("Should alert again once the product runs low again: got %d: %v", n, err)
+	}
+}
+
+// =============================================================================
+
+// recorder keeps the alerts it was asked to deliver.
+type recorder struct {
+	alerts []stockalert.Alert
+}
+
+func (r *recorder) Notify(ctx context.Context, alerts []stockalert.Alert) error {
+	r.alerts = append(r.alerts, alerts...)
+	return nil
+}
+
+// failer fails to deliver any alert.
+type failer struct{}
+
+func (failer) Notify(ctx context.Context, alerts []stockalert.Alert) error {
+	return errors.New("delivery failed")
+}
+
+// seed creates a product below its reorder threshold, one above it and one
+// without a threshold, and returns the one below.
+func seed(ctx context.Context, api dbtest.CoreAPIs) (product.Product, error) {
+	usrs, err := api.User.Query(ctx, user.QueryFilter{}, user.DefaultOrderBy, 1, 1)
+	if err != nil {
+		return product.Product{}, fmt.Errorf("seeding users: %w", err)
+	}
+
+	nps := []product.NewProduct{
+		{Name: "Comic Books", Quantity: 2, ReorderThreshold: 5},
+		{Name: "McDonalds Toys", Quantity: 10, ReorderThreshold: 5},
+		{Name: "Trading Cards", Quantity: 1},
+	}
+
+	var prds []product.Product
+	for _, np := range nps {
+		np.Cost = money.MustParse("5.00", money.USD)
+		np.UserID = usrs[0].ID
+
+		prd, err := api.Product.Create(ctx, np)
+		if err != nil {
+			return product.Product{}, fmt.Errorf("seeding products: %w", err)
+		}
+		prds = append(prds, prd)
+	}
+
+	return prds[0], nil
+}
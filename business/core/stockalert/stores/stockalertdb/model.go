@@ -0,0 +1,75 @@
+package stockalertdb
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/aleury/service/business/core/stockalert"
+	"github.com/google/uuid"
+)
+
+// dbAlert represents a stock alert in the database.
+type dbAlert struct {
+	ID          uuid.UUID      `db:"alert_id"`
+	TenantID    uuid.UUID      `db:"tenant_id"`
+	ProductID   uuid.UUID      `db:"product_id"`
+	UserID      uuid.UUID      `db:"user_id"`
+	Name        string         `db:"name"`
+	SKU         sql.NullString `db:"sku"`
+	Quantity    int            `db:"quantity"`
+	Threshold   int            `db:"threshold"`
+	DateCreated time.Time      `db:"date_created"`
+	DateCleared sql.NullTime   `db:"date_cleared"`
+}
+
+func toCoreAlertSlice(dbAlerts []dbAlert) []stockalert.Alert {
+	alerts := make([]stockalert.Alert, len(dbAlerts))
+	for i, dbA := range dbAlerts {
+		alerts[i] = stockalert.Alert{
+			ID:          dbA.ID,
+			TenantID:    dbA.TenantID,
+			ProductID:   dbA.ProductID,
+			UserID:      dbA.UserID,
+			Name:        dbA.Name,
+			SKU:         dbA.SKU.String,
+			Quantity:    dbA.Quantity,
+			Threshold:   dbA.Threshold,
+			DateCreated: dbA.DateCreated.In(time.Local),
+		}
+		if dbA.DateCleared.Valid {
+			alerts[i].DateCleared = dbA.DateCleared.Time.In(time.Local)
+		}
+	}
+	return alerts
+}
+
+// dbLowStock represents a product below its reorder threshold.
+type dbLowStock struct {
+	ProductID   uuid.UUID      `db:"product_id"`
+	TenantID    uuid.UUID      `db:"tenant_id"`
+	UserID      uuid.UUID      `db:"user_id"`
+	Name        string         `db:"name"`
+	SKU         sql.NullString `db:"sku"`
+	Quantity    int            `db:"quantity"`
+	Threshold   int            `db:"threshold"`
+	DateAlerted sql.NullTime   `db:"date_alerted"`
+}
+
+func toCoreLowStockSlice(dbItems []dbLowStock) []stockalert.LowStock {
+	items := make([]stockalert.LowStock, len(dbItems))
+	for i, dbLs := range dbItems {
+		items[i] = stockalert.LowStock{
+			ProductID: dbLs.ProductID,
+			TenantID:  dbLs.TenantID,
+			UserID:    dbLs.UserID,
+			Name:      dbLs.Name,
+			SKU:       dbLs.SKU.String,
+			Quantity:  dbLs.Quantity,
+			Threshold: dbLs.Threshold,
+		}
+		if dbLs.DateAlerted.Valid {
+			items[i].DateAlerted = dbLs.DateAlerted.Time.In(time.Local)
+		}
+	}
+	return items
+}
@@ -0,0 +1,169 @@
+// Package stockalertdb contains stock alert related CRUD functionality.
+package stockalertdb
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aleury/service/business/core/stockalert"
+	"github.com/aleury/service/business/core/tenant"
+	database "github.com/aleury/service/business/sys/database/pgx"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// Store manages the set of APIs for stock alert database access.
+type Store struct {
+	log *zap.SugaredLogger
+	db  *sqlx.DB
+}
+
+// NewStore constructs the api for data access.
+func NewStore(log *zap.SugaredLogger, db *sqlx.DB) *Store {
+	return &Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// WithinTran runs passed function and does commit/rollback at the end. Every
+// store call made with the context handed to the function joins the
+// transaction.
+func (s *Store) WithinTran(ctx context.Context, fn func(ctx context.Context) error) error {
+	return database.WithinTran(ctx, s.log, s.db, fn)
+}
+
+// Raise opens an alert for every product below its reorder threshold that
+// doesn't have an open alert and returns the alerts opened. A product has at
+// most one open alert, so concurrent checks never raise it twice.
+func (s *Store) Raise(ctx context.Context, now time.Time) ([]stockalert.Alert, error) {
+	data := map[string]any{
+		"now": now.UTC(),
+	}
+
+	const q = `
+	INSERT INTO stock_alerts
+		(alert_id, tenant_id, product_id, user_id, name, sku, quantity, threshold, date_created)
+	SELECT
+		gen_random_uuid(), p.tenant_id, p.product_id, p.user_id, p.name, p.sku, p.quantity, p.reorder_threshold, CAST(:now AS TIMESTAMP)
+	FROM
+		products AS p
+	WHERE
+		p.deleted_at IS NULL AND
+		p.quantity < p.reorder_threshold AND
+		NOT EXISTS (SELECT 1 FROM stock_alerts AS a WHERE a.product_id = p.product_id AND a.date_cleared IS NULL)
+	ORDER BY
+		p.tenant_id, p.name
+	ON CONFLICT (product_id) WHERE date_cleared IS NULL DO NOTHING
+	RETURNING
+		*`
+
+	var dbAlerts []dbAlert
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &dbAlerts); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toCoreAlertSlice(dbAlerts), nil
+}
+
+// Clear closes the open alerts of products that are back at or above their
+// reorder threshold, that were deleted or had their threshold removed, and
+// returns how many there were.
+func (s *Store) Clear(ctx context.Context, now time.Time) (int, error) {
+	data := map[string]any{
+		"now": now.UTC(),
+	}
+
+	const q = `
+	WITH cleared AS (
+		UPDATE
+			stock_alerts AS a
+		SET
+			"date_cleared" = :now
+		FROM
+			products AS p
+		WHERE
+			a.product_id = p.product_id AND
+			a.date_cleared IS NULL AND
+			(p.quantity >= p.reorder_threshold OR p.deleted_at IS NOT NULL)
+		RETURNING
+			a.alert_id
+	)
+	SELECT
+		COUNT(*)
+	FROM
+		cleared`
+
+	var result struct {
+		Count int `db:"count"`
+	}
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &result); err != nil {
+		return 0, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return result.Count, nil
+}
+
+// lowStock selects the products below their reorder threshold along with
+// the date of their open alert.
+const lowStock = `
+	SELECT
+		p.product_id,
+		p.tenant_id,
+		p.user_id,
+		p.name,
+		p.sku,
+		p.quantity,
+		p.reorder_threshold AS threshold,
+		a.date_created AS date_alerted
+	FROM
+		products AS p
+	LEFT JOIN
+		stock_alerts AS a ON a.product_id = p.product_id AND a.date_cleared IS NULL
+	WHERE
+		p.deleted_at IS NULL AND p.quantity < p.reorder_threshold`
+
+// QueryLowStock retrieves the products below their reorder threshold,
+// furthest below first.
+func (s *Store) QueryLowStock(ctx context.Context, pageNumber int, rowsPerPage int) ([]stockalert.LowStock, error) {
+	data := map[string]any{
+		"offset":        (pageNumber - 1) * rowsPerPage,
+		"rows_per_page": rowsPerPage,
+	}
+
+	buf := bytes.NewBufferString(lowStock)
+	buf.WriteString(tenant.Scope(ctx, data, "p.tenant_id"))
+	buf.WriteString(" ORDER BY p.reorder_threshold - p.quantity DESC, p.name, p.product_id OFFSET :offset LIMIT :rows_per_page")
+
+	var dbItems []dbLowStock
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, buf.String(), data, &dbItems); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toCoreLowStockSlice(dbItems), nil
+}
+
+// CountLowStock returns the number of products below their reorder
+// threshold.
+func (s *Store) CountLowStock(ctx context.Context) (int, error) {
+	data := map[string]any{}
+
+	const q = `
+	SELECT
+		COUNT(*)
+	FROM
+		products AS p
+	WHERE
+		p.deleted_at IS NULL AND p.quantity < p.reorder_threshold`
+
+	var result struct {
+		Count int `db:"count"`
+	}
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q+tenant.Scope(ctx, data, "p.tenant_id"), data, &result); err != nil {
+		return 0, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return result.Count, nil
+}
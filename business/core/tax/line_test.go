@@ -0,0 +1,89 @@
+package tax
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aleury/service/business/core/product"
+	"github.com/aleury/service/business/data/money"
+)
+
+func Test_Line(t *testing.T) {
+	ny := Jurisdiction{
+		Code:     "US-NY",
+		Rounding: money.RoundHalfUp,
+		Rates:    []Rate{{Class: "STANDARD", Percent: money.MustParsePercent("8.875")}},
+	}
+
+	gb := func(r money.Rounding) Jurisdiction {
+		return Jurisdiction{
+			Code:      "GB",
+			Inclusive: true,
+			Rounding:  r,
+			Rates: []Rate{
+				{Class: "STANDARD", Percent: money.MustParsePercent("20")},
+				{Class: "REDUCED", Percent: money.MustParsePercent("5")},
+			},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		jur      Jurisdiction
+		cost     string
+		class    string
+		quantity int
+		discount string
+		rate     string
+		net      string
+		tax      string
+		gross    string
+	}{
+		{"exclusive", ny, "19.99", "STANDARD", 3, "0.00", "8.875", "59.97", "5.32", "65.29"},
+		{"exclusive fallback", ny, "10.00", "BOOKS", 1, "0.00", "8.875", "10.00", "0.89", "10.89"},
+		{"inclusive", gb(money.RoundHalfUp), "12.00", "STANDARD", 1, "0.00", "20", "10.00", "2.00", "12.00"},
+		{"inclusive class", gb(money.RoundHalfUp), "10.50", "REDUCED", 2, "0.00", "5", "20.00", "1.00", "21.00"},
+		{"half up", gb(money.RoundHalfUp), "9.99", "STANDARD", 1, "0.00", "20", "8.32", "1.67", "9.99"},
+		{"half even", gb(money.RoundHalfEven), "9.99", "STANDARD", 1, "0.00", "20", "8.33", "1.66", "9.99"},
+		{"down", gb(money.RoundDown), "9.99", "STANDARD", 1, "0.00", "20", "8.33", "1.66", "9.99"},
+		{"up", gb(money.RoundUp), "9.99", "STANDARD", 1, "0.00", "20", "8.32", "1.67", "9.99"},
+		{"exclusive discounted", ny, "19.99", "STANDARD", 3, "9.97", "8.875", "50.00", "4.44", "54.44"},
+		{"inclusive discounted", gb(money.RoundHalfUp), "12.00", "STANDARD", 1, "2.00", "20", "8.33", "1.67", "10.00"},
+	}
+
+	for _, tt := range tests {
+		prd := product.Product{
+			Cost:     money.MustParse(tt.cost, money.USD),
+			TaxClass: tt.class,
+		}
+
+		ln, err := tt.jur.line(prd, tt.quantity, money.MustParse(tt.discount, money.USD))
+		if err != nil {
+			t.Errorf("%s: Should be able to work out the tax: %s.", tt.name, err)
+			continue
+		}
+
+		if !ln.Rate.Equal(money.MustParsePercent(tt.rate)) {
+			t.Errorf("%s: Should tax at %s%%: got %s%%", tt.name, tt.rate, ln.Rate)
+		}
+
+		if ln.Net.String() != tt.net || ln.Tax.String() != tt.tax || ln.Gross.String() != tt.gross {
+			t.Errorf("%s: Should get net %s tax %s gross %s: got %s %s %s", tt.name, tt.net, tt.tax, tt.gross, ln.Net, ln.Tax, ln.Gross)
+		}
+	}
+
+	noStandard := Jurisdiction{
+		Code:  "XX",
+		Rates: []Rate{{Class: "REDUCED", Percent: money.MustParsePercent("5")}},
+	}
+
+	prd := product.Product{Cost: money.MustParse("1.00", money.USD), TaxClass: "STANDARD"}
+	if _, err := noStandard.line(prd, 1, money.Money{}); !errors.Is(err, ErrNoRate) {
+		t.Errorf("Should NOT tax a class without a rate or a STANDARD rate to fall back on: %v.", err)
+	}
+
+	prd.Cost = money.New(1<<62, money.USD)
+	if _, err := ny.line(prd, 4, money.Money{}); !errors.Is(err, money.ErrOverflow) {
+		t.Errorf("Should NOT tax a line that overflows: %v.", err)
+	}
+}
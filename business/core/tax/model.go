@@ -0,0 +1,72 @@
+package tax
+
+import (
+	"time"
+
+	"github.com/aleury/service/business/data/money"
+	"github.com/google/uuid"
+)
+
+// Jurisdiction represents a region with its own tax rates. Prices are taken
+// to include tax in an inclusive jurisdiction and to have it added on top
+// otherwise. Tax is rounded on every line with the rounding of the
+// jurisdiction.
+type Jurisdiction struct {
+	ID          uuid.UUID
+	TenantID    uuid.UUID
+	Code        string
+	Name        string
+	Inclusive   bool
+	Rounding    money.Rounding
+	Rates       []Rate
+	DateCreated time.Time
+	DateUpdated time.Time
+}
+
+// Rate is the percentage products of a tax class are taxed at.
+type Rate struct {
+	Class   string
+	Percent money.Percent
+}
+
+// NewJurisdiction is what we require to load the rates of a jurisdiction.
+type NewJurisdiction struct {
+	Code      string
+	Name      string
+	Inclusive bool
+	Rounding  money.Rounding
+	Rates     []Rate
+}
+
+// NewLine is a product and the quantity of it a quote is asked for. The
+// product is given by its ID or its SKU. The discount, if any, is taken off
+// the line before it is taxed.
+type NewLine struct {
+	ProductID uuid.UUID
+	SKU       string
+	Quantity  int
+	Discount  money.Money
+}
+
+// Quote is the tax due on a prospective order in a jurisdiction.
+type Quote struct {
+	Jurisdiction Jurisdiction
+	Lines        []Line
+	Net          money.Money
+	Tax          money.Money
+	Gross        money.Money
+}
+
+// Line is the tax due on a line of a quote. Net is the amount before tax and
+// Gross the amount after it, whichever of them the price of the product was.
+type Line struct {
+	Number    int
+	ProductID uuid.UUID
+	SKU       string
+	Quantity  int
+	TaxClass  string
+	Rate      money.Percent
+	Net       money.Money
+	Tax       money.Money
+	Gross     money.Money
+}
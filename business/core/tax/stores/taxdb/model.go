@@ -0,0 +1,76 @@
+package taxdb
+
+import (
+	"time"
+
+	"github.com/aleury/service/business/core/tax"
+	"github.com/aleury/service/business/data/money"
+	"github.com/google/uuid"
+)
+
+// dbJurisdiction represents the structure we need for moving data
+// between the app and the database.
+type dbJurisdiction struct {
+	ID          uuid.UUID `db:"jurisdiction_id"`
+	TenantID    uuid.UUID `db:"tenant_id"`
+	Code        string    `db:"code"`
+	Name        string    `db:"name"`
+	Inclusive   bool      `db:"inclusive"`
+	Rounding    string    `db:"rounding"`
+	DateCreated time.Time `db:"date_created"`
+	DateUpdated time.Time `db:"date_updated"`
+}
+
+func toDBJurisdiction(jur tax.Jurisdiction) dbJurisdiction {
+	return dbJurisdiction{
+		ID:          jur.ID,
+		TenantID:    jur.TenantID,
+		Code:        jur.Code,
+		Name:        jur.Name,
+		Inclusive:   jur.Inclusive,
+		Rounding:    jur.Rounding.Name(),
+		DateCreated: jur.DateCreated.UTC(),
+		DateUpdated: jur.DateUpdated.UTC(),
+	}
+}
+
+func toCoreJurisdiction(dbJur dbJurisdiction, dbRates []dbRate) tax.Jurisdiction {
+	rates := make([]tax.Rate, len(dbRates))
+	for i, dbRate := range dbRates {
+		rates[i] = tax.Rate{
+			Class:   dbRate.TaxClass,
+			Percent: dbRate.Rate,
+		}
+	}
+
+	return tax.Jurisdiction{
+		ID:          dbJur.ID,
+		TenantID:    dbJur.TenantID,
+		Code:        dbJur.Code,
+		Name:        dbJur.Name,
+		Inclusive:   dbJur.Inclusive,
+		Rounding:    money.MustParseRounding(dbJur.Rounding),
+		Rates:       rates,
+		DateCreated: dbJur.DateCreated.In(time.Local),
+		DateUpdated: dbJur.DateUpdated.In(time.Local),
+	}
+}
+
+// =============================================================================
+
+// dbRate represents the rate a tax class is taxed at in a jurisdiction.
+type dbRate struct {
+	JurisdictionID uuid.UUID     `db:"jurisdiction_id"`
+	TenantID       uuid.UUID     `db:"tenant_id"`
+	TaxClass       string        `db:"tax_class"`
+	Rate           money.Percent `db:"rate"`
+}
+
+func toDBRate(jur tax.Jurisdiction, rate tax.Rate) dbRate {
+	return dbRate{
+		JurisdictionID: jur.ID,
+		TenantID:       jur.TenantID,
+		TaxClass:       rate.Class,
+		Rate:           rate.Percent,
+	}
+}
@@ -0,0 +1,181 @@
+// Package taxdb contains tax jurisdiction related CRUD functionality.
+package taxdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aleury/service/business/core/tax"
+	"github.com/aleury/service/business/core/tenant"
+	database "github.com/aleury/service/business/sys/database/pgx"
+	"github.com/aleury/service/business/sys/database/pgx/dbarray"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// Store manages the set of APIs for tax database access.
+type Store struct {
+	log *zap.SugaredLogger
+	db  *sqlx.DB
+}
+
+// NewStore constructs the api for data access.
+func NewStore(log *zap.SugaredLogger, db *sqlx.DB) *Store {
+	return &Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// WithinTran runs passed function and does commit/rollback at the end. Every
+// store call made with the context handed to the function joins the
+// transaction.
+func (s *Store) WithinTran(ctx context.Context, fn func(ctx context.Context) error) error {
+	return database.WithinTran(ctx, s.log, s.db, fn)
+}
+
+// Upsert adds a jurisdiction to the database or updates the one already
+// recorded with its ID, and replaces its rates. The caller is expected to
+// run it in a transaction.
+func (s *Store) Upsert(ctx context.Context, jur tax.Jurisdiction) error {
+	const q = `
+	INSERT INTO tax_jurisdictions
+		(jurisdiction_id, tenant_id, code, name, inclusive, rounding, date_created, date_updated)
+	VALUES
+		(:jurisdiction_id, :tenant_id, :code, :name, :inclusive, :rounding, :date_created, :date_updated)
+	ON CONFLICT (jurisdiction_id) DO UPDATE SET
+		"name" = EXCLUDED.name,
+		"inclusive" = EXCLUDED.inclusive,
+		"rounding" = EXCLUDED.rounding,
+		"date_updated" = EXCLUDED.date_updated`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, toDBJurisdiction(jur)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	data := map[string]any{
+		"jurisdiction_id": jur.ID,
+	}
+
+	const qd = `
+	DELETE FROM
+		tax_rates
+	WHERE
+		jurisdiction_id = :jurisdiction_id`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, qd, data); err != nil {
+		return fmt.Errorf("namedexeccontext: delete rates: %w", err)
+	}
+
+	const qr = `
+	INSERT INTO tax_rates
+		(jurisdiction_id, tenant_id, tax_class, rate)
+	VALUES
+		(:jurisdiction_id, :tenant_id, :tax_class, :rate)`
+
+	for _, rate := range jur.Rates {
+		if err := database.NamedExecContext(ctx, s.log, s.db, qr, toDBRate(jur, rate)); err != nil {
+			return fmt.Errorf("namedexeccontext: rate[%s]: %w", rate.Class, err)
+		}
+	}
+
+	return nil
+}
+
+// Query retrieves the jurisdictions from the database ordered by code.
+func (s *Store) Query(ctx context.Context) ([]tax.Jurisdiction, error) {
+	data := map[string]any{}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		tax_jurisdictions
+	WHERE
+		TRUE`
+
+	const orderBy = " ORDER BY code"
+
+	var dbJurs []dbJurisdiction
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q+tenant.Scope(ctx, data, "tenant_id")+orderBy, data, &dbJurs); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return s.withRates(ctx, dbJurs)
+}
+
+// QueryByCode gets the jurisdiction with the code from the database.
+func (s *Store) QueryByCode(ctx context.Context, code string) (tax.Jurisdiction, error) {
+	data := map[string]any{
+		"code": code,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		tax_jurisdictions
+	WHERE
+		code = :code`
+
+	var dbJur dbJurisdiction
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q+tenant.Scope(ctx, data, "tenant_id"), data, &dbJur); err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return tax.Jurisdiction{}, fmt.Errorf("namedquerystruct: %w", tax.ErrNotFound)
+		}
+		return tax.Jurisdiction{}, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	jurs, err := s.withRates(ctx, []dbJurisdiction{dbJur})
+	if err != nil {
+		return tax.Jurisdiction{}, err
+	}
+
+	return jurs[0], nil
+}
+
+// withRates loads the rates of the jurisdictions and returns the complete
+// jurisdictions.
+func (s *Store) withRates(ctx context.Context, dbJurs []dbJurisdiction) ([]tax.Jurisdiction, error) {
+	if len(dbJurs) == 0 {
+		return []tax.Jurisdiction{}, nil
+	}
+
+	ids := make([]string, len(dbJurs))
+	for i, dbJur := range dbJurs {
+		ids[i] = dbJur.ID.String()
+	}
+
+	data := map[string]any{
+		"jurisdiction_ids": dbarray.Array(ids),
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		tax_rates
+	WHERE
+		jurisdiction_id = ANY(:jurisdiction_ids)
+	ORDER BY
+		jurisdiction_id, tax_class`
+
+	var dbRates []dbRate
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &dbRates); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	ratesByJur := make(map[uuid.UUID][]dbRate, len(dbJurs))
+	for _, dbRate := range dbRates {
+		ratesByJur[dbRate.JurisdictionID] = append(ratesByJur[dbRate.JurisdictionID], dbRate)
+	}
+
+	jurs := make([]tax.Jurisdiction, len(dbJurs))
+	for i, dbJur := range dbJurs {
+		jurs[i] = toCoreJurisdiction(dbJur, ratesByJur[dbJur.ID])
+	}
+
+	return jurs, nil
+}
@@ -0,0 +1,383 @@
+// Package tax provides the core business API for the tax rates of
+// jurisdictions and the tax due on orders.
+package tax
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aleury/service/business/core/product"
+	"github.com/aleury/service/business/core/tenant"
+	"github.com/aleury/service/business/data/money"
+	"github.com/google/uuid"
+)
+
+// Set of error variables for CRUD operations.
+var (
+	ErrNotFound     = errors.New("tax jurisdiction not found")
+	ErrNoRate       = errors.New("jurisdiction has no rate for the tax class")
+	ErrDuplicate    = errors.New("tax class is listed more than once")
+	ErrEmptyQuote   = errors.New("quote must have at least one line")
+	ErrInvalidCSV   = errors.New("invalid csv")
+	ErrInconsistent = errors.New("records of a jurisdiction disagree")
+)
+
+// Storer interface declares the behavior this package needs to persist and
+// retrieve data.
+type Storer interface {
+	WithinTran(ctx context.Context, fn func(ctx context.Context) error) error
+	Upsert(ctx context.Context, jur Jurisdiction) error
+	Query(ctx context.Context) ([]Jurisdiction, error)
+	QueryByCode(ctx context.Context, code string) (Jurisdiction, error)
+}
+
+// Core manages the set of APIs for tax access.
+type Core struct {
+	storer  Storer
+	prdCore *product.Core
+}
+
+// NewCore constructs a core for tax api access.
+func NewCore(storer Storer, prdCore *product.Core) *Core {
+	return &Core{
+		storer:  storer,
+		prdCore: prdCore,
+	}
+}
+
+// Load records the jurisdictions for the tenant of the request. The rates
+// of a jurisdiction that is already known by its code are replaced with the
+// new ones. Either all of the jurisdictions are recorded or none are.
+func (c *Core) Load(ctx context.Context, njs []NewJurisdiction) ([]Jurisdiction, error) {
+	now := time.Now()
+
+	jurs := make([]Jurisdiction, len(njs))
+	for i, nj := range njs {
+		rates := make([]Rate, len(nj.Rates))
+		seen := make(map[string]bool, len(nj.Rates))
+		for j, rate := range nj.Rates {
+			rate.Class = normalize(rate.Class)
+			if seen[rate.Class] {
+				return nil, fmt.Errorf("jurisdiction[%s] class[%s]: %w", nj.Code, rate.Class, ErrDuplicate)
+			}
+			seen[rate.Class] = true
+			rates[j] = rate
+		}
+
+		jurs[i] = Jurisdiction{
+			ID:          uuid.New(),
+			TenantID:    tenant.GetTenantID(ctx),
+			Code:        normalize(nj.Code),
+			Name:        nj.Name,
+			Inclusive:   nj.Inclusive,
+			Rounding:    nj.Rounding,
+			Rates:       rates,
+			DateCreated: now,
+			DateUpdated: now,
+		}
+	}
+
+	// Jurisdictions are written in code order so concurrent loads lock the
+	// rows in the same order and can't deadlock.
+	sort.SliceStable(jurs, func(i, j int) bool {
+		return jurs[i].Code < jurs[j].Code
+	})
+
+	tran := func(ctx context.Context) error {
+		for i, jur := range jurs {
+			old, err := c.storer.QueryByCode(ctx, jur.Code)
+			switch {
+			case err == nil:
+				jur.ID = old.ID
+				jur.DateCreated = old.DateCreated
+			case !errors.Is(err, ErrNotFound):
+				return fmt.Errorf("query: code[%s]: %w", jur.Code, err)
+			}
+
+			if err := c.storer.Upsert(ctx, jur); err != nil {
+				return fmt.Errorf("upsert: code[%s]: %w", jur.Code, err)
+			}
+
+			jurs[i] = jur
+		}
+		return nil
+	}
+
+	if err := c.storer.WithinTran(ctx, tran); err != nil {
+		return nil, err
+	}
+
+	return jurs, nil
+}
+
+// Query retrieves the jurisdictions of the tenant of the request ordered by
+// code.
+func (c *Core) Query(ctx context.Context) ([]Jurisdiction, error) {
+	jurs, err := c.storer.Query(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+	return jurs, nil
+}
+
+// QueryByCode gets the jurisdiction with the code.
+func (c *Core) QueryByCode(ctx context.Context, code string) (Jurisdiction, error) {
+	jur, err := c.storer.QueryByCode(ctx, normalize(code))
+	if err != nil {
+		return Jurisdiction{}, fmt.Errorf("query: code[%s]: %w", code, err)
+	}
+	return jur, nil
+}
+
+// Quote works out the tax due on every line of a prospective order in the
+// jurisdiction with the code. Lines are priced at the cost of their product.
+// It fails with ErrNoRate when the jurisdiction has no rate for the tax class
+// of a product, nor a STANDARD rate to fall back on.
+func (c *Core) Quote(ctx context.Context, code string, nls []NewLine) (Quote, error) {
+	if len(nls) == 0 {
+		return Quote{}, ErrEmptyQuote
+	}
+
+	jur, err := c.QueryByCode(ctx, code)
+	if err != nil {
+		return Quote{}, err
+	}
+
+	qte := Quote{
+		Jurisdiction: jur,
+		Lines:        make([]Line, len(nls)),
+	}
+
+	for i, nl := range nls {
+		prd, err := c.queryProduct(ctx, nl)
+		if err != nil {
+			return Quote{}, fmt.Errorf("line[%d]: %w", i+1, err)
+		}
+
+		if prd.HasVariants() {
+			return Quote{}, fmt.Errorf("line[%d]: %w", i+1, product.ErrHasVariants)
+		}
+
+		ln, err := jur.line(prd, nl.Quantity, nl.Discount)
+		if err != nil {
+			return Quote{}, fmt.Errorf("line[%d]: %w", i+1, err)
+		}
+		ln.Number = i + 1
+
+		if qte.Net, err = qte.Net.Add(ln.Net); err != nil {
+			return Quote{}, fmt.Errorf("net: line[%d]: %w", ln.Number, err)
+		}
+		if qte.Tax, err = qte.Tax.Add(ln.Tax); err != nil {
+			return Quote{}, fmt.Errorf("tax: line[%d]: %w", ln.Number, err)
+		}
+		if qte.Gross, err = qte.Gross.Add(ln.Gross); err != nil {
+			return Quote{}, fmt.Errorf("gross: line[%d]: %w", ln.Number, err)
+		}
+
+		qte.Lines[i] = ln
+	}
+
+	return qte, nil
+}
+
+// queryProduct finds the product of a line by its ID or its SKU.
+func (c *Core) queryProduct(ctx context.Context, nl NewLine) (product.Product, error) {
+	if nl.ProductID == uuid.Nil && nl.SKU != "" {
+		prd, err := c.prdCore.QueryBySKU(ctx, nl.SKU)
+		if err != nil {
+			return product.Product{}, fmt.Errorf("querybysku: %w", err)
+		}
+		return prd, nil
+	}
+
+	prd, err := c.prdCore.QueryByID(ctx, nl.ProductID)
+	if err != nil {
+		return product.Product{}, fmt.Errorf("querybyid: %w", err)
+	}
+	return prd, nil
+}
+
+// =============================================================================
+
+// rate returns the rate of the tax class, or the STANDARD rate when the class
+// has none of its own.
+func (j Jurisdiction) rate(class string) (money.Percent, error) {
+	var standard *money.Percent
+	for i, rate := range j.Rates {
+		switch rate.Class {
+		case class:
+			return rate.Percent, nil
+		case product.DefaultTaxClass:
+			standard = &j.Rates[i].Percent
+		}
+	}
+
+	if standard == nil {
+		return money.Percent{}, fmt.Errorf("jurisdiction[%s] class[%s]: %w", j.Code, class, ErrNoRate)
+	}
+
+	return *standard, nil
+}
+
+// line works out the tax due on a quantity of the product once the discount
+// is taken off.
+func (j Jurisdiction) line(prd product.Product, quantity int, discount money.Money) (Line, error) {
+	rate, err := j.rate(prd.TaxClass)
+	if err != nil {
+		return Line{}, err
+	}
+
+	amount, err := prd.Cost.Mul(int64(quantity))
+	if err != nil {
+		return Line{}, err
+	}
+
+	if amount, err = amount.Sub(discount); err != nil {
+		return Line{}, err
+	}
+
+	ln := Line{
+		ProductID: prd.ID,
+		SKU:       prd.SKU,
+		Quantity:  quantity,
+		TaxClass:  prd.TaxClass,
+		Rate:      rate,
+	}
+
+	switch {
+	case j.Inclusive:
+		ln.Tax = amount.PercentIncluded(rate, j.Rounding)
+		ln.Gross = amount
+		ln.Net, err = amount.Sub(ln.Tax)
+	default:
+		if ln.Tax, err = amount.Percent(rate, j.Rounding); err != nil {
+			return Line{}, err
+		}
+		ln.Net = amount
+		ln.Gross, err = amount.Add(ln.Tax)
+	}
+	if err != nil {
+		return Line{}, err
+	}
+
+	return ln, nil
+}
+
+// =============================================================================
+
+// ParseCSV reads jurisdictions from CSV records of the form
+// jurisdiction,name,inclusive,rounding,class,rate such as
+// "US-NY,New York,false,HALF_UP,STANDARD,8.875". A jurisdiction has one
+// record per tax class, which have to agree on its name, inclusive and
+// rounding. A first record of column names is skipped. Errors name the line
+// of the record that couldn't be read.
+func ParseCSV(r io.Reader) ([]NewJurisdiction, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = 6
+	cr.TrimLeadingSpace = true
+
+	var njs []NewJurisdiction
+	idx := make(map[string]int)
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				return nil, fmt.Errorf("%w: %s", ErrInvalidCSV, err)
+			}
+			return nil, fmt.Errorf("read: %w", err)
+		}
+
+		line, _ := cr.FieldPos(0)
+		if line == 1 && strings.EqualFold(record[0], "jurisdiction") {
+			continue
+		}
+
+		nj, err := parseRecord(record)
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %s", ErrInvalidCSV, line, err)
+		}
+
+		i, exists := idx[nj.Code]
+		if !exists {
+			idx[nj.Code] = len(njs)
+			njs = append(njs, nj)
+			continue
+		}
+
+		prev := &njs[i]
+		if prev.Name != nj.Name || prev.Inclusive != nj.Inclusive || !prev.Rounding.Equal(nj.Rounding) {
+			return nil, fmt.Errorf("%w: line %d: %s: %s", ErrInvalidCSV, line, nj.Code, ErrInconsistent)
+		}
+		for _, rate := range prev.Rates {
+			if rate.Class == nj.Rates[0].Class {
+				return nil, fmt.Errorf("%w: line %d: %s: %s: %s", ErrInvalidCSV, line, nj.Code, rate.Class, ErrDuplicate)
+			}
+		}
+		prev.Rates = append(prev.Rates, nj.Rates[0])
+	}
+
+	if len(njs) == 0 {
+		return nil, fmt.Errorf("%w: no jurisdictions", ErrInvalidCSV)
+	}
+
+	return njs, nil
+}
+
+func parseRecord(record []string) (NewJurisdiction, error) {
+	code := normalize(record[0])
+	if code == "" {
+		return NewJurisdiction{}, errors.New("jurisdiction: is required")
+	}
+
+	name := strings.TrimSpace(record[1])
+	if name == "" {
+		return NewJurisdiction{}, errors.New("name: is required")
+	}
+
+	inclusive, err := strconv.ParseBool(strings.TrimSpace(record[2]))
+	if err != nil {
+		return NewJurisdiction{}, fmt.Errorf("inclusive: %w", err)
+	}
+
+	rounding, err := money.ParseRounding(normalize(record[3]))
+	if err != nil {
+		return NewJurisdiction{}, fmt.Errorf("rounding: %w", err)
+	}
+
+	class := normalize(record[4])
+	if class == "" {
+		return NewJurisdiction{}, errors.New("class: is required")
+	}
+
+	percent, err := money.ParsePercent(record[5])
+	if err != nil {
+		return NewJurisdiction{}, fmt.Errorf("rate: %w", err)
+	}
+
+	nj := NewJurisdiction{
+		Code:      code,
+		Name:      name,
+		Inclusive: inclusive,
+		Rounding:  rounding,
+		Rates:     []Rate{{Class: class, Percent: percent}},
+	}
+
+	return nj, nil
+}
+
+// normalize stores codes and tax classes in upper case so they match however
+// they were typed.
+func normalize(s string) string {
+	return strings.ToUpper(strings.TrimSpace(s))
+}
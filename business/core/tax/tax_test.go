@@ -0,0 +1,110 @@
+package tax_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aleury/service/business/core/tax"
+	"github.com/aleury/service/business/data/money"
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_ParseCSV(t *testing.T) {
+	t.Run("valid", parseValid)
+	t.Run("invalid", parseInvalid)
+}
+
+func parseValid(t *testing.T) {
+	tests := []struct {
+		name string
+		csv  string
+		want []tax.NewJurisdiction
+	}{
+		{
+			name: "header",
+			csv: "jurisdiction,name,inclusive,rounding,class,rate\n" +
+				"US-NY,New York,false,HALF_UP,STANDARD,8.875\n",
+			want: []tax.NewJurisdiction{
+				{
+					Code:      "US-NY",
+					Name:      "New York",
+					Inclusive: false,
+					Rounding:  money.RoundHalfUp,
+					Rates:     []tax.Rate{{Class: "STANDARD", Percent: money.MustParsePercent("8.875")}},
+				},
+			},
+		},
+		{
+			name: "classes",
+			csv: "gb,United Kingdom,true,half_even,standard,20\n" +
+				"US-NY,New York,false,HALF_UP,STANDARD,8.875\n" +
+				"GB, United Kingdom, true, HALF_EVEN, reduced, 5\n",
+			want: []tax.NewJurisdiction{
+				{
+					Code:      "GB",
+					Name:      "United Kingdom",
+					Inclusive: true,
+					Rounding:  money.RoundHalfEven,
+					Rates: []tax.Rate{
+						{Class: "STANDARD", Percent: money.MustParsePercent("20")},
+						{Class: "REDUCED", Percent: money.MustParsePercent("5")},
+					},
+				},
+				{
+					Code:      "US-NY",
+					Name:      "New York",
+					Inclusive: false,
+					Rounding:  money.RoundHalfUp,
+					Rates:     []tax.Rate{{Class: "STANDARD", Percent: money.MustParsePercent("8.875")}},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := tax.ParseCSV(strings.NewReader(tt.csv))
+		if err != nil {
+			t.Errorf("%s: Should be able to parse the csv: %s.", tt.name, err)
+			continue
+		}
+
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Errorf("%s: Should get back the jurisdictions. diff:\n%s", tt.name, diff)
+		}
+	}
+}
+
+func parseInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		csv  string
+		want error
+	}{
+		{"empty", "", tax.ErrInvalidCSV},
+		{"header only", "jurisdiction,name,inclusive,rounding,class,rate\n", tax.ErrInvalidCSV},
+		{"fields", "US-NY,New York,false,HALF_UP,STANDARD\n", tax.ErrInvalidCSV},
+		{"code", ",New York,false,HALF_UP,STANDARD,8.875\n", tax.ErrInvalidCSV},
+		{"name", "US-NY,,false,HALF_UP,STANDARD,8.875\n", tax.ErrInvalidCSV},
+		{"inclusive", "US-NY,New York,maybe,HALF_UP,STANDARD,8.875\n", tax.ErrInvalidCSV},
+		{"rounding", "US-NY,New York,false,SIDEWAYS,STANDARD,8.875\n", tax.ErrInvalidCSV},
+		{"class", "US-NY,New York,false,HALF_UP,,8.875\n", tax.ErrInvalidCSV},
+		{"rate", "US-NY,New York,false,HALF_UP,STANDARD,lots\n", tax.ErrInvalidCSV},
+		{
+			"inconsistent",
+			"US-NY,New York,false,HALF_UP,STANDARD,8.875\nUS-NY,New York,true,HALF_UP,REDUCED,4\n",
+			tax.ErrInvalidCSV,
+		},
+		{
+			"duplicate",
+			"US-NY,New York,false,HALF_UP,STANDARD,8.875\nus-ny,New York,false,HALF_UP,standard,4\n",
+			tax.ErrInvalidCSV,
+		},
+	}
+
+	for _, tt := range tests {
+		if _, err := tax.ParseCSV(strings.NewReader(tt.csv)); !errors.Is(err, tt.want) {
+			t.Errorf("%s: Should NOT be able to parse the csv: got %v want %v.", tt.name, err, tt.want)
+		}
+	}
+}
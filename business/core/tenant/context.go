@@ -0,0 +1,43 @@
+package tenant
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// ctxKey represents the type of value for the context key.
+type ctxKey int
+
+// tenantKey is used to store/retrieve the tenant id from a context.Context.
+const tenantKey ctxKey = 1
+
+// SetTenantID stores the id of the tenant a request is made for in the
+// context.
+func SetTenantID(ctx context.Context, tenantID uuid.UUID) context.Context {
+	return context.WithValue(ctx, tenantKey, tenantID)
+}
+
+// GetTenantID returns the id of the tenant a request is made for. A zero
+// value id is returned for work done by the system on behalf of all tenants.
+func GetTenantID(ctx context.Context) uuid.UUID {
+	v, ok := ctx.Value(tenantKey).(uuid.UUID)
+	if !ok {
+		return uuid.UUID{}
+	}
+	return v
+}
+
+// Scope returns the condition that restricts a query to the tenant carried by
+// the context and adds the tenant id to the query data. The column names the
+// tenant id in the query, qualified when it joins tables. Work done by the
+// system on behalf of all tenants is not restricted.
+func Scope(ctx context.Context, data map[string]any, column string) string {
+	tenantID := GetTenantID(ctx)
+	if tenantID == uuid.Nil {
+		return ""
+	}
+
+	data["tenant_id"] = tenantID
+	return " AND " + column + " = :tenant_id"
+}
@@ -0,0 +1,21 @@
+package tenant
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Tenant represents an organization whose users and products are kept apart
+// from every other organization.
+type Tenant struct {
+	ID          uuid.UUID
+	Name        string
+	DateCreated time.Time
+	DateUpdated time.Time
+}
+
+// NewTenant contains information needed to create a new tenant.
+type NewTenant struct {
+	Name string
+}
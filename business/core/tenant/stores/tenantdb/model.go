@@ -0,0 +1,35 @@
+package tenantdb
+
+import (
+	"time"
+
+	"github.com/aleury/service/business/core/tenant"
+	"github.com/google/uuid"
+)
+
+// dbTenant represents the structure we need for moving data
+// between the app and the database.
+type dbTenant struct {
+	ID          uuid.UUID `db:"tenant_id"`
+	Name        string    `db:"name"`
+	DateCreated time.Time `db:"date_created"`
+	DateUpdated time.Time `db:"date_updated"`
+}
+
+func toDBTenant(tnt tenant.Tenant) dbTenant {
+	return dbTenant{
+		ID:          tnt.ID,
+		Name:        tnt.Name,
+		DateCreated: tnt.DateCreated.UTC(),
+		DateUpdated: tnt.DateUpdated.UTC(),
+	}
+}
+
+func toCoreTenant(dbTnt dbTenant) tenant.Tenant {
+	return tenant.Tenant{
+		ID:          dbTnt.ID,
+		Name:        dbTnt.Name,
+		DateCreated: dbTnt.DateCreated.In(time.Local),
+		DateUpdated: dbTnt.DateUpdated.In(time.Local),
+	}
+}
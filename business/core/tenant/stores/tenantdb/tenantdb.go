@@ -0,0 +1,73 @@
+// Package tenantdb contains tenant related CRUD functionality.
+package tenantdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aleury/service/business/core/tenant"
+	database "github.com/aleury/service/business/sys/database/pgx"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// Store manages the set of APIs for tenant database access.
+type Store struct {
+	log *zap.SugaredLogger
+	db  *sqlx.DB
+}
+
+// NewStore constructs the api for data access.
+func NewStore(log *zap.SugaredLogger, db *sqlx.DB) *Store {
+	return &Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// Create inserts a new tenant into the database.
+func (s *Store) Create(ctx context.Context, tnt tenant.Tenant) error {
+	const q = `
+	INSERT INTO tenants
+		(tenant_id, name, date_created, date_updated)
+	VALUES
+		(:tenant_id, :name, :date_created, :date_updated)`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, toDBTenant(tnt)); err != nil {
+		if errors.Is(err, database.ErrDBDuplicatedEntry) {
+			return fmt.Errorf("namedexeccontext: %w", tenant.ErrUniqueName)
+		}
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// QueryByID gets the specified tenant from the database.
+func (s *Store) QueryByID(ctx context.Context, tenantID uuid.UUID) (tenant.Tenant, error) {
+	data := struct {
+		ID string `db:"tenant_id"`
+	}{
+		ID: tenantID.String(),
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		tenants
+	WHERE
+		tenant_id = :tenant_id`
+
+	var dbTnt dbTenant
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbTnt); err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return tenant.Tenant{}, fmt.Errorf("namedquerystruct: %w", tenant.ErrNotFound)
+		}
+		return tenant.Tenant{}, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return toCoreTenant(dbTnt), nil
+}
@@ -0,0 +1,65 @@
+// Package tenant provides the core business API for tenants, the
+// organizations that users and products belong to.
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Set of error variables for CRUD operations.
+var (
+	ErrNotFound   = errors.New("tenant not found")
+	ErrUniqueName = errors.New("name is not unique")
+)
+
+// Storer interface declares the behavior this package needs to persist and
+// retrieve data.
+type Storer interface {
+	Create(ctx context.Context, tnt Tenant) error
+	QueryByID(ctx context.Context, tenantID uuid.UUID) (Tenant, error)
+}
+
+// Core manages the set of APIs for tenant access.
+type Core struct {
+	storer Storer
+}
+
+// NewCore constructs a core for tenant api access.
+func NewCore(storer Storer) *Core {
+	return &Core{
+		storer: storer,
+	}
+}
+
+// Create inserts a new tenant into the database.
+func (c *Core) Create(ctx context.Context, nt NewTenant) (Tenant, error) {
+	now := time.Now()
+
+	tnt := Tenant{
+		ID:          uuid.New(),
+		Name:        nt.Name,
+		DateCreated: now,
+		DateUpdated: now,
+	}
+
+	if err := c.storer.Create(ctx, tnt); err != nil {
+		return Tenant{}, fmt.Errorf("create: %w", err)
+	}
+
+	return tnt, nil
+}
+
+// QueryByID gets the specified tenant from the database.
+func (c *Core) QueryByID(ctx context.Context, tenantID uuid.UUID) (Tenant, error) {
+	tnt, err := c.storer.QueryByID(ctx, tenantID)
+	if err != nil {
+		return Tenant{}, fmt.Errorf("query: tenantID[%s]: %w", tenantID, err)
+	}
+
+	return tnt, nil
+}
@@ -0,0 +1,194 @@
+package tenant_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"testing"
+	"time"
+
+	"github.com/aleury/service/business/core/tenant"
+	"github.com/aleury/service/business/core/user"
+	"github.com/aleury/service/business/data/dbtest"
+	database "github.com/aleury/service/business/sys/database/pgx"
+	"github.com/aleury/service/foundation/docker"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+)
+
+var c *docker.Container
+
+func TestMain(m *testing.M) {
+	var err error
+	c, err = dbtest.StartDB()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer dbtest.StopDB(c)
+
+	m.Run()
+}
+
+func Test_Tenant(t *testing.T) {
+	t.Run("crud", crud)
+	t.Run("isolation", isolation)
+	t.Run("rls", rls)
+}
+
+// =============================================================================
+
+func crud(t *testing.T) {
+	test := dbtest.NewTest(t, c)
+	defer func() {
+		if r := recover(); r != nil {
+			t.Log(r)
+			t.Error(string(debug.Stack()))
+		}
+		test.Teardown()
+	}()
+
+	api := test.CoreAPIs
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// -------------------------------------------------------------------------
+
+	tnt, err := api.Tenant.Create(ctx, tenant.NewTenant{Name: "Other Tenant"})
+	if err != nil {
+		t.Fatalf("Should be able to create tenant: %s.", err)
+	}
+
+	saved, err := api.Tenant.QueryByID(ctx, tnt.ID)
+	if err != nil {
+		t.Fatalf("Should be able to retrieve tenant by ID: %s.", err)
+	}
+
+	if tnt.DateCreated.UnixMilli() != saved.DateCreated.UnixMilli() {
+		t.Logf("got:  %v", saved.DateCreated)
+		t.Logf("want: %v", tnt.DateCreated)
+		t.Error("Should get back the same date created")
+	}
+
+	tnt.DateCreated = time.Time{}
+	tnt.DateUpdated = time.Time{}
+	saved.DateCreated = time.Time{}
+	saved.DateUpdated = time.Time{}
+
+	if diff := cmp.Diff(tnt, saved); diff != "" {
+		t.Fatalf("Should get back the same tenant. diff:\n%s", diff)
+	}
+
+	if _, err := api.Tenant.Create(ctx, tenant.NewTenant{Name: "Other Tenant"}); !errors.Is(err, tenant.ErrUniqueName) {
+		t.Errorf("Should NOT be able to create a tenant with the same name: %v.", err)
+	}
+
+	if _, err := api.Tenant.QueryByID(ctx, uuid.New()); !errors.Is(err, tenant.ErrNotFound) {
+		t.Errorf("Should NOT be able to retrieve a tenant that doesn't exist: %v.", err)
+	}
+}
+
+func isolation(t *testing.T) {
+	test := dbtest.NewTest(t, c)
+	defer func() {
+		if r := recover(); r != nil {
+			t.Log(r)
+			t.Error(string(debug.Stack()))
+		}
+		test.Teardown()
+	}()
+
+	api := test.CoreAPIs
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// -------------------------------------------------------------------------
+
+	usrs, err := api.User.Query(ctx, user.QueryFilter{}, user.DefaultOrderBy, 1, 1)
+	if err != nil || len(usrs) != 1 {
+		t.Fatalf("Should be able to retrieve a seeded user: %v.", err)
+	}
+
+	tnt, err := api.Tenant.Create(ctx, tenant.NewTenant{Name: "Other Tenant"})
+	if err != nil {
+		t.Fatalf("Should be able to create tenant: %s.", err)
+	}
+
+	ownCtx := tenant.SetTenantID(ctx, usrs[0].TenantID)
+
+	if _, err := api.User.QueryByID(ownCtx, usrs[0].ID); err != nil {
+		t.Fatalf("Should be able to retrieve a user of the tenant: %s.", err)
+	}
+
+	otherCtx := tenant.SetTenantID(ctx, tnt.ID)
+
+	if _, err := api.User.QueryByID(otherCtx, usrs[0].ID); !errors.Is(err, user.ErrNotFound) {
+		t.Fatalf("Should NOT be able to retrieve a user from another tenant: %s.", err)
+	}
+
+	count, err := api.User.Count(otherCtx, user.QueryFilter{})
+	if err != nil {
+		t.Fatalf("Should be able to count users in another tenant: %s.", err)
+	}
+
+	if count != 0 {
+		t.Fatalf("Should see no users in a new tenant: got %d", count)
+	}
+}
+
+func rls(t *testing.T) {
+	test := dbtest.NewTest(t, c)
+	defer func() {
+		if r := recover(); r != nil {
+			t.Log(r)
+			t.Error(string(debug.Stack()))
+		}
+		test.Teardown()
+	}()
+
+	api := test.CoreAPIs
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// -------------------------------------------------------------------------
+
+	usrs, err := api.User.Query(ctx, user.QueryFilter{}, user.DefaultOrderBy, 1, 1)
+	if err != nil || len(usrs) != 1 {
+		t.Fatalf("Should be able to retrieve a seeded user: %v.", err)
+	}
+
+	tnt, err := api.Tenant.Create(ctx, tenant.NewTenant{Name: "Other Tenant"})
+	if err != nil {
+		t.Fatalf("Should be able to create tenant: %s.", err)
+	}
+
+	// The query deliberately has no tenant filter so only the row level
+	// security policies decide which users are visible.
+	const q = `SELECT user_id FROM users`
+
+	var rows []struct {
+		ID string `db:"user_id"`
+	}
+
+	sessionCtx := database.SetSession(ctx, database.Session{TenantID: tnt.ID.String()})
+	if err := database.QuerySlice(sessionCtx, test.Log, test.DB, q, &rows); err != nil {
+		t.Fatalf("Should be able to query users with a session: %s.", err)
+	}
+
+	if len(rows) != 0 {
+		t.Fatalf("Should see no users from another tenant with a session: got %d", len(rows))
+	}
+
+	sessionCtx = database.SetSession(ctx, database.Session{TenantID: usrs[0].TenantID.String()})
+	if err := database.QuerySlice(sessionCtx, test.Log, test.DB, q, &rows); err != nil {
+		t.Fatalf("Should be able to query users with a session: %s.", err)
+	}
+
+	if len(rows) == 0 {
+		t.Fatal("Should see the users of the tenant with a session.")
+	}
+}
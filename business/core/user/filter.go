@@ -14,8 +14,13 @@ type QueryFilter struct {
 	ID               *uuid.UUID    `validate:"omitempty"`
 	Name             *string       `validate:"omitempty,min=3"`
 	Email            *mail.Address `validate:"omitempty"`
+	DepartmentID     *uuid.UUID    `validate:"omitempty"`
+	Role             *Role         `validate:"omitempty"`
+	Enabled          *bool         `validate:"omitempty"`
 	StartCreatedDate *time.Time    `validate:"omitempty"`
 	EndCreatedDate   *time.Time    `validate:"omitempty"`
+	Search           *string       `validate:"omitempty,min=1"`
+	IncludeDeleted   *bool         `validate:"omitempty"`
 }
 
 // Validate checks the data in the model is considered clean.
@@ -41,6 +46,21 @@ func (qf *QueryFilter) WithEmail(email mail.Address) {
 	qf.Email = &email
 }
 
+// WithDepartmentID sets the DepartmentID field of the QueryFilter value.
+func (qf *QueryFilter) WithDepartmentID(departmentID uuid.UUID) {
+	qf.DepartmentID = &departmentID
+}
+
+// WithRole sets the Role field of the QueryFilter value.
+func (qf *QueryFilter) WithRole(role Role) {
+	qf.Role = &role
+}
+
+// WithEnabled sets the Enabled field of the QueryFilter value.
+func (qf *QueryFilter) WithEnabled(enabled bool) {
+	qf.Enabled = &enabled
+}
+
 // WithStartCreatedDate sets the StartCreatedDate field of the QueryFilter value.
 func (qf *QueryFilter) WithStartCreatedDate(startDate time.Time) {
 	d := startDate.UTC()
@@ -52,3 +72,15 @@ func (qf *QueryFilter) WithEndCreatedDate(endDate time.Time) {
 	d := endDate.UTC()
 	qf.EndCreatedDate = &d
 }
+
+// WithSearch sets the Search field of the QueryFilter value. Users are
+// matched on the words of their name and email, or on a name close to the
+// text, and are returned most relevant first.
+func (qf *QueryFilter) WithSearch(text string) {
+	qf.Search = &text
+}
+
+// WithIncludeDeleted sets the IncludeDeleted field of the QueryFilter value.
+func (qf *QueryFilter) WithIncludeDeleted(includeDeleted bool) {
+	qf.IncludeDeleted = &includeDeleted
+}
@@ -10,22 +10,36 @@ import (
 // User represents information about an individual user.
 type User struct {
 	ID           uuid.UUID
+	TenantID     uuid.UUID
 	Name         string
 	Email        mail.Address
 	Roles        []Role
 	PasswordHash []byte
-	Department   string
+	DepartmentID uuid.UUID
 	Enabled      bool
+	Version      int
 	DateCreated  time.Time
 	DateUpdated  time.Time
+	DateDeleted  time.Time
+	DateErased   time.Time
+}
+
+// Version represents the state of a user from the moment it was recorded
+// until the next version of the user was recorded. Password hashes are not
+// kept in the history.
+type Version struct {
+	Number    int
+	ValidFrom time.Time
+	User      User
 }
 
 // NewUser contains information needed to create a new user.
 type NewUser struct {
+	TenantID        uuid.UUID
 	Name            string
 	Email           mail.Address
 	Roles           []Role
-	Department      string
+	DepartmentID    uuid.UUID
 	Password        string
 	PasswordConfirm string
 }
@@ -35,7 +49,7 @@ type UpdateUser struct {
 	Name            *string
 	Email           *mail.Address
 	Roles           []Role
-	Department      *string
+	DepartmentID    *uuid.UUID
 	Password        *string
 	PasswordConfirm *string
 	Enabled         *bool
@@ -3,14 +3,16 @@ package user
 import "errors"
 
 var (
-	RoleAdmin = Role{"ADMIN"}
-	RoleUser  = Role{"USER"}
+	RoleAdmin           = Role{"ADMIN"}
+	RoleDepartmentAdmin = Role{"DEPARTMENT_ADMIN"}
+	RoleUser            = Role{"USER"}
 )
 
 // Set of known roles.
 var roles = map[string]Role{
-	RoleAdmin.name: RoleAdmin,
-	RoleUser.name:  RoleUser,
+	RoleAdmin.name:           RoleAdmin,
+	RoleDepartmentAdmin.name: RoleDepartmentAdmin,
+	RoleUser.name:            RoleUser,
 }
 
 // Role represents a role in the system.
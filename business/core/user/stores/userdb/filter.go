@@ -2,15 +2,30 @@ package userdb
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"strings"
 
+	"github.com/aleury/service/business/core/tenant"
 	"github.com/aleury/service/business/core/user"
+	"github.com/google/uuid"
 )
 
-func (s *Store) applyFilter(filter user.QueryFilter, data map[string]any, buf *bytes.Buffer) {
+// matchUsers matches the users whose words match the search text or whose
+// name is close to it, which catches misspelled queries.
+const matchUsers = `(search @@ websearch_to_tsquery('simple', :search) OR name % :search)`
+
+// rankUsers scores how well a user matches the search text.
+const rankUsers = `ts_rank(search, websearch_to_tsquery('simple', :search)) + similarity(name, :search)`
+
+func (s *Store) applyFilter(ctx context.Context, filter user.QueryFilter, data map[string]any, buf *bytes.Buffer) {
 	var wc []string
 
+	if tenantID := tenant.GetTenantID(ctx); tenantID != uuid.Nil {
+		data["tenant_id"] = tenantID
+		wc = append(wc, "tenant_id = :tenant_id")
+	}
+
 	if filter.ID != nil {
 		data["user_id"] = *filter.ID
 		wc = append(wc, "user_id = :user_id")
@@ -26,6 +41,21 @@ func (s *Store) applyFilter(filter user.QueryFilter, data map[string]any, buf *b
 		wc = append(wc, "email = :email")
 	}
 
+	if filter.DepartmentID != nil {
+		data["department_id"] = *filter.DepartmentID
+		wc = append(wc, "department_id = :department_id")
+	}
+
+	if filter.Role != nil {
+		data["role"] = filter.Role.Name()
+		wc = append(wc, ":role = ANY(roles)")
+	}
+
+	if filter.Enabled != nil {
+		data["enabled"] = *filter.Enabled
+		wc = append(wc, "enabled = :enabled")
+	}
+
 	if filter.StartCreatedDate != nil {
 		data["start_date_created"] = *filter.StartCreatedDate
 		wc = append(wc, "date_created >= :start_date_created")
@@ -36,6 +66,15 @@ func (s *Store) applyFilter(filter user.QueryFilter, data map[string]any, buf *b
 		wc = append(wc, "date_created <= :end_date_created")
 	}
 
+	if filter.Search != nil {
+		data["search"] = *filter.Search
+		wc = append(wc, matchUsers)
+	}
+
+	if filter.IncludeDeleted == nil || !*filter.IncludeDeleted {
+		wc = append(wc, "deleted_at IS NULL")
+	}
+
 	if len(wc) > 0 {
 		buf.WriteString(" WHERE ")
 		buf.WriteString(strings.Join(wc, " AND "))
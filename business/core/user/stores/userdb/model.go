@@ -14,14 +14,22 @@ import (
 // between the app and the database.
 type dbUser struct {
 	ID           uuid.UUID      `db:"user_id"`
+	TenantID     uuid.UUID      `db:"tenant_id"`
 	Name         string         `db:"name"`
 	Email        string         `db:"email"`
 	Roles        dbarray.String `db:"roles"`
 	PasswordHash []byte         `db:"password_hash"`
 	Enabled      bool           `db:"enabled"`
-	Department   sql.NullString `db:"department"`
+	DepartmentID uuid.NullUUID  `db:"department_id"`
+	Version      int            `db:"version"`
 	DateCreated  time.Time      `db:"date_created"`
 	DateUpdated  time.Time      `db:"date_updated"`
+	DateDeleted  sql.NullTime   `db:"deleted_at"`
+	DateErased   sql.NullTime   `db:"erased_at"`
+
+	// Search holds the words the user is searched by. It is generated by the
+	// database and only read along with the rest of the row.
+	Search sql.NullString `db:"search"`
 }
 
 func toDBUser(usr user.User) dbUser {
@@ -32,17 +40,27 @@ func toDBUser(usr user.User) dbUser {
 
 	return dbUser{
 		ID:           usr.ID,
+		TenantID:     usr.TenantID,
 		Name:         usr.Name,
 		Email:        usr.Email.Address,
 		Roles:        dbarray.String(roles),
 		PasswordHash: usr.PasswordHash,
 		Enabled:      usr.Enabled,
-		Department: sql.NullString{
-			String: usr.Department,
-			Valid:  usr.Department != "",
+		DepartmentID: uuid.NullUUID{
+			UUID:  usr.DepartmentID,
+			Valid: usr.DepartmentID != uuid.Nil,
 		},
+		Version:     usr.Version,
 		DateCreated: usr.DateCreated.UTC(),
 		DateUpdated: usr.DateUpdated.UTC(),
+		DateDeleted: sql.NullTime{
+			Time:  usr.DateDeleted.UTC(),
+			Valid: !usr.DateDeleted.IsZero(),
+		},
+		DateErased: sql.NullTime{
+			Time:  usr.DateErased.UTC(),
+			Valid: !usr.DateErased.IsZero(),
+		},
 	}
 }
 
@@ -58,16 +76,26 @@ func toCoreUser(dbUsr dbUser) user.User {
 
 	usr := user.User{
 		ID:           dbUsr.ID,
+		TenantID:     dbUsr.TenantID,
 		Name:         dbUsr.Name,
 		Email:        email,
 		Roles:        roles,
 		PasswordHash: dbUsr.PasswordHash,
-		Department:   dbUsr.Department.String,
+		DepartmentID: dbUsr.DepartmentID.UUID,
 		Enabled:      dbUsr.Enabled,
+		Version:      dbUsr.Version,
 		DateCreated:  dbUsr.DateCreated.In(time.Local),
 		DateUpdated:  dbUsr.DateUpdated.In(time.Local),
 	}
 
+	if dbUsr.DateDeleted.Valid {
+		usr.DateDeleted = dbUsr.DateDeleted.Time.In(time.Local)
+	}
+
+	if dbUsr.DateErased.Valid {
+		usr.DateErased = dbUsr.DateErased.Time.In(time.Local)
+	}
+
 	return usr
 }
 
@@ -78,3 +106,32 @@ func toCoreUserSlice(dbUsers []dbUser) []user.User {
 	}
 	return users
 }
+
+// =============================================================================
+
+// dbVersion represents a recorded version of a user in the history table.
+type dbVersion struct {
+	Version   int       `db:"version"`
+	ValidFrom time.Time `db:"valid_from"`
+	dbUser
+}
+
+func toCoreVersionSlice(dbVersions []dbVersion) []user.Version {
+	versions := make([]user.Version, len(dbVersions))
+	for i, dbVer := range dbVersions {
+		versions[i] = user.Version{
+			Number:    dbVer.Version,
+			ValidFrom: dbVer.ValidFrom.In(time.Local),
+			User:      toCoreUser(dbVer.dbUser),
+		}
+	}
+	return versions
+}
+
+// =============================================================================
+
+// dbSnippet represents the highlighted name and email of a user.
+type dbSnippet struct {
+	ID      uuid.UUID `db:"user_id"`
+	Snippet string    `db:"snippet"`
+}
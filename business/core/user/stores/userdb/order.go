@@ -22,3 +22,13 @@ func orderByClause(orderBy order.By) (string, error) {
 	}
 	return fmt.Sprintf(" ORDER BY %s %s", by, orderBy.Direction), nil
 }
+
+// rankedOrderByClause orders search results by relevance first and then by
+// the requested field.
+func rankedOrderByClause(rank string, orderBy order.By) (string, error) {
+	by, exists := orderByFields[orderBy.Field]
+	if !exists {
+		return "", fmt.Errorf("field %q does not exist", orderBy.Field)
+	}
+	return fmt.Sprintf(" ORDER BY %s DESC, %s %s", rank, by, orderBy.Direction), nil
+}
@@ -4,16 +4,17 @@ package userdb
 import (
 	"bytes"
 	"context"
-	"database/sql"
-	"database/sql/driver"
 	"errors"
 	"fmt"
 	"net/mail"
+	"time"
 
+	"github.com/aleury/service/business/core/tenant"
 	"github.com/aleury/service/business/core/user"
 	"github.com/aleury/service/business/data/order"
 	database "github.com/aleury/service/business/sys/database/pgx"
 	"github.com/aleury/service/business/sys/database/pgx/dbarray"
+	"github.com/aleury/service/business/sys/database/pgx/dbheadline"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 	"go.uber.org/zap"
@@ -21,9 +22,8 @@ import (
 
 // Store manages the set of APIs for user database access.
 type Store struct {
-	log    *zap.SugaredLogger
-	db     sqlx.ExtContext
-	inTran bool
+	log *zap.SugaredLogger
+	db  *sqlx.DB
 }
 
 // NewStore constructs the api for data access.
@@ -34,43 +34,37 @@ func NewStore(log *zap.SugaredLogger, db *sqlx.DB) *Store {
 	}
 }
 
-// WithinTran runs passed function and does commit/rollback at the end.
-func (s *Store) WithinTran(ctx context.Context, fn func(s user.Store) error) error {
-	if s.inTran {
-		return fn(s)
-	}
-
-	f := func(tx *sqlx.Tx) error {
-		s := &Store{
-			log:    s.log,
-			db:     tx,
-			inTran: true,
-		}
-		return fn(s)
-	}
-
-	return database.WithinTran(ctx, s.log, s.db.(*sqlx.DB), f)
+// WithinTran runs passed function and does commit/rollback at the end. Every
+// store call made with the context handed to the function joins the
+// transaction.
+func (s *Store) WithinTran(ctx context.Context, fn func(ctx context.Context) error) error {
+	return database.WithinTran(ctx, s.log, s.db, fn)
 }
 
 // Create inserts a new user into the database.
 func (s *Store) Create(ctx context.Context, usr user.User) error {
 	const q = `
 	INSERT INTO users
-		(user_id, name, email, roles, password_hash, department, enabled, date_created, date_updated)
+		(user_id, tenant_id, name, email, roles, password_hash, department_id, enabled, version, date_created, date_updated)
 	VALUES
-		(:user_id, :name, :email, :roles, :password_hash, :department, :enabled, :date_created, :date_updated)`
+		(:user_id, :tenant_id, :name, :email, :roles, :password_hash, :department_id, :enabled, :version, :date_created, :date_updated)`
 
 	if err := database.NamedExecContext(ctx, s.log, s.db, q, toDBUser(usr)); err != nil {
 		if errors.Is(err, database.ErrDBDuplicatedEntry) {
 			return fmt.Errorf("namedexeccontext: %w", user.ErrUniqueEmail)
 		}
+		if errors.Is(err, database.ErrDBForeignKey) {
+			return fmt.Errorf("namedexeccontext: %w", user.ErrDepartmentNotFound)
+		}
 		return fmt.Errorf("namedexeccontext: %w", err)
 	}
 
 	return nil
 }
 
-// Update replaces a user document in the database.
+// Update replaces a user document in the database. The user carries the
+// version it is updated to, which has to follow the stored one. ErrConflict
+// is returned when it doesn't.
 func (s *Store) Update(ctx context.Context, usr user.User) error {
 	const q = `
 	UPDATE users
@@ -79,40 +73,143 @@ func (s *Store) Update(ctx context.Context, usr user.User) error {
 		"email" = :email,
 		"roles" = :roles,
 		"password_hash" = :password_hash,
-		"department" = :department,
+		"department_id" = :department_id,
 		"enabled" = :enabled,
+		"version" = :version,
 		"date_updated" = :date_updated
 	WHERE
-		"user_id" = :user_id`
+		"user_id" = :user_id AND "version" = :version - 1
+	RETURNING
+		user_id`
 
-	if err := database.NamedExecContext(ctx, s.log, s.db, q, toDBUser(usr)); err != nil {
+	var result struct {
+		ID uuid.UUID `db:"user_id"`
+	}
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, toDBUser(usr), &result); err != nil {
 		if errors.Is(err, database.ErrDBDuplicatedEntry) {
 			return user.ErrUniqueEmail
 		}
-		return fmt.Errorf("namedexeccontext: %w", err)
+		if errors.Is(err, database.ErrDBForeignKey) {
+			return user.ErrDepartmentNotFound
+		}
+		if errors.Is(err, database.ErrDBNotFound) {
+			return fmt.Errorf("namedquerystruct: %w", user.ErrConflict)
+		}
+		return fmt.Errorf("namedquerystruct: %w", err)
 	}
 
 	return nil
 }
 
-// Delete removes a user from teh database.
+// Delete marks a user as deleted in the database.
 func (s *Store) Delete(ctx context.Context, usr user.User) error {
+	const q = `
+	UPDATE
+		users
+	SET
+		"deleted_at" = :deleted_at
+	WHERE
+		user_id = :user_id AND deleted_at IS NULL`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, toDBUser(usr)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Restore clears the deleted mark from a user and returns the restored user.
+func (s *Store) Restore(ctx context.Context, userID uuid.UUID, now time.Time) (user.User, error) {
+	data := map[string]any{
+		"user_id":      userID,
+		"date_updated": now.UTC(),
+	}
+
+	const q = `
+	UPDATE
+		users
+	SET
+		"deleted_at" = NULL,
+		"date_updated" = :date_updated
+	WHERE
+		user_id = :user_id AND deleted_at IS NOT NULL`
+
+	buf := bytes.NewBufferString(q)
+	buf.WriteString(tenant.Scope(ctx, data, "tenant_id"))
+	buf.WriteString(" RETURNING *")
+
+	var dbUsr dbUser
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, buf.String(), data, &dbUsr); err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return user.User{}, fmt.Errorf("namedquerystruct: %w", user.ErrNotFound)
+		}
+		return user.User{}, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return toCoreUser(dbUsr), nil
+}
+
+// Purge permanently removes users that were deleted before the specified time.
+// Users that still own products or have placed orders are kept.
+func (s *Store) Purge(ctx context.Context, before time.Time) error {
 	data := struct {
-		UserID string `db:"user_id"`
+		Before time.Time `db:"before"`
 	}{
-		UserID: usr.ID.String(),
+		Before: before.UTC(),
 	}
 
 	const q = `
 	DELETE FROM
+		users AS u
+	WHERE
+		u.deleted_at < :before AND
+		NOT EXISTS (SELECT 1 FROM products AS p WHERE p.user_id = u.user_id) AND
+		NOT EXISTS (SELECT 1 FROM orders AS o WHERE o.user_id = u.user_id)`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Erase replaces the personal data of a user with the anonymized values
+// provided. The recorded history of the user is anonymized as well.
+func (s *Store) Erase(ctx context.Context, usr user.User) error {
+	const q = `
+	UPDATE
 		users
+	SET
+		"name" = :name,
+		"email" = :email,
+		"password_hash" = :password_hash,
+		"department_id" = :department_id,
+		"enabled" = :enabled,
+		"date_updated" = :date_updated,
+		"erased_at" = :erased_at
 	WHERE
 		user_id = :user_id`
 
-	if err := database.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+	dbUsr := toDBUser(usr)
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, q, dbUsr); err != nil {
 		return fmt.Errorf("namedexeccontext: %w", err)
 	}
 
+	const qh = `
+	UPDATE
+		users_history
+	SET
+		"name" = :name,
+		"email" = :email,
+		"department_id" = :department_id
+	WHERE
+		user_id = :user_id`
+
+	if err := database.NamedExecContext(ctx, s.log, s.db, qh, dbUsr); err != nil {
+		return fmt.Errorf("namedexeccontext: history: %w", err)
+	}
+
 	return nil
 }
 
@@ -130,9 +227,12 @@ func (s *Store) Query(ctx context.Context, filter user.QueryFilter, orderBy orde
 		users`
 
 	buf := bytes.NewBufferString(q)
-	s.applyFilter(filter, data, buf)
+	s.applyFilter(ctx, filter, data, buf)
 
 	orderByClause, err := orderByClause(orderBy)
+	if filter.Search != nil {
+		orderByClause, err = rankedOrderByClause(rankUsers, orderBy)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -159,7 +259,7 @@ func (s *Store) Count(ctx context.Context, filter user.QueryFilter) (int, error)
 		users`
 
 	buf := bytes.NewBufferString(q)
-	s.applyFilter(filter, data, buf)
+	s.applyFilter(ctx, filter, data, buf)
 
 	var result struct {
 		Count int `db:"count"`
@@ -173,10 +273,8 @@ func (s *Store) Count(ctx context.Context, filter user.QueryFilter) (int, error)
 
 // QueryByID gets the specified user from the database.
 func (s *Store) QueryByID(ctx context.Context, userID uuid.UUID) (user.User, error) {
-	data := struct {
-		ID string `db:"user_id"`
-	}{
-		ID: userID.String(),
+	data := map[string]any{
+		"user_id": userID,
 	}
 
 	const q = `
@@ -185,10 +283,10 @@ func (s *Store) QueryByID(ctx context.Context, userID uuid.UUID) (user.User, err
 	FROM
 		users
 	WHERE
-		user_id = :user_id`
+		user_id = :user_id AND deleted_at IS NULL`
 
 	var dbUsr dbUser
-	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbUsr); err != nil {
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q+tenant.Scope(ctx, data, "tenant_id"), data, &dbUsr); err != nil {
 		if errors.Is(err, database.ErrDBNotFound) {
 			return user.User{}, fmt.Errorf("namedquerystruct: %w", user.ErrNotFound)
 		}
@@ -198,6 +296,46 @@ func (s *Store) QueryByID(ctx context.Context, userID uuid.UUID) (user.User, err
 	return toCoreUser(dbUsr), nil
 }
 
+// Highlight returns the names and emails of the specified users with the
+// words matching the search text marked.
+func (s *Store) Highlight(ctx context.Context, userIDs []uuid.UUID, text string) (map[uuid.UUID]string, error) {
+	if len(userIDs) == 0 {
+		return map[uuid.UUID]string{}, nil
+	}
+
+	ids := make([]string, len(userIDs))
+	for i, id := range userIDs {
+		ids[i] = id.String()
+	}
+
+	data := map[string]any{
+		"user_ids":         dbarray.Array(ids),
+		"search":           text,
+		"headline_options": dbheadline.Options,
+	}
+
+	const q = `
+	SELECT
+		user_id,
+		ts_headline('simple', name || ' ' || email, websearch_to_tsquery('simple', :search), :headline_options) AS snippet
+	FROM
+		users
+	WHERE
+		user_id = ANY(:user_ids)`
+
+	var dbSnps []dbSnippet
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q+tenant.Scope(ctx, data, "tenant_id"), data, &dbSnps); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	snippets := make(map[uuid.UUID]string, len(dbSnps))
+	for _, dbSnp := range dbSnps {
+		snippets[dbSnp.ID] = dbheadline.Mark(dbSnp.Snippet)
+	}
+
+	return snippets, nil
+}
+
 // QueryByIDs gets the specified users from the database.
 func (s *Store) QueryByIDs(ctx context.Context, userIDs []uuid.UUID) ([]user.User, error) {
 	ids := make([]string, len(userIDs))
@@ -205,13 +343,8 @@ func (s *Store) QueryByIDs(ctx context.Context, userIDs []uuid.UUID) ([]user.Use
 		ids[i] = id.String()
 	}
 
-	data := struct {
-		UserIDs interface {
-			driver.Valuer
-			sql.Scanner
-		} `db:"user_ids"`
-	}{
-		UserIDs: dbarray.Array(ids),
+	data := map[string]any{
+		"user_ids": dbarray.Array(ids),
 	}
 
 	const q = `
@@ -220,10 +353,10 @@ func (s *Store) QueryByIDs(ctx context.Context, userIDs []uuid.UUID) ([]user.Use
 	FROM
 		users
 	WHERE
-		:user_id = ANY(user_ids)`
+		user_id = ANY(:user_ids) AND deleted_at IS NULL`
 
 	var dbUsrs []dbUser
-	if err := database.NamedQuerySlice(ctx, s.log, s.db, q, data, &dbUsrs); err != nil {
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, q+tenant.Scope(ctx, data, "tenant_id"), data, &dbUsrs); err != nil {
 		if errors.Is(err, database.ErrDBNotFound) {
 			return nil, user.ErrNotFound
 		}
@@ -235,10 +368,8 @@ func (s *Store) QueryByIDs(ctx context.Context, userIDs []uuid.UUID) ([]user.Use
 
 // QueryByEmail gets the specified user from the database by email.
 func (s *Store) QueryByEmail(ctx context.Context, email mail.Address) (user.User, error) {
-	data := struct {
-		Email string `db:"email"`
-	}{
-		Email: email.Address,
+	data := map[string]any{
+		"email": email.Address,
 	}
 
 	const q = `
@@ -247,10 +378,10 @@ func (s *Store) QueryByEmail(ctx context.Context, email mail.Address) (user.User
 	FROM
 		users
 	WHERE
-		email = :email`
+		email = :email AND deleted_at IS NULL`
 
 	var dbUsr dbUser
-	if err := database.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbUsr); err != nil {
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q+tenant.Scope(ctx, data, "tenant_id"), data, &dbUsr); err != nil {
 		if errors.Is(err, database.ErrDBNotFound) {
 			return user.User{}, fmt.Errorf("namedquerystruct: %w", user.ErrNotFound)
 		}
@@ -259,3 +390,90 @@ func (s *Store) QueryByEmail(ctx context.Context, email mail.Address) (user.User
 
 	return toCoreUser(dbUsr), nil
 }
+
+// QueryByIDAsOf reconstructs the specified user from the latest version
+// recorded at or before the specified time.
+func (s *Store) QueryByIDAsOf(ctx context.Context, userID uuid.UUID, asOf time.Time) (user.User, error) {
+	data := map[string]any{
+		"user_id": userID,
+		"as_of":   asOf.UTC(),
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		users_history
+	WHERE
+		user_id = :user_id AND valid_from <= :as_of`
+
+	buf := bytes.NewBufferString(q)
+	buf.WriteString(tenant.Scope(ctx, data, "tenant_id"))
+	buf.WriteString(" ORDER BY version DESC LIMIT 1")
+
+	var dbVer dbVersion
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, buf.String(), data, &dbVer); err != nil {
+		if errors.Is(err, database.ErrDBNotFound) {
+			return user.User{}, fmt.Errorf("namedquerystruct: %w", user.ErrNotFound)
+		}
+		return user.User{}, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	if dbVer.DateDeleted.Valid {
+		return user.User{}, user.ErrNotFound
+	}
+
+	return toCoreUser(dbVer.dbUser), nil
+}
+
+// QueryHistory retrieves the recorded versions of a user, newest first.
+func (s *Store) QueryHistory(ctx context.Context, userID uuid.UUID, pageNumber int, rowsPerPage int) ([]user.Version, error) {
+	data := map[string]any{
+		"user_id":       userID,
+		"offset":        (pageNumber - 1) * rowsPerPage,
+		"rows_per_page": rowsPerPage,
+	}
+
+	const q = `
+	SELECT
+		*
+	FROM
+		users_history
+	WHERE
+		user_id = :user_id`
+
+	buf := bytes.NewBufferString(q)
+	buf.WriteString(tenant.Scope(ctx, data, "tenant_id"))
+	buf.WriteString(" ORDER BY version DESC OFFSET :offset LIMIT :rows_per_page")
+
+	var dbVers []dbVersion
+	if err := database.NamedQuerySlice(ctx, s.log, s.db, buf.String(), data, &dbVers); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toCoreVersionSlice(dbVers), nil
+}
+
+// CountHistory returns the number of recorded versions of a user.
+func (s *Store) CountHistory(ctx context.Context, userID uuid.UUID) (int, error) {
+	data := map[string]any{
+		"user_id": userID,
+	}
+
+	const q = `
+	SELECT
+		COUNT(*)
+	FROM
+		users_history
+	WHERE
+		user_id = :user_id`
+
+	var result struct {
+		Count int `db:"count"`
+	}
+	if err := database.NamedQueryStruct(ctx, s.log, s.db, q+tenant.Scope(ctx, data, "tenant_id"), data, &result); err != nil {
+		return 0, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return result.Count, nil
+}
@@ -10,6 +10,7 @@ import (
 	"net/mail"
 	"time"
 
+	"github.com/aleury/service/business/core/audit"
 	"github.com/aleury/service/business/data/order"
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
@@ -19,30 +20,44 @@ import (
 var (
 	ErrNotFound              = errors.New("user not found")
 	ErrUniqueEmail           = errors.New("email is not unique")
+	ErrDepartmentNotFound    = errors.New("department not found")
 	ErrAuthenticationFailure = errors.New("authentication failed")
+	ErrConflict              = errors.New("user was changed by another request")
 )
 
 // Store inteface declares the behavior this package needs to persist and
 // retrieve data.
 type Store interface {
+	WithinTran(ctx context.Context, fn func(ctx context.Context) error) error
 	Create(ctx context.Context, usr User) error
 	Update(ctx context.Context, usr User) error
 	Delete(ctx context.Context, usr User) error
+	Restore(ctx context.Context, userID uuid.UUID, now time.Time) (User, error)
+	Purge(ctx context.Context, before time.Time) error
+	Erase(ctx context.Context, usr User) error
 	Query(ctx context.Context, filter QueryFilter, orderBy order.By, pageNumber int, rowsPerPage int) ([]User, error)
 	Count(ctx context.Context, filter QueryFilter) (int, error)
 	QueryByID(ctx context.Context, userID uuid.UUID) (User, error)
 	QueryByIDs(ctx context.Context, userIDs []uuid.UUID) ([]User, error)
+	Highlight(ctx context.Context, userIDs []uuid.UUID, text string) (map[uuid.UUID]string, error)
 	QueryByEmail(ctx context.Context, email mail.Address) (User, error)
+	QueryByIDAsOf(ctx context.Context, userID uuid.UUID, asOf time.Time) (User, error)
+	QueryHistory(ctx context.Context, userID uuid.UUID, pageNumber int, rowsPerPage int) ([]Version, error)
+	CountHistory(ctx context.Context, userID uuid.UUID) (int, error)
 }
 
 // Core manages the set of APIs for user access.
 type Core struct {
-	store Store
+	store     Store
+	auditCore *audit.Core
 }
 
 // NewCore constructs a core for user api access.
-func NewCore(store Store) *Core {
-	return &Core{store: store}
+func NewCore(store Store, auditCore *audit.Core) *Core {
+	return &Core{
+		store:     store,
+		auditCore: auditCore,
+	}
 }
 
 // Create inserts a new user into the database.
@@ -56,25 +71,37 @@ func (c *Core) Create(ctx context.Context, nu NewUser) (User, error) {
 
 	usr := User{
 		ID:           uuid.New(),
+		TenantID:     nu.TenantID,
 		Name:         nu.Name,
 		Email:        nu.Email,
 		Roles:        nu.Roles,
 		PasswordHash: hash,
-		Department:   nu.Department,
+		DepartmentID: nu.DepartmentID,
 		Enabled:      true,
+		Version:      1,
 		DateCreated:  now,
 		DateUpdated:  now,
 	}
 
-	if err := c.store.Create(ctx, usr); err != nil {
-		return User{}, fmt.Errorf("create: %w", err)
+	tran := func(ctx context.Context) error {
+		if err := c.store.Create(ctx, usr); err != nil {
+			return fmt.Errorf("create: %w", err)
+		}
+		return c.record(ctx, audit.ActionCreate, User{}, usr)
+	}
+
+	if err := c.store.WithinTran(ctx, tran); err != nil {
+		return User{}, err
 	}
 
 	return usr, nil
 }
 
-// Update replaces a user document in the database.
+// Update replaces a user document in the database. It fails with ErrConflict
+// when the user was updated by someone else since it was read.
 func (c *Core) Update(ctx context.Context, usr User, uu UpdateUser) (User, error) {
+	before := usr
+
 	if uu.Name != nil {
 		usr.Name = *uu.Name
 	}
@@ -91,29 +118,139 @@ func (c *Core) Update(ctx context.Context, usr User, uu UpdateUser) (User, error
 		}
 		usr.PasswordHash = hash
 	}
-	if uu.Department != nil {
-		usr.Department = *uu.Department
+	if uu.DepartmentID != nil {
+		usr.DepartmentID = *uu.DepartmentID
 	}
 	if uu.Enabled != nil {
 		usr.Enabled = *uu.Enabled
 	}
+	usr.Version++
 	usr.DateUpdated = time.Now()
 
-	if err := c.store.Update(ctx, usr); err != nil {
-		return User{}, fmt.Errorf("update: %w", err)
+	tran := func(ctx context.Context) error {
+		if err := c.store.Update(ctx, usr); err != nil {
+			return fmt.Errorf("update: %w", err)
+		}
+		return c.record(ctx, audit.ActionUpdate, before, usr)
+	}
+
+	if err := c.store.WithinTran(ctx, tran); err != nil {
+		return User{}, err
 	}
 
 	return usr, nil
 }
 
-// Delete removes a user from the database.
+// Delete marks a user as deleted in the database. The user is no longer
+// returned by queries but can be restored until it is purged.
 func (c *Core) Delete(ctx context.Context, usr User) error {
-	if err := c.store.Delete(ctx, usr); err != nil {
-		return fmt.Errorf("delete: %w", err)
+	before := usr
+	usr.DateDeleted = time.Now()
+
+	tran := func(ctx context.Context) error {
+		if err := c.store.Delete(ctx, usr); err != nil {
+			return fmt.Errorf("delete: %w", err)
+		}
+		return c.record(ctx, audit.ActionDelete, before, usr)
+	}
+
+	return c.store.WithinTran(ctx, tran)
+}
+
+// Restore brings back a user that was previously deleted.
+func (c *Core) Restore(ctx context.Context, userID uuid.UUID) (User, error) {
+	var usr User
+
+	tran := func(ctx context.Context) error {
+		var filter QueryFilter
+		filter.WithUserID(userID)
+		filter.WithIncludeDeleted(true)
+
+		users, err := c.store.Query(ctx, filter, DefaultOrderBy, 1, 1)
+		if err != nil {
+			return fmt.Errorf("query: userID[%s]: %w", userID, err)
+		}
+		if len(users) == 0 {
+			return fmt.Errorf("query: userID[%s]: %w", userID, ErrNotFound)
+		}
+
+		usr, err = c.store.Restore(ctx, userID, time.Now())
+		if err != nil {
+			return fmt.Errorf("restore: userID[%s]: %w", userID, err)
+		}
+
+		return c.record(ctx, audit.ActionRestore, users[0], usr)
+	}
+
+	if err := c.store.WithinTran(ctx, tran); err != nil {
+		return User{}, err
+	}
+
+	return usr, nil
+}
+
+// Purge permanently removes users that were deleted before the specified time.
+// Users that still own products, live or deleted, are kept until the products
+// are gone. Users that placed orders are never purged so the sales history
+// stays intact, they can be erased instead.
+func (c *Core) Purge(ctx context.Context, before time.Time) error {
+	if err := c.store.Purge(ctx, before); err != nil {
+		return fmt.Errorf("purge: before[%s]: %w", before, err)
 	}
 	return nil
 }
 
+// Erase anonymizes the personal data of a user, including its recorded history
+// and audit trail, while keeping the user record and everything referencing it
+// in place. Erasing a user that has already been erased changes nothing, but
+// like every erasure it is recorded.
+func (c *Core) Erase(ctx context.Context, usr User) (User, error) {
+	if !usr.DateErased.IsZero() {
+		if err := c.recordErase(ctx, usr); err != nil {
+			return User{}, err
+		}
+		return usr, nil
+	}
+
+	// The password is replaced with a hash of a random value nobody knows.
+	hash, err := bcrypt.GenerateFromPassword([]byte(uuid.NewString()), bcrypt.DefaultCost)
+	if err != nil {
+		return User{}, fmt.Errorf("generating password hash: %w", err)
+	}
+
+	now := time.Now()
+
+	usr.Name = "Erased User"
+	usr.Email = mail.Address{Address: fmt.Sprintf("erased-%s@erased.invalid", usr.ID)}
+	usr.DepartmentID = uuid.Nil
+	usr.PasswordHash = hash
+	usr.Enabled = false
+	usr.DateUpdated = now
+	usr.DateErased = now
+
+	tran := func(ctx context.Context) error {
+		if err := c.store.Erase(ctx, usr); err != nil {
+			return fmt.Errorf("erase: %w", err)
+		}
+
+		if err := c.auditCore.Redact(ctx, audit.EntityUser, usr.ID, personalFields); err != nil {
+			return fmt.Errorf("audit: %w", err)
+		}
+
+		if err := c.recordErase(ctx, usr); err != nil {
+			return err
+		}
+
+		return nil
+	}
+
+	if err := c.store.WithinTran(ctx, tran); err != nil {
+		return User{}, err
+	}
+
+	return usr, nil
+}
+
 // Query retrieves a list of existing users from the database.
 func (c *Core) Query(ctx context.Context, filter QueryFilter, orderBy order.By, pageNumber int, rowsPerPage int) ([]User, error) {
 	users, err := c.store.Query(ctx, filter, orderBy, pageNumber, rowsPerPage)
@@ -137,6 +274,17 @@ func (c *Core) QueryByID(ctx context.Context, userID uuid.UUID) (User, error) {
 	return user, nil
 }
 
+// Highlight returns the names and emails of the specified users with the
+// words matching the search text marked, keyed by user ID. The snippets are
+// escaped HTML with the matching words wrapped in <mark> elements.
+func (c *Core) Highlight(ctx context.Context, userIDs []uuid.UUID, text string) (map[uuid.UUID]string, error) {
+	snippets, err := c.store.Highlight(ctx, userIDs, text)
+	if err != nil {
+		return nil, fmt.Errorf("highlight: %w", err)
+	}
+	return snippets, nil
+}
+
 // QueryByIDs gets the specified users from the database.
 func (c *Core) QueryByIDs(ctx context.Context, userIDs []uuid.UUID) ([]User, error) {
 	users, err := c.store.QueryByIDs(ctx, userIDs)
@@ -155,6 +303,101 @@ func (c *Core) QueryByEmail(ctx context.Context, email mail.Address) (User, erro
 	return user, nil
 }
 
+// QueryByIDAsOf reconstructs the specified user as it was at the specified
+// time. It returns ErrNotFound if the user did not exist or was deleted at
+// that time.
+func (c *Core) QueryByIDAsOf(ctx context.Context, userID uuid.UUID, asOf time.Time) (User, error) {
+	user, err := c.store.QueryByIDAsOf(ctx, userID, asOf)
+	if err != nil {
+		return User{}, fmt.Errorf("query: userID[%s] asOf[%s]: %w", userID, asOf, err)
+	}
+	return user, nil
+}
+
+// QueryHistory retrieves the recorded versions of a user, newest first.
+func (c *Core) QueryHistory(ctx context.Context, userID uuid.UUID, pageNumber int, rowsPerPage int) ([]Version, error) {
+	versions, err := c.store.QueryHistory(ctx, userID, pageNumber, rowsPerPage)
+	if err != nil {
+		return nil, fmt.Errorf("query: userID[%s]: %w", userID, err)
+	}
+	return versions, nil
+}
+
+// CountHistory returns the number of recorded versions of a user.
+func (c *Core) CountHistory(ctx context.Context, userID uuid.UUID) (int, error) {
+	count, err := c.store.CountHistory(ctx, userID)
+	if err != nil {
+		return 0, fmt.Errorf("count: userID[%s]: %w", userID, err)
+	}
+	return count, nil
+}
+
+// =============================================================================
+
+// record writes an audit entry for the change between the before and after
+// versions of the user. A zero value before represents a newly created user.
+func (c *Core) record(ctx context.Context, action string, before User, after User) error {
+	ne := audit.NewEntry{
+		Action:     action,
+		EntityType: audit.EntityUser,
+		EntityID:   after.ID,
+		After:      auditFields(after),
+	}
+	if before.ID != uuid.Nil {
+		ne.Before = auditFields(before)
+	}
+
+	if _, err := c.auditCore.Record(ctx, ne); err != nil {
+		return fmt.Errorf("audit: %w", err)
+	}
+
+	return nil
+}
+
+// recordErase writes an audit entry for an erasure of the user, including one
+// that found the user already erased.
+func (c *Core) recordErase(ctx context.Context, usr User) error {
+	ne := audit.NewEntry{
+		Action:     audit.ActionErase,
+		EntityType: audit.EntityUser,
+		EntityID:   usr.ID,
+		After:      map[string]any{"dateErased": usr.DateErased.UTC()},
+	}
+	if _, err := c.auditCore.Record(ctx, ne); err != nil {
+		return fmt.Errorf("audit: %w", err)
+	}
+
+	return nil
+}
+
+// personalFields is the set of audited fields holding personal data. Entries
+// recorded before departments were introduced hold the department by name.
+var personalFields = []string{"name", "email", "department", "departmentId"}
+
+// auditFields returns the set of user fields that are tracked by the audit
+// log. The password hash is deliberately left out.
+func auditFields(usr User) map[string]any {
+	roles := make([]string, len(usr.Roles))
+	for i, role := range usr.Roles {
+		roles[i] = role.Name()
+	}
+
+	fields := map[string]any{
+		"name":    usr.Name,
+		"email":   usr.Email.Address,
+		"roles":   roles,
+		"enabled": usr.Enabled,
+	}
+	if usr.DepartmentID != uuid.Nil {
+		fields["departmentId"] = usr.DepartmentID.String()
+	}
+	if !usr.DateDeleted.IsZero() {
+		fields["dateDeleted"] = usr.DateDeleted.UTC()
+	}
+
+	return fields
+}
+
 // =============================================================================
 
 // Authenticate finds a user by their email and verifies their password. On
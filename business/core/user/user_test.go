@@ -6,14 +6,21 @@ import (
 	"fmt"
 	"net/mail"
 	"runtime/debug"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/aleury/service/business/core/audit"
+	"github.com/aleury/service/business/core/product"
+	"github.com/aleury/service/business/core/salesorder"
+	"github.com/aleury/service/business/core/tenant"
 	"github.com/aleury/service/business/core/user"
 	"github.com/aleury/service/business/data/dbtest"
+	"github.com/aleury/service/business/data/money"
 	"github.com/aleury/service/business/data/order"
 	"github.com/aleury/service/foundation/docker"
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
 )
 
 var c *docker.Container
@@ -33,6 +40,8 @@ func TestMain(m *testing.M) {
 func Test_User(t *testing.T) {
 	t.Run("crud", crud)
 	t.Run("paging", paging)
+	t.Run("purge", purge)
+	t.Run("search", search)
 }
 
 // =============================================================================
@@ -110,9 +119,8 @@ func crud(t *testing.T) {
 	}
 
 	upd := user.UpdateUser{
-		Name:       dbtest.StringPointer("Jacob Walker"),
-		Email:      email,
-		Department: dbtest.StringPointer("development"),
+		Name:  dbtest.StringPointer("Jacob Walker"),
+		Email: email,
 	}
 	if _, err := api.User.Update(ctx, users[0], upd); err != nil {
 		t.Fatalf("Should be able to update user: %s.", err)
@@ -140,10 +148,42 @@ func crud(t *testing.T) {
 		t.Error("Should be able to see updates to Email")
 	}
 
-	if saved.Department != *upd.Department {
-		t.Logf("got:  %v", saved.Department)
-		t.Logf("want: %v", *upd.Department)
-		t.Error("Should be able to see updates to Department")
+	var auditFilter audit.QueryFilter
+	auditFilter.WithEntityID(saved.ID)
+	auditFilter.WithAction(audit.ActionUpdate)
+
+	n, err := api.Audit.Count(ctx, auditFilter)
+	if err != nil {
+		t.Fatalf("Should be able to count audit entries: %s.", err)
+	}
+
+	if n != 1 {
+		t.Logf("got:  %v", n)
+		t.Logf("want: %v", 1)
+		t.Error("Should have recorded the update in the audit log")
+	}
+
+	asOf := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+	past, err := api.User.QueryByIDAsOf(ctx, saved.ID, asOf)
+	if err != nil {
+		t.Fatalf("Should be able to retrieve user as of %v: %s.", asOf, err)
+	}
+
+	if past.Name != users[0].Name {
+		t.Logf("got:  %v", past.Name)
+		t.Logf("want: %v", users[0].Name)
+		t.Error("Should see the name the user had before the update")
+	}
+
+	versions, err := api.User.CountHistory(ctx, saved.ID)
+	if err != nil {
+		t.Fatalf("Should be able to count user history: %s.", err)
+	}
+
+	if versions < 2 {
+		t.Logf("got:  %v", versions)
+		t.Logf("want: >= %v", 2)
+		t.Error("Should have recorded a version for the update")
 	}
 
 	if err := api.User.Delete(ctx, saved); err != nil {
@@ -154,6 +194,38 @@ func crud(t *testing.T) {
 	if !errors.Is(err, user.ErrNotFound) {
 		t.Fatalf("Should NOT be able to retrieve user: %s.", err)
 	}
+
+	// -------------------------------------------------------------------------
+
+	var filter user.QueryFilter
+	filter.WithUserID(saved.ID)
+	filter.WithIncludeDeleted(true)
+
+	deleted, err := api.User.Query(ctx, filter, user.DefaultOrderBy, 1, 1)
+	if err != nil {
+		t.Fatalf("Should be able to query deleted users: %s.", err)
+	}
+
+	if len(deleted) != 1 || deleted[0].DateDeleted.IsZero() {
+		t.Fatalf("Should be able to see the deleted user when including deleted: %+v", deleted)
+	}
+
+	restored, err := api.User.Restore(ctx, saved.ID)
+	if err != nil {
+		t.Fatalf("Should be able to restore user: %s.", err)
+	}
+
+	if !restored.DateDeleted.IsZero() {
+		t.Errorf("Should have cleared DateDeleted: %v", restored.DateDeleted)
+	}
+
+	if _, err := api.User.QueryByID(ctx, saved.ID); err != nil {
+		t.Fatalf("Should be able to retrieve restored user: %s.", err)
+	}
+
+	if _, err := api.User.Restore(ctx, saved.ID); !errors.Is(err, user.ErrNotFound) {
+		t.Fatalf("Should NOT be able to restore a user that isn't deleted: %s.", err)
+	}
 }
 
 func paging(t *testing.T) {
@@ -225,3 +297,176 @@ func paging(t *testing.T) {
 		t.Error("Should have different users")
 	}
 }
+
+func purge(t *testing.T) {
+	test := dbtest.NewTest(t, c)
+	defer func() {
+		if r := recover(); r != nil {
+			t.Log(r)
+			t.Error(string(debug.Stack()))
+		}
+		test.Teardown()
+	}()
+
+	api := test.CoreAPIs
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// -------------------------------------------------------------------------
+
+	tnt, err := api.Tenant.Create(ctx, tenant.NewTenant{Name: "Purge Tenant"})
+	if err != nil {
+		t.Fatalf("Should be able to create tenant: %s.", err)
+	}
+
+	newUser := func(name string, email string) user.User {
+		addr, err := mail.ParseAddress(email)
+		if err != nil {
+			t.Fatalf("Should be able to parse email address: %s.", err)
+		}
+
+		usr, err := api.User.Create(ctx, user.NewUser{
+			TenantID: tnt.ID,
+			Name:     name,
+			Email:    *addr,
+			Roles:    []user.Role{user.RoleUser},
+			Password: "gophers",
+		})
+		if err != nil {
+			t.Fatalf("Should be able to create user: %s.", err)
+		}
+
+		return usr
+	}
+
+	owner := newUser("Owner Gopher", "owner@example.com")
+	buyer := newUser("Buyer Gopher", "buyer@example.com")
+	idle := newUser("Idle Gopher", "idle@example.com")
+
+	prd, err := api.Product.Create(ctx, product.NewProduct{
+		Name:     "Comic Books",
+		Cost:     money.MustParse("10.00", money.USD),
+		Quantity: 5,
+		UserID:   owner.ID,
+	})
+	if err != nil {
+		t.Fatalf("Should be able to create product: %s.", err)
+	}
+
+	ord, err := api.SalesOrder.Create(ctx, salesorder.NewOrder{
+		UserID:   buyer.ID,
+		TenantID: tnt.ID,
+		Lines:    []salesorder.NewLine{{ProductID: prd.ID, Quantity: 1}},
+	})
+	if err != nil {
+		t.Fatalf("Should be able to create order: %s.", err)
+	}
+
+	for _, usr := range []user.User{owner, buyer, idle} {
+		if err := api.User.Delete(ctx, usr); err != nil {
+			t.Fatalf("Should be able to delete user: %s.", err)
+		}
+	}
+
+	if err := api.User.Purge(ctx, time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("Should be able to purge users: %s.", err)
+	}
+
+	if _, err := api.User.Restore(ctx, idle.ID); !errors.Is(err, user.ErrNotFound) {
+		t.Errorf("Should have purged the user without products: %v.", err)
+	}
+
+	if _, err := api.User.Restore(ctx, owner.ID); err != nil {
+		t.Errorf("Should have kept the user owning products: %v.", err)
+	}
+
+	if _, err := api.Product.QueryByID(ctx, prd.ID); err != nil {
+		t.Errorf("Should have kept the live product of the user: %v.", err)
+	}
+
+	if _, err := api.User.Restore(ctx, buyer.ID); err != nil {
+		t.Errorf("Should have kept the user with orders: %v.", err)
+	}
+
+	if _, err := api.SalesOrder.QueryByID(ctx, ord.ID); err != nil {
+		t.Errorf("Should have kept the order of the user: %v.", err)
+	}
+}
+
+func search(t *testing.T) {
+	test := dbtest.NewTest(t, c)
+	defer func() {
+		if r := recover(); r != nil {
+			t.Log(r)
+			t.Error(string(debug.Stack()))
+		}
+		test.Teardown()
+	}()
+
+	api := test.CoreAPIs
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// -------------------------------------------------------------------------
+
+	var filter user.QueryFilter
+	filter.WithSearch("admin")
+
+	found, err := api.User.Query(ctx, filter, user.DefaultOrderBy, 1, 10)
+	if err != nil {
+		t.Fatalf("Should be able to search users: %s.", err)
+	}
+
+	if len(found) != 1 || found[0].Name != "Admin Gopher" {
+		t.Fatalf("Should find the user matching the search: %+v", found)
+	}
+
+	snippets, err := api.User.Highlight(ctx, []uuid.UUID{found[0].ID}, "admin")
+	if err != nil {
+		t.Fatalf("Should be able to highlight the search results: %s.", err)
+	}
+
+	if !strings.Contains(snippets[found[0].ID], "<mark>Admin</mark>") {
+		t.Errorf("Should mark the words matching the search: got %q", snippets[found[0].ID])
+	}
+
+	filter.WithSearch("gopher")
+
+	n, err := api.User.Count(ctx, filter)
+	if err != nil {
+		t.Fatalf("Should be able to count the users matching the search: %s.", err)
+	}
+
+	if n != 2 {
+		t.Errorf("Should count every user matching the search: got %d", n)
+	}
+
+	// -------------------------------------------------------------------------
+
+	addr, err := mail.ParseAddress("mallory@example.com")
+	if err != nil {
+		t.Fatalf("Should be able to parse email address: %s.", err)
+	}
+
+	usr, err := api.User.Create(ctx, user.NewUser{
+		TenantID: found[0].TenantID,
+		Name:     "<img src=x onerror=alert(1)> Mallory",
+		Email:    *addr,
+		Roles:    []user.Role{user.RoleUser},
+		Password: "gophers",
+	})
+	if err != nil {
+		t.Fatalf("Should be able to create user: %s.", err)
+	}
+
+	snippets, err = api.User.Highlight(ctx, []uuid.UUID{usr.ID}, "mallory")
+	if err != nil {
+		t.Fatalf("Should be able to highlight the search results: %s.", err)
+	}
+
+	if want := "&lt;img src=x onerror=alert(1)&gt; <mark>Mallory</mark>"; !strings.HasPrefix(snippets[usr.ID], want) {
+		t.Errorf("Should escape the markup of the name: got %q want prefix %q", snippets[usr.ID], want)
+	}
+}
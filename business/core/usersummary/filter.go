@@ -9,8 +9,9 @@ import (
 
 // QueryFilter holds the available fields a query can be filtered on.
 type QueryFilter struct {
-	UserID   *uuid.UUID `validate:"omitempty"`
-	UserName *string    `validate:"omitempty,min=3"`
+	UserID       *uuid.UUID `validate:"omitempty"`
+	UserName     *string    `validate:"omitempty,min=3"`
+	DepartmentID *uuid.UUID `validate:"omitempty"`
 }
 
 func (qf *QueryFilter) Validate() error {
@@ -29,3 +30,8 @@ func (qf *QueryFilter) WithUserID(userID uuid.UUID) {
 func (qf *QueryFilter) WithUserName(userName string) {
 	qf.UserName = &userName
 }
+
+// WithDepartmentID sets the DepartmentID field of the QueryFilter value.
+func (qf *QueryFilter) WithDepartmentID(departmentID uuid.UUID) {
+	qf.DepartmentID = &departmentID
+}
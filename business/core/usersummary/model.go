@@ -1,11 +1,18 @@
 package usersummary
 
-import "github.com/google/uuid"
+import (
+	"github.com/aleury/service/business/core/exchange"
+	"github.com/aleury/service/business/data/money"
+	"github.com/google/uuid"
+)
 
 // Summary repesents information about an individual user and their products.
+// Rate is the exchange rate TotalCost was converted at, if it was.
 type Summary struct {
-	UserID     uuid.UUID
-	UserName   string
-	TotalCount int
-	TotalCost  float64
+	UserID       uuid.UUID
+	UserName     string
+	DepartmentID uuid.UUID
+	TotalCount   int
+	TotalCost    money.Money
+	Rate         exchange.Rate
 }
@@ -0,0 +1,41 @@
+package usersummarydb
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aleury/service/business/core/tenant"
+	"github.com/aleury/service/business/core/usersummary"
+	"github.com/google/uuid"
+)
+
+func (s *Store) applyFilter(ctx context.Context, filter usersummary.QueryFilter, data map[string]any, buf *bytes.Buffer) {
+	var wc []string
+
+	if tenantID := tenant.GetTenantID(ctx); tenantID != uuid.Nil {
+		data["tenant_id"] = tenantID
+		wc = append(wc, "tenant_id = :tenant_id")
+	}
+
+	if filter.UserID != nil {
+		data["user_id"] = *filter.UserID
+		wc = append(wc, "user_id = :user_id")
+	}
+
+	if filter.UserName != nil {
+		data["user_name"] = fmt.Sprintf("%%%s%%", *filter.UserName)
+		wc = append(wc, "user_name ILIKE :user_name")
+	}
+
+	if filter.DepartmentID != nil {
+		data["department_id"] = *filter.DepartmentID
+		wc = append(wc, "department_id = :department_id")
+	}
+
+	if len(wc) > 0 {
+		buf.WriteString(" WHERE ")
+		buf.WriteString(strings.Join(wc, " AND "))
+	}
+}
@@ -0,0 +1,36 @@
+package usersummarydb
+
+import (
+	"github.com/aleury/service/business/core/usersummary"
+	"github.com/aleury/service/business/data/money"
+	"github.com/google/uuid"
+)
+
+// dbSummary represents the structure we need for moving data
+// between the app and the database.
+type dbSummary struct {
+	UserID       uuid.UUID     `db:"user_id"`
+	TenantID     uuid.UUID     `db:"tenant_id"`
+	DepartmentID uuid.NullUUID `db:"department_id"`
+	UserName     string        `db:"user_name"`
+	TotalCount   int           `db:"total_count"`
+	TotalCost    money.Money   `db:"total_cost"`
+}
+
+func toCoreSummary(dbSum dbSummary) usersummary.Summary {
+	return usersummary.Summary{
+		UserID:       dbSum.UserID,
+		UserName:     dbSum.UserName,
+		DepartmentID: dbSum.DepartmentID.UUID,
+		TotalCount:   dbSum.TotalCount,
+		TotalCost:    dbSum.TotalCost,
+	}
+}
+
+func toCoreSummarySlice(dbSummaries []dbSummary) []usersummary.Summary {
+	sums := make([]usersummary.Summary, len(dbSummaries))
+	for i, dbSum := range dbSummaries {
+		sums[i] = toCoreSummary(dbSum)
+	}
+	return sums
+}
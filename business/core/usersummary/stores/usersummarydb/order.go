@@ -0,0 +1,21 @@
+package usersummarydb
+
+import (
+	"fmt"
+
+	"github.com/aleury/service/business/core/usersummary"
+	"github.com/aleury/service/business/data/order"
+)
+
+var orderByFields = map[string]string{
+	usersummary.OrderByUserID:   "user_id",
+	usersummary.OrderByUserName: "user_name",
+}
+
+func orderByClause(orderBy order.By) (string, error) {
+	by, exists := orderByFields[orderBy.Field]
+	if !exists {
+		return "", fmt.Errorf("field %q does not exist", orderBy.Field)
+	}
+	return fmt.Sprintf(" ORDER BY %s %s", by, orderBy.Direction), nil
+}
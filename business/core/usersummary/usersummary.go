@@ -6,6 +6,8 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/aleury/service/business/core/exchange"
+	"github.com/aleury/service/business/data/money"
 	"github.com/aleury/service/business/data/order"
 )
 
@@ -18,13 +20,15 @@ type Storer interface {
 
 // Core manages the set of API's for user summaries.
 type Core struct {
-	storer Storer
+	storer   Storer
+	exchCore *exchange.Core
 }
 
 // NewCore constructs a Core for user summary api access.
-func NewCore(storer Storer) *Core {
+func NewCore(storer Storer, exchCore *exchange.Core) *Core {
 	return &Core{
-		storer: storer,
+		storer:   storer,
+		exchCore: exchCore,
 	}
 }
 
@@ -45,3 +49,35 @@ func (c *Core) Count(ctx context.Context, filter QueryFilter) (int, error) {
 	}
 	return count, nil
 }
+
+// Convert returns the summaries with their total cost converted into the
+// currency at the recorded exchange rate. An exchange.ErrNotFound is returned
+// when there is no such rate.
+func (c *Core) Convert(ctx context.Context, sums []Summary, to money.Currency) ([]Summary, error) {
+	rates := make(map[money.Currency]exchange.Rate)
+
+	converted := make([]Summary, len(sums))
+	for i, sum := range sums {
+		from := sum.TotalCost.Currency()
+
+		rate, exists := rates[from]
+		if !exists {
+			var err error
+			if rate, err = c.exchCore.QueryRate(ctx, from, to); err != nil {
+				return nil, fmt.Errorf("rate: userID[%s]: %w", sum.UserID, err)
+			}
+			rates[from] = rate
+		}
+
+		total, err := sum.TotalCost.Convert(to, rate.Rate)
+		if err != nil {
+			return nil, fmt.Errorf("convert: userID[%s]: %w", sum.UserID, err)
+		}
+
+		sum.TotalCost = total
+		sum.Rate = rate
+		converted[i] = sum
+	}
+
+	return converted, nil
+}
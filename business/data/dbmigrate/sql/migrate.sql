@@ -42,4 +42,1635 @@ FROM
 JOIN
     products AS p ON p.user_id = u.user_id
 GROUP BY
-    u.user_id
\ No newline at end of file
+    u.user_id
+
+-- Version: 1.04
+-- Description: Add soft delete support to users and products.
+ALTER TABLE users ADD COLUMN deleted_at TIMESTAMP NULL;
+ALTER TABLE products ADD COLUMN deleted_at TIMESTAMP NULL;
+
+CREATE OR REPLACE VIEW user_summary AS
+SELECT
+    u.user_id   AS user_id,
+    u.name      AS user_name,
+    COUNT(p.*)  AS total_count,
+    SUM(p.cost) AS total_cost
+FROM
+    users AS u
+JOIN
+    products AS p ON p.user_id = u.user_id
+WHERE
+    u.deleted_at IS NULL AND p.deleted_at IS NULL
+GROUP BY
+    u.user_id;
+
+-- Version: 1.05
+-- Description: Create table audits
+CREATE TABLE audits (
+    audit_id        UUID        NOT NULL,
+    actor_id        UUID        NULL,
+    action          TEXT        NOT NULL,
+    entity_type     TEXT        NOT NULL,
+    entity_id       UUID        NOT NULL,
+    diff            JSONB       NOT NULL,
+    trace_id        TEXT        NOT NULL,
+    date_created    TIMESTAMP   NOT NULL,
+
+    PRIMARY KEY (audit_id)
+);
+
+CREATE INDEX audits_entity_idx ON audits (entity_type, entity_id);
+
+-- Version: 1.06
+-- Description: Create history tables for users and products
+CREATE TABLE users_history (
+    user_id         UUID        NOT NULL,
+    version         INT         NOT NULL,
+    name            TEXT        NOT NULL,
+    email           TEXT        NOT NULL,
+    roles           TEXT[]      NOT NULL,
+    department      TEXT        NULL,
+    enabled         BOOLEAN     NOT NULL,
+    date_created    TIMESTAMP   NOT NULL,
+    date_updated    TIMESTAMP   NOT NULL,
+    deleted_at      TIMESTAMP   NULL,
+    valid_from      TIMESTAMP   NOT NULL,
+
+    PRIMARY KEY (user_id, version)
+);
+
+CREATE TABLE products_history (
+    product_id      UUID            NOT NULL,
+    version         INT             NOT NULL,
+    user_id         UUID            NOT NULL,
+    name            TEXT            NOT NULL,
+    cost            NUMERIC(10, 2)  NOT NULL,
+    quantity        INT             NOT NULL,
+    date_created    TIMESTAMP       NOT NULL,
+    date_updated    TIMESTAMP       NOT NULL,
+    deleted_at      TIMESTAMP       NULL,
+    valid_from      TIMESTAMP       NOT NULL,
+
+    PRIMARY KEY (product_id, version)
+);
+
+-- A new version becomes valid at the latest of the update and delete times
+-- so deletes and restores are captured at the moment they happened.
+CREATE FUNCTION users_history_record() RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO users_history
+        (user_id, version, name, email, roles, department, enabled, date_created, date_updated, deleted_at, valid_from)
+    VALUES
+        (
+            NEW.user_id,
+            COALESCE((SELECT MAX(version) FROM users_history WHERE user_id = NEW.user_id), 0) + 1,
+            NEW.name, NEW.email, NEW.roles, NEW.department, NEW.enabled,
+            NEW.date_created, NEW.date_updated, NEW.deleted_at,
+            GREATEST(NEW.date_updated, COALESCE(NEW.deleted_at, NEW.date_updated))
+        );
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE FUNCTION products_history_record() RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO products_history
+        (product_id, version, user_id, name, cost, quantity, date_created, date_updated, deleted_at, valid_from)
+    VALUES
+        (
+            NEW.product_id,
+            COALESCE((SELECT MAX(version) FROM products_history WHERE product_id = NEW.product_id), 0) + 1,
+            NEW.user_id, NEW.name, NEW.cost, NEW.quantity,
+            NEW.date_created, NEW.date_updated, NEW.deleted_at,
+            GREATEST(NEW.date_updated, COALESCE(NEW.deleted_at, NEW.date_updated))
+        );
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER users_history_trigger
+    AFTER INSERT OR UPDATE ON users
+    FOR EACH ROW EXECUTE FUNCTION users_history_record();
+
+CREATE TRIGGER products_history_trigger
+    AFTER INSERT OR UPDATE ON products
+    FOR EACH ROW EXECUTE FUNCTION products_history_record();
+
+INSERT INTO users_history
+    (user_id, version, name, email, roles, department, enabled, date_created, date_updated, deleted_at, valid_from)
+SELECT
+    user_id, 1, name, email, roles, department, enabled, date_created, date_updated, deleted_at,
+    GREATEST(date_updated, COALESCE(deleted_at, date_updated))
+FROM
+    users;
+
+INSERT INTO products_history
+    (product_id, version, user_id, name, cost, quantity, date_created, date_updated, deleted_at, valid_from)
+SELECT
+    product_id, 1, user_id, name, cost, quantity, date_created, date_updated, deleted_at,
+    GREATEST(date_updated, COALESCE(deleted_at, date_updated))
+FROM
+    products;
+
+-- Version: 1.07
+-- Description: Track users whose personal data has been erased
+ALTER TABLE users ADD COLUMN erased_at TIMESTAMP NULL;
+
+-- Version: 1.08
+-- Description: Add tenants and scope users, products and audits to a tenant
+CREATE TABLE tenants (
+    tenant_id       UUID        NOT NULL,
+    name            TEXT UNIQUE NOT NULL,
+    date_created    TIMESTAMP   NOT NULL,
+    date_updated    TIMESTAMP   NOT NULL,
+
+    PRIMARY KEY (tenant_id)
+);
+
+-- Rows that existed before tenants were introduced belong to a default tenant.
+INSERT INTO tenants (tenant_id, name, date_created, date_updated) VALUES
+    ('d0c2b8a6-7d4e-4a55-9c1e-3f5b0e6a1c01', 'default', NOW(), NOW());
+
+ALTER TABLE users ADD COLUMN tenant_id UUID NOT NULL DEFAULT 'd0c2b8a6-7d4e-4a55-9c1e-3f5b0e6a1c01' REFERENCES tenants(tenant_id);
+ALTER TABLE users ALTER COLUMN tenant_id DROP DEFAULT;
+
+ALTER TABLE products ADD COLUMN tenant_id UUID NOT NULL DEFAULT 'd0c2b8a6-7d4e-4a55-9c1e-3f5b0e6a1c01' REFERENCES tenants(tenant_id);
+ALTER TABLE products ALTER COLUMN tenant_id DROP DEFAULT;
+
+ALTER TABLE users_history ADD COLUMN tenant_id UUID NOT NULL DEFAULT 'd0c2b8a6-7d4e-4a55-9c1e-3f5b0e6a1c01';
+ALTER TABLE users_history ALTER COLUMN tenant_id DROP DEFAULT;
+
+ALTER TABLE products_history ADD COLUMN tenant_id UUID NOT NULL DEFAULT 'd0c2b8a6-7d4e-4a55-9c1e-3f5b0e6a1c01';
+ALTER TABLE products_history ALTER COLUMN tenant_id DROP DEFAULT;
+
+-- Audit entries written by the system outside of a request have no tenant.
+ALTER TABLE audits ADD COLUMN tenant_id UUID NULL;
+UPDATE audits SET tenant_id = 'd0c2b8a6-7d4e-4a55-9c1e-3f5b0e6a1c01';
+
+CREATE INDEX users_tenant_idx ON users (tenant_id);
+CREATE INDEX products_tenant_idx ON products (tenant_id);
+CREATE INDEX audits_tenant_idx ON audits (tenant_id);
+
+CREATE OR REPLACE FUNCTION users_history_record() RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO users_history
+        (user_id, version, tenant_id, name, email, roles, department, enabled, date_created, date_updated, deleted_at, valid_from)
+    VALUES
+        (
+            NEW.user_id,
+            COALESCE((SELECT MAX(version) FROM users_history WHERE user_id = NEW.user_id), 0) + 1,
+            NEW.tenant_id, NEW.name, NEW.email, NEW.roles, NEW.department, NEW.enabled,
+            NEW.date_created, NEW.date_updated, NEW.deleted_at,
+            GREATEST(NEW.date_updated, COALESCE(NEW.deleted_at, NEW.date_updated))
+        );
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION products_history_record() RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO products_history
+        (product_id, version, tenant_id, user_id, name, cost, quantity, date_created, date_updated, deleted_at, valid_from)
+    VALUES
+        (
+            NEW.product_id,
+            COALESCE((SELECT MAX(version) FROM products_history WHERE product_id = NEW.product_id), 0) + 1,
+            NEW.tenant_id, NEW.user_id, NEW.name, NEW.cost, NEW.quantity,
+            NEW.date_created, NEW.date_updated, NEW.deleted_at,
+            GREATEST(NEW.date_updated, COALESCE(NEW.deleted_at, NEW.date_updated))
+        );
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Version: 1.09
+-- Description: Enforce tenant and ownership isolation with row level security
+-- Request scoped transactions switch to this role, which is not exempt from
+-- row level security, after setting the app session variables.
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT FROM pg_roles WHERE rolname = 'sales_api') THEN
+        CREATE ROLE sales_api NOLOGIN;
+    END IF;
+    EXECUTE format('GRANT sales_api TO %I', current_user);
+END;
+$$;
+
+GRANT USAGE ON SCHEMA public TO sales_api;
+GRANT SELECT, INSERT, UPDATE, DELETE ON ALL TABLES IN SCHEMA public TO sales_api;
+ALTER DEFAULT PRIVILEGES IN SCHEMA public GRANT SELECT, INSERT, UPDATE, DELETE ON TABLES TO sales_api;
+
+CREATE FUNCTION app_tenant_id() RETURNS UUID AS $$
+    SELECT NULLIF(current_setting('app.tenant_id', true), '')::UUID
+$$ LANGUAGE SQL STABLE;
+
+CREATE FUNCTION app_user_id() RETURNS UUID AS $$
+    SELECT NULLIF(current_setting('app.user_id', true), '')::UUID
+$$ LANGUAGE SQL STABLE;
+
+CREATE FUNCTION app_is_admin() RETURNS BOOLEAN AS $$
+    SELECT 'ADMIN' = ANY(string_to_array(COALESCE(current_setting('app.roles', true), ''), ','))
+$$ LANGUAGE SQL STABLE;
+
+ALTER TABLE users ENABLE ROW LEVEL SECURITY;
+CREATE POLICY users_select ON users FOR SELECT
+    USING (tenant_id = app_tenant_id());
+CREATE POLICY users_insert ON users FOR INSERT
+    WITH CHECK (tenant_id = app_tenant_id() AND app_is_admin());
+CREATE POLICY users_update ON users FOR UPDATE
+    USING (tenant_id = app_tenant_id() AND (app_is_admin() OR user_id = app_user_id()));
+CREATE POLICY users_delete ON users FOR DELETE
+    USING (tenant_id = app_tenant_id() AND app_is_admin());
+
+ALTER TABLE products ENABLE ROW LEVEL SECURITY;
+CREATE POLICY products_select ON products FOR SELECT
+    USING (tenant_id = app_tenant_id());
+CREATE POLICY products_insert ON products FOR INSERT
+    WITH CHECK (tenant_id = app_tenant_id() AND (app_is_admin() OR user_id = app_user_id()));
+CREATE POLICY products_update ON products FOR UPDATE
+    USING (tenant_id = app_tenant_id() AND (app_is_admin() OR user_id = app_user_id()));
+CREATE POLICY products_delete ON products FOR DELETE
+    USING (tenant_id = app_tenant_id() AND app_is_admin());
+
+-- History rows are written by triggers on behalf of the request.
+ALTER TABLE users_history ENABLE ROW LEVEL SECURITY;
+CREATE POLICY users_history_select ON users_history FOR SELECT
+    USING (tenant_id = app_tenant_id());
+CREATE POLICY users_history_insert ON users_history FOR INSERT
+    WITH CHECK (tenant_id = app_tenant_id());
+CREATE POLICY users_history_update ON users_history FOR UPDATE
+    USING (tenant_id = app_tenant_id() AND app_is_admin());
+
+ALTER TABLE products_history ENABLE ROW LEVEL SECURITY;
+CREATE POLICY products_history_select ON products_history FOR SELECT
+    USING (tenant_id = app_tenant_id());
+CREATE POLICY products_history_insert ON products_history FOR INSERT
+    WITH CHECK (tenant_id = app_tenant_id());
+
+ALTER TABLE audits ENABLE ROW LEVEL SECURITY;
+CREATE POLICY audits_select ON audits FOR SELECT
+    USING (tenant_id = app_tenant_id());
+CREATE POLICY audits_insert ON audits FOR INSERT
+    WITH CHECK (tenant_id = app_tenant_id());
+CREATE POLICY audits_update ON audits FOR UPDATE
+    USING (tenant_id = app_tenant_id() AND app_is_admin());
+
+-- Views bypass row level security unless they run as the caller.
+ALTER VIEW user_summary SET (security_invoker = true);
+
+-- Version: 1.10
+-- Description: Add departments and reference them from users
+CREATE TABLE departments (
+    department_id   UUID        NOT NULL,
+    tenant_id       UUID        NOT NULL,
+    name            TEXT        NOT NULL,
+    date_created    TIMESTAMP   NOT NULL,
+    date_updated    TIMESTAMP   NOT NULL,
+
+    PRIMARY KEY (department_id),
+    UNIQUE (tenant_id, name),
+    UNIQUE (tenant_id, department_id),
+    FOREIGN KEY (tenant_id) REFERENCES tenants(tenant_id)
+);
+
+INSERT INTO departments (department_id, tenant_id, name, date_created, date_updated)
+SELECT
+    gen_random_uuid(), tenant_id, department, NOW(), NOW()
+FROM
+    users
+WHERE
+    department IS NOT NULL AND department <> ''
+GROUP BY
+    tenant_id, department;
+
+-- Referencing the department together with the tenant keeps users from being
+-- placed in a department of another tenant.
+ALTER TABLE users ADD COLUMN department_id UUID NULL;
+ALTER TABLE users ADD FOREIGN KEY (tenant_id, department_id) REFERENCES departments(tenant_id, department_id);
+ALTER TABLE users_history ADD COLUMN department_id UUID NULL;
+
+UPDATE users AS u SET department_id = d.department_id
+FROM departments AS d
+WHERE d.tenant_id = u.tenant_id AND d.name = u.department;
+
+UPDATE users_history AS h SET department_id = d.department_id
+FROM departments AS d
+WHERE d.tenant_id = h.tenant_id AND d.name = h.department;
+
+CREATE OR REPLACE FUNCTION users_history_record() RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO users_history
+        (user_id, version, tenant_id, name, email, roles, department_id, enabled, date_created, date_updated, deleted_at, valid_from)
+    VALUES
+        (
+            NEW.user_id,
+            COALESCE((SELECT MAX(version) FROM users_history WHERE user_id = NEW.user_id), 0) + 1,
+            NEW.tenant_id, NEW.name, NEW.email, NEW.roles, NEW.department_id, NEW.enabled,
+            NEW.date_created, NEW.date_updated, NEW.deleted_at,
+            GREATEST(NEW.date_updated, COALESCE(NEW.deleted_at, NEW.date_updated))
+        );
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE users DROP COLUMN department;
+ALTER TABLE users_history DROP COLUMN department;
+
+CREATE INDEX users_department_idx ON users (department_id);
+
+ALTER TABLE departments ENABLE ROW LEVEL SECURITY;
+CREATE POLICY departments_select ON departments FOR SELECT
+    USING (tenant_id = app_tenant_id());
+CREATE POLICY departments_modify ON departments FOR ALL
+    USING (tenant_id = app_tenant_id() AND app_is_admin());
+
+DROP VIEW user_summary;
+
+CREATE VIEW user_summary WITH (security_invoker = true) AS
+SELECT
+    u.user_id       AS user_id,
+    u.tenant_id     AS tenant_id,
+    u.department_id AS department_id,
+    u.name          AS user_name,
+    COUNT(p.*)      AS total_count,
+    SUM(p.cost)     AS total_cost
+FROM
+    users AS u
+JOIN
+    products AS p ON p.user_id = u.user_id
+WHERE
+    u.deleted_at IS NULL AND p.deleted_at IS NULL
+GROUP BY
+    u.user_id;
+
+-- Version: 1.11
+-- Description: Let department admins manage the users of their department
+CREATE FUNCTION app_has_role(role TEXT) RETURNS BOOLEAN AS $$
+    SELECT role = ANY(string_to_array(COALESCE(current_setting('app.roles', true), ''), ','))
+$$ LANGUAGE SQL STABLE;
+
+-- Runs as the owner so the lookup isn't subject to the users policies that
+-- call it.
+CREATE FUNCTION app_department_id() RETURNS UUID AS $$
+    SELECT department_id FROM users WHERE user_id = app_user_id()
+$$ LANGUAGE SQL STABLE SECURITY DEFINER SET search_path = public;
+
+DROP POLICY users_insert ON users;
+CREATE POLICY users_insert ON users FOR INSERT
+    WITH CHECK (
+        tenant_id = app_tenant_id() AND (
+            app_is_admin() OR
+            (app_has_role('DEPARTMENT_ADMIN') AND department_id = app_department_id())
+        )
+    );
+
+DROP POLICY users_update ON users;
+CREATE POLICY users_update ON users FOR UPDATE
+    USING (
+        tenant_id = app_tenant_id() AND (
+            app_is_admin() OR
+            user_id = app_user_id() OR
+            (app_has_role('DEPARTMENT_ADMIN') AND department_id = app_department_id())
+        )
+    );
+
+DROP POLICY users_delete ON users;
+CREATE POLICY users_delete ON users FOR DELETE
+    USING (
+        tenant_id = app_tenant_id() AND (
+            app_is_admin() OR
+            (app_has_role('DEPARTMENT_ADMIN') AND department_id = app_department_id())
+        )
+    );
+
+-- Version: 1.12
+-- Description: Add bearer credentials for SCIM provisioning clients
+CREATE TABLE scim_tokens (
+    token_id        UUID        NOT NULL,
+    tenant_id       UUID        NOT NULL,
+    name            TEXT        NOT NULL,
+    token_hash      TEXT        NOT NULL,
+    date_created    TIMESTAMP   NOT NULL,
+    date_revoked    TIMESTAMP   NULL,
+
+    PRIMARY KEY (token_id),
+    UNIQUE (token_hash),
+    FOREIGN KEY (tenant_id) REFERENCES tenants(tenant_id)
+);
+
+-- Tokens are looked up before a session exists, so only the owner reads them
+-- unscoped. Request scoped access is limited to admins of the tenant.
+ALTER TABLE scim_tokens ENABLE ROW LEVEL SECURITY;
+CREATE POLICY scim_tokens_admin ON scim_tokens FOR ALL
+    USING (tenant_id = app_tenant_id() AND app_is_admin());
+
+-- Version: 1.13
+-- Description: Link users to the subjects of external identity providers
+CREATE TABLE user_identities (
+    issuer          TEXT        NOT NULL,
+    subject         TEXT        NOT NULL,
+    user_id         UUID        NOT NULL,
+    tenant_id       UUID        NOT NULL,
+    date_created    TIMESTAMP   NOT NULL,
+
+    PRIMARY KEY (issuer, subject),
+    FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE,
+    FOREIGN KEY (tenant_id) REFERENCES tenants(tenant_id)
+);
+
+CREATE INDEX user_identities_user_idx ON user_identities (user_id);
+
+-- Links are created while signing in, before a session exists, so request
+-- scoped access is limited to reading them.
+ALTER TABLE user_identities ENABLE ROW LEVEL SECURITY;
+CREATE POLICY user_identities_select ON user_identities FOR SELECT
+    USING (tenant_id = app_tenant_id());
+
+-- Version: 1.14
+-- Description: Add server side sessions for browsers
+CREATE TABLE sessions (
+    session_id      UUID        NOT NULL,
+    tenant_id       UUID        NOT NULL,
+    user_id         UUID        NOT NULL,
+    token_hash      TEXT        UNIQUE NOT NULL,
+    user_agent      TEXT        NOT NULL,
+    ip_address      TEXT        NOT NULL,
+    date_created    TIMESTAMP   NOT NULL,
+    date_last_seen  TIMESTAMP   NOT NULL,
+    date_expires    TIMESTAMP   NOT NULL,
+    date_revoked    TIMESTAMP   NULL,
+
+    PRIMARY KEY (session_id),
+    FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE,
+    FOREIGN KEY (tenant_id) REFERENCES tenants(tenant_id)
+);
+
+CREATE INDEX sessions_user_idx ON sessions (user_id);
+
+-- Sessions are created and looked up while signing in, before a request
+-- session exists. Afterwards users see and revoke their own sessions.
+ALTER TABLE sessions ENABLE ROW LEVEL SECURITY;
+CREATE POLICY sessions_select ON sessions FOR SELECT
+    USING (tenant_id = app_tenant_id() AND (app_is_admin() OR user_id = app_user_id()));
+CREATE POLICY sessions_update ON sessions FOR UPDATE
+    USING (tenant_id = app_tenant_id() AND (app_is_admin() OR user_id = app_user_id()));
+
+-- Version: 1.15
+-- Description: Add orders with line items and compute product sales from them
+CREATE TABLE orders (
+    order_id        UUID            NOT NULL,
+    tenant_id       UUID            NOT NULL,
+    user_id         UUID            NOT NULL,
+    total_quantity  INT             NOT NULL,
+    total           NUMERIC(12, 2)  NOT NULL,
+    date_created    TIMESTAMP       NOT NULL,
+    date_updated    TIMESTAMP       NOT NULL,
+
+    PRIMARY KEY (order_id),
+    FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE,
+    FOREIGN KEY (tenant_id) REFERENCES tenants(tenant_id)
+);
+
+CREATE INDEX orders_tenant_idx ON orders (tenant_id);
+CREATE INDEX orders_user_idx ON orders (user_id);
+
+-- Lines keep a copy of the product name and cost at the time of the sale, so
+-- they stay meaningful once the product changes or is purged.
+CREATE TABLE order_lines (
+    order_id        UUID            NOT NULL,
+    line_number     INT             NOT NULL,
+    tenant_id       UUID            NOT NULL,
+    product_id      UUID            NULL,
+    name            TEXT            NOT NULL,
+    quantity        INT             NOT NULL,
+    unit_cost       NUMERIC(10, 2)  NOT NULL,
+    total           NUMERIC(12, 2)  NOT NULL,
+
+    PRIMARY KEY (order_id, line_number),
+    FOREIGN KEY (order_id) REFERENCES orders(order_id) ON DELETE CASCADE,
+    FOREIGN KEY (product_id) REFERENCES products(product_id) ON DELETE SET NULL,
+    FOREIGN KEY (tenant_id) REFERENCES tenants(tenant_id)
+);
+
+CREATE INDEX order_lines_product_idx ON order_lines (product_id);
+
+ALTER TABLE orders ENABLE ROW LEVEL SECURITY;
+CREATE POLICY orders_select ON orders FOR SELECT
+    USING (tenant_id = app_tenant_id() AND (app_is_admin() OR user_id = app_user_id()));
+CREATE POLICY orders_insert ON orders FOR INSERT
+    WITH CHECK (tenant_id = app_tenant_id() AND (app_is_admin() OR user_id = app_user_id()));
+
+-- Lines are visible with the order they belong to.
+ALTER TABLE order_lines ENABLE ROW LEVEL SECURITY;
+CREATE POLICY order_lines_select ON order_lines FOR SELECT
+    USING (EXISTS (SELECT 1 FROM orders o WHERE o.order_id = order_lines.order_id));
+CREATE POLICY order_lines_insert ON order_lines FOR INSERT
+    WITH CHECK (EXISTS (SELECT 1 FROM orders o WHERE o.order_id = order_lines.order_id));
+
+-- Totals cover every sale of a product, not just the orders the caller can
+-- see, so the view runs as the owner. It is only ever joined to products,
+-- whose policies decide which rows come back.
+CREATE VIEW product_sales AS
+SELECT
+    product_id      AS product_id,
+    SUM(quantity)   AS sold,
+    SUM(total)      AS revenue
+FROM
+    order_lines
+WHERE
+    product_id IS NOT NULL
+GROUP BY
+    product_id;
+
+-- Buyers can't update products they don't own, so checkout takes stock
+-- through a function that runs as the owner. It only touches products of the
+-- tenant of the request and never takes more than is on hand.
+CREATE FUNCTION app_take_stock(p_product_id UUID, p_quantity INT, p_date_updated TIMESTAMP) RETURNS SETOF products AS $$
+    UPDATE
+        products
+    SET
+        quantity = quantity - p_quantity,
+        date_updated = p_date_updated
+    WHERE
+        product_id = p_product_id AND
+        tenant_id = COALESCE(app_tenant_id(), tenant_id) AND
+        deleted_at IS NULL AND
+        quantity >= p_quantity
+    RETURNING *
+$$ LANGUAGE SQL VOLATILE SECURITY DEFINER SET search_path = public;
+
+-- Version: 1.16
+-- Description: Track the lifecycle of orders
+ALTER TABLE orders ADD COLUMN status TEXT NOT NULL DEFAULT 'PENDING';
+
+CREATE TABLE order_transitions (
+    order_id        UUID        NOT NULL,
+    tenant_id       UUID        NOT NULL,
+    from_status     TEXT        NOT NULL,
+    to_status       TEXT        NOT NULL,
+    actor_id        UUID        NULL,
+    date_created    TIMESTAMP   NOT NULL,
+
+    FOREIGN KEY (order_id) REFERENCES orders(order_id) ON DELETE CASCADE,
+    FOREIGN KEY (tenant_id) REFERENCES tenants(tenant_id)
+);
+
+CREATE INDEX order_transitions_order_idx ON order_transitions (order_id);
+
+-- Which transitions a user may make is decided by the authorization policy,
+-- so the database only keeps orders within the tenant and to their owner.
+CREATE POLICY orders_update ON orders FOR UPDATE
+    USING (tenant_id = app_tenant_id() AND (app_is_admin() OR user_id = app_user_id()));
+
+ALTER TABLE order_transitions ENABLE ROW LEVEL SECURITY;
+CREATE POLICY order_transitions_select ON order_transitions FOR SELECT
+    USING (EXISTS (SELECT 1 FROM orders o WHERE o.order_id = order_transitions.order_id));
+CREATE POLICY order_transitions_insert ON order_transitions FOR INSERT
+    WITH CHECK (EXISTS (SELECT 1 FROM orders o WHERE o.order_id = order_transitions.order_id));
+
+-- Cancelled and refunded orders no longer count as sales.
+CREATE OR REPLACE VIEW product_sales AS
+SELECT
+    l.product_id    AS product_id,
+    SUM(l.quantity) AS sold,
+    SUM(l.total)    AS revenue
+FROM
+    order_lines AS l
+JOIN
+    orders AS o ON o.order_id = l.order_id
+WHERE
+    l.product_id IS NOT NULL AND o.status NOT IN ('CANCELLED', 'REFUNDED')
+GROUP BY
+    l.product_id;
+
+-- Stock of cancelled orders goes back to the products, under the same rules
+-- as taking it.
+CREATE FUNCTION app_return_stock(p_product_id UUID, p_quantity INT, p_date_updated TIMESTAMP) RETURNS SETOF products AS $$
+    UPDATE
+        products
+    SET
+        quantity = quantity + p_quantity,
+        date_updated = p_date_updated
+    WHERE
+        product_id = p_product_id AND
+        tenant_id = COALESCE(app_tenant_id(), tenant_id)
+    RETURNING *
+$$ LANGUAGE SQL VOLATILE SECURITY DEFINER SET search_path = public;
+
+-- Version: 1.17
+-- Description: Add price lists per currency and exchange rates
+CREATE TABLE product_prices (
+    product_id      UUID            NOT NULL,
+    currency        CHAR(3)         NOT NULL,
+    tenant_id       UUID            NOT NULL,
+    amount          NUMERIC(10, 2)  NOT NULL CHECK (amount >= 0),
+    date_updated    TIMESTAMP       NOT NULL,
+
+    PRIMARY KEY (product_id, currency),
+    FOREIGN KEY (product_id) REFERENCES products(product_id) ON DELETE CASCADE,
+    FOREIGN KEY (tenant_id) REFERENCES tenants(tenant_id)
+);
+
+-- A rate is the number of units of the quote currency one unit of the base
+-- currency buys.
+CREATE TABLE exchange_rates (
+    tenant_id       UUID            NOT NULL,
+    base_currency   CHAR(3)         NOT NULL,
+    quote_currency  CHAR(3)         NOT NULL,
+    rate            NUMERIC(18, 8)  NOT NULL CHECK (rate > 0),
+    date_updated    TIMESTAMP       NOT NULL,
+
+    PRIMARY KEY (tenant_id, base_currency, quote_currency),
+    FOREIGN KEY (tenant_id) REFERENCES tenants(tenant_id)
+);
+
+-- Prices follow the rules of the products they belong to.
+ALTER TABLE product_prices ENABLE ROW LEVEL SECURITY;
+CREATE POLICY product_prices_select ON product_prices FOR SELECT
+    USING (tenant_id = app_tenant_id());
+CREATE POLICY product_prices_modify ON product_prices FOR ALL
+    USING (EXISTS (
+        SELECT 1 FROM products p
+        WHERE p.product_id = product_prices.product_id AND
+              p.tenant_id = app_tenant_id() AND
+              (app_is_admin() OR p.user_id = app_user_id())
+    ));
+
+ALTER TABLE exchange_rates ENABLE ROW LEVEL SECURITY;
+CREATE POLICY exchange_rates_select ON exchange_rates FOR SELECT
+    USING (tenant_id = app_tenant_id());
+CREATE POLICY exchange_rates_modify ON exchange_rates FOR ALL
+    USING (tenant_id = app_tenant_id() AND app_is_admin());
+
+-- Version: 1.18
+-- Description: Record every change to stock in an append-only ledger
+CREATE TABLE stock_movements (
+    movement_id     UUID        NOT NULL,
+    tenant_id       UUID        NOT NULL,
+    product_id      UUID        NOT NULL,
+    kind            TEXT        NOT NULL,
+    quantity        INT         NOT NULL CHECK (quantity <> 0),
+    reason          TEXT        NULL,
+    order_id        UUID        NULL,
+    actor_id        UUID        NULL,
+    date_created    TIMESTAMP   NOT NULL,
+
+    PRIMARY KEY (movement_id),
+    FOREIGN KEY (product_id) REFERENCES products(product_id) ON DELETE CASCADE,
+    FOREIGN KEY (tenant_id) REFERENCES tenants(tenant_id)
+);
+
+CREATE INDEX stock_movements_product_idx ON stock_movements (product_id, date_created);
+
+-- Movements are never changed or removed. They only go when the product they
+-- belong to is purged, which deletes them from within the foreign key
+-- trigger.
+CREATE FUNCTION stock_movements_append_only() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'DELETE' AND pg_trigger_depth() > 1 THEN
+        RETURN OLD;
+    END IF;
+    RAISE EXCEPTION 'stock movements are append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER stock_movements_append_only_trigger
+    BEFORE UPDATE OR DELETE ON stock_movements
+    FOR EACH ROW EXECUTE FUNCTION stock_movements_append_only();
+
+-- Movements are written through app_move_stock only, so there is no insert
+-- policy. Owners of a product and admins can read its movements.
+ALTER TABLE stock_movements ENABLE ROW LEVEL SECURITY;
+CREATE POLICY stock_movements_select ON stock_movements FOR SELECT
+    USING (tenant_id = app_tenant_id() AND (
+        app_is_admin() OR
+        EXISTS (SELECT 1 FROM products p WHERE p.product_id = stock_movements.product_id AND p.user_id = app_user_id())
+    ));
+
+-- Stock on hand only changes together with the movement that explains it.
+-- Like taking stock it runs as the owner, so buyers can record sales of
+-- products they don't own. It never leaves less than nothing on hand and
+-- only adds to the stock of deleted products, for orders being undone.
+CREATE FUNCTION app_move_stock(
+    p_movement_id UUID, p_product_id UUID, p_kind TEXT, p_quantity INT,
+    p_reason TEXT, p_order_id UUID, p_actor_id UUID, p_date_created TIMESTAMP
+) RETURNS SETOF products AS $$
+    WITH moved AS (
+        UPDATE
+            products
+        SET
+            quantity = quantity + p_quantity,
+            date_updated = p_date_created
+        WHERE
+            product_id = p_product_id AND
+            tenant_id = COALESCE(app_tenant_id(), tenant_id) AND
+            (deleted_at IS NULL OR p_quantity > 0) AND
+            quantity + p_quantity >= 0
+        RETURNING *
+    ), recorded AS (
+        INSERT INTO stock_movements
+            (movement_id, tenant_id, product_id, kind, quantity, reason, order_id, actor_id, date_created)
+        SELECT
+            p_movement_id, tenant_id, product_id, p_kind, p_quantity, p_reason, p_order_id, p_actor_id, p_date_created
+        FROM
+            moved
+    )
+    SELECT * FROM moved
+$$ LANGUAGE SQL VOLATILE SECURITY DEFINER SET search_path = public;
+
+DROP FUNCTION app_take_stock(UUID, INT, TIMESTAMP);
+DROP FUNCTION app_return_stock(UUID, INT, TIMESTAMP);
+
+-- Stock on hand before the ledger existed is recorded as an opening balance.
+INSERT INTO stock_movements
+    (movement_id, tenant_id, product_id, kind, quantity, reason, order_id, actor_id, date_created)
+SELECT
+    gen_random_uuid(), tenant_id, product_id, 'ADJUSTMENT', quantity, 'OPENING', NULL, NULL, date_updated
+FROM
+    products
+WHERE
+    quantity <> 0;
+
+-- Version: 1.19
+-- Description: Hold stock for carts with reservations that expire
+CREATE TABLE stock_reservations (
+    reservation_id  UUID        NOT NULL,
+    tenant_id       UUID        NOT NULL,
+    product_id      UUID        NOT NULL,
+    user_id         UUID        NOT NULL,
+    quantity        INT         NOT NULL CHECK (quantity > 0),
+    status          TEXT        NOT NULL DEFAULT 'HELD',
+    order_id        UUID        NULL,
+    date_created    TIMESTAMP   NOT NULL,
+    date_expires    TIMESTAMP   NOT NULL,
+    date_resolved   TIMESTAMP   NULL,
+
+    PRIMARY KEY (reservation_id),
+    FOREIGN KEY (product_id) REFERENCES products(product_id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE,
+    FOREIGN KEY (tenant_id) REFERENCES tenants(tenant_id)
+);
+
+CREATE INDEX stock_reservations_held_idx ON stock_reservations (product_id, date_expires) WHERE status = 'HELD';
+CREATE INDEX stock_reservations_user_idx ON stock_reservations (user_id);
+
+-- Reservations are created through app_reserve_stock only, so there is no
+-- insert policy. Users see and resolve their own, admins those of the tenant.
+ALTER TABLE stock_reservations ENABLE ROW LEVEL SECURITY;
+CREATE POLICY stock_reservations_select ON stock_reservations FOR SELECT
+    USING (tenant_id = app_tenant_id() AND (app_is_admin() OR user_id = app_user_id()));
+CREATE POLICY stock_reservations_update ON stock_reservations FOR UPDATE
+    USING (tenant_id = app_tenant_id() AND (app_is_admin() OR user_id = app_user_id()));
+
+-- Stock held by reservations that haven't been resolved or run out yet.
+CREATE FUNCTION app_held_stock(p_product_id UUID, p_now TIMESTAMP) RETURNS INT AS $$
+    SELECT
+        COALESCE(SUM(quantity), 0)::INT
+    FROM
+        stock_reservations
+    WHERE
+        product_id = p_product_id AND status = 'HELD' AND date_expires > p_now
+$$ LANGUAGE SQL STABLE SECURITY DEFINER SET search_path = public;
+
+-- Reserving locks the product before it counts what is already held, so
+-- parallel reservations of the same product queue up behind each other and
+-- each sees the holds committed before it. Nothing is returned when there
+-- isn't enough available.
+CREATE FUNCTION app_reserve_stock(
+    p_reservation_id UUID, p_product_id UUID, p_user_id UUID, p_quantity INT,
+    p_date_created TIMESTAMP, p_date_expires TIMESTAMP
+) RETURNS SETOF stock_reservations AS $$
+DECLARE
+    v_tenant_id UUID;
+    v_quantity  INT;
+BEGIN
+    SELECT
+        tenant_id, quantity INTO v_tenant_id, v_quantity
+    FROM
+        products
+    WHERE
+        product_id = p_product_id AND
+        tenant_id = COALESCE(app_tenant_id(), tenant_id) AND
+        deleted_at IS NULL
+    FOR UPDATE;
+
+    IF NOT FOUND OR v_quantity - app_held_stock(p_product_id, p_date_created) < p_quantity THEN
+        RETURN;
+    END IF;
+
+    RETURN QUERY
+    INSERT INTO stock_reservations
+        (reservation_id, tenant_id, product_id, user_id, quantity, status, order_id, date_created, date_expires, date_resolved)
+    VALUES
+        (p_reservation_id, v_tenant_id, p_product_id, p_user_id, p_quantity, 'HELD', NULL, p_date_created, p_date_expires, NULL)
+    RETURNING *;
+END;
+$$ LANGUAGE plpgsql VOLATILE SECURITY DEFINER SET search_path = public;
+
+-- Sales can't take stock held for someone else. The product is locked before
+-- the holds are counted for the same reason as when reserving.
+CREATE OR REPLACE FUNCTION app_move_stock(
+    p_movement_id UUID, p_product_id UUID, p_kind TEXT, p_quantity INT,
+    p_reason TEXT, p_order_id UUID, p_actor_id UUID, p_date_created TIMESTAMP
+) RETURNS SETOF products AS $$
+DECLARE
+    v_held INT := 0;
+BEGIN
+    PERFORM
+        1
+    FROM
+        products
+    WHERE
+        product_id = p_product_id AND
+        tenant_id = COALESCE(app_tenant_id(), tenant_id)
+    FOR UPDATE;
+
+    IF p_kind = 'SALE' THEN
+        v_held := app_held_stock(p_product_id, p_date_created);
+    END IF;
+
+    RETURN QUERY
+    WITH moved AS (
+        UPDATE
+            products
+        SET
+            quantity = quantity + p_quantity,
+            date_updated = p_date_created
+        WHERE
+            product_id = p_product_id AND
+            tenant_id = COALESCE(app_tenant_id(), tenant_id) AND
+            (deleted_at IS NULL OR p_quantity > 0) AND
+            quantity + p_quantity >= v_held
+        RETURNING *
+    ), recorded AS (
+        INSERT INTO stock_movements
+            (movement_id, tenant_id, product_id, kind, quantity, reason, order_id, actor_id, date_created)
+        SELECT
+            p_movement_id, tenant_id, product_id, p_kind, p_quantity, p_reason, p_order_id, p_actor_id, p_date_created
+        FROM
+            moved
+    )
+    SELECT * FROM moved;
+END;
+$$ LANGUAGE plpgsql VOLATILE SECURITY DEFINER SET search_path = public;
+
+-- Version: 1.20
+-- Description: Track the version of users and products for concurrent edits
+-- Updates only apply to the version they were made against and bump it, so
+-- one of two concurrent edits fails instead of silently overwriting the other.
+ALTER TABLE users ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE products ADD COLUMN version INT NOT NULL DEFAULT 1;
+
+-- Version: 1.21
+-- Description: Organize products in a tree of categories
+-- Categories form a tree through their parent. The parent has to belong to
+-- the same tenant and can't be deleted while it has children.
+CREATE TABLE categories (
+    category_id     UUID        NOT NULL,
+    tenant_id       UUID        NOT NULL,
+    parent_id       UUID        NULL,
+    name            TEXT        NOT NULL,
+    date_created    TIMESTAMP   NOT NULL,
+    date_updated    TIMESTAMP   NOT NULL,
+
+    PRIMARY KEY (category_id),
+    UNIQUE (tenant_id, category_id),
+    FOREIGN KEY (tenant_id) REFERENCES tenants(tenant_id),
+    FOREIGN KEY (tenant_id, parent_id) REFERENCES categories(tenant_id, category_id) ON DELETE RESTRICT
+);
+
+-- Names are unique among siblings, top level categories included.
+CREATE UNIQUE INDEX categories_name_idx ON categories (tenant_id, COALESCE(parent_id, '00000000-0000-0000-0000-000000000000'), name);
+CREATE INDEX categories_parent_idx ON categories (parent_id);
+
+CREATE TABLE product_categories (
+    product_id      UUID        NOT NULL,
+    category_id     UUID        NOT NULL,
+    tenant_id       UUID        NOT NULL,
+    date_created    TIMESTAMP   NOT NULL,
+
+    PRIMARY KEY (product_id, category_id),
+    FOREIGN KEY (product_id) REFERENCES products(product_id) ON DELETE CASCADE,
+    FOREIGN KEY (tenant_id, category_id) REFERENCES categories(tenant_id, category_id) ON DELETE CASCADE
+);
+
+CREATE INDEX product_categories_category_idx ON product_categories (category_id);
+
+ALTER TABLE categories ENABLE ROW LEVEL SECURITY;
+CREATE POLICY categories_select ON categories FOR SELECT
+    USING (tenant_id = app_tenant_id());
+CREATE POLICY categories_modify ON categories FOR ALL
+    USING (tenant_id = app_tenant_id() AND app_is_admin());
+
+-- Assignments follow the rules of the products they belong to.
+ALTER TABLE product_categories ENABLE ROW LEVEL SECURITY;
+CREATE POLICY product_categories_select ON product_categories FOR SELECT
+    USING (tenant_id = app_tenant_id());
+CREATE POLICY product_categories_modify ON product_categories FOR ALL
+    USING (EXISTS (
+        SELECT 1 FROM products p
+        WHERE p.product_id = product_categories.product_id AND
+              p.tenant_id = app_tenant_id() AND
+              (app_is_admin() OR p.user_id = app_user_id())
+    ));
+
+-- Version: 1.22
+-- Description: Sell products in variants identified by SKU
+-- A product with options is sold through its variants, which are products
+-- of their own so each keeps its own cost, stock, ledger and reservations.
+-- The options of a product name the dimensions its variants vary in, the
+-- option values of a variant pick one value for each of them.
+ALTER TABLE products ADD COLUMN parent_id UUID NULL REFERENCES products(product_id) ON DELETE CASCADE;
+ALTER TABLE products ADD COLUMN sku TEXT NULL;
+ALTER TABLE products ADD COLUMN options TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE products ADD COLUMN option_values JSONB NULL;
+
+CREATE UNIQUE INDEX products_sku_idx ON products (tenant_id, sku);
+CREATE UNIQUE INDEX products_variant_idx ON products (parent_id, option_values) WHERE parent_id IS NOT NULL;
+
+ALTER TABLE order_lines ADD COLUMN sku TEXT NULL;
+
+-- Version: 1.23
+-- Description: Search products and users by text
+-- The search columns hold the words of a row for full-text matching and the
+-- trigram indexes find names close to a misspelled query. The trigram index
+-- on the product name also serves the existing name ILIKE filter.
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+ALTER TABLE products ADD COLUMN search TSVECTOR GENERATED ALWAYS AS (
+    to_tsvector('english', name || ' ' || COALESCE(sku, ''))
+) STORED;
+
+CREATE INDEX products_search_idx ON products USING GIN (search);
+CREATE INDEX products_name_trgm_idx ON products USING GIN (name gin_trgm_ops);
+
+ALTER TABLE users ADD COLUMN search TSVECTOR GENERATED ALWAYS AS (
+    to_tsvector('simple', name || ' ' || email)
+) STORED;
+
+CREATE INDEX users_search_idx ON users USING GIN (search);
+CREATE INDEX users_name_trgm_idx ON users USING GIN (name gin_trgm_ops);
+
+-- Version: 1.24
+-- Description: Add promotions redeemed with discount codes at checkout
+-- A promotion takes a percentage or a fixed amount off the products it is
+-- scoped to, or off every product when it has no scope. Codes are stored in
+-- upper case and are unique within a tenant.
+CREATE TABLE promotions (
+    promotion_id        UUID            NOT NULL,
+    tenant_id           UUID            NOT NULL,
+    code                TEXT            NOT NULL,
+    kind                TEXT            NOT NULL,
+    percent             INT             NULL CHECK (percent BETWEEN 1 AND 100),
+    amount              NUMERIC(10, 2)  NULL CHECK (amount > 0),
+    min_order           NUMERIC(12, 2)  NOT NULL DEFAULT 0,
+    max_uses            INT             NULL CHECK (max_uses > 0),
+    max_uses_per_user   INT             NULL CHECK (max_uses_per_user > 0),
+    uses                INT             NOT NULL DEFAULT 0,
+    product_ids         UUID[]          NOT NULL DEFAULT '{}',
+    category_ids        UUID[]          NOT NULL DEFAULT '{}',
+    enabled             BOOLEAN         NOT NULL,
+    date_starts         TIMESTAMP       NOT NULL,
+    date_ends           TIMESTAMP       NULL,
+    date_created        TIMESTAMP       NOT NULL,
+    date_updated        TIMESTAMP       NOT NULL,
+
+    PRIMARY KEY (promotion_id),
+    UNIQUE (tenant_id, code),
+    FOREIGN KEY (tenant_id) REFERENCES tenants(tenant_id)
+);
+
+-- Every order a promotion was used in, which the usage limits are counted on.
+CREATE TABLE promotion_redemptions (
+    promotion_id    UUID            NOT NULL,
+    order_id        UUID            NOT NULL,
+    tenant_id       UUID            NOT NULL,
+    user_id         UUID            NOT NULL,
+    amount          NUMERIC(12, 2)  NOT NULL,
+    date_created    TIMESTAMP       NOT NULL,
+
+    PRIMARY KEY (promotion_id, order_id),
+    FOREIGN KEY (promotion_id) REFERENCES promotions(promotion_id) ON DELETE CASCADE,
+    FOREIGN KEY (order_id) REFERENCES orders(order_id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE,
+    FOREIGN KEY (tenant_id) REFERENCES tenants(tenant_id)
+);
+
+CREATE INDEX promotion_redemptions_user_idx ON promotion_redemptions (promotion_id, user_id);
+CREATE INDEX promotion_redemptions_order_idx ON promotion_redemptions (order_id);
+
+-- The discount of an order broken down by line and code. The code is kept so
+-- the breakdown stays meaningful once the promotion is deleted.
+CREATE TABLE order_discounts (
+    order_id        UUID            NOT NULL,
+    line_number     INT             NOT NULL,
+    tenant_id       UUID            NOT NULL,
+    promotion_id    UUID            NULL,
+    code            TEXT            NOT NULL,
+    amount          NUMERIC(12, 2)  NOT NULL,
+
+    PRIMARY KEY (order_id, line_number, code),
+    FOREIGN KEY (order_id, line_number) REFERENCES order_lines(order_id, line_number) ON DELETE CASCADE,
+    FOREIGN KEY (promotion_id) REFERENCES promotions(promotion_id) ON DELETE SET NULL,
+    FOREIGN KEY (tenant_id) REFERENCES tenants(tenant_id)
+);
+
+CREATE INDEX order_discounts_promotion_idx ON order_discounts (promotion_id);
+
+ALTER TABLE orders ADD COLUMN discount NUMERIC(12, 2) NOT NULL DEFAULT 0;
+
+-- Only admins manage promotions. Redemptions are recorded through
+-- app_redeem_promotion only, so there is no insert policy.
+ALTER TABLE promotions ENABLE ROW LEVEL SECURITY;
+CREATE POLICY promotions_select ON promotions FOR SELECT
+    USING (tenant_id = app_tenant_id());
+CREATE POLICY promotions_modify ON promotions FOR ALL
+    USING (tenant_id = app_tenant_id() AND app_is_admin());
+
+ALTER TABLE promotion_redemptions ENABLE ROW LEVEL SECURITY;
+CREATE POLICY promotion_redemptions_select ON promotion_redemptions FOR SELECT
+    USING (tenant_id = app_tenant_id() AND (app_is_admin() OR user_id = app_user_id()));
+
+ALTER TABLE order_discounts ENABLE ROW LEVEL SECURITY;
+CREATE POLICY order_discounts_select ON order_discounts FOR SELECT
+    USING (EXISTS (SELECT 1 FROM orders o WHERE o.order_id = order_discounts.order_id));
+CREATE POLICY order_discounts_insert ON order_discounts FOR INSERT
+    WITH CHECK (EXISTS (SELECT 1 FROM orders o WHERE o.order_id = order_discounts.order_id));
+
+-- Redeeming locks the promotion before it counts the uses, so parallel
+-- checkouts with the same code queue up behind each other and the limits
+-- hold. The order has to belong to the user and the tenant of the promotion.
+-- False is returned when a limit has been reached.
+CREATE FUNCTION app_redeem_promotion(
+    p_promotion_id UUID, p_order_id UUID, p_user_id UUID, p_amount NUMERIC, p_date_created TIMESTAMP
+) RETURNS BOOLEAN AS $$
+DECLARE
+    v_tenant_id         UUID;
+    v_uses              INT;
+    v_max_uses          INT;
+    v_max_uses_per_user INT;
+BEGIN
+    SELECT
+        tenant_id, uses, max_uses, max_uses_per_user
+        INTO v_tenant_id, v_uses, v_max_uses, v_max_uses_per_user
+    FROM
+        promotions
+    WHERE
+        promotion_id = p_promotion_id AND
+        tenant_id = COALESCE(app_tenant_id(), tenant_id)
+    FOR UPDATE;
+
+    IF NOT FOUND THEN
+        RETURN FALSE;
+    END IF;
+
+    PERFORM 1 FROM orders WHERE order_id = p_order_id AND user_id = p_user_id AND tenant_id = v_tenant_id;
+    IF NOT FOUND THEN
+        RETURN FALSE;
+    END IF;
+
+    IF v_max_uses IS NOT NULL AND v_uses >= v_max_uses THEN
+        RETURN FALSE;
+    END IF;
+
+    IF v_max_uses_per_user IS NOT NULL AND (
+        SELECT COUNT(*) FROM promotion_redemptions WHERE promotion_id = p_promotion_id AND user_id = p_user_id
+    ) >= v_max_uses_per_user THEN
+        RETURN FALSE;
+    END IF;
+
+    INSERT INTO promotion_redemptions
+        (promotion_id, order_id, tenant_id, user_id, amount, date_created)
+    VALUES
+        (p_promotion_id, p_order_id, v_tenant_id, p_user_id, p_amount, p_date_created);
+
+    UPDATE promotions SET uses = uses + 1 WHERE promotion_id = p_promotion_id;
+
+    RETURN TRUE;
+END;
+$$ LANGUAGE plpgsql VOLATILE SECURITY DEFINER SET search_path = public;
+
+-- Cancelling an order gives back the uses of the promotions redeemed with it.
+CREATE FUNCTION app_release_promotions(p_order_id UUID) RETURNS VOID AS $$
+    WITH released AS (
+        DELETE FROM
+            promotion_redemptions AS r
+        USING
+            orders AS o
+        WHERE
+            r.order_id = p_order_id AND
+            o.order_id = r.order_id AND
+            o.status = 'CANCELLED' AND
+            o.tenant_id = COALESCE(app_tenant_id(), o.tenant_id)
+        RETURNING
+            r.promotion_id
+    )
+    UPDATE
+        promotions AS p
+    SET
+        uses = p.uses - 1
+    FROM
+        released AS r
+    WHERE
+        p.promotion_id = r.promotion_id
+$$ LANGUAGE SQL VOLATILE SECURITY DEFINER SET search_path = public;
+
+-- Revenue is what was actually charged for the lines.
+CREATE OR REPLACE VIEW product_sales AS
+SELECT
+    l.product_id                            AS product_id,
+    SUM(l.quantity)                         AS sold,
+    SUM(l.total - COALESCE(d.amount, 0))    AS revenue
+FROM
+    order_lines AS l
+JOIN
+    orders AS o ON o.order_id = l.order_id
+LEFT JOIN (
+    SELECT order_id, line_number, SUM(amount) AS amount FROM order_discounts GROUP BY order_id, line_number
+) AS d ON d.order_id = l.order_id AND d.line_number = l.line_number
+WHERE
+    l.product_id IS NOT NULL AND o.status NOT IN ('CANCELLED', 'REFUNDED')
+GROUP BY
+    l.product_id;
+
+-- Version: 1.25
+-- Description: Add tax jurisdictions with rates by product tax class
+-- A jurisdiction decides whether its prices include tax and how tax is
+-- rounded. Its rates are kept by tax class, with STANDARD used for classes
+-- that have no rate of their own.
+CREATE TABLE tax_jurisdictions (
+    jurisdiction_id UUID        NOT NULL,
+    tenant_id       UUID        NOT NULL,
+    code            TEXT        NOT NULL,
+    name            TEXT        NOT NULL,
+    inclusive       BOOLEAN     NOT NULL,
+    rounding        TEXT        NOT NULL,
+    date_created    TIMESTAMP   NOT NULL,
+    date_updated    TIMESTAMP   NOT NULL,
+
+    PRIMARY KEY (jurisdiction_id),
+    UNIQUE (tenant_id, code),
+    FOREIGN KEY (tenant_id) REFERENCES tenants(tenant_id)
+);
+
+CREATE TABLE tax_rates (
+    jurisdiction_id UUID            NOT NULL,
+    tenant_id       UUID            NOT NULL,
+    tax_class       TEXT            NOT NULL,
+    rate            NUMERIC(7, 4)   NOT NULL CHECK (rate >= 0),
+
+    PRIMARY KEY (jurisdiction_id, tax_class),
+    FOREIGN KEY (jurisdiction_id) REFERENCES tax_jurisdictions(jurisdiction_id) ON DELETE CASCADE,
+    FOREIGN KEY (tenant_id) REFERENCES tenants(tenant_id)
+);
+
+ALTER TABLE products ADD COLUMN tax_class TEXT NOT NULL DEFAULT 'STANDARD';
+
+ALTER TABLE tax_jurisdictions ENABLE ROW LEVEL SECURITY;
+CREATE POLICY tax_jurisdictions_select ON tax_jurisdictions FOR SELECT
+    USING (tenant_id = app_tenant_id());
+CREATE POLICY tax_jurisdictions_modify ON tax_jurisdictions FOR ALL
+    USING (tenant_id = app_tenant_id() AND app_is_admin());
+
+ALTER TABLE tax_rates ENABLE ROW LEVEL SECURITY;
+CREATE POLICY tax_rates_select ON tax_rates FOR SELECT
+    USING (tenant_id = app_tenant_id());
+CREATE POLICY tax_rates_modify ON tax_rates FOR ALL
+    USING (tenant_id = app_tenant_id() AND app_is_admin());
+
+-- Version: 1.26
+-- Description: Add returns of order lines with refunds and optional restock
+-- A line keeps count of how much of it was returned and refunded, and an
+-- order of how much of it was refunded, so returns can't take back more than
+-- was sold or refund more than was paid.
+ALTER TABLE order_lines ADD COLUMN returned INT NOT NULL DEFAULT 0;
+ALTER TABLE order_lines ADD COLUMN refunded NUMERIC(12, 2) NOT NULL DEFAULT 0;
+ALTER TABLE order_lines ADD CONSTRAINT order_lines_returned_check CHECK (returned BETWEEN 0 AND quantity);
+ALTER TABLE order_lines ADD CONSTRAINT order_lines_refunded_check CHECK (refunded BETWEEN 0 AND total);
+
+ALTER TABLE orders ADD COLUMN refunded NUMERIC(12, 2) NOT NULL DEFAULT 0;
+ALTER TABLE orders ADD CONSTRAINT orders_refunded_check CHECK (refunded BETWEEN 0 AND total);
+
+-- Every return is kept as the record of the refund it gave.
+CREATE TABLE order_returns (
+    return_id       UUID            NOT NULL,
+    order_id        UUID            NOT NULL,
+    tenant_id       UUID            NOT NULL,
+    reason          TEXT            NOT NULL,
+    restock         BOOLEAN         NOT NULL,
+    amount          NUMERIC(12, 2)  NOT NULL CHECK (amount >= 0),
+    actor_id        UUID            NULL,
+    date_created    TIMESTAMP       NOT NULL,
+
+    PRIMARY KEY (return_id),
+    FOREIGN KEY (order_id) REFERENCES orders(order_id) ON DELETE CASCADE,
+    FOREIGN KEY (tenant_id) REFERENCES tenants(tenant_id)
+);
+
+CREATE INDEX order_returns_order_idx ON order_returns (order_id);
+
+CREATE TABLE order_return_lines (
+    return_id       UUID            NOT NULL,
+    line_number     INT             NOT NULL,
+    order_id        UUID            NOT NULL,
+    tenant_id       UUID            NOT NULL,
+    quantity        INT             NOT NULL CHECK (quantity > 0),
+    amount          NUMERIC(12, 2)  NOT NULL CHECK (amount >= 0),
+
+    PRIMARY KEY (return_id, line_number),
+    FOREIGN KEY (return_id) REFERENCES order_returns(return_id) ON DELETE CASCADE,
+    FOREIGN KEY (order_id, line_number) REFERENCES order_lines(order_id, line_number) ON DELETE CASCADE,
+    FOREIGN KEY (tenant_id) REFERENCES tenants(tenant_id)
+);
+
+-- Returns are seen with the order they belong to and only taken by admins.
+CREATE POLICY order_lines_update ON order_lines FOR UPDATE
+    USING (EXISTS (SELECT 1 FROM orders o WHERE o.order_id = order_lines.order_id) AND app_is_admin());
+
+ALTER TABLE order_returns ENABLE ROW LEVEL SECURITY;
+CREATE POLICY order_returns_select ON order_returns FOR SELECT
+    USING (EXISTS (SELECT 1 FROM orders o WHERE o.order_id = order_returns.order_id));
+CREATE POLICY order_returns_insert ON order_returns FOR INSERT
+    WITH CHECK (EXISTS (SELECT 1 FROM orders o WHERE o.order_id = order_returns.order_id) AND app_is_admin());
+
+ALTER TABLE order_return_lines ENABLE ROW LEVEL SECURITY;
+CREATE POLICY order_return_lines_select ON order_return_lines FOR SELECT
+    USING (EXISTS (SELECT 1 FROM orders o WHERE o.order_id = order_return_lines.order_id));
+CREATE POLICY order_return_lines_insert ON order_return_lines FOR INSERT
+    WITH CHECK (EXISTS (SELECT 1 FROM orders o WHERE o.order_id = order_return_lines.order_id) AND app_is_admin());
+
+-- What was returned is no longer sold and what was refunded no longer
+-- earned.
+CREATE OR REPLACE VIEW product_sales AS
+SELECT
+    l.product_id                                        AS product_id,
+    SUM(l.quantity - l.returned)                        AS sold,
+    SUM(l.total - COALESCE(d.amount, 0) - l.refunded)   AS revenue
+FROM
+    order_lines AS l
+JOIN
+    orders AS o ON o.order_id = l.order_id
+LEFT JOIN (
+    SELECT order_id, line_number, SUM(amount) AS amount FROM order_discounts GROUP BY order_id, line_number
+) AS d ON d.order_id = l.order_id AND d.line_number = l.line_number
+WHERE
+    l.product_id IS NOT NULL AND o.status NOT IN ('CANCELLED', 'REFUNDED')
+GROUP BY
+    l.product_id;
+
+-- Version: 1.27
+-- Description: Schedule cost changes and keep the price history of products
+CREATE TABLE price_changes (
+    change_id       UUID            NOT NULL,
+    tenant_id       UUID            NOT NULL,
+    product_id      UUID            NOT NULL,
+    cost            NUMERIC(10, 2)  NOT NULL CHECK (cost >= 0),
+    previous_cost   NUMERIC(10, 2)  NULL,
+    status          TEXT            NOT NULL,
+    effective_at    TIMESTAMP       NOT NULL,
+    created_by      UUID            NULL,
+    date_created    TIMESTAMP       NOT NULL,
+    date_resolved   TIMESTAMP       NULL,
+
+    PRIMARY KEY (change_id),
+    FOREIGN KEY (product_id) REFERENCES products(product_id) ON DELETE CASCADE,
+    FOREIGN KEY (tenant_id) REFERENCES tenants(tenant_id)
+);
+
+-- The worker looks for scheduled changes that are due.
+CREATE INDEX price_changes_due_idx ON price_changes (effective_at) WHERE status = 'SCHEDULED';
+CREATE INDEX price_changes_product_idx ON price_changes (product_id, effective_at);
+
+-- Every cost a product had, with the change that set it when it was
+-- scheduled.
+CREATE TABLE price_history (
+    entry_id        UUID            NOT NULL,
+    tenant_id       UUID            NOT NULL,
+    product_id      UUID            NOT NULL,
+    cost            NUMERIC(10, 2)  NOT NULL,
+    previous_cost   NUMERIC(10, 2)  NULL,
+    change_id       UUID            NULL,
+    actor_id        UUID            NULL,
+    date_created    TIMESTAMP       NOT NULL,
+
+    PRIMARY KEY (entry_id),
+    FOREIGN KEY (product_id) REFERENCES products(product_id) ON DELETE CASCADE,
+    FOREIGN KEY (change_id) REFERENCES price_changes(change_id) ON DELETE SET NULL,
+    FOREIGN KEY (tenant_id) REFERENCES tenants(tenant_id)
+);
+
+CREATE INDEX price_history_product_idx ON price_history (product_id, date_created);
+
+-- The history starts out with the costs recorded in the product versions.
+INSERT INTO price_history
+    (entry_id, tenant_id, product_id, cost, previous_cost, change_id, actor_id, date_created)
+SELECT
+    gen_random_uuid(), tenant_id, product_id, cost, previous_cost, NULL, NULL, valid_from
+FROM (
+    SELECT
+        h.*,
+        LAG(h.cost) OVER (PARTITION BY h.product_id ORDER BY h.version) AS previous_cost
+    FROM
+        products_history AS h
+) AS v
+WHERE
+    previous_cost IS NULL OR previous_cost <> cost;
+
+-- Changes and history follow the rules of the products they belong to.
+ALTER TABLE price_changes ENABLE ROW LEVEL SECURITY;
+CREATE POLICY price_changes_select ON price_changes FOR SELECT
+    USING (tenant_id = app_tenant_id());
+CREATE POLICY price_changes_modify ON price_changes FOR ALL
+    USING (EXISTS (
+        SELECT 1 FROM products p
+        WHERE p.product_id = price_changes.product_id AND
+              p.tenant_id = app_tenant_id() AND
+              (app_is_admin() OR p.user_id = app_user_id())
+    ));
+
+ALTER TABLE price_history ENABLE ROW LEVEL SECURITY;
+CREATE POLICY price_history_select ON price_history FOR SELECT
+    USING (tenant_id = app_tenant_id());
+CREATE POLICY price_history_insert ON price_history FOR INSERT
+    WITH CHECK (EXISTS (
+        SELECT 1 FROM products p
+        WHERE p.product_id = price_history.product_id AND
+              p.tenant_id = app_tenant_id() AND
+              (app_is_admin() OR p.user_id = app_user_id())
+    ));
+
+-- Version: 1.28
+-- Description: Add reorder thresholds and alert when stock falls below them
+ALTER TABLE products ADD COLUMN reorder_threshold INT NOT NULL DEFAULT 0 CHECK (reorder_threshold >= 0);
+
+-- An alert stays open while the product is low on stock and is cleared once
+-- it's restocked, so a product alerts once each time it runs low. The name,
+-- sku and owner of the product are kept as they were when it alerted.
+CREATE TABLE stock_alerts (
+    alert_id        UUID        NOT NULL,
+    tenant_id       UUID        NOT NULL,
+    product_id      UUID        NOT NULL,
+    user_id         UUID        NOT NULL,
+    name            TEXT        NOT NULL,
+    sku             TEXT        NULL,
+    quantity        INT         NOT NULL,
+    threshold       INT         NOT NULL,
+    date_created    TIMESTAMP   NOT NULL,
+    date_cleared    TIMESTAMP   NULL,
+
+    PRIMARY KEY (alert_id),
+    FOREIGN KEY (product_id) REFERENCES products(product_id) ON DELETE CASCADE,
+    FOREIGN KEY (tenant_id) REFERENCES tenants(tenant_id)
+);
+
+CREATE UNIQUE INDEX stock_alerts_open_idx ON stock_alerts (product_id) WHERE date_cleared IS NULL;
+
+-- Emails waiting to be sent. They are written by background jobs and picked
+-- up by a mailer, so requests have no access to them.
+CREATE TABLE email_outbox (
+    email_id        UUID        NOT NULL,
+    tenant_id       UUID        NOT NULL,
+    recipient       TEXT        NOT NULL,
+    subject         TEXT        NOT NULL,
+    body            TEXT        NOT NULL,
+    date_created    TIMESTAMP   NOT NULL,
+    date_sent       TIMESTAMP   NULL,
+
+    PRIMARY KEY (email_id),
+    FOREIGN KEY (tenant_id) REFERENCES tenants(tenant_id)
+);
+
+CREATE INDEX email_outbox_unsent_idx ON email_outbox (date_created) WHERE date_sent IS NULL;
+
+-- Alerts are raised by the stock alert job only.
+ALTER TABLE stock_alerts ENABLE ROW LEVEL SECURITY;
+CREATE POLICY stock_alerts_select ON stock_alerts FOR SELECT
+    USING (tenant_id = app_tenant_id());
+
+ALTER TABLE email_outbox ENABLE ROW LEVEL SECURITY;
+
+-- Version: 1.29
+-- Description: Keep the products of a user when the user is purged
+-- Purging a user used to delete every product the user owned, live ones
+-- included. A user now can't be removed while owning products.
+ALTER TABLE products DROP CONSTRAINT products_user_id_fkey;
+ALTER TABLE products ADD CONSTRAINT products_user_id_fkey
+    FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE RESTRICT;
+
+-- Version: 1.30
+-- Description: Keep the orders of a user when the user is purged
+-- Orders are the sales history products are credited with, so they outlive
+-- the user who placed them. A user with orders is erased instead.
+ALTER TABLE orders DROP CONSTRAINT orders_user_id_fkey;
+ALTER TABLE orders ADD CONSTRAINT orders_user_id_fkey
+    FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE RESTRICT;
+
+-- Version: 1.31
+-- Description: Never remove stock movements, not even with their product
+-- Purging a product used to take its movements with it through the foreign
+-- key. Products with movements now stay, so the ledger is never shortened.
+CREATE OR REPLACE FUNCTION stock_movements_append_only() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'stock movements are append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE stock_movements DROP CONSTRAINT stock_movements_product_id_fkey;
+ALTER TABLE stock_movements ADD CONSTRAINT stock_movements_product_id_fkey
+    FOREIGN KEY (product_id) REFERENCES products(product_id) ON DELETE RESTRICT;
+
+-- Version: 1.32
+-- Description: Refund orders through returns only and count them once in sales
+-- Orders moved to REFUNDED by hand got no return, so nothing recorded the
+-- refund and their lines still counted as sold. Record a return for what
+-- was left on each of them, as if everything was sent back.
+CREATE TEMPORARY TABLE refunded_orders AS
+SELECT
+    gen_random_uuid() AS return_id, order_id, tenant_id, total - refunded AS amount, date_updated
+FROM
+    orders AS o
+WHERE
+    status = 'REFUNDED' AND
+    EXISTS (SELECT 1 FROM order_lines AS l WHERE l.order_id = o.order_id AND l.returned < l.quantity);
+
+CREATE TEMPORARY TABLE refunded_lines AS
+SELECT
+    r.return_id, l.order_id, l.line_number, l.tenant_id,
+    l.quantity - l.returned AS quantity,
+    l.total - COALESCE(d.amount, 0) - l.refunded AS amount
+FROM
+    refunded_orders AS r
+JOIN
+    order_lines AS l ON l.order_id = r.order_id
+LEFT JOIN (
+    SELECT order_id, line_number, SUM(amount) AS amount FROM order_discounts GROUP BY order_id, line_number
+) AS d ON d.order_id = l.order_id AND d.line_number = l.line_number
+WHERE
+    l.returned < l.quantity;
+
+INSERT INTO order_returns
+    (return_id, order_id, tenant_id, reason, restock, amount, actor_id, date_created)
+SELECT
+    return_id, order_id, tenant_id, 'OTHER', FALSE, amount, NULL, date_updated
+FROM
+    refunded_orders;
+
+INSERT INTO order_return_lines
+    (return_id, line_number, order_id, tenant_id, quantity, amount)
+SELECT
+    return_id, line_number, order_id, tenant_id, quantity, amount
+FROM
+    refunded_lines;
+
+UPDATE order_lines AS l SET
+    returned = l.returned + rl.quantity,
+    refunded = l.refunded + rl.amount
+FROM
+    refunded_lines AS rl
+WHERE
+    l.order_id = rl.order_id AND l.line_number = rl.line_number;
+
+UPDATE orders AS o SET
+    refunded = o.refunded + r.amount
+FROM
+    refunded_orders AS r
+WHERE
+    o.order_id = r.order_id;
+
+DROP TABLE refunded_lines;
+DROP TABLE refunded_orders;
+
+-- Everything on a refunded order was returned, so subtracting the returns
+-- already leaves nothing of it. Leaving the order out as well would subtract
+-- its returns twice.
+CREATE OR REPLACE VIEW product_sales AS
+SELECT
+    l.product_id                                        AS product_id,
+    SUM(l.quantity - l.returned)                        AS sold,
+    SUM(l.total - COALESCE(d.amount, 0) - l.refunded)   AS revenue
+FROM
+    order_lines AS l
+JOIN
+    orders AS o ON o.order_id = l.order_id
+LEFT JOIN (
+    SELECT order_id, line_number, SUM(amount) AS amount FROM order_discounts GROUP BY order_id, line_number
+) AS d ON d.order_id = l.order_id AND d.line_number = l.line_number
+WHERE
+    l.product_id IS NOT NULL AND o.status <> 'CANCELLED'
+GROUP BY
+    l.product_id;
+
+-- Version: 1.33
+-- Description: Record the tax due on orders placed in a tax jurisdiction
+ALTER TABLE orders ADD COLUMN jurisdiction TEXT NULL;
+ALTER TABLE orders ADD COLUMN tax_inclusive BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE orders ADD COLUMN tax NUMERIC(12, 2) NOT NULL DEFAULT 0 CHECK (tax >= 0);
+ALTER TABLE order_lines ADD COLUMN tax NUMERIC(12, 2) NOT NULL DEFAULT 0 CHECK (tax >= 0);
+
+-- Version: 1.34
+-- Description: Refund the tax added on top of returned lines
+-- Refunds include the tax that was added on top of the price of a line, so
+-- they can add up to more than its total. What is earned leaves out the share
+-- of the refund that went to tax.
+ALTER TABLE order_lines DROP CONSTRAINT order_lines_refunded_check;
+ALTER TABLE order_lines ADD CONSTRAINT order_lines_refunded_check CHECK (refunded BETWEEN 0 AND total + tax);
+
+ALTER TABLE orders DROP CONSTRAINT orders_refunded_check;
+ALTER TABLE orders ADD CONSTRAINT orders_refunded_check CHECK (refunded BETWEEN 0 AND total + tax);
+
+CREATE OR REPLACE VIEW product_sales AS
+SELECT
+    l.product_id                                        AS product_id,
+    SUM(l.quantity - l.returned)                        AS sold,
+    SUM(
+        l.total - COALESCE(d.amount, 0) - COALESCE(ROUND(
+            l.refunded * (l.total - COALESCE(d.amount, 0)) /
+            NULLIF(l.total - COALESCE(d.amount, 0) + CASE WHEN o.tax_inclusive THEN 0 ELSE l.tax END, 0),
+        2), 0)
+    )                                                   AS revenue
+FROM
+    order_lines AS l
+JOIN
+    orders AS o ON o.order_id = l.order_id
+LEFT JOIN (
+    SELECT order_id, line_number, SUM(amount) AS amount FROM order_discounts GROUP BY order_id, line_number
+) AS d ON d.order_id = l.order_id AND d.line_number = l.line_number
+WHERE
+    l.product_id IS NOT NULL AND o.status <> 'CANCELLED'
+GROUP BY
+    l.product_id;
+
+-- Version: 1.35
+-- Description: Keep stock held by reservations from being adjusted away
+-- Nothing that takes stock away can take what is held for someone else, so
+-- adjustments can't write off stock held by a reservation any more than sales
+-- can sell it.
+CREATE OR REPLACE FUNCTION app_move_stock(
+    p_movement_id UUID, p_product_id UUID, p_kind TEXT, p_quantity INT,
+    p_reason TEXT, p_order_id UUID, p_actor_id UUID, p_date_created TIMESTAMP
+) RETURNS SETOF products AS $$
+DECLARE
+    v_held INT := 0;
+BEGIN
+    PERFORM
+        1
+    FROM
+        products
+    WHERE
+        product_id = p_product_id AND
+        tenant_id = COALESCE(app_tenant_id(), tenant_id)
+    FOR UPDATE;
+
+    IF p_quantity < 0 THEN
+        v_held := app_held_stock(p_product_id, p_date_created);
+    END IF;
+
+    RETURN QUERY
+    WITH moved AS (
+        UPDATE
+            products
+        SET
+            quantity = quantity + p_quantity,
+            date_updated = p_date_created
+        WHERE
+            product_id = p_product_id AND
+            tenant_id = COALESCE(app_tenant_id(), tenant_id) AND
+            (deleted_at IS NULL OR p_quantity > 0) AND
+            quantity + p_quantity >= v_held
+        RETURNING *
+    ), recorded AS (
+        INSERT INTO stock_movements
+            (movement_id, tenant_id, product_id, kind, quantity, reason, order_id, actor_id, date_created)
+        SELECT
+            p_movement_id, tenant_id, product_id, p_kind, p_quantity, p_reason, p_order_id, p_actor_id, p_date_created
+        FROM
+            moved
+    )
+    SELECT * FROM moved;
+END;
+$$ LANGUAGE plpgsql VOLATILE SECURITY DEFINER SET search_path = public;
+
+-- Version: 1.36
+-- Description: Keep track of the API tokens issued to users
+-- The tokens themselves are signed and never stored. What they were issued
+-- for is kept so the tokens of a user can be accounted for.
+CREATE TABLE api_tokens (
+    token_id        UUID        NOT NULL,
+    tenant_id       UUID        NOT NULL,
+    user_id         UUID        NOT NULL,
+    issuer          TEXT        NOT NULL,
+    date_created    TIMESTAMP   NOT NULL,
+    date_expires    TIMESTAMP   NOT NULL,
+
+    PRIMARY KEY (token_id),
+    FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE,
+    FOREIGN KEY (tenant_id) REFERENCES tenants(tenant_id)
+);
+
+CREATE INDEX api_tokens_user_idx ON api_tokens (user_id);
+
+-- Tokens are issued while signing in, before a session exists. Afterwards
+-- users see the tokens issued to them.
+ALTER TABLE api_tokens ENABLE ROW LEVEL SECURITY;
+CREATE POLICY api_tokens_select ON api_tokens FOR SELECT
+    USING (tenant_id = app_tenant_id() AND (app_is_admin() OR user_id = app_user_id()));
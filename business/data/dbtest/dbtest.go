@@ -11,6 +11,32 @@ import (
 	"testing"
 	"time"
 
+	"github.com/aleury/service/business/core/apitoken"
+	"github.com/aleury/service/business/core/apitoken/stores/apitokendb"
+	"github.com/aleury/service/business/core/audit"
+	"github.com/aleury/service/business/core/audit/stores/auditdb"
+	"github.com/aleury/service/business/core/category"
+	"github.com/aleury/service/business/core/category/stores/categorydb"
+	"github.com/aleury/service/business/core/department"
+	"github.com/aleury/service/business/core/department/stores/departmentdb"
+	"github.com/aleury/service/business/core/exchange"
+	"github.com/aleury/service/business/core/exchange/stores/exchangedb"
+	"github.com/aleury/service/business/core/identity"
+	"github.com/aleury/service/business/core/identity/stores/identitydb"
+	"github.com/aleury/service/business/core/product"
+	"github.com/aleury/service/business/core/product/stores/productdb"
+	"github.com/aleury/service/business/core/promotion"
+	"github.com/aleury/service/business/core/promotion/stores/promotiondb"
+	"github.com/aleury/service/business/core/reservation"
+	"github.com/aleury/service/business/core/reservation/stores/reservationdb"
+	"github.com/aleury/service/business/core/salesorder"
+	"github.com/aleury/service/business/core/salesorder/stores/salesorderdb"
+	"github.com/aleury/service/business/core/session"
+	"github.com/aleury/service/business/core/session/stores/sessiondb"
+	"github.com/aleury/service/business/core/tax"
+	"github.com/aleury/service/business/core/tax/stores/taxdb"
+	"github.com/aleury/service/business/core/tenant"
+	"github.com/aleury/service/business/core/tenant/stores/tenantdb"
 	"github.com/aleury/service/business/core/user"
 	"github.com/aleury/service/business/core/user/stores/userdb"
 	"github.com/aleury/service/business/data/dbmigrate"
@@ -197,7 +223,8 @@ func (test *Test) Token(email string, pass string) string {
 			ExpiresAt: jwt.NewNumericDate(time.Now().UTC().Add(time.Hour)),
 			IssuedAt:  jwt.NewNumericDate(time.Now().UTC()),
 		},
-		Roles: dbUsr.Roles,
+		Roles:  dbUsr.Roles,
+		Tenant: dbUsr.TenantID,
 	}
 
 	token, err := test.Auth.GenerateToken(kid, claims)
@@ -235,14 +262,46 @@ func FloatPointer(f float64) *float64 {
 
 // CoreAPIs represents all of the core api's needed for testing.
 type CoreAPIs struct {
-	User *user.Core
+	Tenant      *tenant.Core
+	Audit       *audit.Core
+	Department  *department.Core
+	Category    *category.Core
+	User        *user.Core
+	Identity    *identity.Core
+	Session     *session.Core
+	APIToken    *apitoken.Core
+	Exchange    *exchange.Core
+	Product     *product.Core
+	Reservation *reservation.Core
+	Promotion   *promotion.Core
+	Tax         *tax.Core
+	SalesOrder  *salesorder.Core
 }
 
 func newCoreAPIs(log *zap.SugaredLogger, db *sqlx.DB) CoreAPIs {
-	usrCore := user.NewCore(userdb.NewStore(log, db))
+	auditCore := audit.NewCore(auditdb.NewStore(log, db))
+	usrCore := user.NewCore(userdb.NewStore(log, db), auditCore)
+	exchCore := exchange.NewCore(exchangedb.NewStore(log, db))
+	prdCore := product.NewCore(log, usrCore, auditCore, exchCore, productdb.NewStore(log, db))
+	resCore := reservation.NewCore(reservationdb.NewStore(log, db), prdCore)
+	promoCore := promotion.NewCore(promotiondb.NewStore(log, db), auditCore)
+	taxCore := tax.NewCore(taxdb.NewStore(log, db), prdCore)
 
 	return CoreAPIs{
-		User: usrCore,
+		Tenant:      tenant.NewCore(tenantdb.NewStore(log, db)),
+		Audit:       auditCore,
+		Department:  department.NewCore(departmentdb.NewStore(log, db), auditCore),
+		Category:    category.NewCore(categorydb.NewStore(log, db), auditCore),
+		User:        usrCore,
+		Identity:    identity.NewCore(identitydb.NewStore(log, db), usrCore),
+		Session:     session.NewCore(sessiondb.NewStore(log, db), usrCore),
+		APIToken:    apitoken.NewCore(apitokendb.NewStore(log, db)),
+		Exchange:    exchCore,
+		Product:     prdCore,
+		Reservation: resCore,
+		Promotion:   promoCore,
+		Tax:         taxCore,
+		SalesOrder:  salesorder.NewCore(salesorderdb.NewStore(log, db), prdCore, resCore, promoCore, taxCore, auditCore),
 	}
 }
 
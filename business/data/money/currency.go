@@ -0,0 +1,81 @@
+package money
+
+import (
+	"errors"
+)
+
+// Set of supported currencies.
+var (
+	USD = Currency{"USD", 2}
+	EUR = Currency{"EUR", 2}
+	GBP = Currency{"GBP", 2}
+	JPY = Currency{"JPY", 0}
+)
+
+// Set of known currencies.
+var currencies = map[string]Currency{
+	USD.code: USD,
+	EUR.code: EUR,
+	GBP.code: GBP,
+	JPY.code: JPY,
+}
+
+// DefaultCurrency is the currency of amounts that are read without one.
+var DefaultCurrency = USD
+
+// Currency represents an ISO 4217 currency and the number of digits of its
+// minor unit.
+type Currency struct {
+	code   string
+	digits int
+}
+
+// ParseCurrency parses the ISO 4217 code and returns a currency if one
+// exists.
+func ParseCurrency(code string) (Currency, error) {
+	cur, exists := currencies[code]
+	if !exists {
+		return Currency{}, errors.New("invalid currency")
+	}
+	return cur, nil
+}
+
+// MustParseCurrency parses the ISO 4217 code and returns a currency if one
+// exists. If an error occurs the function panics.
+func MustParseCurrency(code string) Currency {
+	cur, err := ParseCurrency(code)
+	if err != nil {
+		panic(err)
+	}
+	return cur
+}
+
+// Code returns the ISO 4217 code of the currency.
+func (c Currency) Code() string {
+	return c.code
+}
+
+// Digits returns the number of digits of the minor unit of the currency.
+func (c Currency) Digits() int {
+	return c.digits
+}
+
+// UnmarshalText implements the unmarshal interface for JSON conversions.
+func (c *Currency) UnmarshalText(data []byte) error {
+	cur, err := ParseCurrency(string(data))
+	if err != nil {
+		return err
+	}
+	*c = cur
+	return nil
+}
+
+// MarshalText implements the marshal interface for JSON conversions.
+func (c Currency) MarshalText() ([]byte, error) {
+	return []byte(c.code), nil
+}
+
+// Equal provides support for the go-cmp package and testing.
+func (c Currency) Equal(c2 Currency) bool {
+	return c.code == c2.code
+}
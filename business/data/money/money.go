@@ -0,0 +1,335 @@
+// Package money provides an exact representation of amounts of money.
+package money
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Set of error variables for working with money.
+var (
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+	ErrInvalidAmount    = errors.New("invalid amount")
+	ErrOverflow         = errors.New("amount out of range")
+)
+
+// Money represents an amount as a whole number of minor units of a currency,
+// cents for USD, so arithmetic on it is exact. The zero value is zero in no
+// currency and can be added to an amount of any currency, which makes it a
+// convenient starting point for totals.
+type Money struct {
+	amount   int64
+	currency Currency
+}
+
+// New constructs an amount from a number of minor units.
+func New(minor int64, currency Currency) Money {
+	return Money{
+		amount:   minor,
+		currency: currency,
+	}
+}
+
+// Zero returns a zero amount of the currency.
+func Zero(currency Currency) Money {
+	return Money{currency: currency}
+}
+
+// Parse parses a decimal amount such as "-12.34" in the currency. Digits
+// beyond the minor unit of the currency are only accepted when they are
+// zeros, so an amount is never rounded.
+func Parse(value string, currency Currency) (Money, error) {
+	s := strings.TrimSpace(value)
+
+	var neg bool
+	switch {
+	case strings.HasPrefix(s, "-"):
+		neg = true
+		s = s[1:]
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	}
+
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" && frac == "" {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, value)
+	}
+
+	if len(frac) > currency.digits {
+		if strings.Trim(frac[currency.digits:], "0") != "" {
+			return Money{}, fmt.Errorf("%w: %q has more than %d decimal places", ErrInvalidAmount, value, currency.digits)
+		}
+		frac = frac[:currency.digits]
+	}
+	frac += strings.Repeat("0", currency.digits-len(frac))
+
+	digits := whole + frac
+	if digits == "" {
+		digits = "0"
+	}
+
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, value)
+		}
+	}
+
+	amount, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("%w: %q: %s", ErrInvalidAmount, value, err)
+	}
+
+	if neg {
+		amount = -amount
+	}
+
+	return New(amount, currency), nil
+}
+
+// MustParse parses a decimal amount in the currency. If an error occurs the
+// function panics.
+func MustParse(value string, currency Currency) Money {
+	m, err := Parse(value, currency)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// Amount returns the amount in minor units.
+func (m Money) Amount() int64 {
+	return m.amount
+}
+
+// Currency returns the currency of the amount.
+func (m Money) Currency() Currency {
+	return m.currency
+}
+
+// IsZero reports whether the amount is zero.
+func (m Money) IsZero() bool {
+	return m.amount == 0
+}
+
+// IsNegative reports whether the amount is less than zero.
+func (m Money) IsNegative() bool {
+	return m.amount < 0
+}
+
+// Add returns the sum of the amounts. They must be in the same currency.
+// ErrOverflow is returned when the sum doesn't fit in the minor units an
+// amount can hold.
+func (m Money) Add(m2 Money) (Money, error) {
+	cur, err := m.common(m2)
+	if err != nil {
+		return Money{}, err
+	}
+
+	switch {
+	case m2.amount > 0 && m.amount > math.MaxInt64-m2.amount,
+		m2.amount < 0 && m.amount < math.MinInt64-m2.amount:
+		return Money{}, fmt.Errorf("%w: %s + %s", ErrOverflow, m, m2)
+	}
+
+	return New(m.amount+m2.amount, cur), nil
+}
+
+// Sub returns the difference of the amounts. They must be in the same
+// currency. ErrOverflow is returned when the difference doesn't fit in the
+// minor units an amount can hold.
+func (m Money) Sub(m2 Money) (Money, error) {
+	cur, err := m.common(m2)
+	if err != nil {
+		return Money{}, err
+	}
+
+	switch {
+	case m2.amount < 0 && m.amount > math.MaxInt64+m2.amount,
+		m2.amount > 0 && m.amount < math.MinInt64+m2.amount:
+		return Money{}, fmt.Errorf("%w: %s - %s", ErrOverflow, m, m2)
+	}
+
+	return New(m.amount-m2.amount, cur), nil
+}
+
+// Mul returns the amount multiplied by a quantity. ErrOverflow is returned
+// when the result doesn't fit in the minor units an amount can hold.
+func (m Money) Mul(quantity int64) (Money, error) {
+	amount := m.amount * quantity
+
+	switch {
+	case m.amount == -1 && quantity == math.MinInt64,
+		m.amount != 0 && amount/m.amount != quantity:
+		return Money{}, fmt.Errorf("%w: %s * %d", ErrOverflow, m, quantity)
+	}
+
+	return New(amount, m.currency), nil
+}
+
+// Neg returns the amount with the opposite sign.
+func (m Money) Neg() Money {
+	return New(-m.amount, m.currency)
+}
+
+// Cmp compares the amounts and returns -1, 0 or +1. They must be in the same
+// currency.
+func (m Money) Cmp(m2 Money) (int, error) {
+	if _, err := m.common(m2); err != nil {
+		return 0, err
+	}
+
+	switch {
+	case m.amount < m2.amount:
+		return -1, nil
+	case m.amount > m2.amount:
+		return 1, nil
+	default:
+		return 0, nil
+	}
+}
+
+// String returns the amount as a decimal number without the currency.
+func (m Money) String() string {
+	amount := m.amount
+
+	var sign string
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	s := strconv.FormatInt(amount, 10)
+	if m.currency.digits == 0 {
+		return sign + s
+	}
+
+	if pad := m.currency.digits + 1 - len(s); pad > 0 {
+		s = strings.Repeat("0", pad) + s
+	}
+
+	cut := len(s) - m.currency.digits
+	return sign + s[:cut] + "." + s[cut:]
+}
+
+// Equal provides support for the go-cmp package and testing.
+func (m Money) Equal(m2 Money) bool {
+	return m.amount == m2.amount && m.currency == m2.currency
+}
+
+// jsonMoney is the JSON form of an amount. The amount is encoded as a
+// string so clients don't read it into a floating point number.
+type jsonMoney struct {
+	Amount   string `json:"amount"`
+	Currency string `json:"currency,omitempty"`
+}
+
+// MarshalJSON implements the json.Marshaler interface. Amounts are encoded
+// as an object holding the amount and its currency.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonMoney{
+		Amount:   m.String(),
+		Currency: m.currency.code,
+	})
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface. Besides the
+// object form, a bare string or number is accepted for the amount. An amount
+// without a currency keeps the currency it has if it has one and uses the
+// default currency otherwise.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	cur := m.currencyOrDefault()
+
+	var s string
+	switch {
+	case len(data) > 0 && data[0] == '{':
+		var jm jsonMoney
+		if err := json.Unmarshal(data, &jm); err != nil {
+			return fmt.Errorf("%w: %s", ErrInvalidAmount, data)
+		}
+
+		if jm.Currency != "" {
+			var err error
+			if cur, err = ParseCurrency(jm.Currency); err != nil {
+				return err
+			}
+		}
+		s = jm.Amount
+
+	default:
+		if err := json.Unmarshal(data, &s); err != nil {
+			var n json.Number
+			if err := json.Unmarshal(data, &n); err != nil {
+				return fmt.Errorf("%w: %s", ErrInvalidAmount, data)
+			}
+			s = n.String()
+		}
+	}
+
+	parsed, err := Parse(s, cur)
+	if err != nil {
+		return err
+	}
+
+	*m = parsed
+	return nil
+}
+
+// Scan implements the sql.Scanner interface for NUMERIC columns. The amount
+// keeps its currency if it has one and uses the default currency otherwise.
+func (m *Money) Scan(src any) error {
+	var s string
+	switch v := src.(type) {
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	case int64:
+		s = strconv.FormatInt(v, 10)
+	case float64:
+		s = strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Errorf("%w: can't scan %T", ErrInvalidAmount, src)
+	}
+
+	parsed, err := Parse(s, m.currencyOrDefault())
+	if err != nil {
+		return err
+	}
+
+	*m = parsed
+	return nil
+}
+
+// Value implements the driver.Valuer interface for NUMERIC columns.
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
+
+// =============================================================================
+
+// common returns the currency two amounts share. The zero value takes the
+// currency of the other amount.
+func (m Money) common(m2 Money) (Currency, error) {
+	switch {
+	case m.currency == m2.currency:
+		return m.currency, nil
+	case m == Money{}:
+		return m2.currency, nil
+	case m2 == Money{}:
+		return m.currency, nil
+	default:
+		return Currency{}, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.currency.code, m2.currency.code)
+	}
+}
+
+func (m Money) currencyOrDefault() Currency {
+	if m.currency == (Currency{}) {
+		return DefaultCurrency
+	}
+	return m.currency
+}
@@ -0,0 +1,281 @@
+package money_test
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/aleury/service/business/data/money"
+)
+
+func Test_Money(t *testing.T) {
+	t.Run("parse", parse)
+	t.Run("arithmetic", arithmetic)
+	t.Run("encoding", encoding)
+	t.Run("conversion", conversion)
+	t.Run("percent", percent)
+}
+
+func parse(t *testing.T) {
+	tests := []struct {
+		value    string
+		currency money.Currency
+		minor    int64
+		str      string
+	}{
+		{"12.34", money.USD, 1234, "12.34"},
+		{"12.3", money.USD, 1230, "12.30"},
+		{"12", money.USD, 1200, "12.00"},
+		{"-0.05", money.USD, -5, "-0.05"},
+		{".5", money.EUR, 50, "0.50"},
+		{"10.00", money.USD, 1000, "10.00"},
+		{"1500.00", money.JPY, 1500, "1500"},
+	}
+
+	for _, tt := range tests {
+		m, err := money.Parse(tt.value, tt.currency)
+		if err != nil {
+			t.Fatalf("Should be able to parse %q: %s.", tt.value, err)
+		}
+
+		if m.Amount() != tt.minor {
+			t.Errorf("Should parse %q to %d minor units: got %d", tt.value, tt.minor, m.Amount())
+		}
+
+		if m.String() != tt.str {
+			t.Errorf("Should format %q as %q: got %q", tt.value, tt.str, m.String())
+		}
+	}
+
+	for _, value := range []string{"", "abc", "1.2.3", "1.234", "--1", "1e3"} {
+		if _, err := money.Parse(value, money.USD); !errors.Is(err, money.ErrInvalidAmount) {
+			t.Errorf("Should NOT be able to parse %q: %v.", value, err)
+		}
+	}
+}
+
+func arithmetic(t *testing.T) {
+	// Summing these as float64 gives 0.30000000000000004.
+	a := money.MustParse("0.10", money.USD)
+	b := money.MustParse("0.20", money.USD)
+
+	sum, err := a.Add(b)
+	if err != nil || sum.String() != "0.30" {
+		t.Errorf("Should add amounts exactly: got %s %v", sum, err)
+	}
+
+	var total money.Money
+	if total, err = total.Add(sum); err != nil || !total.Equal(sum) {
+		t.Errorf("Should add to the zero value: got %s %v", total, err)
+	}
+
+	if diff, err := a.Sub(b); err != nil || diff.String() != "-0.10" {
+		t.Errorf("Should subtract amounts: got %s %v", diff, err)
+	}
+
+	if m, err := money.MustParse("19.99", money.USD).Mul(3); err != nil || m.String() != "59.97" {
+		t.Errorf("Should multiply amounts: got %s %v", m, err)
+	}
+
+	overflows := []struct {
+		minor    int64
+		quantity int64
+	}{
+		{math.MaxInt64, 2},
+		{math.MinInt64, -1},
+		{-1, math.MinInt64},
+		{1 << 32, 1 << 32},
+	}
+
+	for _, tt := range overflows {
+		if _, err := money.New(tt.minor, money.USD).Mul(tt.quantity); !errors.Is(err, money.ErrOverflow) {
+			t.Errorf("Should NOT multiply %d by %d: %v.", tt.minor, tt.quantity, err)
+		}
+	}
+
+	if m, err := money.New(math.MinInt64, money.USD).Mul(1); err != nil || m.Amount() != math.MinInt64 {
+		t.Errorf("Should multiply the smallest amount by one: got %d %v", m.Amount(), err)
+	}
+
+	largest := money.New(math.MaxInt64, money.USD)
+	smallest := money.New(math.MinInt64, money.USD)
+	one := money.New(1, money.USD)
+
+	if _, err := largest.Add(one); !errors.Is(err, money.ErrOverflow) {
+		t.Errorf("Should NOT add past the largest amount: %v.", err)
+	}
+
+	if _, err := smallest.Add(one.Neg()); !errors.Is(err, money.ErrOverflow) {
+		t.Errorf("Should NOT add past the smallest amount: %v.", err)
+	}
+
+	if _, err := smallest.Sub(one); !errors.Is(err, money.ErrOverflow) {
+		t.Errorf("Should NOT subtract past the smallest amount: %v.", err)
+	}
+
+	if _, err := money.Zero(money.USD).Sub(smallest); !errors.Is(err, money.ErrOverflow) {
+		t.Errorf("Should NOT subtract past the largest amount: %v.", err)
+	}
+
+	if m, err := largest.Sub(largest); err != nil || !m.IsZero() {
+		t.Errorf("Should subtract the largest amount from itself: got %s %v", m, err)
+	}
+
+	if m, err := smallest.Add(largest); err != nil || m.Amount() != -1 {
+		t.Errorf("Should add the largest amount to the smallest: got %s %v", m, err)
+	}
+
+	if c, err := a.Cmp(b); err != nil || c != -1 {
+		t.Errorf("Should compare amounts: got %d %v", c, err)
+	}
+
+	eur := money.MustParse("1.00", money.EUR)
+	if _, err := a.Add(eur); !errors.Is(err, money.ErrCurrencyMismatch) {
+		t.Errorf("Should NOT add amounts in different currencies: %v.", err)
+	}
+
+	if _, err := a.Cmp(eur); !errors.Is(err, money.ErrCurrencyMismatch) {
+		t.Errorf("Should NOT compare amounts in different currencies: %v.", err)
+	}
+}
+
+func encoding(t *testing.T) {
+	m := money.MustParse("1234.50", money.USD)
+
+	data, err := json.Marshal(m)
+	if err != nil || string(data) != `{"amount":"1234.50","currency":"USD"}` {
+		t.Errorf("Should encode amounts with their currency: got %s %v", data, err)
+	}
+
+	for _, in := range []string{`"1234.50"`, `1234.5`, `{"amount":"1234.50","currency":"USD"}`, `{"amount":"1234.5"}`} {
+		var got money.Money
+		if err := json.Unmarshal([]byte(in), &got); err != nil || !got.Equal(m) {
+			t.Errorf("Should decode %s: got %s %v", in, got, err)
+		}
+	}
+
+	eur := money.MustParse("1234.50", money.EUR)
+
+	var got money.Money
+	if err := json.Unmarshal([]byte(`{"amount":"1234.50","currency":"EUR"}`), &got); err != nil || !got.Equal(eur) {
+		t.Errorf("Should decode the currency of the amount: got %s %s %v", got, got.Currency().Code(), err)
+	}
+
+	if data, err := json.Marshal(eur); err != nil {
+		t.Errorf("Should encode amounts: %v.", err)
+	} else if err := json.Unmarshal(data, &got); err != nil || !got.Equal(eur) {
+		t.Errorf("Should round trip amounts with their currency: got %s %s %v", got, got.Currency().Code(), err)
+	}
+
+	for _, in := range []string{`{"amount":"1.00","currency":"XXX"}`, `{"amount":"1.001","currency":"USD"}`, `{"amount":1}`} {
+		if err := json.Unmarshal([]byte(in), &got); err == nil {
+			t.Errorf("Should NOT decode %s.", in)
+		}
+	}
+
+	var scanned money.Money
+	if err := scanned.Scan([]byte("1234.50")); err != nil || !scanned.Equal(m) {
+		t.Errorf("Should scan NUMERIC values: got %s %v", scanned, err)
+	}
+
+	if v, err := m.Value(); err != nil || v != "1234.50" {
+		t.Errorf("Should store amounts as decimals: got %v %v", v, err)
+	}
+}
+
+func conversion(t *testing.T) {
+	tests := []struct {
+		amount string
+		from   money.Currency
+		to     money.Currency
+		rate   string
+		want   string
+	}{
+		{"10.00", money.USD, money.EUR, "0.92", "9.20"},
+		{"19.99", money.USD, money.GBP, "0.7891", "15.77"},
+		{"0.01", money.USD, money.EUR, "0.5", "0.01"},
+		{"-0.01", money.USD, money.EUR, "0.5", "-0.01"},
+		{"12.34", money.USD, money.JPY, "149.5", "1845"},
+		{"1500", money.JPY, money.USD, "0.0067", "10.05"},
+	}
+
+	for _, tt := range tests {
+		m := money.MustParse(tt.amount, tt.from)
+
+		got, err := m.Convert(tt.to, money.MustParseRate(tt.rate))
+		if err != nil {
+			t.Errorf("Should be able to convert %s %s: %s.", tt.amount, tt.from.Code(), err)
+			continue
+		}
+
+		if got.String() != tt.want || !got.Currency().Equal(tt.to) {
+			t.Errorf("Should convert %s %s at %s to %s %s: got %s %s", tt.amount, tt.from.Code(), tt.rate, tt.want, tt.to.Code(), got, got.Currency().Code())
+		}
+	}
+
+	if _, err := money.New(math.MaxInt64, money.USD).Convert(money.JPY, money.MustParseRate("150")); !errors.Is(err, money.ErrOverflow) {
+		t.Errorf("Should NOT convert an amount that overflows: %v.", err)
+	}
+
+	if r := money.MustParseRate("1.25000000"); r.String() != "1.25" {
+		t.Errorf("Should format rates without trailing zeros: got %s", r)
+	}
+
+	for _, value := range []string{"0", "-1", "0.000000001", "abc"} {
+		if _, err := money.ParseRate(value); !errors.Is(err, money.ErrInvalidRate) {
+			t.Errorf("Should NOT be able to parse rate %q: %v.", value, err)
+		}
+	}
+}
+
+func percent(t *testing.T) {
+	tests := []struct {
+		amount   string
+		percent  string
+		rounding money.Rounding
+		added    string
+		included string
+	}{
+		{"100.00", "10", money.RoundHalfUp, "10.00", "9.09"},
+		{"19.99", "8.875", money.RoundHalfUp, "1.77", "1.63"},
+		{"19.99", "8.875", money.RoundDown, "1.77", "1.62"},
+		{"19.99", "8.875", money.RoundUp, "1.78", "1.63"},
+		{"0.25", "10", money.RoundHalfUp, "0.03", "0.02"},
+		{"0.25", "10", money.RoundHalfEven, "0.02", "0.02"},
+		{"0.35", "10", money.RoundHalfEven, "0.04", "0.03"},
+		{"-0.25", "10", money.RoundHalfUp, "-0.03", "-0.02"},
+		{"50.00", "0", money.RoundUp, "0.00", "0.00"},
+	}
+
+	for _, tt := range tests {
+		m := money.MustParse(tt.amount, money.USD)
+		p := money.MustParsePercent(tt.percent)
+
+		if got, err := m.Percent(p, tt.rounding); err != nil || got.String() != tt.added {
+			t.Errorf("Should take %s%% of %s rounding %s as %s: got %s %v", tt.percent, tt.amount, tt.rounding.Name(), tt.added, got, err)
+		}
+
+		if got := m.PercentIncluded(p, tt.rounding); got.String() != tt.included {
+			t.Errorf("Should find %s%% included in %s rounding %s as %s: got %s", tt.percent, tt.amount, tt.rounding.Name(), tt.included, got)
+		}
+	}
+
+	if _, err := money.New(math.MaxInt64, money.USD).Percent(money.MustParsePercent("200"), money.RoundHalfUp); !errors.Is(err, money.ErrOverflow) {
+		t.Errorf("Should NOT take a percentage that overflows: %v.", err)
+	}
+
+	if p := money.MustParsePercent("8.8750"); p.String() != "8.875" {
+		t.Errorf("Should format percentages without trailing zeros: got %s", p)
+	}
+
+	if p := money.MustParsePercent("20"); p.String() != "20" {
+		t.Errorf("Should format whole percentages without a decimal point: got %s", p)
+	}
+
+	for _, value := range []string{"-1", "0.00001", "abc"} {
+		if _, err := money.ParsePercent(value); !errors.Is(err, money.ErrInvalidPercent) {
+			t.Errorf("Should NOT be able to parse percent %q: %v.", value, err)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package money
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidPercent is returned when a percentage can't be parsed or is
+// negative.
+var ErrInvalidPercent = errors.New("invalid percent")
+
+// percentDigits is the number of decimal places a percentage is kept to,
+// which matches the NUMERIC(7, 4) column it is stored in.
+const percentDigits = 4
+
+// Percent represents a percentage such as a tax rate of 8.875. It is kept as
+// a whole number of ten thousandths of a percent so calculations are exact up
+// to the final rounding.
+type Percent struct {
+	value int64
+}
+
+// ParsePercent parses a decimal percentage such as "8.875". Zero is a valid
+// percentage, negative ones are not.
+func ParsePercent(value string) (Percent, error) {
+	cur := Currency{digits: percentDigits}
+
+	m, err := Parse(value, cur)
+	if err != nil {
+		return Percent{}, fmt.Errorf("%w: %q", ErrInvalidPercent, value)
+	}
+
+	if m.amount < 0 {
+		return Percent{}, fmt.Errorf("%w: %q must be 0 or greater", ErrInvalidPercent, value)
+	}
+
+	return Percent{value: m.amount}, nil
+}
+
+// MustParsePercent parses a decimal percentage. If an error occurs the
+// function panics.
+func MustParsePercent(value string) Percent {
+	p, err := ParsePercent(value)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// IsZero reports whether the percentage is zero.
+func (p Percent) IsZero() bool {
+	return p.value == 0
+}
+
+// String returns the percentage as a decimal number without trailing zeros.
+func (p Percent) String() string {
+	s := New(p.value, Currency{digits: percentDigits}).String()
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+// Equal provides support for the go-cmp package and testing.
+func (p Percent) Equal(p2 Percent) bool {
+	return p.value == p2.value
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (p Percent) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.String())
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface. Both strings and
+// numbers are accepted.
+func (p *Percent) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		var n json.Number
+		if err := json.Unmarshal(data, &n); err != nil {
+			return fmt.Errorf("%w: %s", ErrInvalidPercent, data)
+		}
+		s = n.String()
+	}
+
+	parsed, err := ParsePercent(s)
+	if err != nil {
+		return err
+	}
+
+	*p = parsed
+	return nil
+}
+
+// Scan implements the sql.Scanner interface for NUMERIC columns.
+func (p *Percent) Scan(src any) error {
+	var s string
+	switch v := src.(type) {
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	case int64:
+		s = strconv.FormatInt(v, 10)
+	case float64:
+		s = strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Errorf("%w: can't scan %T", ErrInvalidPercent, src)
+	}
+
+	parsed, err := ParsePercent(s)
+	if err != nil {
+		return err
+	}
+
+	*p = parsed
+	return nil
+}
+
+// Value implements the driver.Valuer interface for NUMERIC columns.
+func (p Percent) Value() (driver.Value, error) {
+	return p.String(), nil
+}
+
+// Percent returns the percentage of the amount, as for the tax added on top
+// of a price. The result is rounded to the minor unit with the rounding.
+// ErrOverflow is returned when the result doesn't fit in the minor units an
+// amount can hold.
+func (m Money) Percent(p Percent, r Rounding) (Money, error) {
+	num := big.NewInt(m.amount)
+	num.Mul(num, big.NewInt(p.value))
+
+	den := big.NewInt(100 * pow10(percentDigits))
+
+	amount, err := divide(num, den, r)
+	if err != nil {
+		return Money{}, fmt.Errorf("%s%% of %s: %w", p, m, err)
+	}
+
+	return New(amount, m.currency), nil
+}
+
+// PercentIncluded returns the part of the amount that is the percentage of
+// the rest, as for the tax included in a price. The result is rounded to the
+// minor unit with the rounding. It is never larger than the amount itself.
+func (m Money) PercentIncluded(p Percent, r Rounding) Money {
+	num := big.NewInt(m.amount)
+	num.Mul(num, big.NewInt(p.value))
+
+	den := big.NewInt(100*pow10(percentDigits) + p.value)
+
+	amount, _ := divide(num, den, r)
+
+	return New(amount, m.currency)
+}
@@ -0,0 +1,156 @@
+package money
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidRate is returned when an exchange rate can't be parsed or isn't
+// positive.
+var ErrInvalidRate = errors.New("invalid rate")
+
+// rateDigits is the number of decimal places a rate is kept to, which
+// matches the NUMERIC(18, 8) column it is stored in.
+const rateDigits = 8
+
+// Rate represents an exchange rate, the number of units of one currency a
+// unit of another buys. It is kept as a whole number of hundred millionths so
+// conversions are exact up to the final rounding.
+type Rate struct {
+	value int64
+}
+
+// One is the rate between a currency and itself.
+var One = Rate{value: pow10(rateDigits)}
+
+// ParseRate parses a positive decimal rate such as "0.92".
+func ParseRate(value string) (Rate, error) {
+	cur := Currency{digits: rateDigits}
+
+	m, err := Parse(value, cur)
+	if err != nil {
+		return Rate{}, fmt.Errorf("%w: %q", ErrInvalidRate, value)
+	}
+
+	if m.amount <= 0 {
+		return Rate{}, fmt.Errorf("%w: %q must be greater than 0", ErrInvalidRate, value)
+	}
+
+	return Rate{value: m.amount}, nil
+}
+
+// MustParseRate parses a positive decimal rate. If an error occurs the
+// function panics.
+func MustParseRate(value string) Rate {
+	r, err := ParseRate(value)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// IsZero reports whether the rate is unset.
+func (r Rate) IsZero() bool {
+	return r.value == 0
+}
+
+// String returns the rate as a decimal number without trailing zeros.
+func (r Rate) String() string {
+	s := New(r.value, Currency{digits: rateDigits}).String()
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+// Equal provides support for the go-cmp package and testing.
+func (r Rate) Equal(r2 Rate) bool {
+	return r.value == r2.value
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (r Rate) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.String())
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface. Both strings and
+// numbers are accepted.
+func (r *Rate) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		var n json.Number
+		if err := json.Unmarshal(data, &n); err != nil {
+			return fmt.Errorf("%w: %s", ErrInvalidRate, data)
+		}
+		s = n.String()
+	}
+
+	parsed, err := ParseRate(s)
+	if err != nil {
+		return err
+	}
+
+	*r = parsed
+	return nil
+}
+
+// Scan implements the sql.Scanner interface for NUMERIC columns.
+func (r *Rate) Scan(src any) error {
+	var s string
+	switch v := src.(type) {
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	case int64:
+		s = strconv.FormatInt(v, 10)
+	case float64:
+		s = strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Errorf("%w: can't scan %T", ErrInvalidRate, src)
+	}
+
+	parsed, err := ParseRate(s)
+	if err != nil {
+		return err
+	}
+
+	*r = parsed
+	return nil
+}
+
+// Value implements the driver.Valuer interface for NUMERIC columns.
+func (r Rate) Value() (driver.Value, error) {
+	return r.String(), nil
+}
+
+// Convert returns the amount in another currency at the rate. The result is
+// rounded half away from zero to the minor unit of that currency. ErrOverflow
+// is returned when the result doesn't fit in the minor units an amount can
+// hold.
+func (m Money) Convert(to Currency, rate Rate) (Money, error) {
+	num := big.NewInt(m.amount)
+	num.Mul(num, big.NewInt(rate.value))
+	num.Mul(num, big.NewInt(pow10(to.digits)))
+
+	den := big.NewInt(pow10(rateDigits))
+	den.Mul(den, big.NewInt(pow10(m.currency.digits)))
+
+	amount, err := divide(num, den, RoundHalfUp)
+	if err != nil {
+		return Money{}, fmt.Errorf("%s %s at %s: %w", m, m.currency.code, rate, err)
+	}
+
+	return New(amount, to), nil
+}
+
+func pow10(n int) int64 {
+	p := int64(1)
+	for i := 0; i < n; i++ {
+		p *= 10
+	}
+	return p
+}
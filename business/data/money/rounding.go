@@ -0,0 +1,110 @@
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// Set of ways an amount is rounded to the minor unit of its currency.
+var (
+	RoundHalfUp   = Rounding{"HALF_UP"}
+	RoundHalfEven = Rounding{"HALF_EVEN"}
+	RoundUp       = Rounding{"UP"}
+	RoundDown     = Rounding{"DOWN"}
+)
+
+// Set of known roundings.
+var roundings = map[string]Rounding{
+	RoundHalfUp.name:   RoundHalfUp,
+	RoundHalfEven.name: RoundHalfEven,
+	RoundUp.name:       RoundUp,
+	RoundDown.name:     RoundDown,
+}
+
+// Rounding represents how a result that falls between two minor units is
+// rounded. Halves are rounded away from zero by HALF_UP and to the even unit
+// by HALF_EVEN. UP rounds away from zero and DOWN towards it.
+type Rounding struct {
+	name string
+}
+
+// ParseRounding parses the string value and returns a rounding if one
+// exists.
+func ParseRounding(value string) (Rounding, error) {
+	r, exists := roundings[value]
+	if !exists {
+		return Rounding{}, errors.New("invalid rounding")
+	}
+	return r, nil
+}
+
+// MustParseRounding parses the string value and returns a rounding if one
+// exists. If an error occurs the function panics.
+func MustParseRounding(value string) Rounding {
+	r, err := ParseRounding(value)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// Name returns the name of the rounding.
+func (r Rounding) Name() string {
+	return r.name
+}
+
+// UnmarshalText implements the unmarshal interface for JSON conversions.
+func (r *Rounding) UnmarshalText(data []byte) error {
+	r.name = string(data)
+	return nil
+}
+
+// MarshalText implements the marshal interface for JSON conversions.
+func (r Rounding) MarshalText() ([]byte, error) {
+	return []byte(r.name), nil
+}
+
+// Equal provides support for the go-cmp package and testing.
+func (r Rounding) Equal(r2 Rounding) bool {
+	return r.name == r2.name
+}
+
+// divide returns num / den rounded to a whole number with the rounding. The
+// denominator has to be positive. ErrOverflow is returned when the result
+// doesn't fit in the minor units an amount can hold.
+func divide(num *big.Int, den *big.Int, r Rounding) (int64, error) {
+	quo, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+	if rem.Sign() == 0 {
+		return minor(quo)
+	}
+
+	twice := new(big.Int).Mul(new(big.Int).Abs(rem), big.NewInt(2))
+
+	var away bool
+	switch r {
+	case RoundUp:
+		away = true
+	case RoundDown:
+		away = false
+	case RoundHalfEven:
+		cmp := twice.Cmp(den)
+		away = cmp > 0 || (cmp == 0 && quo.Bit(0) == 1)
+	default:
+		away = twice.Cmp(den) >= 0
+	}
+
+	if away {
+		quo.Add(quo, big.NewInt(int64(num.Sign())))
+	}
+
+	return minor(quo)
+}
+
+// minor returns the whole number as a number of minor units.
+func minor(n *big.Int) (int64, error) {
+	if !n.IsInt64() {
+		return 0, fmt.Errorf("%w: %s minor units", ErrOverflow, n)
+	}
+	return n.Int64(), nil
+}
@@ -0,0 +1,27 @@
+// Package dbheadline provides support for highlighting search results with
+// the ts_headline function without trusting the text being highlighted.
+package dbheadline
+
+import (
+	"html"
+	"strings"
+)
+
+// Markers ts_headline puts around the matching words. They are control
+// characters so they can't be confused with the markup of the snippet.
+const (
+	startSel = "\x02"
+	stopSel  = "\x03"
+)
+
+// Options are the ts_headline options that mark the matching words of every
+// fragment with the markers Mark replaces.
+const Options = "StartSel=" + startSel + ", StopSel=" + stopSel + ", HighlightAll=true"
+
+// Mark escapes the HTML of a snippet produced with Options and wraps its
+// matching words in <mark> elements, so the snippet is safe to render as
+// HTML whatever the highlighted text contained.
+func Mark(snippet string) string {
+	r := strings.NewReplacer(startSel, "<mark>", stopSel, "</mark>")
+	return r.Replace(html.EscapeString(snippet))
+}
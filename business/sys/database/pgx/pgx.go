@@ -18,14 +18,16 @@ import (
 )
 
 const (
-	uniqueViolation = "23505"
-	undefinedTable  = "42P01"
+	uniqueViolation     = "23505"
+	foreignKeyViolation = "23503"
+	undefinedTable      = "42P01"
 )
 
 // Set of error variables for CRUD operations.
 var (
 	ErrDBNotFound        = sql.ErrNoRows
 	ErrDBDuplicatedEntry = errors.New("duplicated entry")
+	ErrDBForeignKey      = errors.New("foreign key violation")
 	ErrUndefinedTable    = errors.New("undefined table")
 )
 
@@ -105,8 +107,49 @@ func StatusCheck(ctx context.Context, db *sqlx.DB) error {
 	return db.QueryRowContext(ctx, q).Scan(&tmp)
 }
 
-// WithinTran runs passed functin and do commit/rollback at the end.
-func WithinTran(ctx context.Context, log *zap.SugaredLogger, db *sqlx.DB, fn func(*sqlx.Tx) error) error {
+// ctxKey represents the type of value for the context key.
+type ctxKey int
+
+// txKey is used to store/retrieve a transaction from a context.Context.
+const txKey ctxKey = 1
+
+// sessionKey is used to store/retrieve a session from a context.Context.
+const sessionKey ctxKey = 2
+
+// sessionRole is the database role request scoped work runs as. It is created
+// by the migrations and, unlike the role used to connect, is subject to row
+// level security.
+const sessionRole = "sales_api"
+
+// Session represents the identity a request is made with. The database uses
+// it through row level security policies to decide which rows are visible.
+type Session struct {
+	UserID   string
+	TenantID string
+	Roles    []string
+}
+
+// SetSession stores the session in the context. Every statement run with the
+// context executes inside a transaction that carries the session.
+func SetSession(ctx context.Context, s Session) context.Context {
+	return context.WithValue(ctx, sessionKey, s)
+}
+
+// GetSession returns the session from the context.
+func GetSession(ctx context.Context) (Session, bool) {
+	s, ok := ctx.Value(sessionKey).(Session)
+	return s, ok
+}
+
+// WithinTran runs passed function and do commit/rollback at the end. The
+// transaction is bound to the context handed to the function so every call
+// made through this package with that context joins the transaction. If the
+// context already carries a transaction, the function simply joins it.
+func WithinTran(ctx context.Context, log *zap.SugaredLogger, db *sqlx.DB, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey).(*sqlx.Tx); ok {
+		return fn(ctx)
+	}
+
 	traceID := web.GetTraceID(ctx)
 
 	log.Infow("begin tran")
@@ -127,7 +170,13 @@ func WithinTran(ctx context.Context, log *zap.SugaredLogger, db *sqlx.DB, fn fun
 		log.Infow("rollback tran", "trace_id", traceID)
 	}()
 
-	if err := fn(tx); err != nil {
+	if s, ok := GetSession(ctx); ok {
+		if err := applySession(ctx, tx, s); err != nil {
+			return fmt.Errorf("apply session: %w", err)
+		}
+	}
+
+	if err := fn(context.WithValue(ctx, txKey, tx)); err != nil {
 		if pqerr, ok := err.(*pgconn.PgError); ok && pqerr.Code == uniqueViolation {
 			return ErrDBDuplicatedEntry
 		}
@@ -142,6 +191,59 @@ func WithinTran(ctx context.Context, log *zap.SugaredLogger, db *sqlx.DB, fn fun
 	return nil
 }
 
+// applySession sets the session variables read by the row level security
+// policies and switches to the role the policies apply to. Both only last
+// until the end of the transaction.
+func applySession(ctx context.Context, tx *sqlx.Tx, s Session) error {
+	const q = `
+	SELECT
+		set_config('app.user_id', $1, true),
+		set_config('app.tenant_id', $2, true),
+		set_config('app.roles', $3, true)`
+
+	if _, err := tx.ExecContext(ctx, q, s.UserID, s.TenantID, strings.Join(s.Roles, ",")); err != nil {
+		return fmt.Errorf("set config: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, "SET LOCAL ROLE "+sessionRole); err != nil {
+		return fmt.Errorf("set role: %w", err)
+	}
+
+	return nil
+}
+
+// within executes the function against the transaction bound to the context
+// if one exists. A context that carries a session but no transaction gets a
+// transaction of its own so the session applies to the statement. Otherwise
+// the provided database connection is used.
+func within(ctx context.Context, db sqlx.ExtContext, fn func(db sqlx.ExtContext) error) error {
+	if tx, ok := ctx.Value(txKey).(*sqlx.Tx); ok {
+		return fn(tx)
+	}
+
+	s, ok := GetSession(ctx)
+	sqlxDB, isDB := db.(*sqlx.DB)
+	if !ok || !isDB {
+		return fn(db)
+	}
+
+	tx, err := sqlxDB.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tran: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := applySession(ctx, tx, s); err != nil {
+		return fmt.Errorf("apply session: %w", err)
+	}
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
 // ExecContext is a helper function to execute a CUD operation with
 // logging and tracing.
 func ExecContext(ctx context.Context, log *zap.SugaredLogger, db sqlx.ExtContext, query string) error {
@@ -159,13 +261,19 @@ func NamedExecContext(ctx context.Context, log *zap.SugaredLogger, db sqlx.ExtCo
 		log.WithOptions(zap.AddCallerSkip(2)).Infow("database.NamedExecContext", "trace_id", web.GetTraceID(ctx), "query", q)
 	}
 
-	if _, err := sqlx.NamedExecContext(ctx, db, query, data); err != nil {
+	err := within(ctx, db, func(db sqlx.ExtContext) error {
+		_, err := sqlx.NamedExecContext(ctx, db, query, data)
+		return err
+	})
+	if err != nil {
 		if pqerr, ok := err.(*pgconn.PgError); ok {
 			switch pqerr.Code {
 			case undefinedTable:
 				return ErrUndefinedTable
 			case uniqueViolation:
 				return ErrDBDuplicatedEntry
+			case foreignKeyViolation:
+				return ErrDBForeignKey
 			}
 		}
 		return err
@@ -199,6 +307,12 @@ func namedQuerySlice[T any](ctx context.Context, log *zap.SugaredLogger, db sqlx
 
 	log.WithOptions(zap.AddCallerSkip(3)).Infow("database.NamedQuerySlice", "trace_id", web.GetTraceID(ctx), "query", q)
 
+	return within(ctx, db, func(db sqlx.ExtContext) error {
+		return querySlice(ctx, db, query, data, dest, withIn)
+	})
+}
+
+func querySlice[T any](ctx context.Context, db sqlx.ExtContext, query string, data any, dest *[]T, withIn bool) error {
 	var rows *sqlx.Rows
 	var err error
 
@@ -268,6 +382,12 @@ func namedQueryStruct(ctx context.Context, log *zap.SugaredLogger, db sqlx.ExtCo
 
 	log.WithOptions(zap.AddCallerSkip(3)).Infow("database.NamedQueryStruct", "trace_id", web.GetTraceID(ctx), "query", q)
 
+	return within(ctx, db, func(db sqlx.ExtContext) error {
+		return queryStruct(ctx, db, query, data, dest, withIn)
+	})
+}
+
+func queryStruct(ctx context.Context, db sqlx.ExtContext, query string, data any, dest any, withIn bool) error {
 	var rows *sqlx.Rows
 	var err error
 
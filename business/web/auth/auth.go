@@ -12,6 +12,7 @@ import (
 
 	"github.com/aleury/service/business/core/user"
 	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
 	"github.com/open-policy-agent/opa/rego"
 	"go.uber.org/zap"
 )
@@ -22,7 +23,18 @@ var ErrForbidden = errors.New("attempted action is not allowed")
 // Claims represents the authorization claims transmitted via a JWT.
 type Claims struct {
 	jwt.RegisteredClaims
-	Roles []user.Role `json:"roles"`
+	Roles  []user.Role `json:"roles"`
+	Tenant uuid.UUID   `json:"tenant"`
+}
+
+// HasRole reports whether the specified role is present in the claims.
+func (c Claims) HasRole(role user.Role) bool {
+	for _, r := range c.Roles {
+		if r.Equal(role) {
+			return true
+		}
+	}
+	return false
 }
 
 // KeyLookup declares a method set of behavior for looking up
@@ -136,10 +148,19 @@ func (a *Auth) Authenticate(ctx context.Context, bearerToken string) (Claims, er
 // none of the input roles are within the user's claimsk, we return an error
 // otherwise the user is authorized.
 func (a *Auth) Authorize(ctx context.Context, claims Claims, rule string) error {
+	var resourceTenant string
+	if tenantID := GetResourceTenantID(ctx); tenantID != uuid.Nil {
+		resourceTenant = tenantID.String()
+	}
+
 	input := map[string]any{
-		"Roles":   claims.Roles,
-		"Subject": claims.Subject,
-		"UserID":  claims.Subject,
+		"Roles":             claims.Roles,
+		"Subject":           claims.Subject,
+		"UserID":            GetUserID(ctx).String(),
+		"Tenant":            claims.Tenant.String(),
+		"ResourceTenant":    resourceTenant,
+		"SubjectDepartment": departmentInput(GetSubjectDepartmentID(ctx)),
+		"UserDepartment":    departmentInput(GetUserDepartmentID(ctx)),
 	}
 
 	if err := a.opaPolicyEvaluation(ctx, opaAuthorization, rule, input); err != nil {
@@ -149,8 +170,43 @@ func (a *Auth) Authorize(ctx context.Context, claims Claims, rule string) error
 	return nil
 }
 
+// AuthorizeTransition validates that the claims allow moving the resource
+// being accessed from one state to another. The owner and tenant of the
+// resource are taken from the context as they are for Authorize.
+func (a *Auth) AuthorizeTransition(ctx context.Context, claims Claims, rule string, from string, to string) error {
+	var resourceTenant string
+	if tenantID := GetResourceTenantID(ctx); tenantID != uuid.Nil {
+		resourceTenant = tenantID.String()
+	}
+
+	input := map[string]any{
+		"Roles":          claims.Roles,
+		"Subject":        claims.Subject,
+		"UserID":         GetUserID(ctx).String(),
+		"Tenant":         claims.Tenant.String(),
+		"ResourceTenant": resourceTenant,
+		"From":           from,
+		"To":             to,
+	}
+
+	if err := a.opaPolicyEvaluation(ctx, opaTransition, rule, input); err != nil {
+		return fmt.Errorf("rego evaluation failed: %w", err)
+	}
+
+	return nil
+}
+
 // =============================================================================
 
+// departmentInput converts a department id for use in a policy. Users that
+// don't belong to a department are represented by an empty string.
+func departmentInput(departmentID uuid.UUID) string {
+	if departmentID == uuid.Nil {
+		return ""
+	}
+	return departmentID.String()
+}
+
 // publicKeyLookup performs a lookup for the public pem for the specified kid.
 func (a *Auth) publicKeyLookup(kid string) (string, error) {
 	pem, err := func() (string, error) {
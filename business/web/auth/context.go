@@ -15,6 +15,18 @@ const claimKey ctxKey = 1
 // key is used to store/retrieve a user value from a context.Context.
 const userKey ctxKey = 2
 
+// key is used to store/retrieve the tenant of the resource being accessed
+// from a context.Context.
+const resourceTenantKey ctxKey = 3
+
+// key is used to store/retrieve the department of the caller from a
+// context.Context.
+const subjectDepartmentKey ctxKey = 4
+
+// key is used to store/retrieve the department of the user being accessed
+// from a context.Context.
+const userDepartmentKey ctxKey = 5
+
 // =============================================================================
 
 // SetClaims stores the claims in the context.
@@ -44,3 +56,51 @@ func GetUserID(ctx context.Context) uuid.UUID {
 	}
 	return v
 }
+
+// SetResourceTenantID stores the id of the tenant that owns the resource
+// being accessed in the context.
+func SetResourceTenantID(ctx context.Context, tenantID uuid.UUID) context.Context {
+	return context.WithValue(ctx, resourceTenantKey, tenantID)
+}
+
+// GetResourceTenantID returns the id of the tenant that owns the resource
+// being accessed.
+func GetResourceTenantID(ctx context.Context) uuid.UUID {
+	v, ok := ctx.Value(resourceTenantKey).(uuid.UUID)
+	if !ok {
+		return uuid.UUID{}
+	}
+	return v
+}
+
+// SetSubjectDepartmentID stores the id of the department the caller belongs
+// to in the context.
+func SetSubjectDepartmentID(ctx context.Context, departmentID uuid.UUID) context.Context {
+	return context.WithValue(ctx, subjectDepartmentKey, departmentID)
+}
+
+// GetSubjectDepartmentID returns the id of the department the caller belongs
+// to.
+func GetSubjectDepartmentID(ctx context.Context) uuid.UUID {
+	v, ok := ctx.Value(subjectDepartmentKey).(uuid.UUID)
+	if !ok {
+		return uuid.UUID{}
+	}
+	return v
+}
+
+// SetUserDepartmentID stores the id of the department the user being
+// accessed belongs to in the context.
+func SetUserDepartmentID(ctx context.Context, departmentID uuid.UUID) context.Context {
+	return context.WithValue(ctx, userDepartmentKey, departmentID)
+}
+
+// GetUserDepartmentID returns the id of the department the user being
+// accessed belongs to.
+func GetUserDepartmentID(ctx context.Context) uuid.UUID {
+	v, ok := ctx.Value(userDepartmentKey).(uuid.UUID)
+	if !ok {
+		return uuid.UUID{}
+	}
+	return v
+}
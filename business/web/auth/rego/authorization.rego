@@ -4,36 +4,80 @@ default ruleAny = false
 default ruleAdminOnly = false
 default ruleUserOnly = false
 default ruleAdminOrSubject = false
+default ruleAdminOrDepartmentAdmin = false
+default ruleAdminOrDepartmentAdminOrSubject = false
 
 roleUser := "USER"
 roleAdmin := "ADMIN"
-roleAll := {roleAdmin, roleUser}
+roleDepartmentAdmin := "DEPARTMENT_ADMIN"
+roleAll := {roleAdmin, roleDepartmentAdmin, roleUser}
+
+# A resource can only be accessed from within the tenant that owns it. When
+# the resource tenant is not known, the stores scope the data to the tenant.
+sameTenant {
+    input.ResourceTenant == ""
+} else {
+    input.ResourceTenant == input.Tenant
+}
 
 ruleAny {
+    sameTenant
     claim_roles := {role | role := input.Roles[_]}
     input_roles := roleAll & claim_roles
     count(input_roles) > 0
 }
 
 ruleAdminOnly {
+    sameTenant
     claim_roles := {role | role := input.Roles[_]}
     input_admin := {roleAdmin} & claim_roles
     count(input_admin) > 0
 }
 
 roleUserOnly {
+    sameTenant
     claim_roles := {role | role := input.Roles[_]}
     input_user := {roleUser} & claim_roles
     count(input_user) > 0
 }
 
 ruleAdminOrSubject {
+    sameTenant
     claim_roles := {role | role := input.Roles[_]}
     input_admin := {roleAdmin} & claim_roles
     count(input_admin) > 0 
 } else {
+    sameTenant
+    claim_roles := {role | role := input.Roles[_]}
+    input_user := {roleUser} & claim_roles
+    count(input_user) > 0
+    input.UserID == input.Subject
+}
+
+ruleAdminOrDepartmentAdmin {
+    sameTenant
+    claim_roles := {role | role := input.Roles[_]}
+    input_admin := {roleAdmin, roleDepartmentAdmin} & claim_roles
+    count(input_admin) > 0
+}
+
+# Department admins can only manage the users of their own department.
+ruleAdminOrDepartmentAdminOrSubject {
+    sameTenant
+    claim_roles := {role | role := input.Roles[_]}
+    input_admin := {roleAdmin} & claim_roles
+    count(input_admin) > 0
+} else {
+    sameTenant
+    claim_roles := {role | role := input.Roles[_]}
+    input_department_admin := {roleDepartmentAdmin} & claim_roles
+    count(input_department_admin) > 0
+    input.UserDepartment != ""
+    input.UserDepartment == input.SubjectDepartment
+} else {
+    sameTenant
     claim_roles := {role | role := input.Roles[_]}
     input_user := {roleUser} & claim_roles
     count(input_user) > 0
     input.UserID == input.Subject
-}
\ No newline at end of file
+}
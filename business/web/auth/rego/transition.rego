@@ -0,0 +1,28 @@
+package ardan.rego
+
+default ruleOrderTransition = false
+
+roleAdmin := "ADMIN"
+
+# Customers can only cancel their own orders while they are pending. Marking
+# an order paid, fulfilment and refunds are left to admins so a customer
+# can't pay for an order by saying so.
+customerOrderTransitions := {
+    {"From": "PENDING", "To": "CANCELLED"},
+}
+
+# A resource can only be accessed from within the tenant that owns it.
+sameTenant {
+    input.ResourceTenant == input.Tenant
+}
+
+ruleOrderTransition {
+    sameTenant
+    claim_roles := {role | role := input.Roles[_]}
+    input_admin := {roleAdmin} & claim_roles
+    count(input_admin) > 0
+} else {
+    sameTenant
+    input.UserID == input.Subject
+    customerOrderTransitions[{"From": input.From, "To": input.To}]
+}
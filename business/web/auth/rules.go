@@ -11,6 +11,14 @@ const (
 	RuleAdminOnly      = "ruleAdminOnly"
 	RuleUserOnly       = "ruleUserOnly"
 	RuleAdminOrSubject = "ruleAdminOrSubject"
+
+	RuleAdminOrDepartmentAdmin          = "ruleAdminOrDepartmentAdmin"
+	RuleAdminOrDepartmentAdminOrSubject = "ruleAdminOrDepartmentAdminOrSubject"
+)
+
+// These are the rules for moving resources between states.
+const (
+	RuleOrderTransition = "ruleOrderTransition"
 )
 
 // Package name of our rego code.
@@ -25,4 +33,7 @@ var (
 
 	//go:embed rego/authorization.rego
 	opaAuthorization string
+
+	//go:embed rego/transition.rego
+	opaTransition string
 )
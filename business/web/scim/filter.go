@@ -0,0 +1,187 @@
+package scim
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Set of comparison operators defined for filters.
+const (
+	OpEqual         = "eq"
+	OpNotEqual      = "ne"
+	OpContains      = "co"
+	OpStartsWith    = "sw"
+	OpEndsWith      = "ew"
+	OpPresent       = "pr"
+	OpGreaterThan   = "gt"
+	OpGreaterOrEq   = "ge"
+	OpLessThan      = "lt"
+	OpLessOrEqualTo = "le"
+)
+
+var operators = map[string]struct{}{
+	OpEqual: {}, OpNotEqual: {}, OpContains: {}, OpStartsWith: {}, OpEndsWith: {},
+	OpPresent: {}, OpGreaterThan: {}, OpGreaterOrEq: {}, OpLessThan: {}, OpLessOrEqualTo: {},
+}
+
+// Condition represents a single attribute comparison from a filter. Value
+// holds a string, bool, float64 or nil depending on the literal used.
+type Condition struct {
+	Attribute string
+	Operator  string
+	Value     any
+}
+
+// ParseFilter parses a filter expression into the conditions that must all
+// hold. Only conjunctions of simple comparisons are supported; "or", "not"
+// and grouping are rejected as an invalid filter. Attribute names and
+// operators are case insensitive and returned in lower case.
+func ParseFilter(filter string) ([]Condition, error) {
+	tokens, err := tokenize(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var conds []Condition
+	for i := 0; i < len(tokens); {
+		if len(conds) > 0 {
+			if !strings.EqualFold(tokens[i].text, "and") || tokens[i].quoted {
+				return nil, invalidFilter("expected \"and\" but found %q", tokens[i].text)
+			}
+			i++
+		}
+
+		if i+1 >= len(tokens) {
+			return nil, invalidFilter("incomplete expression")
+		}
+
+		attr := tokens[i]
+		op := strings.ToLower(tokens[i+1].text)
+		if attr.quoted || !isAttribute(attr.text) {
+			return nil, invalidFilter("invalid attribute %q", attr.text)
+		}
+		if _, exists := operators[op]; !exists || tokens[i+1].quoted {
+			return nil, invalidFilter("unsupported operator %q", tokens[i+1].text)
+		}
+
+		cond := Condition{
+			Attribute: strings.ToLower(attr.text),
+			Operator:  op,
+		}
+		i += 2
+
+		if op != OpPresent {
+			if i >= len(tokens) {
+				return nil, invalidFilter("missing value for %q", attr.text)
+			}
+			cond.Value, err = literal(tokens[i])
+			if err != nil {
+				return nil, err
+			}
+			i++
+		}
+
+		conds = append(conds, cond)
+	}
+
+	if len(conds) == 0 {
+		return nil, invalidFilter("empty filter")
+	}
+
+	return conds, nil
+}
+
+// =============================================================================
+
+type token struct {
+	text   string
+	quoted bool
+}
+
+func tokenize(filter string) ([]token, error) {
+	var tokens []token
+
+	for i := 0; i < len(filter); {
+		switch c := filter[i]; {
+		case c == ' ' || c == '\t':
+			i++
+
+		case c == '(' || c == ')' || c == '[' || c == ']':
+			return nil, invalidFilter("grouping is not supported")
+
+		case c == '"':
+			end := i + 1
+			for ; end < len(filter); end++ {
+				if filter[end] == '\\' {
+					end++
+					continue
+				}
+				if filter[end] == '"' {
+					break
+				}
+			}
+			if end >= len(filter) {
+				return nil, invalidFilter("unterminated string")
+			}
+
+			var s string
+			if err := json.Unmarshal([]byte(filter[i:end+1]), &s); err != nil {
+				return nil, invalidFilter("invalid string %s", filter[i:end+1])
+			}
+			tokens = append(tokens, token{text: s, quoted: true})
+			i = end + 1
+
+		default:
+			end := i
+			for end < len(filter) && !strings.ContainsRune(" \t\"()[]", rune(filter[end])) {
+				end++
+			}
+			tokens = append(tokens, token{text: filter[i:end]})
+			i = end
+		}
+	}
+
+	return tokens, nil
+}
+
+func isAttribute(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '_', r == '-', r == '$':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func literal(t token) (any, error) {
+	if t.quoted {
+		return t.text, nil
+	}
+
+	switch strings.ToLower(t.text) {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	}
+
+	n, err := strconv.ParseFloat(t.text, 64)
+	if err != nil {
+		return nil, invalidFilter("invalid value %q", t.text)
+	}
+	return n, nil
+}
+
+func invalidFilter(format string, args ...any) error {
+	return NewError(http.StatusBadRequest, TypeInvalidFilter, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,68 @@
+package scim
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Set of operations a patch request can perform.
+const (
+	PatchAdd     = "add"
+	PatchReplace = "replace"
+	PatchRemove  = "remove"
+)
+
+// PatchRequest represents the body of a PATCH request.
+type PatchRequest struct {
+	Schemas    []string         `json:"schemas"`
+	Operations []PatchOperation `json:"Operations"`
+}
+
+// PatchOperation represents a single change of a PATCH request. Value is
+// kept raw since its shape depends on the targeted attribute.
+type PatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value"`
+}
+
+// Validate checks the request is well formed. Operation names are normalized
+// to lower case since some clients send them capitalized.
+func (pr *PatchRequest) Validate() error {
+	var found bool
+	for _, schema := range pr.Schemas {
+		if schema == SchemaPatchOp {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return NewError(http.StatusBadRequest, TypeInvalidSyntax, "missing PatchOp schema")
+	}
+
+	if len(pr.Operations) == 0 {
+		return NewError(http.StatusBadRequest, TypeInvalidSyntax, "no operations provided")
+	}
+
+	for i := range pr.Operations {
+		op := &pr.Operations[i]
+		op.Op = strings.ToLower(op.Op)
+
+		switch op.Op {
+		case PatchAdd, PatchReplace:
+			if len(op.Value) == 0 {
+				return NewError(http.StatusBadRequest, TypeInvalidValue, fmt.Sprintf("operation %d: missing value", i))
+			}
+		case PatchRemove:
+			if op.Path == "" {
+				return NewError(http.StatusBadRequest, TypeNoTarget, fmt.Sprintf("operation %d: remove requires a path", i))
+			}
+		default:
+			return NewError(http.StatusBadRequest, TypeInvalidSyntax, fmt.Sprintf("operation %d: unsupported op %q", i, op.Op))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,210 @@
+// Package scim provides the protocol types and helpers needed to expose
+// resources using SCIM 2.0 (RFC 7643 and RFC 7644).
+package scim
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/aleury/service/foundation/web"
+)
+
+// Set of schema URNs used by the supported resources and messages.
+const (
+	SchemaUser         = "urn:ietf:params:scim:schemas:core:2.0:User"
+	SchemaGroup        = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	SchemaListResponse = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	SchemaPatchOp      = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	SchemaError        = "urn:ietf:params:scim:api:messages:2.0:Error"
+)
+
+// Set of scimType values used to describe errors to clients.
+const (
+	TypeInvalidFilter = "invalidFilter"
+	TypeInvalidSyntax = "invalidSyntax"
+	TypeInvalidPath   = "invalidPath"
+	TypeInvalidValue  = "invalidValue"
+	TypeMutability    = "mutability"
+	TypeUniqueness    = "uniqueness"
+	TypeNoTarget      = "noTarget"
+)
+
+// Set of paging limits. Clients asking for more resources than the maximum
+// receive the maximum.
+const (
+	DefaultCount = 100
+	MaxCount     = 100
+)
+
+// =============================================================================
+
+// Error is used to pass an error during the request through the application
+// with the details a SCIM client expects.
+type Error struct {
+	Status   int
+	ScimType string
+	Detail   string
+}
+
+// NewError constructs an error for the specified status and scimType.
+func NewError(status int, scimType string, detail string) error {
+	return &Error{
+		Status:   status,
+		ScimType: scimType,
+		Detail:   detail,
+	}
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	return e.Detail
+}
+
+// GetError returns a copy of the Error pointer.
+func GetError(err error) *Error {
+	var se *Error
+	if !errors.As(err, &se) {
+		return nil
+	}
+	return se
+}
+
+// ErrorResponse is the form used for SCIM responses from failures in the API.
+type ErrorResponse struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	ScimType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail,omitempty"`
+}
+
+// NewErrorResponse constructs the response for the specified error details.
+func NewErrorResponse(status int, scimType string, detail string) ErrorResponse {
+	return ErrorResponse{
+		Schemas:  []string{SchemaError},
+		Status:   strconv.Itoa(status),
+		ScimType: scimType,
+		Detail:   detail,
+	}
+}
+
+// =============================================================================
+
+// ListResponse is the form used to return a page of resources.
+type ListResponse[T any] struct {
+	Schemas      []string `json:"schemas"`
+	TotalResults int      `json:"totalResults"`
+	StartIndex   int      `json:"startIndex"`
+	ItemsPerPage int      `json:"itemsPerPage"`
+	Resources    []T      `json:"Resources"`
+}
+
+// NewListResponse constructs a list response for a page of resources.
+func NewListResponse[T any](resources []T, total int, startIndex int) ListResponse[T] {
+	if resources == nil {
+		resources = []T{}
+	}
+
+	return ListResponse[T]{
+		Schemas:      []string{SchemaListResponse},
+		TotalResults: total,
+		StartIndex:   startIndex,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	}
+}
+
+// Page represents the requested window of resources. StartIndex is 1-based.
+type Page struct {
+	StartIndex int
+	Count      int
+}
+
+// ParsePage parses the startIndex and count query parameters. Values out of
+// range are adjusted as described by RFC 7644 section 3.4.2.4.
+func ParsePage(r *http.Request) (Page, error) {
+	values := r.URL.Query()
+
+	page := Page{
+		StartIndex: 1,
+		Count:      DefaultCount,
+	}
+
+	if v := values.Get("startIndex"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return Page{}, NewError(http.StatusBadRequest, TypeInvalidValue, fmt.Sprintf("startIndex: %s", err))
+		}
+		if n > 1 {
+			page.StartIndex = n
+		}
+	}
+
+	if v := values.Get("count"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return Page{}, NewError(http.StatusBadRequest, TypeInvalidValue, fmt.Sprintf("count: %s", err))
+		}
+		switch {
+		case n < 0:
+			page.Count = 0
+		case n > MaxCount:
+			page.Count = MaxCount
+		default:
+			page.Count = n
+		}
+	}
+
+	return page, nil
+}
+
+// =============================================================================
+
+type validator interface {
+	Validate() error
+}
+
+// Decode reads the body of the request into the provided value. Unlike
+// web.Decode, attributes that aren't modelled are ignored since clients
+// commonly send more than a service stores.
+func Decode(r *http.Request, val any) error {
+	if err := json.NewDecoder(r.Body).Decode(val); err != nil {
+		return NewError(http.StatusBadRequest, TypeInvalidSyntax, err.Error())
+	}
+
+	if v, ok := val.(validator); ok {
+		if err := v.Validate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Respond converts a Go value to JSON and sends it to the client using the
+// SCIM media type.
+func Respond(ctx context.Context, w http.ResponseWriter, data any, statusCode int) error {
+	web.SetStatusCode(ctx, statusCode)
+
+	if statusCode == http.StatusNoContent {
+		w.WriteHeader(statusCode)
+		return nil
+	}
+
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/scim+json")
+	w.WriteHeader(statusCode)
+
+	if _, err := w.Write(jsonData); err != nil {
+		return err
+	}
+
+	return nil
+}
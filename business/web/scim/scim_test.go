@@ -0,0 +1,159 @@
+package scim_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aleury/service/business/web/scim"
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_SCIM(t *testing.T) {
+	t.Run("filter", filter)
+	t.Run("page", page)
+	t.Run("patch", patch)
+}
+
+func filter(t *testing.T) {
+	tests := []struct {
+		filter string
+		conds  []scim.Condition
+	}{
+		{
+			`userName eq "bjensen"`,
+			[]scim.Condition{{Attribute: "username", Operator: scim.OpEqual, Value: "bjensen"}},
+		},
+		{
+			`name.familyName CO "O\"Malley" and active EQ true`,
+			[]scim.Condition{
+				{Attribute: "name.familyname", Operator: scim.OpContains, Value: `O"Malley`},
+				{Attribute: "active", Operator: scim.OpEqual, Value: true},
+			},
+		},
+		{
+			`title pr and meta.lastModified gt "2011-05-13T04:42:34Z" and x.count le 2`,
+			[]scim.Condition{
+				{Attribute: "title", Operator: scim.OpPresent},
+				{Attribute: "meta.lastmodified", Operator: scim.OpGreaterThan, Value: "2011-05-13T04:42:34Z"},
+				{Attribute: "x.count", Operator: scim.OpLessOrEqualTo, Value: 2.0},
+			},
+		},
+		{
+			`externalId ne null`,
+			[]scim.Condition{{Attribute: "externalid", Operator: scim.OpNotEqual, Value: nil}},
+		},
+	}
+
+	for _, tt := range tests {
+		conds, err := scim.ParseFilter(tt.filter)
+		if err != nil {
+			t.Errorf("Should be able to parse %s: %s.", tt.filter, err)
+			continue
+		}
+
+		if diff := cmp.Diff(tt.conds, conds); diff != "" {
+			t.Errorf("Should get back the conditions of %s. diff:\n%s", tt.filter, diff)
+		}
+	}
+
+	invalid := []string{
+		``,
+		`userName`,
+		`userName eq`,
+		`userName xx "bjensen"`,
+		`userName eq "bjensen`,
+		`userName eq bjensen`,
+		`"userName" eq "bjensen"`,
+		`userName eq "a" or userName eq "b"`,
+		`not (userName eq "a")`,
+		`emails[type eq "work"]`,
+	}
+
+	for _, f := range invalid {
+		_, err := scim.ParseFilter(f)
+
+		se := scim.GetError(err)
+		if se == nil || se.Status != http.StatusBadRequest || se.ScimType != scim.TypeInvalidFilter {
+			t.Errorf("Should NOT be able to parse %s: %v.", f, err)
+		}
+	}
+}
+
+func page(t *testing.T) {
+	tests := []struct {
+		query string
+		page  scim.Page
+	}{
+		{"", scim.Page{StartIndex: 1, Count: scim.DefaultCount}},
+		{"startIndex=11&count=10", scim.Page{StartIndex: 11, Count: 10}},
+		{"startIndex=0&count=-1", scim.Page{StartIndex: 1, Count: 0}},
+		{"count=1000", scim.Page{StartIndex: 1, Count: scim.MaxCount}},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/?"+tt.query, nil)
+
+		got, err := scim.ParsePage(r)
+		if err != nil {
+			t.Errorf("Should be able to parse the page of %q: %s.", tt.query, err)
+			continue
+		}
+
+		if got != tt.page {
+			t.Errorf("Should get back the page of %q: got %+v want %+v", tt.query, got, tt.page)
+		}
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/?count=ten", nil)
+	if _, err := scim.ParsePage(r); scim.GetError(err) == nil {
+		t.Errorf("Should NOT be able to parse a count that isn't a number: %v.", err)
+	}
+}
+
+func patch(t *testing.T) {
+	valid := `{
+		"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+		"Operations": [
+			{"op": "Replace", "path": "active", "value": false},
+			{"op": "remove", "path": "title"}
+		]
+	}`
+
+	var pr scim.PatchRequest
+	if err := json.Unmarshal([]byte(valid), &pr); err != nil {
+		t.Fatalf("Should be able to unmarshal the patch request: %s.", err)
+	}
+
+	if err := pr.Validate(); err != nil {
+		t.Fatalf("Should be able to validate the patch request: %s.", err)
+	}
+
+	if pr.Operations[0].Op != scim.PatchReplace {
+		t.Errorf("Should normalize the name of the operation: got %s", pr.Operations[0].Op)
+	}
+
+	tests := []struct {
+		body     string
+		scimType string
+	}{
+		{`{"schemas": [], "Operations": [{"op": "remove", "path": "title"}]}`, scim.TypeInvalidSyntax},
+		{`{"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"], "Operations": []}`, scim.TypeInvalidSyntax},
+		{`{"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"], "Operations": [{"op": "add", "path": "title"}]}`, scim.TypeInvalidValue},
+		{`{"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"], "Operations": [{"op": "remove"}]}`, scim.TypeNoTarget},
+		{`{"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"], "Operations": [{"op": "move", "path": "title"}]}`, scim.TypeInvalidSyntax},
+	}
+
+	for _, tt := range tests {
+		var pr scim.PatchRequest
+		if err := json.Unmarshal([]byte(tt.body), &pr); err != nil {
+			t.Fatalf("Should be able to unmarshal the patch request: %s.", err)
+		}
+
+		se := scim.GetError(pr.Validate())
+		if se == nil || se.ScimType != tt.scimType {
+			t.Errorf("Should NOT be able to validate %s: got %+v want %s", tt.body, se, tt.scimType)
+		}
+	}
+}
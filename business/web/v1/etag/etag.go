@@ -0,0 +1,96 @@
+// Package etag exposes the version of a resource as an entity tag so clients
+// can make conditional requests with If-Match and If-None-Match.
+package etag
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	v1 "github.com/aleury/service/business/web/v1"
+)
+
+// Set of error variables for handling preconditions.
+var (
+	ErrNotModified          = errors.New("resource was not modified")
+	ErrPreconditionFailed   = errors.New("resource was changed since it was read, fetch it again and retry")
+	ErrPreconditionRequired = errors.New("request must be conditional, send the etag of the resource in If-Match")
+)
+
+// Format returns the entity tag of a resource at the specified version.
+func Format(version int) string {
+	return strconv.Quote(strconv.Itoa(version))
+}
+
+// Set adds the entity tag of a resource at the specified version to the
+// response.
+func Set(w http.ResponseWriter, version int) {
+	w.Header().Set("ETag", Format(version))
+}
+
+// Check evaluates the If-Match and If-None-Match headers of the request
+// against the version of the resource as described in RFC 9110. If-Match
+// uses the strong comparison so weak tags never match, If-None-Match uses
+// the weak comparison. Requests without either header pass. A request error
+// with status 304 is returned for GET and HEAD requests whose If-None-Match
+// matches, otherwise failed preconditions return one with status 412.
+func Check(r *http.Request, version int) error {
+	tag := Format(version)
+
+	if header := r.Header.Get("If-Match"); header != "" {
+		if !match(header, tag, strongCompare) {
+			return v1.NewRequestError(ErrPreconditionFailed, http.StatusPreconditionFailed)
+		}
+	}
+
+	if header := r.Header.Get("If-None-Match"); header != "" {
+		if match(header, tag, weakCompare) {
+			if r.Method == http.MethodGet || r.Method == http.MethodHead {
+				return v1.NewRequestError(ErrNotModified, http.StatusNotModified)
+			}
+			return v1.NewRequestError(ErrPreconditionFailed, http.StatusPreconditionFailed)
+		}
+	}
+
+	return nil
+}
+
+// =============================================================================
+
+// match reports whether the list of entity tags in a header value matches
+// the tag using the specified comparison. The value "*" matches any tag.
+func match(header string, tag string, compare func(a, b string) bool) bool {
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+
+	for _, v := range strings.Split(header, ",") {
+		if compare(strings.TrimSpace(v), tag) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// strongCompare reports whether two entity tags match with the strong
+// comparison, both have to be strong and have the same opaque tag.
+func strongCompare(a, b string) bool {
+	if isWeak(a) || isWeak(b) {
+		return false
+	}
+	return a == b
+}
+
+// weakCompare reports whether two entity tags match with the weak
+// comparison, the opaque tags have to be the same regardless of either one
+// being weak.
+func weakCompare(a, b string) bool {
+	return strings.TrimPrefix(a, "W/") == strings.TrimPrefix(b, "W/")
+}
+
+// isWeak reports whether an entity tag is weak.
+func isWeak(tag string) bool {
+	return strings.HasPrefix(tag, "W/")
+}
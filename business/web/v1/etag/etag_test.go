@@ -0,0 +1,80 @@
+package etag_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	v1 "github.com/aleury/service/business/web/v1"
+	"github.com/aleury/service/business/web/v1/etag"
+)
+
+func Test_ETag(t *testing.T) {
+	t.Run("ifmatch", ifMatch)
+	t.Run("ifnonematch", ifNoneMatch)
+}
+
+func ifMatch(t *testing.T) {
+	tests := []struct {
+		header string
+		status int
+	}{
+		{"", 0},
+		{`"3"`, 0},
+		{`"1", "3"`, 0},
+		{`*`, 0},
+		{`"2"`, http.StatusPreconditionFailed},
+		{`W/"3"`, http.StatusPreconditionFailed},
+		{`"1", W/"3"`, http.StatusPreconditionFailed},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodPut, "/", nil)
+		if tt.header != "" {
+			r.Header.Set("If-Match", tt.header)
+		}
+
+		if got := status(etag.Check(r, 3)); got != tt.status {
+			t.Errorf("Should get status %d for If-Match %s: got %d", tt.status, tt.header, got)
+		}
+	}
+}
+
+func ifNoneMatch(t *testing.T) {
+	tests := []struct {
+		method string
+		header string
+		status int
+	}{
+		{http.MethodGet, "", 0},
+		{http.MethodGet, `"2"`, 0},
+		{http.MethodGet, `W/"2"`, 0},
+		{http.MethodGet, `"3"`, http.StatusNotModified},
+		{http.MethodGet, `W/"3"`, http.StatusNotModified},
+		{http.MethodHead, `"1", W/"3"`, http.StatusNotModified},
+		{http.MethodGet, `*`, http.StatusNotModified},
+		{http.MethodPut, `"2"`, 0},
+		{http.MethodPut, `W/"3"`, http.StatusPreconditionFailed},
+		{http.MethodPut, `*`, http.StatusPreconditionFailed},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest(tt.method, "/", nil)
+		if tt.header != "" {
+			r.Header.Set("If-None-Match", tt.header)
+		}
+
+		if got := status(etag.Check(r, 3)); got != tt.status {
+			t.Errorf("Should get status %d for %s with If-None-Match %s: got %d", tt.status, tt.method, tt.header, got)
+		}
+	}
+}
+
+// status returns the status of the request error or zero when the check
+// passed.
+func status(err error) int {
+	if err == nil {
+		return 0
+	}
+	return v1.GetRequestError(err).Status
+}
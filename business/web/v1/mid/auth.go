@@ -3,8 +3,15 @@ package mid
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
 
+	"github.com/aleury/service/business/core/audit"
+	"github.com/aleury/service/business/core/product"
+	"github.com/aleury/service/business/core/salesorder"
+	"github.com/aleury/service/business/core/tenant"
+	"github.com/aleury/service/business/core/user"
+	database "github.com/aleury/service/business/sys/database/pgx"
 	"github.com/aleury/service/business/web/auth"
 	v1 "github.com/aleury/service/business/web/v1"
 	"github.com/aleury/service/foundation/web"
@@ -25,9 +32,11 @@ func Authenticate(a *auth.Auth) web.Middleware {
 				return auth.NewAuthError("authenticate: failed: %s", err)
 			}
 
-			ctx = auth.SetClaims(ctx, claims)
+			if claims.Tenant == uuid.Nil {
+				return auth.NewAuthError("authenticate: failed: token is not bound to a tenant")
+			}
 
-			return handler(ctx, w, r)
+			return handler(setAuthenticated(ctx, claims), w, r)
 		}
 	}
 }
@@ -62,3 +71,207 @@ func Authorize(a *auth.Auth, rule string) web.Middleware {
 		}
 	}
 }
+
+// AuthorizeProduct executes the specified rule after extracting the product
+// from the database using the product_id route parameter. The owner of the
+// product is used as the user id the rule is evaluated against, and the
+// product is stored in the context for the handler to use.
+func AuthorizeProduct(a *auth.Auth, rule string, prdCore *product.Core) web.Middleware {
+	return func(handler web.Handler) web.Handler {
+		return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			claims := auth.GetClaims(ctx)
+			if claims.Subject == "" {
+				return auth.NewAuthError("authorize: you are not authorized for that action, no claims.")
+			}
+
+			productID, err := uuid.Parse(web.Param(r, "product_id"))
+			if err != nil {
+				return v1.NewRequestError(ErrInvalidID, http.StatusBadRequest)
+			}
+
+			prd, err := prdCore.QueryByID(ctx, productID)
+			if err != nil {
+				switch {
+				case errors.Is(err, product.ErrNotFound):
+					return v1.NewRequestError(err, http.StatusNotFound)
+				default:
+					return fmt.Errorf("querybyid: productID[%s]: %w", productID, err)
+				}
+			}
+
+			ctx = auth.SetUserID(ctx, prd.UserID)
+			ctx = auth.SetResourceTenantID(ctx, prd.TenantID)
+			ctx = setProduct(ctx, prd)
+
+			if err := a.Authorize(ctx, claims, rule); err != nil {
+				return auth.NewAuthError("authorize: you are not authorized for that action: claims[%v] rule[%v]: %s", claims.Roles, rule, err)
+			}
+
+			return handler(ctx, w, r)
+		}
+	}
+}
+
+// AuthorizeUser executes the specified rule after extracting the user from
+// the database using the user_id route parameter. The departments of the
+// user and the caller are made available to the rule so department admins
+// can be limited to the users of their own department.
+func AuthorizeUser(a *auth.Auth, rule string, usrCore *user.Core) web.Middleware {
+	return func(handler web.Handler) web.Handler {
+		return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			claims := auth.GetClaims(ctx)
+			if claims.Subject == "" {
+				return auth.NewAuthError("authorize: you are not authorized for that action, no claims.")
+			}
+
+			userID, err := uuid.Parse(web.Param(r, "user_id"))
+			if err != nil {
+				return v1.NewRequestError(ErrInvalidID, http.StatusBadRequest)
+			}
+
+			// Deleted users are included so they can still be restored or
+			// inspected by the admins allowed to see them.
+			var filter user.QueryFilter
+			filter.WithUserID(userID)
+			filter.WithIncludeDeleted(true)
+
+			usrs, err := usrCore.Query(ctx, filter, user.DefaultOrderBy, 1, 1)
+			if err != nil {
+				return fmt.Errorf("query: userID[%s]: %w", userID, err)
+			}
+			if len(usrs) == 0 {
+				return v1.NewRequestError(user.ErrNotFound, http.StatusNotFound)
+			}
+
+			ctx = auth.SetUserID(ctx, userID)
+			ctx = auth.SetResourceTenantID(ctx, usrs[0].TenantID)
+			ctx = auth.SetUserDepartmentID(ctx, usrs[0].DepartmentID)
+
+			if claims.HasRole(user.RoleDepartmentAdmin) {
+				subjectID, err := uuid.Parse(claims.Subject)
+				if err != nil {
+					return auth.NewAuthError("authorize: invalid subject: %s", err)
+				}
+
+				subject, err := usrCore.QueryByID(ctx, subjectID)
+				if err != nil {
+					return auth.NewAuthError("authorize: subject lookup failed: %s", err)
+				}
+
+				ctx = auth.SetSubjectDepartmentID(ctx, subject.DepartmentID)
+			}
+
+			if err := a.Authorize(ctx, claims, rule); err != nil {
+				return auth.NewAuthError("authorize: you are not authorized for that action: claims[%v] rule[%v]: %s", claims.Roles, rule, err)
+			}
+
+			return handler(ctx, w, r)
+		}
+	}
+}
+
+// AuthorizeOrder executes the specified rule after extracting the order from
+// the database using the order_id route parameter. The user who placed the
+// order is used as the user id the rule is evaluated against, and the order
+// is stored in the context for the handler to use.
+func AuthorizeOrder(a *auth.Auth, rule string, ordCore *salesorder.Core) web.Middleware {
+	return func(handler web.Handler) web.Handler {
+		return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			claims := auth.GetClaims(ctx)
+			if claims.Subject == "" {
+				return auth.NewAuthError("authorize: you are not authorized for that action, no claims.")
+			}
+
+			ctx, err := loadOrder(ctx, r, ordCore)
+			if err != nil {
+				return err
+			}
+
+			if err := a.Authorize(ctx, claims, rule); err != nil {
+				return auth.NewAuthError("authorize: you are not authorized for that action: claims[%v] rule[%v]: %s", claims.Roles, rule, err)
+			}
+
+			return handler(ctx, w, r)
+		}
+	}
+}
+
+// AuthorizeOrderTransition loads the order like AuthorizeOrder and checks
+// the caller is allowed to move it from its current status to the specified
+// one. Whether the move is valid at all is left to the order core.
+func AuthorizeOrderTransition(a *auth.Auth, to salesorder.Status, ordCore *salesorder.Core) web.Middleware {
+	return func(handler web.Handler) web.Handler {
+		return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			claims := auth.GetClaims(ctx)
+			if claims.Subject == "" {
+				return auth.NewAuthError("authorize: you are not authorized for that action, no claims.")
+			}
+
+			ctx, err := loadOrder(ctx, r, ordCore)
+			if err != nil {
+				return err
+			}
+
+			from := GetOrder(ctx).Status
+			if err := a.AuthorizeTransition(ctx, claims, auth.RuleOrderTransition, from.Name(), to.Name()); err != nil {
+				return auth.NewAuthError("authorize: you are not authorized to move the order from %s to %s: claims[%v]: %s", from.Name(), to.Name(), claims.Roles, err)
+			}
+
+			return handler(ctx, w, r)
+		}
+	}
+}
+
+// loadOrder extracts the order named by the order_id route parameter and
+// stores it, its owner and its tenant in the context.
+func loadOrder(ctx context.Context, r *http.Request, ordCore *salesorder.Core) (context.Context, error) {
+	orderID, err := uuid.Parse(web.Param(r, "order_id"))
+	if err != nil {
+		return ctx, v1.NewRequestError(ErrInvalidID, http.StatusBadRequest)
+	}
+
+	ord, err := ordCore.QueryByID(ctx, orderID)
+	if err != nil {
+		switch {
+		case errors.Is(err, salesorder.ErrNotFound):
+			return ctx, v1.NewRequestError(err, http.StatusNotFound)
+		default:
+			return ctx, fmt.Errorf("querybyid: orderID[%s]: %w", orderID, err)
+		}
+	}
+
+	ctx = auth.SetUserID(ctx, ord.UserID)
+	ctx = auth.SetResourceTenantID(ctx, ord.TenantID)
+	ctx = setOrder(ctx, ord)
+
+	return ctx, nil
+}
+
+// setAuthenticated stores what the rest of the call chain needs to know about
+// the authenticated caller.
+func setAuthenticated(ctx context.Context, claims auth.Claims) context.Context {
+	ctx = auth.SetClaims(ctx, claims)
+	ctx = tenant.SetTenantID(ctx, claims.Tenant)
+	ctx = database.SetSession(ctx, toSession(claims))
+
+	if actorID, err := uuid.Parse(claims.Subject); err == nil {
+		ctx = audit.SetActorID(ctx, actorID)
+	}
+
+	return ctx
+}
+
+// toSession converts the claims into the session the database uses to
+// enforce row level security for the request.
+func toSession(claims auth.Claims) database.Session {
+	roles := make([]string, len(claims.Roles))
+	for i, role := range claims.Roles {
+		roles[i] = role.Name()
+	}
+
+	return database.Session{
+		UserID:   claims.Subject,
+		TenantID: claims.Tenant.String(),
+		Roles:    roles,
+	}
+}
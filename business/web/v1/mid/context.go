@@ -0,0 +1,59 @@
+package mid
+
+import (
+	"context"
+
+	"github.com/aleury/service/business/core/product"
+	"github.com/aleury/service/business/core/salesorder"
+	"github.com/google/uuid"
+)
+
+// ctxKey represents the type of value for the context key.
+type ctxKey int
+
+// Set of keys used to store/retrieve values from a context.Context.
+const (
+	productKey ctxKey = iota + 1
+	sessionKey
+	orderKey
+)
+
+func setProduct(ctx context.Context, prd product.Product) context.Context {
+	return context.WithValue(ctx, productKey, prd)
+}
+
+// GetProduct returns the product loaded by the AuthorizeProduct middleware.
+func GetProduct(ctx context.Context) product.Product {
+	v, ok := ctx.Value(productKey).(product.Product)
+	if !ok {
+		return product.Product{}
+	}
+	return v
+}
+
+func setSessionID(ctx context.Context, sessionID uuid.UUID) context.Context {
+	return context.WithValue(ctx, sessionKey, sessionID)
+}
+
+// GetSessionID returns the browser session the request was authenticated
+// with. A nil ID is returned for requests authenticated with a JWT.
+func GetSessionID(ctx context.Context) uuid.UUID {
+	v, ok := ctx.Value(sessionKey).(uuid.UUID)
+	if !ok {
+		return uuid.Nil
+	}
+	return v
+}
+
+func setOrder(ctx context.Context, ord salesorder.Order) context.Context {
+	return context.WithValue(ctx, orderKey, ord)
+}
+
+// GetOrder returns the order loaded by the AuthorizeOrder middleware.
+func GetOrder(ctx context.Context) salesorder.Order {
+	v, ok := ctx.Value(orderKey).(salesorder.Order)
+	if !ok {
+		return salesorder.Order{}
+	}
+	return v
+}
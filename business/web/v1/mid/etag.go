@@ -0,0 +1,25 @@
+package mid
+
+import (
+	"context"
+	"net/http"
+
+	v1 "github.com/aleury/service/business/web/v1"
+	"github.com/aleury/service/business/web/v1/etag"
+	"github.com/aleury/service/foundation/web"
+)
+
+// IfMatch rejects requests without an If-Match header with status 428 when
+// conditional updates are required. Otherwise the header is optional and
+// only checked by the handlers when it is sent.
+func IfMatch(required bool) web.Middleware {
+	return func(handler web.Handler) web.Handler {
+		return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			if required && r.Header.Get("If-Match") == "" {
+				return v1.NewRequestError(etag.ErrPreconditionRequired, http.StatusPreconditionRequired)
+			}
+
+			return handler(ctx, w, r)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package mid
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/aleury/service/business/core/scimtoken"
+	"github.com/aleury/service/business/core/tenant"
+	"github.com/aleury/service/business/core/user"
+	database "github.com/aleury/service/business/sys/database/pgx"
+	"github.com/aleury/service/business/sys/validate"
+	"github.com/aleury/service/business/web/auth"
+	"github.com/aleury/service/business/web/scim"
+	v1 "github.com/aleury/service/business/web/v1"
+	"github.com/aleury/service/foundation/web"
+	"go.uber.org/zap"
+)
+
+// AuthenticateSCIM validates the SCIM bearer credential from the
+// `Authorization` header. JWTs are not accepted, and the credential is not
+// accepted anywhere else, which keeps provisioning clients on these routes.
+func AuthenticateSCIM(tokCore *scimtoken.Core) web.Middleware {
+	return func(handler web.Handler) web.Handler {
+		return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			parts := strings.Split(r.Header.Get("authorization"), " ")
+			if len(parts) != 2 || !strings.EqualFold(parts[0], "bearer") {
+				return auth.NewAuthError("authenticate: expected authorization header format: Bearer <token>")
+			}
+
+			tok, err := tokCore.Authenticate(ctx, parts[1])
+			if err != nil {
+				return auth.NewAuthError("authenticate: failed: %s", err)
+			}
+
+			// Provisioning manages every user of the tenant, so the database
+			// session carries the admin role.
+			ctx = tenant.SetTenantID(ctx, tok.TenantID)
+			ctx = database.SetSession(ctx, database.Session{
+				TenantID: tok.TenantID.String(),
+				Roles:    []string{user.RoleAdmin.Name()},
+			})
+
+			return handler(ctx, w, r)
+		}
+	}
+}
+
+// SCIMErrors handles errors coming out of the SCIM call chain and responds
+// using the SCIM error format. It needs to run before AuthenticateSCIM so
+// authentication failures are reported in the same format.
+func SCIMErrors(log *zap.SugaredLogger) web.Middleware {
+	return func(handler web.Handler) web.Handler {
+		return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			err := handler(ctx, w, r)
+			if err == nil {
+				return nil
+			}
+
+			log.Errorw("ERROR", "trace_id", web.GetTraceID(ctx), "message", err)
+
+			var er scim.ErrorResponse
+			var status int
+
+			switch {
+			case scim.GetError(err) != nil:
+				scimErr := scim.GetError(err)
+				status = scimErr.Status
+				er = scim.NewErrorResponse(status, scimErr.ScimType, scimErr.Detail)
+
+			case v1.IsRequestError(err):
+				reqErr := v1.GetRequestError(err)
+				status = reqErr.Status
+				er = scim.NewErrorResponse(status, "", reqErr.Error())
+
+			case auth.IsAuthError(err):
+				status = http.StatusUnauthorized
+				er = scim.NewErrorResponse(status, "", http.StatusText(status))
+
+			case validate.IsFieldErrors(err):
+				status = http.StatusBadRequest
+				er = scim.NewErrorResponse(status, scim.TypeInvalidValue, fieldsDetail(validate.GetFieldErrors(err)))
+
+			default:
+				status = http.StatusInternalServerError
+				er = scim.NewErrorResponse(status, "", http.StatusText(status))
+			}
+
+			if err := scim.Respond(ctx, w, er, status); err != nil {
+				return err
+			}
+
+			// If we receive the shutdown err we need to return it
+			// back to the base handler to shut down the service.
+			if web.IsShutdown(err) {
+				return err
+			}
+
+			return nil
+		}
+	}
+}
+
+// fieldsDetail flattens field errors into the single detail string the SCIM
+// error format allows.
+func fieldsDetail(fe validate.FieldErrors) string {
+	msgs := make([]string, len(fe))
+	for i, fld := range fe {
+		msgs[i] = fmt.Sprintf("%s: %s", fld.Field, fld.Err)
+	}
+	return strings.Join(msgs, "; ")
+}
@@ -0,0 +1,94 @@
+package mid
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+
+	"github.com/aleury/service/business/core/session"
+	"github.com/aleury/service/business/web/auth"
+	v1 "github.com/aleury/service/business/web/v1"
+	"github.com/aleury/service/foundation/web"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// Names of the cookies and header used by browser sessions. The CSRF cookie
+// is readable by scripts so they can echo it back in the header.
+const (
+	SessionCookie = "session"
+	CSRFCookie    = "csrf_token"
+	CSRFHeader    = "X-CSRF-Token"
+)
+
+// ErrCSRF is returned when a browser request fails the CSRF check.
+var ErrCSRF = errors.New("missing or invalid csrf token")
+
+// AuthenticateSession validates the session cookie of a browser. Requests
+// that carry an `Authorization` header are authenticated with the JWT as
+// Authenticate does, so API clients keep working alongside browsers.
+func AuthenticateSession(a *auth.Auth, sesCore *session.Core) web.Middleware {
+	jwtAuth := Authenticate(a)
+
+	return func(handler web.Handler) web.Handler {
+		withJWT := jwtAuth(handler)
+
+		return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			cookie, err := r.Cookie(SessionCookie)
+			if r.Header.Get("authorization") != "" || err != nil {
+				return withJWT(ctx, w, r)
+			}
+
+			sess, usr, err := sesCore.Authenticate(ctx, cookie.Value)
+			if err != nil {
+				return auth.NewAuthError("authenticate: failed: %s", err)
+			}
+
+			claims := auth.Claims{
+				RegisteredClaims: jwt.RegisteredClaims{
+					Subject:   usr.ID.String(),
+					ExpiresAt: jwt.NewNumericDate(sess.DateExpires),
+					IssuedAt:  jwt.NewNumericDate(sess.DateCreated),
+				},
+				Roles:  usr.Roles,
+				Tenant: usr.TenantID,
+			}
+
+			ctx = setAuthenticated(ctx, claims)
+			ctx = setSessionID(ctx, sess.ID)
+
+			return handler(ctx, w, r)
+		}
+	}
+}
+
+// CSRF applies the double submit check to requests that could change state
+// and were sent by a browser holding a session cookie. The value of the
+// CSRF cookie must be echoed back in the CSRF header. Requests that carry an
+// `Authorization` header can't be forged by another site and are let through.
+func CSRF() web.Middleware {
+	return func(handler web.Handler) web.Handler {
+		return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			switch r.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+				return handler(ctx, w, r)
+			}
+
+			if _, err := r.Cookie(SessionCookie); err != nil || r.Header.Get("authorization") != "" {
+				return handler(ctx, w, r)
+			}
+
+			cookie, err := r.Cookie(CSRFCookie)
+			if err != nil || cookie.Value == "" {
+				return v1.NewRequestError(ErrCSRF, http.StatusForbidden)
+			}
+
+			header := r.Header.Get(CSRFHeader)
+			if subtle.ConstantTimeCompare([]byte(header), []byte(cookie.Value)) != 1 {
+				return v1.NewRequestError(ErrCSRF, http.StatusForbidden)
+			}
+
+			return handler(ctx, w, r)
+		}
+	}
+}
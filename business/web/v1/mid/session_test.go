@@ -0,0 +1,66 @@
+package mid_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	v1 "github.com/aleury/service/business/web/v1"
+	"github.com/aleury/service/business/web/v1/mid"
+)
+
+func Test_CSRF(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  string
+		session bool
+		cookie  string
+		header  string
+		auth    string
+		status  int
+	}{
+		{"safe method", http.MethodGet, true, "", "", "", 0},
+		{"no session", http.MethodPost, false, "", "", "", 0},
+		{"bearer token", http.MethodPost, true, "", "", "Bearer token", 0},
+		{"matching token", http.MethodPost, true, "abc", "abc", "", 0},
+		{"missing cookie", http.MethodPost, true, "", "abc", "", http.StatusForbidden},
+		{"missing header", http.MethodDelete, true, "abc", "", "", http.StatusForbidden},
+		{"wrong header", http.MethodPut, true, "abc", "abd", "", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		var called bool
+		handler := mid.CSRF()(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			called = true
+			return nil
+		})
+
+		r := httptest.NewRequest(tt.method, "/", nil)
+		if tt.session {
+			r.AddCookie(&http.Cookie{Name: mid.SessionCookie, Value: "session"})
+		}
+		if tt.cookie != "" {
+			r.AddCookie(&http.Cookie{Name: mid.CSRFCookie, Value: tt.cookie})
+		}
+		if tt.header != "" {
+			r.Header.Set(mid.CSRFHeader, tt.header)
+		}
+		if tt.auth != "" {
+			r.Header.Set("Authorization", tt.auth)
+		}
+
+		err := handler(context.Background(), httptest.NewRecorder(), r)
+
+		switch {
+		case tt.status == 0:
+			if err != nil || !called {
+				t.Errorf("Should let the request through for %s: %v.", tt.name, err)
+			}
+		default:
+			if err == nil || called || v1.GetRequestError(err).Status != tt.status {
+				t.Errorf("Should reject the request with %d for %s: %v.", tt.status, tt.name, err)
+			}
+		}
+	}
+}
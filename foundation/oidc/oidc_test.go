@@ -0,0 +1,345 @@
+package oidc_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aleury/service/foundation/oidc"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const (
+	clientID     = "sales-api"
+	clientSecret = "secret"
+	redirectURL  = "http://localhost:3000/oidc/callback"
+)
+
+// fakeIdP is a minimal OpenID Connect provider. It issues a code for every
+// authorization request and remembers the PKCE challenge and nonce sent
+// with it.
+type fakeIdP struct {
+	t      *testing.T
+	server *httptest.Server
+	key    *rsa.PrivateKey
+	kid    string
+
+	mu    sync.Mutex
+	codes map[string]authRequest
+
+	// claims lets a test change the ID token before it's signed.
+	claims func(jwt.MapClaims)
+}
+
+type authRequest struct {
+	challenge string
+	nonce     string
+}
+
+func newFakeIdP(t *testing.T) *fakeIdP {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Should be able to generate a key: %s", err)
+	}
+
+	idp := fakeIdP{
+		t:     t,
+		key:   key,
+		kid:   "key-1",
+		codes: make(map[string]authRequest),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", idp.discovery)
+	mux.HandleFunc("/authorize", idp.authorize)
+	mux.HandleFunc("/token", idp.token)
+	mux.HandleFunc("/jwks", idp.jwks)
+
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+
+	return &idp
+}
+
+func (idp *fakeIdP) discovery(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(map[string]string{
+		"issuer":                 idp.server.URL,
+		"authorization_endpoint": idp.server.URL + "/authorize",
+		"token_endpoint":         idp.server.URL + "/token",
+		"jwks_uri":               idp.server.URL + "/jwks",
+	})
+}
+
+func (idp *fakeIdP) authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("code_challenge_method") != "S256" || q.Get("client_id") != clientID {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+
+	code := "code-" + q.Get("state")
+
+	idp.mu.Lock()
+	idp.codes[code] = authRequest{challenge: q.Get("code_challenge"), nonce: q.Get("nonce")}
+	idp.mu.Unlock()
+
+	redirect, _ := url.Parse(q.Get("redirect_uri"))
+	rq := redirect.Query()
+	rq.Set("code", code)
+	rq.Set("state", q.Get("state"))
+	redirect.RawQuery = rq.Encode()
+
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func (idp *fakeIdP) token(w http.ResponseWriter, r *http.Request) {
+	user, pass, ok := r.BasicAuth()
+	if !ok || user != clientID || pass != clientSecret {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	idp.mu.Lock()
+	req, exists := idp.codes[r.PostFormValue("code")]
+	delete(idp.codes, r.PostFormValue("code"))
+	idp.mu.Unlock()
+
+	if !exists || oidc.Challenge(r.PostFormValue("code_verifier")) != req.challenge {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	claims := jwt.MapClaims{
+		"iss":            idp.server.URL,
+		"sub":            "external-123",
+		"aud":            clientID,
+		"exp":            time.Now().Add(time.Hour).Unix(),
+		"iat":            time.Now().Unix(),
+		"nonce":          req.nonce,
+		"email":          "gopher@example.com",
+		"email_verified": true,
+		"name":           "Gopher",
+	}
+	if idp.claims != nil {
+		idp.claims(claims)
+	}
+
+	json.NewEncoder(w).Encode(map[string]string{
+		"access_token": "access",
+		"token_type":   "Bearer",
+		"id_token":     idp.sign(claims),
+	})
+}
+
+func (idp *fakeIdP) jwks(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(map[string]any{
+		"keys": []map[string]string{
+			{
+				"kty": "RSA",
+				"kid": idp.kid,
+				"use": "sig",
+				"alg": "RS256",
+				"n":   base64.RawURLEncoding.EncodeToString(idp.key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(idp.key.E)).Bytes()),
+			},
+		},
+	})
+}
+
+func (idp *fakeIdP) sign(claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = idp.kid
+
+	signed, err := token.SignedString(idp.key)
+	if err != nil {
+		idp.t.Fatalf("Should be able to sign the id token: %s", err)
+	}
+	return signed
+}
+
+// login runs the browser part of the flow and returns the code and state
+// delivered to the redirect URL.
+func (idp *fakeIdP) login(t *testing.T, authURL string) (string, string) {
+	client := http.Client{
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	resp, err := client.Get(authURL)
+	if err != nil {
+		t.Fatalf("Should be able to reach the authorization endpoint: %s", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("Should be redirected back to the client: status %d", resp.StatusCode)
+	}
+
+	loc, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatalf("Should receive a valid redirect: %s", err)
+	}
+
+	return loc.Query().Get("code"), loc.Query().Get("state")
+}
+
+// =============================================================================
+
+func Test_OIDC(t *testing.T) {
+	t.Run("flow", flow)
+	t.Run("verifier", verifier)
+	t.Run("claims", claims)
+	t.Run("keys", keys)
+}
+
+func newProvider(idp *fakeIdP) *oidc.Provider {
+	return oidc.New(oidc.Config{
+		Issuer:       idp.server.URL,
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		RedirectURL:  redirectURL,
+	})
+}
+
+func start(t *testing.T, idp *fakeIdP, p *oidc.Provider) (code string, verifier string, nonce string) {
+	state, _ := oidc.NewRandom()
+	nonce, _ = oidc.NewRandom()
+	verifier, _ = oidc.NewRandom()
+
+	authURL, err := p.AuthCodeURL(context.Background(), state, nonce, verifier)
+	if err != nil {
+		t.Fatalf("Should be able to build the authorization URL: %s", err)
+	}
+
+	code, gotState := idp.login(t, authURL)
+	if gotState != state {
+		t.Fatalf("Should get the state back: got %q want %q", gotState, state)
+	}
+
+	return code, verifier, nonce
+}
+
+func flow(t *testing.T) {
+	idp := newFakeIdP(t)
+	p := newProvider(idp)
+	ctx := context.Background()
+
+	code, verifier, nonce := start(t, idp, p)
+
+	tokens, err := p.Exchange(ctx, code, verifier)
+	if err != nil {
+		t.Fatalf("Should be able to exchange the code: %s", err)
+	}
+
+	idt, err := p.Verify(ctx, tokens.IDToken, nonce)
+	if err != nil {
+		t.Fatalf("Should be able to verify the id token: %s", err)
+	}
+
+	if idt.Subject != "external-123" || idt.Issuer != idp.server.URL {
+		t.Errorf("Should get the subject and issuer: got %q %q", idt.Subject, idt.Issuer)
+	}
+
+	if idt.Email != "gopher@example.com" || !idt.EmailVerified || idt.Name != "Gopher" {
+		t.Errorf("Should get the profile claims: got %+v", idt)
+	}
+
+	if _, err := p.Exchange(ctx, code, verifier); err == nil {
+		t.Error("Should NOT be able to use a code twice")
+	}
+}
+
+func verifier(t *testing.T) {
+	idp := newFakeIdP(t)
+	p := newProvider(idp)
+
+	code, _, _ := start(t, idp, p)
+
+	other, _ := oidc.NewRandom()
+	if _, err := p.Exchange(context.Background(), code, other); err == nil {
+		t.Error("Should NOT be able to exchange a code with the wrong verifier")
+	}
+}
+
+func claims(t *testing.T) {
+	tests := []struct {
+		name  string
+		nonce string
+		edit  func(jwt.MapClaims)
+	}{
+		{name: "nonce", nonce: "wrong"},
+		{name: "audience", edit: func(c jwt.MapClaims) { c["aud"] = "another-client" }},
+		{name: "issuer", edit: func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" }},
+		{name: "expired", edit: func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Minute).Unix() }},
+		{name: "expiry", edit: func(c jwt.MapClaims) { delete(c, "exp") }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idp := newFakeIdP(t)
+			idp.claims = tt.edit
+			p := newProvider(idp)
+			ctx := context.Background()
+
+			code, verifier, nonce := start(t, idp, p)
+			if tt.nonce != "" {
+				nonce = tt.nonce
+			}
+
+			tokens, err := p.Exchange(ctx, code, verifier)
+			if err != nil {
+				t.Fatalf("Should be able to exchange the code: %s", err)
+			}
+
+			if _, err := p.Verify(ctx, tokens.IDToken, nonce); !errors.Is(err, oidc.ErrInvalidToken) {
+				t.Errorf("Should NOT accept the id token: %v", err)
+			}
+		})
+	}
+}
+
+func keys(t *testing.T) {
+	idp := newFakeIdP(t)
+	p := newProvider(idp)
+	ctx := context.Background()
+
+	code, verifier, nonce := start(t, idp, p)
+	tokens, err := p.Exchange(ctx, code, verifier)
+	if err != nil {
+		t.Fatalf("Should be able to exchange the code: %s", err)
+	}
+
+	// Sign with a key the provider hasn't published. The keys are fetched
+	// for the lookup and the key still isn't among them.
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Should be able to generate a key: %s", err)
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss": idp.server.URL, "sub": "x", "aud": clientID, "nonce": nonce,
+		"exp": time.Now().Add(time.Hour).Unix(),
+	})
+	token.Header["kid"] = "unknown"
+	forged, _ := token.SignedString(other)
+
+	if _, err := p.Verify(ctx, forged, nonce); !errors.Is(err, oidc.ErrInvalidToken) {
+		t.Errorf("Should NOT accept a token signed with an unknown key: %v", err)
+	}
+
+	if _, err := p.Verify(ctx, tokens.IDToken, nonce); err != nil {
+		t.Errorf("Should accept a token signed with a published key: %s", err)
+	}
+}
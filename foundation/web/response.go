@@ -10,7 +10,7 @@ import (
 func Respond(ctx context.Context, w http.ResponseWriter, data any, statusCode int) error {
 	SetStatusCode(ctx, statusCode)
 
-	if statusCode == http.StatusNoContent {
+	if statusCode == http.StatusNoContent || statusCode == http.StatusNotModified {
 		w.WriteHeader(statusCode)
 		return nil
 	}
@@ -0,0 +1,94 @@
+// Package worker provides support for running jobs on an interval in the
+// background of a service.
+package worker
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Job is the function that is executed on every tick of the worker.
+type Job func(ctx context.Context) error
+
+// Worker manages a set of jobs that run on their own interval until the
+// worker is shut down.
+type Worker struct {
+	log      *zap.SugaredLogger
+	wg       sync.WaitGroup
+	shutdown chan struct{}
+}
+
+// New constructs a Worker for running background jobs.
+func New(log *zap.SugaredLogger) *Worker {
+	return &Worker{
+		log:      log,
+		shutdown: make(chan struct{}),
+	}
+}
+
+// Start launches the job in its own goroutine. The job is executed once per
+// interval and is given the interval as its deadline.
+func (w *Worker) Start(name string, interval time.Duration, job Job) {
+	w.wg.Add(1)
+
+	go func() {
+		defer w.wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-w.shutdown:
+				return
+			case <-ticker.C:
+				w.run(name, interval, job)
+			}
+		}
+	}()
+}
+
+// Shutdown signals all jobs to stop and waits for any running job to finish
+// or for the context to expire.
+func (w *Worker) Shutdown(ctx context.Context) error {
+	close(w.shutdown)
+
+	done := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("waiting for jobs: %w", ctx.Err())
+	}
+}
+
+// run executes a single iteration of the job, recovering from any panic so
+// one bad run doesn't take down the service.
+func (w *Worker) run(name string, timeout time.Duration, job Job) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	defer func() {
+		if rec := recover(); rec != nil {
+			w.log.Errorw("worker", "job", name, "status", "panic", "ERROR", rec)
+		}
+	}()
+
+	start := time.Now()
+
+	if err := job(ctx); err != nil {
+		w.log.Errorw("worker", "job", name, "status", "failed", "ERROR", err)
+		return
+	}
+
+	w.log.Infow("worker", "job", name, "status", "completed", "since", time.Since(start).String())
+}